@@ -0,0 +1,289 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"math"
+	"sync"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/flexfec"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+const (
+	adaptiveFECDefaultNumMediaPackets = 5
+	adaptiveFECDefaultLossMultiplier  = 2
+	adaptiveFECDefaultMaxOverhead     = 0.5
+	adaptiveFECDefaultLossSmoothing   = 0.3
+)
+
+// AdaptiveFECPolicy holds the knobs used by an AdaptiveFECController to decide how
+// many FEC packets are generated for every batch of media packets.
+// Zero values are replaced by sane defaults.
+type AdaptiveFECPolicy struct {
+	// NumMediaPackets is the number of media packets protected by one batch of FEC packets.
+	NumMediaPackets uint32
+
+	// MinFECPackets and MaxFECPackets bound the number of FEC packets generated per batch.
+	// MaxFECPackets defaults to NumMediaPackets.
+	MinFECPackets, MaxFECPackets uint32
+
+	// LossMultiplier scales the observed loss rate into a protection overhead. With the
+	// default of 2, 5% of loss results in 10% of FEC overhead.
+	LossMultiplier float64
+
+	// MaxOverhead is the upper bound of the FEC overhead as a fraction
+	// of the media bitrate regardless of the observed loss.
+	MaxOverhead float64
+
+	// LossSmoothing is the weight given to every new loss sample in (0, 1].
+	LossSmoothing float64
+}
+
+func (p AdaptiveFECPolicy) withDefaults() AdaptiveFECPolicy {
+	if p.NumMediaPackets == 0 {
+		p.NumMediaPackets = adaptiveFECDefaultNumMediaPackets
+	}
+	if p.MaxFECPackets == 0 || p.MaxFECPackets > p.NumMediaPackets {
+		p.MaxFECPackets = p.NumMediaPackets
+	}
+	if p.LossMultiplier <= 0 {
+		p.LossMultiplier = adaptiveFECDefaultLossMultiplier
+	}
+	if p.MaxOverhead <= 0 {
+		p.MaxOverhead = adaptiveFECDefaultMaxOverhead
+	}
+	if p.LossSmoothing <= 0 || p.LossSmoothing > 1 {
+		p.LossSmoothing = adaptiveFECDefaultLossSmoothing
+	}
+
+	return p
+}
+
+// AdaptiveFECControllerFactory creates an AdaptiveFECController
+// and its FlexFEC encoder for every PeerConnection.
+type AdaptiveFECControllerFactory struct {
+	policy AdaptiveFECPolicy
+
+	mu                  sync.Mutex
+	onNewPeerConnection func(id string, controller *AdaptiveFECController)
+}
+
+// NewAdaptiveFECControllerFactory returns a new AdaptiveFECControllerFactory using the given policy.
+func NewAdaptiveFECControllerFactory(policy AdaptiveFECPolicy) (*AdaptiveFECControllerFactory, error) {
+	policy = policy.withDefaults()
+	if policy.MinFECPackets > policy.MaxFECPackets {
+		return nil, errAdaptiveFECInvalidPolicy
+	}
+
+	return &AdaptiveFECControllerFactory{policy: policy}, nil
+}
+
+// OnNewPeerConnection sets a handler that is fired with the AdaptiveFECController of every new
+// PeerConnection. Use it to feed bitrate information from congestion control to the controller.
+func (f *AdaptiveFECControllerFactory) OnNewPeerConnection(cb func(id string, controller *AdaptiveFECController)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onNewPeerConnection = cb
+}
+
+// NewInterceptor constructs a new AdaptiveFECController chained with a FlexFEC encoder.
+func (f *AdaptiveFECControllerFactory) NewInterceptor(id string) (interceptor.Interceptor, error) {
+	controller := newAdaptiveFECController(f.policy)
+
+	fecFactory, err := flexfec.NewFecInterceptor(
+		flexfec.NumMediaPackets(f.policy.NumMediaPackets),
+		flexfec.NumFECPackets(f.policy.MaxFECPackets),
+		flexfec.FECEncoderFactory(&adaptiveFECEncoderFactory{
+			controller: controller,
+			factory:    flexfec.FlexEncoder03Factory{},
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	fec, err := fecFactory.NewInterceptor(id)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	onNewPeerConnection := f.onNewPeerConnection
+	f.mu.Unlock()
+	if onNewPeerConnection != nil {
+		onNewPeerConnection(id, controller)
+	}
+
+	return interceptor.NewChain([]interceptor.Interceptor{controller, fec}), nil
+}
+
+// AdaptiveFECController adjusts the FEC protection overhead of a PeerConnection. The loss
+// rate is learned from the RTCP reports sent by the remote, and the overhead is capped by the
+// headroom reported via UpdateBitrates.
+type AdaptiveFECController struct {
+	interceptor.NoOp
+
+	policy AdaptiveFECPolicy
+
+	mu            sync.RWMutex
+	localSSRCs    map[uint32]struct{}
+	lossRate      float64
+	headroom      float64
+	hasHeadroom   bool
+	numFECPackets uint32
+}
+
+func newAdaptiveFECController(policy AdaptiveFECPolicy) *AdaptiveFECController {
+	return &AdaptiveFECController{
+		policy:        policy,
+		localSSRCs:    map[uint32]struct{}{},
+		numFECPackets: policy.MinFECPackets,
+	}
+}
+
+// FECPackets returns the number of FEC packets currently generated per batch of media packets.
+func (c *AdaptiveFECController) FECPackets() uint32 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.numFECPackets
+}
+
+// LossRate returns the smoothed loss rate reported by the remote in [0, 1].
+func (c *AdaptiveFECController) LossRate() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.lossRate
+}
+
+// UpdateBitrates informs the controller about the target bitrate allocated by congestion
+// control and the bitrate currently used by media, both in bits per second. The difference
+// is the headroom available for FEC.
+func (c *AdaptiveFECController) UpdateBitrates(targetBitrate, mediaBitrate int) {
+	if mediaBitrate <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.headroom = math.Max(0, float64(targetBitrate-mediaBitrate)/float64(mediaBitrate))
+	c.hasHeadroom = true
+	c.updateFECPackets()
+}
+
+// BindLocalStream records the SSRC of the stream so its loss reports can be tracked.
+func (c *AdaptiveFECController) BindLocalStream(
+	info *interceptor.StreamInfo, writer interceptor.RTPWriter,
+) interceptor.RTPWriter {
+	c.mu.Lock()
+	c.localSSRCs[info.SSRC] = struct{}{}
+	c.mu.Unlock()
+
+	return writer
+}
+
+// UnbindLocalStream stops tracking the loss reports of the stream.
+func (c *AdaptiveFECController) UnbindLocalStream(info *interceptor.StreamInfo) {
+	c.mu.Lock()
+	delete(c.localSSRCs, info.SSRC)
+	c.mu.Unlock()
+}
+
+// BindRTCPReader inspects incoming reports for the loss experienced by the remote.
+func (c *AdaptiveFECController) BindRTCPReader(reader interceptor.RTCPReader) interceptor.RTCPReader {
+	return interceptor.RTCPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		i, attr, err := reader.Read(b, a)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if attr == nil {
+			attr = make(interceptor.Attributes)
+		}
+		pkts, err := attr.GetRTCPPackets(b[:i])
+		if err != nil {
+			return 0, nil, err
+		}
+
+		for _, pkt := range pkts {
+			switch report := pkt.(type) {
+			case *rtcp.ReceiverReport:
+				c.handleReceptionReports(report.Reports)
+			case *rtcp.SenderReport:
+				c.handleReceptionReports(report.Reports)
+			}
+		}
+
+		return i, attr, nil
+	})
+}
+
+func (c *AdaptiveFECController) handleReceptionReports(reports []rtcp.ReceptionReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, report := range reports {
+		if _, ok := c.localSSRCs[report.SSRC]; !ok {
+			continue
+		}
+
+		sample := float64(report.FractionLost) / 256
+		c.lossRate += c.policy.LossSmoothing * (sample - c.lossRate)
+	}
+
+	c.updateFECPackets()
+}
+
+// updateFECPackets must be called with c.mu held.
+func (c *AdaptiveFECController) updateFECPackets() {
+	overhead := math.Min(c.lossRate*c.policy.LossMultiplier, c.policy.MaxOverhead)
+	if c.hasHeadroom {
+		overhead = math.Min(overhead, c.headroom)
+	}
+
+	numFECPackets := uint32(math.Ceil(overhead * float64(c.policy.NumMediaPackets)))
+	if numFECPackets < c.policy.MinFECPackets {
+		numFECPackets = c.policy.MinFECPackets
+	} else if numFECPackets > c.policy.MaxFECPackets {
+		numFECPackets = c.policy.MaxFECPackets
+	}
+
+	c.numFECPackets = numFECPackets
+}
+
+type adaptiveFECEncoderFactory struct {
+	controller *AdaptiveFECController
+	factory    flexfec.EncoderFactory
+}
+
+func (f *adaptiveFECEncoderFactory) NewEncoder(payloadType uint8, ssrc uint32) flexfec.FlexEncoder {
+	return &adaptiveFECEncoder{
+		controller: f.controller,
+		encoder:    f.factory.NewEncoder(payloadType, ssrc),
+	}
+}
+
+// adaptiveFECEncoder ignores the static number of FEC packets
+// and asks the controller instead.
+type adaptiveFECEncoder struct {
+	controller *AdaptiveFECController
+	encoder    flexfec.FlexEncoder
+}
+
+func (e *adaptiveFECEncoder) EncodeFec(mediaPackets []rtp.Packet, _ uint32) []rtp.Packet {
+	numFECPackets := e.controller.FECPackets()
+	if numFECPackets == 0 {
+		return nil
+	}
+
+	return e.encoder.EncodeFec(mediaPackets, numFECPackets)
+}
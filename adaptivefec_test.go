@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/flexfec"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAdaptiveFECTestController(t *testing.T, policy AdaptiveFECPolicy) *AdaptiveFECController {
+	t.Helper()
+
+	factory, err := NewAdaptiveFECControllerFactory(policy)
+	assert.NoError(t, err)
+
+	var controller *AdaptiveFECController
+	factory.OnNewPeerConnection(func(_ string, c *AdaptiveFECController) {
+		controller = c
+	})
+
+	_, err = factory.NewInterceptor("")
+	assert.NoError(t, err)
+	assert.NotNil(t, controller)
+
+	return controller
+}
+
+func feedReceiverReport(t *testing.T, controller *AdaptiveFECController, ssrc uint32, fractionLost uint8) {
+	t.Helper()
+
+	raw, err := rtcp.Marshal([]rtcp.Packet{&rtcp.ReceiverReport{
+		Reports: []rtcp.ReceptionReport{{SSRC: ssrc, FractionLost: fractionLost}},
+	}})
+	assert.NoError(t, err)
+
+	reader := controller.BindRTCPReader(interceptor.RTCPReaderFunc(
+		func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+			return copy(b, raw), a, nil
+		},
+	))
+
+	_, _, err = reader.Read(make([]byte, 1500), nil)
+	assert.NoError(t, err)
+}
+
+func TestAdaptiveFECController(t *testing.T) {
+	t.Run("InvalidPolicy", func(t *testing.T) {
+		_, err := NewAdaptiveFECControllerFactory(AdaptiveFECPolicy{MinFECPackets: 3, MaxFECPackets: 2})
+		assert.ErrorIs(t, err, errAdaptiveFECInvalidPolicy)
+	})
+
+	t.Run("FollowsLoss", func(t *testing.T) {
+		controller := newAdaptiveFECTestController(t, AdaptiveFECPolicy{
+			NumMediaPackets: 10,
+			LossSmoothing:   1,
+		})
+		controller.BindLocalStream(&interceptor.StreamInfo{SSRC: 1234}, nil)
+		assert.Equal(t, uint32(0), controller.FECPackets())
+
+		// Reports for unknown streams are ignored
+		feedReceiverReport(t, controller, 4321, 128)
+		assert.Equal(t, uint32(0), controller.FECPackets())
+
+		// 10% of loss results in 20% of overhead
+		feedReceiverReport(t, controller, 1234, 26)
+		assert.InDelta(t, 0.1, controller.LossRate(), 0.01)
+		assert.Equal(t, uint32(3), controller.FECPackets())
+
+		// Overhead is capped by MaxOverhead
+		feedReceiverReport(t, controller, 1234, 200)
+		assert.Equal(t, uint32(5), controller.FECPackets())
+
+		// and by the headroom left by congestion control
+		controller.UpdateBitrates(1_100_000, 1_000_000)
+		assert.Equal(t, uint32(1), controller.FECPackets())
+
+		feedReceiverReport(t, controller, 1234, 0)
+		assert.Equal(t, uint32(0), controller.FECPackets())
+	})
+
+	t.Run("MinFECPackets", func(t *testing.T) {
+		controller := newAdaptiveFECTestController(t, AdaptiveFECPolicy{MinFECPackets: 1})
+		assert.Equal(t, uint32(1), controller.FECPackets())
+
+		controller.UpdateBitrates(500_000, 1_000_000)
+		assert.Equal(t, uint32(1), controller.FECPackets())
+	})
+
+	t.Run("Encoder", func(t *testing.T) {
+		controller := newAdaptiveFECTestController(t, AdaptiveFECPolicy{NumMediaPackets: 2, LossSmoothing: 1})
+		controller.BindLocalStream(&interceptor.StreamInfo{SSRC: 1234}, nil)
+
+		factory := &adaptiveFECEncoderFactory{controller: controller, factory: &adaptiveFECTestEncoderFactory{}}
+		encoder := factory.NewEncoder(100, 5678)
+		mediaPackets := []rtp.Packet{{}, {}}
+
+		assert.Empty(t, encoder.EncodeFec(mediaPackets, 2))
+
+		feedReceiverReport(t, controller, 1234, 64)
+		assert.Len(t, encoder.EncodeFec(mediaPackets, 2), 1)
+	})
+}
+
+type adaptiveFECTestEncoderFactory struct{}
+
+func (adaptiveFECTestEncoderFactory) NewEncoder(uint8, uint32) flexfec.FlexEncoder {
+	return adaptiveFECTestEncoder{}
+}
+
+type adaptiveFECTestEncoder struct{}
+
+func (adaptiveFECTestEncoder) EncodeFec(_ []rtp.Packet, numFECPackets uint32) []rtp.Packet {
+	return make([]rtp.Packet, numFECPackets)
+}
@@ -0,0 +1,12 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+// AddTrackOptions are the options of PeerConnection.AddTrack.
+type AddTrackOptions struct {
+	// StreamIDs are the IDs of the MediaStreams the track is associated with, the
+	// media section of the track has an msid per stream, as with addTrack(track, ...streams)
+	// in the browser. The stream ID of the track is used if empty.
+	StreamIDs []string
+}
@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/logging"
+	"github.com/pion/sdp/v3"
+)
+
+// OfferSummary describes a remote offer to the admission handler of a SettingEngine,
+// see SettingEngine.SetOfferAdmissionHandler.
+type OfferSummary struct {
+	// Renegotiation is true when the PeerConnection already negotiated a remote description.
+	Renegotiation bool
+	// Audio and Video are the numbers of media sections of each kind.
+	Audio, Video int
+	// DataChannel is true when the offer negotiates an SCTP transport for DataChannels.
+	DataChannel bool
+	// ICELite is true when the remote is an ICE lite agent.
+	ICELite bool
+	// Candidates are the remote candidates of the offer, their addresses tell where the
+	// offer comes from.
+	Candidates []ICECandidate
+	// SDP is the parsed offer, it must not be modified.
+	SDP *sdp.SessionDescription
+}
+
+func summarizeOffer(desc *sdp.SessionDescription, renegotiation bool, log logging.LeveledLogger) (OfferSummary, error) {
+	iceDetails, err := extractICEDetails(desc, log)
+	if err != nil {
+		return OfferSummary{}, err
+	}
+
+	summary := OfferSummary{
+		Renegotiation: renegotiation,
+		ICELite:       isIceLiteSet(desc),
+		Candidates:    iceDetails.Candidates,
+		SDP:           desc,
+	}
+	for _, media := range desc.MediaDescriptions {
+		switch media.MediaName.Media {
+		case RTPCodecTypeAudio.String():
+			summary.Audio++
+		case RTPCodecTypeVideo.String():
+			summary.Video++
+		case mediaSectionApplication:
+			summary.DataChannel = true
+		}
+	}
+
+	return summary, nil
+}
+
+// AdmissionRejectReason is why the admission handler rejected an offer.
+type AdmissionRejectReason int
+
+const (
+	// AdmissionRejectReasonUnspecified is used when the handler returned an error other
+	// than an AdmissionError.
+	AdmissionRejectReasonUnspecified AdmissionRejectReason = iota
+	// AdmissionRejectReasonQuota means the service reached a limit, like its number of
+	// sessions or of media streams.
+	AdmissionRejectReasonQuota
+	// AdmissionRejectReasonBlocked means the remote isn't allowed to connect.
+	AdmissionRejectReasonBlocked
+	// AdmissionRejectReasonUnsupported means the offer asks for something the service
+	// doesn't provide.
+	AdmissionRejectReasonUnsupported
+)
+
+func (r AdmissionRejectReason) String() string {
+	switch r {
+	case AdmissionRejectReasonQuota:
+		return "quota"
+	case AdmissionRejectReasonBlocked:
+		return "blocked"
+	case AdmissionRejectReasonUnsupported:
+		return "unsupported"
+	default:
+		return "unspecified"
+	}
+}
+
+// AdmissionError is returned by SetRemoteDescription when the admission handler
+// rejected the offer. Handlers return it to give the reason of the rejection.
+type AdmissionError struct {
+	Reason AdmissionRejectReason
+	Err    error
+}
+
+func (e *AdmissionError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("offer rejected: %s", e.Reason)
+	}
+
+	return fmt.Sprintf("offer rejected: %s: %v", e.Reason, e.Err)
+}
+
+func (e *AdmissionError) Unwrap() error {
+	return e.Err
+}
+
+// admitOffer runs the admission handler of the SettingEngine for a remote offer.
+func (pc *PeerConnection) admitOffer(desc *sdp.SessionDescription) error {
+	handler := pc.api.settingEngine.offerAdmissionHandler
+	if handler == nil {
+		return nil
+	}
+
+	summary, err := summarizeOffer(desc, pc.CurrentRemoteDescription() != nil, pc.log)
+	if err != nil {
+		return err
+	}
+
+	err = handler(pc, summary)
+	if err == nil {
+		return nil
+	}
+
+	var admissionErr *AdmissionError
+	if !errors.As(err, &admissionErr) {
+		admissionErr = &AdmissionError{Reason: AdmissionRejectReasonUnspecified, Err: err}
+	}
+	pc.log.Infof("Rejected remote offer: %v", admissionErr)
+
+	return admissionErr
+}
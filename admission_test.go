@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettingEngine_SetOfferAdmissionHandler(t *testing.T) {
+	errQuotaReached := errors.New("too many sessions")
+
+	var summaries []OfferSummary
+	admit := func(handler func(OfferSummary) error) *PeerConnection {
+		settingEngine := SettingEngine{}
+		settingEngine.SetOfferAdmissionHandler(func(_ *PeerConnection, offer OfferSummary) error {
+			summaries = append(summaries, offer)
+
+			return handler(offer)
+		})
+
+		pc, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		return pc
+	}
+
+	pcOffer, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	require.NoError(t, err)
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	require.NoError(t, err)
+	_, err = pcOffer.CreateDataChannel("admission", nil)
+	require.NoError(t, err)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	require.NoError(t, err)
+	gatheringComplete := GatheringCompletePromise(pcOffer)
+	require.NoError(t, pcOffer.SetLocalDescription(offer))
+	<-gatheringComplete
+	offer = *pcOffer.LocalDescription()
+
+	t.Run("Admitted", func(t *testing.T) {
+		pc := admit(func(OfferSummary) error { return nil })
+		require.NoError(t, pc.SetRemoteDescription(offer))
+
+		summary := summaries[len(summaries)-1]
+		assert.False(t, summary.Renegotiation)
+		assert.Equal(t, 1, summary.Audio)
+		assert.Equal(t, 1, summary.Video)
+		assert.True(t, summary.DataChannel)
+		assert.False(t, summary.ICELite)
+		assert.NotEmpty(t, summary.Candidates)
+		assert.NotNil(t, summary.SDP)
+		assert.NoError(t, pc.Close())
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		pc := admit(func(offer OfferSummary) error {
+			if offer.Video > 0 {
+				return &AdmissionError{Reason: AdmissionRejectReasonUnsupported}
+			}
+
+			return nil
+		})
+
+		err := pc.SetRemoteDescription(offer)
+		var admissionErr *AdmissionError
+		require.ErrorAs(t, err, &admissionErr)
+		assert.Equal(t, AdmissionRejectReasonUnsupported, admissionErr.Reason)
+		assert.Equal(t, SignalingStateStable, pc.SignalingState())
+		assert.Nil(t, pc.RemoteDescription())
+		assert.NoError(t, pc.Close())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		pc := admit(func(OfferSummary) error { return errQuotaReached })
+
+		err := pc.SetRemoteDescription(offer)
+		var admissionErr *AdmissionError
+		require.ErrorAs(t, err, &admissionErr)
+		assert.Equal(t, AdmissionRejectReasonUnspecified, admissionErr.Reason)
+		assert.ErrorIs(t, err, errQuotaReached)
+		assert.NoError(t, pc.Close())
+	})
+
+	assert.NoError(t, pcOffer.Close())
+}
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+// AnswerDirectionPolicy constrains the directions a PeerConnection answers with, per kind
+// of media. A direction is only narrowed, e.g. a Video policy of RTPTransceiverDirectionRecvonly
+// answers a sendrecv video offer with recvonly, even if a track was added to the transceiver,
+// and a recvonly offer with inactive, as nothing is sent. A sendonly offer is still answered
+// with recvonly. Only the answer is narrowed, the direction of the transceivers is left as
+// set by the application, so it applies again to the next offer. The zero value doesn't
+// constrain the direction.
+type AnswerDirectionPolicy struct {
+	Audio RTPTransceiverDirection
+	Video RTPTransceiverDirection
+}
+
+// constrain returns the direction allowed by the policy for a transceiver of kind.
+func (p AnswerDirectionPolicy) constrain(kind RTPCodecType, direction RTPTransceiverDirection) RTPTransceiverDirection {
+	allowed := RTPTransceiverDirectionUnknown
+	switch kind {
+	case RTPCodecTypeAudio:
+		allowed = p.Audio
+	case RTPCodecTypeVideo:
+		allowed = p.Video
+	default:
+	}
+
+	if allowed == RTPTransceiverDirectionUnknown || allowed == RTPTransceiverDirectionSendrecv {
+		return direction
+	}
+
+	send := hasSendDirection(direction) && hasSendDirection(allowed)
+	recv := hasRecvDirection(direction) && hasRecvDirection(allowed)
+	switch {
+	case send && recv:
+		return RTPTransceiverDirectionSendrecv
+	case send:
+		return RTPTransceiverDirectionSendonly
+	case recv:
+		return RTPTransceiverDirectionRecvonly
+	default:
+		return RTPTransceiverDirectionInactive
+	}
+}
+
+func hasSendDirection(direction RTPTransceiverDirection) bool {
+	return direction == RTPTransceiverDirectionSendrecv || direction == RTPTransceiverDirectionSendonly
+}
+
+func hasRecvDirection(direction RTPTransceiverDirection) bool {
+	return direction == RTPTransceiverDirectionSendrecv || direction == RTPTransceiverDirectionRecvonly
+}
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnswerDirectionPolicy_Constrain(t *testing.T) {
+	policy := AnswerDirectionPolicy{Video: RTPTransceiverDirectionRecvonly, Audio: RTPTransceiverDirectionInactive}
+
+	for _, test := range []struct {
+		kind                RTPCodecType
+		direction, expected RTPTransceiverDirection
+	}{
+		{RTPCodecTypeVideo, RTPTransceiverDirectionSendrecv, RTPTransceiverDirectionRecvonly},
+		{RTPCodecTypeVideo, RTPTransceiverDirectionSendonly, RTPTransceiverDirectionInactive},
+		{RTPCodecTypeVideo, RTPTransceiverDirectionRecvonly, RTPTransceiverDirectionRecvonly},
+		{RTPCodecTypeVideo, RTPTransceiverDirectionInactive, RTPTransceiverDirectionInactive},
+		{RTPCodecTypeAudio, RTPTransceiverDirectionSendrecv, RTPTransceiverDirectionInactive},
+	} {
+		assert.Equal(t, test.expected, policy.constrain(test.kind, test.direction))
+	}
+
+	assert.Equal(
+		t,
+		RTPTransceiverDirectionSendrecv,
+		AnswerDirectionPolicy{}.constrain(RTPCodecTypeVideo, RTPTransceiverDirectionSendrecv),
+	)
+}
+
+func TestPeerConnection_AnswerDirectionPolicy(t *testing.T) {
+	settingEngine := SettingEngine{}
+	settingEngine.SetAnswerDirectionPolicy(AnswerDirectionPolicy{Video: RTPTransceiverDirectionRecvonly})
+	api := NewAPI(WithSettingEngine(settingEngine))
+
+	answerDirections := func(override *AnswerDirectionPolicy) map[string]string {
+		offerer, err := NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		answerer, err := api.NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		if override != nil {
+			answerer.SetAnswerDirectionPolicy(*override)
+		}
+
+		for _, pc := range []*PeerConnection{offerer, answerer} {
+			for _, kind := range []RTPCodecType{RTPCodecTypeAudio, RTPCodecTypeVideo} {
+				_, err = pc.AddTransceiverFromKind(kind)
+				require.NoError(t, err)
+			}
+		}
+
+		offer, err := offerer.CreateOffer(nil)
+		require.NoError(t, err)
+		require.NoError(t, answerer.SetRemoteDescription(offer))
+		answer, err := answerer.CreateAnswer(nil)
+		require.NoError(t, err)
+
+		directions := map[string]string{}
+		for _, media := range answer.parsed.MediaDescriptions {
+			directions[media.MediaName.Media] = getPeerDirection(media).String()
+		}
+		// Only the answer is narrowed
+		for _, transceiver := range answerer.GetTransceivers() {
+			assert.Equal(t, RTPTransceiverDirectionSendrecv, transceiver.Direction())
+		}
+
+		assert.NoError(t, offerer.Close())
+		assert.NoError(t, answerer.Close())
+
+		return directions
+	}
+
+	assert.Equal(t, map[string]string{"audio": "sendrecv", "video": "recvonly"}, answerDirections(nil))
+	assert.Equal(
+		t,
+		map[string]string{"audio": "sendrecv", "video": "sendrecv"},
+		answerDirections(&AnswerDirectionPolicy{}),
+	)
+	assert.Equal(
+		t,
+		map[string]string{"audio": "inactive", "video": "sendrecv"},
+		answerDirections(&AnswerDirectionPolicy{Audio: RTPTransceiverDirectionInactive}),
+	)
+}
+
+func TestPeerConnection_AnswerDirectionPolicy_OfferDirections(t *testing.T) {
+	settingEngine := SettingEngine{}
+	settingEngine.SetAnswerDirectionPolicy(AnswerDirectionPolicy{Video: RTPTransceiverDirectionRecvonly})
+	api := NewAPI(WithSettingEngine(settingEngine))
+
+	for offered, answered := range map[RTPTransceiverDirection]RTPTransceiverDirection{
+		RTPTransceiverDirectionSendonly: RTPTransceiverDirectionRecvonly,
+		RTPTransceiverDirectionRecvonly: RTPTransceiverDirectionInactive,
+	} {
+		offerer, err := NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		answerer, err := api.NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		offerTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "offer")
+		require.NoError(t, err)
+		if offered == RTPTransceiverDirectionSendonly {
+			_, err = offerer.AddTransceiverFromTrack(offerTrack, RTPTransceiverInit{Direction: offered})
+		} else {
+			_, err = offerer.AddTransceiverFromKind(RTPCodecTypeVideo, RTPTransceiverInit{Direction: offered})
+		}
+		require.NoError(t, err)
+		answerTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "answer")
+		require.NoError(t, err)
+		_, err = answerer.AddTrack(answerTrack)
+		require.NoError(t, err)
+
+		offer, err := offerer.CreateOffer(nil)
+		require.NoError(t, err)
+		require.NoError(t, answerer.SetRemoteDescription(offer))
+		answer, err := answerer.CreateAnswer(nil)
+		require.NoError(t, err)
+		require.Len(t, answer.parsed.MediaDescriptions, 1)
+		assert.Equal(t, answered, getPeerDirection(answer.parsed.MediaDescriptions[0]), offered.String())
+
+		assert.NoError(t, offerer.Close())
+		assert.NoError(t, answerer.Close())
+	}
+}
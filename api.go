@@ -7,6 +7,8 @@
 package webrtc
 
 import (
+	"sync/atomic"
+
 	"github.com/pion/interceptor"
 	"github.com/pion/logging"
 )
@@ -22,6 +24,21 @@ type API struct {
 	interceptorRegistry *interceptor.Registry
 
 	interceptor interceptor.Interceptor // Generated per PeerConnection
+
+	// Shared by the PeerConnections to fire their event handlers, see SettingEngine.SetHandlerWorkers
+	workerPool *workerPool
+
+	// Filters the ICEServers of the PeerConnections, see SettingEngine.SetICEServerProbe
+	iceServerProbe *iceServerProbe
+
+	// Tracks the PeerConnections once a DebugHandler is created
+	debugSessions atomic.Pointer[debugSessions]
+
+	// Shared by the mDNS resolvers of the PeerConnections, see SettingEngine.SetMulticastDNSCacheTTL
+	mDNSCache *mDNSCache
+
+	// Adds the ID of the PeerConnection to the lines of its loggers, see AttrsLoggerFactory
+	connectionLoggerFactory logging.LoggerFactory
 }
 
 // NewAPI Creates a new API object for keeping semi-global settings to WebRTC objects
@@ -32,6 +49,7 @@ func NewAPI(options ...func(*API)) *API {
 	api := &API{
 		interceptor:   &interceptor.NoOp{},
 		settingEngine: &SettingEngine{},
+		mDNSCache:     &mDNSCache{},
 	}
 
 	for _, o := range options {
@@ -60,9 +78,35 @@ func NewAPI(options ...func(*API)) *API {
 		}
 	}
 
+	if api.settingEngine.handlerWorkers > 0 {
+		api.workerPool = newWorkerPool(api.settingEngine.handlerWorkers)
+	}
+
+	if servers := api.settingEngine.iceServerProbe.servers; len(servers) > 0 {
+		timeout := api.settingEngine.iceServerProbe.timeout
+		if timeout <= 0 {
+			timeout = defaultICEServerProbeTimeout
+		}
+		interval := api.settingEngine.iceServerProbe.interval
+		if interval <= 0 {
+			interval = defaultICEServerProbeInterval
+		}
+		api.iceServerProbe = newICEServerProbe(api.settingEngine.net, servers, timeout, interval, logger)
+	}
+
 	return api
 }
 
+// loggerFactory returns the LoggerFactory of the objects created by api, the one of its
+// PeerConnection if it has one.
+func (api *API) loggerFactory() logging.LoggerFactory {
+	if api.connectionLoggerFactory != nil {
+		return api.connectionLoggerFactory
+	}
+
+	return api.settingEngine.LoggerFactory
+}
+
 // WithMediaEngine allows providing a MediaEngine to the API.
 // Settings can be changed after passing the engine to an API.
 // When a PeerConnection is created the MediaEngine is copied
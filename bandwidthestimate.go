@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"time"
+)
+
+// BandwidthEstimate is an estimate of the send side congestion controller, see
+// PeerConnection.OnBandwidthEstimate.
+type BandwidthEstimate struct {
+	// TargetBitrate is the bitrate in bits per second the PeerConnection should send
+	// at, it is split between the RTPSenders, see RTPSender.OnTargetBitrateChange.
+	TargetBitrate int
+
+	// LossTargetBitrate and DelayTargetBitrate are the bitrates estimated from the
+	// losses and from the delays of the TWCC feedback, TargetBitrate is the lowest.
+	LossTargetBitrate  int
+	DelayTargetBitrate int
+
+	// AverageLoss is the fraction of the packets reported lost, between 0 and 1.
+	AverageLoss float64
+
+	// DelayEstimate is the estimated variation of the delay between groups of packets,
+	// and Usage tells if it is detected as an "overuse", an "underuse" or is "normal".
+	DelayEstimate time.Duration
+	Usage         string
+}
+
+// newBandwidthEstimate returns the estimate with the stats of a GCC bandwidth
+// estimator, the stats other estimators don't report are left to 0.
+func newBandwidthEstimate(targetBitrate int, estimatorStats map[string]any) BandwidthEstimate {
+	estimate := BandwidthEstimate{TargetBitrate: targetBitrate}
+	estimate.LossTargetBitrate, _ = estimatorStats["lossTargetBitrate"].(int)
+	estimate.DelayTargetBitrate, _ = estimatorStats["delayTargetBitrate"].(int)
+	estimate.AverageLoss, _ = estimatorStats["averageLoss"].(float64)
+	estimate.Usage, _ = estimatorStats["usage"].(string)
+	if delayEstimate, ok := estimatorStats["delayEstimate"].(float64); ok {
+		estimate.DelayEstimate = time.Duration(delayEstimate * float64(time.Millisecond))
+	}
+
+	return estimate
+}
+
+// OnBandwidthEstimate sets an event handler which is called when the congestion
+// controller changes its estimate of the available bandwidth. Congestion control
+// must be enabled via ConfigureCongestionController.
+func (pc *PeerConnection) OnBandwidthEstimate(f func(BandwidthEstimate)) {
+	pc.onBandwidthEstimateHandler.Store(f)
+}
+
+func (pc *PeerConnection) onBandwidthEstimate(targetBitrate int) {
+	estimate := newBandwidthEstimate(targetBitrate, pc.bandwidthEstimator.GetStats())
+	pc.events.emit(BandwidthEstimateEvent{BandwidthEstimate: estimate})
+	if handler, ok := pc.onBandwidthEstimateHandler.Load().(func(BandwidthEstimate)); ok && handler != nil {
+		handler(estimate)
+	}
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBandwidthEstimate(t *testing.T) {
+	assert.Equal(t, BandwidthEstimate{
+		TargetBitrate:      500_000,
+		LossTargetBitrate:  800_000,
+		DelayTargetBitrate: 500_000,
+		AverageLoss:        0.02,
+		DelayEstimate:      1500 * time.Microsecond,
+		Usage:              "overuse",
+	}, newBandwidthEstimate(500_000, map[string]any{
+		"lossTargetBitrate":  800_000,
+		"averageLoss":        0.02,
+		"delayTargetBitrate": 500_000,
+		"delayEstimate":      1.5,
+		"usage":              "overuse",
+		"state":              "decrease",
+	}))
+
+	assert.Equal(t, BandwidthEstimate{TargetBitrate: 300_000}, newBandwidthEstimate(300_000, map[string]any{}))
+}
+
+func TestPeerConnection_OnBandwidthEstimate(t *testing.T) {
+	mediaEngine := &MediaEngine{}
+	require.NoError(t, mediaEngine.RegisterDefaultCodecs())
+
+	interceptorRegistry := &interceptor.Registry{}
+	require.NoError(t, ConfigureCongestionController(mediaEngine, interceptorRegistry))
+
+	pc, err := NewAPI(
+		WithMediaEngine(mediaEngine),
+		WithInterceptorRegistry(interceptorRegistry),
+	).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	events := pc.Events()
+	var estimates []BandwidthEstimate
+	pc.OnBandwidthEstimate(func(estimate BandwidthEstimate) {
+		estimates = append(estimates, estimate)
+	})
+
+	pc.onTargetBitrateChange(750_000)
+
+	if assert.Len(t, estimates, 1) {
+		assert.Equal(t, 750_000, estimates[0].TargetBitrate)
+	}
+	event, ok := (<-events.C()).(BandwidthEstimateEvent)
+	if assert.True(t, ok) {
+		assert.Equal(t, estimates[0], event.BandwidthEstimate)
+	}
+
+	assert.NoError(t, pc.Close())
+}
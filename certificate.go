@@ -107,11 +107,13 @@ func (c Certificate) Expires() time.Time {
 // GetFingerprints returns the list of certificate fingerprints, one of which
 // is computed with the digest algorithm used in the certificate signature.
 func (c Certificate) GetFingerprints() ([]DTLSFingerprint, error) {
-	fingerprintAlgorithms := []crypto.Hash{crypto.SHA256}
-	res := make([]DTLSFingerprint, len(fingerprintAlgorithms))
+	return c.getFingerprints([]crypto.Hash{crypto.SHA256})
+}
 
-	i := 0
-	for _, algo := range fingerprintAlgorithms {
+// getFingerprints returns the fingerprints of the certificate computed with algorithms, in order.
+func (c Certificate) getFingerprints(algorithms []crypto.Hash) ([]DTLSFingerprint, error) {
+	res := make([]DTLSFingerprint, 0, len(algorithms))
+	for _, algo := range algorithms {
 		name, err := fingerprint.StringFromHash(algo)
 		if err != nil {
 			// nolint
@@ -122,13 +124,13 @@ func (c Certificate) GetFingerprints() ([]DTLSFingerprint, error) {
 			// nolint
 			return nil, fmt.Errorf("%w: %v", ErrFailedToGenerateCertificateFingerprint, err)
 		}
-		res[i] = DTLSFingerprint{
+		res = append(res, DTLSFingerprint{
 			Algorithm: name,
 			Value:     value,
-		}
+		})
 	}
 
-	return res[:i+1], nil
+	return res, nil
 }
 
 // GenerateCertificate causes the creation of an X.509 certificate and
@@ -5,8 +5,10 @@ package webrtc
 
 import (
 	"math"
+	"time"
 
 	"github.com/pion/dtls/v3"
+	"github.com/pion/rtcp"
 )
 
 const (
@@ -32,16 +34,187 @@ const (
 	// of Pion before max-message-size was implemented.
 	sctpMaxMessageSizeUnsetValue = math.MaxUint16
 
+	// dataChannelSchedulerWindow is the amount of bytes buffered by the SCTP association
+	// above which the writes of DataChannel.WriteContext wait for their turn.
+	dataChannelSchedulerWindow = 256 * 1024
+	// dataChannelSchedulerQuantum is the amount of bytes added to the deficit of a
+	// DataChannel of weight 1 on each of its turns.
+	dataChannelSchedulerQuantum = 16384
+	// dataChannelSchedulerInterval is how often the buffered amount of the SCTP
+	// association is polled while writes wait for room.
+	dataChannelSchedulerInterval = time.Millisecond
+
 	mediaSectionApplication = "application"
 
+	// srtpKeyingMaterialLabel is the label of the SRTP keying material exported from
+	// DTLS, RFC 5764 section 4.2.
+	srtpKeyingMaterialLabel = "EXTRACTOR-dtls_srtp"
+
 	sdpAttributeRid = "rid"
 
 	sdpAttributeSimulcast = "simulcast"
 
 	outboundMTU = 1200
+	// packetizerMaxMTU bounds the size of the RTP packets packetized by TrackLocalStaticSample
+	// whatever the MTU of its bindings, the UDP payload of an IPv4 packet in a 9000 bytes
+	// jumbo frame.
+	packetizerMaxMTU = 8972
+
+	// pathMTUMinProbeSize is the smallest UDP payload probed by the path MTU discovery,
+	// the one of the 576 bytes IPv4 datagrams every host accepts.
+	pathMTUMinProbeSize = 548
+	// pathMTUHeadroom is the room kept in the path MTU for the SRTP authentication tag
+	// and the header extensions the interceptors add to the packetized RTP packets.
+	pathMTUHeadroom = 64
+	// pathMTUProbeGranularity is the precision of the path MTU discovery.
+	pathMTUProbeGranularity = 16
+	// pathMTUMaxProbes is how many probes of a size are lost before it is deemed too
+	// large, MAX_PROBES of RFC 8899.
+	pathMTUMaxProbes = 3
+	// pathMTUProbeTimeout is how long the response to a probe is waited for, until the
+	// round trip time is measured. The timeout is pathMTUProbeTimeoutRTTs round trips
+	// then, at least pathMTUMinProbeTimeout.
+	pathMTUProbeTimeout     = time.Second
+	pathMTUProbeTimeoutRTTs = 3
+	pathMTUMinProbeTimeout  = 50 * time.Millisecond
+	// pathMTURaiseInterval is how often the path MTU of the selected candidate pair is
+	// searched again, PMTU_RAISE_TIMER of RFC 8899.
+	pathMTURaiseInterval = 10 * time.Minute
+
+	// icePairMigrationWindow is how many of the last checks of a candidate pair are weighed
+	// by the migration of SettingEngine.SetICEPairMigration, the selected pair degrades once
+	// icePairMigrationMaxLoss of them are lost. icePairMigrationProbeTimeout is how long the
+	// response to a check is waited for, the next checks are sent once it is read.
+	icePairMigrationWindow       = 5
+	icePairMigrationMaxLoss      = 2
+	icePairMigrationProbeTimeout = time.Second
+
+	// rtpHeaderSize is the size of an RTP header without CSRCs and extensions.
+	rtpHeaderSize = 12
+
+	// defaultREDDistance is the number of redundant payloads sent by default with RED.
+	defaultREDDistance = 1
+
+	// audioTargetBitrateLimit is the most congestion control allocates
+	// to an audio RTPSender when video is sent as well.
+	audioTargetBitrateLimit = 64000
+
+	// srtpBufferSize is the limit of the buffers of incoming SRTP streams, as used by pion/srtp.
+	srtpBufferSize = 1000 * 1000
+
+	// defaultSRTPDecryptionFailureWindow is the window of SetSRTPDecryptionFailureThreshold
+	// if none is given.
+	defaultSRTPDecryptionFailureWindow = time.Second
+
+	// defaultSRTPReplayProtectionWindow is the replay protection window pion/srtp enables by default.
+	defaultSRTPReplayProtectionWindow = 64
+
+	// defaultEventsBufferSize is how many events an EventSubscription buffers by default.
+	defaultEventsBufferSize = 64
+
+	// trackForwarderKeyframeRequestInterval is the minimum interval between
+	// two keyframe requests a TrackForwarder relays to the publisher.
+	trackForwarderKeyframeRequestInterval = 500 * time.Millisecond
+
+	// trackRemoteCloneBufferPackets is how many packets a cloned TrackRemote buffers
+	// before the packets its reader is too slow to read are dropped.
+	trackRemoteCloneBufferPackets = 128
+
+	// trackFanoutRetryInterval is how long a cloned TrackRemote waits before reading
+	// its RTPReceiver again after a read deadline expired.
+	trackFanoutRetryInterval = 10 * time.Millisecond
+
+	// trackResumeTokenLength is the length of the resume tokens of TrackResumer.NewSession.
+	trackResumeTokenLength = 32
+
+	// defaultTrackResumerSessionTTL is how long a TrackResumer session is kept while
+	// its client doesn't publish, see TrackResumer.SetSessionTTL.
+	defaultTrackResumerSessionTTL = time.Minute
+
+	defaultICEShardedUDPMuxShards = 64
+	// selectedCandidatePairBitrateWindow is the window the bitrates of
+	// PeerConnection.SelectedCandidatePairStats are averaged over, with at most
+	// selectedCandidatePairBitrateSamples samples in it.
+	selectedCandidatePairBitrateWindow  = time.Second
+	selectedCandidatePairBitrateSamples = 32
+
+	// mDNSCacheMaxEntries is how many mDNS names the cache of an API keeps at most.
+	mDNSCacheMaxEntries = 1024
+
+	// pacerMaxQueueSize is how many packets the pacer queues before the writes fail.
+	pacerMaxQueueSize = 1024
+
+	// iceShardedUDPMuxConnQueueSize is how many packets are queued for each ICE agent
+	// of a sharded UDPMux before they are dropped.
+	iceShardedUDPMuxConnQueueSize = 256
+	// iceShardedUDPMuxBatchSize is how many packets a reader of a sharded UDPMux reads
+	// with a recvmmsg syscall at most.
+	iceShardedUDPMuxBatchSize = 32
+	// iceShardedUDPMuxWriteQueueSize is how many packets written to a sharded UDPMux are
+	// queued for each of its batch writers before the writes block.
+	iceShardedUDPMuxWriteQueueSize = 1024
+	// iceShardedUDPMuxGSOMaxSegments is how many packets a sharded UDPMux sends in a single
+	// message with UDP GSO at most, UDP_MAX_SEGMENTS of Linux.
+	iceShardedUDPMuxGSOMaxSegments = 64
+	// iceShardedUDPMuxGSOMaxSize is the size of a message sent with UDP GSO at most, the
+	// largest UDP payload over IPv6.
+	iceShardedUDPMuxGSOMaxSize = 65535 - 8 - 40
+
+	// iceProxyConnectTimeout is how long connecting to a TURN server through a proxy of
+	// SettingEngine.SetICEProxy may take, the CONNECT request and the TLS handshake.
+	iceProxyConnectTimeout = 10 * time.Second
+
+	// turnCredentialsRenewMargin is how long before the TURN credentials of
+	// ICEServer.CredentialProvider expire ICE is restarted to renew them.
+	turnCredentialsRenewMargin = time.Minute
+
+	// maxDSCP is the largest Differentiated Services Code Point, it is 6 bits long.
+	maxDSCP = 63
+
+	// iceOptionRenomination is the ICE option of draft-thatcher-ice-renomination.
+	iceOptionRenomination = "renomination"
+
+	// defaultICEServerProbeTimeout is how long the probe of SettingEngine.SetICEServerProbe
+	// waits for the ICE servers to answer by default.
+	defaultICEServerProbeTimeout = 2 * time.Second
+	// defaultICEServerProbeInterval is how often the ICE servers of
+	// SettingEngine.SetICEServerProbe are probed again by default.
+	defaultICEServerProbeInterval = 5 * time.Minute
+
+	// debugSessionEventsSize is how many recent events DebugHandler shows for a PeerConnection.
+	debugSessionEventsSize = 100
+
+	// debugClosedSessionsSize is how many closed PeerConnections DebugHandler keeps showing.
+	debugClosedSessionsSize = 20
+
+	// debugLogsSize is how many recent log lines DebugHandler shows.
+	debugLogsSize = 200
+
+	// defaultQualityInterval is how often the quality of a PeerConnection is evaluated
+	// for PeerConnection.OnQualityChange by default.
+	defaultQualityInterval = 2 * time.Second
+
+	// The metrics lower the quality score once they exceed these thresholds, by the
+	// penalty per second or per fraction above it: 5% of packet loss or 150ms more
+	// than the threshold each lower the score by 25.
+	qualityRoundTripTimeThreshold   = 150 * time.Millisecond
+	qualityRoundTripTimePenalty     = 166.0
+	qualityJitterThreshold          = 30 * time.Millisecond
+	qualityJitterPenalty            = 250.0
+	qualityPacketLossPenalty        = 500.0
+	qualityBandwidthHeadroomPenalty = 50.0
+
+	// sctpDrainInterval is how often a graceful close checks if the queued DataChannel messages were delivered.
+	sctpDrainInterval = 10 * time.Millisecond
+
+	// srtpResyncMaxRolloverDistance is how far from the estimated rollover counter a re-sync looks.
+	srtpResyncMaxRolloverDistance = 8
 
 	rtpPayloadTypeBitmask = 0x7F
 
+	opusStereoParameter      = "stereo"
+	opusSpropStereoParameter = "sprop-stereo"
+
 	incomingUnhandledRTPSsrc = "Incoming unhandled RTP ssrc(%d), OnTrack will not be fired. %v"
 
 	useReadSimulcast = "Use ReadSimulcast(rid) instead of Read() when multiple tracks are present"
@@ -57,6 +230,35 @@ const (
 	// AttributeRtxSequenceNumber is the interceptor attribute added when
 	// Read() returns an RTX packet containing the RTX stream sequence number.
 	AttributeRtxSequenceNumber = "rtx_sequence_number"
+	// AttributeRepaired is the interceptor attribute set to true when Read()
+	// returns a packet recovered from the RTX stream, with its original
+	// SSRC, payload type and sequence number restored.
+	AttributeRepaired = "repaired"
+	// AttributeFECRecovered is the interceptor attribute set to true when
+	// Read() returns a packet recovered from the FlexFEC stream or from ULPFEC.
+	AttributeFECRecovered = "fec_recovered"
+	// AttributeDependencyDescriptor is the interceptor attribute set to the
+	// *DependencyDescriptor of the packets returned by TrackRemote.Read, once the
+	// dependency descriptor header extension has been negotiated.
+	AttributeDependencyDescriptor = "dependency_descriptor"
+
+	// PlayoutDelayURI is the URI of the playout delay header extension, see
+	// ConfigurePlayoutDelayHeaderExtension.
+	PlayoutDelayURI = "http://www.webrtc.org/experiments/rtp-hdrext/playout-delay"
+
+	// DependencyDescriptorURI is the URI of the dependency descriptor header extension of
+	// the AV1 RTP payload format, which carries the layers of the frames, see SVCFilter.
+	DependencyDescriptorURI = "https://aomediacodec.github.io/av1-rtp-spec/#dependency-descriptor-rtp-header-extension"
+)
+
+// RTCP SDES item types not defined by RFC 3550.
+const (
+	// https://datatracker.ietf.org/doc/html/rfc8852#section-3.1
+	sdesRtpStreamID rtcp.SDESType = 12
+	// https://datatracker.ietf.org/doc/html/rfc8852#section-3.2
+	sdesRepairedRtpStreamID rtcp.SDESType = 13
+	// https://datatracker.ietf.org/doc/html/rfc8843#section-15.3
+	sdesMid rtcp.SDESType = 15
 )
 
 func defaultSrtpProtectionProfiles() []dtls.SRTPProtectionProfile {
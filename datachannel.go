@@ -7,6 +7,7 @@
 package webrtc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -35,6 +36,7 @@ type DataChannel struct {
 	protocol                   string
 	negotiated                 bool
 	id                         *uint16
+	priority                   DataChannelPriority
 	readyState                 atomic.Value // DataChannelState
 	bufferedAmountLowThreshold uint64
 	detachCalled               bool
@@ -58,6 +60,10 @@ type DataChannel struct {
 	onBufferedAmountLow func()
 	onErrorHandler      func(error)
 
+	// bufferedAmountChanged is closed, then replaced, when the buffered amount gets low
+	// or the ready state changes, waking the writes of WriteContext.
+	bufferedAmountChanged atomic.Pointer[chan struct{}]
+
 	sctpTransport *SCTPTransport
 	dataChannel   *datachannel.DataChannel
 
@@ -70,7 +76,7 @@ type DataChannel struct {
 // This constructor is part of the ORTC API. It is not
 // meant to be used together with the basic WebRTC API.
 func (api *API) NewDataChannel(transport *SCTPTransport, params *DataChannelParameters) (*DataChannel, error) {
-	d, err := api.newDataChannel(params, nil, api.settingEngine.LoggerFactory.NewLogger("ortc"))
+	d, err := api.newDataChannel(params, nil, api.loggerFactory().NewLogger("ortc"))
 	if err != nil {
 		return nil, err
 	}
@@ -105,9 +111,13 @@ func (api *API) newDataChannel(
 		ordered:           params.Ordered,
 		maxPacketLifeTime: params.MaxPacketLifeTime,
 		maxRetransmits:    params.MaxRetransmits,
+		priority:          params.Priority,
 		api:               api,
 		log:               log,
 	}
+	if dataChannel.priority == 0 {
+		dataChannel.priority = DataChannelPriorityLow
+	}
 
 	dataChannel.setReadyState(DataChannelStateConnecting)
 
@@ -157,12 +167,12 @@ func (d *DataChannel) open(sctpTransport *SCTPTransport) error { //nolint:cyclop
 
 	cfg := &datachannel.Config{
 		ChannelType:          channelType,
-		Priority:             datachannel.ChannelPriorityNormal,
+		Priority:             uint16(d.priority),
 		ReliabilityParameter: reliabilityParameter,
 		Label:                d.label,
 		Protocol:             d.protocol,
 		Negotiated:           d.negotiated,
-		LoggerFactory:        d.api.settingEngine.LoggerFactory,
+		LoggerFactory:        d.api.loggerFactory(),
 	}
 
 	if d.id == nil {
@@ -185,7 +195,7 @@ func (d *DataChannel) open(sctpTransport *SCTPTransport) error { //nolint:cyclop
 
 	// bufferedAmountLowThreshold and onBufferedAmountLow might be set earlier
 	dc.SetBufferedAmountLowThreshold(d.bufferedAmountLowThreshold)
-	dc.OnBufferedAmountLow(d.onBufferedAmountLow)
+	dc.OnBufferedAmountLow(d.bufferedAmountLowHandler(d.onBufferedAmountLow))
 	d.mu.Unlock()
 
 	d.onDial()
@@ -339,6 +349,9 @@ func (d *DataChannel) handleOpen(dc *datachannel.DataChannel, isRemote, isAlread
 		return
 	}
 	d.dataChannel = dc
+	if d.sctpTransport != nil && d.sctpTransport.reliability != nil && d.id != nil {
+		d.sctpTransport.reliability.reset(*d.id)
+	}
 	bufferedAmountLowThreshold := d.bufferedAmountLowThreshold
 	onBufferedAmountLow := d.onBufferedAmountLow
 	d.mu.Unlock()
@@ -351,7 +364,7 @@ func (d *DataChannel) handleOpen(dc *datachannel.DataChannel, isRemote, isAlread
 	if d.api.settingEngine.detach.DataChannels || isRemote || isAlreadyNegotiated {
 		// bufferedAmountLowThreshold and onBufferedAmountLow might be set earlier
 		d.dataChannel.SetBufferedAmountLowThreshold(bufferedAmountLowThreshold)
-		d.dataChannel.OnBufferedAmountLow(onBufferedAmountLow)
+		d.dataChannel.OnBufferedAmountLow(d.bufferedAmountLowHandler(onBufferedAmountLow))
 		d.onOpen()
 	} else {
 		dc.OnOpen(func() {
@@ -403,21 +416,21 @@ func (d *DataChannel) readLoop() {
 		defer close(readLoopActive)
 	}()
 
-	buffer := make([]byte, sctpMaxMessageSizeUnsetValue)
+	// The SCTP stream reassembles the DATA chunks of each message up to its end of record,
+	// the buffer grows to the messages read up to the max-message-size advertised
+	maxMessageSize := int(d.api.settingEngine.getSCTPMaxMessageSize())
+	buffer := make([]byte, min(sctpMaxMessageSizeUnsetValue, maxMessageSize))
 	for {
 		n, isString, err := d.dataChannel.ReadDataChannel(buffer)
 		if err != nil {
 			if errors.Is(err, io.ErrShortBuffer) {
-				if int64(n) < int64(d.api.settingEngine.getSCTPMaxMessageSize()) {
-					buffer = append(buffer, make([]byte, len(buffer))...) // nolint
+				if len(buffer) < maxMessageSize {
+					buffer = make([]byte, min(2*len(buffer), maxMessageSize))
 
 					continue
 				}
 
-				d.log.Errorf(
-					"Incoming DataChannel message larger then Max Message size %v",
-					d.api.settingEngine.getSCTPMaxMessageSize(),
-				)
+				d.log.Errorf("Incoming DataChannel message larger then Max Message size %v", maxMessageSize)
 			}
 
 			d.setReadyState(DataChannelStateClosed)
@@ -436,30 +449,137 @@ func (d *DataChannel) readLoop() {
 	}
 }
 
-// Send sends the binary message to the DataChannel peer.
+// Send sends the binary message to the DataChannel peer. data is copied by the SCTP
+// association, where it stays until acknowledged, so it may be reused or returned to a
+// pool as soon as Send returns.
 func (d *DataChannel) Send(data []byte) error {
+	return d.send(data, false)
+}
+
+// SendText sends the text message to the DataChannel peer.
+func (d *DataChannel) SendText(s string) error {
+	return d.send([]byte(s), true)
+}
+
+func (d *DataChannel) send(data []byte, isString bool) error {
 	err := d.ensureOpen()
 	if err != nil {
 		return err
 	}
+	if err = d.ensureSendBuffer(); err != nil {
+		return err
+	}
 
-	_, err = d.dataChannel.WriteDataChannel(data, false)
+	_, err = d.dataChannel.WriteDataChannel(data, isString)
 
 	return err
 }
 
-// SendText sends the text message to the DataChannel peer.
-func (d *DataChannel) SendText(s string) error {
-	err := d.ensureOpen()
-	if err != nil {
+// WriteContext sends the binary message to the DataChannel peer once the BufferedAmount
+// is at most the BufferedAmountLowThreshold, blocking until then. This bounds the memory
+// of bulk transfers without handling OnBufferedAmountLow, with a threshold large enough
+// to keep the association busy, a few times the message size. The error of ctx is
+// returned if it is done first, io.ErrClosedPipe if the DataChannel closes. ctx is only
+// checked until the message is passed whole to the SCTP association, which then sends
+// all of its chunks whatever happens to ctx: the message is sent, or not at all.
+//
+// While the SCTP association buffers more than 256 KiB, the writes of its DataChannels
+// take turns by their priority, see DataChannelInit.Priority, so the bulk transfers
+// don't starve the other DataChannels. Send isn't scheduled.
+func (d *DataChannel) WriteContext(ctx context.Context, data []byte) error {
+	return d.writeContext(ctx, data, false)
+}
+
+// WriteTextContext sends the text message to the DataChannel peer like WriteContext.
+func (d *DataChannel) WriteTextContext(ctx context.Context, s string) error {
+	return d.writeContext(ctx, []byte(s), true)
+}
+
+func (d *DataChannel) writeContext(ctx context.Context, data []byte, isString bool) error {
+	for {
+		// The channel is taken before the buffered amount is read, for its change
+		// not to be missed
+		changed := d.bufferedAmountChangedSignal()
+		if err := d.ensureOpen(); err != nil {
+			return err
+		}
+		if d.BufferedAmount() <= d.BufferedAmountLowThreshold() {
+			break
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := d.ensureSendBuffer(); err != nil {
 		return err
 	}
 
-	_, err = d.dataChannel.WriteDataChannel([]byte(s), true)
+	// The writes of the DataChannels sharing the association are scheduled by priority
+	var scheduler *dataChannelScheduler
+	if transport := d.Transport(); transport != nil {
+		scheduler = transport.scheduler
+	}
+	done, err := scheduler.admit(ctx, d, len(data))
+	if err != nil {
+		return err
+	}
+	// The message is written at once, ctx isn't checked anymore
+	_, err = d.dataChannel.WriteDataChannel(data, isString)
+	done()
 
 	return err
 }
 
+// bufferedAmountChangedSignal returns the channel closed on the next change of the
+// buffered amount or of the ready state.
+func (d *DataChannel) bufferedAmountChangedSignal() <-chan struct{} {
+	for {
+		if changed := d.bufferedAmountChanged.Load(); changed != nil {
+			return *changed
+		}
+		changed := make(chan struct{})
+		if d.bufferedAmountChanged.CompareAndSwap(nil, &changed) {
+			return changed
+		}
+	}
+}
+
+// signalBufferedAmountChanged wakes the writes waiting in WriteContext.
+func (d *DataChannel) signalBufferedAmountChanged() {
+	if changed := d.bufferedAmountChanged.Swap(nil); changed != nil {
+		close(*changed)
+	}
+}
+
+// bufferedAmountLowHandler returns the handler of the bufferedamountlow event of the
+// underlying DataChannel, waking the writes of WriteContext before calling f. It doesn't
+// take the lock, as it runs on the goroutine of the association.
+func (d *DataChannel) bufferedAmountLowHandler(f func()) func() {
+	return func() {
+		d.signalBufferedAmountChanged()
+		if f != nil {
+			f()
+		}
+	}
+}
+
+// ensureSendBuffer checks that the send buffer of SettingEngine.SetSCTPMaxSendBufferSize
+// didn't reach its limit.
+func (d *DataChannel) ensureSendBuffer() error {
+	maxSendBufferSize := d.api.settingEngine.sctp.maxSendBufferSize
+	if maxSendBufferSize != 0 && d.BufferedAmount() >= uint64(maxSendBufferSize) {
+		return &rtcerr.OperationError{Err: ErrDataChannelSendBufferFull}
+	}
+
+	return nil
+}
+
 func (d *DataChannel) ensureOpen() error {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
@@ -627,6 +747,14 @@ func (d *DataChannel) Negotiated() bool {
 	return d.negotiated
 }
 
+// Priority represents the priority of the DataChannel, set by the peer creating it.
+func (d *DataChannel) Priority() DataChannelPriority {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.priority
+}
+
 // ID represents the ID for this DataChannel. The value is initially
 // null, which is what will be returned if the ID was not provided at
 // channel creation time, and the DTLS role of the SCTP transport has not
@@ -698,6 +826,7 @@ func (d *DataChannel) SetBufferedAmountLowThreshold(th uint64) {
 	if d.dataChannel != nil {
 		d.dataChannel.SetBufferedAmountLowThreshold(th)
 	}
+	d.signalBufferedAmountChanged()
 }
 
 // OnBufferedAmountLow sets an event handler which is invoked when
@@ -711,9 +840,7 @@ func (d *DataChannel) OnBufferedAmountLow(f func()) {
 		go f()
 	}
 	if d.dataChannel != nil {
-		d.dataChannel.OnBufferedAmountLow(func() {
-			go f()
-		})
+		d.dataChannel.OnBufferedAmountLow(d.bufferedAmountLowHandler(d.onBufferedAmountLow))
 	}
 }
 
@@ -731,13 +858,13 @@ func (d *DataChannel) collectStats(collector *statsReportCollector) {
 	defer d.mu.Unlock()
 
 	stats := DataChannelStats{
-		Timestamp: statsTimestampNow(),
-		Type:      StatsTypeDataChannel,
-		ID:        d.statsID,
-		Label:     d.label,
-		Protocol:  d.protocol,
-		// TransportID string `json:"transportId"`
-		State: d.ReadyState(),
+		Timestamp:   statsTimestampNow(),
+		Type:        StatsTypeDataChannel,
+		ID:          d.statsID,
+		Label:       d.label,
+		Protocol:    d.protocol,
+		TransportID: "iceTransport",
+		State:       d.ReadyState(),
 	}
 
 	if d.id != nil {
@@ -756,4 +883,5 @@ func (d *DataChannel) collectStats(collector *statsReportCollector) {
 
 func (d *DataChannel) setReadyState(r DataChannelState) {
 	d.readyState.Store(r)
+	d.signalBufferedAmountChanged()
 }
@@ -21,7 +21,10 @@ import (
 
 	"github.com/pion/datachannel"
 	"github.com/pion/logging"
+	"github.com/pion/stun/v3"
 	"github.com/pion/transport/v3/test"
+	"github.com/pion/transport/v3/vnet"
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -159,6 +162,38 @@ func TestDataChannelParamters_Go(t *testing.T) {
 		closeReliabilityParamTest(t, offerPC, answerPC, done)
 	})
 
+	t.Run("Priority exchange", func(t *testing.T) {
+		priority := DataChannelPriorityHigh
+		offerPC, answerPC, dc, done := setUpDataChannelParametersTest(t, &DataChannelInit{Priority: &priority})
+		assert.Equal(t, DataChannelPriorityHigh, dc.Priority())
+
+		answerPC.OnDataChannel(func(d *DataChannel) {
+			if d.Label() != expectedLabel {
+				return
+			}
+
+			assert.Equal(t, DataChannelPriorityHigh, d.Priority())
+			done <- true
+		})
+
+		closeReliabilityParamTest(t, offerPC, answerPC, done)
+
+		// The default priority is low
+		offerPC, answerPC, dc, done = setUpDataChannelParametersTest(t, nil)
+		assert.Equal(t, DataChannelPriorityLow, dc.Priority())
+
+		answerPC.OnDataChannel(func(d *DataChannel) {
+			if d.Label() != expectedLabel {
+				return
+			}
+
+			assert.Equal(t, DataChannelPriorityLow, d.Priority())
+			done <- true
+		})
+
+		closeReliabilityParamTest(t, offerPC, answerPC, done)
+	})
+
 	t.Run("All other property methods", func(t *testing.T) {
 		id := uint16(123)
 		dc := &DataChannel{}
@@ -814,6 +849,130 @@ func TestDataChannelMessageSize(t *testing.T) {
 	closePairNow(t, offerPC, answerPC)
 }
 
+func TestDataChannelSendBufferFull(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	offerPC, answerPC, wan := createVNetPair(t, nil)
+	offerPC.api.settingEngine.SetSCTPMaxSendBufferSize(1024)
+
+	// Keep ICE alive while nothing gets acknowledged
+	dropData := &atomic.Bool{}
+	wan.AddChunkFilter(func(c vnet.Chunk) bool {
+		return !dropData.Load() || stun.IsMessage(c.UserData())
+	})
+
+	dc, err := offerPC.CreateDataChannel("buffer", nil)
+	assert.NoError(t, err)
+
+	answerMessages := make(chan []byte, 2)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		d.OnMessage(func(m DataChannelMessage) {
+			answerMessages <- m.Data
+		})
+	})
+
+	opened := make(chan struct{})
+	dc.OnOpen(func() {
+		close(opened)
+	})
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	<-opened
+
+	// The DCEP open message is buffered until acknowledged as well
+	assert.Eventually(t, func() bool { return dc.BufferedAmount() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	dropData.Store(true)
+	// A message is accepted whatever its size below the limit
+	assert.NoError(t, dc.Send(make([]byte, 2048)))
+
+	var operationErr *rtcerr.OperationError
+	assert.ErrorAs(t, dc.Send([]byte{0}), &operationErr)
+	assert.ErrorIs(t, dc.SendText("a"), ErrDataChannelSendBufferFull)
+
+	dropData.Store(false)
+	assert.Len(t, <-answerMessages, 2048)
+	assert.Eventually(t, func() bool { return dc.BufferedAmount() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, dc.Send(make([]byte, 1024)))
+	assert.Len(t, <-answerMessages, 1024)
+
+	assert.NoError(t, wan.Stop())
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestDataChannel_WriteContext(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	offerPC, answerPC, wan := createVNetPair(t, nil)
+
+	// Keep ICE alive while nothing gets acknowledged
+	dropData := &atomic.Bool{}
+	wan.AddChunkFilter(func(c vnet.Chunk) bool {
+		return !dropData.Load() || stun.IsMessage(c.UserData())
+	})
+
+	dc, err := offerPC.CreateDataChannel("write", nil)
+	assert.NoError(t, err)
+	dc.SetBufferedAmountLowThreshold(1024)
+
+	answerMessages := make(chan []byte, 3)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		d.OnMessage(func(m DataChannelMessage) {
+			answerMessages <- m.Data
+		})
+	})
+
+	opened := make(chan struct{})
+	dc.OnOpen(func() {
+		close(opened)
+	})
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	<-opened
+	assert.Eventually(t, func() bool { return dc.BufferedAmount() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	dropData.Store(true)
+	assert.NoError(t, dc.WriteContext(context.Background(), make([]byte, 2048)))
+
+	// Blocked above the threshold until ctx is done, the message isn't sent
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	assert.ErrorIs(t, dc.WriteTextContext(ctx, "dropped"), context.DeadlineExceeded)
+	cancel()
+	assert.Equal(t, uint64(2048), dc.BufferedAmount())
+
+	// Unblocked once the buffered amount is low
+	written := make(chan error)
+	go func() {
+		written <- dc.WriteTextContext(context.Background(), "text")
+	}()
+	dropData.Store(false)
+	assert.NoError(t, <-written)
+	assert.Len(t, <-answerMessages, 2048)
+	assert.Equal(t, []byte("text"), <-answerMessages)
+
+	// A message passed to SCTP is sent whole, whatever happens to ctx then
+	ctx, cancel = context.WithCancel(context.Background())
+	assert.NoError(t, dc.WriteContext(ctx, make([]byte, 200000)))
+	cancel()
+	assert.Len(t, <-answerMessages, 200000)
+
+	// Unblocked by the closing of the DataChannel
+	assert.Eventually(t, func() bool { return dc.BufferedAmount() == 0 }, 5*time.Second, 10*time.Millisecond)
+	dropData.Store(true)
+	assert.NoError(t, dc.WriteContext(context.Background(), make([]byte, 2048)))
+	go func() {
+		written <- dc.WriteContext(context.Background(), []byte{0})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, dc.Close())
+	assert.ErrorIs(t, <-written, io.ErrClosedPipe)
+
+	dropData.Store(false)
+	assert.NoError(t, wan.Stop())
+	closePairNow(t, offerPC, answerPC)
+}
+
 func TestOnBufferedAmountLowDeadlock(t *testing.T) {
 	offerPC, answerPC, err := newPair()
 	assert.NoError(t, err)
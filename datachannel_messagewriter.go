@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// NewMessageWriter returns a writer of a single message to the DataChannel peer, a text
+// message if isString is set, for the messages built in pieces. The data written is held
+// until Close, which sends it as one SCTP user message like WriteContext, blocking while
+// the BufferedAmount is above the BufferedAmountLowThreshold, with the error of ctx if it
+// is done first. The SCTP association fragments the message into DATA chunks, which the
+// SCTP stack of the remote reassembles up to the end of the record, so any remote reads
+// it as a single message, browsers included.
+//
+// Write fails once the message gets larger than the max-message-size of the remote, which
+// it can't read, and the message is then not sent.
+func (d *DataChannel) NewMessageWriter(ctx context.Context, isString bool) (io.WriteCloser, error) {
+	if err := d.ensureOpen(); err != nil {
+		return nil, err
+	}
+
+	return &dataChannelMessageWriter{
+		dataChannel:    d,
+		ctx:            ctx,
+		isString:       isString,
+		maxMessageSize: d.remoteMaxMessageSize(),
+	}, nil
+}
+
+// remoteMaxMessageSize returns the max-message-size of the remote, the size assumed when
+// it isn't declared until the association is started.
+func (d *DataChannel) remoteMaxMessageSize() uint32 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.sctpTransport != nil {
+		if association := d.sctpTransport.association(); association != nil {
+			return association.MaxMessageSize()
+		}
+	}
+
+	return sctpMaxMessageSizeUnsetValue
+}
+
+// dataChannelMessageWriter holds a message until it is sent by Close, see NewMessageWriter.
+type dataChannelMessageWriter struct {
+	dataChannel    *DataChannel
+	ctx            context.Context //nolint:containedctx
+	isString       bool
+	maxMessageSize uint32
+
+	data   []byte
+	closed bool
+	err    error
+}
+
+func (w *dataChannelMessageWriter) Write(p []byte) (int, error) {
+	switch {
+	case w.closed:
+		return 0, io.ErrClosedPipe
+	case w.err != nil:
+		return 0, w.err
+	case uint64(len(w.data)+len(p)) > uint64(w.maxMessageSize):
+		w.data, w.err = nil, fmt.Errorf("%w: %d", errDataChannelMessageTooLarge, w.maxMessageSize)
+
+		return 0, w.err
+	}
+
+	w.data = append(w.data, p...)
+
+	return len(p), nil
+}
+
+// Close sends the message, unless a Write failed.
+func (w *dataChannelMessageWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.err != nil {
+		return w.err
+	}
+
+	data := w.data
+	w.data = nil
+
+	return w.dataChannel.writeContext(w.ctx, data, w.isString)
+}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataChannel_LargeMessages(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// The answerer accepts messages up to 1 MiB
+	answerSettingEngine := SettingEngine{}
+	answerSettingEngine.SetSCTPMaxMessageSize(1 << 20)
+	pcOffer, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := NewAPI(WithSettingEngine(answerSettingEngine)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	dc, err := pcOffer.CreateDataChannel("large", nil)
+	require.NoError(t, err)
+
+	messages := make(chan DataChannelMessage, 3)
+	pcAnswer.OnDataChannel(func(d *DataChannel) {
+		d.OnMessage(func(msg DataChannelMessage) {
+			messages <- msg
+		})
+	})
+
+	opened := make(chan struct{})
+	dc.OnOpen(func() {
+		close(opened)
+	})
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	<-opened
+	assert.Equal(t, uint32(1<<20), dc.remoteMaxMessageSize())
+
+	// Larger than the first read buffer, reassembled by SCTP
+	large := make([]byte, 300000)
+	_, err = rand.Read(large)
+	require.NoError(t, err)
+	require.NoError(t, dc.Send(large))
+	msg := <-messages
+	assert.False(t, msg.IsString)
+	assert.Equal(t, large, msg.Data)
+
+	// A message written in pieces is sent whole by Close
+	writer, err := dc.NewMessageWriter(context.Background(), true)
+	require.NoError(t, err)
+	text := bytes.Repeat([]byte("é"), 50000)
+	for i := 0; i < len(text); i += 7001 {
+		_, err = writer.Write(text[i:min(i+7001, len(text))])
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	msg = <-messages
+	assert.True(t, msg.IsString)
+	assert.Equal(t, text, msg.Data)
+
+	// Larger than the max message size of the remote, not sent
+	writer, err = dc.NewMessageWriter(context.Background(), false)
+	require.NoError(t, err)
+	_, err = writer.Write(make([]byte, 1<<20))
+	require.NoError(t, err)
+	_, err = writer.Write([]byte{0})
+	assert.ErrorIs(t, err, errDataChannelMessageTooLarge)
+	assert.ErrorIs(t, writer.Close(), errDataChannelMessageTooLarge)
+
+	require.NoError(t, dc.SendText("short"))
+	assert.Equal(t, DataChannelMessage{IsString: true, Data: []byte("short")}, <-messages)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
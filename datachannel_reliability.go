@@ -0,0 +1,344 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The SCTP chunks observed, RFC 9260 and RFC 3758.
+const (
+	sctpCommonHeaderSize = 12
+	sctpChunkHeaderSize  = 4
+	sctpChunkTypeData    = 0
+	sctpChunkTypeSACK    = 3
+	sctpChunkTypeForward = 192
+	sctpDataChunkSize    = 16
+	sctpSACKChunkSize    = 16
+	sctpForwardChunkSize = 8
+	sctpDataFlagEnd      = 0x01
+	sctpChecksumOffset   = 8
+	sctpGapAckBlockSize  = 4
+)
+
+// The retransmission timeout computation of RFC 9260 section 6.3.1, with the defaults
+// of the SCTP association.
+const (
+	sctpRTOAlpha          = 0.125
+	sctpRTOBeta           = 0.25
+	sctpRTOInitialDefault = time.Second
+	sctpRTOMinDefault     = time.Second
+	sctpRTOMaxDefault     = 60 * time.Second
+)
+
+// DataChannelReliabilityStats are the retransmission statistics of a DataChannel, see
+// DataChannel.ReliabilityStats.
+type DataChannelReliabilityStats struct {
+	// Retransmissions is the number of DATA chunks of the DataChannel sent again after
+	// a loss or a timeout.
+	Retransmissions uint64
+	// MessagesAbandoned is the number of messages of the DataChannel given up before
+	// they were acknowledged, once their maxRetransmits or maxPacketLifeTime is reached.
+	MessagesAbandoned uint64
+	// EstimatedRTO is the retransmission timeout of the SCTP association, shared by all
+	// its DataChannels, as estimated from the round trips of the DATA chunks like RFC 9260
+	// section 6.3.1 with a minimum of 1 second. The association doesn't report its own,
+	// which is also doubled on each timeout.
+	EstimatedRTO time.Duration
+}
+
+// ReliabilityStats returns the retransmission statistics of the DataChannel, counted
+// since it opened. The SCTP association doesn't report them, they are taken from the
+// SCTP packets sent and received by the SCTPTransport. The statistics are zero unless
+// SettingEngine.EnableSCTPReliabilityStats is set.
+func (d *DataChannel) ReliabilityStats() DataChannelReliabilityStats {
+	d.mu.RLock()
+	transport, id := d.sctpTransport, d.id
+	d.mu.RUnlock()
+
+	if !d.api.settingEngine.sctp.enableReliabilityStats {
+		return DataChannelReliabilityStats{}
+	}
+	if transport == nil || transport.reliability == nil || id == nil {
+		return DataChannelReliabilityStats{EstimatedRTO: sctpRTOInitialDefault}
+	}
+
+	return transport.reliability.stats(*id)
+}
+
+// sctpReliabilityObserver counts the retransmissions and the abandoned messages of the
+// streams of an SCTP association from its packets, and estimates its retransmission
+// timeout from the round trips of the DATA chunks like RFC 9260 section 6.3.1.
+type sctpReliabilityObserver struct {
+	mu sync.Mutex
+
+	// outstanding are the DATA chunks sent not acknowledged by the cumulative TSN ack,
+	// in the order of their TSN.
+	outstanding []sctpSentChunk
+	// measureFrom is the first TSN whose round trip is measured, a single round trip is
+	// measured at a time.
+	measureFrom uint32
+	measuring   bool
+	streams     map[uint16]*sctpStreamCounters
+
+	srtt, rttvar, rto, rtoMax time.Duration
+}
+
+type sctpSentChunk struct {
+	tsn    uint32
+	stream uint16
+	end    bool
+	sentAt time.Time
+	// retransmitted chunks aren't measured, Karn's algorithm.
+	retransmitted, acked bool
+}
+
+type sctpStreamCounters struct {
+	retransmissions, messagesAbandoned uint64
+}
+
+func newSCTPReliabilityObserver(rtoMax time.Duration) *sctpReliabilityObserver {
+	if rtoMax == 0 {
+		rtoMax = sctpRTOMaxDefault
+	}
+
+	return &sctpReliabilityObserver{
+		streams: map[uint16]*sctpStreamCounters{},
+		rto:     sctpRTOInitialDefault,
+		rtoMax:  rtoMax,
+	}
+}
+
+func (o *sctpReliabilityObserver) stats(stream uint16) DataChannelReliabilityStats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	stats := DataChannelReliabilityStats{EstimatedRTO: o.rto}
+	if counters, ok := o.streams[stream]; ok {
+		stats.Retransmissions = counters.retransmissions
+		stats.MessagesAbandoned = counters.messagesAbandoned
+	}
+
+	return stats
+}
+
+// reset starts the counters of stream over, for a DataChannel opening on it.
+func (o *sctpReliabilityObserver) reset(stream uint16) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	delete(o.streams, stream)
+}
+
+func (o *sctpReliabilityObserver) counters(stream uint16) *sctpStreamCounters {
+	counters, ok := o.streams[stream]
+	if !ok {
+		counters = &sctpStreamCounters{}
+		o.streams[stream] = counters
+	}
+
+	return counters
+}
+
+// sent handles an SCTP packet written to the association.
+func (o *sctpReliabilityObserver) sent(packet []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	forEachSCTPChunk(packet, func(chunkType byte, flags byte, value []byte) {
+		switch {
+		case chunkType == sctpChunkTypeData && len(value) >= sctpDataChunkSize-sctpChunkHeaderSize:
+			o.sentData(binary.BigEndian.Uint32(value), binary.BigEndian.Uint16(value[4:]), flags, now)
+		case chunkType == sctpChunkTypeForward && len(value) >= sctpForwardChunkSize-sctpChunkHeaderSize:
+			o.abandon(binary.BigEndian.Uint32(value))
+		}
+	})
+}
+
+// received handles an SCTP packet read from the association.
+func (o *sctpReliabilityObserver) received(packet []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	forEachSCTPChunk(packet, func(chunkType byte, _ byte, value []byte) {
+		if chunkType != sctpChunkTypeSACK || len(value) < sctpSACKChunkSize-sctpChunkHeaderSize {
+			return
+		}
+
+		cumulative := binary.BigEndian.Uint32(value)
+		gaps := int(binary.BigEndian.Uint16(value[8:]))
+		value = value[sctpSACKChunkSize-sctpChunkHeaderSize:]
+		o.ack(cumulative, now)
+		for i := 0; i < gaps && len(value) >= sctpGapAckBlockSize*(i+1); i++ {
+			start := binary.BigEndian.Uint16(value[i*sctpGapAckBlockSize:])
+			end := binary.BigEndian.Uint16(value[i*sctpGapAckBlockSize+2:])
+			o.ackGap(cumulative+uint32(start), cumulative+uint32(end), now)
+		}
+	})
+}
+
+func (o *sctpReliabilityObserver) sentData(tsn uint32, stream uint16, flags byte, now time.Time) {
+	i := o.find(tsn)
+	if i < len(o.outstanding) && o.outstanding[i].tsn == tsn {
+		if chunk := &o.outstanding[i]; !chunk.acked {
+			chunk.retransmitted = true
+			o.counters(chunk.stream).retransmissions++
+		}
+
+		return
+	}
+	if i != len(o.outstanding) {
+		// Acknowledged already
+		return
+	}
+
+	o.outstanding = append(o.outstanding, sctpSentChunk{
+		tsn:    tsn,
+		stream: stream,
+		end:    flags&sctpDataFlagEnd != 0,
+		sentAt: now,
+	})
+	if !o.measuring {
+		o.measureFrom, o.measuring = tsn, true
+	}
+}
+
+// ack handles the cumulative TSN ack of a SACK, the gap blocks are handled by ackGap.
+func (o *sctpReliabilityObserver) ack(cumulative uint32, now time.Time) {
+	i := o.find(cumulative + 1)
+	for j := range o.outstanding[:i] {
+		o.acked(&o.outstanding[j], now)
+	}
+	o.outstanding = o.outstanding[i:]
+}
+
+func (o *sctpReliabilityObserver) ackGap(start, end uint32, now time.Time) {
+	for i := o.find(start); i < len(o.outstanding) && !sctpTSNLess(end, o.outstanding[i].tsn); i++ {
+		o.acked(&o.outstanding[i], now)
+	}
+}
+
+func (o *sctpReliabilityObserver) acked(chunk *sctpSentChunk, now time.Time) {
+	if chunk.acked {
+		return
+	}
+	chunk.acked = true
+
+	if chunk.retransmitted || !o.measuring || sctpTSNLess(chunk.tsn, o.measureFrom) {
+		return
+	}
+	// The next round trip is measured from the next chunk sent
+	o.measuring = false
+	if last := len(o.outstanding); last != 0 {
+		o.measureFrom, o.measuring = o.outstanding[last-1].tsn+1, true
+	}
+	o.measure(now.Sub(chunk.sentAt))
+}
+
+// measure updates the retransmission timeout with the round trip rtt.
+func (o *sctpReliabilityObserver) measure(rtt time.Duration) {
+	if o.srtt == 0 {
+		o.srtt, o.rttvar = rtt, rtt/2
+	} else {
+		delta := o.srtt - rtt
+		if delta < 0 {
+			delta = -delta
+		}
+		o.rttvar = time.Duration((1-sctpRTOBeta)*float64(o.rttvar) + sctpRTOBeta*float64(delta))
+		o.srtt = time.Duration((1-sctpRTOAlpha)*float64(o.srtt) + sctpRTOAlpha*float64(rtt))
+	}
+	o.rto = min(max(o.srtt+4*o.rttvar, sctpRTOMinDefault), o.rtoMax)
+}
+
+// abandon handles a FORWARD TSN moving the cumulative TSN of the receiver to cumulative,
+// the messages of the chunks not acknowledged below it are abandoned.
+func (o *sctpReliabilityObserver) abandon(cumulative uint32) {
+	i := o.find(cumulative + 1)
+	abandoned := false
+	for j, chunk := range o.outstanding[:i] {
+		abandoned = abandoned || !chunk.acked
+		if abandoned && (chunk.end || j == i-1) {
+			o.counters(chunk.stream).messagesAbandoned++
+		}
+		if chunk.end {
+			abandoned = false
+		}
+	}
+	o.outstanding = o.outstanding[i:]
+}
+
+// find returns the index of the first outstanding chunk whose TSN isn't less than tsn.
+func (o *sctpReliabilityObserver) find(tsn uint32) int {
+	return sort.Search(len(o.outstanding), func(i int) bool {
+		return !sctpTSNLess(o.outstanding[i].tsn, tsn)
+	})
+}
+
+// sctpTSNLess compares the TSNs a and b in serial number arithmetic, RFC 1982.
+func sctpTSNLess(a, b uint32) bool {
+	return a != b && b-a < 1<<31
+}
+
+// forEachSCTPChunk calls f with each chunk of packet, its value following its header.
+func forEachSCTPChunk(packet []byte, f func(chunkType, flags byte, value []byte)) {
+	if len(packet) < sctpCommonHeaderSize {
+		return
+	}
+
+	for packet = packet[sctpCommonHeaderSize:]; len(packet) >= sctpChunkHeaderSize; {
+		length := int(binary.BigEndian.Uint16(packet[2:]))
+		if length < sctpChunkHeaderSize || length > len(packet) {
+			return
+		}
+		f(packet[0], packet[1], packet[sctpChunkHeaderSize:length])
+
+		// The chunks are padded to multiples of 4 bytes
+		packet = packet[min((length+3)&^3, len(packet)):]
+	}
+}
+
+// sctpObservedConn passes the SCTP packets read and written to an observer, when not nil,
+// and sets zeroChecksum from the first packet of DATA chunks written: the checksums are
+// skipped or not for the whole association, as negotiated by its INIT and INIT ACK chunks.
+type sctpObservedConn struct {
+	net.Conn
+
+	observer      *sctpReliabilityObserver
+	zeroChecksum  *atomic.Bool
+	checksumKnown atomic.Bool
+}
+
+func (c *sctpObservedConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 && c.observer != nil {
+		c.observer.received(p[:n])
+	}
+
+	return n, err
+}
+
+func (c *sctpObservedConn) Write(p []byte) (int, error) {
+	if !c.checksumKnown.Load() {
+		forEachSCTPChunk(p, func(chunkType, _ byte, _ []byte) {
+			if chunkType == sctpChunkTypeData && !c.checksumKnown.Swap(true) {
+				c.zeroChecksum.Store(binary.BigEndian.Uint32(p[sctpChecksumOffset:]) == 0)
+			}
+		})
+	}
+	if c.observer != nil {
+		c.observer.sent(p)
+	}
+
+	return c.Conn.Write(p)
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/stun/v3"
+	"github.com/pion/transport/v3/test"
+	"github.com/pion/transport/v3/vnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sctpTestPacket(chunks ...[]byte) []byte {
+	packet := make([]byte, sctpCommonHeaderSize)
+	for _, chunk := range chunks {
+		packet = append(packet, chunk...)
+		for len(packet)%4 != 0 {
+			packet = append(packet, 0)
+		}
+	}
+
+	return packet
+}
+
+func sctpTestChunk(chunkType, flags byte, value []byte) []byte {
+	chunk := []byte{chunkType, flags, 0, 0}
+	binary.BigEndian.PutUint16(chunk[2:], uint16(sctpChunkHeaderSize+len(value))) //nolint:gosec // G115
+
+	return append(chunk, value...)
+}
+
+func sctpTestData(tsn uint32, stream uint16, flags byte) []byte {
+	value := make([]byte, sctpDataChunkSize-sctpChunkHeaderSize, sctpDataChunkSize-sctpChunkHeaderSize+1)
+	binary.BigEndian.PutUint32(value, tsn)
+	binary.BigEndian.PutUint16(value[4:], stream)
+
+	return sctpTestChunk(sctpChunkTypeData, flags, append(value, 'a'))
+}
+
+func sctpTestSACK(cumulative uint32, gaps ...uint16) []byte {
+	value := make([]byte, sctpSACKChunkSize-sctpChunkHeaderSize)
+	binary.BigEndian.PutUint32(value, cumulative)
+	binary.BigEndian.PutUint16(value[8:], uint16(len(gaps)/2)) //nolint:gosec // G115
+	for _, gap := range gaps {
+		value = binary.BigEndian.AppendUint16(value, gap)
+	}
+
+	return sctpTestChunk(sctpChunkTypeSACK, 0, value)
+}
+
+func sctpTestForwardTSN(cumulative uint32) []byte {
+	return sctpTestChunk(sctpChunkTypeForward, 0, binary.BigEndian.AppendUint32(nil, cumulative))
+}
+
+func TestSCTPReliabilityObserver(t *testing.T) {
+	observer := newSCTPReliabilityObserver(0)
+	assert.Equal(t, DataChannelReliabilityStats{EstimatedRTO: time.Second}, observer.stats(1))
+
+	// A message of two chunks on the stream 1 and one of a chunk on the stream 3, the
+	// TSNs wrap around
+	first := ^uint32(0) - 1
+	observer.sent(sctpTestPacket(
+		sctpTestData(first, 1, 0),
+		sctpTestData(first+1, 1, sctpDataFlagEnd),
+	))
+	observer.sent(sctpTestPacket(sctpTestData(first+2, 3, sctpDataFlagEnd)))
+
+	// The second chunk is lost, the first one is measured
+	time.Sleep(10 * time.Millisecond)
+	observer.received(sctpTestPacket(sctpTestSACK(first, 2, 2)))
+	observer.sent(sctpTestPacket(sctpTestData(first+1, 1, sctpDataFlagEnd)))
+	assert.Equal(t, uint64(1), observer.stats(1).Retransmissions)
+	assert.Zero(t, observer.stats(3).Retransmissions)
+	assert.GreaterOrEqual(t, observer.srtt, 10*time.Millisecond)
+	// The RTO doesn't go below its minimum
+	assert.Equal(t, time.Second, observer.stats(1).EstimatedRTO)
+
+	// Retransmitted again then abandoned, the chunk acknowledged isn't
+	observer.sent(sctpTestPacket(sctpTestData(first+1, 1, sctpDataFlagEnd)))
+	observer.sent(sctpTestPacket(sctpTestForwardTSN(first + 2)))
+	assert.Equal(t, DataChannelReliabilityStats{
+		Retransmissions: 2, MessagesAbandoned: 1, EstimatedRTO: time.Second,
+	}, observer.stats(1))
+	assert.Zero(t, observer.stats(3).MessagesAbandoned)
+	assert.Empty(t, observer.outstanding)
+
+	// The forward TSN sent again counts nothing
+	observer.sent(sctpTestPacket(sctpTestForwardTSN(first + 2)))
+	assert.Equal(t, uint64(1), observer.stats(1).MessagesAbandoned)
+
+	observer.reset(1)
+	assert.Equal(t, DataChannelReliabilityStats{EstimatedRTO: time.Second}, observer.stats(1))
+
+	// Truncated packets are ignored
+	observer.sent([]byte{0, 1, 2})
+	observer.received(sctpTestPacket([]byte{sctpChunkTypeSACK, 0, 0, 40}))
+}
+
+func TestDataChannel_ReliabilityStats(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	// The packets aren't observed by default
+	pc, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	assert.Nil(t, pc.SCTP().reliability)
+	dc, err := pc.CreateDataChannel("default", nil)
+	require.NoError(t, err)
+	assert.Equal(t, DataChannelReliabilityStats{}, dc.ReliabilityStats())
+	assert.NoError(t, pc.Close())
+
+	offerPC, answerPC, wan := createVNetPair(t, nil, func(s *SettingEngine) {
+		s.EnableSCTPReliabilityStats(true)
+	})
+
+	// Keep ICE alive while the data is dropped
+	dropData := &atomic.Bool{}
+	wan.AddChunkFilter(func(c vnet.Chunk) bool {
+		return !dropData.Load() || stun.IsMessage(c.UserData())
+	})
+
+	maxRetransmits := uint16(2)
+	dc, err = offerPC.CreateDataChannel("partial", &DataChannelInit{MaxRetransmits: &maxRetransmits})
+	require.NoError(t, err)
+	assert.Equal(t, DataChannelReliabilityStats{EstimatedRTO: time.Second}, dc.ReliabilityStats())
+
+	opened := make(chan struct{})
+	dc.OnOpen(func() {
+		close(opened)
+	})
+	require.NoError(t, signalPair(offerPC, answerPC))
+	<-opened
+	assert.Eventually(t, func() bool { return dc.BufferedAmount() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	// Sent, retransmitted on timeout, then abandoned
+	dropData.Store(true)
+	require.NoError(t, dc.Send([]byte("lost")))
+	assert.Eventually(t, func() bool {
+		return dc.ReliabilityStats().MessagesAbandoned == 1
+	}, 10*time.Second, 10*time.Millisecond)
+	dropData.Store(false)
+
+	stats := dc.ReliabilityStats()
+	assert.Equal(t, uint64(1), stats.Retransmissions)
+	assert.GreaterOrEqual(t, stats.EstimatedRTO, time.Second)
+
+	assert.NoError(t, wan.Stop())
+	closePairNow(t, offerPC, answerPC)
+}
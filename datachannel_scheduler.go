@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// dataChannelScheduler schedules the writes of WriteContext to the DataChannels of an
+// SCTPTransport by their priority. The SCTP association sends the messages of all its
+// streams in the order they are written, so the writes are only admitted while the
+// association buffers less than dataChannelSchedulerWindow bytes, bounding the wait of
+// the messages of the other DataChannels behind them. The writes waiting for room are
+// admitted by deficit round robin, each DataChannel getting its weight of quantums a
+// round, like the weighted fair queueing scheduler of RFC 8260.
+type dataChannelScheduler struct {
+	mu sync.Mutex
+	// backlog returns the amount of bytes buffered by the association.
+	backlog func() int
+
+	// queues are the DataChannels with waiting writes in the order of the rounds, next
+	// is the one whose turn it is. run is running as long as queues isn't empty.
+	queues  []*dataChannelSchedulerQueue
+	next    int
+	running bool
+}
+
+// dataChannelSchedulerQueue holds the waiting writes to a DataChannel.
+type dataChannelSchedulerQueue struct {
+	channel *DataChannel
+	weight  int
+	// deficit is the amount of bytes the DataChannel may still write in its turn.
+	deficit int
+	inTurn  bool
+	waiting []*dataChannelSchedulerWrite
+}
+
+type dataChannelSchedulerWrite struct {
+	size int
+	// admitted is closed once the write is admitted, written once it is done.
+	admitted, written chan struct{}
+}
+
+func newDataChannelScheduler(backlog func() int) *dataChannelScheduler {
+	return &dataChannelScheduler{backlog: backlog}
+}
+
+// admit blocks until a write of size bytes to channel is admitted, ctx is done or the
+// DataChannel closes. The write is admitted right away if the association has room and
+// no other write waits. The function returned must be called once the write is done.
+func (s *dataChannelScheduler) admit(
+	ctx context.Context,
+	channel *DataChannel,
+	size int,
+) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+
+	s.mu.Lock()
+	if len(s.queues) == 0 && s.backlog() < dataChannelSchedulerWindow {
+		s.mu.Unlock()
+
+		return func() {}, nil
+	}
+
+	write := &dataChannelSchedulerWrite{
+		size:     size,
+		admitted: make(chan struct{}),
+		written:  make(chan struct{}),
+	}
+	s.push(channel, write)
+	if !s.running {
+		s.running = true
+		go s.run()
+	}
+	s.mu.Unlock()
+
+	done := func() {
+		close(write.written)
+	}
+	for {
+		// The channel is taken before the ready state is read, for its change not to
+		// be missed
+		changed := channel.bufferedAmountChangedSignal()
+		err := channel.ensureOpen()
+		if err == nil {
+			select {
+			case <-write.admitted:
+				return done, nil
+			case <-changed:
+				continue
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		}
+
+		if !s.cancel(channel, write) {
+			// Admitted meanwhile, run waits for the write
+			done()
+		}
+
+		return nil, err
+	}
+}
+
+// run admits the waiting writes as the association drains, it returns once none waits.
+// The association doesn't report its releases, its buffered amount is polled.
+func (s *dataChannelScheduler) run() {
+	for {
+		s.mu.Lock()
+		if len(s.queues) == 0 {
+			s.running = false
+			s.mu.Unlock()
+
+			return
+		}
+		if s.backlog() >= dataChannelSchedulerWindow {
+			s.mu.Unlock()
+			time.Sleep(dataChannelSchedulerInterval)
+
+			continue
+		}
+		write := s.pop()
+		s.mu.Unlock()
+
+		// The next write is admitted once this one is buffered by the association
+		close(write.admitted)
+		<-write.written
+	}
+}
+
+// push queues write to channel, it must be called with the lock held.
+func (s *dataChannelScheduler) push(channel *DataChannel, write *dataChannelSchedulerWrite) {
+	for _, queue := range s.queues {
+		if queue.channel == channel {
+			queue.waiting = append(queue.waiting, write)
+
+			return
+		}
+	}
+
+	s.queues = append(s.queues, &dataChannelSchedulerQueue{
+		channel: channel,
+		weight:  channel.Priority().weight(),
+		waiting: []*dataChannelSchedulerWrite{write},
+	})
+}
+
+// pop returns the next write admitted, it must be called with the lock held and a write
+// waiting. Each turn of a DataChannel adds its weight of quantums to its deficit, the
+// turn lasts as long as the deficit covers its next write.
+func (s *dataChannelScheduler) pop() *dataChannelSchedulerWrite {
+	for {
+		if s.next >= len(s.queues) {
+			s.next = 0
+		}
+		queue := s.queues[s.next]
+		if !queue.inTurn {
+			queue.inTurn = true
+			queue.deficit += dataChannelSchedulerQuantum * queue.weight
+		}
+
+		write := queue.waiting[0]
+		if write.size > queue.deficit {
+			queue.inTurn = false
+			s.next++
+
+			continue
+		}
+
+		queue.deficit -= write.size
+		queue.waiting = queue.waiting[1:]
+		if len(queue.waiting) == 0 {
+			s.remove(s.next)
+		}
+
+		return write
+	}
+}
+
+// cancel removes write from the writes waiting, false if it was admitted already.
+func (s *dataChannelScheduler) cancel(channel *DataChannel, write *dataChannelSchedulerWrite) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, queue := range s.queues {
+		if queue.channel != channel {
+			continue
+		}
+
+		for j, waiting := range queue.waiting {
+			if waiting == write {
+				queue.waiting = append(queue.waiting[:j], queue.waiting[j+1:]...)
+				if len(queue.waiting) == 0 {
+					s.remove(i)
+				}
+
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// remove removes the queue i once it has no write waiting, the DataChannel starts over
+// without deficit. It must be called with the lock held.
+func (s *dataChannelScheduler) remove(i int) {
+	s.queues = append(s.queues[:i], s.queues[i+1:]...)
+	if i < s.next {
+		s.next--
+	}
+}
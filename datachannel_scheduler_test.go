@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSchedulerTestChannel(priority DataChannelPriority) *DataChannel {
+	channel := &DataChannel{priority: priority}
+	channel.setReadyState(DataChannelStateOpen)
+
+	return channel
+}
+
+func TestDataChannelScheduler_Pop(t *testing.T) {
+	scheduler := newDataChannelScheduler(func() int { return 0 })
+	veryLow := newSchedulerTestChannel(DataChannelPriorityVeryLow)
+	medium := newSchedulerTestChannel(DataChannelPriorityMedium)
+
+	writes := map[*dataChannelSchedulerWrite]string{}
+	for i := 0; i < 8; i++ {
+		for _, channel := range []*DataChannel{veryLow, medium} {
+			if channel == veryLow && i >= 4 {
+				continue
+			}
+
+			write := &dataChannelSchedulerWrite{size: dataChannelSchedulerQuantum}
+			writes[write] = channel.Priority().String()
+			scheduler.push(channel, write)
+		}
+	}
+
+	// A turn of medium writes 4 quantums, a turn of very-low 1
+	var order []string
+	for len(scheduler.queues) != 0 {
+		order = append(order, writes[scheduler.pop()])
+	}
+	assert.Equal(t, []string{
+		"very-low", "medium", "medium", "medium", "medium",
+		"very-low", "medium", "medium", "medium", "medium",
+		"very-low", "very-low",
+	}, order)
+}
+
+func TestDataChannelScheduler_Admit(t *testing.T) {
+	var backlog atomic.Int64
+	scheduler := newDataChannelScheduler(func() int { return int(backlog.Load()) })
+	channel := newSchedulerTestChannel(DataChannelPriorityLow)
+
+	// Admitted right away while the association has room
+	done, err := scheduler.admit(context.Background(), channel, 1000)
+	require.NoError(t, err)
+	done()
+
+	backlog.Store(dataChannelSchedulerWindow)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	_, err = scheduler.admit(ctx, channel, 1000)
+	cancel()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	admitted := make(chan error)
+	go func() {
+		done, err := scheduler.admit(context.Background(), channel, 1000)
+		if err == nil {
+			done()
+		}
+		admitted <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	backlog.Store(0)
+	assert.NoError(t, <-admitted)
+
+	backlog.Store(dataChannelSchedulerWindow)
+	go func() {
+		_, err := scheduler.admit(context.Background(), channel, 1000)
+		admitted <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	channel.setReadyState(DataChannelStateClosing)
+	assert.ErrorIs(t, <-admitted, io.ErrClosedPipe)
+
+	// run returns once no write waits
+	assert.Eventually(t, func() bool {
+		scheduler.mu.Lock()
+		defer scheduler.mu.Unlock()
+
+		return !scheduler.running
+	}, time.Second, time.Millisecond)
+}
@@ -33,4 +33,8 @@ type DataChannelInit struct {
 
 	// ID overrides the default selection of ID for this channel.
 	ID *uint16
+
+	// Priority is the priority of the channel, DataChannelPriorityLow by default. The
+	// messages written with DataChannel.WriteContext are scheduled by it.
+	Priority *DataChannelPriority
 }
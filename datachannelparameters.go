@@ -12,4 +12,6 @@ type DataChannelParameters struct {
 	MaxPacketLifeTime *uint16 `json:"maxPacketLifeTime"`
 	MaxRetransmits    *uint16 `json:"maxRetransmits"`
 	Negotiated        bool    `json:"negotiated"`
+
+	Priority DataChannelPriority `json:"priority"`
 }
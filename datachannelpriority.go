@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+// DataChannelPriority indicates the priority of a DataChannel, sent to the remote in the
+// DATA_CHANNEL_OPEN message, see RFC 8832. The values are the ones of RFC 8831.
+type DataChannelPriority uint16
+
+const (
+	// DataChannelPriorityVeryLow is the priority "very-low".
+	DataChannelPriorityVeryLow DataChannelPriority = 128
+
+	// DataChannelPriorityLow is the priority "low", the default one.
+	DataChannelPriorityLow DataChannelPriority = 256
+
+	// DataChannelPriorityMedium is the priority "medium".
+	DataChannelPriorityMedium DataChannelPriority = 512
+
+	// DataChannelPriorityHigh is the priority "high".
+	DataChannelPriorityHigh DataChannelPriority = 1024
+)
+
+// This is done this way because of a linter.
+const (
+	dataChannelPriorityVeryLowStr = "very-low"
+	dataChannelPriorityLowStr     = "low"
+	dataChannelPriorityMediumStr  = "medium"
+	dataChannelPriorityHighStr    = "high"
+)
+
+func (p DataChannelPriority) String() string {
+	switch p {
+	case DataChannelPriorityVeryLow:
+		return dataChannelPriorityVeryLowStr
+	case DataChannelPriorityLow:
+		return dataChannelPriorityLowStr
+	case DataChannelPriorityMedium:
+		return dataChannelPriorityMediumStr
+	case DataChannelPriorityHigh:
+		return dataChannelPriorityHighStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// weight returns the share of the SCTP association given to the DataChannels of the
+// priority, doubling with each level, see dataChannelScheduler. The priorities sent by
+// the remote may lie between the levels.
+func (p DataChannelPriority) weight() int {
+	return max(int(p)/int(DataChannelPriorityVeryLow), 1)
+}
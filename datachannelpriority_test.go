@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataChannelPriority_String(t *testing.T) {
+	testCases := []struct {
+		priority       DataChannelPriority
+		expectedString string
+		expectedWeight int
+	}{
+		{DataChannelPriority(0), ErrUnknownType.Error(), 1},
+		{DataChannelPriorityVeryLow, "very-low", 1},
+		{DataChannelPriorityLow, "low", 2},
+		{DataChannelPriorityMedium, "medium", 4},
+		{DataChannelPriorityHigh, "high", 8},
+		{DataChannelPriority(768), ErrUnknownType.Error(), 6},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.priority.String(),
+			"testCase: %d %v", i, testCase,
+		)
+		assert.Equal(t, testCase.expectedWeight, testCase.priority.weight(), "testCase: %d %v", i, testCase)
+	}
+}
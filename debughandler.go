@@ -0,0 +1,521 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/logging"
+)
+
+// debugEvent is an entry of the history of a debugSession.
+type debugEvent struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// debugSession records the recent events of a PeerConnection for DebugHandler.
+type debugSession struct {
+	pc       *PeerConnection
+	sessions *debugSessions
+	created  time.Time
+
+	mu     sync.Mutex
+	closed bool
+	events []debugEvent
+}
+
+func (s *debugSession) record(event PeerConnectionEvent) {
+	if _, ok := event.(SelectedCandidatePairChangeEvent); ok {
+		// Recorded with the reason from SelectedCandidatePairSwitchEvent
+		return
+	}
+	eventType, detail := describePeerConnectionEvent(event)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.events) == debugSessionEventsSize {
+		s.events = append(s.events[:0], s.events[1:]...)
+	}
+	s.events = append(s.events, debugEvent{Time: time.Now(), Type: eventType, Detail: detail})
+}
+
+// debugSessionJSON is a debugSession as listed by DebugHandler.
+type debugSessionJSON struct {
+	ID                    string       `json:"id"`
+	Created               time.Time    `json:"created"`
+	Closed                *time.Time   `json:"closed,omitempty"`
+	SignalingState        string       `json:"signalingState"`
+	ICEConnectionState    string       `json:"iceConnectionState"`
+	ICEGatheringState     string       `json:"iceGatheringState"`
+	ConnectionState       string       `json:"connectionState"`
+	SelectedCandidatePair string       `json:"selectedCandidatePair,omitempty"`
+	Events                []debugEvent `json:"events"`
+}
+
+func (s *debugSession) toJSON() debugSessionJSON {
+	session := debugSessionJSON{
+		ID:                 s.pc.id,
+		Created:            s.created,
+		SignalingState:     s.pc.SignalingState().String(),
+		ICEConnectionState: s.pc.ICEConnectionState().String(),
+		ICEGatheringState:  s.pc.ICEGatheringState().String(),
+		ConnectionState:    s.pc.ConnectionState().String(),
+	}
+	if pair, err := s.pc.iceTransport.GetSelectedCandidatePair(); err == nil && pair != nil {
+		session.SelectedCandidatePair = pair.String()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session.Events = append([]debugEvent{}, s.events...)
+
+	return session
+}
+
+// debugSessions tracks the PeerConnections of an API once a DebugHandler is created.
+// The closed ones are kept as snapshots until debugClosedSessionsSize more are closed,
+// so they don't retain their PeerConnection.
+type debugSessions struct {
+	logs *debugLogs
+
+	mu     sync.Mutex
+	active map[string]*debugSession
+	// closed are the snapshots of the last closed sessions, the oldest first.
+	closed []debugSessionJSON
+}
+
+func newDebugSessions() *debugSessions {
+	return &debugSessions{
+		logs:   &debugLogs{},
+		active: map[string]*debugSession{},
+	}
+}
+
+func (d *debugSessions) add(pc *PeerConnection) *debugSession {
+	if d == nil {
+		return nil
+	}
+
+	session := &debugSession{pc: pc, sessions: d, created: time.Now()}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.active[pc.id] = session
+
+	return session
+}
+
+// close replaces the session by a snapshot, and forgets the oldest closed one.
+func (s *debugSession) close() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+
+		return
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	snapshot := s.toJSON()
+	closed := time.Now()
+	snapshot.Closed = &closed
+
+	d := s.sessions
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.active, s.pc.id)
+	if len(d.closed) == debugClosedSessionsSize {
+		d.closed = append(d.closed[:0], d.closed[1:]...)
+	}
+	d.closed = append(d.closed, snapshot)
+}
+
+// list returns the sessions ordered by creation, the active ones are snapshotted.
+func (d *debugSessions) list() []debugSessionJSON {
+	d.mu.Lock()
+	active := make([]*debugSession, 0, len(d.active))
+	for _, session := range d.active {
+		active = append(active, session)
+	}
+	listed := append(make([]debugSessionJSON, 0, len(active)+len(d.closed)), d.closed...)
+	d.mu.Unlock()
+
+	for _, session := range active {
+		listed = append(listed, session.toJSON())
+	}
+	sort.SliceStable(listed, func(i, j int) bool {
+		return listed[i].Created.Before(listed[j].Created)
+	})
+
+	return listed
+}
+
+func (d *debugSessions) get(id string) *debugSession {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.active[id]
+}
+
+// debugLog is a log line recorded for DebugHandler.
+type debugLog struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Scope   string    `json:"scope"`
+	Message string    `json:"message"`
+}
+
+// debugLogs keeps the last debugLogsSize lines logged at the info level and above.
+type debugLogs struct {
+	mu   sync.Mutex
+	logs []debugLog
+}
+
+func (l *debugLogs) record(level, scope, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.logs) == debugLogsSize {
+		l.logs = append(l.logs[:0], l.logs[1:]...)
+	}
+	l.logs = append(l.logs, debugLog{Time: time.Now(), Level: level, Scope: scope, Message: message})
+}
+
+func (l *debugLogs) list() []debugLog {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]debugLog{}, l.logs...)
+}
+
+// debugLoggerFactory creates loggers that record their lines in debugLogs as well.
+type debugLoggerFactory struct {
+	factory logging.LoggerFactory
+	logs    *debugLogs
+}
+
+func (f *debugLoggerFactory) NewLogger(scope string) logging.LeveledLogger {
+	return &debugLogger{LeveledLogger: f.factory.NewLogger(scope), scope: scope, logs: f.logs}
+}
+
+func (f *debugLoggerFactory) WithAttrs(attrs ...slog.Attr) logging.LoggerFactory {
+	return &debugLoggerFactory{factory: withLogAttrs(f.factory, attrs...), logs: f.logs}
+}
+
+// debugLogger records the lines at the info level and above, the debug and trace
+// ones are only passed on as they are too many to be kept.
+type debugLogger struct {
+	logging.LeveledLogger
+	scope string
+	logs  *debugLogs
+}
+
+func (l *debugLogger) Info(msg string) {
+	l.logs.record("info", l.scope, msg)
+	l.LeveledLogger.Info(msg)
+}
+
+func (l *debugLogger) Infof(format string, args ...any) {
+	l.logs.record("info", l.scope, fmt.Sprintf(format, args...))
+	l.LeveledLogger.Infof(format, args...)
+}
+
+func (l *debugLogger) Warn(msg string) {
+	l.logs.record("warn", l.scope, msg)
+	l.LeveledLogger.Warn(msg)
+}
+
+func (l *debugLogger) Warnf(format string, args ...any) {
+	l.logs.record("warn", l.scope, fmt.Sprintf(format, args...))
+	l.LeveledLogger.Warnf(format, args...)
+}
+
+func (l *debugLogger) Error(msg string) {
+	l.logs.record("error", l.scope, msg)
+	l.LeveledLogger.Error(msg)
+}
+
+func (l *debugLogger) Errorf(format string, args ...any) {
+	l.logs.record("error", l.scope, fmt.Sprintf(format, args...))
+	l.LeveledLogger.Errorf(format, args...)
+}
+
+// enableDebugSessions starts tracking the PeerConnections of the API and recording
+// its logs, it returns the sessions of the first DebugHandler created.
+func (api *API) enableDebugSessions() *debugSessions {
+	sessions := newDebugSessions()
+	if !api.debugSessions.CompareAndSwap(nil, sessions) {
+		return api.debugSessions.Load()
+	}
+	api.settingEngine.LoggerFactory = &debugLoggerFactory{
+		factory: api.settingEngine.LoggerFactory,
+		logs:    sessions.logs,
+	}
+
+	return sessions
+}
+
+// DebugHandler returns an http.Handler serving a page listing the PeerConnections
+// of api, like a chrome://webrtc-internals for the Go process. For each one it shows
+// the states, the selected candidate pair, the recent events and graphs of the
+// bitrates of its transports and RTP streams, polled from GetStats. The page shows
+// the recent lines logged at the info level and above by the API as well.
+//
+// The PeerConnections are only tracked, and the logs recorded, once the handler is
+// created: it must be created before the PeerConnections it should show, and not
+// while PeerConnections are being created, as it wraps the LoggerFactory of the
+// SettingEngine. The closed PeerConnections are shown from a snapshot taken as
+// they closed, until 20 more are closed.
+//
+// The page is served on the path the handler is mounted on, the JSON it is built
+// from on the sub paths "sessions", "sessions/<id>/stats" and "logs". The handler
+// exposes the candidates, the stats and the logs of all the sessions, it must not
+// be reachable by the remote peers.
+func DebugHandler(api *API) http.Handler {
+	sessions := api.enableDebugSessions()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/logs") {
+			serveDebugJSON(w, sessions.logs.list())
+
+			return
+		}
+
+		_, route, isAPI := strings.Cut(r.URL.Path, "/sessions")
+		switch {
+		case !isAPI:
+			serveDebugPage(w, strings.TrimSuffix(r.URL.Path, "/"))
+		case route == "" || route == "/":
+			serveDebugJSON(w, sessions.list())
+		case strings.HasSuffix(route, "/stats"):
+			session := sessions.get(strings.TrimSuffix(strings.TrimPrefix(route, "/"), "/stats"))
+			if session == nil {
+				http.NotFound(w, r)
+
+				return
+			}
+			serveDebugJSON(w, session.pc.GetStats())
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func serveDebugJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func serveDebugPage(w http.ResponseWriter, base string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = debugPage.Execute(w, base)
+}
+
+// describePeerConnectionEvent returns the type and the details of an event for the
+// history of a debugSession.
+func describePeerConnectionEvent(event PeerConnectionEvent) (string, string) { //nolint:cyclop
+	switch event := event.(type) {
+	case SignalingStateChangeEvent:
+		return "signaling-state", event.State.String()
+	case ICEConnectionStateChangeEvent:
+		return "ice-connection-state", event.State.String()
+	case ConnectionStateChangeEvent:
+		if event.Err != nil {
+			return "connection-state", event.State.String() + ": " + event.Err.Error()
+		}
+
+		return "connection-state", event.State.String()
+	case ICEGatheringStateChangeEvent:
+		return "ice-gathering-state", event.State.String()
+	case ICECandidateEvent:
+		if event.Candidate == nil {
+			return "ice-candidate", "end-of-candidates"
+		}
+
+		return "ice-candidate", event.Candidate.String()
+	case SelectedCandidatePairSwitchEvent:
+		return "selected-candidate-pair", fmt.Sprintf("%s (%s)", event.Pair, event.Reason)
+	case ICERemoteCredentialsChangeEvent:
+		return "ice-remote-credentials", fmt.Sprintf("ufrag %s, restarted %t", event.Ufrag, event.Restarted)
+	case NegotiationNeededEvent:
+		return "negotiation-needed", ""
+	case TrackEvent:
+		return "track", fmt.Sprintf("%s %s, ssrc %d", event.Track.Kind(), event.Track.ID(), event.Track.SSRC())
+	case TrackRemovedEvent:
+		return "track-removed", fmt.Sprintf("%s %s, ssrc %d", event.Track.Kind(), event.Track.ID(), event.Track.SSRC())
+	case DataChannelEvent:
+		return "datachannel", event.DataChannel.Label()
+	case SRTPDecryptionFailureEvent:
+		return "srtp-decryption-failure", fmt.Sprintf(
+			"ssrc %d, %d failures in %s, resynced %t", event.SSRC, event.Failures, event.Window, event.Resynced,
+		)
+	case DTLSHandshakeEvent:
+		if event.Stage == DTLSHandshakeStageFailed {
+			return "dtls-handshake", fmt.Sprintf("failed as %s after %s, %d packets received: %v",
+				event.Role, event.Duration, event.PacketsReceived, event.Err)
+		}
+
+		return "dtls-handshake", fmt.Sprintf("%s as %s", event.Stage, event.Role)
+	case NetworkChangeEvent:
+		return "network-change", fmt.Sprintf("added %v, removed %v", event.Added, event.Removed)
+	case BandwidthEstimateEvent:
+		return "bandwidth-estimate", fmt.Sprintf("%d bps", event.TargetBitrate)
+	default:
+		return fmt.Sprintf("%T", event), ""
+	}
+}
+
+var debugPage = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>WebRTC sessions</title>
+<style>
+body { font-family: sans-serif; font-size: 13px; margin: 16px; }
+details { border: 1px solid #ccc; margin-bottom: 8px; padding: 4px 8px; }
+summary { cursor: pointer; font-weight: bold; }
+table { border-collapse: collapse; margin: 4px 0; }
+td, th { border: 1px solid #ddd; padding: 2px 6px; text-align: left; vertical-align: top; }
+.closed summary { color: #888; }
+canvas { border: 1px solid #ddd; margin: 4px 8px 4px 0; }
+</style>
+</head>
+<body>
+<h1>WebRTC sessions</h1>
+<div id="sessions"></div>
+<details id="logs"><summary>Recent logs</summary><div></div></details>
+<script>
+const base = {{.}};
+const samples = {};
+const open = {};
+
+function row(cells, header) {
+  const tr = document.createElement("tr");
+  for (const cell of cells) {
+    const td = document.createElement(header ? "th" : "td");
+    td.textContent = cell;
+    tr.appendChild(td);
+  }
+  return tr;
+}
+
+function table(rows, header) {
+  const t = document.createElement("table");
+  if (header) {
+    t.appendChild(row(header, true));
+  }
+  for (const r of rows) {
+    t.appendChild(row(r));
+  }
+  return t;
+}
+
+// Plots the bitrates of the stats with bytes counters, from the last 60 polls.
+function graphs(id, report) {
+  const history = samples[id] = samples[id] || {};
+  const div = document.createElement("div");
+  for (const stats of Object.values(report)) {
+    const bytes = stats.bytesSent !== undefined ? stats.bytesSent : stats.bytesReceived;
+    if (bytes === undefined) {
+      continue;
+    }
+    const points = history[stats.id] = history[stats.id] || [];
+    points.push({ time: stats.timestamp, bytes: bytes });
+    if (points.length > 60) {
+      points.shift();
+    }
+
+    const rates = [];
+    for (let i = 1; i < points.length; i++) {
+      const seconds = (points[i].time - points[i - 1].time) / 1000;
+      rates.push(seconds > 0 ? (points[i].bytes - points[i - 1].bytes) * 8 / seconds : 0);
+    }
+    const canvas = document.createElement("canvas");
+    canvas.width = 300;
+    canvas.height = 80;
+    const ctx = canvas.getContext("2d");
+    const max = Math.max(1, ...rates);
+    ctx.beginPath();
+    rates.forEach((rate, i) => ctx.lineTo(i * canvas.width / 59, canvas.height - rate / max * (canvas.height - 14)));
+    ctx.stroke();
+    const last = rates.length ? Math.round(rates[rates.length - 1] / 1000) : 0;
+    ctx.fillText(stats.id + ": " + last + " kbps", 2, 10);
+    div.appendChild(canvas);
+  }
+  return div;
+}
+
+async function refresh() {
+  const sessions = await (await fetch(base + "/sessions")).json();
+  const container = document.getElementById("sessions");
+  const elements = [];
+  for (const session of sessions.reverse()) {
+    const details = document.createElement("details");
+    details.open = open[session.id] || false;
+    details.ontoggle = () => { open[session.id] = details.open; };
+    details.className = session.closed ? "closed" : "";
+
+    const summary = document.createElement("summary");
+    summary.textContent = session.id + " - " + session.connectionState;
+    details.appendChild(summary);
+    details.appendChild(table([
+      ["created", session.created],
+      ["closed", session.closed || ""],
+      ["signaling", session.signalingState],
+      ["ICE connection", session.iceConnectionState],
+      ["ICE gathering", session.iceGatheringState],
+      ["connection", session.connectionState],
+      ["selected candidate pair", session.selectedCandidatePair || ""],
+    ]));
+    if (details.open && !session.closed) {
+      const report = await (await fetch(base + "/sessions/" + encodeURIComponent(session.id) + "/stats")).json();
+      details.appendChild(graphs(session.id, report));
+    }
+    details.appendChild(table(session.events.slice().reverse().map(e => [e.time, e.type, e.detail || ""]), ["time", "event", "detail"]));
+    elements.push(details);
+  }
+  container.replaceChildren(...elements);
+
+  const logs = document.getElementById("logs");
+  if (logs.open) {
+    const lines = await (await fetch(base + "/logs")).json();
+    logs.lastElementChild.replaceChildren(table(lines.reverse().map(l => [l.time, l.level, l.scope, l.message]), ["time", "level", "scope", "message"]));
+  }
+}
+
+refresh();
+setInterval(refresh, 1000);
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugHandler(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	api := NewAPI()
+	assert.Nil(t, api.debugSessions.Load())
+	handler := DebugHandler(api)
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+		return recorder
+	}
+	sessions := func() []debugSessionJSON {
+		recorder := get("/debug/webrtc/sessions")
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var listed []debugSessionJSON
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &listed))
+
+		return listed
+	}
+
+	page := get("/debug/webrtc/")
+	assert.Equal(t, http.StatusOK, page.Code)
+	assert.Contains(t, page.Body.String(), `const base = "/debug/webrtc";`)
+	assert.Empty(t, sessions())
+
+	pcOffer, pcAnswer, err := api.newPair(Configuration{})
+	require.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	listed := sessions()
+	require.Len(t, listed, 2)
+	assert.Equal(t, pcOffer.id, listed[0].ID)
+	assert.Equal(t, "connected", listed[0].ConnectionState)
+	assert.Equal(t, "stable", listed[0].SignalingState)
+	assert.NotEmpty(t, listed[0].SelectedCandidatePair)
+	var connectedEvents []debugEvent
+	for _, event := range listed[0].Events {
+		if event.Type == "connection-state" && event.Detail == "connected" {
+			connectedEvents = append(connectedEvents, event)
+		}
+	}
+	assert.Len(t, connectedEvents, 1)
+
+	stats := get("/debug/webrtc/sessions/" + pcAnswer.id + "/stats")
+	assert.Equal(t, http.StatusOK, stats.Code)
+	assert.Contains(t, stats.Body.String(), `"type":"peer-connection"`)
+	assert.Equal(t, http.StatusNotFound, get("/debug/webrtc/sessions/unknown/stats").Code)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/debug/webrtc/sessions", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	closePairNow(t, pcOffer, pcAnswer)
+
+	// The closed sessions are snapshots, not retaining their PeerConnection
+	listed = sessions()
+	require.Len(t, listed, 2)
+	assert.NotNil(t, listed[0].Closed)
+	assert.Equal(t, "closed", listed[0].ConnectionState)
+	assert.Empty(t, api.debugSessions.Load().active)
+	assert.Equal(t, http.StatusNotFound, get("/debug/webrtc/sessions/"+pcAnswer.id+"/stats").Code)
+
+	// The logs of the API are recorded
+	api.settingEngine.LoggerFactory.NewLogger("test").Warnf("disconnected %d", 1)
+	logs := get("/debug/webrtc/logs")
+	require.Equal(t, http.StatusOK, logs.Code)
+	var lines []debugLog
+	require.NoError(t, json.Unmarshal(logs.Body.Bytes(), &lines))
+	require.NotEmpty(t, lines)
+	last := lines[len(lines)-1]
+	assert.Equal(t, "warn", last.Level)
+	assert.Equal(t, "test", last.Scope)
+	assert.Equal(t, "disconnected 1", last.Message)
+}
+
+func TestDebugSessions_Closed(t *testing.T) {
+	api := NewAPI()
+	sessions := api.enableDebugSessions()
+	assert.Same(t, sessions, api.enableDebugSessions())
+
+	var ids []string
+	for i := 0; i < debugClosedSessionsSize+5; i++ {
+		pc, err := api.NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		ids = append(ids, pc.id)
+		require.NoError(t, pc.Close())
+	}
+
+	// Only the last closed sessions are kept
+	listed := sessions.list()
+	require.Len(t, listed, debugClosedSessionsSize)
+	assert.Equal(t, ids[5], listed[0].ID)
+	assert.Equal(t, ids[len(ids)-1], listed[len(listed)-1].ID)
+}
@@ -0,0 +1,515 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"slices"
+)
+
+const (
+	// dependencyDescriptorMandatorySize is the size of the mandatory fields of the
+	// dependency descriptor, the extended ones follow when it is larger.
+	dependencyDescriptorMandatorySize = 3
+	// dependencyDescriptorMaxTemplates is how many templates its 6 bits template IDs address.
+	dependencyDescriptorMaxTemplates = 64
+	// dependencyDescriptorMaxDecodeTargets is how many decode targets its 5 bits count.
+	dependencyDescriptorMaxDecodeTargets = 32
+	// dependencyDescriptorMaxFrameDiff is the largest frame diff of a template, 4 bits
+	// minus one, those of the frames take up to 12 bits.
+	dependencyDescriptorMaxFrameDiff      = 16
+	dependencyDescriptorMaxFrameDiffFrame = 1 << 12
+)
+
+// DecodeTargetIndication tells how a frame relates to a decode target, see the AV1 RTP
+// payload format appendix A.
+type DecodeTargetIndication uint8
+
+const (
+	// DecodeTargetNotPresent indicates the frame isn't part of the decode target.
+	DecodeTargetNotPresent DecodeTargetIndication = iota
+	// DecodeTargetDiscardable indicates the frame is part of the decode target, and no
+	// frame of the decode target depends on it.
+	DecodeTargetDiscardable
+	// DecodeTargetSwitch indicates the decode target can be switched to at the frame.
+	DecodeTargetSwitch
+	// DecodeTargetRequired indicates the frame is part of the decode target, and other
+	// frames of the decode target may depend on it.
+	DecodeTargetRequired
+)
+
+// FrameDependencyTemplate describes the dependencies of a kind of frame: its layers, how
+// it relates to each decode target, the differences between its frame number and those
+// of the frames it references, and those of the previous frames of each chain.
+type FrameDependencyTemplate struct {
+	SpatialID, TemporalID   int
+	DecodeTargetIndications []DecodeTargetIndication
+	FrameDiffs              []int
+	ChainDiffs              []int
+}
+
+// RenderResolution is the resolution a spatial layer is rendered at.
+type RenderResolution struct {
+	Width, Height int
+}
+
+// FrameDependencyStructure is the template dependency structure of a dependency
+// descriptor, sent with the keyframes. The templates are ordered by spatial layer then
+// temporal layer, the layers of a template follow those of the previous template.
+type FrameDependencyStructure struct {
+	TemplateIDOffset  uint8
+	DecodeTargetCount int
+	// ChainCount is the number of chains, DecodeTargetProtectedByChain the chain of each
+	// decode target, set if ChainCount isn't 0.
+	ChainCount                   int
+	DecodeTargetProtectedByChain []int
+	// Resolutions are the render resolutions of each spatial layer, nil if not sent.
+	Resolutions []RenderResolution
+	Templates   []FrameDependencyTemplate
+}
+
+// DependencyDescriptor is the dependency descriptor RTP header extension of the AV1 RTP
+// payload format appendix A, it describes the layers and references of the frame of
+// a packet, so that a forwarder can drop layers without parsing the codec. Its URI is
+// DependencyDescriptorURI, see ConfigureDependencyDescriptorHeaderExtension.
+type DependencyDescriptor struct {
+	StartOfFrame, EndOfFrame bool
+	TemplateID               uint8
+	FrameNumber              uint16
+	// Structure is the template dependency structure carried by the descriptor, nil if
+	// none, the descriptors then refer to the last structure received.
+	Structure *FrameDependencyStructure
+	// ActiveDecodeTargets is the bitmask of the decode targets the sender still sends,
+	// nil if the descriptor carries none.
+	ActiveDecodeTargets *uint32
+	// FrameDependencies are the dependencies of the frame, those of its template unless
+	// the descriptor overrides them. The nil fields are those of the template once marshaled.
+	FrameDependencies FrameDependencyTemplate
+}
+
+// Unmarshal parses the payload of a dependency descriptor header extension. The
+// descriptors without a template structure are parsed with structure, the last one
+// received.
+func (d *DependencyDescriptor) Unmarshal(payload []byte, structure *FrameDependencyStructure) error {
+	if len(payload) < dependencyDescriptorMandatorySize {
+		return errDependencyDescriptorTooShort
+	}
+
+	reader := &dependencyDescriptorReader{data: payload}
+	*d = DependencyDescriptor{
+		StartOfFrame: reader.readBool(),
+		EndOfFrame:   reader.readBool(),
+		TemplateID:   uint8(reader.read(6)),   //nolint:gosec // G115, 6 bits
+		FrameNumber:  uint16(reader.read(16)), //nolint:gosec // G115, 16 bits
+	}
+
+	var customDTIs, customFrameDiffs, customChains bool
+	if len(payload) > dependencyDescriptorMandatorySize {
+		structurePresent, activeDecodeTargetsPresent := reader.readBool(), reader.readBool()
+		customDTIs, customFrameDiffs, customChains = reader.readBool(), reader.readBool(), reader.readBool()
+		if structurePresent {
+			d.Structure = reader.readStructure()
+			if reader.err {
+				return errDependencyDescriptorTooShort
+			}
+			structure = d.Structure
+		}
+		if activeDecodeTargetsPresent {
+			if structure == nil {
+				return errDependencyDescriptorNoStructure
+			}
+			activeDecodeTargets := reader.read(structure.DecodeTargetCount)
+			d.ActiveDecodeTargets = &activeDecodeTargets
+		}
+	}
+
+	template, err := d.template(structure)
+	if err != nil {
+		return err
+	}
+
+	dependencies := FrameDependencyTemplate{SpatialID: template.SpatialID, TemporalID: template.TemporalID}
+	if customDTIs {
+		for i := 0; i < structure.DecodeTargetCount; i++ {
+			dependencies.DecodeTargetIndications = append(
+				dependencies.DecodeTargetIndications, DecodeTargetIndication(reader.read(2)),
+			)
+		}
+	} else {
+		dependencies.DecodeTargetIndications = slices.Clone(template.DecodeTargetIndications)
+	}
+	if customFrameDiffs {
+		for size := reader.read(2); size != 0 && !reader.err; size = reader.read(2) {
+			dependencies.FrameDiffs = append(dependencies.FrameDiffs, int(reader.read(4*int(size)))+1)
+		}
+	} else {
+		dependencies.FrameDiffs = slices.Clone(template.FrameDiffs)
+	}
+	if customChains {
+		for i := 0; i < structure.ChainCount; i++ {
+			dependencies.ChainDiffs = append(dependencies.ChainDiffs, int(reader.read(8)))
+		}
+	} else {
+		dependencies.ChainDiffs = slices.Clone(template.ChainDiffs)
+	}
+	if reader.err {
+		return errDependencyDescriptorTooShort
+	}
+	d.FrameDependencies = dependencies
+
+	return nil
+}
+
+// Marshal returns the payload of the dependency descriptor header extension. The
+// descriptors without a template structure are marshaled with structure, the last one
+// sent.
+func (d *DependencyDescriptor) Marshal(structure *FrameDependencyStructure) ([]byte, error) { //nolint:cyclop
+	if d.Structure != nil {
+		if err := d.Structure.validate(); err != nil {
+			return nil, err
+		}
+		structure = d.Structure
+	}
+	template, err := d.template(structure)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies := d.FrameDependencies
+	customDTIs := dependencies.DecodeTargetIndications != nil &&
+		!slices.Equal(dependencies.DecodeTargetIndications, template.DecodeTargetIndications)
+	customFrameDiffs := dependencies.FrameDiffs != nil && !slices.Equal(dependencies.FrameDiffs, template.FrameDiffs)
+	customChains := dependencies.ChainDiffs != nil && !slices.Equal(dependencies.ChainDiffs, template.ChainDiffs)
+	if (customDTIs && len(dependencies.DecodeTargetIndications) != structure.DecodeTargetCount) ||
+		(customChains && len(dependencies.ChainDiffs) != structure.ChainCount) {
+		return nil, errDependencyDescriptorInvalid
+	}
+
+	writer := &dependencyDescriptorWriter{}
+	writer.writeBool(d.StartOfFrame)
+	writer.writeBool(d.EndOfFrame)
+	writer.write(6, uint32(d.TemplateID))
+	writer.write(16, uint32(d.FrameNumber))
+	if d.Structure == nil && d.ActiveDecodeTargets == nil && !customDTIs && !customFrameDiffs && !customChains {
+		return writer.data, nil
+	}
+
+	writer.writeBool(d.Structure != nil)
+	writer.writeBool(d.ActiveDecodeTargets != nil)
+	writer.writeBool(customDTIs)
+	writer.writeBool(customFrameDiffs)
+	writer.writeBool(customChains)
+	if d.Structure != nil {
+		writer.writeStructure(d.Structure)
+	}
+	if d.ActiveDecodeTargets != nil {
+		writer.write(structure.DecodeTargetCount, *d.ActiveDecodeTargets)
+	}
+
+	if customDTIs {
+		for _, indication := range dependencies.DecodeTargetIndications {
+			writer.write(2, uint32(indication))
+		}
+	}
+	if customFrameDiffs {
+		for _, diff := range dependencies.FrameDiffs {
+			if diff < 1 || diff > dependencyDescriptorMaxFrameDiffFrame {
+				return nil, errDependencyDescriptorInvalid
+			}
+			// The diff minus one takes 4, 8 or 12 bits
+			size := 1
+			for diff-1 >= 1<<(4*size) {
+				size++
+			}
+			writer.write(2, uint32(size))        //nolint:gosec // G115, at most 3
+			writer.write(4*size, uint32(diff-1)) //nolint:gosec // G115, checked above
+		}
+		writer.write(2, 0)
+	}
+	if customChains {
+		for _, diff := range dependencies.ChainDiffs {
+			if diff < 0 || diff > 0xFF {
+				return nil, errDependencyDescriptorInvalid
+			}
+			writer.write(8, uint32(diff))
+		}
+	}
+
+	return writer.data, nil
+}
+
+// template returns the template of the descriptor in structure.
+func (d *DependencyDescriptor) template(structure *FrameDependencyStructure) (FrameDependencyTemplate, error) {
+	if structure == nil {
+		return FrameDependencyTemplate{}, errDependencyDescriptorNoStructure
+	}
+
+	index := (int(d.TemplateID) + dependencyDescriptorMaxTemplates - int(structure.TemplateIDOffset)) %
+		dependencyDescriptorMaxTemplates
+	if index >= len(structure.Templates) {
+		return FrameDependencyTemplate{}, errDependencyDescriptorUnknownTemplate
+	}
+
+	return structure.Templates[index], nil
+}
+
+// validate checks that the structure can be marshaled.
+func (s *FrameDependencyStructure) validate() error { //nolint:cyclop
+	if s.TemplateIDOffset >= dependencyDescriptorMaxTemplates ||
+		s.DecodeTargetCount < 1 || s.DecodeTargetCount > dependencyDescriptorMaxDecodeTargets ||
+		s.ChainCount < 0 || s.ChainCount > s.DecodeTargetCount ||
+		len(s.Templates) == 0 || len(s.Templates) > dependencyDescriptorMaxTemplates {
+		return errDependencyStructureInvalid
+	}
+	if s.ChainCount != 0 && len(s.DecodeTargetProtectedByChain) != s.DecodeTargetCount {
+		return errDependencyStructureInvalid
+	}
+	for _, chain := range s.DecodeTargetProtectedByChain {
+		if s.ChainCount != 0 && (chain < 0 || chain >= s.ChainCount) {
+			return errDependencyStructureInvalid
+		}
+	}
+
+	for i, template := range s.Templates {
+		if _, ok := nextLayerIndication(s.Templates, i); !ok ||
+			len(template.DecodeTargetIndications) != s.DecodeTargetCount ||
+			len(template.ChainDiffs) != s.ChainCount {
+			return errDependencyStructureInvalid
+		}
+		for _, diff := range template.FrameDiffs {
+			if diff < 1 || diff > dependencyDescriptorMaxFrameDiff {
+				return errDependencyStructureInvalid
+			}
+		}
+		for _, diff := range template.ChainDiffs {
+			if diff < 0 || diff > 0xF {
+				return errDependencyStructureInvalid
+			}
+		}
+	}
+
+	if s.Resolutions != nil && len(s.Resolutions) != s.Templates[len(s.Templates)-1].SpatialID+1 {
+		return errDependencyStructureInvalid
+	}
+	for _, resolution := range s.Resolutions {
+		if resolution.Width < 1 || resolution.Width > 1<<16 || resolution.Height < 1 || resolution.Height > 1<<16 {
+			return errDependencyStructureInvalid
+		}
+	}
+
+	return nil
+}
+
+// nextLayerIndication returns the next_layer_idc following the template i: 0 if the
+// next template has the same layers, 1 the next temporal layer, 2 the next spatial
+// layer, 3 if it is the last template. It returns false if the templates aren't ordered.
+func nextLayerIndication(templates []FrameDependencyTemplate, i int) (uint32, bool) {
+	current := templates[i]
+	if current.SpatialID < 0 || current.TemporalID < 0 || (i == 0 && (current.SpatialID != 0 || current.TemporalID != 0)) {
+		return 0, false
+	}
+	if i == len(templates)-1 {
+		return 3, true
+	}
+
+	switch next := templates[i+1]; {
+	case next.SpatialID == current.SpatialID && next.TemporalID == current.TemporalID:
+		return 0, true
+	case next.SpatialID == current.SpatialID && next.TemporalID == current.TemporalID+1:
+		return 1, true
+	case next.SpatialID == current.SpatialID+1 && next.TemporalID == 0:
+		return 2, true
+	default:
+		return 0, false
+	}
+}
+
+// dependencyDescriptorReader reads the bits of a dependency descriptor, most significant first.
+type dependencyDescriptorReader struct {
+	data []byte
+	pos  int
+	// err is set once reading past the end.
+	err bool
+}
+
+func (r *dependencyDescriptorReader) read(bits int) uint32 {
+	var value uint32
+	for i := 0; i < bits; i++ {
+		if r.pos >= len(r.data)*8 {
+			r.err = true
+
+			return 0
+		}
+		value = value<<1 | uint32(r.data[r.pos/8]>>(7-r.pos%8)&1)
+		r.pos++
+	}
+
+	return value
+}
+
+func (r *dependencyDescriptorReader) readBool() bool {
+	return r.read(1) == 1
+}
+
+// readNonSymmetric reads a value below n, the ns(n) of the AV1 specification section 4.10.7.
+func (r *dependencyDescriptorReader) readNonSymmetric(n int) int {
+	width := 0
+	for x := n; x != 0; x >>= 1 {
+		width++
+	}
+	m := 1<<width - n
+	value := int(r.read(width - 1))
+	if value < m {
+		return value
+	}
+
+	return value<<1 - m + int(r.read(1))
+}
+
+// readStructure reads a template dependency structure.
+func (r *dependencyDescriptorReader) readStructure() *FrameDependencyStructure {
+	structure := &FrameDependencyStructure{
+		TemplateIDOffset:  uint8(r.read(6)), //nolint:gosec // G115, 6 bits
+		DecodeTargetCount: int(r.read(5)) + 1,
+	}
+
+	template := FrameDependencyTemplate{}
+	for !r.err {
+		structure.Templates = append(structure.Templates, template)
+		nextLayer := r.read(2)
+		if nextLayer == 3 {
+			break
+		}
+		if len(structure.Templates) == dependencyDescriptorMaxTemplates {
+			r.err = true
+		}
+		switch nextLayer {
+		case 1:
+			template.TemporalID++
+		case 2:
+			template = FrameDependencyTemplate{SpatialID: template.SpatialID + 1}
+		}
+	}
+
+	for i := range structure.Templates {
+		for j := 0; j < structure.DecodeTargetCount; j++ {
+			structure.Templates[i].DecodeTargetIndications = append(
+				structure.Templates[i].DecodeTargetIndications, DecodeTargetIndication(r.read(2)),
+			)
+		}
+	}
+	for i := range structure.Templates {
+		for r.readBool() {
+			structure.Templates[i].FrameDiffs = append(structure.Templates[i].FrameDiffs, int(r.read(4))+1)
+		}
+	}
+
+	structure.ChainCount = r.readNonSymmetric(structure.DecodeTargetCount + 1)
+	if structure.ChainCount != 0 {
+		for i := 0; i < structure.DecodeTargetCount; i++ {
+			structure.DecodeTargetProtectedByChain = append(
+				structure.DecodeTargetProtectedByChain, r.readNonSymmetric(structure.ChainCount),
+			)
+		}
+		for i := range structure.Templates {
+			for j := 0; j < structure.ChainCount; j++ {
+				structure.Templates[i].ChainDiffs = append(structure.Templates[i].ChainDiffs, int(r.read(4)))
+			}
+		}
+	}
+
+	if r.readBool() {
+		for spatialID := 0; spatialID <= structure.Templates[len(structure.Templates)-1].SpatialID; spatialID++ {
+			structure.Resolutions = append(structure.Resolutions, RenderResolution{
+				Width:  int(r.read(16)) + 1,
+				Height: int(r.read(16)) + 1,
+			})
+		}
+	}
+
+	return structure
+}
+
+// dependencyDescriptorWriter writes the bits of a dependency descriptor, zero padded.
+type dependencyDescriptorWriter struct {
+	data []byte
+	pos  int
+}
+
+func (w *dependencyDescriptorWriter) write(bits int, value uint32) {
+	for i := bits - 1; i >= 0; i-- {
+		if w.pos%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[len(w.data)-1] |= byte(value>>i&1) << (7 - w.pos%8)
+		w.pos++
+	}
+}
+
+func (w *dependencyDescriptorWriter) writeBool(value bool) {
+	if value {
+		w.write(1, 1)
+	} else {
+		w.write(1, 0)
+	}
+}
+
+// writeNonSymmetric writes a value below n, the ns(n) of the AV1 specification.
+func (w *dependencyDescriptorWriter) writeNonSymmetric(n, value int) {
+	width := 0
+	for x := n; x != 0; x >>= 1 {
+		width++
+	}
+	m := 1<<width - n
+	if value < m {
+		w.write(width-1, uint32(value)) //nolint:gosec // G115, below n
+
+		return
+	}
+	w.write(width-1, uint32((value+m)>>1)) //nolint:gosec // G115, below n
+	w.write(1, uint32((value+m)&1))        //nolint:gosec // G115, a bit
+}
+
+// writeStructure writes a template dependency structure, validated.
+func (w *dependencyDescriptorWriter) writeStructure(structure *FrameDependencyStructure) {
+	w.write(6, uint32(structure.TemplateIDOffset))
+	w.write(5, uint32(structure.DecodeTargetCount-1)) //nolint:gosec // G115, validated
+
+	for i := range structure.Templates {
+		nextLayer, _ := nextLayerIndication(structure.Templates, i)
+		w.write(2, nextLayer)
+	}
+	for _, template := range structure.Templates {
+		for _, indication := range template.DecodeTargetIndications {
+			w.write(2, uint32(indication))
+		}
+	}
+	for _, template := range structure.Templates {
+		for _, diff := range template.FrameDiffs {
+			w.writeBool(true)
+			w.write(4, uint32(diff-1)) //nolint:gosec // G115, validated
+		}
+		w.writeBool(false)
+	}
+
+	w.writeNonSymmetric(structure.DecodeTargetCount+1, structure.ChainCount)
+	if structure.ChainCount != 0 {
+		for _, chain := range structure.DecodeTargetProtectedByChain {
+			w.writeNonSymmetric(structure.ChainCount, chain)
+		}
+		for _, template := range structure.Templates {
+			for _, diff := range template.ChainDiffs {
+				w.write(4, uint32(diff)) //nolint:gosec // G115, validated
+			}
+		}
+	}
+
+	w.writeBool(structure.Resolutions != nil)
+	for _, resolution := range structure.Resolutions {
+		w.write(16, uint32(resolution.Width-1))  //nolint:gosec // G115, validated
+		w.write(16, uint32(resolution.Height-1)) //nolint:gosec // G115, validated
+	}
+}
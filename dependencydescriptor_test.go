@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newL2T2DependencyStructure returns the structure of a L2T2 stream, a decode target and
+// a chain per spatial layer and temporal layer.
+func newL2T2DependencyStructure() *FrameDependencyStructure {
+	s, d, r := DecodeTargetSwitch, DecodeTargetDiscardable, DecodeTargetRequired
+	n := DecodeTargetNotPresent
+
+	return &FrameDependencyStructure{
+		TemplateIDOffset:             60,
+		DecodeTargetCount:            4,
+		ChainCount:                   2,
+		DecodeTargetProtectedByChain: []int{0, 0, 1, 1},
+		Resolutions:                  []RenderResolution{{Width: 320, Height: 180}, {Width: 640, Height: 360}},
+		Templates: []FrameDependencyTemplate{
+			{
+				SpatialID: 0, TemporalID: 0, DecodeTargetIndications: []DecodeTargetIndication{s, s, s, s},
+				ChainDiffs: []int{0, 0},
+			},
+			{
+				SpatialID: 0, TemporalID: 0, DecodeTargetIndications: []DecodeTargetIndication{s, s, r, r},
+				FrameDiffs: []int{4}, ChainDiffs: []int{4, 3},
+			},
+			{
+				SpatialID: 0, TemporalID: 1, DecodeTargetIndications: []DecodeTargetIndication{n, d, n, r},
+				FrameDiffs: []int{2}, ChainDiffs: []int{2, 1},
+			},
+			{
+				SpatialID: 1, TemporalID: 0, DecodeTargetIndications: []DecodeTargetIndication{n, n, s, s},
+				FrameDiffs: []int{1}, ChainDiffs: []int{1, 1},
+			},
+			{
+				SpatialID: 1, TemporalID: 1, DecodeTargetIndications: []DecodeTargetIndication{n, n, n, d},
+				FrameDiffs: []int{2, 1}, ChainDiffs: []int{3, 2},
+			},
+		},
+	}
+}
+
+func TestDependencyDescriptor(t *testing.T) {
+	structure := newL2T2DependencyStructure()
+	activeDecodeTargets := uint32(0b0011)
+
+	for _, descriptor := range []DependencyDescriptor{
+		// A keyframe carrying the structure
+		{StartOfFrame: true, TemplateID: 60, FrameNumber: 100, Structure: structure},
+		{StartOfFrame: true, EndOfFrame: true, TemplateID: 61, FrameNumber: 104},
+		{EndOfFrame: true, TemplateID: 0, FrameNumber: 65535, ActiveDecodeTargets: &activeDecodeTargets},
+		// Custom dependencies
+		{TemplateID: 63, FrameNumber: 7, FrameDependencies: FrameDependencyTemplate{
+			DecodeTargetIndications: []DecodeTargetIndication{
+				DecodeTargetNotPresent, DecodeTargetNotPresent, DecodeTargetRequired, DecodeTargetDiscardable,
+			},
+			FrameDiffs: []int{1, 17, 300, 4096},
+			ChainDiffs: []int{255, 0},
+		}},
+	} {
+		payload, err := descriptor.Marshal(structure)
+		require.NoError(t, err)
+
+		parsed := DependencyDescriptor{}
+		require.NoError(t, parsed.Unmarshal(payload, structure))
+
+		template, err := descriptor.template(structure)
+		require.NoError(t, err)
+		expected := descriptor
+		expected.FrameDependencies.SpatialID, expected.FrameDependencies.TemporalID = template.SpatialID, template.TemporalID
+		if expected.FrameDependencies.DecodeTargetIndications == nil {
+			expected.FrameDependencies.DecodeTargetIndications = template.DecodeTargetIndications
+		}
+		if expected.FrameDependencies.FrameDiffs == nil {
+			expected.FrameDependencies.FrameDiffs = template.FrameDiffs
+		}
+		if expected.FrameDependencies.ChainDiffs == nil {
+			expected.FrameDependencies.ChainDiffs = template.ChainDiffs
+		}
+		assert.Equal(t, expected, parsed)
+
+		// The dependencies parsed are marshaled the same
+		remarshaled, err := parsed.Marshal(structure)
+		require.NoError(t, err)
+		assert.Equal(t, payload, remarshaled)
+	}
+
+	// Only the mandatory fields of the frames using their template
+	payload, err := (&DependencyDescriptor{StartOfFrame: true, TemplateID: 62, FrameNumber: 0x1234}).Marshal(structure)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x80 | 62, 0x12, 0x34}, payload)
+
+	parsed := DependencyDescriptor{}
+	assert.Equal(t, errDependencyDescriptorTooShort, parsed.Unmarshal(payload[:2], structure))
+	assert.Equal(t, errDependencyDescriptorNoStructure, parsed.Unmarshal(payload, nil))
+	assert.Equal(t, errDependencyDescriptorUnknownTemplate, parsed.Unmarshal([]byte{10, 0, 0}, structure))
+
+	_, err = (&DependencyDescriptor{TemplateID: 60, FrameDependencies: FrameDependencyTemplate{
+		DecodeTargetIndications: []DecodeTargetIndication{DecodeTargetRequired},
+	}}).Marshal(structure)
+	assert.Equal(t, errDependencyDescriptorInvalid, err)
+
+	// The templates must follow each other
+	invalid := newL2T2DependencyStructure()
+	invalid.Templates[3].TemporalID = 1
+	_, err = (&DependencyDescriptor{TemplateID: 60, Structure: invalid}).Marshal(nil)
+	assert.Equal(t, errDependencyStructureInvalid, err)
+}
+
+func TestDependencyDescriptor_NonSymmetric(t *testing.T) {
+	for n := 1; n <= 33; n++ {
+		for value := 0; value < n; value++ {
+			writer := &dependencyDescriptorWriter{}
+			writer.writeNonSymmetric(n, value)
+			reader := &dependencyDescriptorReader{data: writer.data}
+			assert.Equal(t, value, reader.readNonSymmetric(n), "ns(%d)", n)
+			assert.Equal(t, writer.pos, reader.pos, "ns(%d)", n)
+		}
+	}
+}
+
+func TestPeerConnection_DependencyDescriptor(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newPeerConnection := func() *PeerConnection {
+		mediaEngine := &MediaEngine{}
+		require.NoError(t, mediaEngine.RegisterDefaultCodecs())
+		require.NoError(t, ConfigureDependencyDescriptorHeaderExtension(mediaEngine))
+
+		pc, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		return pc
+	}
+	pcOffer, pcAnswer := newPeerConnection(), newPeerConnection()
+
+	track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeAV1}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	structure := newL2T2DependencyStructure()
+	keyframe, err := (&DependencyDescriptor{
+		StartOfFrame: true, EndOfFrame: true, TemplateID: 60, Structure: structure,
+	}).Marshal(nil)
+	require.NoError(t, err)
+	delta, err := (&DependencyDescriptor{StartOfFrame: true, EndOfFrame: true, TemplateID: 62}).Marshal(structure)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		for {
+			_, attributes, readErr := track.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			descriptor, ok := attributes.Get(AttributeDependencyDescriptor).(*DependencyDescriptor)
+			if !ok || descriptor.Structure != nil {
+				continue
+			}
+			assert.Equal(t, 1, descriptor.FrameDependencies.TemporalID)
+			assert.Equal(t, structure, track.DependencyStructure())
+			cancel()
+
+			return
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	var sequenceNumber uint16
+	for {
+		select {
+		case <-time.After(20 * time.Millisecond):
+			for _, descriptor := range [][]byte{keyframe, delta} {
+				sequenceNumber++
+				assert.NoError(t, track.WriteRTPWithDependencyDescriptor(&rtp.Packet{
+					Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber, Marker: true},
+					Payload: []byte{0x00},
+				}, descriptor))
+			}
+		case <-ctx.Done():
+			closePairNow(t, pcOffer, pcAnswer)
+
+			return
+		}
+	}
+}
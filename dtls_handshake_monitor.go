@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/dtls/v3"
+	"github.com/pion/dtls/v3/pkg/protocol"
+	"github.com/pion/dtls/v3/pkg/protocol/alert"
+	"github.com/pion/srtp/v3"
+)
+
+// The DTLS record header, RFC 6347 section 4.1.
+const (
+	dtlsRecordHeaderSize   = 13
+	dtlsRecordEpochOffset  = 3
+	dtlsRecordLengthOffset = 11
+	dtlsAlertSize          = 2
+)
+
+// DTLSHandshake describes a stage of the DTLS handshake of a DTLSTransport, see
+// DTLSTransport.OnHandshake.
+type DTLSHandshake struct {
+	Stage DTLSHandshakeStage
+	Role  DTLSRole
+	// CipherSuite and SRTPProtectionProfile are the ones negotiated, set once completed.
+	CipherSuite           dtls.CipherSuiteID
+	SRTPProtectionProfile srtp.ProtectionProfile
+	// Err is the cause of the failure.
+	Err error
+	// LocalAlert is the last alert sent to the remote, RemoteAlert the last one received
+	// from it, nil if none. A remote rejecting the certificate sends a bad_certificate
+	// alert for instance. The alerts encrypted, sent once the keys are changed, aren't
+	// decoded.
+	LocalAlert, RemoteAlert *alert.Alert
+	// PacketsSent and PacketsReceived count the DTLS datagrams of the handshake, the
+	// retransmissions included. None received means the packets of the remote never
+	// arrived.
+	PacketsSent, PacketsReceived int
+	// Duration is the time since the handshake started.
+	Duration time.Duration
+}
+
+// dtlsHandshakeMonitor sits between the DTLS endpoint and the DTLS conn, it counts the
+// packets of the handshake and decodes the alerts exchanged until it is done.
+type dtlsHandshakeMonitor struct {
+	net.PacketConn
+
+	role    DTLSRole
+	started time.Time
+	done    atomic.Bool
+
+	mu                      sync.Mutex
+	sent, received          int
+	localAlert, remoteAlert *alert.Alert
+}
+
+func newDTLSHandshakeMonitor(conn net.PacketConn, role DTLSRole) *dtlsHandshakeMonitor {
+	return &dtlsHandshakeMonitor{PacketConn: conn, role: role, started: time.Now()}
+}
+
+func (m *dtlsHandshakeMonitor) ReadFrom(p []byte) (int, net.Addr, error) {
+	n, addr, err := m.PacketConn.ReadFrom(p)
+	if n > 0 && !m.done.Load() {
+		m.observe(p[:n], false)
+	}
+
+	return n, addr, err
+}
+
+func (m *dtlsHandshakeMonitor) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if !m.done.Load() {
+		m.observe(p, true)
+	}
+
+	return m.PacketConn.WriteTo(p, addr)
+}
+
+func (m *dtlsHandshakeMonitor) observe(packet []byte, sent bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if sent {
+		m.sent++
+	} else {
+		m.received++
+	}
+
+	// A datagram may carry several records
+	for len(packet) >= dtlsRecordHeaderSize {
+		length := int(binary.BigEndian.Uint16(packet[dtlsRecordLengthOffset:]))
+		if len(packet) < dtlsRecordHeaderSize+length {
+			return
+		}
+
+		epoch := binary.BigEndian.Uint16(packet[dtlsRecordEpochOffset:])
+		if protocol.ContentType(packet[0]) == protocol.ContentTypeAlert && epoch == 0 && length == dtlsAlertSize {
+			a := &alert.Alert{}
+			if err := a.Unmarshal(packet[dtlsRecordHeaderSize : dtlsRecordHeaderSize+length]); err == nil {
+				if sent {
+					m.localAlert = a
+				} else {
+					m.remoteAlert = a
+				}
+			}
+		}
+		packet = packet[dtlsRecordHeaderSize+length:]
+	}
+}
+
+// finish stops the monitoring and returns the stage reached by the handshake.
+func (m *dtlsHandshakeMonitor) finish(stage DTLSHandshakeStage, err error) DTLSHandshake {
+	m.done.Store(true)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return DTLSHandshake{
+		Stage:           stage,
+		Role:            m.role,
+		Err:             err,
+		LocalAlert:      m.localAlert,
+		RemoteAlert:     m.remoteAlert,
+		PacketsSent:     m.sent,
+		PacketsReceived: m.received,
+		Duration:        time.Since(m.started),
+	}
+}
@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+// DTLSHandshakeStage indicates the stage of the DTLS handshake reported by
+// DTLSTransport.OnHandshake.
+type DTLSHandshakeStage int
+
+const (
+	// DTLSHandshakeStageUnknown is the enum's zero-value.
+	DTLSHandshakeStageUnknown DTLSHandshakeStage = iota
+
+	// DTLSHandshakeStageStarted indicates that the handshake started.
+	DTLSHandshakeStageStarted
+
+	// DTLSHandshakeStageCompleted indicates that the handshake completed and the
+	// certificate of the remote was verified.
+	DTLSHandshakeStageCompleted
+
+	// DTLSHandshakeStageFailed indicates that the handshake failed.
+	DTLSHandshakeStageFailed
+)
+
+// This is done this way because of a linter.
+const (
+	dtlsHandshakeStageStartedStr   = "started"
+	dtlsHandshakeStageCompletedStr = "completed"
+	dtlsHandshakeStageFailedStr    = "failed"
+)
+
+func (s DTLSHandshakeStage) String() string {
+	switch s {
+	case DTLSHandshakeStageStarted:
+		return dtlsHandshakeStageStartedStr
+	case DTLSHandshakeStageCompleted:
+		return dtlsHandshakeStageCompletedStr
+	case DTLSHandshakeStageFailed:
+		return dtlsHandshakeStageFailedStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDTLSHandshakeStage_String(t *testing.T) {
+	testCases := []struct {
+		stage          DTLSHandshakeStage
+		expectedString string
+	}{
+		{DTLSHandshakeStageUnknown, ErrUnknownType.Error()},
+		{DTLSHandshakeStageStarted, "started"},
+		{DTLSHandshakeStageCompleted, "completed"},
+		{DTLSHandshakeStageFailed, "failed"},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.stage.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -42,10 +44,17 @@ type DTLSTransport struct {
 	remoteParameters      DTLSParameters
 	remoteCertificate     []byte
 	state                 DTLSTransportState
+	failedErr             error
 	srtpProtectionProfile srtp.ProtectionProfile
+	srtpKeys              *srtp.SessionKeys
 
-	onStateChangeHandler   func(DTLSTransportState)
-	internalOnCloseHandler func()
+	onStateChangeHandler           func(DTLSTransportState)
+	onSRTPDecryptionFailureHandler func(SRTPDecryptionFailure)
+	onHandshakeHandler             func(DTLSHandshake)
+	internalOnCloseHandler         func()
+
+	internalOnSRTPDecryptionFailureHandler func(SRTPDecryptionFailure)
+	internalOnHandshakeHandler             func(DTLSHandshake)
 
 	conn *dtls.Conn
 
@@ -53,6 +62,8 @@ type DTLSTransport struct {
 	srtpEndpoint, srtcpEndpoint *mux.Endpoint
 	simulcastStreams            []simulcastStreamPair
 	srtpReady                   chan struct{}
+	pacer                       *pacer
+	pipelineMetrics             *pipelineMetrics
 
 	dtlsMatcher mux.MatchFunc
 
@@ -75,7 +86,11 @@ func (api *API) NewDTLSTransport(transport *ICETransport, certificates []Certifi
 		state:        DTLSTransportStateNew,
 		dtlsMatcher:  mux.MatchDTLS,
 		srtpReady:    make(chan struct{}),
-		log:          api.settingEngine.LoggerFactory.NewLogger("DTLSTransport"),
+		pacer:        newPacer(api.settingEngine.pacing.bitrate, api.settingEngine.pacing.burst),
+		log:          api.loggerFactory().NewLogger("DTLSTransport"),
+	}
+	if api.settingEngine.pipelineMetrics {
+		trans.pipelineMetrics = newPipelineMetrics()
 	}
 
 	if len(certificates) > 0 {
@@ -119,6 +134,23 @@ func (t *DTLSTransport) onStateChange(state DTLSTransportState) {
 	}
 }
 
+// fail moves the transport to the failed state because of err, and returns err.
+// It requires the caller holds the lock.
+func (t *DTLSTransport) fail(err error) error {
+	t.failedErr = err
+	t.onStateChange(DTLSTransportStateFailed)
+
+	return err
+}
+
+// getFailedErr returns the error the transport failed with.
+func (t *DTLSTransport) getFailedErr() error {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.failedErr
+}
+
 // OnStateChange sets a handler that is fired when the DTLS
 // connection state changes.
 func (t *DTLSTransport) OnStateChange(f func(DTLSTransportState)) {
@@ -127,6 +159,61 @@ func (t *DTLSTransport) OnStateChange(f func(DTLSTransportState)) {
 	t.onStateChangeHandler = f
 }
 
+// OnSRTPDecryptionFailure sets a handler that is fired when many incoming packets of an SSRC
+// fail to decrypt in a short time, see SettingEngine.SetSRTPDecryptionFailureThreshold.
+// An attempt to re-sync the rollover counter of the SSRC is made before firing it.
+func (t *DTLSTransport) OnSRTPDecryptionFailure(f func(SRTPDecryptionFailure)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.onSRTPDecryptionFailureHandler = f
+}
+
+func (t *DTLSTransport) onSRTPDecryptionFailure(failure SRTPDecryptionFailure) {
+	t.lock.RLock()
+	handler := t.onSRTPDecryptionFailureHandler
+	internalHandler := t.internalOnSRTPDecryptionFailureHandler
+	t.lock.RUnlock()
+
+	log := newLoggerWithAttrs(
+		t.api.loggerFactory(), t.log, "DTLSTransport", slog.Uint64(logKeySSRC, uint64(failure.SSRC)),
+	)
+	log.Warnf("%d packets of ssrc %d failed to decrypt within %v, resynced: %t",
+		failure.Failures, failure.SSRC, failure.Window, failure.Resynced)
+	if internalHandler != nil {
+		internalHandler(failure)
+	}
+	if handler != nil {
+		handler(failure)
+	}
+}
+
+// OnHandshake sets a handler that is fired when the DTLS handshake starts, completes or
+// fails, with its negotiated parameters or the detail of the failure.
+func (t *DTLSTransport) OnHandshake(f func(DTLSHandshake)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.onHandshakeHandler = f
+}
+
+func (t *DTLSTransport) onHandshake(handshake DTLSHandshake) {
+	t.lock.RLock()
+	handler := t.onHandshakeHandler
+	internalHandler := t.internalOnHandshakeHandler
+	t.lock.RUnlock()
+
+	if handshake.Stage == DTLSHandshakeStageFailed {
+		t.log.Warnf("DTLS handshake failed after %v as %s, %d packets sent, %d received, alerts sent %v, received %v: %v",
+			handshake.Duration, handshake.Role, handshake.PacketsSent, handshake.PacketsReceived,
+			handshake.LocalAlert, handshake.RemoteAlert, handshake.Err)
+	}
+	if internalHandler != nil {
+		internalHandler(handshake)
+	}
+	if handler != nil {
+		handler(handshake)
+	}
+}
+
 // State returns the current dtls transport state.
 func (t *DTLSTransport) State() DTLSTransportState {
 	t.lock.RLock()
@@ -162,7 +249,7 @@ func (t *DTLSTransport) GetLocalParameters() (DTLSParameters, error) {
 	fingerprints := []DTLSFingerprint{}
 
 	for _, c := range t.certificates {
-		prints, err := c.GetFingerprints()
+		prints, err := c.getFingerprints(t.api.settingEngine.getDTLSFingerprintAlgorithms())
 		if err != nil {
 			return DTLSParameters{}, err
 		}
@@ -185,11 +272,71 @@ func (t *DTLSTransport) GetRemoteCertificate() []byte {
 	return t.remoteCertificate
 }
 
+// SRTPKeyingMaterial is the SRTP keying material of a DTLSTransport, exported from the
+// DTLS handshake as defined by RFC 5764. The local keys protect the packets sent, the
+// remote ones the packets received. They can be given to srtp.CreateContext.
+type SRTPKeyingMaterial struct {
+	Profile srtp.ProtectionProfile
+	Keys    srtp.SessionKeys
+}
+
+// ExportSRTPKeyingMaterial returns the SRTP keying material negotiated in the DTLS
+// handshake. It returns ErrSRTPKeyExportDisabled unless enabled with
+// SettingEngine.EnableSRTPKeyExport, and an error until the DTLSTransport is connected.
+func (t *DTLSTransport) ExportSRTPKeyingMaterial() (SRTPKeyingMaterial, error) {
+	if !t.api.settingEngine.srtpKeyExport {
+		return SRTPKeyingMaterial{}, ErrSRTPKeyExportDisabled
+	}
+
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.srtpKeys == nil {
+		return SRTPKeyingMaterial{}, errDtlsTransportNotStarted
+	}
+
+	return SRTPKeyingMaterial{
+		Profile: t.srtpProtectionProfile,
+		Keys: srtp.SessionKeys{
+			LocalMasterKey:   append([]byte{}, t.srtpKeys.LocalMasterKey...),
+			LocalMasterSalt:  append([]byte{}, t.srtpKeys.LocalMasterSalt...),
+			RemoteMasterKey:  append([]byte{}, t.srtpKeys.RemoteMasterKey...),
+			RemoteMasterSalt: append([]byte{}, t.srtpKeys.RemoteMasterSalt...),
+		},
+	}, nil
+}
+
+// ExportKeyingMaterial returns length bytes of keying material exported from the DTLS
+// session as defined by RFC 5705, for the application to derive keys bound to it. Both
+// peers export the same bytes for the same label. The labels reserved by TLS are refused,
+// the label of SRTP, RFC 5764, returns ErrSRTPKeyExportDisabled unless enabled with
+// SettingEngine.EnableSRTPKeyExport. A context isn't supported by the DTLS stack. It
+// returns an error until the DTLSTransport is connected.
+func (t *DTLSTransport) ExportKeyingMaterial(label string, context []byte, length int) ([]byte, error) {
+	if label == srtpKeyingMaterialLabel && !t.api.settingEngine.srtpKeyExport {
+		return nil, ErrSRTPKeyExportDisabled
+	}
+
+	t.lock.RLock()
+	conn := t.conn
+	t.lock.RUnlock()
+
+	if conn == nil {
+		return nil, errDtlsTransportNotStarted
+	}
+	state, ok := conn.ConnectionState()
+	if !ok {
+		return nil, errDtlsTransportNotStarted
+	}
+
+	return state.ExportKeyingMaterial(label, context, length)
+}
+
 func (t *DTLSTransport) startSRTP() error {
 	srtpConfig := &srtp.Config{
 		Profile:       t.srtpProtectionProfile,
 		BufferFactory: t.api.settingEngine.BufferFactory,
-		LoggerFactory: t.api.settingEngine.LoggerFactory,
+		LoggerFactory: t.api.loggerFactory(),
 	}
 	if t.api.settingEngine.replayProtection.SRTP != nil {
 		srtpConfig.RemoteOptions = append(
@@ -231,12 +378,45 @@ func (t *DTLSTransport) startSRTP() error {
 		return fmt.Errorf("%w: %v", errDtlsKeyExtractionFailed, err)
 	}
 
-	srtpSession, err := srtp.NewSessionSRTP(t.srtpEndpoint, srtpConfig)
+	if t.api.settingEngine.srtpKeyExport {
+		t.srtpKeys = &srtpConfig.Keys
+	}
+
+	var srtpConn net.Conn = t.srtpEndpoint
+	if threshold, window := t.api.settingEngine.getSRTPDecryptionFailureThreshold(); threshold > 0 {
+		remoteOptions := append(
+			[]srtp.ContextOption{srtp.SRTPReplayProtection(defaultSRTPReplayProtectionWindow)},
+			srtpConfig.RemoteOptions...,
+		)
+		// The packets the session rejects as replayed aren't decryption failures
+		replayWindow := uint(defaultSRTPReplayProtectionWindow)
+		if t.api.settingEngine.replayProtection.SRTP != nil {
+			replayWindow = *t.api.settingEngine.replayProtection.SRTP
+		}
+		if t.api.settingEngine.disableSRTPReplayProtection {
+			replayWindow = 0
+		}
+		monitor := newSRTPDecryptionMonitor(t.srtpEndpoint, srtpConfig.BufferFactory, func() (*srtp.Context, error) {
+			return srtp.CreateContext(
+				srtpConfig.Keys.RemoteMasterKey, srtpConfig.Keys.RemoteMasterSalt, srtpConfig.Profile, remoteOptions...,
+			)
+		}, threshold, window, replayWindow, t.onSRTPDecryptionFailure)
+		srtpConfig.BufferFactory = monitor.BufferFactory
+		srtpConn = monitor
+	}
+	if t.pipelineMetrics != nil {
+		timedConn := &pipelineTimedConn{Conn: srtpConn, metrics: t.pipelineMetrics, bufferFactory: srtpConfig.BufferFactory}
+		srtpConfig.BufferFactory = timedConn.BufferFactory
+		srtpConn = timedConn
+	}
+
+	srtpSession, err := srtp.NewSessionSRTP(srtpConn, srtpConfig)
 	if err != nil {
 		// nolint
 		return fmt.Errorf("%w: %v", errFailedToStartSRTP, err)
 	}
 
+	srtpConfig.BufferFactory = t.api.settingEngine.BufferFactory
 	srtcpSession, err := srtp.NewSessionSRTCP(t.srtcpEndpoint, srtpConfig)
 	if err != nil {
 		// nolint
@@ -331,7 +511,7 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error { //nolint:
 				return defaultSrtpProtectionProfiles()
 			}(),
 			ClientAuth:         dtls.RequireAnyClientCert,
-			LoggerFactory:      t.api.settingEngine.LoggerFactory,
+			LoggerFactory:      t.api.loggerFactory(),
 			InsecureSkipVerify: !t.api.settingEngine.dtls.disableInsecureSkipVerify,
 			CustomCipherSuites: t.api.settingEngine.dtls.customCipherSuites,
 		}, nil
@@ -340,6 +520,8 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error { //nolint:
 	var dtlsConn *dtls.Conn
 	dtlsEndpoint := t.iceTransport.newEndpoint(mux.MatchDTLS)
 	dtlsEndpoint.SetOnClose(t.internalOnCloseHandler)
+	// The receiver window of SCTP must fit in the packets the endpoint buffers
+	dtlsEndpoint.SetBufferLimitSize(int(t.api.settingEngine.sctp.maxReceiveBufferSize)) //nolint:gosec // G115
 	role, dtlsConfig, err := prepareTransport()
 	if err != nil {
 		return err
@@ -356,6 +538,18 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error { //nolint:
 	dtlsConfig.FlightInterval = t.api.settingEngine.dtls.retransmissionInterval
 	dtlsConfig.InsecureSkipVerifyHello = t.api.settingEngine.dtls.insecureSkipHelloVerify
 	dtlsConfig.EllipticCurves = t.api.settingEngine.dtls.ellipticCurves
+	dtlsConfig.CipherSuites = t.api.settingEngine.dtls.cipherSuites
+	dtlsConfig.SignatureSchemes = t.api.settingEngine.dtls.signatureSchemes
+	if verify := t.api.settingEngine.dtls.verifyPeerCertificate; verify != nil {
+		fingerprints := remoteParameters.Fingerprints
+		dtlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if err := verify(rawCerts, fingerprints); err != nil {
+				return fmt.Errorf("%w: %w", errDTLSPeerCertificateRejected, err)
+			}
+
+			return nil
+		}
+	}
 	dtlsConfig.ExtendedMasterSecret = t.api.settingEngine.dtls.extendedMasterSecret
 	dtlsConfig.ClientCAs = t.api.settingEngine.dtls.clientCAs
 	dtlsConfig.RootCAs = t.api.settingEngine.dtls.rootCAs
@@ -364,12 +558,15 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error { //nolint:
 	dtlsConfig.ServerHelloMessageHook = t.api.settingEngine.dtls.serverHelloMessageHook
 	dtlsConfig.CertificateRequestMessageHook = t.api.settingEngine.dtls.certificateRequestMessageHook
 
+	monitor := newDTLSHandshakeMonitor(dtlsEndpoint, role)
+	t.onHandshake(DTLSHandshake{Stage: DTLSHandshakeStageStarted, Role: role})
+
 	// Connect as DTLS Client/Server, function is blocking and we
 	// must not hold the DTLSTransport lock
 	if role == DTLSRoleClient {
-		dtlsConn, err = dtls.Client(dtlsEndpoint, dtlsEndpoint.RemoteAddr(), dtlsConfig)
+		dtlsConn, err = dtls.Client(monitor, dtlsEndpoint.RemoteAddr(), dtlsConfig)
 	} else {
-		dtlsConn, err = dtls.Server(dtlsEndpoint, dtlsEndpoint.RemoteAddr(), dtlsConfig)
+		dtlsConn, err = dtls.Server(monitor, dtlsEndpoint.RemoteAddr(), dtlsConfig)
 	}
 
 	if err == nil {
@@ -381,21 +578,38 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error { //nolint:
 		}
 	}
 
+	if err = t.completeStart(dtlsConn, err); err != nil {
+		t.onHandshake(monitor.finish(DTLSHandshakeStageFailed, err))
+
+		return err
+	}
+
+	handshake := monitor.finish(DTLSHandshakeStageCompleted, nil)
+	if state, ok := dtlsConn.ConnectionState(); ok {
+		handshake.CipherSuite = state.CipherSuiteID
+	}
+	t.lock.RLock()
+	handshake.SRTPProtectionProfile = t.srtpProtectionProfile
+	t.lock.RUnlock()
+	t.onHandshake(handshake)
+
+	return nil
+}
+
+// completeStart verifies the DTLS connection once its handshake is done, handshakeErr
+// being its error, and starts SRTP over it.
+func (t *DTLSTransport) completeStart(dtlsConn *dtls.Conn, handshakeErr error) error { //nolint:cyclop
 	// Re-take the lock, nothing beyond here is blocking
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	if err != nil {
-		t.onStateChange(DTLSTransportStateFailed)
-
-		return err
+	if handshakeErr != nil {
+		return t.fail(handshakeErr)
 	}
 
 	srtpProfile, ok := dtlsConn.SelectedSRTPProtectionProfile()
 	if !ok {
-		t.onStateChange(DTLSTransportStateFailed)
-
-		return ErrNoSRTPProtectionProfile
+		return t.fail(ErrNoSRTPProtectionProfile)
 	}
 
 	switch srtpProfile {
@@ -405,26 +619,28 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error { //nolint:
 		t.srtpProtectionProfile = srtp.ProtectionProfileAeadAes256Gcm
 	case dtls.SRTP_AES128_CM_HMAC_SHA1_80:
 		t.srtpProtectionProfile = srtp.ProtectionProfileAes128CmHmacSha1_80
+	case dtls.SRTP_AES128_CM_HMAC_SHA1_32:
+		t.srtpProtectionProfile = srtp.ProtectionProfileAes128CmHmacSha1_32
+	case dtls.SRTP_AES256_CM_SHA1_80:
+		t.srtpProtectionProfile = srtp.ProtectionProfileAes256CmHmacSha1_80
+	case dtls.SRTP_AES256_CM_SHA1_32:
+		t.srtpProtectionProfile = srtp.ProtectionProfileAes256CmHmacSha1_32
 	case dtls.SRTP_NULL_HMAC_SHA1_80:
 		t.srtpProtectionProfile = srtp.ProtectionProfileNullHmacSha1_80
+	case dtls.SRTP_NULL_HMAC_SHA1_32:
+		t.srtpProtectionProfile = srtp.ProtectionProfileNullHmacSha1_32
 	default:
-		t.onStateChange(DTLSTransportStateFailed)
-
-		return ErrNoSRTPProtectionProfile
+		return t.fail(ErrNoSRTPProtectionProfile)
 	}
 
 	// Check the fingerprint if a certificate was exchanged
 	connectionState, ok := dtlsConn.ConnectionState()
 	if !ok {
-		t.onStateChange(DTLSTransportStateFailed)
-
-		return errNoRemoteCertificate
+		return t.fail(errNoRemoteCertificate)
 	}
 
 	if len(connectionState.PeerCertificates) == 0 {
-		t.onStateChange(DTLSTransportStateFailed)
-
-		return errNoRemoteCertificate
+		return t.fail(errNoRemoteCertificate)
 	}
 	t.remoteCertificate = connectionState.PeerCertificates[0]
 
@@ -435,9 +651,7 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error { //nolint:
 				t.log.Error(err.Error())
 			}
 
-			t.onStateChange(DTLSTransportStateFailed)
-
-			return err
+			return t.fail(err)
 		}
 
 		if err = t.validateFingerPrint(parsedRemoteCert); err != nil {
@@ -445,9 +659,7 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error { //nolint:
 				t.log.Error(err.Error())
 			}
 
-			t.onStateChange(DTLSTransportStateFailed)
-
-			return err
+			return t.fail(err)
 		}
 	}
 
@@ -465,6 +677,8 @@ func (t *DTLSTransport) Stop() error {
 	// Try closing everything and collect the errors
 	var closeErrs []error
 
+	t.pacer.close()
+
 	if srtpSession, err := t.getSRTPSession(); err == nil && srtpSession != nil {
 		closeErrs = append(closeErrs, srtpSession.Close())
 	}
@@ -489,11 +703,15 @@ func (t *DTLSTransport) Stop() error {
 	return util.FlattenErrs(closeErrs)
 }
 
+// validateFingerPrint accepts the remote certificate if it matches any of the remote
+// fingerprints, the fingerprints with an unsupported hash algorithm are ignored.
 func (t *DTLSTransport) validateFingerPrint(remoteCert *x509.Certificate) error {
 	for _, fp := range t.remoteParameters.Fingerprints {
 		hashAlgo, err := fingerprint.HashFromString(fp.Algorithm)
 		if err != nil {
-			return err
+			t.log.Debugf("Ignoring fingerprint with unsupported algorithm %s", fp.Algorithm)
+
+			continue
 		}
 
 		remoteValue, err := fingerprint.Fingerprint(remoteCert, hashAlgo)
@@ -541,8 +759,10 @@ func (t *DTLSTransport) streamsForSSRC(
 		return nil, nil, nil, nil, err
 	}
 
-	rtpInterceptor := t.api.interceptor.BindRemoteStream(
-		&streamInfo,
+	rtpInterceptor := t.pipelineMetrics.bindRemoteStream(
+		func(reader interceptor.RTPReader) interceptor.RTPReader {
+			return t.api.interceptor.BindRemoteStream(&streamInfo, reader)
+		},
 		interceptor.RTPReaderFunc(
 			func(in []byte, a interceptor.Attributes) (n int, attributes interceptor.Attributes, err error) {
 				n, err = rtpReadStream.Read(in)
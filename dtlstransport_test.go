@@ -7,10 +7,24 @@
 package webrtc
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/pion/dtls/v3"
+	dtlsElliptic "github.com/pion/dtls/v3/pkg/crypto/elliptic"
+	"github.com/pion/dtls/v3/pkg/crypto/fingerprint"
+	"github.com/pion/dtls/v3/pkg/protocol/alert"
+	"github.com/pion/logging"
+	"github.com/pion/rtp"
+	"github.com/pion/srtp/v3"
 	"github.com/pion/transport/v3/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -42,6 +56,13 @@ func TestInvalidFingerprintCausesFailed(t *testing.T) { //nolint:cyclop
 		}
 	})
 
+	connectionErrs := make(chan error, 2)
+	for _, pc := range []*PeerConnection{pcOffer, pcAnswer} {
+		pc.OnConnectionError(func(err error) {
+			connectionErrs <- err
+		})
+	}
+
 	offerConnectionHasClosed := untilConnectionState(PeerConnectionStateClosed, pcOffer)
 	answerConnectionHasClosed := untilConnectionState(PeerConnectionStateClosed, pcAnswer)
 
@@ -91,6 +112,9 @@ func TestInvalidFingerprintCausesFailed(t *testing.T) { //nolint:cyclop
 		"DTLS Transport should be closed or failed",
 	)
 	assert.Nil(t, pcAnswer.SCTP().Transport().conn)
+
+	// The failure is reported with the DTLS error that caused it
+	assert.ErrorIs(t, <-connectionErrs, errDTLSTransportFailed)
 }
 
 func TestPeerConnection_DTLSRoleSettingEngine(t *testing.T) {
@@ -121,3 +145,382 @@ func TestPeerConnection_DTLSRoleSettingEngine(t *testing.T) {
 		runTest(DTLSRoleClient)
 	})
 }
+
+func TestPeerConnection_DTLSFingerprintAlgorithms(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerSettings := SettingEngine{}
+	assert.NoError(t, offerSettings.SetDTLSFingerprintAlgorithms(crypto.SHA512, crypto.SHA256))
+	answerSettings := SettingEngine{}
+	assert.NoError(t, answerSettings.SetDTLSFingerprintAlgorithms(crypto.SHA384))
+	assert.ErrorIs(t, answerSettings.SetDTLSFingerprintAlgorithms(crypto.SHA3_256), errSettingEngineFingerprintAlgorithm)
+
+	offerPC, err := NewAPI(WithSettingEngine(offerSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	answerPC, err := NewAPI(WithSettingEngine(answerSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = offerPC.CreateDataChannel("fingerprints", nil)
+	assert.NoError(t, err)
+	offer, err := offerPC.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Regexp(t, "a=fingerprint:sha-512 [0-9A-F:]+\r\na=fingerprint:sha-256 [0-9A-F:]+\r\n", offer.SDP)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+
+	assert.Regexp(t, "a=fingerprint:sha-384 ", answerPC.LocalDescription().SDP)
+	assert.NotRegexp(t, "a=fingerprint:sha-256 ", answerPC.LocalDescription().SDP)
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestPeerConnection_DTLSCipherSuites(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerSettings := SettingEngine{}
+	offerSettings.SetDTLSCipherSuites(dtls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA, dtls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256)
+	offerSettings.SetDTLSSignatureSchemes(tls.ECDSAWithP256AndSHA256)
+	offerSettings.SetDTLSEllipticCurves(dtlsElliptic.P256)
+	answerSettings := SettingEngine{}
+	answerSettings.SetDTLSCipherSuites(dtls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA)
+
+	offerPC, err := NewAPI(WithSettingEngine(offerSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	answerPC, err := NewAPI(WithSettingEngine(answerSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = offerPC.CreateDataChannel("cipher", nil)
+	assert.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+
+	for _, pc := range []*PeerConnection{offerPC, answerPC} {
+		pc.dtlsTransport.lock.RLock()
+		state, ok := pc.dtlsTransport.conn.ConnectionState()
+		pc.dtlsTransport.lock.RUnlock()
+		assert.True(t, ok)
+		assert.Equal(t, dtls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA, state.CipherSuiteID)
+	}
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestPeerConnection_SRTPProtectionProfiles(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	for profile, expected := range map[dtls.SRTPProtectionProfile]srtp.ProtectionProfile{
+		dtls.SRTP_AEAD_AES_256_GCM:       srtp.ProtectionProfileAeadAes256Gcm,
+		dtls.SRTP_AES256_CM_SHA1_80:      srtp.ProtectionProfileAes256CmHmacSha1_80,
+		dtls.SRTP_AES256_CM_SHA1_32:      srtp.ProtectionProfileAes256CmHmacSha1_32,
+		dtls.SRTP_AES128_CM_HMAC_SHA1_32: srtp.ProtectionProfileAes128CmHmacSha1_32,
+	} {
+		settings := SettingEngine{}
+		settings.SetSRTPProtectionProfiles(profile)
+
+		offerPC, err := NewAPI(WithSettingEngine(settings)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+		answerPC, err := NewAPI(WithSettingEngine(settings)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		_, err = offerPC.CreateDataChannel("srtp", nil)
+		assert.NoError(t, err)
+
+		connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+		assert.NoError(t, signalPair(offerPC, answerPC))
+		connected.Wait()
+
+		for _, pc := range []*PeerConnection{offerPC, answerPC} {
+			pc.dtlsTransport.lock.RLock()
+			assert.Equal(t, expected, pc.dtlsTransport.srtpProtectionProfile)
+			pc.dtlsTransport.lock.RUnlock()
+		}
+
+		closePairNow(t, offerPC, answerPC)
+	}
+}
+
+func TestDTLSTransport_ExportSRTPKeyingMaterial(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settings := SettingEngine{}
+	settings.EnableSRTPKeyExport(true)
+
+	offerPC, err := NewAPI(WithSettingEngine(settings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	answerPC, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = offerPC.SCTP().Transport().ExportSRTPKeyingMaterial()
+	assert.ErrorIs(t, err, errDtlsTransportNotStarted)
+
+	_, err = offerPC.CreateDataChannel("keys", nil)
+	assert.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+
+	_, err = answerPC.SCTP().Transport().ExportSRTPKeyingMaterial()
+	assert.ErrorIs(t, err, ErrSRTPKeyExportDisabled)
+
+	// The remote keys of the offer are the local keys of the answer
+	material, err := offerPC.SCTP().Transport().ExportSRTPKeyingMaterial()
+	assert.NoError(t, err)
+	answerPC.dtlsTransport.lock.RLock()
+	srtpConfig := &srtp.Config{Profile: answerPC.dtlsTransport.srtpProtectionProfile}
+	connState, ok := answerPC.dtlsTransport.conn.ConnectionState()
+	assert.True(t, ok)
+	assert.NoError(t, srtpConfig.ExtractSessionKeysFromDTLS(&connState, answerPC.dtlsTransport.role() == DTLSRoleClient))
+	assert.Equal(t, answerPC.dtlsTransport.srtpProtectionProfile, material.Profile)
+	answerPC.dtlsTransport.lock.RUnlock()
+
+	assert.Equal(t, srtpConfig.Keys.LocalMasterKey, material.Keys.RemoteMasterKey)
+	assert.Equal(t, srtpConfig.Keys.LocalMasterSalt, material.Keys.RemoteMasterSalt)
+	assert.Equal(t, srtpConfig.Keys.RemoteMasterKey, material.Keys.LocalMasterKey)
+	assert.Equal(t, srtpConfig.Keys.RemoteMasterSalt, material.Keys.LocalMasterSalt)
+
+	// The keys decrypt the packets protected by the remote
+	receiver, err := srtp.CreateContext(material.Keys.RemoteMasterKey, material.Keys.RemoteMasterSalt, material.Profile)
+	assert.NoError(t, err)
+	sender, err := srtp.CreateContext(srtpConfig.Keys.LocalMasterKey, srtpConfig.Keys.LocalMasterSalt, material.Profile)
+	assert.NoError(t, err)
+	packet := &rtp.Packet{Header: rtp.Header{Version: 2, SSRC: 1234, SequenceNumber: 1}, Payload: []byte{0x01, 0x02}}
+	raw, err := packet.Marshal()
+	assert.NoError(t, err)
+	encrypted, err := sender.EncryptRTP(nil, raw, nil)
+	assert.NoError(t, err)
+	decrypted, err := receiver.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, raw, decrypted)
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestDTLSTransport_ExportKeyingMaterial(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	_, err = offerPC.SCTP().Transport().ExportKeyingMaterial("EXPORTER-test", nil, 32)
+	assert.ErrorIs(t, err, errDtlsTransportNotStarted)
+
+	_, err = offerPC.CreateDataChannel("keys", nil)
+	assert.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+
+	// Both peers export the same material, different for each label
+	offerKey, err := offerPC.SCTP().Transport().ExportKeyingMaterial("EXPORTER-test", nil, 32)
+	assert.NoError(t, err)
+	assert.Len(t, offerKey, 32)
+	answerKey, err := answerPC.SCTP().Transport().ExportKeyingMaterial("EXPORTER-test", nil, 32)
+	assert.NoError(t, err)
+	assert.Equal(t, offerKey, answerKey)
+	otherKey, err := offerPC.SCTP().Transport().ExportKeyingMaterial("EXPORTER-other", nil, 32)
+	assert.NoError(t, err)
+	assert.NotEqual(t, offerKey, otherKey)
+
+	_, err = offerPC.SCTP().Transport().ExportKeyingMaterial("master secret", nil, 32)
+	assert.Error(t, err)
+	_, err = offerPC.SCTP().Transport().ExportKeyingMaterial("EXTRACTOR-dtls_srtp", nil, 32)
+	assert.ErrorIs(t, err, ErrSRTPKeyExportDisabled)
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestPeerConnection_DTLSVerifyPeerCertificate(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	errNotPinned := errors.New("certificate not pinned")
+	for _, pinned := range []bool{true, false} {
+		offerPC, err := NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+		offerCertificate := offerPC.configuration.Certificates[0].x509Cert
+
+		verified := make(chan struct{}, 1)
+		answerSettings := SettingEngine{}
+		answerSettings.SetDTLSVerifyPeerCertificate(func(rawCerts [][]byte, fingerprints []DTLSFingerprint) error {
+			select {
+			case verified <- struct{}{}:
+			default:
+			}
+			if assert.Len(t, rawCerts, 1) && assert.Len(t, fingerprints, 1) {
+				assert.Equal(t, offerCertificate.Raw, rawCerts[0])
+				value, fingerprintErr := fingerprint.Fingerprint(offerCertificate, crypto.SHA256)
+				assert.NoError(t, fingerprintErr)
+				assert.Equal(t, "sha-256", fingerprints[0].Algorithm)
+				assert.True(t, strings.EqualFold(value, fingerprints[0].Value))
+			}
+			if !pinned {
+				return errNotPinned
+			}
+
+			return nil
+		})
+		answerPC, err := NewAPI(WithSettingEngine(answerSettings)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		connectionErr := make(chan error, 1)
+		answerPC.OnConnectionError(func(err error) {
+			connectionErr <- err
+		})
+
+		_, err = offerPC.CreateDataChannel("pinned", nil)
+		assert.NoError(t, err)
+
+		state := PeerConnectionStateConnected
+		if !pinned {
+			state = PeerConnectionStateFailed
+		}
+		reached := untilConnectionState(state, answerPC)
+		assert.NoError(t, signalPair(offerPC, answerPC))
+		reached.Wait()
+		<-verified
+
+		if !pinned {
+			err = <-connectionErr
+			assert.ErrorIs(t, err, errDTLSPeerCertificateRejected)
+			assert.ErrorIs(t, err, errNotPinned)
+		}
+
+		closePairNow(t, offerPC, answerPC)
+	}
+}
+
+func TestDTLSTransport_ValidateFingerprint(t *testing.T) {
+	secretKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	certificate, err := GenerateCertificate(secretKey)
+	assert.NoError(t, err)
+	fingerprints, err := certificate.getFingerprints([]crypto.Hash{crypto.SHA384})
+	assert.NoError(t, err)
+
+	transport := &DTLSTransport{log: logging.NewDefaultLoggerFactory().NewLogger("test")}
+	transport.remoteParameters.Fingerprints = []DTLSFingerprint{
+		{Algorithm: "sha-999", Value: "00"},
+		{Algorithm: "sha-256", Value: "00"},
+		fingerprints[0],
+	}
+	assert.NoError(t, transport.validateFingerPrint(certificate.x509Cert))
+
+	transport.remoteParameters.Fingerprints = transport.remoteParameters.Fingerprints[:2]
+	assert.ErrorIs(t, transport.validateFingerPrint(certificate.x509Cert), errNoMatchingCertificateFingerprint)
+}
+
+func TestDTLSTransport_OnHandshake(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	handshakes := func(pc *PeerConnection) chan DTLSHandshake {
+		stages := make(chan DTLSHandshake, 2)
+		pc.SCTP().Transport().OnHandshake(func(handshake DTLSHandshake) {
+			stages <- handshake
+		})
+
+		return stages
+	}
+
+	t.Run("Completed", func(t *testing.T) {
+		offerPC, answerPC, err := newPair()
+		assert.NoError(t, err)
+		offerHandshakes, answerHandshakes := handshakes(offerPC), handshakes(answerPC)
+
+		_, err = offerPC.CreateDataChannel("completed", nil)
+		assert.NoError(t, err)
+		connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+		assert.NoError(t, signalPair(offerPC, answerPC))
+		connected.Wait()
+
+		for role, stages := range map[DTLSRole]chan DTLSHandshake{
+			DTLSRoleServer: offerHandshakes,
+			DTLSRoleClient: answerHandshakes,
+		} {
+			started := <-stages
+			assert.Equal(t, DTLSHandshakeStageStarted, started.Stage)
+			assert.Equal(t, role, started.Role)
+			assert.Zero(t, started.PacketsReceived)
+
+			completed := <-stages
+			assert.Equal(t, DTLSHandshakeStageCompleted, completed.Stage)
+			assert.NoError(t, completed.Err)
+			assert.NotZero(t, completed.CipherSuite)
+			assert.NotZero(t, completed.SRTPProtectionProfile)
+			assert.Positive(t, completed.PacketsSent)
+			assert.Positive(t, completed.PacketsReceived)
+			assert.Nil(t, completed.LocalAlert)
+			assert.Nil(t, completed.RemoteAlert)
+		}
+
+		closePairNow(t, offerPC, answerPC)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		offerPC, err := NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		errRejected := errors.New("certificate rejected")
+		answerSettings := SettingEngine{}
+		answerSettings.SetDTLSVerifyPeerCertificate(func([][]byte, []DTLSFingerprint) error {
+			return errRejected
+		})
+		answerPC, err := NewAPI(WithSettingEngine(answerSettings)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+		offerHandshakes, answerHandshakes := handshakes(offerPC), handshakes(answerPC)
+
+		_, err = offerPC.CreateDataChannel("rejected", nil)
+		assert.NoError(t, err)
+		failed := untilConnectionState(PeerConnectionStateFailed, answerPC)
+		assert.NoError(t, signalPair(offerPC, answerPC))
+		failed.Wait()
+
+		assert.Equal(t, DTLSHandshakeStageStarted, (<-answerHandshakes).Stage)
+		rejecting := <-answerHandshakes
+		assert.Equal(t, DTLSHandshakeStageFailed, rejecting.Stage)
+		assert.ErrorIs(t, rejecting.Err, errRejected)
+		if assert.NotNil(t, rejecting.LocalAlert) {
+			assert.Equal(t, alert.Fatal, rejecting.LocalAlert.Level)
+		}
+
+		assert.Equal(t, DTLSHandshakeStageStarted, (<-offerHandshakes).Stage)
+		rejected := <-offerHandshakes
+		assert.Equal(t, DTLSHandshakeStageFailed, rejected.Stage)
+		assert.Error(t, rejected.Err)
+		assert.Equal(t, rejecting.LocalAlert, rejected.RemoteAlert)
+
+		closePairNow(t, offerPC, answerPC)
+	})
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+
+	"github.com/pion/rtcp"
+)
+
+// ecnECT1 is the ECN-Capable Transport ECT(1) codepoint of the IP header, see RFC 3168
+// and RFC 9331. It is the codepoint used by L4S senders.
+const ecnECT1 = 0x01
+
+// ECNCounts counts the packets reported by RFC 8888 congestion control feedback
+// per ECN codepoint they were received with.
+type ECNCounts struct {
+	NotECT uint64
+	ECT0   uint64
+	ECT1   uint64
+	CE     uint64
+}
+
+// ECNFeedbackHandler is implemented by the congestion controllers consuming ECN feedback.
+// When ECN is enabled with SettingEngine.EnableECN and the cc.BandwidthEstimator of a
+// PeerConnection implements it, OnECNFeedback is called with the ECN marks of each
+// feedback report received for a local stream.
+type ECNFeedbackHandler interface {
+	OnECNFeedback(ssrc SSRC, counts ECNCounts)
+}
+
+func (c *ECNCounts) add(counts ECNCounts) {
+	c.NotECT += counts.NotECT
+	c.ECT0 += counts.ECT0
+	c.ECT1 += counts.ECT1
+	c.CE += counts.CE
+}
+
+// ecnFeedback accumulates the ECN marks reported for the streams of an RTPSender.
+// A nil *ecnFeedback is valid and disabled.
+type ecnFeedback struct {
+	mu      sync.Mutex
+	counts  ECNCounts
+	handler ECNFeedbackHandler
+}
+
+// observe counts the ECN marks of the packets of ssrc reported by the RTCP in buf.
+func (f *ecnFeedback) observe(buf []byte, ssrc SSRC) {
+	if f == nil {
+		return
+	}
+
+	pkts, err := rtcp.Unmarshal(buf)
+	if err != nil {
+		return
+	}
+
+	for _, pkt := range pkts {
+		report, ok := pkt.(*rtcp.CCFeedbackReport)
+		if !ok {
+			continue
+		}
+
+		var counts ECNCounts
+		for _, block := range report.ReportBlocks {
+			if block.MediaSSRC != uint32(ssrc) {
+				continue
+			}
+
+			for _, metric := range block.MetricBlocks {
+				if !metric.Received {
+					continue
+				}
+
+				// The rtcp constants are named after their values, 01 is ECT(1) and 10 is ECT(0)
+				switch metric.ECN {
+				case rtcp.ECNNonECT:
+					counts.NotECT++
+				case rtcp.ECNECT1:
+					counts.ECT1++
+				case rtcp.ECNECT0:
+					counts.ECT0++
+				case rtcp.ECNCE:
+					counts.CE++
+				}
+			}
+		}
+		if counts == (ECNCounts{}) {
+			continue
+		}
+
+		f.mu.Lock()
+		f.counts.add(counts)
+		handler := f.handler
+		f.mu.Unlock()
+
+		if handler != nil {
+			handler.OnECNFeedback(ssrc, counts)
+		}
+	}
+}
+
+func (f *ecnFeedback) getCounts() ECNCounts {
+	if f == nil {
+		return ECNCounts{}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.counts
+}
+
+func (f *ecnFeedback) setHandler(handler ECNFeedbackHandler) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.handler = handler
+}
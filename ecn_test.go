@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testECNFeedbackHandler struct {
+	ssrcs  []SSRC
+	counts []ECNCounts
+}
+
+func (h *testECNFeedbackHandler) OnECNFeedback(ssrc SSRC, counts ECNCounts) {
+	h.ssrcs = append(h.ssrcs, ssrc)
+	h.counts = append(h.counts, counts)
+}
+
+func TestECNFeedback_Observe(t *testing.T) {
+	raw, err := rtcp.Marshal([]rtcp.Packet{
+		&rtcp.ReceiverReport{SSRC: 1},
+		&rtcp.CCFeedbackReport{
+			SenderSSRC: 1,
+			ReportBlocks: []rtcp.CCFeedbackReportBlock{
+				{
+					MediaSSRC: 1234,
+					MetricBlocks: []rtcp.CCFeedbackMetricBlock{
+						{Received: true, ECN: rtcp.ECNECT1},
+						{Received: true, ECN: rtcp.ECNECT1},
+						{Received: true, ECN: rtcp.ECNCE},
+						{Received: false},
+						{Received: true, ECN: rtcp.ECNNonECT},
+					},
+				},
+				{
+					MediaSSRC: 5678,
+					MetricBlocks: []rtcp.CCFeedbackMetricBlock{
+						{Received: true, ECN: rtcp.ECNECT0},
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	handler := &testECNFeedbackHandler{}
+	feedback := &ecnFeedback{}
+	feedback.setHandler(handler)
+
+	feedback.observe(raw, 1234)
+	feedback.observe(raw, 1234)
+	assert.Equal(t, ECNCounts{NotECT: 2, ECT1: 4, CE: 2}, feedback.getCounts())
+	assert.Equal(t, []SSRC{1234, 1234}, handler.ssrcs)
+	assert.Equal(t, ECNCounts{NotECT: 1, ECT1: 2, CE: 1}, handler.counts[0])
+
+	// Reports not covering the SSRC are ignored
+	feedback.observe(raw, 4321)
+	feedback.observe([]byte{0x00}, 1234)
+	assert.Len(t, handler.ssrcs, 2)
+
+	var disabled *ecnFeedback
+	disabled.observe(raw, 1234)
+	disabled.setHandler(handler)
+	assert.Equal(t, ECNCounts{}, disabled.getCounts())
+}
+
+func TestPeerConnection_ECNFeedback(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settingEngine := SettingEngine{}
+	settingEngine.EnableECN(true)
+	pcOffer, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	mediaEngine := &MediaEngine{}
+	require.NoError(t, mediaEngine.RegisterDefaultCodecs())
+	interceptorRegistry := &interceptor.Registry{}
+	require.NoError(t, ConfigureCongestionControlFeedback(mediaEngine, interceptorRegistry))
+	pcAnswer, err := NewAPI(
+		WithMediaEngine(mediaEngine),
+		WithInterceptorRegistry(interceptorRegistry),
+	).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for {
+			if _, _, readErr := sender.ReadRTCP(); readErr != nil {
+				return
+			}
+
+			// The receiver doesn't read the ECN marks and reports every packet as Not-ECT
+			if sender.ECNCounts().NotECT > 0 {
+				cancel()
+			}
+		}
+	}()
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, ctx.Done(), []*TrackLocalStaticSample{track})
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
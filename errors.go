@@ -65,6 +65,10 @@ var (
 	// specified for a data channel has been exceeded.
 	ErrMaxDataChannelID = errors.New("maximum number ID for datachannel specified")
 
+	// ErrDataChannelSendBufferFull indicates that a message was not sent because the
+	// DataChannel buffers too much data already, see SettingEngine.SetSCTPMaxSendBufferSize.
+	ErrDataChannelSendBufferFull = errors.New("data channel send buffer is full")
+
 	// ErrNegotiatedWithoutID indicates that an attempt to create a data channel
 	// was made while setting the negotiated option to true without providing
 	// the negotiated channel ID.
@@ -135,6 +139,10 @@ var (
 	// ErrNoSRTPProtectionProfile indicates that the DTLS handshake completed and no SRTP Protection Profile was chosen.
 	ErrNoSRTPProtectionProfile = errors.New("DTLS Handshake completed and no SRTP Protection Profile was chosen")
 
+	// ErrSRTPKeyExportDisabled indicates that the SRTP keying material of a DTLSTransport was requested
+	// without enabling its export with SettingEngine.EnableSRTPKeyExport.
+	ErrSRTPKeyExportDisabled = errors.New("SRTP key export is not enabled")
+
 	// ErrFailedToGenerateCertificateFingerprint indicates that we failed to generate the fingerprint
 	// used for comparing certificates.
 	ErrFailedToGenerateCertificateFingerprint = errors.New("failed to generate certificate fingerprint")
@@ -175,6 +183,16 @@ var (
 	// and the requested SSRC was ignored.
 	ErrSimulcastProbeOverflow = errors.New("simulcast probe limit has been reached, new SSRC has been discarded")
 
+	// ErrICEConnectionFailed indicates that the PeerConnection failed because no ICE candidate
+	// pair succeeded its connectivity checks, or the selected one stopped responding, until the
+	// failed timeout, see SettingEngine.SetICETimeouts.
+	ErrICEConnectionFailed = errors.New("ice connection failed: no candidate pair responded to the connectivity checks")
+
+	// ErrICEConsentExpired indicates that the PeerConnection failed because the remote stopped
+	// answering the consent checks of the selected candidate pair, see
+	// SettingEngine.SetICEConsentFreshness. It is wrapped with ErrICEConnectionFailed.
+	ErrICEConsentExpired = errors.New("ice consent expired: the remote stopped answering the consent checks")
+
 	errDetachNotEnabled                 = errors.New("enable detaching by calling webrtc.DetachDataChannels()")
 	errDetachBeforeOpened               = errors.New("datachannel not opened yet, try calling Detach from OnOpen")
 	errDtlsTransportNotStarted          = errors.New("the DTLS transport has not started yet")
@@ -183,6 +201,7 @@ var (
 	errFailedToStartSRTCP               = errors.New("failed to start SRTCP")
 	errInvalidDTLSStart                 = errors.New("attempted to start DTLSTransport that is not in new state")
 	errNoRemoteCertificate              = errors.New("peer didn't provide certificate via DTLS")
+	errDTLSTransportFailed              = errors.New("dtls transport failed")
 	errIdentityProviderNotImplemented   = errors.New("identity provider is not implemented")
 	errNoMatchingCertificateFingerprint = errors.New("remote certificate does not match any fingerprint")
 
@@ -222,6 +241,9 @@ var (
 	errPeerConnAddTransceiverFromTrackOnlyAcceptsOne = errors.New(
 		"AddTransceiverFromTrack only accepts one RTPTransceiverInit",
 	)
+	errPeerConnAddTrackOnlyAcceptsOne = errors.New(
+		"AddTrack only accepts one AddTrackOptions",
+	)
 	errPeerConnAddTransceiverFromKindSupport = errors.New(
 		"AddTransceiverFromKind currently only supports recvonly",
 	)
@@ -240,6 +262,8 @@ var (
 	errRTPReceiverReceiveAlreadyCalled        = errors.New("Receive has already been called")
 	errRTPReceiverWithSSRCTrackStreamNotFound = errors.New("unable to find stream for Track with SSRC")
 	errRTPReceiverForRIDTrackStreamNotFound   = errors.New("no trackStreams found for RID")
+	errRTPReceiverInterceptorNil              = errors.New("Receiver cannot add nil Interceptor")
+	errRTPReceiverStopped                     = errors.New("Receiver has already been stopped")
 
 	errRTPSenderTrackNil             = errors.New("Track must not be nil")
 	errRTPSenderDTLSTransportNil     = errors.New("DTLSTransport must not be nil")
@@ -252,12 +276,17 @@ var (
 	errRTPSenderBaseEncodingMismatch = errors.New("Sender cannot add encoding as provided track does not match base track")
 	errRTPSenderRIDCollision         = errors.New("Sender cannot encoding due to RID collision")
 	errRTPSenderNoTrackForRID        = errors.New("Sender does not have track for RID")
+	errRTPSenderInterceptorNil       = errors.New("Sender cannot add nil Interceptor")
+	errRTPSenderEncodingsMismatch    = errors.New("Sender encodings can't be added, removed or reordered")
+	errRTPSenderScaleResolution      = errors.New("Sender encoding scaleResolutionDownBy must be at least 1")
 
-	errRTPTransceiverCannotChangeMid        = errors.New("cannot change transceiver mid")
-	errRTPTransceiverSetSendingInvalidState = errors.New("invalid state change in RTPTransceiver.setSending")
-	errRTPTransceiverCodecUnsupported       = errors.New("unsupported codec type by this transceiver")
+	errRTPTransceiverCannotChangeMid            = errors.New("cannot change transceiver mid")
+	errRTPTransceiverSetSendingInvalidState     = errors.New("invalid state change in RTPTransceiver.setSending")
+	errRTPTransceiverCodecUnsupported           = errors.New("unsupported codec type by this transceiver")
+	errRTPTransceiverHeaderExtensionUnsupported = errors.New("header extension not registered for this transceiver kind")
 
-	errSCTPTransportDTLS = errors.New("DTLS not established")
+	errSCTPTransportDTLS   = errors.New("DTLS not established")
+	errSCTPTransportFailed = errors.New("sctp association failed")
 
 	errSDPZeroTransceivers                 = errors.New("addTransceiverSDP() called with 0 transceivers")
 	errSDPMediaSectionMediaDataChanInvalid = errors.New("invalid Media Section. Media + DataChannel both enabled")
@@ -266,6 +295,7 @@ var (
 	)
 
 	errSettingEngineSetAnsweringDTLSRole = errors.New("SetAnsweringDTLSRole must DTLSRoleClient or DTLSRoleServer")
+	errSettingEngineFingerprintAlgorithm = errors.New("unsupported fingerprint hash algorithm")
 
 	errSignalingStateCannotRollback            = errors.New("can't rollback from stable state")
 	errSignalingStateProposedTransitionInvalid = errors.New("invalid proposed signaling state transition")
@@ -286,5 +316,44 @@ var (
 
 	errRTPTooShort = errors.New("not long enough to be a RTP Packet")
 
+	errInvalidREDFmtp = errors.New("RED fmtp line does not start with a payload type")
+
+	errAdaptiveFECInvalidPolicy = errors.New("adaptive FEC policy MinFECPackets is larger than MaxFECPackets")
+
 	errExcessiveRetries = errors.New("excessive retries in CreateOffer")
+
+	errSocketOptionsUnsupportedConn     = errors.New("socket options can't be set on a connection without a file descriptor")
+	errSocketOptionsUnsupportedPlatform = errors.New("socket option is not supported on this platform")
+	errInvalidDSCP                      = errors.New("DSCP must be between 0 and 63")
+	errInvalidICEConsentFreshness       = errors.New("ICE consent freshness needs a positive interval and failure count")
+	errInvalidICETimeouts               = errors.New("ICE timeouts can't be negative")
+	errInvalidICEPairMigration          = errors.New("ICE pair migration can't have a negative interval or margin")
+	errInvalidPairStatsInterval         = errors.New("candidate pair stats need a positive interval")
+
+	errPlayoutDelayInvalid = errors.New("playout delay must be 0 <= Min <= Max <= 40.95s")
+
+	errTrackResumerUnknownSession = errors.New("unknown resume token")
+	errTrackResumerKindMismatch   = errors.New("resumed track has a different kind")
+
+	errSimulcastForwarderNoRID        = errors.New("simulcast layer has no RID")
+	errSimulcastForwarderUnknownLayer = errors.New("simulcast layer not forwarded")
+
+	errDependencyDescriptorTooShort        = errors.New("dependency descriptor too short")
+	errDependencyDescriptorNoStructure     = errors.New("dependency descriptor without template structure")
+	errDependencyDescriptorUnknownTemplate = errors.New("dependency descriptor of an unknown template")
+	errDependencyDescriptorInvalid         = errors.New("invalid dependency descriptor frame dependencies")
+	errDependencyStructureInvalid          = errors.New("invalid template dependency structure")
+
+	errICEShardedUDPMuxInvalidAddress = errors.New("address is not the one of the sharded UDPMux")
+
+	errICEProxyUnsupportedScheme = errors.New("unsupported proxy scheme")
+	errICEProxyConnectFailed     = errors.New("proxy refused the CONNECT request")
+
+	errDTLSPeerCertificateRejected = errors.New("remote DTLS certificate rejected")
+
+	errPacerQueueFull = errors.New("pacer queue is full")
+
+	errInvalidPathMTUDiscovery = errors.New("path MTU discovery can't probe below 548 bytes")
+
+	errDataChannelMessageTooLarge = errors.New("message is larger than the max-message-size of the remote")
 )
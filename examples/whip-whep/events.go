@@ -0,0 +1,302 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// The events of the WHEP Server Sent Events extension supported by this example.
+const (
+	eventActive      = "active"
+	eventInactive    = "inactive"
+	eventLayers      = "layers"
+	eventViewerCount = "viewercount"
+
+	// eventsRel is the link relation the WHEP answer advertises the events endpoint with.
+	eventsRel  = "urn:ietf:params:whep:ext:core:server-sent-events"
+	eventsPath = "/whep/events"
+
+	// eventsIdleTimeout is how long a subscription waits for its event stream to
+	// be opened before it is dropped.
+	eventsIdleTimeout = 30 * time.Second
+)
+
+// layer is an encoding of the published video, players use them to render layer selectors.
+type layer struct {
+	EncodingID string `json:"encodingId,omitempty"`
+	MimeType   string `json:"mimeType"`
+}
+
+// event is a Server Sent Event, data is encoded as JSON.
+type event struct {
+	name string
+	data any
+}
+
+// eventSubscription is a player subscribed to a set of events. It is dropped if its
+// event stream isn't opened within eventsIdleTimeout, or once the stream is closed.
+type eventSubscription struct {
+	events    map[string]bool
+	send      chan event
+	streaming bool
+	idle      *time.Timer
+}
+
+// streamEvents implements the WHEP Server Sent Events extension. Players subscribe
+// to the events they want by POSTing them to the events endpoint, and read them
+// from the event stream the response points to.
+type streamEvents struct {
+	mu            sync.Mutex
+	active        bool
+	layers        []layer
+	viewerCount   int
+	nextID        int
+	subscriptions map[string]*eventSubscription
+
+	onViewerCountChange func(count int)
+}
+
+func newStreamEvents() *streamEvents {
+	return &streamEvents{subscriptions: map[string]*eventSubscription{}}
+}
+
+// OnViewerCountChange sets a handler called when a WHEP session connects or disconnects.
+func (s *streamEvents) OnViewerCountChange(f func(count int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onViewerCountChange = f
+}
+
+// setActive notifies the players that the stream started or ended.
+func (s *streamEvents) setActive(active bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == active {
+		return
+	}
+	s.active = active
+	if !active {
+		s.layers = nil
+	}
+	s.broadcast(s.activeEvent())
+}
+
+// addLayer notifies the players of a new layer of the published video.
+func (s *streamEvents) addLayer(l layer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.layers = append(s.layers, l)
+	s.broadcast(s.layersEvent())
+}
+
+// addViewer updates the viewer count when a WHEP session connects or disconnects.
+func (s *streamEvents) addViewer(delta int) {
+	s.mu.Lock()
+	s.viewerCount += delta
+	count := s.viewerCount
+	s.broadcast(event{eventViewerCount, map[string]int{"viewercount": count}})
+	handler := s.onViewerCountChange
+	s.mu.Unlock()
+
+	if handler != nil {
+		handler(count)
+	}
+}
+
+// trackViewer counts peerConnection as a viewer while it is connected.
+func (s *streamEvents) trackViewer(peerConnection *webrtc.PeerConnection) {
+	var mu sync.Mutex
+	counted := false
+	peerConnection.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch state { // nolint: exhaustive
+		case webrtc.PeerConnectionStateConnected:
+			if !counted {
+				counted = true
+				s.addViewer(1)
+			}
+		case webrtc.PeerConnectionStateFailed, webrtc.PeerConnectionStateClosed:
+			if counted {
+				counted = false
+				s.addViewer(-1)
+			}
+		}
+	})
+}
+
+// trackPublisher marks the stream inactive when peerConnection disconnects.
+func (s *streamEvents) trackPublisher(peerConnection *webrtc.PeerConnection) {
+	peerConnection.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		switch state { // nolint: exhaustive
+		case webrtc.PeerConnectionStateConnected:
+			s.setActive(true)
+		case webrtc.PeerConnectionStateFailed, webrtc.PeerConnectionStateClosed:
+			s.setActive(false)
+		}
+	})
+}
+
+// linkHeader is the Link header the WHEP answer advertises the events endpoint with.
+func (s *streamEvents) linkHeader() string {
+	return fmt.Sprintf(`<%s>; rel="%s"; events="%s"`, eventsPath, eventsRel,
+		strings.Join([]string{eventActive, eventInactive, eventLayers, eventViewerCount}, ","))
+}
+
+func (s *streamEvents) activeEvent() event {
+	if s.active {
+		return event{eventActive, struct{}{}}
+	}
+
+	return event{eventInactive, struct{}{}}
+}
+
+func (s *streamEvents) layersEvent() event {
+	layers := append([]layer{}, s.layers...)
+
+	return event{eventLayers, map[string]any{"video": map[string]any{"layers": layers}}}
+}
+
+// broadcast sends e to the subscriptions that asked for it, players too slow to
+// read their events miss them. s.mu must be held.
+func (s *streamEvents) broadcast(e event) {
+	for _, subscription := range s.subscriptions {
+		if !subscription.events[e.name] {
+			continue
+		}
+		select {
+		case subscription.send <- e:
+		default:
+		}
+	}
+}
+
+// ServeHTTP creates subscriptions on POST to the events endpoint, and streams
+// the events of a subscription on GET of its location.
+func (s *streamEvents) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	fmt.Printf("Request to %s, method = %s\n", req.URL, req.Method)
+
+	res.Header().Add("Access-Control-Allow-Origin", "*")
+	res.Header().Add("Access-Control-Allow-Methods", "GET, POST")
+	res.Header().Add("Access-Control-Allow-Headers", "*")
+	res.Header().Add("Access-Control-Expose-Headers", "Location")
+
+	switch {
+	case req.Method == http.MethodOptions:
+	case req.Method == http.MethodPost && req.URL.Path == eventsPath:
+		s.subscribe(res, req)
+	case req.Method == http.MethodGet:
+		s.stream(res, req)
+	default:
+		res.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *streamEvents) subscribe(res http.ResponseWriter, req *http.Request) {
+	var names []string
+	if err := json.NewDecoder(req.Body).Decode(&names); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	subscription := &eventSubscription{events: map[string]bool{}, send: make(chan event, 16)}
+	for _, name := range names {
+		switch name {
+		case eventActive, eventInactive:
+			subscription.events[eventActive] = true
+			subscription.events[eventInactive] = true
+		case eventLayers, eventViewerCount:
+			subscription.events[name] = true
+		}
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	s.subscriptions[id] = subscription
+	subscription.idle = time.AfterFunc(eventsIdleTimeout, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if !subscription.streaming {
+			delete(s.subscriptions, id)
+		}
+	})
+	s.mu.Unlock()
+
+	res.Header().Add("Location", eventsPath+"/"+id)
+	res.WriteHeader(http.StatusCreated)
+}
+
+func (s *streamEvents) stream(res http.ResponseWriter, req *http.Request) {
+	flusher, ok := res.(http.Flusher)
+	id := strings.TrimPrefix(req.URL.Path, eventsPath+"/")
+
+	s.mu.Lock()
+	subscription := s.subscriptions[id]
+	if subscription == nil || subscription.streaming || !ok {
+		s.mu.Unlock()
+		res.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+	subscription.streaming = true
+	subscription.idle.Stop()
+	// Send the current state first, so players joining late know it
+	initial := []event{s.activeEvent(), s.layersEvent(), {eventViewerCount, map[string]int{"viewercount": s.viewerCount}}}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscriptions, id)
+		s.mu.Unlock()
+	}()
+
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.WriteHeader(http.StatusOK)
+
+	for _, e := range initial {
+		if subscription.events[e.name] {
+			writeEvent(res, e)
+		}
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case e := <-subscription.send:
+			writeEvent(res, e)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(res http.ResponseWriter, e event) {
+	data, err := json.Marshal(e.data)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Fprintf(res, "event: %s\ndata: %s\n\n", e.name, data) //nolint: errcheck
+}
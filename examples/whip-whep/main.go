@@ -24,6 +24,9 @@ var (
 	videoTrack *webrtc.TrackLocalStaticRTP
 	audioTrack *webrtc.TrackLocalStaticRTP
 
+	// events notifies the WHEP players of the state of the stream
+	events = newStreamEvents()
+
 	peerConnectionConfiguration = webrtc.Configuration{
 		ICEServers: []webrtc.ICEServer{
 			{
@@ -51,6 +54,12 @@ func main() {
 	http.Handle("/", http.FileServer(http.Dir(".")))
 	http.HandleFunc("/whep", whepHandler)
 	http.HandleFunc("/whip", whipHandler)
+	http.Handle(eventsPath, events)
+	http.Handle(eventsPath+"/", events)
+
+	events.OnViewerCountChange(func(count int) {
+		fmt.Printf("Viewer count has changed: %d\n", count)
+	})
 
 	fmt.Println("Open http://localhost:8080 to access this demo")
 	panic(http.ListenAndServe(":8080", nil)) // nolint: gosec
@@ -151,34 +160,24 @@ func whipHandler(res http.ResponseWriter, req *http.Request) { // nolint: cyclop
 				}
 			}
 		}()
+		// Forward the packets to the track the WHEP sessions are subscribed to,
+		// header extensions are stripped as their IDs are negotiated per PeerConnection.
+		output := audioTrack
+		if track.Kind() == webrtc.RTPCodecTypeVideo {
+			output = videoTrack
+			events.addLayer(layer{EncodingID: track.RID(), MimeType: track.Codec().MimeType})
+		}
+		forwarder := webrtc.NewTrackForwarder(track, peerConnection, output)
 		go func() {
-			for {
-				pkt, _, err := track.ReadRTP()
-				if err != nil {
-					if errors.Is(err, io.EOF) {
-						fmt.Printf("***** EOF reading RTP from publish peer connection\n")
-
-						break
-					}
-					panic(err)
-				}
-
-				// Strip any WHIP extensions before forwarding to WHEP
-				pkt.Header.Extensions = nil
-				pkt.Header.Extension = false
-
-				if track.Kind() == webrtc.RTPCodecTypeVideo {
-					if err = videoTrack.WriteRTP(pkt); err != nil {
-						panic(err)
-					}
-				} else if track.Kind() == webrtc.RTPCodecTypeAudio {
-					if err = audioTrack.WriteRTP(pkt); err != nil {
-						panic(err)
-					}
-				}
+			if err := forwarder.Forward(); !errors.Is(err, io.EOF) {
+				panic(err)
 			}
+			fmt.Printf("***** EOF reading RTP from publish peer connection\n")
 		}()
 	})
+	// Notify the WHEP players when the stream starts and ends
+	events.trackPublisher(peerConnection)
+
 	// Send answer via HTTP Response
 	writeAnswer(res, peerConnection, offer, "/whip")
 }
@@ -285,6 +284,12 @@ func whepHandler(res http.ResponseWriter, req *http.Request) { //nolint:cyclop
 		}
 	}()
 
+	// Count the viewer while it is connected, and advertise the Server Sent Events
+	// extension so players can be notified of the layers and the end of the stream
+	events.trackViewer(peerConnection)
+	res.Header().Add("Link", events.linkHeader())
+	res.Header().Add("Access-Control-Expose-Headers", "Link")
+
 	// Send answer via HTTP Response
 	writeAnswer(res, peerConnection, offer, "/whep")
 }
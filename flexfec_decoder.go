@@ -0,0 +1,286 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	// flexFECHeaderSize is the size of the FlexFEC-03 header of a repair packet
+	// protecting a single SSRC with the first packet mask.
+	flexFECHeaderSize = 20
+	// flexFECMediaWindow is the number of media packets kept to recover the lost ones.
+	flexFECMediaWindow = 256
+	// fecRepairWindow is the number of repair packets kept waiting for their
+	// protected packets.
+	fecRepairWindow = 64
+)
+
+// fecRepair is a parsed FlexFEC-03 or ULPFEC repair packet.
+type fecRepair struct {
+	// headerBits are the first 8 bytes of the FEC header, the XOR of the first 8 bytes
+	// of the RTP headers of the protected packets with their lengths minus 12 as
+	// the sequence number.
+	headerBits [8]byte
+	protected  []uint16
+	payload    []byte
+}
+
+// fecDecoder recovers the lost packets of a stream from its FlexFEC-03 repair
+// stream, see draft-ietf-payload-flexible-fec-scheme-03, or from the ULPFEC packets
+// sent on the stream, see newULPFECDecoder. Only the flexible mask protecting a single
+// SSRC is supported for FlexFEC-03, as sent by libwebrtc and the flexfec interceptor.
+type fecDecoder struct {
+	mu sync.Mutex
+
+	protectedSSRC uint32
+	media         map[uint16]*rtp.Packet
+	mediaOrder    []uint16
+	repairs       []*fecRepair
+	// recovered are the sequence numbers of the media packets recovered, the
+	// original packets arriving late are dropped.
+	recovered map[uint16]struct{}
+
+	// ulpFEC is set by newULPFECDecoder, the repair packets are then ULPFEC packets
+	// with the payload type ulpFECPayloadType, in RED if redPayloadType isn't 0.
+	ulpFEC            bool
+	redPayloadType    PayloadType
+	ulpFECPayloadType PayloadType
+
+	highestSequenceNumber uint16
+	hasMedia              bool
+}
+
+func newFlexFECDecoder(protectedSSRC SSRC) *fecDecoder {
+	return &fecDecoder{
+		protectedSSRC: uint32(protectedSSRC),
+		media:         map[uint16]*rtp.Packet{},
+		recovered:     map[uint16]struct{}{},
+	}
+}
+
+// pushMedia stores a packet of the protected stream and returns the packets it
+// allowed to recover. duplicate is true if the packet has already been recovered,
+// it must then be dropped.
+func (d *fecDecoder) pushMedia(packet *rtp.Packet) (recovered []*rtp.Packet, duplicate bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.recovered[packet.SequenceNumber]; ok {
+		return nil, true
+	}
+	if !d.storeMedia(packet) {
+		return nil, false
+	}
+
+	return d.recover(), false
+}
+
+// pushRepair stores a repair packet and returns the packets it allowed to recover.
+func (d *fecDecoder) pushRepair(packet *rtp.Packet) []*rtp.Packet {
+	repair, ok := d.parseRepair(packet.Payload)
+	if !ok {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.ulpFEC {
+		// The ULPFEC packets share the sequence numbers of the media, a newer one
+		// means the packets it protects are lost rather than late
+		d.protectedSSRC = packet.SSRC
+		d.observeSequenceNumber(packet.SequenceNumber)
+	}
+	d.repairs = append(d.repairs, repair)
+	if len(d.repairs) > fecRepairWindow {
+		d.repairs = d.repairs[1:]
+	}
+
+	return d.recover()
+}
+
+func (d *fecDecoder) storeMedia(packet *rtp.Packet) bool {
+	if _, ok := d.media[packet.SequenceNumber]; ok {
+		return false
+	}
+
+	d.media[packet.SequenceNumber] = packet
+	d.mediaOrder = append(d.mediaOrder, packet.SequenceNumber)
+	if len(d.mediaOrder) > flexFECMediaWindow {
+		delete(d.media, d.mediaOrder[0])
+		delete(d.recovered, d.mediaOrder[0])
+		d.mediaOrder = d.mediaOrder[1:]
+	}
+	d.observeSequenceNumber(packet.SequenceNumber)
+
+	return true
+}
+
+func (d *fecDecoder) observeSequenceNumber(sequenceNumber uint16) {
+	if !d.hasMedia || isNewerSequenceNumber(d.highestSequenceNumber, sequenceNumber) {
+		d.highestSequenceNumber = sequenceNumber
+		d.hasMedia = true
+	}
+}
+
+// recover recovers the packets missing from repair packets all the other protected
+// packets of have been received. A packet is only considered lost once a newer
+// packet has been received, so packets that are only late aren't recovered.
+func (d *fecDecoder) recover() []*rtp.Packet {
+	var recovered []*rtp.Packet
+	for progress := true; progress; {
+		progress = false
+
+		kept := d.repairs[:0]
+		for _, repair := range d.repairs {
+			missing, complete := d.missingPacket(repair)
+			switch {
+			case complete:
+				continue
+			case missing == nil:
+				kept = append(kept, repair)
+
+				continue
+			}
+
+			if packet, ok := d.recoverPacket(repair, *missing); ok {
+				d.storeMedia(packet)
+				d.recovered[packet.SequenceNumber] = struct{}{}
+				recovered = append(recovered, packet)
+				progress = true
+			}
+		}
+		d.repairs = kept
+	}
+
+	return recovered
+}
+
+// missingPacket returns the sequence number of the packet repair can recover, if
+// exactly one of its protected packets is lost, and whether they were all received.
+func (d *fecDecoder) missingPacket(repair *fecRepair) (*uint16, bool) {
+	var missing *uint16
+	for i, sequenceNumber := range repair.protected {
+		if _, ok := d.media[sequenceNumber]; ok {
+			continue
+		}
+		if missing != nil || !d.hasMedia || !isNewerSequenceNumber(sequenceNumber, d.highestSequenceNumber) {
+			return nil, false
+		}
+		missing = &repair.protected[i]
+	}
+
+	return missing, missing == nil
+}
+
+// recoverPacket XORs the repair packet with the protected packets received to
+// rebuild the missing one, see section 6.3.2 of the draft.
+func (d *fecDecoder) recoverPacket(repair *fecRepair, sequenceNumber uint16) (*rtp.Packet, bool) {
+	headerBits := repair.headerBits
+	payload := append([]byte{}, repair.payload...)
+	for _, protected := range repair.protected {
+		if protected == sequenceNumber {
+			continue
+		}
+
+		raw, err := d.media[protected].Marshal()
+		if err != nil {
+			return nil, false
+		}
+
+		for i := 0; i < 8; i++ {
+			headerBits[i] ^= raw[i]
+		}
+		// The length of the packet minus 12 takes the place of the sequence number
+		length := uint16(len(raw) - 12) //nolint:gosec // G115
+		headerBits[2] ^= raw[2] ^ byte(length>>8)
+		headerBits[3] ^= raw[3] ^ byte(length)
+
+		for i := 0; i < len(payload) && 12+i < len(raw); i++ {
+			payload[i] ^= raw[12+i]
+		}
+	}
+
+	length := int(binary.BigEndian.Uint16(headerBits[2:4]))
+	if length > len(payload) {
+		return nil, false
+	}
+
+	raw := make([]byte, 12+length)
+	copy(raw, headerBits[:])
+	// Version 2, the R and F bits of the FEC header take the place of the version
+	raw[0] = (raw[0] & 0x3F) | 0x80
+	binary.BigEndian.PutUint16(raw[2:4], sequenceNumber)
+	binary.BigEndian.PutUint32(raw[8:12], d.protectedSSRC)
+	copy(raw[12:], payload[:length])
+
+	packet := &rtp.Packet{}
+	if err := packet.Unmarshal(raw); err != nil {
+		return nil, false
+	}
+
+	return packet, true
+}
+
+// parseRepair parses the FEC header of a FlexFEC-03 repair packet, repair packets
+// with the R or F bits set, or protecting several SSRCs or another SSRC are ignored.
+func (d *fecDecoder) parseRepair(payload []byte) (*fecRepair, bool) {
+	if d.ulpFEC {
+		return parseULPFECRepair(payload)
+	}
+
+	if len(payload) < flexFECHeaderSize || payload[0]&0xC0 != 0 || payload[8] != 1 ||
+		binary.BigEndian.Uint32(payload[12:16]) != d.protectedSSRC {
+		return nil, false
+	}
+
+	repair := &fecRepair{}
+	copy(repair.headerBits[:], payload[:8])
+	sequenceNumberBase := binary.BigEndian.Uint16(payload[16:18])
+
+	// The packet mask is made of 15, 31 and 63 bits parts, the K bit of a part
+	// is set if it is the last one
+	offset := 18
+	bit := uint16(0)
+	for _, size := range []int{2, 4, 8} {
+		if len(payload) < offset+size {
+			return nil, false
+		}
+
+		var mask uint64
+		for i := 0; i < size; i++ {
+			mask = mask<<8 | uint64(payload[offset+i])
+		}
+		last := mask&(1<<(size*8-1)) != 0
+		bits := size*8 - 1
+		for i := bits - 1; i >= 0; i-- {
+			if mask&(1<<i) != 0 {
+				repair.protected = append(repair.protected, sequenceNumberBase+bit)
+			}
+			bit++
+		}
+		offset += size
+
+		if last {
+			repair.payload = payload[offset:]
+
+			return repair, len(repair.protected) != 0
+		}
+	}
+
+	return nil, false
+}
+
+// isNewerSequenceNumber returns whether b is newer than a, accounting for wrapping.
+func isNewerSequenceNumber(a, b uint16) bool {
+	return a != b && b-a < 0x8000
+}
@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/flexfec"
+	mock_interceptor "github.com/pion/interceptor/pkg/mock"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func flexFECTestPackets(count int, sequenceNumber uint16) []rtp.Packet {
+	packets := make([]rtp.Packet, count)
+	for i := range packets {
+		packets[i] = rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         i%2 == 1,
+				PayloadType:    96,
+				SequenceNumber: sequenceNumber + uint16(i), //nolint:gosec // G115
+				Timestamp:      uint32(3000 * (i / 2)),     //nolint:gosec // G115
+				SSRC:           1234,
+			},
+			// Payloads of different sizes
+			Payload: make([]byte, 20+10*i),
+		}
+		for j := range packets[i].Payload {
+			packets[i].Payload[j] = byte(i + j)
+		}
+	}
+
+	return packets
+}
+
+// pushFECPacket pushes packet to decoder and returns the packets recovered.
+func pushFECPacket(decoder *fecDecoder, packet *rtp.Packet) []*rtp.Packet {
+	_, recovered := decoder.pushPacket(packet)
+
+	return recovered
+}
+
+func TestFlexFECDecoder(t *testing.T) {
+	for _, sequenceNumber := range []uint16{1000, 65533} {
+		media := flexFECTestPackets(5, sequenceNumber)
+		repairs := flexfec.NewFlexEncoder03(120, 5678).EncodeFec(media, 1)
+		require.Len(t, repairs, 1)
+
+		decoder := newFlexFECDecoder(1234)
+		for i := range media {
+			if i != 2 {
+				assert.Empty(t, pushFECPacket(decoder, &media[i]))
+			}
+		}
+
+		recovered := decoder.pushRepair(&repairs[0])
+		require.Len(t, recovered, 1)
+		assert.Equal(t, media[2].Header, recovered[0].Header)
+		assert.Equal(t, media[2].Payload, recovered[0].Payload)
+
+		// The packets are only recovered once, and the original is dropped if it is late
+		assert.Empty(t, decoder.pushRepair(&repairs[0]))
+		late, recovered := decoder.pushPacket(&media[2])
+		assert.Nil(t, late)
+		assert.Empty(t, recovered)
+		delivered, _ := decoder.pushPacket(&media[3])
+		assert.Equal(t, &media[3], delivered, "duplicates of packets not recovered are kept")
+	}
+}
+
+func TestFlexFECDecoder_LatePacket(t *testing.T) {
+	media := flexFECTestPackets(6, 1000)
+	repairs := flexfec.NewFlexEncoder03(120, 5678).EncodeFec(media[:5], 1)
+	require.Len(t, repairs, 1)
+
+	decoder := newFlexFECDecoder(1234)
+	for i := range media[:4] {
+		assert.Empty(t, pushFECPacket(decoder, &media[i]))
+	}
+
+	// The last protected packet may only be late, until a newer packet is received
+	assert.Empty(t, decoder.pushRepair(&repairs[0]))
+	recovered := pushFECPacket(decoder, &media[5])
+	require.Len(t, recovered, 1)
+	assert.Equal(t, media[4].SequenceNumber, recovered[0].SequenceNumber)
+
+	// Two lost packets can't be recovered from a single repair packet
+	decoder = newFlexFECDecoder(1234)
+	for i := range media[:3] {
+		assert.Empty(t, pushFECPacket(decoder, &media[i]))
+	}
+	assert.Empty(t, pushFECPacket(decoder, &media[5]))
+	assert.Empty(t, decoder.pushRepair(&repairs[0]))
+
+	// Repair packets protecting another stream are ignored
+	decoder = newFlexFECDecoder(4321)
+	assert.Empty(t, decoder.pushRepair(&repairs[0]))
+	assert.Empty(t, decoder.repairs)
+}
+
+func TestPeerConnection_FlexFECRecovery(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newAPI := func(dropMedia bool) *API {
+		mediaEngine := &MediaEngine{}
+		require.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeVP8, ClockRate: 90000},
+			PayloadType:        96,
+		}, RTPCodecTypeVideo))
+
+		interceptorRegistry := &interceptor.Registry{}
+		if dropMedia {
+			// Registered first so it sees the FEC packets, drops a media packet in 10
+			interceptorRegistry.Add(&mock_interceptor.Factory{
+				NewInterceptorFn: func(string) (interceptor.Interceptor, error) {
+					return &mock_interceptor.Interceptor{
+						BindLocalStreamFn: func(
+							info *interceptor.StreamInfo, writer interceptor.RTPWriter,
+						) interceptor.RTPWriter {
+							return interceptor.RTPWriterFunc(
+								func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+									if header.SSRC == info.SSRC && header.SequenceNumber%10 == 5 {
+										return len(payload), nil
+									}
+
+									return writer.Write(header, payload, attributes)
+								},
+							)
+						},
+					}, nil
+				},
+			})
+		}
+		require.NoError(t, ConfigureFlexFEC03(120, mediaEngine, interceptorRegistry))
+
+		return NewAPI(WithMediaEngine(mediaEngine), WithInterceptorRegistry(interceptorRegistry))
+	}
+
+	pcOffer, err := newAPI(true).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := newAPI(false).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		assert.True(t, track.HasFEC())
+		for {
+			pkt, attributes, readErr := track.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			if attributes.Get(AttributeFECRecovered) == true {
+				assert.Equal(t, uint16(5), pkt.SequenceNumber%10)
+				assert.Equal(t, uint32(track.SSRC()), pkt.SSRC)
+				assert.Equal(t, uint8(96), pkt.PayloadType)
+				cancel()
+			}
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, ctx.Done(), []*TrackLocalStaticSample{track})
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
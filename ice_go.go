@@ -10,5 +10,5 @@ package webrtc
 // This constructor is part of the ORTC API. It is not
 // meant to be used together with the basic WebRTC API.
 func (api *API) NewICETransport(gatherer *ICEGatherer) *ICETransport {
-	return NewICETransport(gatherer, api.settingEngine.LoggerFactory)
+	return NewICETransport(gatherer, api.loggerFactory())
 }
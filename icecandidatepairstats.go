@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CandidatePairStatsSubscription receives the stats of the candidate pairs of an
+// ICETransport as they change, see ICETransport.SubscribeCandidatePairStats.
+type CandidatePairStatsSubscription struct {
+	transport *ICETransport
+	interval  time.Duration
+	stats     chan ICECandidatePairStats
+	dropped   atomic.Uint64
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// SubscribeCandidatePairStats samples the stats of the candidate pairs every interval,
+// and delivers those of the pairs whose stats changed since their last delivery, their
+// state, round trip time, requests, responses or bytes for instance. Sampling every
+// 100ms catches the checks failing between the samples of a GetStats polled every
+// second. The stats are never blocking the ICETransport, they are dropped if the buffer
+// of the subscription is full, see CandidatePairStatsSubscription.Dropped. The channel
+// is closed by CandidatePairStatsSubscription.Close or once the ICETransport is stopped.
+func (t *ICETransport) SubscribeCandidatePairStats(interval time.Duration) (*CandidatePairStatsSubscription, error) {
+	if interval <= 0 {
+		return nil, errInvalidPairStatsInterval
+	}
+
+	subscription := &CandidatePairStatsSubscription{
+		transport: t,
+		interval:  interval,
+		stats:     make(chan ICECandidatePairStats, defaultEventsBufferSize),
+		done:      make(chan struct{}),
+	}
+	go subscription.run()
+
+	return subscription, nil
+}
+
+// C returns the channel the stats are delivered on.
+func (s *CandidatePairStatsSubscription) C() <-chan ICECandidatePairStats {
+	return s.stats
+}
+
+// Dropped returns the number of stats dropped because the buffer of the subscription was full.
+func (s *CandidatePairStatsSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops the subscription, its channel is closed once the last sample is delivered.
+func (s *CandidatePairStatsSubscription) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *CandidatePairStatsSubscription) run() {
+	defer close(s.stats)
+
+	// delivered are the stats last delivered by pair, their timestamp cleared
+	delivered := map[string]ICECandidatePairStats{}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if s.transport.State() == ICETransportStateClosed {
+			return
+		}
+
+		for _, stats := range s.transport.GetStats() {
+			pairStats, ok := stats.(ICECandidatePairStats)
+			if !ok {
+				continue
+			}
+
+			compared := pairStats
+			compared.Timestamp = 0
+			if last, ok := delivered[pairStats.ID]; ok && last == compared {
+				continue
+			}
+			delivered[pairStats.ID] = compared
+
+			select {
+			case s.stats <- pairStats:
+			default:
+				s.dropped.Add(1)
+			}
+		}
+
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -7,13 +7,16 @@
 package webrtc
 
 import (
+	"crypto/tls"
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/pion/ice/v4"
 	"github.com/pion/logging"
 	"github.com/pion/stun/v3"
+	"github.com/pion/transport/v3/stdnet"
 )
 
 // ICEGatherer gathers local host, server reflexive and relay
@@ -26,12 +29,35 @@ type ICEGatherer struct {
 	state ICEGathererState
 
 	validatedServers []*stun.URI
-	gatherPolicy     ICETransportPolicy
+	// turnTLSConfigs are the TLS configs of the TURNS servers over TCP by address, see
+	// ICEServer.TLSConfig
+	turnTLSConfigs map[string]*tls.Config
+	// turnCredentialProviders provide the credentials of the TURN servers, see
+	// ICEServer.CredentialProvider
+	turnCredentialProviders []turnCredentialProvider
+	// turnCredentialsTimer fires onTURNCredentialsExpiring before the credentials
+	// provided expire
+	turnCredentialsTimer *time.Timer
+	gatherPolicy         ICETransportPolicy
 
 	agent *ice.Agent
-
-	onLocalCandidateHandler atomic.Value // func(candidate *ICECandidate)
-	onStateChangeHandler    atomic.Value // func(state ICEGathererState)
+	// mDNSResolver resolves the remote mDNS candidates in place of the agent, if configured
+	mDNSResolver *mDNSResolver
+	// pathMTUProbes matches the responses to the path MTU probes, if the path MTU is discovered
+	// or the candidate pairs migrate
+	pathMTUProbes *pathMTUProbes
+	// pairMigration migrates the candidate pairs, if enabled
+	pairMigration *icePairMigration
+	// renomination follows the renominations of the remote, if enabled
+	renomination *iceRenomination
+	// networkCost lowers the priorities of the local candidates, if configured
+	networkCost *iceNetworkCost
+
+	onLocalCandidateHandler         atomic.Value // func(candidate *ICECandidate)
+	onStateChangeHandler            atomic.Value // func(state ICEGathererState)
+	internalOnLocalCandidateHandler atomic.Value // func(candidate *ICECandidate)
+	internalOnStateChangeHandler    atomic.Value // func(state ICEGathererState)
+	onTURNCredentialsExpiring       atomic.Value // func()
 
 	// Used for GatheringCompletePromise
 	onGatheringCompleteHandler atomic.Value // func()
@@ -49,6 +75,8 @@ type ICEGatherer struct {
 // meant to be used together with the basic WebRTC API.
 func (api *API) NewICEGatherer(opts ICEGatherOptions) (*ICEGatherer, error) {
 	var validatedServers []*stun.URI
+	var turnCredentialProviders []turnCredentialProvider
+	turnTLSConfigs := map[string]*tls.Config{}
 	if len(opts.ICEServers) > 0 {
 		for _, server := range opts.ICEServers {
 			url, err := server.urls()
@@ -56,17 +84,38 @@ func (api *API) NewICEGatherer(opts ICEGatherOptions) (*ICEGatherer, error) {
 				return nil, err
 			}
 			validatedServers = append(validatedServers, url...)
+
+			if server.CredentialProvider != nil {
+				provider := turnCredentialProvider{provide: server.CredentialProvider}
+				for _, u := range url {
+					if u.Scheme == stun.SchemeTypeTURN || u.Scheme == stun.SchemeTypeTURNS {
+						provider.urls = append(provider.urls, u)
+					}
+				}
+				if len(provider.urls) != 0 {
+					turnCredentialProviders = append(turnCredentialProviders, provider)
+				}
+			}
+			if server.TLSConfig != nil {
+				for _, u := range url {
+					if u.Scheme == stun.SchemeTypeTURNS && u.Proto == stun.ProtoTypeTCP {
+						turnTLSConfigs[turnServerAddress(u)] = server.TLSConfig
+					}
+				}
+			}
 		}
 	}
 
 	return &ICEGatherer{
-		state:            ICEGathererStateNew,
-		gatherPolicy:     opts.ICEGatherPolicy,
-		validatedServers: validatedServers,
-		api:              api,
-		log:              api.settingEngine.LoggerFactory.NewLogger("ice"),
-		sdpMid:           atomic.Value{},
-		sdpMLineIndex:    atomic.Uint32{},
+		state:                   ICEGathererStateNew,
+		gatherPolicy:            opts.ICEGatherPolicy,
+		validatedServers:        validatedServers,
+		turnTLSConfigs:          turnTLSConfigs,
+		turnCredentialProviders: turnCredentialProviders,
+		api:                     api,
+		log:                     api.loggerFactory().NewLogger("ice"),
+		sdpMid:                  atomic.Value{},
+		sdpMLineIndex:           atomic.Uint32{},
 	}, nil
 }
 
@@ -79,8 +128,11 @@ func (g *ICEGatherer) createAgent() error { //nolint:cyclop
 	}
 
 	candidateTypes := []ice.CandidateType{}
+	urls := g.validatedServers
 	if g.api.settingEngine.candidates.ICELite {
+		// A lite agent doesn't use the ICE servers, the agent refuses them
 		candidateTypes = append(candidateTypes, ice.CandidateTypeHost)
+		urls = nil
 	} else if g.gatherPolicy == ICETransportPolicyRelay {
 		candidateTypes = append(candidateTypes, ice.CandidateTypeRelay)
 	}
@@ -95,48 +147,137 @@ func (g *ICEGatherer) createAgent() error { //nolint:cyclop
 		nat1To1CandiTyp = ice.CandidateTypeUnspecified
 	}
 
+	iceNet := g.api.settingEngine.net
+	options := socketOptions{
+		reusePort:    g.api.settingEngine.iceReusePort,
+		trafficClass: int(g.api.settingEngine.iceDSCP) << 2,
+	}
+	if g.api.settingEngine.ecn {
+		options.mediaECN = ecnECT1
+	}
+	// The probes are matched on the sockets gathered by the agent only
+	migration := g.api.settingEngine.icePairMigration
+	if (g.api.settingEngine.pathMTUMax > 0 || migration.interval > 0) && g.api.settingEngine.iceUDPMux == nil {
+		options.pathMTUProbes = newPathMTUProbes()
+		options.dontFragment = g.api.settingEngine.pathMTUMax > 0
+	}
+	var networkCost *iceNetworkCost
+	if cost := g.api.settingEngine.iceNetworkCost; cost != nil {
+		costNet := iceNet
+		if costNet == nil {
+			var err error
+			if costNet, err = stdnet.NewNet(); err != nil {
+				return err
+			}
+		}
+		networkCost = newICENetworkCost(costNet, cost)
+	}
+	bindingRequestHandler := g.api.settingEngine.iceBindingRequestHandler
+	var renomination *iceRenomination
+	if g.api.settingEngine.hasICERenomination() {
+		renomination = &iceRenomination{}
+		bindingRequestHandler = renomination.bindingRequestHandler(bindingRequestHandler)
+	}
+	var pairMigration *icePairMigration
+	if migration.interval > 0 && options.pathMTUProbes != nil {
+		pairMigration = newICEPairMigration(
+			options.pathMTUProbes, migration.interval, migration.rttMargin, networkCost, g.log,
+		)
+		bindingRequestHandler = pairMigration.bindingRequestHandler(bindingRequestHandler)
+	}
+	if options != (socketOptions{}) {
+		var err error
+		if iceNet, err = newSocketOptionsNet(iceNet, options, g.log); err != nil {
+			return err
+		}
+	}
+
+	if turnTCP, relay := g.api.settingEngine.ephemeralTURNTCP, g.api.settingEngine.ephemeralRelay; !turnTCP.isZero() ||
+		!relay.isZero() {
+		var err error
+		if iceNet, err = newPortRangeNet(iceNet, turnTCP, relay); err != nil {
+			return err
+		}
+	}
+
+	proxyDialer := g.api.settingEngine.iceProxyDialer
+	if proxyDialer != nil {
+		proxyDialer = newTURNProxyDialer(proxyDialer, g.validatedServers, g.turnTLSConfigs)
+	} else if len(g.turnTLSConfigs) != 0 {
+		// The agent only connects to the TURNS servers with its own TLS config, the
+		// dialer connects to them directly with theirs
+		proxyDialer = newTURNProxyDialer(iceNet, g.validatedServers, g.turnTLSConfigs)
+	}
+
+	// The consent checks are the keepalives of the agent
+	keepaliveInterval := g.api.settingEngine.timeout.ICEKeepaliveInterval
+	if interval := g.api.settingEngine.timeout.ICEConsentInterval; interval > 0 && !g.api.settingEngine.candidates.ICELite {
+		keepaliveInterval = &interval
+	}
+
 	mDNSMode := g.api.settingEngine.candidates.MulticastDNSMode
 	if mDNSMode != ice.MulticastDNSModeDisabled && mDNSMode != ice.MulticastDNSModeQueryAndGather {
 		// If enum is in state we don't recognized default to MulticastDNSModeQueryOnly
 		mDNSMode = ice.MulticastDNSModeQueryOnly
 	}
 
+	requestedNetworkTypes := g.api.settingEngine.candidates.ICENetworkTypes
+	if len(requestedNetworkTypes) == 0 {
+		requestedNetworkTypes = supportedNetworkTypes()
+	}
+
+	var mDNSResolver *mDNSResolver
+	if mDNSMode != ice.MulticastDNSModeDisabled && g.api.settingEngine.hasMulticastDNSResolverOptions() {
+		var err error
+		if mDNSResolver, err = newMDNSResolver(
+			g.api.settingEngine.net,
+			g.api.settingEngine.candidates.MulticastDNSInterfaces,
+			requestedNetworkTypes,
+			g.api.settingEngine.candidates.IncludeLoopbackCandidate,
+			g.api.settingEngine.candidates.MulticastDNSTimeout,
+			g.api.settingEngine.candidates.MulticastDNSCacheTTL,
+			g.api.mDNSCache,
+			g.api.loggerFactory(),
+		); err != nil {
+			g.log.Warnf("Failed to start the mDNS resolver, the agent resolves the candidates: %v", err)
+		} else if mDNSMode == ice.MulticastDNSModeQueryOnly {
+			// The agent only needs mDNS to answer for its own candidates
+			mDNSMode = ice.MulticastDNSModeDisabled
+		}
+	}
+
 	config := &ice.AgentConfig{
 		Lite:                   g.api.settingEngine.candidates.ICELite,
-		Urls:                   g.validatedServers,
+		Urls:                   urls,
 		PortMin:                g.api.settingEngine.ephemeralUDP.PortMin,
 		PortMax:                g.api.settingEngine.ephemeralUDP.PortMax,
 		DisconnectedTimeout:    g.api.settingEngine.timeout.ICEDisconnectedTimeout,
 		FailedTimeout:          g.api.settingEngine.timeout.ICEFailedTimeout,
-		KeepaliveInterval:      g.api.settingEngine.timeout.ICEKeepaliveInterval,
-		LoggerFactory:          g.api.settingEngine.LoggerFactory,
+		KeepaliveInterval:      keepaliveInterval,
+		LoggerFactory:          g.api.loggerFactory(),
 		CandidateTypes:         candidateTypes,
 		HostAcceptanceMinWait:  g.api.settingEngine.timeout.ICEHostAcceptanceMinWait,
 		SrflxAcceptanceMinWait: g.api.settingEngine.timeout.ICESrflxAcceptanceMinWait,
 		PrflxAcceptanceMinWait: g.api.settingEngine.timeout.ICEPrflxAcceptanceMinWait,
 		RelayAcceptanceMinWait: g.api.settingEngine.timeout.ICERelayAcceptanceMinWait,
 		STUNGatherTimeout:      g.api.settingEngine.timeout.ICESTUNGatherTimeout,
+		CheckInterval:          g.api.settingEngine.timeout.ICECheckInterval,
 		InterfaceFilter:        g.api.settingEngine.candidates.InterfaceFilter,
 		IPFilter:               g.api.settingEngine.candidates.IPFilter,
 		NAT1To1IPs:             g.api.settingEngine.candidates.NAT1To1IPs,
 		NAT1To1IPCandidateType: nat1To1CandiTyp,
 		IncludeLoopback:        g.api.settingEngine.candidates.IncludeLoopbackCandidate,
-		Net:                    g.api.settingEngine.net,
+		Net:                    iceNet,
 		MulticastDNSMode:       mDNSMode,
 		MulticastDNSHostName:   g.api.settingEngine.candidates.MulticastDNSHostName,
 		LocalUfrag:             g.api.settingEngine.candidates.UsernameFragment,
 		LocalPwd:               g.api.settingEngine.candidates.Password,
 		TCPMux:                 g.api.settingEngine.iceTCPMux,
 		UDPMux:                 g.api.settingEngine.iceUDPMux,
-		ProxyDialer:            g.api.settingEngine.iceProxyDialer,
+		ProxyDialer:            proxyDialer,
 		DisableActiveTCP:       g.api.settingEngine.iceDisableActiveTCP,
 		MaxBindingRequests:     g.api.settingEngine.iceMaxBindingRequests,
-		BindingRequestHandler:  g.api.settingEngine.iceBindingRequestHandler,
-	}
-
-	requestedNetworkTypes := g.api.settingEngine.candidates.ICENetworkTypes
-	if len(requestedNetworkTypes) == 0 {
-		requestedNetworkTypes = supportedNetworkTypes()
+		BindingRequestHandler:  bindingRequestHandler,
 	}
 
 	for _, typ := range requestedNetworkTypes {
@@ -145,10 +286,19 @@ func (g *ICEGatherer) createAgent() error { //nolint:cyclop
 
 	agent, err := ice.NewAgent(config)
 	if err != nil {
+		if mDNSResolver != nil {
+			_ = mDNSResolver.close()
+		}
+
 		return err
 	}
 
 	g.agent = agent
+	g.mDNSResolver = mDNSResolver
+	g.pathMTUProbes = options.pathMTUProbes
+	g.pairMigration = pairMigration
+	g.renomination = renomination
+	g.networkCost = networkCost
 
 	return nil
 }
@@ -165,12 +315,20 @@ func (g *ICEGatherer) Gather() error { //nolint:cyclop
 		return fmt.Errorf("%w: unable to gather", errICEAgentNotExist)
 	}
 
+	networkCost := g.getNetworkCost()
 	g.setState(ICEGathererStateGathering)
 	if err := agent.OnCandidate(func(candidate ice.Candidate) {
 		onLocalCandidateHandler := func(*ICECandidate) {}
 		if handler, ok := g.onLocalCandidateHandler.Load().(func(candidate *ICECandidate)); ok && handler != nil {
 			onLocalCandidateHandler = handler
 		}
+		if handler, ok := g.internalOnLocalCandidateHandler.Load().(func(candidate *ICECandidate)); ok && handler != nil {
+			userHandler := onLocalCandidateHandler
+			onLocalCandidateHandler = func(c *ICECandidate) {
+				handler(c)
+				userHandler(c)
+			}
+		}
 
 		onGatheringCompleteHandler := func() {}
 		if handler, ok := g.onGatheringCompleteHandler.Load().(func()); ok && handler != nil {
@@ -192,6 +350,12 @@ func (g *ICEGatherer) Gather() error { //nolint:cyclop
 
 				return
 			}
+			c.Priority = networkCost.priority(candidate)
+			if !g.api.settingEngine.keepICECandidate(c, false) {
+				g.log.Debugf("Local candidate %s dropped by the candidate filter", c)
+
+				return
+			}
 			onLocalCandidateHandler(&c)
 		} else {
 			g.setState(ICEGathererStateComplete)
@@ -203,9 +367,63 @@ func (g *ICEGatherer) Gather() error { //nolint:cyclop
 		return err
 	}
 
+	g.provideTURNCredentials()
+
 	return agent.GatherCandidates()
 }
 
+// turnCredentialProvider provides the credentials of the TURN URLs of an ICE server.
+type turnCredentialProvider struct {
+	urls    []*stun.URI
+	provide func() (TURNCredentials, error)
+}
+
+// provideTURNCredentials sets the credentials of the TURN URLs the agent gathers the
+// relay candidates with, before it gathers. The URLs whose provider fails keep their
+// previous credentials.
+func (g *ICEGatherer) provideTURNCredentials() {
+	if len(g.turnCredentialProviders) == 0 {
+		return
+	}
+
+	var expires time.Time
+	for _, provider := range g.turnCredentialProviders {
+		credentials, err := provider.provide()
+		if err != nil {
+			g.log.Warnf("Failed to get the credentials of the TURN server %s: %v", provider.urls[0], err)
+
+			continue
+		}
+
+		for _, url := range provider.urls {
+			url.Username, url.Password = credentials.Username, credentials.Password
+		}
+		if !credentials.Expires.IsZero() && (expires.IsZero() || credentials.Expires.Before(expires)) {
+			expires = credentials.Expires
+		}
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.turnCredentialsTimer != nil {
+		g.turnCredentialsTimer.Stop()
+		g.turnCredentialsTimer = nil
+	}
+	if expires.IsZero() || g.agent == nil {
+		return
+	}
+
+	// Renewed a margin before they expire, or half way through if they expire sooner
+	renewIn := time.Until(expires)
+	renewIn -= min(turnCredentialsRenewMargin, renewIn/2)
+	g.turnCredentialsTimer = time.AfterFunc(renewIn, func() {
+		if handler, ok := g.onTURNCredentialsExpiring.Load().(func()); ok && handler != nil {
+			handler()
+		}
+	})
+}
+
 // set media stream identification tag and media description index for this gatherer.
 func (g *ICEGatherer) setMediaStreamIdentification(mid string, mLineIndex uint16) {
 	g.sdpMid.Store(mid)
@@ -228,9 +446,14 @@ func (g *ICEGatherer) close(shouldGracefullyClose bool) error {
 	g.lock.Lock()
 	defer g.lock.Unlock()
 
+	if g.turnCredentialsTimer != nil {
+		g.turnCredentialsTimer.Stop()
+		g.turnCredentialsTimer = nil
+	}
 	if g.agent == nil {
 		return nil
 	}
+	g.closeMDNSResolver()
 	if shouldGracefullyClose {
 		if err := g.agent.GracefulClose(); err != nil {
 			return err
@@ -267,7 +490,7 @@ func (g *ICEGatherer) GetLocalParameters() (ICEParameters, error) {
 	return ICEParameters{
 		UsernameFragment: frag,
 		Password:         pwd,
-		ICELite:          false,
+		ICELite:          g.api.settingEngine.candidates.ICELite,
 	}, nil
 }
 
@@ -295,7 +518,23 @@ func (g *ICEGatherer) GetLocalCandidates() ([]ICECandidate, error) {
 
 	sdpMLineIndex := uint16(g.sdpMLineIndex.Load()) //nolint:gosec // G115
 
-	return newICECandidatesFromICE(iceCandidates, sdpMid, sdpMLineIndex)
+	candidates, err := newICECandidatesFromICE(iceCandidates, sdpMid, sdpMLineIndex)
+	if err != nil {
+		return nil, err
+	}
+	networkCost := g.getNetworkCost()
+	for i := range candidates {
+		candidates[i].Priority = networkCost.priority(iceCandidates[i])
+	}
+
+	kept := candidates[:0]
+	for _, candidate := range candidates {
+		if g.api.settingEngine.keepICECandidate(candidate, false) {
+			kept = append(kept, candidate)
+		}
+	}
+
+	return kept, nil
 }
 
 // OnLocalCandidate sets an event handler which fires when a new local ICE candidate is available
@@ -317,6 +556,9 @@ func (g *ICEGatherer) State() ICEGathererState {
 func (g *ICEGatherer) setState(s ICEGathererState) {
 	atomicStoreICEGathererState(&g.state, s)
 
+	if handler, ok := g.internalOnStateChangeHandler.Load().(func(state ICEGathererState)); ok && handler != nil {
+		handler(s)
+	}
 	if handler, ok := g.onStateChangeHandler.Load().(func(state ICEGathererState)); ok && handler != nil {
 		handler(s)
 	}
@@ -329,7 +571,55 @@ func (g *ICEGatherer) getAgent() *ice.Agent {
 	return g.agent
 }
 
-func (g *ICEGatherer) collectStats(collector *statsReportCollector) {
+// closeMDNSResolver stops the mDNS resolver, if any. g.lock must be held.
+func (g *ICEGatherer) closeMDNSResolver() {
+	if g.mDNSResolver == nil {
+		return
+	}
+	if err := g.mDNSResolver.close(); err != nil {
+		g.log.Warnf("Failed to close the mDNS resolver: %v", err)
+	}
+	g.mDNSResolver = nil
+}
+
+func (g *ICEGatherer) getPathMTUProbes() *pathMTUProbes {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return g.pathMTUProbes
+}
+
+func (g *ICEGatherer) getNetworkCost() *iceNetworkCost {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return g.networkCost
+}
+
+func (g *ICEGatherer) getPairMigration() *icePairMigration {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return g.pairMigration
+}
+
+func (g *ICEGatherer) getRenomination() *iceRenomination {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return g.renomination
+}
+
+func (g *ICEGatherer) getMDNSResolver() *mDNSResolver {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return g.mDNSResolver
+}
+
+// collectStats emits the stats of the candidates and candidate pairs. conn is the
+// connection of the ICETransport, if any, its bytes are counted on the selected pair.
+func (g *ICEGatherer) collectStats(collector *statsReportCollector, conn *ice.Conn) {
 	agent := g.getAgent()
 	if agent == nil {
 		return
@@ -337,6 +627,7 @@ func (g *ICEGatherer) collectStats(collector *statsReportCollector) {
 
 	collector.Collecting()
 	go func(collector *statsReportCollector, agent *ice.Agent) {
+		selected, hasSelected := agent.GetSelectedCandidatePairStats()
 		for _, candidatePairStats := range agent.GetCandidatePairsStats() {
 			collector.Collecting()
 
@@ -348,6 +639,16 @@ func (g *ICEGatherer) collectStats(collector *statsReportCollector) {
 				continue
 			}
 
+			stats.TransportID = "iceTransport"
+			// The ICE agent doesn't count the bytes per pair, all the packets of the
+			// transport are sent and received on the selected pair
+			if conn != nil && hasSelected &&
+				candidatePairStats.LocalCandidateID == selected.LocalCandidateID &&
+				candidatePairStats.RemoteCandidateID == selected.RemoteCandidateID {
+				stats.BytesSent = conn.BytesSent()
+				stats.BytesReceived = conn.BytesReceived()
+			}
+
 			collector.Collect(stats.ID, stats)
 		}
 
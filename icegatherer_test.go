@@ -8,7 +8,10 @@ package webrtc
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -57,6 +60,47 @@ func TestNewICEGatherer_Success(t *testing.T) {
 	assert.NoError(t, gatherer.Close())
 }
 
+func TestICEGatherer_Lite(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetLite(true)
+	settingEngine.SetIncludeLoopbackCandidate(true)
+	settingEngine.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+
+	gatherer, err := NewAPI(WithSettingEngine(settingEngine)).NewICEGatherer(ICEGatherOptions{
+		ICEServers: []ICEServer{{URLs: []string{"stun:127.0.0.1:3478"}}},
+	})
+	assert.NoError(t, err)
+
+	gatherFinished := make(chan struct{})
+	gatherer.OnLocalCandidate(func(i *ICECandidate) {
+		if i == nil {
+			close(gatherFinished)
+		}
+	})
+	assert.NoError(t, gatherer.Gather())
+	<-gatherFinished
+
+	params, err := gatherer.GetLocalParameters()
+	assert.NoError(t, err)
+	assert.True(t, params.ICELite)
+
+	// Only host candidates are gathered, the STUN server is not used
+	candidates, err := gatherer.GetLocalCandidates()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, candidates)
+	for _, candidate := range candidates {
+		assert.Equal(t, ICECandidateTypeHost, candidate.Typ)
+	}
+
+	assert.NoError(t, gatherer.Close())
+}
+
 func TestICEGather_mDNSCandidateGathering(t *testing.T) {
 	// Limit runtime in case of deadlocks
 	lim := test.TimeOut(time.Second * 20)
@@ -139,6 +183,47 @@ func TestICEGatherer_AlreadyClosed(t *testing.T) {
 	})
 }
 
+func TestICEGatherer_TURNCredentialProvider(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	var provided atomic.Int32
+	gatherer, err := NewAPI().NewICEGatherer(ICEGatherOptions{
+		ICEServers: []ICEServer{{
+			URLs: []string{"stun:127.0.0.1:3478", "turn:127.0.0.1:3478"},
+			CredentialProvider: func() (TURNCredentials, error) {
+				n := provided.Add(1)
+
+				return TURNCredentials{
+					Username: fmt.Sprintf("%d:pion", n),
+					Password: "secret",
+					Expires:  time.Now().Add(100 * time.Millisecond),
+				}, nil
+			},
+		}},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, gatherer.createAgent())
+
+	expiring := make(chan struct{})
+	gatherer.onTURNCredentialsExpiring.Store(func() {
+		close(expiring)
+	})
+
+	// Only the TURN URLs get the credentials provided
+	gatherer.provideTURNCredentials()
+	assert.Equal(t, int32(1), provided.Load())
+	assert.Empty(t, gatherer.validatedServers[0].Username)
+	assert.Equal(t, "1:pion", gatherer.validatedServers[1].Username)
+	assert.Equal(t, "secret", gatherer.validatedServers[1].Password)
+
+	<-expiring
+	assert.NoError(t, gatherer.Close())
+}
+
 func TestNewICEGathererSetMediaStreamIdentification(t *testing.T) { //nolint:cyclop
 	// Limit runtime in case of deadlocks
 	lim := test.TimeOut(time.Second * 20)
@@ -191,3 +276,67 @@ func TestNewICEGathererSetMediaStreamIdentification(t *testing.T) { //nolint:cyc
 
 	assert.NoError(t, gatherer.Close())
 }
+
+func TestICECandidateFilter(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// The offerer only uses its loopback candidates
+	offerSettingEngine := SettingEngine{}
+	offerSettingEngine.SetIncludeLoopbackCandidate(true)
+	offerSettingEngine.SetICECandidateFilter(func(candidate ICECandidate, remote bool) bool {
+		return remote || candidate.Address == "127.0.0.1"
+	})
+
+	var (
+		mu               sync.Mutex
+		remoteCandidates []ICECandidate
+	)
+	answerSettingEngine := SettingEngine{}
+	answerSettingEngine.SetIncludeLoopbackCandidate(true)
+	answerSettingEngine.SetICECandidateFilter(func(candidate ICECandidate, remote bool) bool {
+		if remote {
+			mu.Lock()
+			remoteCandidates = append(remoteCandidates, candidate)
+			mu.Unlock()
+		}
+
+		return true
+	})
+
+	pcOffer, err := NewAPI(WithSettingEngine(offerSettingEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewAPI(WithSettingEngine(answerSettingEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	connected, connectedFunc := context.WithCancel(context.Background())
+	pcAnswer.OnICEConnectionStateChange(func(state ICEConnectionState) {
+		if state == ICEConnectionStateConnected {
+			connectedFunc()
+		}
+	})
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	<-connected.Done()
+
+	localCandidates, err := pcOffer.iceGatherer.GetLocalCandidates()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, localCandidates)
+	for _, candidate := range localCandidates {
+		assert.Equal(t, "127.0.0.1", candidate.Address)
+	}
+
+	mu.Lock()
+	assert.NotEmpty(t, remoteCandidates)
+	for _, candidate := range remoteCandidates {
+		assert.Equal(t, "127.0.0.1", candidate.Address)
+	}
+	mu.Unlock()
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -0,0 +1,515 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/logging"
+	"github.com/pion/stun/v3"
+	"github.com/pion/transport/v3/deadline"
+)
+
+// NewICEShardedUDPMux creates an ice.UDPMux serving many PeerConnections on a single UDP
+// port like NewICEUDPMux, for servers handling thousands of them. The connection of each
+// remote address is looked up in one of shards tables, each with its own lock, so the
+// packets of different remotes don't contend on a global lock, and readers goroutines
+// read the socket in parallel. On Linux each reader reads batches of packets with
+// recvmmsg, saving a syscall per packet, and the packets written are sent in batches with
+// sendmmsg by readers writer goroutines, each sending the packets of a part of the remote
+// addresses, so a burst of packets, like a paced keyframe, takes a few syscalls. The
+// packets of a batch sent to the same remote with the same size are sent as a single
+// message with UDP GSO when the kernel supports it. The packets received from a remote may
+// be reordered when readers is above 1, as the network can do, the ones sent are not.
+// shards and readers default to 64 and 1 when 0.
+//
+// As the packets are written in the background, an error writing a packet is returned by
+// the next WriteTo of the connection of the ICE agent which wrote it.
+//
+// udpConn must listen on a specific address, not an unspecified one like 0.0.0.0, as the
+// address is used for the host candidates.
+func NewICEShardedUDPMux(logger logging.LeveledLogger, udpConn net.PacketConn, shards, readers int) ice.UDPMux {
+	if shards <= 0 {
+		shards = defaultICEShardedUDPMuxShards
+	}
+	if readers <= 0 {
+		readers = 1
+	}
+
+	mux := &shardedUDPMux{
+		conn:   udpConn,
+		log:    logger,
+		shards: make([]shardedUDPMuxShard, shards),
+		conns:  map[shardedUDPMuxKey]*shardedUDPMuxConn{},
+		closed: make(chan struct{}),
+		pool: sync.Pool{New: func() any {
+			buf := make([]byte, receiveMTU)
+
+			return &buf
+		}},
+	}
+	for i := range mux.shards {
+		mux.shards[i].conns = map[netip.AddrPort]*shardedUDPMuxConn{}
+	}
+
+	mux.loops.Add(readers)
+	for i := 0; i < readers; i++ {
+		go mux.readLoop()
+	}
+	if conn, ok := udpConn.(*net.UDPConn); ok {
+		mux.startBatchWrites(conn, readers)
+	}
+
+	return mux
+}
+
+type shardedUDPMux struct {
+	conn   net.PacketConn
+	log    logging.LeveledLogger
+	shards []shardedUDPMuxShard
+	pool   sync.Pool
+	loops  sync.WaitGroup
+	// writers send the packets in batches, the writer of a remote address is picked by its
+	// hash. They are nil when the packets are written one by one.
+	writers []chan shardedUDPMuxPacket
+
+	// mu guards the connections by ufrag, used when a remote address is first seen
+	mu     sync.Mutex
+	conns  map[shardedUDPMuxKey]*shardedUDPMuxConn
+	closed chan struct{}
+}
+
+// shardedUDPMuxShard is a part of the table of the connections by remote address.
+type shardedUDPMuxShard struct {
+	mu    sync.RWMutex
+	conns map[netip.AddrPort]*shardedUDPMuxConn
+}
+
+type shardedUDPMuxKey struct {
+	ufrag  string
+	isIPv6 bool
+}
+
+type shardedUDPMuxPacket struct {
+	buf  *[]byte
+	n    int
+	addr netip.AddrPort
+	// conn is the connection which wrote the packet, nil for the packets read.
+	conn *shardedUDPMuxConn
+}
+
+// GetConn returns the connection of the local ufrag, creating it if needed.
+func (m *shardedUDPMux) GetConn(ufrag string, addr net.Addr) (net.PacketConn, error) {
+	if addr.String() != m.conn.LocalAddr().String() {
+		return nil, errICEShardedUDPMuxInvalidAddress
+	}
+
+	key := shardedUDPMuxKey{ufrag: ufrag}
+	if udpAddr, ok := addr.(*net.UDPAddr); ok && udpAddr.IP.To4() == nil {
+		key.isIPv6 = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	select {
+	case <-m.closed:
+		return nil, io.ErrClosedPipe
+	default:
+	}
+
+	if conn, ok := m.conns[key]; ok {
+		return conn, nil
+	}
+
+	conn := &shardedUDPMuxConn{
+		mux:          m,
+		key:          key,
+		packets:      make(chan shardedUDPMuxPacket, iceShardedUDPMuxConnQueueSize),
+		closed:       make(chan struct{}),
+		readDeadline: deadline.New(),
+	}
+	m.conns[key] = conn
+
+	return conn, nil
+}
+
+// RemoveConnByUfrag closes and removes the connections of the local ufrag.
+func (m *shardedUDPMux) RemoveConnByUfrag(ufrag string) {
+	m.mu.Lock()
+	var removed []*shardedUDPMuxConn
+	for _, isIPv6 := range []bool{false, true} {
+		if conn, ok := m.conns[shardedUDPMuxKey{ufrag: ufrag, isIPv6: isIPv6}]; ok {
+			removed = append(removed, conn)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, conn := range removed {
+		_ = conn.Close()
+	}
+}
+
+// GetListenAddresses returns the address of the socket.
+func (m *shardedUDPMux) GetListenAddresses() []net.Addr {
+	return []net.Addr{m.conn.LocalAddr()}
+}
+
+// Close closes the connections and the socket.
+func (m *shardedUDPMux) Close() error {
+	m.mu.Lock()
+	select {
+	case <-m.closed:
+		m.mu.Unlock()
+
+		return nil
+	default:
+	}
+	close(m.closed)
+	conns := m.conns
+	m.conns = map[shardedUDPMuxKey]*shardedUDPMuxConn{}
+	m.mu.Unlock()
+
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+	err := m.conn.Close()
+	m.loops.Wait()
+
+	return err
+}
+
+// hashAddrPort returns the FNV-1a of the address and port.
+func hashAddrPort(addr netip.AddrPort) uint32 {
+	hash := uint32(2166136261)
+	ip := addr.Addr().As16()
+	for _, b := range ip {
+		hash = (hash ^ uint32(b)) * 16777619
+	}
+	hash = (hash ^ uint32(addr.Port()>>8)) * 16777619
+	hash = (hash ^ uint32(addr.Port()&0xff)) * 16777619
+
+	return hash
+}
+
+func (m *shardedUDPMux) shard(addr netip.AddrPort) *shardedUDPMuxShard {
+	return &m.shards[hashAddrPort(addr)%uint32(len(m.shards))] //nolint:gosec // G115
+}
+
+// register makes conn receive the packets of addr.
+func (m *shardedUDPMux) register(conn *shardedUDPMuxConn, addr netip.AddrPort) {
+	shard := m.shard(addr)
+	shard.mu.Lock()
+	previous := shard.conns[addr]
+	shard.conns[addr] = conn
+	shard.mu.Unlock()
+
+	if previous != nil && previous != conn {
+		previous.removeAddress(addr)
+	}
+	conn.addAddress(addr)
+}
+
+func (m *shardedUDPMux) removeAddresses(conn *shardedUDPMuxConn) {
+	for _, addr := range conn.getAddresses() {
+		shard := m.shard(addr)
+		shard.mu.Lock()
+		if shard.conns[addr] == conn {
+			delete(shard.conns, addr)
+		}
+		shard.mu.Unlock()
+	}
+}
+
+func (m *shardedUDPMux) readLoop() {
+	defer m.loops.Done()
+
+	udpConn, isUDPConn := m.conn.(*net.UDPConn)
+	if isUDPConn && m.readBatches(udpConn) {
+		return
+	}
+
+	for {
+		buf, _ := m.pool.Get().(*[]byte)
+
+		var (
+			n    int
+			addr netip.AddrPort
+			err  error
+		)
+		if isUDPConn {
+			n, addr, err = udpConn.ReadFromUDPAddrPort(*buf)
+		} else {
+			var netAddr net.Addr
+			if n, netAddr, err = m.conn.ReadFrom(*buf); err == nil {
+				udpAddr, ok := netAddr.(*net.UDPAddr)
+				if !ok {
+					m.pool.Put(buf)
+
+					continue
+				}
+				addr = udpAddr.AddrPort()
+			}
+		}
+
+		if err != nil {
+			m.pool.Put(buf)
+			if m.isReadFatal(err) {
+				return
+			}
+
+			continue
+		}
+
+		m.dispatch(buf, n, addr)
+	}
+}
+
+// isReadFatal reports whether the read loops must stop after err, logging it unless
+// the socket was closed.
+func (m *shardedUDPMux) isReadFatal(err error) bool {
+	if os.IsTimeout(err) {
+		return false
+	}
+	if !errors.Is(err, net.ErrClosed) && !errors.Is(err, io.EOF) {
+		m.log.Errorf("Failed to read UDP packet: %v", err)
+	}
+
+	return true
+}
+
+// dispatch queues the packet read in buf for the connection of its remote address.
+func (m *shardedUDPMux) dispatch(buf *[]byte, n int, addr netip.AddrPort) {
+	addr = netip.AddrPortFrom(addr.Addr().Unmap(), addr.Port())
+	if conn := m.lookup(addr, (*buf)[:n]); conn != nil {
+		conn.push(shardedUDPMuxPacket{buf: buf, n: n, addr: addr})
+	} else {
+		m.pool.Put(buf)
+	}
+}
+
+// queueWrite queues a copy of p for the batch writer of addr, the write is reported done
+// as the one of a socket buffer.
+func (m *shardedUDPMux) queueWrite(conn *shardedUDPMuxConn, p []byte, addr netip.AddrPort) (int, error) {
+	buf, _ := m.pool.Get().(*[]byte)
+	n := copy(*buf, p)
+
+	writer := m.writers[hashAddrPort(addr)%uint32(len(m.writers))] //nolint:gosec // G115
+	select {
+	case writer <- shardedUDPMuxPacket{buf: buf, n: n, addr: addr, conn: conn}:
+		return n, nil
+	case <-m.closed:
+		m.pool.Put(buf)
+
+		return 0, io.ErrClosedPipe
+	}
+}
+
+// lookup returns the connection of the remote address of a packet. The connection of
+// an address not seen yet is found by the ufrag of its STUN binding request.
+func (m *shardedUDPMux) lookup(addr netip.AddrPort, packet []byte) *shardedUDPMuxConn {
+	shard := m.shard(addr)
+	shard.mu.RLock()
+	conn := shard.conns[addr]
+	shard.mu.RUnlock()
+	if conn != nil || !stun.IsMessage(packet) {
+		return conn
+	}
+
+	msg := &stun.Message{Raw: append([]byte{}, packet...)}
+	if err := msg.Decode(); err != nil {
+		m.log.Warnf("Failed to decode STUN message from %s: %v", addr, err)
+
+		return nil
+	}
+	username, err := msg.Get(stun.AttrUsername)
+	if err != nil {
+		m.log.Warnf("No Username attribute in STUN message from %s", addr)
+
+		return nil
+	}
+
+	ufrag, _, _ := strings.Cut(string(username), ":")
+	m.mu.Lock()
+	conn = m.conns[shardedUDPMuxKey{ufrag: ufrag, isIPv6: !addr.Addr().Is4()}]
+	m.mu.Unlock()
+	if conn != nil {
+		m.register(conn, addr)
+	}
+
+	return conn
+}
+
+// shardedUDPMuxConn is the net.PacketConn of an ICE agent, see NewICEShardedUDPMux.
+type shardedUDPMuxConn struct {
+	mux          *shardedUDPMux
+	key          shardedUDPMuxKey
+	packets      chan shardedUDPMuxPacket
+	closed       chan struct{}
+	closeOnce    sync.Once
+	readDeadline *deadline.Deadline
+	// writeErr is the error of a packet written in the background, returned by the next
+	// WriteTo.
+	writeErr atomic.Pointer[error]
+
+	mu        sync.Mutex
+	addresses []netip.AddrPort
+}
+
+// failWrite records the error of a packet written in the background for the next WriteTo,
+// it reports whether no error was pending.
+func (c *shardedUDPMuxConn) failWrite(err error) bool {
+	return c.writeErr.Swap(&err) == nil
+}
+
+// push queues a packet, it is dropped when the queue is full as with a socket buffer.
+func (c *shardedUDPMuxConn) push(packet shardedUDPMuxPacket) {
+	select {
+	case <-c.closed:
+		c.mux.pool.Put(packet.buf)
+	case c.packets <- packet:
+	default:
+		c.mux.pool.Put(packet.buf)
+	}
+}
+
+func (c *shardedUDPMuxConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	select {
+	case packet := <-c.packets:
+		n := copy(p, (*packet.buf)[:packet.n])
+		c.mux.pool.Put(packet.buf)
+		if n < packet.n {
+			return n, net.UDPAddrFromAddrPort(packet.addr), io.ErrShortBuffer
+		}
+
+		return n, net.UDPAddrFromAddrPort(packet.addr), nil
+	case <-c.readDeadline.Done():
+		return 0, nil, os.ErrDeadlineExceeded
+	case <-c.closed:
+		return 0, nil, io.EOF
+	}
+}
+
+func (c *shardedUDPMuxConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return 0, errICEShardedUDPMuxInvalidAddress
+	}
+	addrPort := udpAddr.AddrPort()
+	addrPort = netip.AddrPortFrom(addrPort.Addr().Unmap(), addrPort.Port())
+	if !c.hasAddress(addrPort) {
+		c.mux.register(c, addrPort)
+	}
+	if err := c.writeErr.Swap(nil); err != nil {
+		return 0, *err
+	}
+	if c.mux.writers != nil && len(p) <= receiveMTU {
+		return c.mux.queueWrite(c, p, addrPort)
+	}
+
+	return c.mux.conn.WriteTo(p, addr)
+}
+
+func (c *shardedUDPMuxConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+
+		c.mux.mu.Lock()
+		if c.mux.conns[c.key] == c {
+			delete(c.mux.conns, c.key)
+		}
+		c.mux.mu.Unlock()
+		c.mux.removeAddresses(c)
+
+		for {
+			select {
+			case packet := <-c.packets:
+				c.mux.pool.Put(packet.buf)
+			default:
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func (c *shardedUDPMuxConn) LocalAddr() net.Addr {
+	return c.mux.conn.LocalAddr()
+}
+
+func (c *shardedUDPMuxConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+func (c *shardedUDPMuxConn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.Set(t)
+
+	return nil
+}
+
+// SetWriteDeadline is a no-op, the writes to the shared socket don't block.
+func (c *shardedUDPMuxConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+func (c *shardedUDPMuxConn) addAddress(addr netip.AddrPort) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, existing := range c.addresses {
+		if existing == addr {
+			return
+		}
+	}
+	c.addresses = append(c.addresses, addr)
+}
+
+func (c *shardedUDPMuxConn) removeAddress(addr netip.AddrPort) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, existing := range c.addresses {
+		if existing == addr {
+			c.addresses = append(c.addresses[:i], c.addresses[i+1:]...)
+
+			return
+		}
+	}
+}
+
+func (c *shardedUDPMuxConn) hasAddress(addr netip.AddrPort) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, existing := range c.addresses {
+		if existing == addr {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *shardedUDPMuxConn) getAddresses() []netip.AddrPort {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]netip.AddrPort{}, c.addresses...)
+}
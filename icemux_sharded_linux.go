@@ -0,0 +1,232 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+// +build linux
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"unsafe"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+	"golang.org/x/sys/unix"
+)
+
+// batchConn reads and writes batches of packets on a UDP socket.
+type batchConn interface {
+	ReadBatch(msgs []ipv4.Message, flags int) (int, error)
+	WriteBatch(msgs []ipv4.Message, flags int) (int, error)
+}
+
+func newBatchConn(udpConn *net.UDPConn) batchConn {
+	if addr, ok := udpConn.LocalAddr().(*net.UDPAddr); ok && addr.IP.To4() == nil {
+		return ipv6.NewPacketConn(udpConn)
+	}
+
+	return ipv4.NewPacketConn(udpConn)
+}
+
+// readBatches reads the socket with recvmmsg until it is closed, dispatching the
+// packets of each batch read.
+func (m *shardedUDPMux) readBatches(udpConn *net.UDPConn) bool {
+	conn := newBatchConn(udpConn)
+
+	msgs := make([]ipv4.Message, iceShardedUDPMuxBatchSize)
+	bufs := make([]*[]byte, len(msgs))
+	defer func() {
+		for _, buf := range bufs {
+			if buf != nil {
+				m.pool.Put(buf)
+			}
+		}
+	}()
+
+	for {
+		// The buffers dispatched by the previous batch are replaced
+		for i := range msgs {
+			if bufs[i] == nil {
+				bufs[i], _ = m.pool.Get().(*[]byte)
+				msgs[i].Buffers = [][]byte{*bufs[i]}
+			}
+		}
+
+		n, err := conn.ReadBatch(msgs, 0)
+		if err != nil {
+			if m.isReadFatal(err) {
+				return true
+			}
+
+			continue
+		}
+
+		for i := 0; i < n; i++ {
+			if udpAddr, ok := msgs[i].Addr.(*net.UDPAddr); ok {
+				m.dispatch(bufs[i], msgs[i].N, udpAddr.AddrPort())
+				bufs[i] = nil
+			}
+		}
+	}
+}
+
+// startBatchWrites starts the writers sending the packets queued by WriteTo in batches.
+func (m *shardedUDPMux) startBatchWrites(udpConn *net.UDPConn, writers int) {
+	gso := supportsGSO(udpConn)
+	m.writers = make([]chan shardedUDPMuxPacket, writers)
+	for i := range m.writers {
+		m.writers[i] = make(chan shardedUDPMuxPacket, iceShardedUDPMuxWriteQueueSize)
+		m.loops.Add(1)
+		go m.writeBatches(newBatchConn(udpConn), m.writers[i], gso)
+	}
+}
+
+// supportsGSO reports whether the kernel segments the UDP packets of the socket.
+func supportsGSO(udpConn *net.UDPConn) bool {
+	rawConn, err := udpConn.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var sockErr error
+	if err = rawConn.Control(func(fd uintptr) {
+		_, sockErr = unix.GetsockoptInt(int(fd), unix.IPPROTO_UDP, unix.UDP_SEGMENT)
+	}); err != nil {
+		return false
+	}
+
+	return sockErr == nil
+}
+
+// shardedUDPMuxBatch is the messages of a batch of packets sent with sendmmsg.
+type shardedUDPMuxBatch struct {
+	msgs []ipv4.Message
+	// ends is the number of packets in the messages up to each one.
+	ends []int
+	// oobs holds the UDP_SEGMENT control message of each message.
+	oobs [][]byte
+	gso  bool
+}
+
+// writeBatches sends the packets queued with sendmmsg until the mux is closed. It waits
+// for a packet, then sends it with the ones queued meanwhile, so the packets are not
+// delayed and the batches grow with the bursts.
+func (m *shardedUDPMux) writeBatches(conn batchConn, writes chan shardedUDPMuxPacket, gso bool) {
+	defer m.loops.Done()
+
+	batch := &shardedUDPMuxBatch{
+		msgs: make([]ipv4.Message, iceShardedUDPMuxBatchSize),
+		ends: make([]int, iceShardedUDPMuxBatchSize),
+		oobs: make([][]byte, iceShardedUDPMuxBatchSize),
+		gso:  gso,
+	}
+	for i := range batch.oobs {
+		batch.oobs[i] = make([]byte, unix.CmsgSpace(2))
+	}
+	packets := make([]shardedUDPMuxPacket, 0, iceShardedUDPMuxBatchSize)
+	for {
+		select {
+		case packet := <-writes:
+			packets = append(packets, packet)
+		case <-m.closed:
+			return
+		}
+
+	collect:
+		for len(packets) < cap(packets) {
+			select {
+			case packet := <-writes:
+				packets = append(packets, packet)
+			default:
+				break collect
+			}
+		}
+
+		m.sendBatch(conn, batch, packets)
+
+		for i, packet := range packets {
+			m.pool.Put(packet.buf)
+			packets[i] = shardedUDPMuxPacket{}
+		}
+		packets = packets[:0]
+	}
+}
+
+// sendBatch sends packets, the ones failing are dropped as a write error of a socket and
+// the error is returned by the next WriteTo of their connection.
+func (m *shardedUDPMux) sendBatch(conn batchConn, batch *shardedUDPMuxBatch, packets []shardedUDPMuxPacket) {
+	for sent := 0; sent < len(packets); {
+		count := batch.prepare(packets[sent:])
+		n, err := conn.WriteBatch(batch.msgs[:count], 0)
+		if err != nil {
+			// The segmentation fails with EIO without checksum offload, the packets are
+			// sent again one by one
+			if batch.gso && len(batch.msgs[0].Buffers) > 1 && errors.Is(err, unix.EIO) {
+				m.log.Warnf("Disabling UDP GSO after failing to send segmented packets: %v", err)
+				batch.gso = false
+
+				continue
+			}
+
+			for _, packet := range packets[sent : sent+batch.ends[0]] {
+				if packet.conn != nil && packet.conn.failWrite(err) {
+					m.log.Warnf("Failed to write UDP packet to %s: %v", packet.addr, err)
+				}
+			}
+			n = 1
+		}
+		sent += batch.ends[n-1]
+	}
+
+	for i := range batch.msgs {
+		for j := range batch.msgs[i].Buffers {
+			batch.msgs[i].Buffers[j] = nil
+		}
+		batch.msgs[i].Buffers = batch.msgs[i].Buffers[:0]
+		batch.msgs[i].Addr = nil
+	}
+}
+
+// prepare fills the messages with the packets and returns how many are used. With GSO,
+// consecutive packets to the same address are sent as a single message when they have
+// the same size, the last one being smaller or equal.
+func (b *shardedUDPMuxBatch) prepare(packets []shardedUDPMuxPacket) int {
+	count := 0
+	for start := 0; start < len(packets) && count < len(b.msgs); count++ {
+		size, total := packets[start].n, packets[start].n
+		end := start + 1
+		for b.gso && end < len(packets) && end-start < iceShardedUDPMuxGSOMaxSegments &&
+			packets[end].addr == packets[start].addr &&
+			packets[end-1].n == size && packets[end].n <= size &&
+			total+packets[end].n <= iceShardedUDPMuxGSOMaxSize {
+			total += packets[end].n
+			end++
+		}
+
+		msg := &b.msgs[count]
+		for _, packet := range packets[start:end] {
+			msg.Buffers = append(msg.Buffers, (*packet.buf)[:packet.n])
+		}
+		msg.Addr = net.UDPAddrFromAddrPort(packets[start].addr)
+		msg.OOB = nil
+		if end-start > 1 {
+			msg.OOB = b.oobs[count]
+			putGSOControl(msg.OOB, size)
+		}
+		b.ends[count] = end
+		start = end
+	}
+
+	return count
+}
+
+// putGSOControl writes the UDP_SEGMENT control message of segments of size bytes in oob.
+func putGSOControl(oob []byte, size int) {
+	header := (*unix.Cmsghdr)(unsafe.Pointer(&oob[0])) //nolint:gosec // G103
+	header.Level = unix.SOL_UDP
+	header.Type = unix.UDP_SEGMENT
+	header.SetLen(unix.CmsgLen(2))
+	binary.NativeEndian.PutUint16(oob[unix.CmsgLen(0):], uint16(size)) //nolint:gosec // G115
+}
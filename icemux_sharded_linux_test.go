@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+// +build linux
+
+package webrtc
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/ipv4"
+)
+
+func TestShardedUDPMuxBatch_Prepare(t *testing.T) {
+	first := netip.MustParseAddrPort("127.0.0.1:1")
+	second := netip.MustParseAddrPort("127.0.0.1:2")
+	packet := func(addr netip.AddrPort, n int) shardedUDPMuxPacket {
+		buf := make([]byte, n)
+
+		return shardedUDPMuxPacket{buf: &buf, n: n, addr: addr}
+	}
+	packets := []shardedUDPMuxPacket{
+		packet(first, 100), packet(first, 100), packet(first, 50), // segmented, the last one smaller
+		packet(first, 100), packet(first, 200), // larger than the previous ones
+		packet(second, 200), // another address
+		packet(second, 200),
+	}
+
+	batch := &shardedUDPMuxBatch{
+		msgs: make([]ipv4.Message, iceShardedUDPMuxBatchSize),
+		ends: make([]int, iceShardedUDPMuxBatchSize),
+		oobs: make([][]byte, iceShardedUDPMuxBatchSize),
+		gso:  true,
+	}
+	for i := range batch.oobs {
+		batch.oobs[i] = make([]byte, 32)
+	}
+	count := batch.prepare(packets)
+	assert.Equal(t, 4, count)
+	assert.Equal(t, []int{3, 4, 5, 7}, batch.ends[:count])
+	assert.Len(t, batch.msgs[0].Buffers, 3)
+	assert.NotNil(t, batch.msgs[0].OOB)
+	assert.Nil(t, batch.msgs[1].OOB)
+	assert.Equal(t, "127.0.0.1:2", batch.msgs[3].Addr.String())
+
+	batch = &shardedUDPMuxBatch{
+		msgs: make([]ipv4.Message, iceShardedUDPMuxBatchSize),
+		ends: make([]int, iceShardedUDPMuxBatchSize),
+	}
+	assert.Equal(t, len(packets), batch.prepare(packets))
+}
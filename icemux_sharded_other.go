@@ -0,0 +1,17 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !linux
+// +build !linux
+
+package webrtc
+
+import "net"
+
+// readBatches is not supported on this platform, the packets are read one by one.
+func (m *shardedUDPMux) readBatches(*net.UDPConn) bool {
+	return false
+}
+
+// startBatchWrites is not supported on this platform, the packets are written one by one.
+func (m *shardedUDPMux) startBatchWrites(*net.UDPConn, int) {}
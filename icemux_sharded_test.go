@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pion/logging"
+	"github.com/pion/stun/v3"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestICEShardedUDPMux(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	udpConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IP{127, 0, 0, 1}})
+	require.NoError(t, err)
+	mux := NewICEShardedUDPMux(logging.NewDefaultLoggerFactory().NewLogger("test"), udpConn, 4, 2)
+	assert.Equal(t, []net.Addr{udpConn.LocalAddr()}, mux.GetListenAddresses())
+
+	_, err = mux.GetConn("local", &net.UDPAddr{IP: net.IP{127, 0, 0, 2}, Port: 1})
+	assert.ErrorIs(t, err, errICEShardedUDPMuxInvalidAddress)
+
+	first, err := mux.GetConn("first", udpConn.LocalAddr())
+	require.NoError(t, err)
+	second, err := mux.GetConn("second", udpConn.LocalAddr())
+	require.NoError(t, err)
+	again, err := mux.GetConn("first", udpConn.LocalAddr())
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	remote, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IP{127, 0, 0, 1}})
+	require.NoError(t, err)
+
+	read := func(conn net.PacketConn) []byte {
+		buf := make([]byte, receiveMTU)
+		n, addr, readErr := conn.ReadFrom(buf)
+		require.NoError(t, readErr)
+		assert.Equal(t, remote.LocalAddr().String(), addr.String())
+
+		return buf[:n]
+	}
+
+	// Packets from an unknown address are dropped until its binding request
+	_, err = remote.WriteTo([]byte("media"), udpConn.LocalAddr())
+	require.NoError(t, err)
+
+	request, err := stun.Build(stun.TransactionID, stun.BindingRequest, stun.NewUsername("second:remote"))
+	require.NoError(t, err)
+	_, err = remote.WriteTo(request.Raw, udpConn.LocalAddr())
+	require.NoError(t, err)
+	assert.Equal(t, request.Raw, read(second))
+
+	_, err = remote.WriteTo([]byte("media"), udpConn.LocalAddr())
+	require.NoError(t, err)
+	assert.Equal(t, []byte("media"), read(second))
+
+	// The packets of a burst are all received, on Linux in batches
+	var sent, received [][]byte
+	for i := 0; i < 3*iceShardedUDPMuxBatchSize; i++ {
+		packet := []byte{byte(i)}
+		sent = append(sent, packet)
+		_, err = remote.WriteTo(packet, udpConn.LocalAddr())
+		require.NoError(t, err)
+	}
+	for range sent {
+		received = append(received, read(second))
+	}
+	assert.ElementsMatch(t, sent, received)
+
+	// A burst written is received in order, on Linux it is sent in batches
+	for _, packet := range sent {
+		_, err = second.WriteTo(packet, remote.LocalAddr())
+		require.NoError(t, err)
+	}
+	buf := make([]byte, receiveMTU)
+	for _, packet := range sent {
+		n, _, readErr := remote.ReadFrom(buf)
+		require.NoError(t, readErr)
+		assert.Equal(t, packet, buf[:n])
+	}
+
+	// A write failing in the background is returned by the next one
+	unreachable := &net.UDPAddr{IP: net.IPv6loopback, Port: 9} // from an IPv4 socket
+	assert.Eventually(t, func() bool {
+		_, err = second.WriteTo([]byte("unreachable"), unreachable)
+
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+	_, err = second.WriteTo([]byte("recovered"), remote.LocalAddr())
+	assert.NoError(t, err)
+	n, _, err := remote.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("recovered"), buf[:n])
+
+	// The address moves to the connection which writes to it
+	_, err = first.WriteTo([]byte("check"), remote.LocalAddr())
+	require.NoError(t, err)
+	_, err = remote.WriteTo([]byte("moved"), udpConn.LocalAddr())
+	require.NoError(t, err)
+	assert.Equal(t, []byte("moved"), read(first))
+
+	require.NoError(t, second.SetReadDeadline(time.Now()))
+	_, _, err = second.ReadFrom(make([]byte, receiveMTU))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	mux.RemoveConnByUfrag("first")
+	_, _, err = first.ReadFrom(make([]byte, receiveMTU))
+	assert.Error(t, err)
+
+	require.NoError(t, remote.Close())
+	require.NoError(t, mux.Close())
+	_, err = mux.GetConn("third", udpConn.LocalAddr())
+	assert.Error(t, err)
+}
+
+func TestICEShardedUDPMux_PeerConnection(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	udpConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IP{127, 0, 0, 1}})
+	require.NoError(t, err)
+	mux := NewICEShardedUDPMux(logging.NewDefaultLoggerFactory().NewLogger("test"), udpConn, 0, 0)
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetICEUDPMux(mux)
+	settingEngine.SetIncludeLoopbackCandidate(true)
+	settingEngine.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+
+	offerSettingEngine := SettingEngine{}
+	offerSettingEngine.SetIncludeLoopbackCandidate(true)
+	offerSettingEngine.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+
+	pcOffer, err := NewAPI(WithSettingEngine(offerSettingEngine)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	opened, openedFunc := context.WithCancel(context.Background())
+	pcAnswer.OnDataChannel(func(d *DataChannel) {
+		d.OnOpen(openedFunc)
+	})
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	<-opened.Done()
+
+	closePairNow(t, pcOffer, pcAnswer)
+	require.NoError(t, mux.Close())
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"sync"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/transport/v3"
+)
+
+// iceNetworkCost lowers the priorities of the local candidates by the cost of their
+// network interface, see SettingEngine.SetICENetworkCost.
+type iceNetworkCost struct {
+	net  transport.Net
+	cost func(interfaceName string) uint16
+
+	mu sync.Mutex
+	// interfaces are the names of the interfaces by address, listed again once an
+	// address is missing.
+	interfaces map[string]string
+}
+
+func newICENetworkCost(n transport.Net, cost func(interfaceName string) uint16) *iceNetworkCost {
+	return &iceNetworkCost{net: n, cost: cost, interfaces: map[string]string{}}
+}
+
+// candidateCost returns the cost of the interface the local candidate is gathered on,
+// the one of its base for the reflexive candidates. The relay candidates, and those of
+// unknown interfaces, cost nothing.
+func (c *iceNetworkCost) candidateCost(candidate ice.Candidate) uint16 {
+	if c == nil {
+		return 0
+	}
+
+	address := candidate.Address()
+	switch candidate.Type() {
+	case ice.CandidateTypeHost:
+	case ice.CandidateTypeServerReflexive, ice.CandidateTypePeerReflexive:
+		related := candidate.RelatedAddress()
+		if related == nil {
+			return 0
+		}
+		address = related.Address
+	default:
+		return 0
+	}
+
+	name, ok := c.interfaceName(address)
+	if !ok {
+		return 0
+	}
+
+	return c.cost(name)
+}
+
+// priority returns the priority of the local candidate, its local preference lowered by
+// its cost down to 0. The type preference still comes first.
+func (c *iceNetworkCost) priority(candidate ice.Candidate) uint32 {
+	priority := candidate.Priority()
+	if c == nil {
+		return priority
+	}
+
+	localPreference := (priority >> 8) & 0xffff
+
+	return priority - min(uint32(c.candidateCost(candidate)), localPreference)<<8
+}
+
+func (c *iceNetworkCost) interfaceName(address string) (string, bool) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return "", false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if name, ok := c.interfaces[ip.String()]; ok {
+		return name, true
+	}
+
+	interfaces, err := c.net.Interfaces()
+	if err != nil {
+		return "", false
+	}
+	c.interfaces = map[string]string{}
+	for _, iface := range interfaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				c.interfaces[ipNet.IP.String()] = iface.Name
+			}
+		}
+	}
+	name, ok := c.interfaces[ip.String()]
+
+	return name, ok
+}
@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/logging"
+	"github.com/pion/transport/v3/test"
+	"github.com/pion/transport/v3/vnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newICENetworkCostNet returns a Net whose eth0 interface has the address 1.2.3.4.
+func newICENetworkCostNet(t *testing.T) (*vnet.Router, *vnet.Net) {
+	t.Helper()
+
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	require.NoError(t, err)
+	vnetNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{"1.2.3.4"}})
+	require.NoError(t, err)
+	require.NoError(t, wan.AddNet(vnetNet))
+	require.NoError(t, wan.Start())
+
+	return wan, vnetNet
+}
+
+func TestICENetworkCost(t *testing.T) {
+	wan, vnetNet := newICENetworkCostNet(t)
+	cost := newICENetworkCost(vnetNet, func(interfaceName string) uint16 {
+		if interfaceName == "eth0" {
+			return 100
+		}
+
+		return 0
+	})
+
+	host, err := ice.NewCandidateHost(&ice.CandidateHostConfig{
+		Network: "udp", Address: "1.2.3.4", Port: 1000, Component: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint16(100), cost.candidateCost(host))
+	assert.Equal(t, host.Priority()-100<<8, cost.priority(host))
+
+	// The server reflexive candidates cost as much as their base
+	srflx, err := ice.NewCandidateServerReflexive(&ice.CandidateServerReflexiveConfig{
+		Network: "udp", Address: "5.6.7.8", Port: 2000, Component: 1, RelAddr: "1.2.3.4", RelPort: 1000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint16(100), cost.candidateCost(srflx))
+	assert.Less(t, cost.priority(host), host.Priority())
+	assert.Greater(t, cost.priority(host), srflx.Priority())
+
+	unknown, err := ice.NewCandidateHost(&ice.CandidateHostConfig{
+		Network: "udp", Address: "9.9.9.9", Port: 1000, Component: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, unknown.Priority(), cost.priority(unknown))
+
+	relay, err := ice.NewCandidateRelay(&ice.CandidateRelayConfig{
+		Network: "udp", Address: "5.6.7.8", Port: 3000, Component: 1, RelAddr: "1.2.3.4", RelPort: 1000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, relay.Priority(), cost.priority(relay))
+
+	// The local preference doesn't go below 0
+	var none *iceNetworkCost
+	assert.Equal(t, host.Priority(), none.priority(host))
+	cost.cost = func(string) uint16 {
+		return 0xffff
+	}
+	assert.Equal(t, host.Priority()&^(0xffff<<8), cost.priority(host))
+	assert.NoError(t, wan.Stop())
+}
+
+func TestICEGatherer_NetworkCost(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	wan, vnetNet := newICENetworkCostNet(t)
+
+	settings := SettingEngine{}
+	settings.SetNet(vnetNet)
+	settings.SetICENetworkCost(func(string) uint16 {
+		return 10
+	})
+	gatherer, err := NewAPI(WithSettingEngine(settings)).NewICEGatherer(ICEGatherOptions{})
+	require.NoError(t, err)
+
+	gathered := make(chan *ICECandidate, 1)
+	gatherer.OnLocalCandidate(func(candidate *ICECandidate) {
+		if candidate != nil {
+			select {
+			case gathered <- candidate:
+			default:
+			}
+		}
+	})
+	require.NoError(t, gatherer.Gather())
+	candidate := <-gathered
+
+	iceCandidates, err := gatherer.getAgent().GetLocalCandidates()
+	require.NoError(t, err)
+	require.NotEmpty(t, iceCandidates)
+	assert.Equal(t, iceCandidates[0].Priority()-10<<8, candidate.Priority)
+
+	candidates, err := gatherer.GetLocalCandidates()
+	require.NoError(t, err)
+	assert.Equal(t, candidate.Priority, candidates[0].Priority)
+
+	assert.NoError(t, gatherer.Close())
+	assert.NoError(t, wan.Stop())
+}
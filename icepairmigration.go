@@ -0,0 +1,400 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/logging"
+	"github.com/pion/randutil"
+	"github.com/pion/stun/v3"
+)
+
+// icePairMigration keeps checking the candidate pairs of an ICETransport once one is
+// selected, and migrates to another pair when the selected one degrades, see
+// SettingEngine.SetICEPairMigration. The agent stops checking the other pairs once a pair
+// is selected, so the checks are binding requests sent on the sockets gathered by ICE,
+// their responses matched like the path MTU probes. The agent only selects another pair
+// on a binding request read on it, the migration is done by its BindingRequestHandler.
+// The controlling agent only migrates with a remote advertising the renomination ICE
+// option, the controlled agent follows the migrations with iceRenomination.
+type icePairMigration struct {
+	probes              *pathMTUProbes
+	interval, rttMargin time.Duration
+	// cost is the cost of the local candidates, nil if they all cost the same.
+	cost *iceNetworkCost
+	log  logging.LeveledLogger
+
+	mu sync.Mutex
+	// selected returns the pair selected by the agent, it is nil until the ICETransport
+	// is connected.
+	selected    func() *ice.CandidatePair
+	controlling bool
+	// onMigrate is called as the agent is about to select target.
+	onMigrate func()
+	// target is the pair the controlling agent migrates to, selected once the remote
+	// answers its nomination with a check on it.
+	target *ice.CandidatePair
+	health map[string]*icePairHealth
+
+	// nomination numbers the nominations, see iceRenomination.
+	nomination uint32
+}
+
+// icePairHealth holds the last checks of a candidate pair, oldest first.
+type icePairHealth struct {
+	pair   *ice.CandidatePair
+	checks []icePairCheck
+}
+
+type icePairCheck struct {
+	rtt  time.Duration
+	lost bool
+}
+
+func newICEPairMigration(
+	probes *pathMTUProbes,
+	interval, rttMargin time.Duration,
+	cost *iceNetworkCost,
+	log logging.LeveledLogger,
+) *icePairMigration {
+	return &icePairMigration{
+		probes:    probes,
+		interval:  interval,
+		rttMargin: rttMargin,
+		cost:      cost,
+		log:       log,
+		health:    map[string]*icePairHealth{},
+	}
+}
+
+// bindingRequestHandler returns the BindingRequestHandler of the agent, next is the one
+// set with SettingEngine.SetICEBindingRequestHandler, its switches come first.
+func (m *icePairMigration) bindingRequestHandler(
+	next func(*stun.Message, ice.Candidate, ice.Candidate, *ice.CandidatePair) bool,
+) func(*stun.Message, ice.Candidate, ice.Candidate, *ice.CandidatePair) bool {
+	return func(msg *stun.Message, local, remote ice.Candidate, pair *ice.CandidatePair) bool {
+		if next != nil && next(msg, local, remote, pair) {
+			return true
+		}
+
+		return m.handleBindingRequest(msg, local, remote)
+	}
+}
+
+// handleBindingRequest reports whether the agent selects the pair of local and remote,
+// a binding request was read on it. It runs on the loop of the agent.
+func (m *icePairMigration) handleBindingRequest(_ *stun.Message, local, remote ice.Candidate) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.controlling || m.target == nil || !m.target.Local.Equal(local) || !m.target.Remote.Equal(remote) {
+		return false
+	}
+	m.target = nil
+	m.onMigrate()
+
+	return true
+}
+
+// run checks the candidate pairs every interval until ctx is done, once the ICETransport
+// is connected. selected returns the pair selected by the agent, and remote reports
+// whether the remote advertised the renomination ICE option. The controlled agent
+// doesn't check, it follows the nominations of the controlling one.
+func (m *icePairMigration) run(
+	ctx context.Context,
+	agent *ice.Agent,
+	role ICERole,
+	selected func() *ice.CandidatePair,
+	remote func() bool,
+	onMigrate func(),
+) {
+	m.mu.Lock()
+	m.selected, m.controlling, m.onMigrate = selected, role == ICERoleControlling, onMigrate
+	m.mu.Unlock()
+
+	if role != ICERoleControlling {
+		return
+	}
+
+	tieBreaker := randutil.NewMathRandomGenerator().Uint64()
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// A remote that doesn't renominate would not follow the migrations
+		if !remote() {
+			continue
+		}
+		if err := m.check(ctx, agent, tieBreaker); err != nil {
+			m.log.Debugf("Failed to check the ICE candidate pairs: %v", err)
+		}
+	}
+}
+
+// check sends a check on every pair, then migrates if the selected one degraded. The
+// agent isn't called with the lock held, the BindingRequestHandler takes it on its loop.
+func (m *icePairMigration) check(ctx context.Context, agent *ice.Agent, tieBreaker uint64) error {
+	selected := m.selected()
+	if selected == nil {
+		// Restarting, the pairs change
+		m.mu.Lock()
+		m.target = nil
+		m.health = map[string]*icePairHealth{}
+		m.mu.Unlock()
+
+		return nil
+	}
+
+	pairs, err := icePairMigrationPairs(agent)
+	if err != nil {
+		return err
+	}
+	localUfrag, _, err := agent.GetLocalUserCredentials()
+	if err != nil {
+		return err
+	}
+	remoteUfrag, remotePwd, err := agent.GetRemoteUserCredentials()
+	if err != nil {
+		return err
+	}
+	username := remoteUfrag + ":" + localUfrag
+
+	m.mu.Lock()
+	target, nomination := m.target, m.nomination
+	m.mu.Unlock()
+
+	checks := make([]icePairCheck, len(pairs))
+	var wg sync.WaitGroup
+	for i, pair := range pairs {
+		// The pending nomination is sent again with the check of its pair
+		var pairNomination uint32
+		if target != nil && target.Local.Equal(pair.Local) && target.Remote.Equal(pair.Remote) {
+			pairNomination = nomination
+		}
+		msg, err := icePairMigrationRequest(pair, username, remotePwd, tieBreaker, pairNomination)
+		if err != nil {
+			return err
+		}
+
+		wg.Add(1)
+		go func(i int, pair *ice.CandidatePair, msg *stun.Message) {
+			defer wg.Done()
+			checks[i] = m.probe(ctx, pair, msg)
+		}(i, pair, msg)
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	health := make(map[string]*icePairHealth, len(pairs))
+	for i, pair := range pairs {
+		key := pair.Local.ID() + "/" + pair.Remote.ID()
+		pairHealth, ok := m.health[key]
+		if !ok {
+			pairHealth = &icePairHealth{pair: pair}
+		}
+		pairHealth.record(checks[i])
+		health[key] = pairHealth
+	}
+	m.health = health
+
+	m.target = m.evaluate(selected)
+	target, nominated := m.target, target
+	if target != nil && target != nominated {
+		m.nomination++
+	}
+	nomination = m.nomination
+	m.mu.Unlock()
+
+	if target == nil || target == nominated {
+		return nil
+	}
+
+	m.log.Infof("Migrating from the degraded ICE candidate pair %s to %s", selected, target)
+	msg, err := icePairMigrationRequest(target, username, remotePwd, tieBreaker, nomination)
+	if err != nil {
+		return err
+	}
+	m.probe(ctx, target, msg)
+
+	return nil
+}
+
+// evaluate returns the pair to migrate to from selected, the pending migration until the
+// remote answers it, nil if selected hasn't degraded or no other pair is better. Of the
+// pairs whose last checks were all answered, the pair migrated to once selected lost
+// checks is the one of the cheapest interface with the lowest round trip time, the
+// cheapest pair is migrated to if its round trip time is within the margin, and the
+// pair with the lowest round trip time once selected exceeds it by the margin. It must
+// be called with the lock held.
+func (m *icePairMigration) evaluate(selected *ice.CandidatePair) *ice.CandidatePair {
+	current, ok := m.health[selected.Local.ID()+"/"+selected.Remote.ID()]
+	if !ok {
+		return nil
+	}
+
+	if m.target != nil {
+		target, ok := m.health[m.target.Local.ID()+"/"+m.target.Remote.ID()]
+		if !ok || target == current || target.checks[len(target.checks)-1].lost {
+			return nil
+		}
+
+		return target.pair
+	}
+
+	lost, rtt, ok := current.summary()
+	if !ok {
+		return nil
+	}
+
+	var cheapest, fastest *icePairHealth
+	var cheapestCost uint16
+	var cheapestRTT, fastestRTT time.Duration
+	for _, health := range m.health {
+		if health == current {
+			continue
+		}
+		healthLost, healthRTT, ok := health.summary()
+		if !ok || healthLost != 0 {
+			continue
+		}
+
+		healthCost := m.cost.candidateCost(health.pair.Local)
+		if cheapest == nil || healthCost < cheapestCost || (healthCost == cheapestCost && healthRTT < cheapestRTT) {
+			cheapest, cheapestCost, cheapestRTT = health, healthCost, healthRTT
+		}
+		if fastest == nil || healthRTT < fastestRTT {
+			fastest, fastestRTT = health, healthRTT
+		}
+	}
+
+	switch {
+	case cheapest == nil:
+		return nil
+	case lost >= icePairMigrationMaxLoss:
+		return cheapest.pair
+	case cheapestCost < m.cost.candidateCost(current.pair.Local) &&
+		(m.rttMargin == 0 || cheapestRTT <= rtt+m.rttMargin):
+		return cheapest.pair
+	case m.rttMargin != 0 && rtt > fastestRTT+m.rttMargin:
+		return fastest.pair
+	default:
+		return nil
+	}
+}
+
+// probe sends the check msg on pair, and waits for its response.
+func (m *icePairMigration) probe(ctx context.Context, pair *ice.CandidatePair, msg *stun.Message) icePairCheck {
+	done := m.probes.add(msg.TransactionID, false)
+	sent := time.Now()
+	if _, err := pair.Write(msg.Raw); err != nil {
+		m.probes.remove(msg.TransactionID)
+
+		return icePairCheck{lost: true}
+	}
+
+	timer := time.NewTimer(icePairMigrationProbeTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return icePairCheck{rtt: time.Since(sent)}
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	m.probes.remove(msg.TransactionID)
+
+	return icePairCheck{lost: true}
+}
+
+func (h *icePairHealth) record(check icePairCheck) {
+	h.checks = append(h.checks, check)
+	if len(h.checks) > icePairMigrationWindow {
+		h.checks = h.checks[1:]
+	}
+}
+
+// summary returns how many of the last checks were lost and the average round trip time
+// of the others, false until icePairMigrationWindow checks were sent.
+func (h *icePairHealth) summary() (lost int, rtt time.Duration, ok bool) {
+	if len(h.checks) < icePairMigrationWindow {
+		return 0, 0, false
+	}
+
+	for _, check := range h.checks {
+		if check.lost {
+			lost++
+		} else {
+			rtt += check.rtt
+		}
+	}
+	if delivered := len(h.checks) - lost; delivered != 0 {
+		rtt /= time.Duration(delivered)
+	}
+
+	return lost, rtt, true
+}
+
+// icePairMigrationPairs returns the pairs checked, the UDP pairs of host and server
+// reflexive local candidates, paired by network type like the agent does. The responses
+// to the checks sent through a relay are not read by the sockets matching them.
+func icePairMigrationPairs(agent *ice.Agent) ([]*ice.CandidatePair, error) {
+	locals, err := agent.GetLocalCandidates()
+	if err != nil {
+		return nil, err
+	}
+	remotes, err := agent.GetRemoteCandidates()
+	if err != nil {
+		return nil, err
+	}
+
+	var pairs []*ice.CandidatePair
+	for _, local := range locals {
+		if local.Type() == ice.CandidateTypeRelay || !local.NetworkType().IsUDP() {
+			continue
+		}
+		for _, remote := range remotes {
+			if remote.NetworkType() == local.NetworkType() {
+				pairs = append(pairs, &ice.CandidatePair{Local: local, Remote: remote})
+			}
+		}
+	}
+
+	return pairs, nil
+}
+
+// icePairMigrationRequest returns a check of pair, a binding request of the controlling
+// agent, with USE-CANDIDATE and the NOMINATION attribute nomination if it nominates the
+// pair, nomination isn't 0 then, see iceRenomination.
+func icePairMigrationRequest(
+	pair *ice.CandidatePair,
+	username, password string,
+	tieBreaker uint64,
+	nomination uint32,
+) (*stun.Message, error) {
+	setters := []stun.Setter{
+		stun.BindingRequest,
+		stun.TransactionID,
+		stun.NewUsername(username),
+		ice.AttrControlling(tieBreaker),
+		ice.PriorityAttr(pair.Local.Priority()),
+	}
+	if nomination != 0 {
+		value := binary.BigEndian.AppendUint32(nil, nomination)
+		setters = append(setters, ice.UseCandidate(), stun.RawAttribute{Type: stunAttrNomination, Value: value})
+	}
+
+	return stun.Build(append(setters, stun.NewShortTermIntegrity(password), stun.Fingerprint)...)
+}
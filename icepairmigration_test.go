@@ -0,0 +1,247 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/logging"
+	"github.com/pion/transport/v3"
+	"github.com/pion/transport/v3/test"
+	"github.com/pion/transport/v3/vnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestICEPairMigration(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	require.NoError(t, err)
+
+	// The offerer, controlling, reaches the answerer from two addresses
+	newPC := func(ips ...string) *PeerConnection {
+		vnetNet, netErr := vnet.NewNet(&vnet.NetConfig{StaticIPs: ips})
+		require.NoError(t, netErr)
+		require.NoError(t, wan.AddNet(vnetNet))
+
+		settings := SettingEngine{}
+		settings.SetNet(vnetNet)
+		require.NoError(t, settings.SetICEPairMigration(50*time.Millisecond, 0))
+		pc, pcErr := NewAPI(WithSettingEngine(settings)).NewPeerConnection(Configuration{})
+		require.NoError(t, pcErr)
+
+		return pc
+	}
+	offerPC, answerPC := newPC("1.2.3.4", "1.2.3.6"), newPC("1.2.3.5")
+	require.NoError(t, wan.Start())
+
+	// Everything sent from or to the degraded address is dropped
+	degraded := &atomic.Value{}
+	wan.AddChunkFilter(func(c vnet.Chunk) bool {
+		ip, ok := degraded.Load().(string)
+		if !ok {
+			return true
+		}
+
+		return c.SourceAddr().(*net.UDPAddr).IP.String() != ip && //nolint:forcetypeassert
+			c.DestinationAddr().(*net.UDPAddr).IP.String() != ip //nolint:forcetypeassert
+	})
+
+	changes := make(chan SelectedCandidatePairChange, 10)
+	offerPC.OnSelectedCandidatePairChange(func(change SelectedCandidatePairChange) {
+		changes <- change
+	})
+
+	dc, err := offerPC.CreateDataChannel("migration", nil)
+	require.NoError(t, err)
+	received := make(chan struct{}, 10)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		d.OnMessage(func(DataChannelMessage) {
+			received <- struct{}{}
+		})
+	})
+	opened := make(chan struct{})
+	dc.OnOpen(func() {
+		close(opened)
+	})
+
+	require.NoError(t, signalPair(offerPC, answerPC))
+	initial := <-changes
+	assert.Equal(t, SelectedCandidatePairChangeReasonInitial, initial.Reason)
+	<-opened
+
+	degraded.Store(initial.Pair.Local.Address)
+	migrated := <-changes
+	assert.Equal(t, SelectedCandidatePairChangeReasonDegraded, migrated.Reason)
+	assert.Equal(t, initial.Pair, migrated.Previous)
+	assert.NotEqual(t, initial.Pair.Local.Address, migrated.Pair.Local.Address)
+
+	// The answerer followed the nomination
+	assert.Eventually(t, func() bool {
+		pair, pairErr := answerPC.SCTP().Transport().ICETransport().GetSelectedCandidatePair()
+
+		return pairErr == nil && pair != nil && pair.Remote.Address == migrated.Pair.Local.Address
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, dc.SendText("migrated"))
+	<-received
+	assert.Equal(t, ICETransportStateConnected, offerPC.SCTP().Transport().ICETransport().State())
+
+	closePairNow(t, offerPC, answerPC)
+	assert.NoError(t, wan.Stop())
+}
+
+func TestICEPairMigration_RemoteWithoutRenomination(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	require.NoError(t, err)
+
+	// The answerer, controlled, doesn't enable the migration
+	newPC := func(migration bool, ips ...string) *PeerConnection {
+		vnetNet, netErr := vnet.NewNet(&vnet.NetConfig{StaticIPs: ips})
+		require.NoError(t, netErr)
+		require.NoError(t, wan.AddNet(vnetNet))
+
+		settings := SettingEngine{}
+		settings.SetNet(vnetNet)
+		if migration {
+			require.NoError(t, settings.SetICEPairMigration(50*time.Millisecond, 0))
+		}
+		pc, pcErr := NewAPI(WithSettingEngine(settings)).NewPeerConnection(Configuration{})
+		require.NoError(t, pcErr)
+
+		return pc
+	}
+	offerPC, answerPC := newPC(true, "1.2.3.4", "1.2.3.6"), newPC(false, "1.2.3.5")
+	require.NoError(t, wan.Start())
+
+	degraded := &atomic.Value{}
+	wan.AddChunkFilter(func(c vnet.Chunk) bool {
+		ip, ok := degraded.Load().(string)
+		if !ok {
+			return true
+		}
+
+		return c.SourceAddr().(*net.UDPAddr).IP.String() != ip && //nolint:forcetypeassert
+			c.DestinationAddr().(*net.UDPAddr).IP.String() != ip //nolint:forcetypeassert
+	})
+
+	changes := make(chan SelectedCandidatePairChange, 10)
+	offerPC.OnSelectedCandidatePairChange(func(change SelectedCandidatePairChange) {
+		changes <- change
+	})
+	connected := make(chan struct{})
+	answerPC.OnICEConnectionStateChange(func(state ICEConnectionState) {
+		if state == ICEConnectionStateConnected {
+			close(connected)
+		}
+	})
+
+	require.NoError(t, signalPair(offerPC, answerPC))
+	assert.True(t, hasICEOption(answerPC.RemoteDescription().parsed, iceOptionRenomination))
+	assert.False(t, hasICEOption(offerPC.RemoteDescription().parsed, iceOptionRenomination))
+	initial := <-changes
+	<-connected
+	answerPair, err := answerPC.SCTP().Transport().ICETransport().GetSelectedCandidatePair()
+	require.NoError(t, err)
+	require.NotNil(t, answerPair)
+
+	// The offerer doesn't nominate another pair, the answerer would not follow it
+	degraded.Store(initial.Pair.Local.Address)
+	select {
+	case change := <-changes:
+		assert.Fail(t, "unexpected migration", change)
+	case <-time.After(time.Second):
+	}
+	pair, err := answerPC.SCTP().Transport().ICETransport().GetSelectedCandidatePair()
+	require.NoError(t, err)
+	assert.Equal(t, answerPair.Remote.Address, pair.Remote.Address)
+	assert.Equal(t, initial.Pair.Local.Address, pair.Remote.Address)
+
+	closePairNow(t, offerPC, answerPC)
+	assert.NoError(t, wan.Stop())
+}
+
+func TestICEPairMigration_NetworkCost(t *testing.T) {
+	wan, vnetNet := newICENetworkCostNet(t)
+	costNet := &networkMonitorNet{Net: vnetNet}
+	iface := transport.NewInterface(net.Interface{Index: 2, MTU: 1500, Name: "eth1", Flags: net.FlagUp})
+	iface.AddAddress(&net.IPNet{IP: net.ParseIP("1.2.3.5"), Mask: net.CIDRMask(24, 32)})
+	costNet.added = append(costNet.added, iface)
+
+	// eth0 is metered
+	cost := newICENetworkCost(costNet, func(interfaceName string) uint16 {
+		if interfaceName == "eth0" {
+			return 100
+		}
+
+		return 0
+	})
+
+	newPair := func(address string) *ice.CandidatePair {
+		local, err := ice.NewCandidateHost(&ice.CandidateHostConfig{
+			Network: "udp", Address: address, Port: 1000, Component: 1,
+		})
+		require.NoError(t, err)
+		remote, err := ice.NewCandidateHost(&ice.CandidateHostConfig{
+			Network: "udp", Address: "1.2.3.6", Port: 1000, Component: 1,
+		})
+		require.NoError(t, err)
+
+		return &ice.CandidatePair{Local: local, Remote: remote}
+	}
+	metered, wired := newPair("1.2.3.4"), newPair("1.2.3.5")
+
+	log := logging.NewDefaultLoggerFactory().NewLogger("test")
+	evaluate := func(
+		rttMargin time.Duration,
+		selected *ice.CandidatePair,
+		rtts map[*ice.CandidatePair]time.Duration,
+	) *ice.CandidatePair {
+		migration := newICEPairMigration(nil, time.Second, rttMargin, cost, log)
+		for pair, rtt := range rtts {
+			health := &icePairHealth{pair: pair}
+			for i := 0; i < icePairMigrationWindow; i++ {
+				health.record(icePairCheck{rtt: rtt})
+			}
+			migration.health[pair.Local.ID()+"/"+pair.Remote.ID()] = health
+		}
+
+		return migration.evaluate(selected)
+	}
+
+	rtts := map[*ice.CandidatePair]time.Duration{metered: 10 * time.Millisecond, wired: 20 * time.Millisecond}
+	assert.Equal(t, wired, evaluate(0, metered, rtts))
+	assert.Nil(t, evaluate(0, wired, rtts))
+
+	// The cheapest pair is migrated to within the margin only
+	assert.Equal(t, wired, evaluate(20*time.Millisecond, metered, rtts))
+	assert.Nil(t, evaluate(5*time.Millisecond, metered, rtts))
+	assert.Equal(t, metered, evaluate(5*time.Millisecond, wired, rtts))
+
+	assert.NoError(t, wan.Stop())
+}
@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pion/stun/v3"
+	"golang.org/x/net/proxy"
+)
+
+// NewHTTPProxyDialer creates a proxy.Dialer tunneling the connections through the HTTP
+// proxy of proxyURL with the CONNECT method, as browsers do for TURN over TCP and TLS.
+// The proxy is reached over TLS when the scheme of proxyURL is https, and the user of
+// proxyURL is sent with Basic authentication. The connections to the proxy are dialed
+// with forward, proxy.Direct when nil.
+func NewHTTPProxyDialer(proxyURL *url.URL, forward proxy.Dialer) (proxy.Dialer, error) {
+	if proxyURL.Scheme != "http" && proxyURL.Scheme != "https" {
+		return nil, fmt.Errorf("%w: %s", errICEProxyUnsupportedScheme, proxyURL.Scheme)
+	}
+	if forward == nil {
+		forward = proxy.Direct
+	}
+
+	return &httpProxyDialer{proxyURL: proxyURL, forward: forward}, nil
+}
+
+type httpProxyDialer struct {
+	proxyURL *url.URL
+	forward  proxy.Dialer
+}
+
+func (d *httpProxyDialer) Dial(_, addr string) (net.Conn, error) {
+	proxyAddr := d.proxyURL.Host
+	if d.proxyURL.Port() == "" {
+		port := "80"
+		if d.proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(d.proxyURL.Hostname(), port)
+	}
+
+	conn, err := d.forward.Dial("tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	if err = conn.SetDeadline(time.Now().Add(iceProxyConnectTimeout)); err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	if d.proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: d.proxyURL.Hostname(), MinVersion: tls.VersionTLS12})
+		if err = tlsConn.Handshake(); err != nil {
+			_ = conn.Close()
+
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if user := d.proxyURL.User; user != nil {
+		password, _ := user.Password()
+		req.Header.Set("Proxy-Authorization",
+			"Basic "+base64.StdEncoding.EncodeToString([]byte(user.Username()+":"+password)))
+	}
+	if err = req.Write(conn); err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_ = conn.Close()
+
+		return nil, fmt.Errorf("%w: %s", errICEProxyConnectFailed, resp.Status)
+	}
+
+	if err = conn.SetDeadline(time.Time{}); err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// bufferedConn reads the bytes buffered while reading the CONNECT response first.
+type bufferedConn struct {
+	net.Conn
+
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// turnProxyDialer dials the TURN servers through a proxy, connecting over TLS to the
+// TURNS ones as ICE sends the TURN messages as is on the connections of the proxy.
+type turnProxyDialer struct {
+	proxy.Dialer
+
+	// tlsConfigs are the TLS configs of the TURNS servers by address
+	tlsConfigs map[string]*tls.Config
+}
+
+// newTURNProxyDialer returns the dialer of servers through dialer. tlsConfigs are the
+// configs of ICEServer.TLSConfig by address, the other TURNS servers are connected to
+// with the default config.
+func newTURNProxyDialer(dialer proxy.Dialer, servers []*stun.URI, tlsConfigs map[string]*tls.Config) proxy.Dialer {
+	configs := map[string]*tls.Config{}
+	for _, server := range servers {
+		if server.Scheme != stun.SchemeTypeTURNS || server.Proto != stun.ProtoTypeTCP {
+			continue
+		}
+
+		address := turnServerAddress(server)
+		config := &tls.Config{MinVersion: tls.VersionTLS12}
+		if tlsConfig, ok := tlsConfigs[address]; ok {
+			config = tlsConfig.Clone()
+		}
+		if config.ServerName == "" {
+			config.ServerName = server.Host
+		}
+		configs[address] = config
+	}
+	if len(configs) == 0 {
+		return dialer
+	}
+
+	return &turnProxyDialer{Dialer: dialer, tlsConfigs: configs}
+}
+
+// turnServerAddress returns the address of the TURN server, formatted as the one dialed
+// by ICE.
+func turnServerAddress(server *stun.URI) string {
+	return fmt.Sprintf("%s:%d", server.Host, server.Port)
+}
+
+func (d *turnProxyDialer) Dial(network, addr string) (net.Conn, error) {
+	conn, err := d.Dialer.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	config, ok := d.tlsConfigs[addr]
+	if !ok {
+		return conn, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), iceProxyConnectTimeout)
+	defer cancel()
+
+	tlsConn := tls.Client(conn, config)
+	if err = tlsConn.HandshakeContext(ctx); err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
@@ -0,0 +1,214 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pion/stun/v3"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/proxy"
+)
+
+// serveHTTPProxy serves the CONNECT requests of the user pion until listener is closed.
+func serveHTTPProxy(t *testing.T, listener net.Listener) {
+	t.Helper()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer func() {
+				_ = conn.Close()
+			}()
+
+			req, err := http.ReadRequest(bufio.NewReader(conn))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, http.MethodConnect, req.Method)
+			if req.Header.Get("Proxy-Authorization") != "Basic cGlvbjpzZWNyZXQ=" {
+				_, _ = io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+
+				return
+			}
+
+			target, err := net.Dial("tcp", req.Host)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer func() {
+				_ = target.Close()
+			}()
+
+			_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+			go func() {
+				_, _ = io.Copy(target, conn)
+				_ = target.Close()
+			}()
+			_, _ = io.Copy(conn, target)
+		}()
+	}
+}
+
+func TestHTTPProxyDialer(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// The server echoes the bytes it receives
+	server, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		conn, acceptErr := server.Accept()
+		if acceptErr != nil {
+			return
+		}
+		_, _ = io.Copy(conn, conn)
+		_ = conn.Close()
+	}()
+
+	proxyListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go serveHTTPProxy(t, proxyListener)
+
+	_, err = NewHTTPProxyDialer(&url.URL{Scheme: "ftp", Host: proxyListener.Addr().String()}, nil)
+	assert.ErrorIs(t, err, errICEProxyUnsupportedScheme)
+
+	dialer, err := NewHTTPProxyDialer(&url.URL{Scheme: "http", Host: proxyListener.Addr().String()}, nil)
+	require.NoError(t, err)
+	_, err = dialer.Dial("tcp4", server.Addr().String())
+	assert.ErrorIs(t, err, errICEProxyConnectFailed)
+
+	dialer, err = NewHTTPProxyDialer(&url.URL{
+		Scheme: "http",
+		Host:   proxyListener.Addr().String(),
+		User:   url.UserPassword("pion", "secret"),
+	}, nil)
+	require.NoError(t, err)
+	conn, err := dialer.Dial("tcp4", server.Addr().String())
+	require.NoError(t, err)
+
+	// ICE reads the local address of the connections of the proxy as a TCP address
+	_, isTCPAddr := conn.LocalAddr().(*net.TCPAddr)
+	assert.True(t, isTCPAddr)
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ping"), buf)
+
+	assert.NoError(t, conn.Close())
+	assert.NoError(t, proxyListener.Close())
+	assert.NoError(t, server.Close())
+}
+
+func TestTURNProxyDialer(t *testing.T) {
+	turnURI, err := stun.ParseURI("turn:turn.example.com:3478?transport=tcp")
+	require.NoError(t, err)
+	turnsURI, err := stun.ParseURI("turns:turn.example.com:5349?transport=tcp")
+	require.NoError(t, err)
+
+	// The dialer is used as is without TURNS servers
+	assert.Equal(t, proxy.Direct, newTURNProxyDialer(proxy.Direct, []*stun.URI{turnURI}, nil))
+
+	dialer, ok := newTURNProxyDialer(proxy.Direct, []*stun.URI{turnURI, turnsURI}, nil).(*turnProxyDialer)
+	require.True(t, ok)
+	require.Len(t, dialer.tlsConfigs, 1)
+	assert.Equal(t, "turn.example.com", dialer.tlsConfigs["turn.example.com:5349"].ServerName)
+
+	// The configs of the ICE servers are copied, their server name defaulting to the host
+	config := &tls.Config{NextProtos: []string{"stun.turn"}, MinVersion: tls.VersionTLS13}
+	dialer, ok = newTURNProxyDialer(proxy.Direct, []*stun.URI{turnsURI}, map[string]*tls.Config{
+		"turn.example.com:5349": config,
+	}).(*turnProxyDialer)
+	require.True(t, ok)
+	assert.Equal(t, "turn.example.com", dialer.tlsConfigs["turn.example.com:5349"].ServerName)
+	assert.Equal(t, []string{"stun.turn"}, dialer.tlsConfigs["turn.example.com:5349"].NextProtos)
+	assert.Empty(t, config.ServerName)
+}
+
+func TestTURNProxyDialer_MutualTLS(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	parsed, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	pool := x509.NewCertPool()
+	pool.AddCert(parsed)
+
+	// The server requires a client certificate signed by its CA
+	listener, err := tls.Listen("tcp4", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+	require.NoError(t, err)
+	go func() {
+		conn, acceptErr := listener.Accept()
+		if acceptErr != nil {
+			return
+		}
+		_, _ = io.Copy(conn, conn)
+		_ = conn.Close()
+	}()
+
+	turnsURI, err := stun.ParseURI("turns:" + listener.Addr().String() + "?transport=tcp")
+	require.NoError(t, err)
+	dialer := newTURNProxyDialer(proxy.Direct, []*stun.URI{turnsURI}, map[string]*tls.Config{
+		listener.Addr().String(): {Certificates: []tls.Certificate{cert}, RootCAs: pool, MinVersion: tls.VersionTLS12},
+	})
+	conn, err := dialer.Dial("tcp4", listener.Addr().String())
+	require.NoError(t, err)
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ping"), buf)
+
+	assert.NoError(t, conn.Close())
+	assert.NoError(t, listener.Close())
+}
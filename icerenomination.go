@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/stun/v3"
+)
+
+// stunAttrNomination is the NOMINATION attribute of draft-thatcher-ice-renomination, the
+// controlling agent numbers its nominations with it.
+const stunAttrNomination stun.AttrType = 0xC001
+
+// iceRenomination follows the renominations of the remote controlling agent when the
+// ICETransport is controlled, see SettingEngine.SetICERenomination. The agent only follows
+// the nominations of pairs of a higher priority than the selected one, the others are
+// followed by its BindingRequestHandler.
+type iceRenomination struct {
+	mu sync.Mutex
+	// selected returns the pair selected by the agent, it is nil unless the ICETransport
+	// is started controlled, and remote reports whether the remote advertised the option.
+	selected func() *ice.CandidatePair
+	remote   func() bool
+	// nomination is the NOMINATION attribute of the last renomination followed.
+	nomination uint32
+}
+
+// bindingRequestHandler returns the BindingRequestHandler of the agent, next is the one
+// it wraps, its switches come first.
+func (r *iceRenomination) bindingRequestHandler(
+	next func(*stun.Message, ice.Candidate, ice.Candidate, *ice.CandidatePair) bool,
+) func(*stun.Message, ice.Candidate, ice.Candidate, *ice.CandidatePair) bool {
+	return func(msg *stun.Message, local, remote ice.Candidate, pair *ice.CandidatePair) bool {
+		if next != nil && next(msg, local, remote, pair) {
+			return true
+		}
+
+		return r.handleBindingRequest(msg, local, remote)
+	}
+}
+
+// handleBindingRequest reports whether the agent selects the pair of local and remote,
+// a binding request was read on it. It runs on the loop of the agent.
+func (r *iceRenomination) handleBindingRequest(msg *stun.Message, local, remote ice.Candidate) bool {
+	if !msg.Contains(stun.AttrUseCandidate) {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.selected == nil || !r.remote() {
+		return false
+	}
+	selected := r.selected()
+	if selected == nil || (selected.Local.Equal(local) && selected.Remote.Equal(remote)) {
+		return false
+	}
+
+	// A nomination sent again, or reordered, doesn't take the agent back
+	if value, err := msg.Get(stunAttrNomination); err == nil && len(value) == 4 {
+		nomination := binary.BigEndian.Uint32(value)
+		if nomination <= r.nomination {
+			return false
+		}
+		r.nomination = nomination
+	}
+
+	return true
+}
+
+// start follows the renominations once the ICETransport is connected, if role is
+// controlled. selected returns the pair selected by the agent, and remote reports
+// whether the remote advertised the renomination option.
+func (r *iceRenomination) start(role ICERole, selected func() *ice.CandidatePair, remote func() bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.selected, r.remote, r.nomination = nil, remote, 0
+	if role == ICERoleControlled {
+		r.selected = selected
+	}
+}
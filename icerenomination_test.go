@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/stun/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestICERenomination(t *testing.T) {
+	newCandidate := func(address string) ice.Candidate {
+		candidate, err := ice.NewCandidateHost(&ice.CandidateHostConfig{
+			Network:   "udp",
+			Address:   address,
+			Port:      1234,
+			Component: 1,
+		})
+		require.NoError(t, err)
+
+		return candidate
+	}
+	local, remote, other := newCandidate("1.2.3.4"), newCandidate("1.2.3.5"), newCandidate("1.2.3.6")
+	selected := func() *ice.CandidatePair {
+		return &ice.CandidatePair{Local: local, Remote: remote}
+	}
+	nominate := func(nomination uint32) *stun.Message {
+		setters := []stun.Setter{stun.BindingRequest, stun.TransactionID, ice.UseCandidate()}
+		if nomination != 0 {
+			value := binary.BigEndian.AppendUint32(nil, nomination)
+			setters = append(setters, stun.RawAttribute{Type: stunAttrNomination, Value: value})
+		}
+		msg, err := stun.Build(setters...)
+		require.NoError(t, err)
+
+		return msg
+	}
+	check, err := stun.Build(stun.BindingRequest, stun.TransactionID)
+	require.NoError(t, err)
+
+	renomination := &iceRenomination{}
+	handler := renomination.bindingRequestHandler(nil)
+
+	// Not started
+	assert.False(t, handler(nominate(0), other, remote, nil))
+
+	// The controlling agent doesn't follow the remote
+	renomination.start(ICERoleControlling, selected, func() bool { return true })
+	assert.False(t, handler(nominate(0), other, remote, nil))
+
+	// The remote didn't advertise the option
+	remoteRenomination := false
+	renomination.start(ICERoleControlled, selected, func() bool { return remoteRenomination })
+	assert.False(t, handler(nominate(0), other, remote, nil))
+
+	remoteRenomination = true
+	assert.False(t, handler(check, other, remote, nil))
+	assert.False(t, handler(nominate(0), local, remote, nil))
+	assert.True(t, handler(nominate(0), other, remote, nil))
+
+	// The nominations numbered only move forward
+	assert.True(t, handler(nominate(2), other, remote, nil))
+	assert.False(t, handler(nominate(2), other, remote, nil))
+	assert.False(t, handler(nominate(1), other, remote, nil))
+	assert.True(t, handler(nominate(3), other, remote, nil))
+
+	// The handler set with SettingEngine.SetICEBindingRequestHandler comes first
+	next := func(*stun.Message, ice.Candidate, ice.Candidate, *ice.CandidatePair) bool {
+		return true
+	}
+	handler = renomination.bindingRequestHandler(next)
+	assert.True(t, handler(check, local, remote, nil))
+}
@@ -7,7 +7,9 @@
 package webrtc
 
 import (
+	"crypto/tls"
 	"encoding/json"
+	"time"
 
 	"github.com/pion/stun/v3"
 	"github.com/pion/webrtc/v4/pkg/rtcerr"
@@ -20,6 +22,26 @@ type ICEServer struct {
 	Username       string            `json:"username,omitempty"`
 	Credential     any               `json:"credential,omitempty"`
 	CredentialType ICECredentialType `json:"credentialType,omitempty"`
+	// TLSConfig configures the TLS connections to the turns: URLs over TCP, the CA pool,
+	// client certificates of mutual TLS and ALPN for instance. ServerName defaults to the
+	// host of the URL. The default config is used when nil. It isn't marshaled.
+	TLSConfig *tls.Config `json:"-"`
+	// CredentialProvider returns the credentials of the TURN URLs in place of Username
+	// and Credential, the ephemeral ones of a TURN REST API for instance. It is called
+	// each time candidates are gathered, the allocations are created with the credentials
+	// it returns. It isn't marshaled.
+	CredentialProvider func() (TURNCredentials, error) `json:"-"`
+}
+
+// TURNCredentials are the credentials of a TURN server returned by
+// ICEServer.CredentialProvider.
+type TURNCredentials struct {
+	Username, Password string
+	// Expires is when the credentials expire, zero if never. The allocations are
+	// refreshed with the credentials they were created with, so a PeerConnection restarts
+	// ICE shortly before as RestartICE does: OnNegotiationNeeded is fired, and the next
+	// offer gathers the relay candidates with new credentials.
+	Expires time.Time
 }
 
 func (s ICEServer) parseURL(i int) (*stun.URI, error) {
@@ -41,7 +63,7 @@ func (s ICEServer) urls() ([]*stun.URI, error) { //nolint:cyclop
 			return nil, &rtcerr.InvalidAccessError{Err: err}
 		}
 
-		if url.Scheme == stun.SchemeTypeTURN || url.Scheme == stun.SchemeTypeTURNS {
+		if (url.Scheme == stun.SchemeTypeTURN || url.Scheme == stun.SchemeTypeTURNS) && s.CredentialProvider == nil {
 			// https://www.w3.org/TR/webrtc/#set-the-configuration (step #11.3.2)
 			if s.Username == "" || s.Credential == nil {
 				return nil, &rtcerr.InvalidAccessError{Err: ErrNoTurnCredentials}
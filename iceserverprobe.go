@@ -0,0 +1,250 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pion/logging"
+	"github.com/pion/stun/v3"
+	"github.com/pion/transport/v3"
+	"github.com/pion/transport/v3/stdnet"
+)
+
+// iceServerProbeResult is the outcome of probing an ICE server URL.
+type iceServerProbeResult struct {
+	reachable     bool
+	roundTripTime time.Duration
+}
+
+// iceServerProbe probes the ICE servers of SettingEngine.SetICEServerProbe in the
+// background, and drops the unreachable ones from the ICEServers of the PeerConnections.
+type iceServerProbe struct {
+	net      transport.Net
+	servers  []ICEServer
+	timeout  time.Duration
+	interval time.Duration
+	log      logging.LeveledLogger
+
+	mu sync.RWMutex
+	// results are the results of the last probe by URL, probedAt when it completed.
+	results  map[string]iceServerProbeResult
+	probedAt time.Time
+	probing  bool
+}
+
+func newICEServerProbe(
+	n transport.Net,
+	servers []ICEServer,
+	timeout, interval time.Duration,
+	log logging.LeveledLogger,
+) *iceServerProbe {
+	if n == nil {
+		var err error
+		if n, err = stdnet.NewNet(); err != nil {
+			log.Warnf("Failed to probe ICE servers: %v", err)
+
+			return nil
+		}
+	}
+
+	probe := &iceServerProbe{
+		net:      n,
+		servers:  servers,
+		timeout:  timeout,
+		interval: interval,
+		log:      log,
+		results:  map[string]iceServerProbeResult{},
+	}
+	probe.refresh()
+
+	return probe
+}
+
+// refresh probes the servers again in the background if the last probe is older than
+// the interval, and no probe is in progress.
+func (p *iceServerProbe) refresh() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.probing || (!p.probedAt.IsZero() && time.Since(p.probedAt) < p.interval) {
+		return
+	}
+	p.probing = true
+
+	go func() {
+		results := probeICEServers(p.net, p.servers, p.timeout, p.log)
+
+		p.mu.Lock()
+		p.results = results
+		p.probedAt = time.Now()
+		p.probing = false
+		p.mu.Unlock()
+	}()
+}
+
+// probeICEServers probes all the URLs of servers in parallel, it returns once they
+// all answered or timeout elapsed.
+func probeICEServers(
+	n transport.Net,
+	servers []ICEServer,
+	timeout time.Duration,
+	log logging.LeveledLogger,
+) map[string]iceServerProbeResult {
+	results := map[string]iceServerProbeResult{}
+
+	var (
+		mu      sync.Mutex
+		probing sync.WaitGroup
+	)
+	for _, server := range servers {
+		for _, rawURL := range server.URLs {
+			probing.Add(1)
+			go func(rawURL string) {
+				defer probing.Done()
+
+				result := iceServerProbeResult{}
+				roundTripTime, err := probeICEServerURL(n, rawURL, timeout)
+				if err != nil {
+					log.Warnf("ICE server %s is unreachable: %v", rawURL, err)
+				} else {
+					result = iceServerProbeResult{reachable: true, roundTripTime: roundTripTime}
+				}
+
+				mu.Lock()
+				results[rawURL] = result
+				mu.Unlock()
+			}(rawURL)
+		}
+	}
+	probing.Wait()
+
+	return results
+}
+
+// probeICEServerURL measures the round trip time of a STUN binding request to the
+// server of rawURL, or the time to connect to it for the TCP and TLS servers.
+func probeICEServerURL(n transport.Net, rawURL string, timeout time.Duration) (time.Duration, error) {
+	url, err := stun.ParseURI(rawURL)
+	if err != nil {
+		return 0, err
+	}
+	address := net.JoinHostPort(url.Host, strconv.Itoa(url.Port))
+
+	start := time.Now()
+	if url.Scheme == stun.SchemeTypeSTUNS || url.Scheme == stun.SchemeTypeTURNS || url.Proto == stun.ProtoTypeTCP {
+		conn, dialErr := n.CreateDialer(&net.Dialer{Timeout: timeout}).Dial("tcp", address)
+		if dialErr != nil {
+			return 0, dialErr
+		}
+
+		return time.Since(start), conn.Close()
+	}
+
+	serverAddr, err := n.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return 0, err
+	}
+	conn, err := n.ListenPacket("udp", ":0")
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close() //nolint:errcheck
+
+	request, err := stun.Build(stun.TransactionID, stun.BindingRequest)
+	if err != nil {
+		return 0, err
+	}
+	if err = conn.SetDeadline(start.Add(timeout)); err != nil {
+		return 0, err
+	}
+	if _, err = conn.WriteTo(request.Raw, serverAddr); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, receiveMTU)
+	for {
+		size, _, readErr := conn.ReadFrom(buf)
+		if readErr != nil {
+			return 0, readErr
+		}
+
+		response := &stun.Message{Raw: buf[:size]}
+		if response.Decode() == nil && response.TransactionID == request.TransactionID {
+			return time.Since(start), nil
+		}
+	}
+}
+
+// filter returns servers without the URLs the last probe found unreachable, the servers
+// left without URL are dropped. The URLs which weren't probed are kept, and so are all
+// the servers if none of them is reachable, a network outage during the probe
+// shouldn't leave the PeerConnections without relays. It triggers a new probe once the
+// last one is older than the interval.
+func (p *iceServerProbe) filter(servers []ICEServer) []ICEServer {
+	if p == nil || len(servers) == 0 {
+		return servers
+	}
+	p.refresh()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	filtered := make([]ICEServer, 0, len(servers))
+	for _, server := range servers {
+		urls := make([]string, 0, len(server.URLs))
+		for _, rawURL := range server.URLs {
+			if result, ok := p.results[rawURL]; !ok || result.reachable {
+				urls = append(urls, rawURL)
+			}
+		}
+		if len(urls) == 0 {
+			continue
+		}
+		server.URLs = urls
+		filtered = append(filtered, server)
+	}
+	if len(filtered) == 0 {
+		return servers
+	}
+
+	return filtered
+}
+
+func (p *iceServerProbe) collectStats(collector *statsReportCollector, servers []ICEServer) {
+	if p == nil {
+		return
+	}
+	p.refresh()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for i, server := range servers {
+		for _, rawURL := range server.URLs {
+			result, ok := p.results[rawURL]
+			if !ok {
+				continue
+			}
+
+			collector.Collecting()
+			stats := ICEServerStats{
+				Timestamp:     statsTimestampNow(),
+				Type:          StatsTypeICEServer,
+				ID:            "ice-server-" + rawURL,
+				URL:           rawURL,
+				Priority:      i,
+				Reachable:     result.reachable,
+				RoundTripTime: result.roundTripTime.Seconds(),
+			}
+			collector.Collect(stats.ID, stats)
+		}
+	}
+}
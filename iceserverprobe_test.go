@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/stun/v3"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listenSTUN answers the STUN binding requests received on a local UDP port.
+func listenSTUN(t *testing.T) net.PacketConn {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		buf := make([]byte, receiveMTU)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			request := &stun.Message{Raw: append([]byte{}, buf[:n]...)}
+			if request.Decode() != nil {
+				continue
+			}
+			response, err := stun.Build(stun.NewTransactionIDSetter(request.TransactionID), stun.BindingSuccess)
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(response.Raw, addr)
+		}
+	}()
+
+	return conn
+}
+
+func TestICEServerProbe_Filter(t *testing.T) {
+	probe := &iceServerProbe{
+		interval: time.Hour,
+		probedAt: time.Now(),
+		results: map[string]iceServerProbeResult{
+			"stun:far.example.com":  {reachable: true, roundTripTime: 150 * time.Millisecond},
+			"stun:near.example.com": {reachable: true, roundTripTime: 20 * time.Millisecond},
+			"stun:down.example.com": {},
+		},
+	}
+
+	far := ICEServer{URLs: []string{"stun:far.example.com"}}
+	mixed := ICEServer{URLs: []string{"stun:down.example.com", "stun:near.example.com"}, Username: "user"}
+	down := ICEServer{URLs: []string{"stun:down.example.com"}}
+	unprobed := ICEServer{URLs: []string{"stun:unprobed.example.com"}}
+
+	// The unreachable URLs are dropped, and the servers left without URL
+	assert.Equal(t,
+		[]ICEServer{unprobed, far, {URLs: []string{"stun:near.example.com"}, Username: "user"}},
+		probe.filter([]ICEServer{down, unprobed, far, mixed}),
+	)
+	assert.Equal(t, []string{"stun:down.example.com", "stun:near.example.com"}, mixed.URLs)
+
+	// All the servers are kept if none is reachable
+	assert.Equal(t, []ICEServer{down}, probe.filter([]ICEServer{down}))
+
+	var disabled *iceServerProbe
+	assert.Equal(t, []ICEServer{down, far}, disabled.filter([]ICEServer{down, far}))
+}
+
+func TestSettingEngine_SetICEServerProbe(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	stunServer := listenSTUN(t)
+	defer func() {
+		assert.NoError(t, stunServer.Close())
+	}()
+
+	// Nothing answers on the port of a closed socket
+	closed, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, closed.Close())
+
+	reachable := ICEServer{URLs: []string{"stun:" + stunServer.LocalAddr().String()}}
+	unreachable := ICEServer{URLs: []string{"stun:" + closed.LocalAddr().String()}}
+	unprobed := ICEServer{URLs: []string{"stun:127.0.0.1:3478"}}
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetICEServerProbe([]ICEServer{unreachable, reachable}, 200*time.Millisecond, time.Hour)
+
+	// The servers are probed in the background
+	start := time.Now()
+	api := NewAPI(WithSettingEngine(settingEngine))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		api.iceServerProbe.mu.RLock()
+		defer api.iceServerProbe.mu.RUnlock()
+
+		return !api.iceServerProbe.probedAt.IsZero()
+	}, time.Second, 10*time.Millisecond)
+
+	iceServers := []ICEServer{unreachable, unprobed, reachable}
+	pc, err := api.NewPeerConnection(Configuration{ICEServers: iceServers})
+	require.NoError(t, err)
+	assert.Equal(t, iceServers, pc.GetConfiguration().ICEServers)
+	assert.Len(t, pc.iceGatherer.validatedServers, 2)
+
+	statsReport := pc.GetStats()
+	reachableStats, ok := statsReport["ice-server-"+reachable.URLs[0]].(ICEServerStats)
+	require.True(t, ok)
+	assert.Equal(t, StatsTypeICEServer, reachableStats.Type)
+	assert.True(t, reachableStats.Reachable)
+	assert.Equal(t, 2, reachableStats.Priority)
+	assert.Greater(t, reachableStats.RoundTripTime, 0.0)
+
+	unreachableStats, ok := statsReport["ice-server-"+unreachable.URLs[0]].(ICEServerStats)
+	require.True(t, ok)
+	assert.False(t, unreachableStats.Reachable)
+	assert.Equal(t, 0, unreachableStats.Priority)
+
+	_, ok = statsReport["ice-server-"+unprobed.URLs[0]]
+	assert.False(t, ok)
+
+	b, err := json.Marshal(reachableStats)
+	require.NoError(t, err)
+	unmarshaled, err := UnmarshalStatsJSON(b)
+	require.NoError(t, err)
+	assert.Equal(t, reachableStats, unmarshaled)
+
+	assert.NoError(t, pc.Close())
+}
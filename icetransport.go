@@ -9,12 +9,14 @@ package webrtc
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/pion/ice/v4"
 	"github.com/pion/logging"
+	"github.com/pion/stun/v3"
 	"github.com/pion/webrtc/v4/internal/mux"
 	"github.com/pion/webrtc/v4/internal/util"
 )
@@ -26,12 +28,33 @@ type ICETransport struct {
 
 	role ICERole
 
-	onConnectionStateChangeHandler         atomic.Value // func(ICETransportState)
-	internalOnConnectionStateChangeHandler atomic.Value // func(ICETransportState)
-	onSelectedCandidatePairChangeHandler   atomic.Value // func(*ICECandidatePair)
+	onConnectionStateChangeHandler               atomic.Value // func(ICETransportState)
+	internalOnConnectionStateChangeHandler       atomic.Value // func(ICETransportState)
+	onSelectedCandidatePairChangeHandler         atomic.Value // func(*ICECandidatePair)
+	internalOnSelectedCandidatePairChangeHandler atomic.Value // func(SelectedCandidatePairChange)
+
+	// selectedPair is the last selected candidate pair, and selectedPairChangeReason
+	// the reason of the next change if not a renomination.
+	selectedPairLock         sync.Mutex
+	selectedPair             *ICECandidatePair
+	selectedPairChangeReason SelectedCandidatePairChangeReason
 
 	state atomic.Value // ICETransportState
 
+	// consentExpired stops the states of the agent being reported until the ICE restart.
+	consentExpired atomic.Bool
+
+	// timeouts are the ICE timeouts set with SetTimeouts, enforced by checkTimeouts on
+	// agentPair, the selected candidate pair of the agent, and timedOut stops the states
+	// of the agent being reported like consentExpired once they failed the ICETransport.
+	timeouts        atomic.Pointer[iceTimeouts]
+	timeoutsChanged chan struct{}
+	agentPair       atomic.Pointer[ice.CandidatePair]
+	timedOut        atomic.Bool
+
+	// remoteRenomination is whether the remote advertised the renomination ICE option.
+	remoteRenomination atomic.Bool
+
 	gatherer *ICEGatherer
 	conn     *ice.Conn
 	mux      *mux.Mux
@@ -40,13 +63,29 @@ type ICETransport struct {
 
 	loggerFactory logging.LoggerFactory
 
-	log logging.LeveledLogger
+	// log adds the local username fragment to the lines, it is updated by the ICE restarts.
+	log atomic.Pointer[iceTransportLog]
+
+	// discoveredMTU is the size of the RTP packets fitting the path MTU of the selected
+	// pair, 0 until it is discovered, see SettingEngine.SetPathMTUDiscovery.
+	discoveredMTU atomic.Uint32
+	// pathMTULock guards the discovery, it isn't restarted once stopped.
+	pathMTULock    sync.Mutex
+	pathMTUCancel  func()
+	pathMTUStopped bool
+}
+
+// iceTransportLog is the logger of an ICETransport.
+type iceTransportLog struct {
+	logging.LeveledLogger
 }
 
 // GetSelectedCandidatePair returns the selected candidate pair on which packets are sent
 // if there is no selected pair nil is returned.
 func (t *ICETransport) GetSelectedCandidatePair() (*ICECandidatePair, error) {
+	t.lock.RLock()
 	agent := t.gatherer.getAgent()
+	t.lock.RUnlock()
 	if agent == nil {
 		return nil, nil //nolint:nilnil
 	}
@@ -72,16 +111,21 @@ func (t *ICETransport) GetSelectedCandidatePair() (*ICECandidatePair, error) {
 // GetSelectedCandidatePairStats returns the selected candidate pair stats on which packets are sent
 // if there is no selected pair empty stats, false is returned to indicate stats not available.
 func (t *ICETransport) GetSelectedCandidatePairStats() (ICECandidatePairStats, bool) {
-	return t.gatherer.getSelectedCandidatePairStats()
+	t.lock.RLock()
+	gatherer := t.gatherer
+	t.lock.RUnlock()
+
+	return gatherer.getSelectedCandidatePairStats()
 }
 
 // NewICETransport creates a new NewICETransport.
 func NewICETransport(gatherer *ICEGatherer, loggerFactory logging.LoggerFactory) *ICETransport {
 	iceTransport := &ICETransport{
-		gatherer:      gatherer,
-		loggerFactory: loggerFactory,
-		log:           loggerFactory.NewLogger("ortc"),
+		gatherer:        gatherer,
+		timeoutsChanged: make(chan struct{}, 1),
+		loggerFactory:   loggerFactory,
 	}
+	iceTransport.log.Store(&iceTransportLog{loggerFactory.NewLogger("ortc")})
 	iceTransport.setState(ICETransportStateNew)
 
 	return iceTransport
@@ -108,9 +152,29 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 	if agent == nil {
 		return fmt.Errorf("%w: unable to start ICETransport", errICEAgentNotExist)
 	}
+	t.updateLog(agent)
 
+	if role == nil {
+		// A full agent is controlling against a lite one, RFC 8445 S6.1.1
+		defaultRole := ICERoleControlled
+		if params.ICELite && !t.gatherer.api.settingEngine.candidates.ICELite {
+			defaultRole = ICERoleControlling
+		}
+		role = &defaultRole
+	}
+	t.role = *role
+
+	probes := t.gatherer.getPathMTUProbes()
+	sendMTU := int(t.gatherer.api.settingEngine.getSendMTU())  //nolint:gosec // G115
+	maxPathMTU := int(t.gatherer.api.settingEngine.pathMTUMax) //nolint:gosec // G115
 	if err := agent.OnConnectionStateChange(func(iceState ice.ConnectionState) {
 		state := newICETransportStateFromICE(iceState)
+		if (t.consentExpired.Load() || t.timedOut.Load()) && state != ICETransportStateClosed {
+			return
+		}
+		if state == ICETransportStateDisconnected || state == ICETransportStateFailed {
+			t.setSelectedPairChangeReason(SelectedCandidatePairChangeReasonDisconnected)
+		}
 
 		t.setState(state)
 		t.onConnectionStateChange(state)
@@ -118,9 +182,18 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 		return err
 	}
 	if err := agent.OnSelectedCandidatePairChange(func(local, remote ice.Candidate) {
+		// The pair migrated to is selected again when the agent follows the nomination itself
+		if current := t.agentPair.Load(); current != nil && current.Local.Equal(local) && current.Remote.Equal(remote) {
+			return
+		}
+		pair := &ice.CandidatePair{Local: local, Remote: remote}
+		t.agentPair.Store(pair)
+		if probes != nil && maxPathMTU > 0 {
+			t.discoverPathMTU(agent, probes, *role, pair, sendMTU+pathMTUHeadroom, maxPathMTU)
+		}
 		candidates, err := newICECandidatesFromICE([]ice.Candidate{local, remote}, "", 0)
 		if err != nil {
-			t.log.Warnf("%w: %s", errICECandiatesCoversionFailed, err)
+			t.logger().Warnf("%w: %s", errICECandiatesCoversionFailed, err)
 
 			return
 		}
@@ -129,12 +202,6 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 		return err
 	}
 
-	if role == nil {
-		controlled := ICERoleControlled
-		role = &controlled
-	}
-	t.role = *role
-
 	ctx, ctxCancel := context.WithCancel(context.Background())
 	t.ctxCancel = ctxCancel
 
@@ -178,11 +245,227 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 	}
 	t.mux = mux.NewMux(config)
 
+	// A lite agent doesn't send checks, RFC 7675 only applies to full agents
+	if interval := t.gatherer.api.settingEngine.timeout.ICEConsentInterval; interval > 0 &&
+		!t.gatherer.api.settingEngine.candidates.ICELite {
+		go t.checkConsent(ctx, agent, interval, t.gatherer.api.settingEngine.timeout.ICEConsentMaxFailures)
+	}
+	go t.checkTimeouts(ctx)
+	if renomination := t.gatherer.getRenomination(); renomination != nil {
+		renomination.start(*role, t.agentPair.Load, t.remoteRenomination.Load)
+	}
+	if migration := t.gatherer.getPairMigration(); migration != nil {
+		go migration.run(ctx, agent, *role, t.agentPair.Load, t.remoteRenomination.Load, func() {
+			t.setSelectedPairChangeReason(SelectedCandidatePairChangeReasonDegraded)
+		})
+	}
+
+	return nil
+}
+
+// checkConsent fails the ICETransport once maxFailures consent checks in a row, the
+// binding requests sent by the agent on the selected pair every interval, went
+// unanswered, RFC 7675. It runs until the ICETransport is stopped.
+func (t *ICETransport) checkConsent(ctx context.Context, agent *ice.Agent, interval time.Duration, maxFailures uint16) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var pair string
+	var responses uint64
+	var failures uint16
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stats, ok := agent.GetSelectedCandidatePairStats()
+		if !ok {
+			continue
+		}
+
+		// A response, or a new pair, renews the consent
+		if id := stats.LocalCandidateID + "/" + stats.RemoteCandidateID; id != pair || stats.ResponsesReceived != responses {
+			pair, responses, failures = id, stats.ResponsesReceived, 0
+
+			continue
+		}
+
+		if failures++; failures != maxFailures {
+			continue
+		}
+
+		t.logger().Warnf("ICE consent expired, no response received on %s for %s", pair, interval*time.Duration(maxFailures))
+		t.consentExpired.Store(true)
+		t.setState(ICETransportStateFailed)
+		t.onConnectionStateChange(ICETransportStateFailed)
+	}
+}
+
+// iceTimeouts are the ICE timeouts of SetTimeouts.
+type iceTimeouts struct {
+	disconnected, failed, keepalive time.Duration
+}
+
+// interval returns how often the timeouts are checked, 0 if they are all disabled.
+func (t iceTimeouts) interval() time.Duration {
+	var interval time.Duration
+	for _, timeout := range []time.Duration{t.disconnected, t.failed, t.keepalive} {
+		if timeout != 0 && (interval == 0 || timeout < interval) {
+			interval = timeout
+		}
+	}
+
+	return interval
+}
+
+// SetTimeouts changes the ICE timeouts of a live ICETransport, e.g. to send keepalives
+// more often once the application moved to a mobile network. The arguments are those of
+// SettingEngine.SetICETimeouts, and 0 disables them. They are enforced on top of the
+// timeouts of the ICE agent, set with SettingEngine.SetICETimeouts when it was created,
+// so they can only make the ICETransport disconnect or fail sooner, and the keepalives,
+// STUN Binding Indications, more frequent. Loosening them later requires generous
+// SettingEngine timeouts. They apply until they are changed again, ICE restarts included.
+func (t *ICETransport) SetTimeouts(disconnectedTimeout, failedTimeout, keepAliveInterval time.Duration) error {
+	if disconnectedTimeout < 0 || failedTimeout < 0 || keepAliveInterval < 0 {
+		return errInvalidICETimeouts
+	}
+
+	t.timeouts.Store(&iceTimeouts{
+		disconnected: disconnectedTimeout,
+		failed:       failedTimeout,
+		keepalive:    keepAliveInterval,
+	})
+	select {
+	case t.timeoutsChanged <- struct{}{}:
+	default:
+	}
+
 	return nil
 }
 
-// restart is not exposed currently because ORTC has users create a whole new ICETransport
-// so for now lets keep it private so we don't cause ORTC users to depend on non-standard APIs.
+// checkTimeouts sends the keepalives of the timeouts set with SetTimeouts, and changes the
+// state of the ICETransport once nothing was received on the selected pair for their
+// disconnected and failed timeouts, like the agent does. It runs until the ICETransport
+// is stopped.
+func (t *ICETransport) checkTimeouts(ctx context.Context) { //nolint:cyclop
+	// disconnected is whether the timeouts, and not the agent, disconnected the ICETransport
+	disconnected := false
+	for {
+		timeouts := t.timeouts.Load()
+		var tick <-chan time.Time
+		if timeouts != nil && timeouts.interval() > 0 {
+			tick = time.After(timeouts.interval())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.timeoutsChanged:
+			continue
+		case <-tick:
+		}
+
+		pair := t.agentPair.Load()
+		if pair == nil {
+			continue
+		}
+
+		if timeouts.keepalive != 0 && time.Since(pair.Local.LastSent()) >= timeouts.keepalive {
+			t.sendKeepalive(pair)
+		}
+
+		state := t.State()
+		if state != ICETransportStateDisconnected {
+			disconnected = false
+		}
+		if state != ICETransportStateConnected && state != ICETransportStateCompleted &&
+			state != ICETransportStateDisconnected {
+			continue
+		}
+
+		elapsed := time.Since(pair.Remote.LastReceived())
+		switch {
+		case timeouts.failed != 0 && elapsed > timeouts.disconnected+timeouts.failed:
+			t.logger().Warnf("ICE failed, nothing received on %s for %s", pair, elapsed)
+			t.timedOut.Store(true)
+			state = ICETransportStateFailed
+		case timeouts.disconnected != 0 && elapsed > timeouts.disconnected:
+			if state == ICETransportStateDisconnected {
+				continue
+			}
+			disconnected = true
+			state = ICETransportStateDisconnected
+		case disconnected:
+			disconnected = false
+			state = ICETransportStateConnected
+		default:
+			continue
+		}
+
+		if state != ICETransportStateConnected {
+			t.setSelectedPairChangeReason(SelectedCandidatePairChangeReasonDisconnected)
+		}
+		t.setState(state)
+		t.onConnectionStateChange(state)
+	}
+}
+
+// sendKeepalive sends a STUN Binding Indication on pair, RFC 8445 Section 11.
+func (t *ICETransport) sendKeepalive(pair *ice.CandidatePair) {
+	msg, err := stun.Build(stun.NewType(stun.MethodBinding, stun.ClassIndication), stun.TransactionID, stun.Fingerprint)
+	if err != nil {
+		t.logger().Warnf("Failed to build the ICE keepalive: %v", err)
+
+		return
+	}
+
+	if _, err = pair.Write(msg.Raw); err != nil {
+		t.logger().Tracef("Failed to send the ICE keepalive on %s: %v", pair, err)
+	}
+}
+
+// Restart restarts ICE on the started ICETransport, keeping its Conn and the DTLSTransport
+// over it. The local parameters change, see GetLocalParameters, the remote candidates are
+// dropped and the ICEGatherer gathers again, its OnLocalCandidate handler trickling the
+// new candidates. The remote must restart as well, its new parameters are set with
+// SetRemoteParameters and its candidates added with AddRemoteCandidate.
+func (t *ICETransport) Restart() error {
+	switch t.State() {
+	case ICETransportStateNew:
+		return errICEConnectionNotStarted
+	case ICETransportStateClosed:
+		return errICETransportClosed
+	default:
+	}
+
+	return t.restart()
+}
+
+// SetRemoteParameters sets the parameters of the remote, after it restarted ICE. The
+// connectivity checks continue with them.
+func (t *ICETransport) SetRemoteParameters(params ICEParameters) error {
+	return t.setRemoteCredentials(params.UsernameFragment, params.Password)
+}
+
+// GetStats returns the stats of the ICETransport, its candidates and candidate pairs.
+func (t *ICETransport) GetStats() StatsReport {
+	collector := newStatsReportCollector()
+	collector.Collecting()
+
+	t.lock.RLock()
+	gatherer, conn := t.gatherer, t.conn
+	t.lock.RUnlock()
+	if gatherer != nil {
+		gatherer.collectStats(collector, conn)
+	}
+	t.collectStats(collector)
+	collector.Done()
+
+	return collector.Ready()
+}
+
 func (t *ICETransport) restart() error {
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -198,10 +481,104 @@ func (t *ICETransport) restart() error {
 	); err != nil {
 		return err
 	}
+	t.updateLog(agent)
+	t.setSelectedPairChangeReason(SelectedCandidatePairChangeReasonICERestart)
+	t.consentExpired.Store(false)
+	t.timedOut.Store(false)
+	t.agentPair.Store(nil)
 
 	return t.gatherer.Gather()
 }
 
+// getDiscoveredMTU returns the size of the RTP packets fitting the path MTU of the
+// selected pair, 0 if it isn't discovered.
+func (t *ICETransport) getDiscoveredMTU() int {
+	return int(t.discoveredMTU.Load())
+}
+
+func (t *ICETransport) logger() logging.LeveledLogger {
+	return t.log.Load().LeveledLogger
+}
+
+// updateLog adds the local username fragment of agent to the lines of the logger.
+func (t *ICETransport) updateLog(agent *ice.Agent) {
+	ufrag, _, err := agent.GetLocalUserCredentials()
+	if err != nil {
+		return
+	}
+
+	t.log.Store(&iceTransportLog{
+		newLoggerWithAttrs(t.loggerFactory, t.logger(), "ortc", slog.String(logKeyUfrag, ufrag)),
+	})
+}
+
+// discoverPathMTU searches the path MTU of pair, the new selected pair, from baseSize
+// up to maxSize. The RTP packets keep the send MTU until it is discovered.
+func (t *ICETransport) discoverPathMTU(
+	agent *ice.Agent,
+	probes *pathMTUProbes,
+	role ICERole,
+	pair *ice.CandidatePair,
+	baseSize, maxSize int,
+) {
+	t.pathMTULock.Lock()
+	defer t.pathMTULock.Unlock()
+
+	if t.pathMTUCancel != nil {
+		t.pathMTUCancel()
+		t.pathMTUCancel = nil
+	}
+	t.discoveredMTU.Store(0)
+
+	// The responses to the probes sent through a relay or on TCP are not read by
+	// the sockets matching them
+	if t.pathMTUStopped || probes.unsupported.Load() || pair.Local.Type() == ice.CandidateTypeRelay ||
+		!pair.Local.NetworkType().IsUDP() {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.pathMTUCancel = cancel
+	go func() {
+		search, err := newPathMTUSearch(probes, agent, pair, role, baseSize, maxSize)
+		if err != nil {
+			t.logger().Debugf("Failed to start the path MTU discovery on %s: %v", pair, err)
+
+			return
+		}
+
+		for {
+			if size, ok := search.run(ctx); ok {
+				t.discoveredMTU.Store(uint32(size - pathMTUHeadroom)) //nolint:gosec // G115
+				t.logger().Debugf("Path MTU of %s is %d bytes", pair, size)
+			} else if ctx.Err() == nil {
+				t.logger().Debugf("No path MTU probe delivered on %s", pair)
+			}
+
+			timer := time.NewTimer(pathMTURaiseInterval)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+
+				return
+			}
+		}
+	}()
+}
+
+// stopPathMTUDiscovery stops the discovery for good.
+func (t *ICETransport) stopPathMTUDiscovery() {
+	t.pathMTULock.Lock()
+	defer t.pathMTULock.Unlock()
+
+	if t.pathMTUCancel != nil {
+		t.pathMTUCancel()
+		t.pathMTUCancel = nil
+	}
+	t.pathMTUStopped = true
+}
+
 // Stop irreversibly stops the ICETransport.
 func (t *ICETransport) Stop() error {
 	return t.stop(false /* shouldGracefullyClose */)
@@ -226,6 +603,7 @@ func (t *ICETransport) stop(shouldGracefullyClose bool) error {
 	mux := t.mux
 	gatherer := t.gatherer
 	t.lock.Unlock()
+	t.stopPathMTUDiscovery()
 
 	if mux != nil {
 		var closeErrs []error
@@ -233,6 +611,11 @@ func (t *ICETransport) stop(shouldGracefullyClose bool) error {
 			// we can't access icegatherer/icetransport.Close via
 			// mux's net.Conn Close so we call it earlier here.
 			closeErrs = append(closeErrs, gatherer.GracefulClose())
+		} else if gatherer != nil {
+			// the agent is closed with the mux, not its mDNS resolver
+			gatherer.lock.Lock()
+			gatherer.closeMDNSResolver()
+			gatherer.lock.Unlock()
 		}
 		closeErrs = append(closeErrs, mux.Close())
 
@@ -255,9 +638,39 @@ func (t *ICETransport) OnSelectedCandidatePairChange(f func(*ICECandidatePair))
 }
 
 func (t *ICETransport) onSelectedCandidatePairChange(pair *ICECandidatePair) {
+	t.selectedPairLock.Lock()
+	change := SelectedCandidatePairChange{
+		Pair:     pair,
+		Previous: t.selectedPair,
+		Reason:   t.selectedPairChangeReason,
+	}
+	switch {
+	case change.Previous == nil && change.Reason != SelectedCandidatePairChangeReasonICERestart:
+		change.Reason = SelectedCandidatePairChangeReasonInitial
+	case change.Reason == SelectedCandidatePairChangeReasonUnknown:
+		change.Reason = SelectedCandidatePairChangeReasonRenomination
+	}
+	t.selectedPair = pair
+	t.selectedPairChangeReason = SelectedCandidatePairChangeReasonUnknown
+	t.selectedPairLock.Unlock()
+
 	if handler, ok := t.onSelectedCandidatePairChangeHandler.Load().(func(*ICECandidatePair)); ok {
 		handler(pair)
 	}
+	if handler, ok := t.internalOnSelectedCandidatePairChangeHandler.Load().(func(SelectedCandidatePairChange)); ok {
+		handler(change)
+	}
+}
+
+// setSelectedPairChangeReason records why the next candidate pair is selected, an ICE
+// restart takes precedence over the disconnection it usually follows.
+func (t *ICETransport) setSelectedPairChangeReason(reason SelectedCandidatePairChangeReason) {
+	t.selectedPairLock.Lock()
+	defer t.selectedPairLock.Unlock()
+
+	if t.selectedPairChangeReason != SelectedCandidatePairChangeReasonICERestart {
+		t.selectedPairChangeReason = reason
+	}
 }
 
 // OnConnectionStateChange sets a handler that is fired when the ICE
@@ -297,7 +710,20 @@ func (t *ICETransport) SetRemoteCandidates(remoteCandidates []ICECandidate) erro
 		return fmt.Errorf("%w: unable to set remote candidates", errICEAgentNotExist)
 	}
 
+	resolver := t.gatherer.getMDNSResolver()
 	for _, c := range remoteCandidates {
+		if !t.gatherer.api.settingEngine.keepICECandidate(c, true) {
+			t.logger().Debugf("Remote candidate %s dropped by the candidate filter", c)
+
+			continue
+		}
+
+		if resolver != nil && isMDNSCandidate(c) {
+			t.addResolvedCandidate(resolver, agent, c)
+
+			continue
+		}
+
 		i, err := c.ToICE()
 		if err != nil {
 			return err
@@ -326,6 +752,12 @@ func (t *ICETransport) AddRemoteCandidate(remoteCandidate *ICECandidate) error {
 	}
 
 	if remoteCandidate != nil {
+		if !t.gatherer.api.settingEngine.keepICECandidate(*remoteCandidate, true) {
+			t.logger().Debugf("Remote candidate %s dropped by the candidate filter", remoteCandidate)
+
+			return nil
+		}
+
 		if candidate, err = remoteCandidate.ToICE(); err != nil {
 			return err
 		}
@@ -336,9 +768,29 @@ func (t *ICETransport) AddRemoteCandidate(remoteCandidate *ICECandidate) error {
 		return fmt.Errorf("%w: unable to add remote candidates", errICEAgentNotExist)
 	}
 
+	if resolver := t.gatherer.getMDNSResolver(); resolver != nil && remoteCandidate != nil &&
+		isMDNSCandidate(*remoteCandidate) {
+		t.addResolvedCandidate(resolver, agent, *remoteCandidate)
+
+		return nil
+	}
+
 	return agent.AddRemoteCandidate(candidate)
 }
 
+// addResolvedCandidate adds the mDNS candidate to agent once resolver resolved it.
+func (t *ICETransport) addResolvedCandidate(resolver *mDNSResolver, agent *ice.Agent, candidate ICECandidate) {
+	resolver.resolveCandidate(candidate, func(resolved ICECandidate) {
+		i, err := resolved.ToICE()
+		if err == nil {
+			err = agent.AddRemoteCandidate(i)
+		}
+		if err != nil {
+			t.logger().Warnf("Failed to add mDNS candidate %s: %v", candidate.Address, err)
+		}
+	}, t.logger())
+}
+
 // State returns the current ice transport state.
 func (t *ICETransport) State() ICETransportState {
 	if v, ok := t.state.Load().(ICETransportState); ok {
@@ -441,6 +893,61 @@ func (t *ICETransport) haveRemoteCredentialsChange(newUfrag, newPwd string) bool
 	return uFrag != newUfrag || uPwd != newPwd
 }
 
+func (t *ICETransport) getRemoteParameters() (ICEParameters, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	agent := t.gatherer.getAgent()
+	if agent == nil {
+		return ICEParameters{}, fmt.Errorf("%w: unable to get remote parameters", errICEAgentNotExist)
+	}
+
+	ufrag, pwd, err := agent.GetRemoteUserCredentials()
+	if err != nil {
+		return ICEParameters{}, err
+	}
+
+	return ICEParameters{UsernameFragment: ufrag, Password: pwd}, nil
+}
+
+// restoreCredentials sets back the credentials changed by a rolled back remote offer.
+// If the offer restarted the agent it is restarted again with the previous local
+// credentials, the connectivity checks resume with the ones the remote still uses.
+func (t *ICETransport) restoreCredentials(local, remote ICEParameters, restart bool) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	agent := t.gatherer.getAgent()
+	if agent == nil {
+		return fmt.Errorf("%w: unable to restore credentials", errICEAgentNotExist)
+	}
+
+	if restart {
+		if err := agent.Restart(local.UsernameFragment, local.Password); err != nil {
+			return err
+		}
+		t.updateLog(agent)
+		t.setSelectedPairChangeReason(SelectedCandidatePairChangeReasonICERestart)
+		t.consentExpired.Store(false)
+		t.timedOut.Store(false)
+		t.agentPair.Store(nil)
+	}
+	if err := agent.SetRemoteCredentials(remote.UsernameFragment, remote.Password); err != nil {
+		return err
+	}
+	if restart {
+		return t.gatherer.Gather()
+	}
+
+	return nil
+}
+
+// setRemoteRenomination records whether the remote advertised the renomination ICE option,
+// see SettingEngine.SetICERenomination.
+func (t *ICETransport) setRemoteRenomination(renomination bool) {
+	t.remoteRenomination.Store(renomination)
+}
+
 func (t *ICETransport) setRemoteCredentials(newUfrag, newPwd string) error {
 	t.lock.Lock()
 	defer t.lock.Unlock()
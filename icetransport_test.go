@@ -7,13 +7,18 @@
 package webrtc
 
 import (
+	"net"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/pion/ice/v4"
+	"github.com/pion/stun/v3"
 	"github.com/pion/transport/v3/test"
+	"github.com/pion/transport/v3/vnet"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestICETransport_OnConnectionStateChange(t *testing.T) {
@@ -90,6 +95,67 @@ func TestICETransport_OnSelectedCandidatePairChange(t *testing.T) {
 	closePairNow(t, pcOffer, pcAnswer)
 }
 
+func TestICETransport_SelectedCandidatePairChangeReason(t *testing.T) {
+	transport := &ICETransport{}
+	var changes []SelectedCandidatePairChange
+	transport.internalOnSelectedCandidatePairChangeHandler.Store(func(change SelectedCandidatePairChange) {
+		changes = append(changes, change)
+	})
+
+	host := &ICECandidatePair{Local: &ICECandidate{Typ: ICECandidateTypeHost}}
+	relay := &ICECandidatePair{Local: &ICECandidate{Typ: ICECandidateTypeRelay}}
+	srflx := &ICECandidatePair{Local: &ICECandidate{Typ: ICECandidateTypeSrflx}}
+
+	transport.onSelectedCandidatePairChange(host)
+	transport.onSelectedCandidatePairChange(srflx)
+	transport.setSelectedPairChangeReason(SelectedCandidatePairChangeReasonDisconnected)
+	transport.onSelectedCandidatePairChange(relay)
+	// The disconnection an ICE restart follows doesn't change its reason
+	transport.setSelectedPairChangeReason(SelectedCandidatePairChangeReasonICERestart)
+	transport.setSelectedPairChangeReason(SelectedCandidatePairChangeReasonDisconnected)
+	transport.onSelectedCandidatePairChange(host)
+
+	assert.Equal(t, []SelectedCandidatePairChange{
+		{Pair: host, Reason: SelectedCandidatePairChangeReasonInitial},
+		{Pair: srflx, Previous: host, Reason: SelectedCandidatePairChangeReasonRenomination},
+		{Pair: relay, Previous: srflx, Reason: SelectedCandidatePairChangeReasonDisconnected},
+		{Pair: host, Previous: relay, Reason: SelectedCandidatePairChangeReasonICERestart},
+	}, changes)
+	assert.Equal(t, "ice-restart", SelectedCandidatePairChangeReasonICERestart.String())
+	assert.Equal(t, "degraded", SelectedCandidatePairChangeReasonDegraded.String())
+}
+
+func TestPeerConnection_OnSelectedCandidatePairChange(t *testing.T) {
+	report := test.CheckRoutines(t)
+	defer report()
+
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	changed := make(chan SelectedCandidatePairChange, 1)
+	pcOffer.OnSelectedCandidatePairChange(func(change SelectedCandidatePairChange) {
+		select {
+		case changed <- change:
+		default:
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	change := <-changed
+
+	assert.Equal(t, SelectedCandidatePairChangeReasonInitial, change.Reason)
+	assert.Nil(t, change.Previous)
+	if assert.NotNil(t, change.Pair) {
+		assert.Equal(t, ICECandidateTypeHost, change.Pair.Local.Typ)
+		assert.Equal(t, ICECandidateTypeHost, change.Pair.Remote.Typ)
+	}
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
 func TestICETransport_GetSelectedCandidatePair(t *testing.T) {
 	offerer, answerer, err := newPair()
 	assert.NoError(t, err)
@@ -126,6 +192,90 @@ func TestICETransport_GetSelectedCandidatePair(t *testing.T) {
 	closePairNow(t, offerer, answerer)
 }
 
+func TestICETransport_SubscribeCandidatePairStats(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerer, answerer, err := newPair()
+	assert.NoError(t, err)
+
+	transport := offerer.SCTP().Transport().ICETransport()
+	_, err = transport.SubscribeCandidatePairStats(0)
+	assert.ErrorIs(t, err, errInvalidPairStatsInterval)
+
+	subscription, err := transport.SubscribeCandidatePairStats(10 * time.Millisecond)
+	assert.NoError(t, err)
+
+	peerConnectionConnected := untilConnectionState(PeerConnectionStateConnected, offerer, answerer)
+	assert.NoError(t, signalPair(offerer, answerer))
+	peerConnectionConnected.Wait()
+
+	// The stats of the pair are delivered again as it gets nominated
+	for stats := range subscription.C() {
+		assert.Equal(t, StatsTypeCandidatePair, stats.Type)
+		if stats.Nominated && stats.State == StatsICECandidatePairStateSucceeded && stats.ResponsesReceived > 0 {
+			break
+		}
+	}
+
+	// The channel is closed once the transport is stopped
+	closePairNow(t, offerer, answerer)
+	assert.Eventually(t, func() bool {
+		_, ok := <-subscription.C()
+
+		return !ok
+	}, 5*time.Second, time.Millisecond)
+	subscription.Close()
+}
+
+func TestICETransport_ActiveTCP(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// The offerer only accepts TCP, the answerer dials it without a TCPMux of its own
+	listener, err := net.ListenTCP("tcp4", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	tcpMux := NewICETCPMux(nil, listener, 8)
+
+	newPC := func(tcpMux ice.TCPMux) *PeerConnection {
+		settings := SettingEngine{}
+		settings.SetNetworkTypes([]NetworkType{NetworkTypeTCP4})
+		settings.SetIncludeLoopbackCandidate(true)
+		settings.SetIPFilter(func(ip net.IP) bool {
+			return ip.IsLoopback()
+		})
+		if tcpMux != nil {
+			settings.SetICETCPMux(tcpMux)
+		}
+		pc, pcErr := NewAPI(WithSettingEngine(settings)).NewPeerConnection(Configuration{})
+		require.NoError(t, pcErr)
+
+		return pc
+	}
+	offerer, answerer := newPC(tcpMux), newPC(nil)
+
+	peerConnectionConnected := untilConnectionState(PeerConnectionStateConnected, offerer, answerer)
+	_, err = offerer.CreateDataChannel("active-tcp", nil)
+	require.NoError(t, err)
+	require.NoError(t, signalPair(offerer, answerer))
+	peerConnectionConnected.Wait()
+
+	pair, err := answerer.SCTP().Transport().ICETransport().GetSelectedCandidatePair()
+	require.NoError(t, err)
+	assert.Equal(t, ICEProtocolTCP, pair.Local.Protocol)
+	assert.Equal(t, ice.TCPTypeActive.String(), pair.Local.TCPType)
+	assert.Equal(t, ice.TCPTypePassive.String(), pair.Remote.TCPType)
+
+	closePairNow(t, offerer, answerer)
+	assert.NoError(t, tcpMux.Close())
+}
+
 func TestICETransport_GetLocalAndRemoteParameters(t *testing.T) {
 	offerer, answerer, err := newPair()
 	assert.NoError(t, err)
@@ -157,3 +307,226 @@ func TestICETransport_GetLocalAndRemoteParameters(t *testing.T) {
 
 	closePairNow(t, offerer, answerer)
 }
+
+func TestICETransport_ConsentFreshness(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settingEngine := SettingEngine{}
+	assert.ErrorIs(t, settingEngine.SetICEConsentFreshness(time.Second, 0), errInvalidICEConsentFreshness)
+	assert.ErrorIs(t, settingEngine.SetICEConsentFreshness(-time.Second, 1), errInvalidICEConsentFreshness)
+	assert.NoError(t, settingEngine.SetICEConsentFreshness(0, 0))
+
+	offerPC, answerPC, wan := createVNetPair(t, nil)
+	assert.NoError(t, offerPC.api.settingEngine.SetICEConsentFreshness(100*time.Millisecond, 5))
+	// Other packets would keep the connection alive, only the consent checks notice
+	offerPC.api.settingEngine.SetICETimeouts(10*time.Second, 10*time.Second, time.Second)
+
+	dropSTUN := &atomic.Bool{}
+	wan.AddChunkFilter(func(c vnet.Chunk) bool {
+		return !dropSTUN.Load() || !stun.IsMessage(c.UserData())
+	})
+
+	connectionErr := make(chan error, 1)
+	offerPC.OnConnectionError(func(err error) {
+		connectionErr <- err
+	})
+
+	_, err := offerPC.CreateDataChannel("consent", nil)
+	assert.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+
+	// Consent is renewed while the remote answers
+	time.Sleep(time.Second)
+	assert.Equal(t, ICETransportStateConnected, offerPC.SCTP().Transport().ICETransport().State())
+
+	failed := untilConnectionState(PeerConnectionStateFailed, offerPC)
+	dropSTUN.Store(true)
+	failed.Wait()
+
+	err = <-connectionErr
+	assert.ErrorIs(t, err, ErrICEConsentExpired)
+	assert.ErrorIs(t, err, ErrICEConnectionFailed)
+	assert.Equal(t, ICEConnectionStateFailed, offerPC.ICEConnectionState())
+
+	assert.NoError(t, wan.Stop())
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestICETransport_SetTimeouts(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, wan := createVNetPair(t, nil)
+	assert.ErrorIs(t, offerPC.SetICETimeouts(-time.Second, 0, 0), errInvalidICETimeouts)
+
+	var keepalives atomic.Int32
+	drop := &atomic.Bool{}
+	wan.AddChunkFilter(func(c vnet.Chunk) bool {
+		msg := &stun.Message{Raw: c.UserData()}
+		if msg.Decode() == nil && msg.Type.Class == stun.ClassIndication {
+			keepalives.Add(1)
+		}
+
+		return !drop.Load()
+	})
+
+	_, err := offerPC.CreateDataChannel("timeouts", nil)
+	assert.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+
+	// The agent sends binding requests as keepalives, the indications are the tightened ones
+	assert.NoError(t, offerPC.SetICETimeouts(time.Second, time.Second, 50*time.Millisecond))
+	time.Sleep(500 * time.Millisecond)
+	assert.Greater(t, keepalives.Load(), int32(3))
+	assert.Equal(t, ICETransportStateConnected, offerPC.SCTP().Transport().ICETransport().State())
+
+	// Way sooner than the 5 and 25 seconds of the agent
+	var states []ICEConnectionState
+	var statesLock sync.Mutex
+	offerPC.OnICEConnectionStateChange(func(state ICEConnectionState) {
+		statesLock.Lock()
+		states = append(states, state)
+		statesLock.Unlock()
+	})
+	failed := untilConnectionState(PeerConnectionStateFailed, offerPC)
+	drop.Store(true)
+	failed.Wait()
+
+	statesLock.Lock()
+	assert.Equal(t, []ICEConnectionState{ICEConnectionStateDisconnected, ICEConnectionStateFailed}, states)
+	statesLock.Unlock()
+
+	assert.NoError(t, wan.Stop())
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestICETransport_ConsentFreshnessLite(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, wan := createVNetPair(t, nil)
+	// A lite agent sends no binding requests of its own, it has no consent to renew
+	answerPC.api.settingEngine.SetLite(true)
+	answerPC.api.settingEngine.SetICETimeouts(10*time.Second, 10*time.Second, time.Second)
+	assert.NoError(t, answerPC.api.settingEngine.SetICEConsentFreshness(100*time.Millisecond, 5))
+
+	dropSTUN := &atomic.Bool{}
+	wan.AddChunkFilter(func(c vnet.Chunk) bool {
+		return !dropSTUN.Load() || !stun.IsMessage(c.UserData())
+	})
+
+	_, err := offerPC.CreateDataChannel("consent", nil)
+	assert.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+
+	dropSTUN.Store(true)
+	time.Sleep(time.Second)
+	assert.Equal(t, ICETransportStateConnected, answerPC.SCTP().Transport().ICETransport().State())
+	dropSTUN.Store(false)
+
+	assert.NoError(t, wan.Stop())
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestICETransport_Restart(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	stackA, stackB, err := newORTCPair()
+	assert.NoError(t, err)
+	assert.ErrorIs(t, stackA.ice.Restart(), errICEConnectionNotStarted)
+
+	messages := make(chan string, 2)
+	stackB.sctp.OnDataChannel(func(d *DataChannel) {
+		d.OnMessage(func(msg DataChannelMessage) {
+			messages <- string(msg.Data)
+		})
+	})
+	assert.NoError(t, signalORTCPair(stackA, stackB))
+
+	var id uint16 = 1
+	channel, err := stackA.api.NewDataChannel(stackA.sctp, &DataChannelParameters{Label: "restart", ID: &id})
+	assert.NoError(t, err)
+	assert.NoError(t, channel.SendText("before"))
+	assert.Equal(t, "before", <-messages)
+
+	// The candidates gathered again are trickled to the remote once it restarted too
+	restart := func(s *testORTCStack) (ICEParameters, chan *ICECandidate) {
+		candidates := make(chan *ICECandidate, 16)
+		s.gatherer.OnLocalCandidate(func(c *ICECandidate) {
+			candidates <- c
+		})
+		previous, err := s.ice.GetLocalParameters()
+		assert.NoError(t, err)
+		assert.NoError(t, s.ice.Restart())
+		params, err := s.ice.GetLocalParameters()
+		assert.NoError(t, err)
+		assert.NotEqual(t, previous.UsernameFragment, params.UsernameFragment)
+
+		return params, candidates
+	}
+	paramsA, candidatesA := restart(stackA)
+	paramsB, candidatesB := restart(stackB)
+
+	restarted := make(chan SelectedCandidatePairChangeReason, 1)
+	stackA.ice.internalOnSelectedCandidatePairChangeHandler.Store(func(change SelectedCandidatePairChange) {
+		select {
+		case restarted <- change.Reason:
+		default:
+		}
+	})
+
+	trickle := func(candidates chan *ICECandidate, remote *testORTCStack) {
+		for candidate := range candidates {
+			assert.NoError(t, remote.ice.AddRemoteCandidate(candidate))
+			if candidate == nil {
+				return
+			}
+		}
+	}
+	assert.NoError(t, stackA.ice.SetRemoteParameters(paramsB))
+	assert.NoError(t, stackB.ice.SetRemoteParameters(paramsA))
+	trickle(candidatesA, stackB)
+	trickle(candidatesB, stackA)
+
+	assert.Equal(t, SelectedCandidatePairChangeReasonICERestart, <-restarted)
+	assert.NoError(t, channel.SendText("after"))
+	assert.Equal(t, "after", <-messages)
+
+	stats := stackA.ice.GetStats()
+	_, ok := stats["iceTransport"]
+	assert.True(t, ok)
+	var pairs int
+	for _, s := range stats {
+		if _, ok := s.(ICECandidatePairStats); ok {
+			pairs++
+		}
+	}
+	assert.NotZero(t, pairs)
+
+	assert.NoError(t, stackA.close())
+	assert.NoError(t, stackB.close())
+	assert.ErrorIs(t, stackA.ice.Restart(), errICETransportClosed)
+}
@@ -7,11 +7,14 @@
 package webrtc
 
 import (
+	"math"
 	"sync"
 	"sync/atomic"
 
 	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/cc"
 	"github.com/pion/interceptor/pkg/flexfec"
+	"github.com/pion/interceptor/pkg/gcc"
 	"github.com/pion/interceptor/pkg/nack"
 	"github.com/pion/interceptor/pkg/report"
 	"github.com/pion/interceptor/pkg/rfc8888"
@@ -77,6 +80,49 @@ func cleanupStats(id string) {
 // key: string (peerconnection.statsId), value: stats.Getter
 var statsGetter sync.Map // nolint:gochecknoglobals
 
+// ConfigureCongestionController will setup everything necessary for send side bandwidth estimation
+// with Google Congestion Control. The estimate of every PeerConnection is split between its RTPSenders
+// and reported via RTPSender.OnTargetBitrateChange, the whole estimate is reported via
+// PeerConnection.OnBandwidthEstimate.
+func ConfigureCongestionController(
+	mediaEngine *MediaEngine,
+	interceptorRegistry *interceptor.Registry,
+	options ...gcc.Option,
+) error {
+	congestionController, err := cc.NewInterceptor(func() (cc.BandwidthEstimator, error) {
+		return gcc.NewSendSideBWE(options...)
+	})
+	if err != nil {
+		return err
+	}
+
+	congestionController.OnNewPeerConnection(func(id string, estimator cc.BandwidthEstimator) {
+		bandwidthEstimators.Store(id, estimator)
+	})
+	interceptorRegistry.Add(congestionController)
+
+	return ConfigureTWCCHeaderExtensionSender(mediaEngine, interceptorRegistry)
+}
+
+// lookupBandwidthEstimator returns the bandwidth estimator for a given peerconnection.statsId.
+func lookupBandwidthEstimator(id string) (cc.BandwidthEstimator, bool) {
+	if value, exists := bandwidthEstimators.Load(id); exists {
+		if estimator, ok := value.(cc.BandwidthEstimator); ok {
+			return estimator, true
+		}
+	}
+
+	return nil, false
+}
+
+// cleanupBandwidthEstimator removes the bandwidth estimator for a given peerconnection.statsId.
+func cleanupBandwidthEstimator(id string) {
+	bandwidthEstimators.Delete(id)
+}
+
+// key: string (peerconnection.statsId), value: cc.BandwidthEstimator
+var bandwidthEstimators sync.Map // nolint:gochecknoglobals
+
 // ConfigureRTCPReports will setup everything necessary for generating Sender and Receiver Reports.
 func ConfigureRTCPReports(interceptorRegistry *interceptor.Registry) error {
 	reciver, err := report.NewReceiverInterceptor()
@@ -96,6 +142,9 @@ func ConfigureRTCPReports(interceptorRegistry *interceptor.Registry) error {
 }
 
 // ConfigureNack will setup everything necessary for handling generating/responding to nack messages.
+// Recently sent packets are cached per RTPSender, if an RTX codec with a matching apt is negotiated
+// nacked packets are retransmitted as described in RFC 4588 on the RTX SSRC of the encoding,
+// otherwise they are resent as is on the media SSRC.
 func ConfigureNack(mediaEngine *MediaEngine, interceptorRegistry *interceptor.Registry) error {
 	generator, err := nack.NewGeneratorInterceptor()
 	if err != nil {
@@ -200,6 +249,26 @@ func ConfigureSimulcastExtensionHeaders(mediaEngine *MediaEngine) error {
 	)
 }
 
+// ConfigurePlayoutDelayHeaderExtension enables the playout delay header extension for video,
+// which carries the delays set with RTPSender.SetPlayoutDelay and received with
+// TrackRemote.PlayoutDelay.
+func ConfigurePlayoutDelayHeaderExtension(mediaEngine *MediaEngine) error {
+	return mediaEngine.RegisterHeaderExtension(
+		RTPHeaderExtensionCapability{URI: PlayoutDelayURI}, RTPCodecTypeVideo,
+	)
+}
+
+// ConfigureDependencyDescriptorHeaderExtension enables the dependency descriptor header
+// extension of the AV1 RTP payload format for video, which carries the layers of the
+// frames of SVC streams. It is written with TrackLocalStaticRTP.WriteRTPWithDependencyDescriptor,
+// forwarded by TrackForwarder, and read with TrackRemote.DependencyStructure and
+// AttributeDependencyDescriptor.
+func ConfigureDependencyDescriptorHeaderExtension(mediaEngine *MediaEngine) error {
+	return mediaEngine.RegisterHeaderExtension(
+		RTPHeaderExtensionCapability{URI: DependencyDescriptorURI}, RTPCodecTypeVideo,
+	)
+}
+
 // ConfigureFlexFEC03 registers flexfec-03 codec with provided payloadType in mediaEngine
 // and adds corresponding interceptor to the registry.
 // Note that this function should be called before any other interceptor that modifies RTP packets
@@ -235,9 +304,64 @@ func ConfigureFlexFEC03(
 	return nil
 }
 
-type interceptorToTrackLocalWriter struct{ interceptor atomic.Value } // interceptor.RTPWriter }
+// FlexFECOverhead returns the options for ConfigureFlexFEC03 protecting every group of
+// numMediaPackets media packets with enough repair packets to reach the requested overhead,
+// e.g. 0.25 sends 1 FlexFEC packet for every 4 media packets. At least one repair packet
+// is sent per group.
+func FlexFECOverhead(numMediaPackets uint32, overhead float64) []flexfec.FecOption {
+	numMediaPackets = max(numMediaPackets, 1)
+	numFECPackets := uint32(math.Ceil(float64(numMediaPackets) * max(overhead, 0)))
+
+	return []flexfec.FecOption{
+		flexfec.NumMediaPackets(numMediaPackets),
+		flexfec.NumFECPackets(max(numFECPackets, 1)),
+	}
+}
+
+// ConfigureAdaptiveFlexFEC03 registers flexfec-03 codec with provided payloadType in mediaEngine
+// and adds a FlexFEC interceptor whose protection overhead follows the loss reported by the remote.
+// The returned factory can be used to access the AdaptiveFECController of every PeerConnection.
+// The same ordering constraints as ConfigureFlexFEC03 apply.
+func ConfigureAdaptiveFlexFEC03(
+	payloadType PayloadType,
+	mediaEngine *MediaEngine,
+	interceptorRegistry *interceptor.Registry,
+	policy AdaptiveFECPolicy,
+) (*AdaptiveFECControllerFactory, error) {
+	codecFEC := RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{
+			MimeType:     MimeTypeFlexFEC03,
+			ClockRate:    90000,
+			SDPFmtpLine:  "repair-window=10000000",
+			RTCPFeedback: nil,
+		},
+		PayloadType: payloadType,
+	}
+
+	if err := mediaEngine.RegisterCodec(codecFEC, RTPCodecTypeVideo); err != nil {
+		return nil, err
+	}
+
+	generator, err := NewAdaptiveFECControllerFactory(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	interceptorRegistry.Add(generator)
+
+	return generator, nil
+}
+
+type interceptorToTrackLocalWriter struct {
+	interceptor atomic.Value // interceptor.RTPWriter
+	paused      atomic.Bool
+}
 
 func (i *interceptorToTrackLocalWriter) WriteRTP(header *rtp.Header, payload []byte) (int, error) {
+	if i.paused.Load() {
+		return 0, nil
+	}
+
 	if writer, ok := i.interceptor.Load().(interceptor.RTPWriter); ok && writer != nil {
 		return writer.Write(header, payload, interceptor.Attributes{})
 	}
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/flexfec"
 	mock_interceptor "github.com/pion/interceptor/pkg/mock"
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
@@ -220,6 +221,100 @@ func Test_Interceptor_BindUnbind(t *testing.T) { //nolint:cyclop
 	assert.Equal(t, uint32(2), atomic.LoadUint32(&cntClose), "CloseFn is expected to be called twice")
 }
 
+func Test_Interceptor_TrackScoped(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	var cntClose uint32
+	senderInterceptor := &mock_interceptor.Interceptor{
+		BindLocalStreamFn: func(_ *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+			return interceptor.RTPWriterFunc(
+				func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+					header.Extension = true
+					header.ExtensionProfile = 0xBEDE
+					assert.NoError(t, header.SetExtension(2, []byte("foo")))
+
+					return writer.Write(header, payload, attributes)
+				},
+			)
+		},
+		CloseFn: func() error {
+			atomic.AddUint32(&cntClose, 1)
+
+			return nil
+		},
+	}
+	receiverInterceptor := &mock_interceptor.Interceptor{
+		BindRemoteStreamFn: func(_ *interceptor.StreamInfo, reader interceptor.RTPReader) interceptor.RTPReader {
+			return interceptor.RTPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+				if a == nil {
+					a = interceptor.Attributes{}
+				}
+
+				a.Set("attribute", "value")
+
+				return reader.Read(b, a)
+			})
+		},
+		CloseFn: func() error {
+			atomic.AddUint32(&cntClose, 1)
+
+			return nil
+		},
+	}
+
+	offerer, answerer, err := newPair()
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+
+	sender, err := offerer.AddTrack(track)
+	assert.NoError(t, err)
+	assert.NoError(t, sender.AddInterceptor(senderInterceptor))
+	assert.ErrorIs(t, sender.AddInterceptor(nil), errRTPSenderInterceptorNil)
+
+	seenRTP, seenRTPCancel := context.WithCancel(context.Background())
+	answerer.OnTrack(func(track *TrackRemote, receiver *RTPReceiver) {
+		assert.NoError(t, receiver.AddInterceptor(receiverInterceptor))
+
+		for {
+			p, attributes, readErr := track.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			if attributes.Get("attribute") == "value" {
+				assert.Equal(t, "foo", string(p.GetExtension(2)))
+				seenRTPCancel()
+
+				return
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(offerer, answerer))
+
+	func() {
+		ticker := time.NewTicker(time.Millisecond * 20)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-seenRTP.Done():
+				return
+			case <-ticker.C:
+				assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Second}))
+			}
+		}
+	}()
+
+	closePairNow(t, offerer, answerer)
+	assert.Equal(t, uint32(2), atomic.LoadUint32(&cntClose), "CloseFn is expected to be called twice")
+}
+
 func Test_InterceptorRegistry_Build(t *testing.T) {
 	registryBuildCount := 0
 
@@ -301,6 +396,67 @@ func TestConfigureFlexFEC03_FECParameters(t *testing.T) {
 	assert.True(t, fecCodecFound, "FlexFEC-03 codec should be registered")
 }
 
+func TestFlexFECOverhead(t *testing.T) {
+	for _, tc := range []struct {
+		numMediaPackets  uint32
+		overhead         float64
+		expectFECPackets int
+	}{
+		{4, 0.5, 2},
+		{10, 0.25, 3},
+		{5, 0, 1},
+	} {
+		generator, err := flexfec.NewFecInterceptor(FlexFECOverhead(tc.numMediaPackets, tc.overhead)...)
+		assert.NoError(t, err)
+
+		fecInterceptor, err := generator.NewInterceptor("")
+		assert.NoError(t, err)
+
+		fecPackets := 0
+		writer := fecInterceptor.BindLocalStream(&interceptor.StreamInfo{
+			SSRC:                              1,
+			SSRCForwardErrorCorrection:        2,
+			PayloadTypeForwardErrorCorrection: 120,
+		}, interceptor.RTPWriterFunc(func(header *rtp.Header, _ []byte, _ interceptor.Attributes) (int, error) {
+			if header.SSRC == 2 {
+				fecPackets++
+			}
+
+			return 0, nil
+		}))
+
+		for i := uint32(0); i < tc.numMediaPackets; i++ {
+			_, err = writer.Write(&rtp.Header{SSRC: 1, SequenceNumber: uint16(i)}, []byte{0x01, 0x02}, nil)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, tc.expectFECPackets, fecPackets)
+		assert.NoError(t, fecInterceptor.Close())
+	}
+}
+
+func TestConfigureCongestionController(t *testing.T) {
+	mediaEngine := &MediaEngine{}
+	assert.NoError(t, mediaEngine.RegisterDefaultCodecs())
+
+	interceptorRegistry := &interceptor.Registry{}
+	assert.NoError(t, ConfigureCongestionController(mediaEngine, interceptorRegistry))
+
+	pc, err := NewAPI(
+		WithMediaEngine(mediaEngine),
+		WithInterceptorRegistry(interceptorRegistry),
+	).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	assert.NotNil(t, pc.bandwidthEstimator)
+
+	_, ok := lookupBandwidthEstimator(pc.id)
+	assert.True(t, ok)
+
+	assert.NoError(t, pc.Close())
+
+	_, ok = lookupBandwidthEstimator(pc.id)
+	assert.False(t, ok)
+}
+
 func Test_Interceptor_ZeroSSRC(t *testing.T) {
 	to := test.TimeOut(time.Second * 20)
 	defer to.Stop()
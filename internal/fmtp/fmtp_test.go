@@ -104,6 +104,18 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			"h265",
+			"video/h265",
+			90000,
+			0,
+			"key-name=value",
+			&h265FMTP{
+				parameters: map[string]string{
+					"key-name": "value",
+				},
+			},
+		},
 		{
 			"vp9",
 			"video/vp9",
@@ -128,6 +140,20 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			"opus",
+			"audio/opus",
+			48000,
+			2,
+			"stereo=1",
+			&opusFMTP{
+				clockRate: 48000,
+				channels:  2,
+				parameters: map[string]string{
+					"stereo": "1",
+				},
+			},
+		},
 	} {
 		t.Run(ca.name, func(t *testing.T) {
 			f := Parse(ca.mimeType, ca.clockRate, ca.channels, ca.line)
@@ -432,6 +458,90 @@ func TestMatch(t *testing.T) { //nolint:maintidx
 			},
 			false,
 		},
+		{
+			"h265 equal",
+			&h265FMTP{
+				parameters: map[string]string{
+					"level-id":   "93",
+					"profile-id": "1",
+					"tier-flag":  "0",
+					"tx-mode":    "SRST",
+				},
+			},
+			&h265FMTP{
+				parameters: map[string]string{
+					"level-id":   "93",
+					"profile-id": "1",
+					"tier-flag":  "0",
+					"tx-mode":    "SRST",
+				},
+			},
+			true,
+		},
+		{
+			"h265 inferred parameters",
+			&h265FMTP{
+				parameters: map[string]string{
+					"profile-id": "1",
+					"tier-flag":  "0",
+					"tx-mode":    "srst",
+				},
+			},
+			&h265FMTP{
+				parameters: map[string]string{},
+			},
+			true,
+		},
+		{
+			"h265 different level ids",
+			&h265FMTP{
+				parameters: map[string]string{
+					"level-id":   "186",
+					"profile-id": "1",
+				},
+			},
+			&h265FMTP{
+				parameters: map[string]string{
+					"level-id":   "93",
+					"profile-id": "1",
+				},
+			},
+			true,
+		},
+		{
+			"h265 inconsistent different kind",
+			&h265FMTP{
+				parameters: map[string]string{},
+			},
+			&h264FMTP{},
+			false,
+		},
+		{
+			"h265 inconsistent different profile",
+			&h265FMTP{
+				parameters: map[string]string{
+					"profile-id": "2",
+				},
+			},
+			&h265FMTP{
+				parameters: map[string]string{},
+			},
+			false,
+		},
+		{
+			"h265 inconsistent different tier",
+			&h265FMTP{
+				parameters: map[string]string{
+					"tier-flag": "1",
+				},
+			},
+			&h265FMTP{
+				parameters: map[string]string{
+					"tier-flag": "0",
+				},
+			},
+			false,
+		},
 		{
 			"vp9 equal",
 			&vp9FMTP{
@@ -696,6 +806,59 @@ func TestMatch(t *testing.T) { //nolint:maintidx
 			},
 			true,
 		},
+		{
+			"opus different stereo",
+			&opusFMTP{
+				clockRate: 48000,
+				channels:  2,
+				parameters: map[string]string{
+					"minptime":     "10",
+					"stereo":       "1",
+					"sprop-stereo": "1",
+				},
+			},
+			&opusFMTP{
+				clockRate: 48000,
+				channels:  2,
+				parameters: map[string]string{
+					"minptime": "10",
+					"stereo":   "0",
+				},
+			},
+			true,
+		},
+		{
+			"opus different params",
+			&opusFMTP{
+				clockRate: 48000,
+				channels:  2,
+				parameters: map[string]string{
+					"minptime": "10",
+					"stereo":   "1",
+				},
+			},
+			&opusFMTP{
+				clockRate: 48000,
+				channels:  2,
+				parameters: map[string]string{
+					"minptime": "20",
+					"stereo":   "1",
+				},
+			},
+			false,
+		},
+		{
+			"opus inconsistent channels",
+			&opusFMTP{
+				clockRate: 48000,
+				channels:  2,
+			},
+			&opusFMTP{
+				clockRate: 48000,
+				channels:  1,
+			},
+			false,
+		},
 	} {
 		t.Run(ca.name, func(t *testing.T) {
 			c := ca.a.Match(ca.b)
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package fmtp
+
+import (
+	"strings"
+)
+
+type h265FMTP struct {
+	parameters map[string]string
+}
+
+func (h *h265FMTP) MimeType() string {
+	return "video/h265"
+}
+
+// Match returns true if h and b are compatible fmtp descriptions
+// Based on RFC7798 Section 7.2.2:
+//
+//	The parameters identifying a media format configuration for HEVC
+//	are profile-space, tier-flag, profile-id, and tx-mode. These media
+//	format configuration parameters MUST be used symmetrically.
+//
+// As the level part of profile-level-id for H.264, level-id is the highest
+// level supported and is not compared. The parameters missing take their
+// default values of RFC7798 Section 7.1.
+func (h *h265FMTP) Match(b FMTP) bool {
+	c, ok := b.(*h265FMTP)
+	if !ok {
+		return false
+	}
+
+	for key, def := range map[string]string{
+		"profile-space": "0",
+		"profile-id":    "1",
+		"tier-flag":     "0",
+		"tx-mode":       "SRST",
+	} {
+		hValue, ok := h.parameters[key]
+		if !ok {
+			hValue = def
+		}
+		cValue, ok := c.parameters[key]
+		if !ok {
+			cValue = def
+		}
+		if !strings.EqualFold(hValue, cValue) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (h *h265FMTP) Parameter(key string) (string, bool) {
+	v, ok := h.parameters[key]
+
+	return v, ok
+}
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package fmtp
+
+type opusFMTP struct {
+	clockRate  uint32
+	channels   uint16
+	parameters map[string]string
+}
+
+func (o *opusFMTP) MimeType() string {
+	return "audio/opus"
+}
+
+// RTP Payload Format for the Opus Speech and Audio Codec
+// https://datatracker.ietf.org/doc/html/rfc7587#section-7
+// stereo and sprop-stereo describe what each endpoint prefers to receive or
+// is likely to send, they don't need to be equal for the codecs to match.
+func (o *opusFMTP) Match(b FMTP) bool {
+	c, ok := b.(*opusFMTP)
+	if !ok {
+		return false
+	}
+
+	return ClockRateEqual(o.MimeType(), o.clockRate, c.clockRate) &&
+		ChannelsEqual(o.MimeType(), o.channels, c.channels) &&
+		paramsEqual(withoutStereoParameters(o.parameters), withoutStereoParameters(c.parameters))
+}
+
+func (o *opusFMTP) Parameter(key string) (string, bool) {
+	v, ok := o.parameters[key]
+
+	return v, ok
+}
+
+func withoutStereoParameters(parameters map[string]string) map[string]string {
+	filtered := make(map[string]string, len(parameters))
+	for k, v := range parameters {
+		if k != "stereo" && k != "sprop-stereo" {
+			filtered[k] = v
+		}
+	}
+
+	return filtered
+}
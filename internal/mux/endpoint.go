@@ -35,6 +35,14 @@ func (e *Endpoint) Close() (err error) {
 	return nil
 }
 
+// SetBufferLimitSize raises the maximum size in bytes of the packets buffered by the
+// Endpoint, 1MB by default: the packets received beyond it are dropped.
+func (e *Endpoint) SetBufferLimitSize(limit int) {
+	if limit > maxBufferSize {
+		e.buffer.SetLimitSize(limit)
+	}
+}
+
 func (e *Endpoint) close() error {
 	return e.buffer.Close()
 }
@@ -80,14 +88,17 @@ func (e *Endpoint) RemoteAddr() net.Addr {
 	return e.mux.nextConn.RemoteAddr()
 }
 
-// SetDeadline is a stub.
-func (e *Endpoint) SetDeadline(time.Time) error {
-	return nil
+// SetDeadline sets the read deadline, the writes don't block.
+func (e *Endpoint) SetDeadline(t time.Time) error {
+	return e.SetReadDeadline(t)
 }
 
-// SetReadDeadline is a stub.
-func (e *Endpoint) SetReadDeadline(time.Time) error {
-	return nil
+// SetReadDeadline sets the deadline of Read. DTLS reads through
+// netctx.PacketConn, which sets a deadline in the past to interrupt the read
+// when the handshake is aborted, like when the certificate of the peer is
+// rejected.
+func (e *Endpoint) SetReadDeadline(t time.Time) error {
+	return e.buffer.SetReadDeadline(t)
 }
 
 // SetWriteDeadline is a stub.
@@ -4,12 +4,14 @@
 package mux
 
 import (
+	"context"
 	"io"
 	"net"
 	"testing"
 	"time"
 
 	"github.com/pion/logging"
+	"github.com/pion/transport/v3/netctx"
 	"github.com/pion/transport/v3/packetio"
 	"github.com/pion/transport/v3/test"
 	"github.com/stretchr/testify/require"
@@ -189,3 +191,32 @@ func TestPendingQueue(t *testing.T) {
 	}
 	require.Equal(t, len(mux.pendingPackets), maxPendingPackets)
 }
+
+func TestEndpointReadDeadline(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	mux := &Mux{
+		endpoints: make(map[*Endpoint]MatchFunc),
+		log:       logging.NewDefaultLoggerFactory().NewLogger("mux"),
+	}
+	endpoint := mux.NewEndpoint(MatchDTLS)
+
+	// Canceling the context of a read interrupts it, as when a DTLS handshake is aborted
+	conn := netctx.NewPacketConn(endpoint)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, _, err := conn.ReadFromContext(ctx, make([]byte, 10))
+	require.ErrorIs(t, err, context.Canceled)
+
+	// The deadline is cleared after the read
+	inBuffer := []byte{20, 1, 2, 3, 4}
+	require.NoError(t, mux.dispatch(inBuffer))
+	outBuffer := make([]byte, len(inBuffer))
+	_, err = endpoint.Read(outBuffer)
+	require.NoError(t, err)
+	require.Equal(t, inBuffer, outBuffer)
+}
@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pion/interceptor/pkg/stats"
+)
+
+// LatencyBreakdown decomposes the latency of the streams of a media section, see
+// PeerConnection.LatencyBreakdown. The parts that can't be estimated from the
+// available feedback are 0.
+type LatencyBreakdown struct {
+	// RoundTripTime is the last round trip time measured with the RTCP reports of
+	// the streams: the Receiver Reports about the sent streams, or the Extended
+	// Reports about the received ones. The ICE round trip time of the selected
+	// candidate pair is used if no report measured it.
+	RoundTripTime time.Duration
+
+	// NetworkDelay is an estimate of the one-way delay of the network: half the round
+	// trip time, which assumes a symmetric path. It isn't measured, an asymmetric
+	// path or the processing time of the remote RTCP make it off.
+	NetworkDelay time.Duration
+
+	// QueueingDelay is an estimate of the queueing on the path: the delay variation
+	// between groups of sent packets, as estimated by the congestion controller from
+	// the TWCC feedback, which grows as the queues of the path fill up. It is the
+	// filtered trend of the controller, not a measured delay, and needs a congestion
+	// controller, see ConfigureCongestionController.
+	QueueingDelay time.Duration
+
+	// Jitter is the largest interarrival jitter of the received streams, which a
+	// receiver has to buffer to play them out smoothly.
+	Jitter time.Duration
+
+	// BufferingDelay is the average delay of the local playout buffers of the received
+	// streams, as reported by their AudioPlayoutStatsProviders.
+	BufferingDelay time.Duration
+}
+
+// LatencyBreakdown estimates where the latency of the streams of the media section
+// mid comes from, combining the RTCP reports, the TWCC feedback and the playout
+// statistics. The RTCP based parts are only known when the stats interceptor is
+// registered, see ConfigureStatsInterceptor, and are updated as the RTCP is read.
+func (pc *PeerConnection) LatencyBreakdown(mid string) (LatencyBreakdown, error) {
+	var transceiver *RTPTransceiver
+	for _, t := range pc.GetTransceivers() {
+		if t.Mid() == mid {
+			transceiver = t
+
+			break
+		}
+	}
+	if transceiver == nil {
+		return LatencyBreakdown{}, fmt.Errorf("%w: %q", errPeerConnTranscieverMidNil, mid)
+	}
+
+	breakdown := LatencyBreakdown{}
+	if pc.statsGetter != nil {
+		if sender := transceiver.Sender(); sender != nil {
+			for _, ssrc := range sender.sendingSSRCs() {
+				if interceptorStats := pc.statsGetter.Get(ssrc); interceptorStats != nil &&
+					interceptorStats.RemoteInboundRTPStreamStats.RoundTripTimeMeasurements != 0 {
+					breakdown.RoundTripTime = interceptorStats.RemoteInboundRTPStreamStats.RoundTripTime
+				}
+			}
+		}
+	}
+
+	now := time.Now()
+	var playoutDelay float64
+	var playoutSamples uint64
+	if receiver := transceiver.Receiver(); receiver != nil {
+		for _, track := range receiver.Tracks() {
+			if pc.statsGetter != nil {
+				if interceptorStats := pc.statsGetter.Get(uint32(track.SSRC())); interceptorStats != nil {
+					breakdown.latencyFromReceivedStream(interceptorStats)
+				}
+			}
+
+			for _, playoutStats := range track.pullAudioPlayoutStats(now) {
+				playoutDelay += playoutStats.TotalPlayoutDelay
+				playoutSamples += playoutStats.TotalSamplesCount
+			}
+		}
+	}
+	if playoutSamples != 0 {
+		breakdown.BufferingDelay = time.Duration(playoutDelay / float64(playoutSamples) * float64(time.Second))
+	}
+
+	pc.mu.RLock()
+	iceTransport := pc.iceTransport
+	pc.mu.RUnlock()
+	if breakdown.RoundTripTime == 0 && iceTransport != nil {
+		if selected, ok := iceTransport.GetSelectedCandidatePairStats(); ok {
+			breakdown.RoundTripTime = time.Duration(selected.CurrentRoundTripTime * float64(time.Second))
+		}
+	}
+	breakdown.NetworkDelay = breakdown.RoundTripTime / 2
+
+	if pc.bandwidthEstimator != nil {
+		if estimate := newBandwidthEstimate(0, pc.bandwidthEstimator.GetStats()); estimate.DelayEstimate > 0 {
+			breakdown.QueueingDelay = estimate.DelayEstimate
+		}
+	}
+
+	return breakdown, nil
+}
+
+// latencyFromReceivedStream updates the breakdown with the statistics of a received stream.
+func (b *LatencyBreakdown) latencyFromReceivedStream(interceptorStats *stats.Stats) {
+	if jitter := time.Duration(interceptorStats.InboundRTPStreamStats.Jitter * float64(time.Second)); jitter > b.Jitter {
+		b.Jitter = jitter
+	}
+
+	if b.RoundTripTime == 0 && interceptorStats.RemoteOutboundRTPStreamStats.RoundTripTimeMeasurements != 0 {
+		b.RoundTripTime = interceptorStats.RemoteOutboundRTPStreamStats.RoundTripTime
+	}
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor/pkg/stats"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyBreakdown_ReceivedStream(t *testing.T) {
+	breakdown := LatencyBreakdown{Jitter: 5 * time.Millisecond}
+
+	received := &stats.Stats{}
+	received.InboundRTPStreamStats.Jitter = 0.002
+	breakdown.latencyFromReceivedStream(received)
+	assert.Equal(t, LatencyBreakdown{Jitter: 5 * time.Millisecond}, breakdown)
+
+	received.InboundRTPStreamStats.Jitter = 0.010
+	received.RemoteOutboundRTPStreamStats.RoundTripTime = 30 * time.Millisecond
+	received.RemoteOutboundRTPStreamStats.RoundTripTimeMeasurements = 1
+	breakdown.latencyFromReceivedStream(received)
+	assert.Equal(t, LatencyBreakdown{Jitter: 10 * time.Millisecond, RoundTripTime: 30 * time.Millisecond}, breakdown)
+}
+
+func TestPeerConnection_LatencyBreakdown(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	_, err = pcOffer.LatencyBreakdown("0")
+	assert.ErrorIs(t, err, errPeerConnTranscieverMidNil)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	// The Receiver Reports are processed as they are read
+	go func() {
+		for {
+			if _, _, readErr := sender.ReadRTCP(); readErr != nil {
+				return
+			}
+		}
+	}()
+
+	provider := NewAudioPlayoutStatsProvider("playout")
+	pcAnswer.OnTrack(func(track *TrackRemote, receiver *RTPReceiver) {
+		// The Sender Reports the Receiver Reports refer to are known as they are read
+		go func() {
+			for {
+				if _, _, readErr := receiver.ReadRTCP(); readErr != nil {
+					return
+				}
+			}
+		}()
+
+		assert.NoError(t, provider.AddTrack(track))
+		provider.Accumulate(960, 48000, 40*time.Millisecond, false)
+
+		for {
+			if _, _, readErr := track.ReadRTP(); readErr != nil {
+				return
+			}
+		}
+	})
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		assert.Eventually(t, func() bool {
+			breakdown, breakdownErr := pcOffer.LatencyBreakdown("0")
+			assert.NoError(t, breakdownErr)
+
+			return breakdown.RoundTripTime > 0 && breakdown.NetworkDelay == breakdown.RoundTripTime/2
+		}, 10*time.Second, 50*time.Millisecond)
+
+		assert.Eventually(t, func() bool {
+			breakdown, breakdownErr := pcAnswer.LatencyBreakdown("0")
+			assert.NoError(t, breakdownErr)
+
+			return breakdown.BufferingDelay == 40*time.Millisecond && breakdown.RoundTripTime > 0
+		}, 10*time.Second, 50*time.Millisecond)
+		cancel()
+	}()
+	sendVideoUntilDone(t, ctx.Done(), []*TrackLocalStaticSample{track})
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -0,0 +1,234 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/logging"
+	"github.com/pion/mdns/v2"
+	"github.com/pion/transport/v3"
+	"github.com/pion/transport/v3/stdnet"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// mDNSCache keeps the addresses of the mDNS names resolved, it is shared by the
+// PeerConnections of an API.
+type mDNSCache struct {
+	mu      sync.Mutex
+	entries map[string]mDNSCacheEntry
+}
+
+type mDNSCacheEntry struct {
+	addr    netip.Addr
+	expires time.Time
+}
+
+func (c *mDNSCache) get(name string, now time.Time) (netip.Addr, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[name]
+	if !ok {
+		return netip.Addr{}, false
+	}
+	if !now.Before(entry.expires) {
+		delete(c.entries, name)
+
+		return netip.Addr{}, false
+	}
+
+	return entry.addr, true
+}
+
+// put caches the address of name until expires. The expired entries are pruned first,
+// and once the cache is full the entry expiring first is evicted.
+func (c *mDNSCache) put(name string, addr netip.Addr, now, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[string]mDNSCacheEntry{}
+	}
+	if _, ok := c.entries[name]; !ok && len(c.entries) >= mDNSCacheMaxEntries {
+		evicted, evictedExpires := "", time.Time{}
+		for cachedName, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, cachedName)
+
+				continue
+			}
+			if evicted == "" || entry.expires.Before(evictedExpires) {
+				evicted, evictedExpires = cachedName, entry.expires
+			}
+		}
+		if len(c.entries) >= mDNSCacheMaxEntries {
+			delete(c.entries, evicted)
+		}
+	}
+	c.entries[name] = mDNSCacheEntry{addr: addr, expires: expires}
+}
+
+// mDNSResolver resolves the mDNS names of the remote candidates in place of the agent,
+// when SettingEngine.SetMulticastDNSResolveTimeout, SetMulticastDNSInterfaces or
+// SetMulticastDNSCacheTTL are used. The loopback interfaces are queried if named.
+type mDNSResolver struct {
+	conn     *mdns.Conn
+	cache    *mDNSCache
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	ctx       context.Context //nolint:containedctx
+	ctxCancel func()
+	resolving sync.WaitGroup
+}
+
+func newMDNSResolver(
+	n transport.Net,
+	interfaceNames []string,
+	networkTypes []NetworkType,
+	includeLoopback bool,
+	timeout, cacheTTL time.Duration,
+	cache *mDNSCache,
+	loggerFactory logging.LoggerFactory,
+) (*mDNSResolver, error) {
+	if n == nil {
+		var err error
+		if n, err = stdnet.NewNet(); err != nil {
+			return nil, err
+		}
+	}
+
+	var interfaces []net.Interface
+	for _, name := range interfaceNames {
+		ifc, err := n.InterfaceByName(name)
+		if err != nil {
+			return nil, err
+		}
+		interfaces = append(interfaces, ifc.Interface)
+	}
+
+	useV4, useV6 := len(networkTypes) == 0, len(networkTypes) == 0
+	for _, networkType := range networkTypes {
+		useV4 = useV4 || ice.NetworkType(networkType).IsIPv4()
+		useV6 = useV6 || ice.NetworkType(networkType).IsIPv6()
+	}
+
+	var conn4 *ipv4.PacketConn
+	if useV4 {
+		addr, err := n.ResolveUDPAddr("udp4", mdns.DefaultAddressIPv4)
+		if err != nil {
+			return nil, err
+		}
+		l, err := n.ListenUDP("udp4", addr)
+		if err != nil {
+			return nil, err
+		}
+		conn4 = ipv4.NewPacketConn(l)
+	}
+
+	var conn6 *ipv6.PacketConn
+	if useV6 {
+		addr, err := n.ResolveUDPAddr("udp6", mdns.DefaultAddressIPv6)
+		if err == nil {
+			var l transport.UDPConn
+			if l, err = n.ListenUDP("udp6", addr); err == nil {
+				conn6 = ipv6.NewPacketConn(l)
+			}
+		}
+		if err != nil && conn4 == nil {
+			return nil, err
+		}
+	}
+
+	conn, err := mdns.Server(conn4, conn6, &mdns.Config{
+		Interfaces:      interfaces,
+		IncludeLoopback: includeLoopback || len(interfaces) > 0,
+		LoggerFactory:   loggerFactory,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+
+	return &mDNSResolver{
+		conn:      conn,
+		cache:     cache,
+		timeout:   timeout,
+		cacheTTL:  cacheTTL,
+		ctx:       ctx,
+		ctxCancel: ctxCancel,
+	}, nil
+}
+
+// isMDNSCandidate tells if the address of candidate is an mDNS name.
+func isMDNSCandidate(candidate ICECandidate) bool {
+	return candidate.Typ == ICECandidateTypeHost && strings.HasSuffix(candidate.Address, ".local")
+}
+
+// resolve returns the address of name, from the cache if it is still valid.
+func (r *mDNSResolver) resolve(name string) (netip.Addr, error) {
+	if r.cacheTTL > 0 {
+		if addr, ok := r.cache.get(name, time.Now()); ok {
+			return addr, nil
+		}
+	}
+
+	ctx := r.ctx
+	if r.timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	_, addr, err := r.conn.QueryAddr(ctx, name)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	if r.cacheTTL > 0 {
+		now := time.Now()
+		r.cache.put(name, addr, now, now.Add(r.cacheTTL))
+	}
+
+	return addr, nil
+}
+
+// resolveCandidate resolves the address of candidate in the background, add is called
+// with the candidate resolved unless it fails.
+func (r *mDNSResolver) resolveCandidate(candidate ICECandidate, add func(ICECandidate), log logging.LeveledLogger) {
+	r.resolving.Add(1)
+	go func() {
+		defer r.resolving.Done()
+
+		addr, err := r.resolve(candidate.Address)
+		if err != nil {
+			log.Warnf("Failed to resolve mDNS candidate %s: %v", candidate.Address, err)
+
+			return
+		}
+
+		candidate.Address = addr.Unmap().String()
+		add(candidate)
+	}()
+}
+
+func (r *mDNSResolver) close() error {
+	r.ctxCancel()
+	err := r.conn.Close()
+	r.resolving.Wait()
+
+	return err
+}
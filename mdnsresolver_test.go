@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMDNSCache(t *testing.T) {
+	cache := &mDNSCache{}
+	now := time.Now()
+	addr := netip.MustParseAddr("192.168.1.2")
+
+	_, ok := cache.get("peer.local", now)
+	assert.False(t, ok)
+
+	cache.put("peer.local", addr, now, now.Add(time.Minute))
+	cached, ok := cache.get("peer.local", now.Add(time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, addr, cached)
+
+	_, ok = cache.get("peer.local", now.Add(time.Minute))
+	assert.False(t, ok)
+
+	// Once full, the expired entries are pruned and then the one expiring first evicted
+	for i := 0; i < mDNSCacheMaxEntries; i++ {
+		cache.put(fmt.Sprintf("peer-%d.local", i), addr, now, now.Add(time.Duration(i+1)*time.Second))
+	}
+	later := now.Add(10 * time.Second)
+	cache.put("new.local", addr, later, later.Add(time.Minute))
+	assert.Len(t, cache.entries, mDNSCacheMaxEntries-10+1)
+
+	for i := 0; i < 9; i++ {
+		cache.put(fmt.Sprintf("other-%d.local", i), addr, later, later.Add(time.Minute))
+	}
+	assert.Len(t, cache.entries, mDNSCacheMaxEntries)
+	cache.put("last.local", addr, later, later.Add(time.Minute))
+	assert.Len(t, cache.entries, mDNSCacheMaxEntries)
+	_, ok = cache.get("peer-10.local", later)
+	assert.False(t, ok)
+	_, ok = cache.get("peer-11.local", later)
+	assert.True(t, ok)
+}
+
+func TestPeerConnection_MulticastDNSResolver(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerSettings := SettingEngine{}
+	offerSettings.SetICEMulticastDNSMode(ice.MulticastDNSModeQueryAndGather)
+	offerSettings.SetIncludeLoopbackCandidate(true)
+	offerSettings.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+
+	answerSettings := SettingEngine{}
+	answerSettings.SetIncludeLoopbackCandidate(true)
+	answerSettings.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+	answerSettings.SetMulticastDNSInterfaces("lo")
+	answerSettings.SetMulticastDNSResolveTimeout(5 * time.Second)
+	answerSettings.SetMulticastDNSCacheTTL(time.Minute)
+	answerAPI := NewAPI(WithSettingEngine(answerSettings))
+
+	offerPC, err := NewAPI(WithSettingEngine(offerSettings)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	answerPC, err := answerAPI.NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = offerPC.CreateDataChannel("mdns", nil)
+	assert.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+
+	assert.Contains(t, offerPC.LocalDescription().SDP, ".local")
+	pair, err := answerPC.SCTP().Transport().ICETransport().GetSelectedCandidatePair()
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", pair.Remote.Address)
+
+	closePairNow(t, offerPC, answerPC)
+}
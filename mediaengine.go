@@ -18,6 +18,7 @@ import (
 	"github.com/pion/rtp/codecs"
 	"github.com/pion/sdp/v3"
 	"github.com/pion/webrtc/v4/internal/fmtp"
+	"github.com/pion/webrtc/v4/pkg/codecs/rawvideo"
 )
 
 type mediaEngineHeaderExtension struct {
@@ -180,6 +181,7 @@ func (m *MediaEngine) RegisterDefaultCodecs() error {
 			RTPCodecCapability: RTPCodecCapability{
 				MimeType:     MimeTypeH265,
 				ClockRate:    90000,
+				SDPFmtpLine:  "level-id=93;profile-id=1;tier-flag=0;tx-mode=SRST",
 				RTCPFeedback: videoRTCPFeedback,
 			},
 			PayloadType: 116,
@@ -227,6 +229,15 @@ func (m *MediaEngine) RegisterDefaultCodecs() error {
 			RTPCodecCapability: RTPCodecCapability{MimeTypeRTX, 90000, 0, "apt=112", nil},
 			PayloadType:        113,
 		},
+
+		{
+			RTPCodecCapability: RTPCodecCapability{
+				MimeTypeRaw, 90000, 0,
+				"sampling=YCbCr-4:2:2; width=1280; height=720; depth=8",
+				[]RTCPFeedback{{"nack", ""}},
+			},
+			PayloadType: 118,
+		},
 	} {
 		if err := m.RegisterCodec(codec, RTPCodecTypeVideo); err != nil {
 			return err
@@ -363,6 +374,21 @@ func (m *MediaEngine) getHeaderExtensionID(extension RTPHeaderExtensionCapabilit
 	return
 }
 
+// isHeaderExtensionRegistered returns true if the header extension has been
+// registered for the kind with RegisterHeaderExtension.
+func (m *MediaEngine) isHeaderExtensionRegistered(extension RTPHeaderExtensionCapability, typ RTPCodecType) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, h := range m.headerExtensions {
+		if extension.URI == h.uri && (h.isAudio && typ == RTPCodecTypeAudio || h.isVideo && typ == RTPCodecTypeVideo) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // copy copies any user modifiable state of the MediaEngine
 // all internal state is reset.
 func (m *MediaEngine) copy() *MediaEngine {
@@ -432,6 +458,7 @@ func (m *MediaEngine) collectStats(collector *statsReportCollector) {
 				Type:        StatsTypeCodec,
 				ID:          codec.statsID,
 				PayloadType: codec.PayloadType,
+				TransportID: "iceTransport",
 				MimeType:    codec.MimeType,
 				ClockRate:   codec.ClockRate,
 				Channels:    uint8(codec.Channels), //nolint:gosec // G115
@@ -657,6 +684,7 @@ func (m *MediaEngine) updateFromRemoteDescription(desc sdp.SessionDescription) e
 			}
 
 			remoteCodec.RTCPFeedback = rtcpFeedbackIntersection(localCodec.RTCPFeedback, remoteCodec.RTCPFeedback)
+			remoteCodec.statsID = localCodec.statsID
 
 			if matchType == codecMatchExact {
 				exactMatches = addIfNew(exactMatches, remoteCodec)
@@ -672,6 +700,7 @@ func (m *MediaEngine) updateFromRemoteDescription(desc sdp.SessionDescription) e
 			}
 
 			remoteCodec.RTCPFeedback = rtcpFeedbackIntersection(localCodec.RTCPFeedback, remoteCodec.RTCPFeedback)
+			remoteCodec.statsID = localCodec.statsID
 
 			if matchType == codecMatchExact {
 				exactMatches = addIfNew(exactMatches, remoteCodec)
@@ -702,6 +731,23 @@ func (m *MediaEngine) updateFromRemoteDescription(desc sdp.SessionDescription) e
 	return nil
 }
 
+// sdpFmtpLine returns the fmtp line of a codec to signal. Negotiated codecs are copies of the remote ones,
+// the stereo parameters of Opus describe what each peer receives and sends, so the local ones are signaled.
+func (m *MediaEngine) sdpFmtpLine(codec RTPCodecParameters) string {
+	if !strings.EqualFold(codec.MimeType, MimeTypeOpus) {
+		return codec.SDPFmtpLine
+	}
+
+	m.mu.RLock()
+	localCodec, matchType := codecParametersFuzzySearch(codec, m.audioCodecs)
+	m.mu.RUnlock()
+	if matchType == codecMatchNone {
+		return codec.SDPFmtpLine
+	}
+
+	return withStereoParameters(codec.SDPFmtpLine, localCodec.SDPFmtpLine)
+}
+
 func (m *MediaEngine) getCodecsByKind(typ RTPCodecType) []RTPCodecParameters {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -809,7 +855,8 @@ func payloaderForCodec(codec RTPCodecCapability) (rtp.Payloader, error) {
 		return &codecs.H264Payloader{}, nil
 	case strings.ToLower(MimeTypeH265):
 		return &codecs.H265Payloader{}, nil
-	case strings.ToLower(MimeTypeOpus):
+	case strings.ToLower(MimeTypeOpus), strings.ToLower(MimeTypeRED):
+		// RED carries the Opus payloads as is, redundancy is added by TrackLocalStaticSample
 		return &codecs.OpusPayloader{}, nil
 	case strings.ToLower(MimeTypeVP8):
 		return &codecs.VP8Payloader{
@@ -823,6 +870,13 @@ func payloaderForCodec(codec RTPCodecCapability) (rtp.Payloader, error) {
 		return &codecs.G722Payloader{}, nil
 	case strings.ToLower(MimeTypePCMU), strings.ToLower(MimeTypePCMA):
 		return &codecs.G711Payloader{}, nil
+	case strings.ToLower(MimeTypeRaw):
+		format, err := rawvideo.ParseFormat(codec.SDPFmtpLine)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rawvideo.Payloader{Format: format}, nil
 	default:
 		return nil, ErrNoPayloaderForCodec
 	}
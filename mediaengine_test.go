@@ -7,13 +7,20 @@
 package webrtc
 
 import (
+	"bytes"
 	"fmt"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
+	"github.com/pion/rtp/codecs/av1/obu"
 	"github.com/pion/sdp/v3"
 	"github.com/pion/transport/v3/test"
+	"github.com/pion/webrtc/v4/pkg/codecs/rawvideo"
+	"github.com/pion/webrtc/v4/pkg/media"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,6 +40,64 @@ func TestOpusCase(t *testing.T) {
 	assert.NoError(t, pc.Close())
 }
 
+func TestOpusStereo(t *testing.T) {
+	newAPI := func(fmtpLine string) *API {
+		mediaEngine := &MediaEngine{}
+		assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeOpus, 48000, 2, fmtpLine, nil},
+			PayloadType:        111,
+		}, RTPCodecTypeAudio))
+
+		return NewAPI(WithMediaEngine(mediaEngine))
+	}
+
+	pcOffer, err := newAPI("minptime=10;stereo=1;sprop-stereo=1").NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := newAPI("minptime=10;useinbandfec=1").NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+	receiver, err := pcAnswer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	// both stereo parameters are only signaled by the offerer, the answer must not echo them
+	assert.Contains(t, pcOffer.LocalDescription().SDP, "a=fmtp:111 minptime=10;stereo=1;sprop-stereo=1")
+	assert.Contains(t, pcAnswer.LocalDescription().SDP, "a=fmtp:111 minptime=10\r\n")
+
+	offerCodecs := sender.Sender().GetParameters().Codecs
+	assert.Equal(t, 1, len(offerCodecs))
+	assert.Equal(t, OpusChannelConfig{}, offerCodecs[0].OpusChannelConfig())
+
+	answerCodecs := receiver.Sender().GetParameters().Codecs
+	assert.Equal(t, 1, len(answerCodecs))
+	assert.Equal(t, OpusChannelConfig{Stereo: true, SpropStereo: true}, answerCodecs[0].OpusChannelConfig())
+
+	closePairNow(t, pcOffer, pcAnswer)
+
+	// the answerer prefers to receive stereo even though it hasn't been offered
+	pcOffer, err = newAPI("minptime=10").NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err = newAPI("minptime=10;stereo=1").NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	sender, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+	_, err = pcAnswer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	assert.Contains(t, pcAnswer.LocalDescription().SDP, "a=fmtp:111 minptime=10;stereo=1\r\n")
+	offerCodecs = sender.Sender().GetParameters().Codecs
+	assert.Equal(t, 1, len(offerCodecs))
+	assert.Equal(t, OpusChannelConfig{Stereo: true}, offerCodecs[0].OpusChannelConfig())
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
 // pion/example-webrtc-applications#89
 // .
 func TestVideoCase(t *testing.T) {
@@ -1011,3 +1076,144 @@ a=ssrc:4281768245 msid:6ff05509-be96-4ef1-a74f-425e14720983 16d5d7fe-d076-4718-9
 		assert.Len(t, mediaEngine.negotiatedVideoCodecs, 2)
 	})
 }
+
+func TestRawVideoCodec(t *testing.T) {
+	rawFmtp := "sampling=YCbCr-4:2:2; width=64; height=8; depth=8"
+
+	mediaEngine := &MediaEngine{}
+	assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeRaw, ClockRate: 90000, SDPFmtpLine: rawFmtp},
+		PayloadType:        96,
+	}, RTPCodecTypeVideo))
+
+	pc, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeRaw, ClockRate: 90000, SDPFmtpLine: rawFmtp}, "video", "pion",
+	)
+	assert.NoError(t, err)
+
+	_, err = pc.AddTrack(track)
+	assert.NoError(t, err)
+
+	offer, err := pc.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, offer.SDP, "a=rtpmap:96 raw/90000")
+	assert.Contains(t, offer.SDP, "a=fmtp:96 "+rawFmtp)
+	assert.NoError(t, pc.Close())
+
+	payloader, err := payloaderForCodec(RTPCodecCapability{MimeType: "video/RAW", SDPFmtpLine: rawFmtp})
+	assert.NoError(t, err)
+	assert.IsType(t, &rawvideo.Payloader{}, payloader)
+
+	_, err = payloaderForCodec(RTPCodecCapability{MimeType: MimeTypeRaw})
+	assert.Error(t, err)
+
+	// Registered by default, for 720p 4:2:2
+	mediaEngine = &MediaEngine{}
+	assert.NoError(t, mediaEngine.RegisterDefaultCodecs())
+	var defaultRaw *RTPCodecParameters
+	for i := range mediaEngine.videoCodecs {
+		if mediaEngine.videoCodecs[i].MimeType == MimeTypeRaw {
+			defaultRaw = &mediaEngine.videoCodecs[i]
+		}
+	}
+	if assert.NotNil(t, defaultRaw) {
+		assert.Equal(t, PayloadType(118), defaultRaw.PayloadType)
+		format, formatErr := rawvideo.ParseFormat(defaultRaw.SDPFmtpLine)
+		assert.NoError(t, formatErr)
+		assert.Equal(t, rawvideo.Format{Sampling: rawvideo.SamplingYCbCr422, Width: 1280, Height: 720, Depth: 8}, format)
+	}
+
+	// The extended sequence numbers of a track follow the rollovers of its sequence numbers
+	rawFmtp = "sampling=YCbCr-4:2:2; width=1280; height=8; depth=8"
+	track, err = NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeRaw, ClockRate: 90000, SDPFmtpLine: rawFmtp}, "video", "pion",
+		WithRTPSequenceNumber(65535),
+	)
+	assert.NoError(t, err)
+	_, err = track.Bind(rawTrackLocalContext{
+		dummyTrackLocalContext: dummyTrackLocalContext{id: "raw"},
+		codec:                  RTPCodecParameters{RTPCodecCapability: track.Codec(), PayloadType: 96},
+	})
+	assert.NoError(t, err)
+	writer := &recordingPayloadWriter{}
+	track.rtpTrack.bindings[0].writeStream = writer
+
+	format, err := rawvideo.ParseFormat(rawFmtp)
+	assert.NoError(t, err)
+	assert.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, format.FrameSize()), Duration: time.Second}))
+	depacketizer := &rawvideo.Packet{}
+	for i, payload := range writer.payloads {
+		_, err = depacketizer.Unmarshal(payload)
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(min(i, 1)), depacketizer.ExtendedSequenceNumber)
+	}
+	assert.Greater(t, len(writer.payloads), 1)
+}
+
+type rawTrackLocalContext struct {
+	dummyTrackLocalContext
+	codec RTPCodecParameters
+}
+
+func (r rawTrackLocalContext) CodecParameters() []RTPCodecParameters {
+	return []RTPCodecParameters{r.codec}
+}
+
+type recordingPayloadWriter struct {
+	payloads [][]byte
+}
+
+func (r *recordingPayloadWriter) WriteRTP(_ *rtp.Header, payload []byte) (int, error) {
+	r.payloads = append(r.payloads, append([]byte(nil), payload...))
+
+	return len(payload), nil
+}
+
+func (r *recordingPayloadWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+func TestAV1Payloader(t *testing.T) {
+	payloader, err := payloaderForCodec(RTPCodecCapability{MimeType: MimeTypeAV1})
+	assert.NoError(t, err)
+
+	// A temporal unit of a temporal delimiter, a sequence header and a frame
+	temporalUnit := func(frameSize int) []byte {
+		frame := []byte{0x32}
+		frame = append(frame, obu.WriteToLeb128(uint(frameSize))...) //nolint:gosec // G115, positive
+		frame = append(frame, bytes.Repeat([]byte{0xaf}, frameSize)...)
+
+		return append([]byte{0x12, 0x00, 0x0a, 0x03, 0x00, 0x00, 0x00}, frame...)
+	}
+	depacketize := func(payloads [][]byte) []byte {
+		depacketizer := &codecs.AV1Depacketizer{}
+		var temporalUnit []byte
+		for _, payload := range payloads {
+			assert.LessOrEqual(t, len(payload), 1200)
+			obus, depacketizeErr := depacketizer.Unmarshal(payload)
+			assert.NoError(t, depacketizeErr)
+			temporalUnit = append(temporalUnit, obus...)
+		}
+
+		return temporalUnit
+	}
+
+	// The OBUs are aggregated, the last one without length field, in a new coded video sequence
+	payloads := payloader.Payload(1200, temporalUnit(100))
+	if assert.Len(t, payloads, 1) {
+		assert.Equal(t, byte(0x28), payloads[0][0])
+		assert.Equal(t, []byte{0x04, 0x08, 0x00, 0x00, 0x00, 0x30}, payloads[0][1:7])
+		assert.Len(t, payloads[0], 107)
+	}
+	assert.Equal(t, temporalUnit(100)[2:], depacketize(payloads))
+
+	// The OBUs larger than the MTU are continued over the next packets
+	payloads = payloader.Payload(1200, temporalUnit(3000))
+	if assert.Len(t, payloads, 3) {
+		assert.Equal(t, byte(0x48), payloads[0][0]&0xc8)
+		assert.Equal(t, byte(0xc0), payloads[1][0]&0xc8)
+		assert.Equal(t, byte(0x80), payloads[2][0]&0xc8)
+	}
+	assert.Equal(t, temporalUnit(3000)[2:], depacketize(payloads))
+}
@@ -22,6 +22,10 @@ const (
 	// MimeTypeAV1 AV1 MIME type
 	// Note: Matching should be case insensitive.
 	MimeTypeAV1 = "video/AV1"
+	// MimeTypeRaw uncompressed video MIME type, the frame layout is
+	// described by the sampling, width, height and depth fmtp parameters.
+	// Note: Matching should be case insensitive.
+	MimeTypeRaw = "video/raw"
 	// MimeTypeG722 G722 MIME type
 	// Note: Matching should be case insensitive.
 	MimeTypeG722 = "audio/G722"
@@ -31,6 +35,10 @@ const (
 	// MimeTypePCMA PCMA MIME type
 	// Note: Matching should be case insensitive.
 	MimeTypePCMA = "audio/PCMA"
+	// MimeTypeRED redundant audio MIME type, the fmtp line lists
+	// the payload types of the blocks, e.g. 111/111 for Opus.
+	// Note: Matching should be case insensitive.
+	MimeTypeRED = "audio/red"
 	// MimeTypeRTX RTX MIME type
 	// Note: Matching should be case insensitive.
 	MimeTypeRTX = "video/rtx"
@@ -43,4 +51,8 @@ const (
 	// MimeTypeUlpFEC UlpFEC MIME Type
 	// Note: Matching should be case insensitive.
 	MimeTypeUlpFEC = "video/ulpfec"
+	// MimeTypeVideoRED redundant video MIME type, carrying the ULPFEC packets
+	// with the media packets they protect.
+	// Note: Matching should be case insensitive.
+	MimeTypeVideoRED = "video/red"
 )
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"sort"
+	"time"
+
+	"github.com/pion/transport/v3"
+	"github.com/pion/transport/v3/stdnet"
+)
+
+// monitorNetwork polls the addresses of the network interfaces every interval set with
+// SettingEngine.SetNetworkMonitor, and restarts ICE once they change. The OS notifies
+// the changes of its interfaces differently on each platform, and not to a virtual Net.
+// It runs until the PeerConnection is closed.
+func (pc *PeerConnection) monitorNetwork(interval time.Duration) {
+	n := pc.api.settingEngine.net
+	if n == nil {
+		var err error
+		if n, err = stdnet.NewNet(); err != nil {
+			pc.log.Warnf("Failed to monitor the network interfaces: %v", err)
+
+			return
+		}
+	}
+
+	known, err := pc.networkAddresses(n)
+	if err != nil {
+		pc.log.Warnf("Failed to monitor the network interfaces: %v", err)
+
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-pc.isCloseDone:
+			return
+		case <-ticker.C:
+		}
+
+		addresses, err := pc.networkAddresses(n)
+		if err != nil {
+			pc.log.Debugf("Failed to list the network interfaces: %v", err)
+
+			continue
+		}
+
+		change := NetworkChangeEvent{Added: networkAddressesMissing(addresses, known)}
+		change.Removed = networkAddressesMissing(known, addresses)
+		if len(change.Added) == 0 && len(change.Removed) == 0 {
+			continue
+		}
+		known = addresses
+		pc.onNetworkChange(change)
+	}
+}
+
+// onNetworkChange restarts ICE once candidates were gathered: the next offer gathers the
+// candidates of the new addresses, and drops those of the addresses removed.
+func (pc *PeerConnection) onNetworkChange(change NetworkChangeEvent) {
+	pc.log.Infof("network addresses changed, added %v, removed %v", change.Added, change.Removed)
+	pc.events.emit(change)
+
+	if pc.isClosed.Load() || pc.ICEGatheringState() == ICEGatheringStateNew {
+		return
+	}
+	pc.RestartICE()
+}
+
+// networkAddresses returns the addresses the host candidates are gathered from, by their
+// string, filtered like the ICE agent does with the settings of the SettingEngine.
+func (pc *PeerConnection) networkAddresses(n transport.Net) (map[string]net.IP, error) {
+	interfaces, err := n.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := pc.api.settingEngine.candidates
+	ipv4, ipv6 := len(candidates.ICENetworkTypes) == 0, len(candidates.ICENetworkTypes) == 0
+	for _, networkType := range candidates.ICENetworkTypes {
+		switch networkType {
+		case NetworkTypeUDP4, NetworkTypeTCP4:
+			ipv4 = true
+		case NetworkTypeUDP6, NetworkTypeTCP6:
+			ipv6 = true
+		default:
+		}
+	}
+
+	addresses := map[string]net.IP{}
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 ||
+			(iface.Flags&net.FlagLoopback != 0 && !candidates.IncludeLoopbackCandidate) ||
+			(candidates.InterfaceFilter != nil && !candidates.InterfaceFilter(iface.Name)) {
+			continue
+		}
+
+		ifaceAddrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range ifaceAddrs {
+			var ip net.IP
+			switch addr := addr.(type) {
+			case *net.IPNet:
+				ip = addr.IP
+			case *net.IPAddr:
+				ip = addr.IP
+			default:
+				continue
+			}
+
+			isIPv4 := ip.To4() != nil
+			// The agent skips the link-local IPv6 addresses
+			if (ip.IsLoopback() && !candidates.IncludeLoopbackCandidate) || (isIPv4 && !ipv4) ||
+				(!isIPv4 && (!ipv6 || ip.IsLinkLocalUnicast())) ||
+				(candidates.IPFilter != nil && !candidates.IPFilter(ip)) {
+				continue
+			}
+			addresses[ip.String()] = ip
+		}
+	}
+
+	return addresses, nil
+}
+
+// networkAddressesMissing returns the addresses of a missing from b, sorted.
+func networkAddressesMissing(a, b map[string]net.IP) []net.IP {
+	var missing []net.IP
+	for key, ip := range a {
+		if _, ok := b[key]; !ok {
+			missing = append(missing, ip)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].String() < missing[j].String()
+	})
+
+	return missing
+}
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/logging"
+	"github.com/pion/transport/v3"
+	"github.com/pion/transport/v3/test"
+	"github.com/pion/transport/v3/vnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// networkMonitorNet is a vnet.Net whose interfaces can be added.
+type networkMonitorNet struct {
+	*vnet.Net
+
+	mu    sync.Mutex
+	added []*transport.Interface
+}
+
+func (n *networkMonitorNet) Interfaces() ([]*transport.Interface, error) {
+	interfaces, err := n.Net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append(interfaces, n.added...), nil
+}
+
+func TestNetworkMonitor(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	require.NoError(t, err)
+
+	newNet := func(ip string) *vnet.Net {
+		vnetNet, netErr := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{ip}})
+		require.NoError(t, netErr)
+		require.NoError(t, wan.AddNet(vnetNet))
+
+		return vnetNet
+	}
+	monitoredNet := &networkMonitorNet{Net: newNet("1.2.3.4")}
+	answerNet := newNet("1.2.3.6")
+	require.NoError(t, wan.Start())
+
+	settings := SettingEngine{}
+	settings.SetNet(monitoredNet)
+	settings.SetNetworkMonitor(10 * time.Millisecond)
+	pc, err := NewAPI(WithSettingEngine(settings)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	events := pc.Events()
+
+	answerSettings := SettingEngine{}
+	answerSettings.SetNet(answerNet)
+	answerPC, err := NewAPI(WithSettingEngine(answerSettings)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	_, err = pc.CreateDataChannel("monitor", nil)
+	require.NoError(t, err)
+	connected := untilConnectionState(PeerConnectionStateConnected, pc)
+	require.NoError(t, signalPair(pc, answerPC))
+	connected.Wait()
+
+	negotiationNeeded := make(chan struct{})
+	pc.OnNegotiationNeeded(func() {
+		close(negotiationNeeded)
+	})
+
+	iface := transport.NewInterface(net.Interface{Index: 2, MTU: 1500, Name: "eth1", Flags: net.FlagUp})
+	iface.AddAddress(&net.IPNet{IP: net.ParseIP("1.2.3.5"), Mask: net.CIDRMask(24, 32)})
+	monitoredNet.mu.Lock()
+	monitoredNet.added = append(monitoredNet.added, iface)
+	monitoredNet.mu.Unlock()
+
+	for event := range events.C() {
+		if change, ok := event.(NetworkChangeEvent); ok {
+			assert.Equal(t, []net.IP{net.ParseIP("1.2.3.5")}, change.Added)
+			assert.Empty(t, change.Removed)
+
+			break
+		}
+	}
+	<-negotiationNeeded
+	assert.True(t, pc.isICERestartNeeded.Load())
+
+	closePairNow(t, pc, answerPC)
+	assert.NoError(t, wan.Stop())
+}
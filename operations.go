@@ -21,6 +21,10 @@ type operations struct {
 	updateNegotiationNeededFlagOnEmptyChain *atomic.Bool
 	onNegotiationNeeded                     func()
 	isClosed                                bool
+
+	// running counts the operations executed by their caller instead of the queue,
+	// like CreateOffer, they are part of the chain too.
+	running int
 }
 
 func newOperations(
@@ -65,12 +69,34 @@ func (o *operations) tryEnqueue(op operation) bool {
 	return true
 }
 
-// IsEmpty checks if there are tasks in the queue.
+// IsEmpty checks if there are tasks in the queue, or running operations.
 func (o *operations) IsEmpty() bool {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
-	return o.ops.Len() == 0
+	return o.ops.Len() == 0 && o.running == 0
+}
+
+// begin adds an operation executed by the caller to the chain, until end is called.
+// https://www.w3.org/TR/webrtc/#dfn-chain-an-operation
+func (o *operations) begin() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.running++
+}
+
+// end removes an operation added by begin. Once the chain is empty, it updates
+// the negotiation-needed flag if it was requested while the chain was not empty.
+func (o *operations) end() {
+	o.mu.Lock()
+	o.running--
+	empty := o.running == 0 && o.ops.Len() == 0 && o.busyCh == nil
+	o.mu.Unlock()
+
+	if empty && o.updateNegotiationNeededFlagOnEmptyChain.CompareAndSwap(true, false) {
+		o.onNegotiationNeeded()
+	}
 }
 
 // Done blocks until all currently enqueued operations are finished executing.
@@ -151,9 +177,13 @@ func (o *operations) start() {
 		fn()
 		fn = o.pop()
 	}
-	if !o.updateNegotiationNeededFlagOnEmptyChain.Load() {
+
+	// The last running operation updates the flag when it ends
+	o.mu.Lock()
+	running := o.running
+	o.mu.Unlock()
+	if running != 0 || !o.updateNegotiationNeededFlagOnEmptyChain.CompareAndSwap(true, false) {
 		return
 	}
-	o.updateNegotiationNeededFlagOnEmptyChain.Store(false)
 	o.onNegotiationNeeded()
 }
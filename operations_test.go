@@ -81,3 +81,28 @@ func TestOperations_GracefulClose(t *testing.T) {
 	ops.Done()
 	assert.Equal(t, counterCur, times)
 }
+
+func TestOperations_BeginEnd(t *testing.T) {
+	updateNegotiationNeededFlagOnEmptyChain := &atomic.Bool{}
+	var onNegotiationNeededCalledCount atomic.Int32
+	ops := newOperations(updateNegotiationNeededFlagOnEmptyChain, func() {
+		onNegotiationNeededCalledCount.Add(1)
+	})
+	defer ops.GracefulClose()
+
+	ops.begin()
+	ops.begin()
+	assert.False(t, ops.IsEmpty())
+
+	// The queue drains while operations are running, the update waits for them
+	updateNegotiationNeededFlagOnEmptyChain.Store(true)
+	ops.Done()
+	assert.Equal(t, int32(0), onNegotiationNeededCalledCount.Load())
+
+	ops.end()
+	assert.Equal(t, int32(0), onNegotiationNeededCalledCount.Load())
+	ops.end()
+	assert.True(t, ops.IsEmpty())
+	assert.Equal(t, int32(1), onNegotiationNeededCalledCount.Load())
+	assert.False(t, updateNegotiationNeededFlagOnEmptyChain.Load())
+}
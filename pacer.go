@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// pacedPacket is a packet queued by the pacer with the function writing it.
+type pacedPacket struct {
+	header  *rtp.Header
+	payload []byte
+	write   func(*rtp.Header, []byte) (int, error)
+}
+
+func (p *pacedPacket) size() int {
+	return p.header.MarshalSize() + len(p.payload)
+}
+
+// pacer is a leaky bucket shared by the RTPSenders of a DTLSTransport. Packets exceeding
+// the bucket are queued and written by a goroutine as it drains, so the outgoing RTP
+// doesn't exceed bitrate for more than burst bytes without blocking the writers.
+type pacer struct {
+	mu      sync.Mutex
+	bitrate int // bits per second, 0 disables the pacing
+	burst   int // bytes
+	// level is the amount of bytes in the bucket at last.
+	level float64
+	last  time.Time
+
+	// queue is drained by run, it is running as long as the queue isn't empty.
+	// The packet at the front stays queued while it is written, keeping the order.
+	queue []pacedPacket
+	// rateChanged wakes run up when the rate changes.
+	rateChanged chan struct{}
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newPacer(bitrate, burst int) *pacer {
+	return &pacer{
+		bitrate:     bitrate,
+		burst:       burst,
+		rateChanged: make(chan struct{}, 1),
+		closed:      make(chan struct{}),
+	}
+}
+
+// setRate changes the bitrate and the burst of the pacer, the packets queued are
+// written at the new rate.
+func (p *pacer) setRate(bitrate, burst int) {
+	p.mu.Lock()
+	p.drain(time.Now())
+	p.bitrate = bitrate
+	p.burst = burst
+	p.mu.Unlock()
+
+	select {
+	case p.rateChanged <- struct{}{}:
+	default:
+	}
+}
+
+// writeRTP writes the packet right away if the bucket allows it, otherwise the packet
+// is copied and queued. It never blocks on the pacing, the errors of the queued
+// packets are dropped like the packets lost by the network.
+func (p *pacer) writeRTP(
+	header *rtp.Header,
+	payload []byte,
+	write func(*rtp.Header, []byte) (int, error),
+) (int, error) {
+	size := header.MarshalSize() + len(payload)
+
+	p.mu.Lock()
+	select {
+	case <-p.closed:
+		p.mu.Unlock()
+
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
+	if len(p.queue) == 0 && p.admit(size, time.Now()) {
+		p.mu.Unlock()
+
+		return write(header, payload)
+	}
+
+	if len(p.queue) >= pacerMaxQueueSize {
+		p.mu.Unlock()
+
+		return 0, errPacerQueueFull
+	}
+
+	clone := header.Clone()
+	p.queue = append(p.queue, pacedPacket{
+		header:  &clone,
+		payload: append([]byte(nil), payload...),
+		write:   write,
+	})
+	if len(p.queue) == 1 {
+		go p.run()
+	}
+	p.mu.Unlock()
+
+	return size, nil
+}
+
+// run writes the queued packets as the bucket drains, it returns once the queue is empty.
+func (p *pacer) run() {
+	for {
+		p.mu.Lock()
+		if len(p.queue) == 0 {
+			p.mu.Unlock()
+
+			return
+		}
+
+		pkt := p.queue[0]
+		now := time.Now()
+		if !p.admit(pkt.size(), now) {
+			delay := p.delay(pkt.size())
+			p.mu.Unlock()
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-p.rateChanged:
+				timer.Stop()
+			case <-p.closed:
+				timer.Stop()
+
+				return
+			}
+
+			continue
+		}
+		p.mu.Unlock()
+
+		_, _ = pkt.write(pkt.header, pkt.payload)
+
+		p.mu.Lock()
+		if len(p.queue) > 0 { // emptied by close
+			p.queue[0] = pacedPacket{}
+			p.queue = p.queue[1:]
+		}
+		p.mu.Unlock()
+	}
+}
+
+// admit adds size bytes to the bucket if it has room for them, it must be called with the
+// lock held. A packet larger than the burst is admitted once the bucket is empty.
+func (p *pacer) admit(size int, now time.Time) bool {
+	if p.bitrate <= 0 {
+		return true
+	}
+
+	p.drain(now)
+	if p.level > 0 && p.level+float64(size) > float64(p.burst) {
+		return false
+	}
+	p.level += float64(size)
+
+	return true
+}
+
+// delay returns how long until the bucket has room for size bytes, it must be called
+// with the lock held.
+func (p *pacer) delay(size int) time.Duration {
+	excess := p.level + float64(size) - float64(p.burst)
+	if excess > p.level {
+		excess = p.level
+	}
+
+	return time.Duration(excess * 8 * float64(time.Second) / float64(p.bitrate))
+}
+
+// drain removes the bytes sent since last from the bucket, it must be called with the lock held.
+func (p *pacer) drain(now time.Time) {
+	if !p.last.IsZero() && p.bitrate > 0 {
+		p.level -= now.Sub(p.last).Seconds() * float64(p.bitrate) / 8
+		if p.level < 0 {
+			p.level = 0
+		}
+	}
+	p.last = now
+}
+
+// close stops the pacer, the packets queued are dropped.
+func (p *pacer) close() {
+	p.closeOnce.Do(func() {
+		p.mu.Lock()
+		close(p.closed)
+		p.queue = nil
+		p.mu.Unlock()
+	})
+}
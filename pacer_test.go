@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacer_Admit(t *testing.T) {
+	// 8 kbit/s drains 1000 bytes per second
+	pacer := newPacer(8000, 1000)
+	now := time.Now()
+
+	// The burst is admitted right away, the following packets wait for the bucket
+	assert.True(t, pacer.admit(500, now))
+	assert.True(t, pacer.admit(500, now))
+	assert.False(t, pacer.admit(100, now))
+	assert.Equal(t, 100*time.Millisecond, pacer.delay(100))
+
+	// The bucket drains over time
+	assert.True(t, pacer.admit(100, now.Add(100*time.Millisecond)))
+
+	// A packet larger than the burst waits for the bucket to be empty
+	now = now.Add(10 * time.Second)
+	pacer.setRate(16000, 0)
+	assert.True(t, pacer.admit(100, now))
+	assert.False(t, pacer.admit(100, now))
+	assert.Equal(t, 50*time.Millisecond, pacer.delay(100))
+
+	pacer.setRate(0, 0)
+	assert.True(t, pacer.admit(100_000, now))
+}
+
+func TestPacer_WriteRTP(t *testing.T) {
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// 80 kbit/s drains 100 bytes per 10ms
+	pacer := newPacer(80_000, 0)
+
+	var mu sync.Mutex
+	var written []uint16
+	var times []time.Time
+	write := func(header *rtp.Header, payload []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		written = append(written, header.SequenceNumber)
+		times = append(times, time.Now())
+
+		return header.MarshalSize() + len(payload), nil
+	}
+
+	// The writes don't block, the packets are written in order at the pacing rate
+	start := time.Now()
+	header := &rtp.Header{}
+	payload := make([]byte, 100-header.MarshalSize())
+	for i := uint16(0); i < 5; i++ {
+		header.SequenceNumber = i
+		n, err := pacer.writeRTP(header, payload, write)
+		assert.NoError(t, err)
+		assert.Equal(t, 100, n)
+	}
+	assert.Less(t, time.Since(start), 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return len(written) == 5
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []uint16{0, 1, 2, 3, 4}, written)
+	assert.GreaterOrEqual(t, times[4].Sub(start), 40*time.Millisecond)
+
+	// The queue is bounded, the first packet may be written right away
+	pacer.setRate(8, 0)
+	for i := 0; i < pacerMaxQueueSize; i++ {
+		_, err := pacer.writeRTP(header, payload, write)
+		assert.NoError(t, err)
+	}
+	_, err := pacer.writeRTP(header, payload, write)
+	if err == nil {
+		_, err = pacer.writeRTP(header, payload, write)
+	}
+	assert.ErrorIs(t, err, errPacerQueueFull)
+
+	// The queued packets are dropped once the pacer is closed
+	pacer.close()
+	_, err = pacer.writeRTP(header, payload, write)
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+}
+
+func TestPeerConnection_Pacing(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetPacing(1_000_000, 1500)
+
+	pcOffer, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(*TrackRemote, *RTPReceiver) {
+		cancel()
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, ctx.Done(), []*TrackLocalStaticSample{track})
+
+	pcOffer.SetPacing(0, 0)
+	assert.True(t, pcOffer.dtlsTransport.pacer.admit(100_000, time.Now()))
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -0,0 +1,308 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/randutil"
+	"github.com/pion/stun/v3"
+	"github.com/pion/transport/v3"
+)
+
+// The sizes of the parts of a STUN message, the transaction ID ends the header.
+const (
+	stunHeaderSize           = 20
+	stunAttributeHeaderSize  = 4
+	stunMessageIntegritySize = 20
+	stunFingerprintSize      = 4
+)
+
+// pathMTUProbes matches the responses to the path MTU probes read by the sockets
+// gathered by ICE, see SettingEngine.SetPathMTUDiscovery. The responses are not
+// passed on to the agent, which doesn't know their transaction.
+type pathMTUProbes struct {
+	mu      sync.Mutex
+	pending map[[stun.TransactionIDSize]byte]pathMTUProbe
+	// count is the size of pending, checked before the packets are parsed.
+	count atomic.Int32
+	// unsupported is set once the Don't Fragment bit can't be set on a socket, the
+	// probes could be fragmented on the way.
+	unsupported atomic.Bool
+}
+
+// pathMTUProbe is a pending probe, done is closed once its response is read.
+type pathMTUProbe struct {
+	done chan struct{}
+	// dontFragment sends the probe with the Don't Fragment bit, the other packets of
+	// the socket are sent as the socket is set.
+	dontFragment bool
+}
+
+func newPathMTUProbes() *pathMTUProbes {
+	return &pathMTUProbes{pending: map[[stun.TransactionIDSize]byte]pathMTUProbe{}}
+}
+
+// add returns the channel closed once the response to the probe id is read. The probe is
+// sent with the Don't Fragment bit if dontFragment is set.
+func (p *pathMTUProbes) add(id [stun.TransactionIDSize]byte, dontFragment bool) <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	probe := pathMTUProbe{done: make(chan struct{}), dontFragment: dontFragment}
+	p.pending[id] = probe
+	p.count.Store(int32(len(p.pending))) //nolint:gosec // G115
+
+	return probe.done
+}
+
+func (p *pathMTUProbes) remove(id [stun.TransactionIDSize]byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.pending, id)
+	p.count.Store(int32(len(p.pending))) //nolint:gosec // G115
+}
+
+// handle reports whether packet is the response to a pending probe, it ends the probe.
+func (p *pathMTUProbes) handle(packet []byte) bool {
+	id, ok := p.transactionID(packet)
+	if !ok {
+		return false
+	}
+
+	p.mu.Lock()
+	probe, ok := p.pending[id]
+	if ok {
+		delete(p.pending, id)
+		p.count.Store(int32(len(p.pending))) //nolint:gosec // G115
+	}
+	p.mu.Unlock()
+
+	if ok {
+		close(probe.done)
+	}
+
+	return ok
+}
+
+// dontFragment reports whether packet is a pending probe sent with the Don't Fragment bit.
+func (p *pathMTUProbes) dontFragment(packet []byte) bool {
+	id, ok := p.transactionID(packet)
+	if !ok {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.pending[id].dontFragment
+}
+
+// transactionID returns the transaction of packet, false if it is not a STUN message or
+// no probe is pending.
+func (p *pathMTUProbes) transactionID(packet []byte) (id [stun.TransactionIDSize]byte, ok bool) {
+	if p.count.Load() == 0 || !stun.IsMessage(packet) {
+		return id, false
+	}
+	copy(id[:], packet[stunHeaderSize-stun.TransactionIDSize:stunHeaderSize])
+
+	return id, true
+}
+
+// pathMTUProbeConn drops the responses to the path MTU probes read on a socket, and
+// sends the probes with the Don't Fragment bit if setDontFragment is set. The bit is only
+// set for the time of their sending, so the fragmentation of the media and data packets
+// is left to the socket: the other packets are sent under the read lock of mu.
+type pathMTUProbeConn struct {
+	transport.UDPConn
+
+	probes          *pathMTUProbes
+	mu              sync.RWMutex
+	setDontFragment func(enabled bool) error
+}
+
+func (c *pathMTUProbeConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if c.setDontFragment == nil {
+		return c.UDPConn.WriteTo(p, addr)
+	}
+
+	if !c.probes.dontFragment(p) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+
+		return c.UDPConn.WriteTo(p, addr)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.setDontFragment(true); err != nil {
+		return 0, err
+	}
+	n, err := c.UDPConn.WriteTo(p, addr)
+	if restoreErr := c.setDontFragment(false); err == nil {
+		err = restoreErr
+	}
+
+	return n, err
+}
+
+func (c *pathMTUProbeConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	for {
+		n, addr, err := c.UDPConn.ReadFrom(p)
+		if err != nil || !c.probes.handle(p[:n]) {
+			return n, addr, err
+		}
+	}
+}
+
+// pathMTUSearch searches the path MTU of a candidate pair in the style of the Datagram
+// PLPMTUD of RFC 8899. The probes are binding requests padded to the size probed,
+// sent like connectivity checks, and a size is delivered once a response is read.
+type pathMTUSearch struct {
+	probes *pathMTUProbes
+	pair   *ice.CandidatePair
+
+	// username and password authenticate the probes to the remote agent.
+	username, password string
+	controlling        bool
+	tieBreaker         uint64
+
+	// baseSize is probed first, the search continues above it if it is delivered,
+	// below it otherwise.
+	baseSize, maxSize int
+	// timeout is how long the response to a probe is waited for, it follows the
+	// round trip time once a response is read.
+	timeout time.Duration
+}
+
+func newPathMTUSearch(
+	probes *pathMTUProbes,
+	agent *ice.Agent,
+	pair *ice.CandidatePair,
+	role ICERole,
+	baseSize, maxSize int,
+) (*pathMTUSearch, error) {
+	localUfrag, _, err := agent.GetLocalUserCredentials()
+	if err != nil {
+		return nil, err
+	}
+	remoteUfrag, remotePwd, err := agent.GetRemoteUserCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pathMTUSearch{
+		probes:      probes,
+		pair:        pair,
+		username:    remoteUfrag + ":" + localUfrag,
+		password:    remotePwd,
+		controlling: role == ICERoleControlling,
+		tieBreaker:  randutil.NewMathRandomGenerator().Uint64(),
+		baseSize:    min(baseSize, maxSize),
+		maxSize:     maxSize,
+		timeout:     pathMTUProbeTimeout,
+	}, nil
+}
+
+// run returns the largest UDP payload delivered on the pair, false if the probes
+// of every size were lost or ctx is done.
+func (s *pathMTUSearch) run(ctx context.Context) (int, bool) {
+	low, high := pathMTUMinProbeSize-1, s.maxSize
+	found := false
+	for size := s.baseSize; ; size = low + (high-low+1)/2 {
+		size &^= 3 // STUN attributes are padded to multiples of 4 bytes
+		delivered, err := s.probe(ctx, size)
+		if err != nil {
+			return 0, false
+		}
+
+		if delivered {
+			low, found = size, true
+		} else {
+			high = size - 1
+		}
+		if high-low < pathMTUProbeGranularity {
+			return low, found
+		}
+	}
+}
+
+// probe sends probes of size until one is delivered, up to pathMTUMaxProbes times.
+// The probes that can't be sent, larger than the MTU of the interface, are lost.
+func (s *pathMTUSearch) probe(ctx context.Context, size int) (bool, error) {
+	for i := 0; i < pathMTUMaxProbes; i++ {
+		msg, err := s.build(size)
+		if err != nil {
+			return false, err
+		}
+
+		done := s.probes.add(msg.TransactionID, true)
+		sent := time.Now()
+		if _, err = s.pair.Write(msg.Raw); err != nil {
+			s.probes.remove(msg.TransactionID)
+
+			return false, nil //nolint:nilerr
+		}
+
+		timer := time.NewTimer(s.timeout)
+		select {
+		case <-done:
+			timer.Stop()
+			s.timeout = max(pathMTUProbeTimeoutRTTs*time.Since(sent), pathMTUMinProbeTimeout)
+
+			return true, nil
+		case <-timer.C:
+			s.probes.remove(msg.TransactionID)
+		case <-ctx.Done():
+			timer.Stop()
+			s.probes.remove(msg.TransactionID)
+
+			return false, ctx.Err()
+		}
+	}
+
+	return false, nil
+}
+
+// build returns a probe of size bytes, a binding request filled with a PADDING
+// attribute, RFC 5780.
+func (s *pathMTUSearch) build(size int) (*stun.Message, error) {
+	var role stun.Setter = ice.AttrControlled(s.tieBreaker)
+	if s.controlling {
+		role = ice.AttrControlling(s.tieBreaker)
+	}
+
+	msg, err := stun.Build(
+		stun.BindingRequest,
+		stun.TransactionID,
+		stun.NewUsername(s.username),
+		role,
+		ice.PriorityAttr(s.pair.Local.Priority()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The MESSAGE-INTEGRITY and FINGERPRINT attributes follow the padding
+	padding := size - len(msg.Raw) - stunAttributeHeaderSize*3 - stunMessageIntegritySize - stunFingerprintSize
+	msg.Add(stun.AttrPadding, make([]byte, max(padding, 0)))
+	if err = stun.NewShortTermIntegrity(s.password).AddTo(msg); err != nil {
+		return nil, err
+	}
+	if err = stun.Fingerprint.AddTo(msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/stun/v3"
+	"github.com/pion/transport/v3"
+	"github.com/pion/transport/v3/stdnet"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathMTUSearch_Build(t *testing.T) {
+	local, err := ice.NewCandidateHost(&ice.CandidateHostConfig{
+		Network: "udp", Address: "127.0.0.1", Port: 5000, Component: 1,
+	})
+	require.NoError(t, err)
+
+	search := &pathMTUSearch{
+		pair:        &ice.CandidatePair{Local: local},
+		username:    "remote:local",
+		password:    "remotepassword",
+		controlling: true,
+		tieBreaker:  42,
+	}
+	for _, size := range []int{pathMTUMinProbeSize, 1200, 1436, 8972} {
+		msg, err := search.build(size)
+		require.NoError(t, err)
+		assert.Len(t, msg.Raw, size)
+
+		decoded := &stun.Message{Raw: append([]byte{}, msg.Raw...)}
+		require.NoError(t, decoded.Decode())
+		assert.Equal(t, stun.BindingRequest, decoded.Type)
+		assert.NoError(t, stun.NewShortTermIntegrity("remotepassword").Check(decoded))
+		assert.NoError(t, stun.Fingerprint.Check(decoded))
+		assert.True(t, decoded.Contains(stun.AttrICEControlling))
+	}
+}
+
+func TestPathMTUProbes(t *testing.T) {
+	probes := newPathMTUProbes()
+
+	response, err := stun.Build(stun.BindingSuccess, stun.TransactionID)
+	require.NoError(t, err)
+	// Nothing pending, the packets are passed on
+	assert.False(t, probes.handle(response.Raw))
+
+	done := probes.add(response.TransactionID, true)
+	assert.False(t, probes.handle([]byte{0x80, 0x60, 0x00, 0x01}))
+	other, err := stun.Build(stun.BindingSuccess, stun.TransactionID)
+	require.NoError(t, err)
+	assert.False(t, probes.handle(other.Raw))
+
+	assert.True(t, probes.handle(response.Raw))
+	<-done
+	assert.Zero(t, probes.count.Load())
+	// The probe ended, its retransmitted response is passed on
+	assert.False(t, probes.handle(response.Raw))
+
+	probes.add(other.TransactionID, false)
+	probes.remove(other.TransactionID)
+	assert.False(t, probes.handle(other.Raw))
+}
+
+func TestPathMTUProbeConn(t *testing.T) {
+	udpConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, udpConn.Close())
+	}()
+
+	if runtime.GOOS == "linux" {
+		setDontFragment, setterErr := newDontFragmentSetter(udpConn)
+		require.NoError(t, setterErr)
+		assert.NoError(t, setDontFragment(true))
+		assert.NoError(t, setDontFragment(false))
+	}
+
+	// Only the path MTU probes are sent with the Don't Fragment bit
+	var set []bool
+	probes := newPathMTUProbes()
+	conn := &pathMTUProbeConn{UDPConn: udpConn, probes: probes, setDontFragment: func(enabled bool) error {
+		set = append(set, enabled)
+
+		return nil
+	}}
+
+	probe, err := stun.Build(stun.BindingRequest, stun.TransactionID)
+	require.NoError(t, err)
+	check, err := stun.Build(stun.BindingRequest, stun.TransactionID)
+	require.NoError(t, err)
+	probes.add(probe.TransactionID, true)
+	probes.add(check.TransactionID, false)
+
+	for _, packet := range [][]byte{{0x80, 0x60, 0x00, 0x01}, check.Raw, probe.Raw} {
+		_, err = conn.WriteTo(packet, udpConn.LocalAddr())
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []bool{true, false}, set)
+}
+
+// pathMTUTestNet drops the UDP packets larger than limit, like a tunnel on the path.
+type pathMTUTestNet struct {
+	*stdnet.Net
+
+	limit int
+}
+
+func (n *pathMTUTestNet) ListenUDP(network string, locAddr *net.UDPAddr) (transport.UDPConn, error) {
+	conn, err := n.Net.ListenUDP(network, locAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pathMTUTestConn{UDPConn: conn.(*net.UDPConn), limit: n.limit}, nil //nolint:forcetypeassert
+}
+
+type pathMTUTestConn struct {
+	*net.UDPConn
+
+	limit int
+}
+
+func (c *pathMTUTestConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if len(p) > c.limit {
+		return len(p), nil
+	}
+
+	return c.UDPConn.WriteTo(p, addr)
+}
+
+func TestPeerConnection_PathMTUDiscovery(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the Don't Fragment bit is only set on Linux")
+	}
+
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const limit = 1300
+	newPeerConnection := func() *PeerConnection {
+		stdNet, err := stdnet.NewNet()
+		require.NoError(t, err)
+
+		settingEngine := SettingEngine{}
+		settingEngine.SetNet(&pathMTUTestNet{Net: stdNet, limit: limit})
+		settingEngine.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+		settingEngine.SetIncludeLoopbackCandidate(true)
+		settingEngine.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+		require.NoError(t, settingEngine.SetPathMTUDiscovery(1500))
+
+		pc, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		return pc
+	}
+	pcOffer, pcAnswer := newPeerConnection(), newPeerConnection()
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	// The search ends within the granularity below the limit of the path
+	for _, pc := range []*PeerConnection{pcOffer, pcAnswer} {
+		assert.Eventually(t, func() bool {
+			return pc.iceTransport.getDiscoveredMTU() != 0
+		}, 20*time.Second, 10*time.Millisecond)
+
+		mtu := pc.iceTransport.getDiscoveredMTU()
+		assert.LessOrEqual(t, mtu, limit-pathMTUHeadroom)
+		assert.Greater(t, mtu, limit-pathMTUHeadroom-pathMTUProbeGranularity)
+	}
+	assert.Equal(t, pcOffer.iceTransport.getDiscoveredMTU(), sender.mtu())
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -7,12 +7,14 @@
 package webrtc
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"slices"
 	"strconv"
 	"strings"
@@ -22,6 +24,7 @@ import (
 
 	"github.com/pion/ice/v4"
 	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/cc"
 	"github.com/pion/interceptor/pkg/stats"
 	"github.com/pion/logging"
 	"github.com/pion/rtcp"
@@ -62,7 +65,18 @@ type PeerConnection struct {
 	isCloseDone                             chan struct{}
 	isGracefulCloseDone                     chan struct{}
 	isNegotiationNeeded                     *atomic.Bool
+	isICERestartNeeded                      atomic.Bool
 	updateNegotiationNeededFlagOnEmptyChain *atomic.Bool
+	// iceRestartStarted is set once the ICE agent restarted for the restart requested
+	// by RestartICE, which is needed until a description with the new credentials is
+	// applied by an answer. Guarded by mu.
+	iceRestartStarted bool
+
+	// The contexts of the operations called with one close the PeerConnection
+	// when done, until it has connected.
+	contextWatchersLock sync.Mutex
+	contextWatchers     []func() bool
+	hasConnected        bool
 
 	lastOffer  string
 	lastAnswer string
@@ -73,15 +87,23 @@ type PeerConnection struct {
 	// should be defined (see JSEP 3.4.1).
 	greaterMid int
 
-	rtpTransceivers        []*RTPTransceiver
+	rtpTransceivers []*RTPTransceiver
+	// remoteOfferUndo is what the pending remote offer changed, it is restored if the
+	// offer is rolled back and discarded once it is answered.
+	remoteOfferUndo        *remoteOfferUndo
 	nonMediaBandwidthProbe atomic.Value // RTPReceiver
 
-	onSignalingStateChangeHandler     func(SignalingState)
-	onICEConnectionStateChangeHandler atomic.Value // func(ICEConnectionState)
-	onConnectionStateChangeHandler    atomic.Value // func(PeerConnectionState)
-	onTrackHandler                    func(*TrackRemote, *RTPReceiver)
-	onDataChannelHandler              func(*DataChannel)
-	onNegotiationNeededHandler        atomic.Value // func()
+	onSignalingStateChangeHandler        func(SignalingState)
+	onICEConnectionStateChangeHandler    atomic.Value // func(ICEConnectionState)
+	onConnectionStateChangeHandler       atomic.Value // func(PeerConnectionState)
+	onConnectionErrorHandler             atomic.Value // func(error)
+	onTrackHandler                       func(*TrackRemote, *RTPReceiver)
+	onDataChannelHandler                 func(*DataChannel)
+	onNegotiationNeededHandler           atomic.Value // func()
+	onSelectedCandidatePairChangeHandler atomic.Value // func(SelectedCandidatePairChange)
+	onBandwidthEstimateHandler           atomic.Value // func(BandwidthEstimate)
+	onQualityChangeHandler               atomic.Value // func(ConnectionQuality)
+	events                               eventBus
 
 	iceGatherer   *ICEGatherer
 	iceTransport  *ICETransport
@@ -93,7 +115,19 @@ type PeerConnection struct {
 	log logging.LeveledLogger
 
 	interceptorRTCPWriter interceptor.RTCPWriter
-	statsGetter           stats.Getter
+	cname                 atomic.Value // string
+	// sourceDescriptionItems are the SDES items besides the CNAME, see SetSourceDescription
+	sourceDescriptionItems atomic.Value // []rtcp.SourceDescriptionItem
+	answerDirectionPolicy  atomic.Value // AnswerDirectionPolicy
+	statsGetter            stats.Getter
+	bandwidthEstimator     cc.BandwidthEstimator
+	selectedPairBitrate    selectedCandidatePairBitrate
+	iceServerProbe         *iceServerProbe
+	debugSession           *debugSession
+	quality                qualityMonitor
+
+	iceRestartTimerLock sync.Mutex
+	iceRestartTimer     *time.Timer
 }
 
 // NewPeerConnection creates a PeerConnection with the default codecs and interceptors.
@@ -138,7 +172,6 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 		signalingState:                          SignalingStateStable,
 
 		api: api,
-		log: api.settingEngine.LoggerFactory.NewLogger("pc"),
 	}
 	pc.ops = newOperations(pc.updateNegotiationNeededFlagOnEmptyChain, pc.onNegotiationNeeded)
 
@@ -154,10 +187,19 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 		pc.statsGetter = getter
 	}
 
+	if estimator, ok := lookupBandwidthEstimator(pc.id); ok {
+		pc.bandwidthEstimator = estimator
+		estimator.OnTargetBitrateChange(pc.onTargetBitrateChange)
+	}
+
 	pc.api = &API{
-		settingEngine: api.settingEngine,
-		interceptor:   i,
+		settingEngine:           api.settingEngine,
+		interceptor:             i,
+		mDNSCache:               api.mDNSCache,
+		workerPool:              api.workerPool,
+		connectionLoggerFactory: withLogAttrs(api.settingEngine.LoggerFactory, slog.String(logKeyConnectionID, pc.id)),
 	}
+	pc.log = pc.api.loggerFactory().NewLogger("pc")
 
 	if api.settingEngine.disableMediaEngineCopy {
 		pc.api.mediaEngine = api.mediaEngine
@@ -170,6 +212,8 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 		return nil, err
 	}
 
+	pc.iceServerProbe = api.iceServerProbe
+
 	pc.iceGatherer, err = pc.createICEGatherer()
 	if err != nil {
 		return nil, err
@@ -185,12 +229,20 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 		return nil, err
 	}
 	pc.dtlsTransport = dtlsTransport
+	pc.dtlsTransport.internalOnSRTPDecryptionFailureHandler = func(failure SRTPDecryptionFailure) {
+		pc.events.emit(SRTPDecryptionFailureEvent{SRTPDecryptionFailure: failure})
+	}
+	pc.dtlsTransport.internalOnHandshakeHandler = func(handshake DTLSHandshake) {
+		pc.events.emit(DTLSHandshakeEvent{DTLSHandshake: handshake})
+	}
 
 	// Create the SCTP transport
 	pc.sctpTransport = pc.api.NewSCTPTransport(pc.dtlsTransport)
+	pc.sctpTransport.internalOnErrorHandler = pc.onSCTPError
 
 	// Wire up the on datachannel handler
 	pc.sctpTransport.OnDataChannel(func(d *DataChannel) {
+		pc.events.emit(DataChannelEvent{DataChannel: d})
 		pc.mu.RLock()
 		handler := pc.onDataChannelHandler
 		pc.mu.RUnlock()
@@ -201,6 +253,14 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 
 	pc.interceptorRTCPWriter = pc.api.interceptor.BindRTCPWriter(interceptor.RTCPWriterFunc(pc.writeRTCP))
 
+	if pc.debugSession = api.debugSessions.Load().add(pc); pc.debugSession != nil {
+		pc.events.observer = pc.debugSession.record
+	}
+
+	if interval := pc.api.settingEngine.networkMonitor; interval > 0 {
+		go pc.monitorNetwork(interval)
+	}
+
 	return pc, nil
 }
 
@@ -277,8 +337,9 @@ func (pc *PeerConnection) onSignalingStateChange(newState SignalingState) {
 	pc.mu.RUnlock()
 
 	pc.log.Infof("signaling state changed to %s", newState)
+	pc.events.emit(SignalingStateChangeEvent{State: newState})
 	if handler != nil {
-		go handler(newState)
+		pc.api.workerPool.run(func() { handler(newState) })
 	}
 }
 
@@ -349,6 +410,7 @@ func (pc *PeerConnection) negotiationNeededOp() {
 	pc.isNegotiationNeeded.Store(true)
 
 	// 4.7.3.2.7 Fire an event named negotiationneeded at connection.
+	pc.events.emit(NegotiationNeededEvent{})
 	if handler, ok := pc.onNegotiationNeededHandler.Load().(func()); ok && handler != nil {
 		handler()
 	}
@@ -356,7 +418,12 @@ func (pc *PeerConnection) negotiationNeededOp() {
 
 func (pc *PeerConnection) checkNegotiationNeeded() bool { //nolint:gocognit,cyclop
 	// To check if negotiation is needed for connection, perform the following checks:
-	// Skip 1, 2 steps
+	// Skip 1 step
+	// Step 2
+	if pc.isICERestartNeeded.Load() {
+		return true
+	}
+
 	// Step 3
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
@@ -392,7 +459,7 @@ func (pc *PeerConnection) checkNegotiationNeeded() bool { //nolint:gocognit,cycl
 		// Step 5.3.1
 		if transceiver.Direction() == RTPTransceiverDirectionSendrecv ||
 			transceiver.Direction() == RTPTransceiverDirectionSendonly {
-			descMsid, okMsid := mid.Attribute(sdp.AttrKeyMsid)
+			_, okMsid := mid.Attribute(sdp.AttrKeyMsid)
 			sender := transceiver.Sender()
 			if sender == nil {
 				return true
@@ -406,7 +473,7 @@ func (pc *PeerConnection) checkNegotiationNeeded() bool { //nolint:gocognit,cycl
 				// As calling replaceTrack does not require renegotiation, we skip check for this transceiver
 				continue
 			}
-			if !okMsid || descMsid != track.StreamID()+" "+track.ID() {
+			if !okMsid || !msidsMatch(mid, sender.getStreamIDs(track), track.ID()) {
 				return true
 			}
 		}
@@ -481,6 +548,7 @@ func (pc *PeerConnection) onTrack(t *TrackRemote, r *RTPReceiver) {
 
 	pc.log.Debugf("got new track: %+v", t)
 	if t != nil {
+		pc.events.emit(TrackEvent{Track: t, Receiver: r})
 		if handler != nil {
 			go handler(t, r)
 		} else {
@@ -498,11 +566,100 @@ func (pc *PeerConnection) OnICEConnectionStateChange(f func(ICEConnectionState))
 func (pc *PeerConnection) onICEConnectionStateChange(cs ICEConnectionState) {
 	pc.iceConnectionState.Store(cs)
 	pc.log.Infof("ICE connection state changed: %s", cs)
+	pc.updateICERestartTimer(cs)
+	pc.events.emit(ICEConnectionStateChangeEvent{State: cs})
 	if handler, ok := pc.onICEConnectionStateChangeHandler.Load().(func(ICEConnectionState)); ok && handler != nil {
 		handler(cs)
 	}
 }
 
+// RestartICE requests an ICE restart: OnNegotiationNeeded is fired and the next offer
+// created gathers candidates with new ICE credentials, as if ICERestart was set in its
+// OfferOptions. The restart stays needed until an answer applies the new credentials,
+// the offers created until then carry the same ones, including after a rollback.
+// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-restartice
+func (pc *PeerConnection) RestartICE() {
+	pc.mu.Lock()
+	pc.isICERestartNeeded.Store(true)
+	pc.iceRestartStarted = false
+	pc.onNegotiationNeeded()
+	pc.mu.Unlock()
+}
+
+// startICERestart records that the ICE agent restarted, with new local credentials
+// for the restart requested by RestartICE if there is one.
+func (pc *PeerConnection) startICERestart() {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.isICERestartNeeded.Load() {
+		pc.iceRestartStarted = true
+	}
+}
+
+// completeICERestart clears the restart requested by RestartICE once an answer applied
+// the new local credentials, it must be called with mu held.
+func (pc *PeerConnection) completeICERestart() {
+	if pc.iceRestartStarted {
+		pc.isICERestartNeeded.Store(false)
+		pc.iceRestartStarted = false
+	}
+}
+
+// updateICERestartTimer arms the automatic ICE restart of SettingEngine.SetICERestartPolicy
+// when the ICE connection is lost, and disarms it when it recovers.
+func (pc *PeerConnection) updateICERestartTimer(cs ICEConnectionState) {
+	after := pc.api.settingEngine.iceRestartPolicy
+	if after <= 0 {
+		return
+	}
+
+	pc.iceRestartTimerLock.Lock()
+	defer pc.iceRestartTimerLock.Unlock()
+
+	switch {
+	case cs == ICEConnectionStateDisconnected || cs == ICEConnectionStateFailed:
+		if pc.iceRestartTimer == nil && !pc.isClosed.Load() {
+			pc.iceRestartTimer = time.AfterFunc(after, pc.onICERestartTimeout)
+		}
+	case pc.iceRestartTimer != nil:
+		pc.iceRestartTimer.Stop()
+		pc.iceRestartTimer = nil
+	}
+}
+
+func (pc *PeerConnection) onICERestartTimeout() {
+	pc.iceRestartTimerLock.Lock()
+	pc.iceRestartTimer = nil
+	pc.iceRestartTimerLock.Unlock()
+
+	state := pc.ICEConnectionState()
+	if pc.isClosed.Load() || (state != ICEConnectionStateDisconnected && state != ICEConnectionStateFailed) {
+		return
+	}
+
+	pc.log.Infof("restarting ICE, the connection stayed %s for %s", state, pc.api.settingEngine.iceRestartPolicy)
+	pc.RestartICE()
+	// Requested again if the restart doesn't happen or doesn't recover the connection
+	pc.updateICERestartTimer(state)
+}
+
+// OnSelectedCandidatePairChange sets an event handler which is called when the
+// ICE transport switches to a new candidate pair, with the previous pair and the
+// reason of the switch.
+func (pc *PeerConnection) OnSelectedCandidatePairChange(f func(SelectedCandidatePairChange)) {
+	pc.onSelectedCandidatePairChangeHandler.Store(f)
+}
+
+func (pc *PeerConnection) onSelectedCandidatePairChange(change SelectedCandidatePairChange) {
+	pc.log.Infof("selected candidate pair changed (%s): %s", change.Reason, change.Pair)
+	pc.events.emit(SelectedCandidatePairChangeEvent{Pair: change.Pair})
+	pc.events.emit(SelectedCandidatePairSwitchEvent{SelectedCandidatePairChange: change})
+	if handler, ok := pc.onSelectedCandidatePairChangeHandler.Load().(func(SelectedCandidatePairChange)); ok && handler != nil {
+		handler(change)
+	}
+}
+
 // OnConnectionStateChange sets an event handler which is called
 // when the PeerConnectionState has changed.
 func (pc *PeerConnection) OnConnectionStateChange(f func(PeerConnectionState)) {
@@ -511,12 +668,63 @@ func (pc *PeerConnection) OnConnectionStateChange(f func(PeerConnectionState)) {
 
 func (pc *PeerConnection) onConnectionStateChange(cs PeerConnectionState) {
 	pc.connectionState.Store(cs)
-	pc.log.Infof("peer connection state changed: %s", cs)
+	if cs == PeerConnectionStateConnected {
+		pc.stopWatchingContexts(true)
+	}
+
+	var err error
+	if cs == PeerConnectionStateFailed {
+		err = pc.connectionFailedErr()
+		pc.log.Infof("peer connection state changed: %s (%s)", cs, err)
+	} else {
+		pc.log.Infof("peer connection state changed: %s", cs)
+	}
+	pc.events.emit(ConnectionStateChangeEvent{State: cs, Err: err})
 	if handler, ok := pc.onConnectionStateChangeHandler.Load().(func(PeerConnectionState)); ok && handler != nil {
-		go handler(cs)
+		pc.api.workerPool.run(func() { handler(cs) })
+	}
+	if err != nil {
+		pc.onConnectionError(err)
 	}
 }
 
+// OnConnectionError sets an event handler which is called with the cause of the
+// failure when the PeerConnectionState becomes failed: the error of the DTLS
+// handshake, an alert of the remote for example, or ErrICEConnectionFailed, which wraps
+// ErrICEConsentExpired when the remote stopped answering the consent checks. It is
+// also called when the SCTP association fails, when it is aborted by the remote for
+// example, which doesn't change the PeerConnectionState.
+func (pc *PeerConnection) OnConnectionError(f func(error)) {
+	pc.onConnectionErrorHandler.Store(f)
+}
+
+func (pc *PeerConnection) onConnectionError(err error) {
+	if handler, ok := pc.onConnectionErrorHandler.Load().(func(error)); ok && handler != nil {
+		pc.api.workerPool.run(func() { handler(err) })
+	}
+}
+
+// connectionFailedErr returns why the PeerConnectionState is failed, the DTLS
+// transport reports its own error while ICE only knows the checks failed.
+func (pc *PeerConnection) connectionFailedErr() error {
+	if err := pc.dtlsTransport.getFailedErr(); err != nil && pc.dtlsTransport.State() == DTLSTransportStateFailed {
+		return fmt.Errorf("%w: %w", errDTLSTransportFailed, err)
+	}
+	if pc.iceTransport.consentExpired.Load() {
+		return fmt.Errorf("%w: %w", ErrICEConnectionFailed, ErrICEConsentExpired)
+	}
+
+	return ErrICEConnectionFailed
+}
+
+func (pc *PeerConnection) onSCTPError(err error) {
+	if pc.isClosed.Load() {
+		return
+	}
+
+	pc.onConnectionError(fmt.Errorf("%w: %w", errSCTPTransportFailed, err))
+}
+
 // SetConfiguration updates the configuration of this PeerConnection object.
 func (pc *PeerConnection) SetConfiguration(configuration Configuration) error { //nolint:gocognit,cyclop
 	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-setconfiguration (step #2)
@@ -604,6 +812,11 @@ func (pc *PeerConnection) ID() string {
 	return pc.id
 }
 
+// ssrcLog returns the logger of the lines about the RTP stream ssrc.
+func (pc *PeerConnection) ssrcLog(ssrc SSRC) logging.LeveledLogger {
+	return newLoggerWithAttrs(pc.api.loggerFactory(), pc.log, "pc", slog.Uint64(logKeySSRC, uint64(ssrc)))
+}
+
 // hasLocalDescriptionChanged returns whether local media (rtpTransceivers) has changed
 // caller of this method should hold `pc.mu` lock.
 func (pc *PeerConnection) hasLocalDescriptionChanged(desc *SessionDescription) bool {
@@ -621,11 +834,81 @@ func (pc *PeerConnection) hasLocalDescriptionChanged(desc *SessionDescription) b
 	return false
 }
 
+// watchContext closes the PeerConnection once ctx is done, unless it has connected
+// before. It returns the error of ctx if it is already done.
+func (pc *PeerConnection) watchContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	pc.contextWatchersLock.Lock()
+	defer pc.contextWatchersLock.Unlock()
+
+	if pc.hasConnected {
+		return nil
+	}
+
+	pc.contextWatchers = append(pc.contextWatchers, context.AfterFunc(ctx, func() {
+		pc.contextWatchersLock.Lock()
+		hasConnected := pc.hasConnected
+		pc.contextWatchersLock.Unlock()
+		if hasConnected || pc.isClosed.Load() {
+			return
+		}
+
+		pc.log.Infof("Closing PeerConnection, its negotiation was cancelled: %v", context.Cause(ctx))
+		if err := pc.Close(); err != nil {
+			pc.log.Warnf("Failed to close PeerConnection: %v", err)
+		}
+	}))
+
+	return nil
+}
+
+// stopWatchingContexts stops the watchers started by watchContext, connected
+// prevents any new one from being started.
+func (pc *PeerConnection) stopWatchingContexts(connected bool) {
+	pc.contextWatchersLock.Lock()
+	defer pc.contextWatchersLock.Unlock()
+
+	pc.hasConnected = pc.hasConnected || connected
+	for _, stop := range pc.contextWatchers {
+		stop()
+	}
+	pc.contextWatchers = nil
+}
+
+// CreateOfferContext is like CreateOffer, and closes the PeerConnection if ctx is
+// done before it has connected. This aborts the candidate gathering, the
+// connectivity checks and the DTLS handshake in progress, so that a negotiation
+// can be given up when the remote peer goes away from the signaling.
+// The error of ctx is returned if it is done before the offer is created.
+//
+// ctx must be the context of the signaling session, not a timeout of the call:
+// the gathering and the handshake go on after the call returned, so ctx is
+// watched until the PeerConnection connects. A ctx done later, a deferred cancel
+// of the caller for example, closes the PeerConnection.
+func (pc *PeerConnection) CreateOfferContext(ctx context.Context, options *OfferOptions) (SessionDescription, error) {
+	if err := pc.watchContext(ctx); err != nil {
+		return SessionDescription{}, err
+	}
+
+	offer, err := pc.CreateOffer(options)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return SessionDescription{}, ctxErr
+	}
+
+	return offer, err
+}
+
 // CreateOffer starts the PeerConnection and generates the localDescription
 // https://w3c.github.io/webrtc-pc/#dom-rtcpeerconnection-createoffer
 //
 //nolint:gocognit,cyclop
 func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription, error) {
+	pc.ops.begin()
+	defer pc.ops.end()
+
 	useIdentity := pc.idpLoginURL != nil
 	switch {
 	case useIdentity:
@@ -634,10 +917,16 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 		return SessionDescription{}, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
-	if options != nil && options.ICERestart {
+	// The restart requested by RestartICE is only started once, the offers created until
+	// an answer applies it carry the same new credentials
+	pc.mu.Lock()
+	restart := (options != nil && options.ICERestart) || (pc.isICERestartNeeded.Load() && !pc.iceRestartStarted)
+	pc.mu.Unlock()
+	if restart {
 		if err := pc.iceTransport.restart(); err != nil {
 			return SessionDescription{}, err
 		}
+		pc.startICERestart()
 	}
 
 	var (
@@ -742,20 +1031,63 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 		}
 	}
 
+	if err = pc.transformSDP(pc.api.settingEngine.localSDPTransform, &offer); err != nil {
+		return SessionDescription{}, err
+	}
 	pc.lastOffer = offer.SDP
 
 	return offer, nil
 }
 
+// transformSDP calls transform, set with SettingEngine.SetLocalSDPTransform or
+// SetRemoteSDPTransform, with the parsed description of desc and updates its SDP.
+func (pc *PeerConnection) transformSDP(
+	transform func(*PeerConnection, SDPType, *sdp.SessionDescription) error,
+	desc *SessionDescription,
+) error {
+	if transform == nil {
+		return nil
+	}
+
+	if err := transform(pc, desc.Type, desc.parsed); err != nil {
+		return err
+	}
+	sdpBytes, err := desc.parsed.Marshal()
+	if err != nil {
+		return err
+	}
+	desc.SDP = string(sdpBytes)
+
+	return nil
+}
+
 func (pc *PeerConnection) createICEGatherer() (*ICEGatherer, error) {
 	g, err := pc.api.NewICEGatherer(ICEGatherOptions{
-		ICEServers:      pc.configuration.getICEServers(),
+		ICEServers:      pc.iceServerProbe.filter(pc.configuration.getICEServers()),
 		ICEGatherPolicy: pc.configuration.ICETransportPolicy,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	g.internalOnLocalCandidateHandler.Store(func(candidate *ICECandidate) {
+		pc.events.emit(ICECandidateEvent{Candidate: candidate})
+	})
+	g.onTURNCredentialsExpiring.Store(func() {
+		pc.log.Info("TURN credentials are about to expire, restarting ICE")
+		pc.RestartICE()
+	})
+	g.internalOnStateChangeHandler.Store(func(state ICEGathererState) {
+		switch state {
+		case ICEGathererStateGathering:
+			pc.events.emit(ICEGatheringStateChangeEvent{State: ICEGatheringStateGathering})
+		case ICEGathererStateComplete:
+			pc.events.emit(ICEGatheringStateChangeEvent{State: ICEGatheringStateComplete})
+		default:
+			// Other states ignored
+		}
+	})
+
 	return g, nil
 }
 
@@ -811,6 +1143,7 @@ func (pc *PeerConnection) updateConnectionState(
 
 func (pc *PeerConnection) createICETransport() *ICETransport {
 	transport := pc.api.NewICETransport(pc.iceGatherer)
+	transport.internalOnSelectedCandidatePairChangeHandler.Store(pc.onSelectedCandidatePairChange)
 	transport.internalOnConnectionStateChangeHandler.Store(func(state ICETransportState) {
 		var cs ICEConnectionState
 		switch state {
@@ -840,10 +1173,28 @@ func (pc *PeerConnection) createICETransport() *ICETransport {
 	return transport
 }
 
+// CreateAnswerContext is like CreateAnswer, and closes the PeerConnection if ctx
+// is done before it has connected, see CreateOfferContext.
+func (pc *PeerConnection) CreateAnswerContext(ctx context.Context, options *AnswerOptions) (SessionDescription, error) {
+	if err := pc.watchContext(ctx); err != nil {
+		return SessionDescription{}, err
+	}
+
+	answer, err := pc.CreateAnswer(options)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return SessionDescription{}, ctxErr
+	}
+
+	return answer, err
+}
+
 // CreateAnswer starts the PeerConnection and generates the localDescription.
 //
 //nolint:cyclop
 func (pc *PeerConnection) CreateAnswer(*AnswerOptions) (SessionDescription, error) {
+	pc.ops.begin()
+	defer pc.ops.end()
+
 	useIdentity := pc.idpLoginURL != nil
 	remoteDesc := pc.RemoteDescription()
 	switch {
@@ -888,6 +1239,9 @@ func (pc *PeerConnection) CreateAnswer(*AnswerOptions) (SessionDescription, erro
 		SDP:    string(sdpBytes),
 		parsed: descr,
 	}
+	if err = pc.transformSDP(pc.api.settingEngine.localSDPTransform, &desc); err != nil {
+		return SessionDescription{}, err
+	}
 	pc.lastAnswer = desc.SDP
 
 	return desc, nil
@@ -942,6 +1296,7 @@ func (pc *PeerConnection) setDescription(sd *SessionDescription, op stateChangeO
 					pc.currentRemoteDescription = pc.pendingRemoteDescription
 					pc.pendingRemoteDescription = nil
 					pc.pendingLocalDescription = nil
+					pc.completeICERestart()
 				}
 			case SDPTypeRollback:
 				nextState, err = checkNextSignalingState(cur, SignalingStateStable, setLocal, sd.Type)
@@ -977,6 +1332,7 @@ func (pc *PeerConnection) setDescription(sd *SessionDescription, op stateChangeO
 					pc.currentLocalDescription = pc.pendingLocalDescription
 					pc.pendingRemoteDescription = nil
 					pc.pendingLocalDescription = nil
+					pc.completeICERestart()
 				}
 			case SDPTypeRollback:
 				nextState, err = checkNextSignalingState(cur, SignalingStateStable, setRemote, sd.Type)
@@ -1013,14 +1369,40 @@ func (pc *PeerConnection) setDescription(sd *SessionDescription, op stateChangeO
 	return err
 }
 
+// SetLocalDescriptionContext is like SetLocalDescription, and closes the
+// PeerConnection if ctx is done before it has connected, see CreateOfferContext.
+func (pc *PeerConnection) SetLocalDescriptionContext(ctx context.Context, desc SessionDescription) error {
+	if err := pc.watchContext(ctx); err != nil {
+		return err
+	}
+	if err := pc.SetLocalDescription(desc); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
 // SetLocalDescription sets the SessionDescription of the local peer
 //
 //nolint:cyclop
 func (pc *PeerConnection) SetLocalDescription(desc SessionDescription) error {
+	pc.ops.begin()
+	defer pc.ops.end()
+
 	if pc.isClosed.Load() {
 		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
+	// A rollback discards the pending local offer, its SDP is ignored
+	if desc.Type == SDPTypeRollback {
+		if err := pc.setDescription(&desc, stateChangeOpSetLocal); err != nil {
+			return err
+		}
+		pc.rollbackTransceivers(nil)
+
+		return nil
+	}
+
 	haveLocalDescription := pc.currentLocalDescription != nil
 
 	// JSEP 5.4
@@ -1045,6 +1427,13 @@ func (pc *PeerConnection) SetLocalDescription(desc SessionDescription) error {
 		return err
 	}
 
+	// The remote offer answered can't be rolled back anymore
+	if desc.Type == SDPTypeAnswer || desc.Type == SDPTypePranswer {
+		pc.mu.Lock()
+		pc.remoteOfferUndo = nil
+		pc.mu.Unlock()
+	}
+
 	currentTransceivers := append([]*RTPTransceiver{}, pc.GetTransceivers()...)
 
 	weAnswer := desc.Type == SDPTypeAnswer
@@ -1072,6 +1461,165 @@ func (pc *PeerConnection) SetLocalDescription(desc SessionDescription) error {
 	return nil
 }
 
+// remoteOfferUndo records the state changed by a remote offer, so it can be rolled back.
+type remoteOfferUndo struct {
+	transceivers map[*RTPTransceiver]transceiverUndo
+
+	// iceCredentialsChanged is set if the offer changed the remote ICE credentials,
+	// iceRestarted if it restarted the ICE agent as well. iceRestartStarted is the
+	// PeerConnection one before the restart.
+	iceCredentialsChanged bool
+	iceRestarted          bool
+	iceRestartStarted     bool
+	localICEParameters    ICEParameters
+	remoteICEParameters   ICEParameters
+}
+
+// transceiverUndo is the state of a transceiver before a remote offer changed it.
+type transceiverUndo struct {
+	// created is set for the transceivers created by the offer.
+	created                bool
+	direction              RTPTransceiverDirection
+	currentRemoteDirection RTPTransceiverDirection
+}
+
+// recordRemoteOfferTransceiver saves the state of a transceiver before the pending remote
+// offer changes it, the first state recorded is kept.
+func (pc *PeerConnection) recordRemoteOfferTransceiver(transceiver *RTPTransceiver, created bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.remoteOfferUndo == nil {
+		return
+	}
+	if _, ok := pc.remoteOfferUndo.transceivers[transceiver]; ok {
+		return
+	}
+	pc.remoteOfferUndo.transceivers[transceiver] = transceiverUndo{
+		created:                created,
+		direction:              transceiver.Direction(),
+		currentRemoteDirection: transceiver.getCurrentRemoteDirection(),
+	}
+}
+
+// recordRemoteOfferICECredentials saves the ICE credentials before the pending remote
+// offer changes them.
+func (pc *PeerConnection) recordRemoteOfferICECredentials(restart bool) error {
+	local, err := pc.iceGatherer.GetLocalParameters()
+	if err != nil {
+		return err
+	}
+	remote, err := pc.iceTransport.getRemoteParameters()
+	if err != nil {
+		return err
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.remoteOfferUndo == nil || pc.remoteOfferUndo.iceCredentialsChanged {
+		return nil
+	}
+	pc.remoteOfferUndo.iceCredentialsChanged = true
+	pc.remoteOfferUndo.iceRestarted = restart
+	pc.remoteOfferUndo.iceRestartStarted = pc.iceRestartStarted
+	pc.remoteOfferUndo.localICEParameters = local
+	pc.remoteOfferUndo.remoteICEParameters = remote
+
+	return nil
+}
+
+// rollbackRemoteOffer undoes the changes of a rolled back remote offer.
+func (pc *PeerConnection) rollbackRemoteOffer() {
+	pc.mu.Lock()
+	undo := pc.remoteOfferUndo
+	pc.remoteOfferUndo = nil
+	pc.mu.Unlock()
+
+	if undo == nil {
+		return
+	}
+	pc.rollbackTransceivers(undo.transceivers)
+
+	if !undo.iceCredentialsChanged {
+		return
+	}
+	if err := pc.iceTransport.restoreCredentials(
+		undo.localICEParameters, undo.remoteICEParameters, undo.iceRestarted,
+	); err != nil {
+		pc.log.Warnf("Failed to restore the ICE credentials of rolled back offer: %s", err)
+
+		return
+	}
+	if undo.iceRestarted {
+		// The local credentials are the ones before the offer again
+		pc.mu.Lock()
+		pc.iceRestartStarted = undo.iceRestartStarted
+		pc.mu.Unlock()
+	}
+
+	// The restart dropped the remote candidates, those of the current description are
+	// added back, the trickled ones are expected again from the remote
+	current := pc.CurrentRemoteDescription()
+	if !undo.iceRestarted || current == nil || current.parsed == nil {
+		return
+	}
+	iceDetails, err := extractICEDetails(current.parsed, pc.log)
+	if err != nil {
+		pc.log.Warnf("Failed to restore the ICE candidates of rolled back offer: %s", err)
+
+		return
+	}
+	for i := range iceDetails.Candidates {
+		if err = pc.iceTransport.AddRemoteCandidate(&iceDetails.Candidates[i]); err != nil {
+			pc.log.Warnf("Failed to restore the ICE candidates of rolled back offer: %s", err)
+		}
+	}
+}
+
+// rollbackTransceivers undoes the changes of a rolled back offer to the transceivers.
+// The mids it assigned are cleared, so the transceivers can be associated with the
+// media sections of the next offer. The states changed by a remote offer are restored,
+// and the transceivers it created are removed unless a track was added since.
+func (pc *PeerConnection) rollbackTransceivers(undo map[*RTPTransceiver]transceiverUndo) {
+	negotiatedMids := map[string]bool{}
+	if current := pc.CurrentLocalDescription(); current != nil && current.parsed != nil {
+		for _, media := range current.parsed.MediaDescriptions {
+			negotiatedMids[getMidValue(media)] = true
+		}
+	}
+
+	pc.mu.Lock()
+	var removed []*RTPTransceiver
+	transceivers := make([]*RTPTransceiver, 0, len(pc.rtpTransceivers))
+	for _, transceiver := range pc.rtpTransceivers {
+		state, changed := undo[transceiver]
+		mid := transceiver.Mid()
+		switch {
+		case mid != "" && negotiatedMids[mid]:
+		case changed && state.created && transceiver.Sender() == nil:
+			removed = append(removed, transceiver)
+
+			continue
+		default:
+			transceiver.mid.Store("")
+		}
+		if changed && !state.created {
+			transceiver.setDirection(state.direction)
+			transceiver.setCurrentRemoteDirection(state.currentRemoteDirection)
+		}
+		transceivers = append(transceivers, transceiver)
+	}
+	pc.rtpTransceivers = transceivers
+	pc.mu.Unlock()
+
+	for _, transceiver := range removed {
+		if err := transceiver.Stop(); err != nil {
+			pc.log.Warnf("Failed to stop transceiver of rolled back offer: %s", err)
+		}
+	}
+}
+
 // LocalDescription returns PendingLocalDescription if it is not null and
 // otherwise it returns CurrentLocalDescription. This property is used to
 // determine if SetLocalDescription has already been called.
@@ -1084,19 +1632,53 @@ func (pc *PeerConnection) LocalDescription() *SessionDescription {
 	return pc.CurrentLocalDescription()
 }
 
+// SetRemoteDescriptionContext is like SetRemoteDescription, and closes the
+// PeerConnection if ctx is done before it has connected, see CreateOfferContext.
+func (pc *PeerConnection) SetRemoteDescriptionContext(ctx context.Context, desc SessionDescription) error {
+	if err := pc.watchContext(ctx); err != nil {
+		return err
+	}
+	if err := pc.SetRemoteDescription(desc); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
 // SetRemoteDescription sets the SessionDescription of the remote peer
 //
 //nolint:gocognit,gocyclo,cyclop,maintidx
 func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
+	pc.ops.begin()
+	defer pc.ops.end()
+
 	if pc.isClosed.Load() {
 		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
+	// A rollback discards the pending remote offer, its SDP is ignored
+	if desc.Type == SDPTypeRollback {
+		if err := pc.setDescription(&desc, stateChangeOpSetRemote); err != nil {
+			return err
+		}
+		pc.rollbackRemoteOffer()
+
+		return nil
+	}
+
 	isRenegotiation := pc.currentRemoteDescription != nil
 
 	if _, err := desc.Unmarshal(); err != nil {
 		return err
 	}
+	if err := pc.transformSDP(pc.api.settingEngine.remoteSDPTransform, &desc); err != nil {
+		return err
+	}
+	if desc.Type == SDPTypeOffer {
+		if err := pc.admitOffer(desc.parsed); err != nil {
+			return err
+		}
+	}
 	if err := pc.setDescription(&desc, stateChangeOpSetRemote); err != nil {
 		return err
 	}
@@ -1119,7 +1701,14 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 
 	weOffer := desc.Type == SDPTypeAnswer
 
+	if !weOffer {
+		pc.mu.Lock()
+		pc.remoteOfferUndo = &remoteOfferUndo{transceivers: map[*RTPTransceiver]transceiverUndo{}}
+		pc.mu.Unlock()
+	}
+
 	if !weOffer && !detectedPlanB { //nolint:nestif
+
 		for _, media := range pc.RemoteDescription().parsed.MediaDescriptions {
 			midValue := getMidValue(media)
 			if midValue == "" {
@@ -1145,12 +1734,13 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 				}
 			}
 			if transceiver != nil {
+				pc.recordRemoteOfferTransceiver(transceiver, false)
 				transceiver.setCurrentRemoteDirection(direction)
 			}
 
 			switch {
 			case transceiver == nil:
-				receiver, err := pc.api.NewRTPReceiver(kind, pc.dtlsTransport)
+				receiver, err := pc.newRTPReceiver(kind)
 				if err != nil {
 					return err
 				}
@@ -1165,6 +1755,7 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 				transceiver = newRTPTransceiver(receiver, nil, localDirection, kind, pc.api)
 				transceiver.setCurrentRemoteDirection(direction)
 				transceiver.setCodecPreferencesFromRemoteDescription(media)
+				pc.recordRemoteOfferTransceiver(transceiver, true)
 				pc.mu.Lock()
 				pc.addRTPTransceiver(transceiver)
 				pc.mu.Unlock()
@@ -1199,18 +1790,28 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 	if err != nil {
 		return err
 	}
+	pc.iceTransport.setRemoteRenomination(hasICEOption(desc.parsed, iceOptionRenomination))
 
 	if isRenegotiation && pc.iceTransport.haveRemoteCredentialsChange(iceDetails.Ufrag, iceDetails.Password) {
-		// An ICE Restart only happens implicitly for a SetRemoteDescription of type offer
+		// An ICE Restart only happens implicitly for a SetRemoteDescription of type offer,
+		// otherwise the connectivity checks continue with the new remote credentials
+		restart := !weOffer && !pc.api.settingEngine.iceDisableRestartOnCredentialsChange
 		if !weOffer {
+			if err = pc.recordRemoteOfferICECredentials(restart); err != nil {
+				return err
+			}
+		}
+		if restart {
 			if err = pc.iceTransport.restart(); err != nil {
 				return err
 			}
+			pc.startICERestart()
 		}
 
 		if err = pc.iceTransport.setRemoteCredentials(iceDetails.Ufrag, iceDetails.Password); err != nil {
 			return err
 		}
+		pc.events.emit(ICERemoteCredentialsChangeEvent{Ufrag: iceDetails.Ufrag, Restarted: restart})
 	}
 
 	for i := range iceDetails.Candidates {
@@ -1238,7 +1839,7 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 
 	remoteIsLite := isIceLiteSet(desc.parsed)
 
-	fingerprint, fingerprintHash, err := extractFingerprint(desc.parsed)
+	fingerprints, err := extractFingerprints(desc.parsed)
 	if err != nil {
 		return err
 	}
@@ -1269,8 +1870,7 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 			dtlsRoleFromRemoteSDP(desc.parsed),
 			iceDetails.Ufrag,
 			iceDetails.Password,
-			fingerprint,
-			fingerprintHash,
+			fingerprints,
 		)
 		if weOffer {
 			pc.startRTP(false, &desc, currentTransceivers)
@@ -1314,13 +1914,13 @@ func (pc *PeerConnection) startReceiver(incoming trackDetails, receiver *RTPRece
 			b := make([]byte, pc.api.settingEngine.getReceiveMTU())
 			n, _, err := track.peek(b)
 			if err != nil {
-				pc.log.Warnf("Could not determine PayloadType for SSRC %d (%s)", track.SSRC(), err)
+				pc.ssrcLog(track.SSRC()).Warnf("Could not determine PayloadType for SSRC %d (%s)", track.SSRC(), err)
 
 				return
 			}
 
 			if err = track.checkAndUpdateTrack(b[:n]); err != nil {
-				pc.log.Warnf("Failed to set codec settings for track SSRC %d (%s)", track.SSRC(), err)
+				pc.ssrcLog(track.SSRC()).Warnf("Failed to set codec settings for track SSRC %d (%s)", track.SSRC(), err)
 
 				return
 			}
@@ -1462,13 +2062,18 @@ func (pc *PeerConnection) configureRTPReceivers(
 				continue
 			}
 
+			if receiver.haveReceived() {
+				for _, track := range tracks {
+					pc.events.emit(TrackRemovedEvent{Track: track, Receiver: receiver})
+				}
+			}
 			if err := receiver.Stop(); err != nil {
 				pc.log.Warnf("Failed to stop RtpReceiver: %s", err)
 
 				continue
 			}
 
-			receiver, err := pc.api.NewRTPReceiver(receiver.kind, pc.dtlsTransport)
+			receiver, err := pc.newRTPReceiver(receiver.kind)
 			if err != nil {
 				pc.log.Warnf("Failed to create new RtpReceiver: %s", err)
 
@@ -1535,7 +2140,7 @@ func (pc *PeerConnection) startRTPReceivers(remoteDesc *SessionDescription, curr
 				Direction: RTPTransceiverDirectionSendrecv,
 			})
 			if err != nil {
-				pc.log.Warnf("Could not add transceiver for remote SSRC %d: %s", incomingTrack.ssrcs[0], err)
+				pc.ssrcLog(incomingTrack.ssrcs[0]).Warnf("Could not add transceiver for remote SSRC %d: %s", incomingTrack.ssrcs[0], err)
 
 				continue
 			}
@@ -1662,7 +2267,7 @@ func (pc *PeerConnection) findMediaSectionByPayloadType(
 // Chrome sends probing traffic on SSRC 0. This reads the packets to ensure that we properly
 // generate TWCC reports for it. Since this isn't actually media we don't pass this to the user.
 func (pc *PeerConnection) handleNonMediaBandwidthProbe() {
-	nonMediaBandwidthProbe, err := pc.api.NewRTPReceiver(RTPCodecTypeVideo, pc.dtlsTransport)
+	nonMediaBandwidthProbe, err := pc.newRTPReceiver(RTPCodecTypeVideo)
 	if err != nil {
 		pc.log.Errorf("handleNonMediaBandwidthProbe failed to create RTPReceiver: %v", err)
 
@@ -1882,7 +2487,7 @@ func (pc *PeerConnection) undeclaredRTPMediaProcessor() { //nolint:cyclop
 		// open accompanying srtcp stream
 		srtcpReadStream, err := srtcpSession.OpenReadStream(ssrc)
 		if err != nil {
-			pc.log.Warnf("Failed to open RTCP stream for %d: %v", ssrc, err)
+			pc.ssrcLog(SSRC(ssrc)).Warnf("Failed to open RTCP stream for %d: %v", ssrc, err)
 
 			return
 		}
@@ -1915,7 +2520,7 @@ func (pc *PeerConnection) undeclaredRTPMediaProcessor() { //nolint:cyclop
 
 		go func(rtpStream io.Reader, ssrc SSRC) {
 			if err := pc.handleIncomingSSRC(rtpStream, ssrc); err != nil {
-				pc.log.Errorf(incomingUnhandledRTPSsrc, ssrc, err)
+				pc.ssrcLog(ssrc).Errorf(incomingUnhandledRTPSsrc, ssrc, err)
 			}
 			atomic.AddUint64(&simulcastRoutineCount, ^uint64(0))
 		}(srtpReadStream, SSRC(ssrc))
@@ -1943,7 +2548,7 @@ func (pc *PeerConnection) undeclaredRTCPMediaProcessor() {
 
 			return
 		}
-		pc.log.Warnf("Incoming unhandled RTCP ssrc(%d), OnTrack will not be fired", ssrc)
+		pc.ssrcLog(SSRC(ssrc)).Warnf("Incoming unhandled RTCP ssrc(%d), OnTrack will not be fired", ssrc)
 		unhandledStreams = append(unhandledStreams, stream)
 	}
 }
@@ -1966,6 +2571,9 @@ func (pc *PeerConnection) RemoteDescription() *SessionDescription {
 // AddICECandidate accepts an ICE candidate string and adds it
 // to the existing set of candidates.
 func (pc *PeerConnection) AddICECandidate(candidate ICECandidateInit) error {
+	pc.ops.begin()
+	defer pc.ops.end()
+
 	remoteDesc := pc.RemoteDescription()
 	if remoteDesc == nil {
 		return &rtcerr.InvalidStateError{Err: ErrNoRemoteDescription}
@@ -2051,6 +2659,93 @@ func (pc *PeerConnection) GetSenders() (result []*RTPSender) {
 	return result
 }
 
+// onTargetBitrateChange splits the estimate of the congestion controller between the active RTPSenders.
+func (pc *PeerConnection) onTargetBitrateChange(bitrate int) {
+	pc.onBandwidthEstimate(bitrate)
+
+	senders := []*RTPSender{}
+	for _, sender := range pc.GetSenders() {
+		if sender.hasSent() && !sender.hasStopped() && sender.Track() != nil {
+			senders = append(senders, sender)
+		}
+	}
+
+	for i, senderBitrate := range allocateTargetBitrate(bitrate, senders) {
+		senders[i].setTargetBitrate(senderBitrate)
+	}
+}
+
+// allocateTargetBitrate gives every audio sender an equal share capped at audioTargetBitrateLimit,
+// the remaining bitrate is split equally between the video senders. Senders without active encodings
+// get nothing and no sender gets more than the MaxBitrate of its encodings, see RTPSender.SetParameters.
+func allocateTargetBitrate(bitrate int, senders []*RTPSender) []int { //nolint:cyclop
+	allocations := make([]int, len(senders))
+	limits := make([]int, len(senders))
+
+	activeSenders, videoSenders := 0, 0
+	for i, sender := range senders {
+		limit, paused := sender.getBitrateLimit()
+		if paused {
+			limits[i] = -1
+
+			continue
+		}
+
+		limits[i] = limit
+		activeSenders++
+		if sender.kind == RTPCodecTypeVideo {
+			videoSenders++
+		}
+	}
+	if activeSenders == 0 {
+		return allocations
+	}
+
+	remaining := bitrate
+	audioShare := bitrate / activeSenders
+	if videoSenders != 0 && audioShare > audioTargetBitrateLimit {
+		audioShare = audioTargetBitrateLimit
+	}
+	for i, sender := range senders {
+		if sender.kind != RTPCodecTypeVideo && limits[i] >= 0 {
+			allocations[i] = audioShare
+			if limits[i] != 0 && limits[i] < audioShare {
+				allocations[i] = limits[i]
+			}
+			remaining -= allocations[i]
+		}
+	}
+
+	// Give the limited video senders their limit when it is below an equal share,
+	// until the share of the others doesn't increase anymore
+	allocated := make([]bool, len(senders))
+	for videoSenders != 0 {
+		share := remaining / videoSenders
+		limited := false
+		for i, sender := range senders {
+			if sender.kind == RTPCodecTypeVideo && !allocated[i] && limits[i] > 0 && limits[i] <= share {
+				allocations[i] = limits[i]
+				allocated[i] = true
+				remaining -= limits[i]
+				videoSenders--
+				limited = true
+			}
+		}
+
+		if !limited {
+			for i, sender := range senders {
+				if sender.kind == RTPCodecTypeVideo && !allocated[i] && limits[i] >= 0 {
+					allocations[i] = share
+				}
+			}
+
+			break
+		}
+	}
+
+	return allocations
+}
+
 // GetReceivers returns the RTPReceivers that are currently attached to this PeerConnection.
 func (pc *PeerConnection) GetReceivers() (receivers []*RTPReceiver) {
 	pc.mu.Lock()
@@ -2073,14 +2768,22 @@ func (pc *PeerConnection) GetTransceivers() []*RTPTransceiver {
 	return pc.rtpTransceivers
 }
 
-// AddTrack adds a Track to the PeerConnection.
+// AddTrack adds a Track to the PeerConnection. The track is associated with its
+// stream ID, unless AddTrackOptions list the IDs of the MediaStreams it belongs to.
 //
 //nolint:cyclop
-func (pc *PeerConnection) AddTrack(track TrackLocal) (*RTPSender, error) {
+func (pc *PeerConnection) AddTrack(track TrackLocal, options ...AddTrackOptions) (*RTPSender, error) {
 	if pc.isClosed.Load() {
 		return nil, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
+	var streamIDs []string
+	if len(options) > 1 {
+		return nil, errPeerConnAddTrackOnlyAcceptsOne
+	} else if len(options) == 1 {
+		streamIDs = options[0].StreamIDs
+	}
+
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
 	for _, transceiver := range pc.rtpTransceivers {
@@ -2088,8 +2791,9 @@ func (pc *PeerConnection) AddTrack(track TrackLocal) (*RTPSender, error) {
 			continue
 		}
 
-		sender, err := pc.api.NewRTPSender(track, pc.dtlsTransport)
+		sender, err := pc.newRTPSender(track)
 		if err == nil {
+			sender.setStreamIDs(streamIDs)
 			err = transceiver.SetSender(sender, track)
 			if err != nil {
 				_ = sender.Stop()
@@ -2104,7 +2808,11 @@ func (pc *PeerConnection) AddTrack(track TrackLocal) (*RTPSender, error) {
 		return sender, nil
 	}
 
-	transceiver, err := pc.newTransceiverFromTrack(RTPTransceiverDirectionSendrecv, track)
+	transceiver, err := pc.newTransceiverFromTrack(
+		RTPTransceiverDirectionSendrecv,
+		track,
+		RTPTransceiverInit{Direction: RTPTransceiverDirectionSendrecv, StreamIDs: streamIDs},
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -2153,13 +2861,13 @@ func (pc *PeerConnection) newTransceiverFromTrack(
 	)
 	switch direction {
 	case RTPTransceiverDirectionSendrecv:
-		receiver, err = pc.api.NewRTPReceiver(track.Kind(), pc.dtlsTransport)
+		receiver, err = pc.newRTPReceiver(track.Kind())
 		if err != nil {
 			return t, err
 		}
-		sender, err = pc.api.NewRTPSender(track, pc.dtlsTransport)
+		sender, err = pc.newRTPSender(track)
 	case RTPTransceiverDirectionSendonly:
-		sender, err = pc.api.NewRTPSender(track, pc.dtlsTransport)
+		sender, err = pc.newRTPSender(track)
 	default:
 		err = errPeerConnAddTransceiverFromTrackSupport
 	}
@@ -2167,10 +2875,13 @@ func (pc *PeerConnection) newTransceiverFromTrack(
 		return t, err
 	}
 
-	// Allow RTPTransceiverInit to override SSRC
-	if sender != nil && len(sender.trackEncodings) == 1 &&
-		len(init) == 1 && len(init[0].SendEncodings) == 1 && init[0].SendEncodings[0].SSRC != 0 {
-		sender.trackEncodings[0].ssrc = init[0].SendEncodings[0].SSRC
+	if sender != nil && len(init) == 1 {
+		if len(init[0].SendEncodings) != 0 {
+			if err = sender.setSendEncodings(init[0].SendEncodings); err != nil {
+				return t, err
+			}
+		}
+		sender.setStreamIDs(init[0].StreamIDs)
 	}
 
 	return newRTPTransceiver(receiver, sender, direction, track.Kind(), pc.api), nil
@@ -2208,7 +2919,7 @@ func (pc *PeerConnection) AddTransceiverFromKind(
 			return nil, err
 		}
 	case RTPTransceiverDirectionRecvonly:
-		receiver, err := pc.api.NewRTPReceiver(kind, pc.dtlsTransport)
+		receiver, err := pc.newRTPReceiver(kind)
 		if err != nil {
 			return nil, err
 		}
@@ -2270,6 +2981,11 @@ func (pc *PeerConnection) CreateDataChannel(label string, options *DataChannelIn
 		params.ID = options.ID
 	}
 
+	// https://w3c.github.io/webrtc-pc/#peer-to-peer-data-api (Step #14)
+	if params.ID != nil && *params.ID >= pc.sctpTransport.MaxChannels() {
+		return nil, &rtcerr.TypeError{Err: ErrMaxDataChannelID}
+	}
+
 	if options != nil { //nolint:nestif
 		// Ordered indicates if data is allowed to be delivered out of order. The
 		// default value of true, guarantees that data will be delivered in order.
@@ -2302,6 +3018,10 @@ func (pc *PeerConnection) CreateDataChannel(label string, options *DataChannelIn
 		if options.Negotiated != nil {
 			params.Negotiated = *options.Negotiated
 		}
+
+		if options.Priority != nil {
+			params.Priority = *options.Priority
+		}
 	}
 
 	dataChannel, err := pc.api.newDataChannel(params, nil, pc.log)
@@ -2350,7 +3070,130 @@ func (pc *PeerConnection) WriteRTCP(pkts []rtcp.Packet) error {
 }
 
 func (pc *PeerConnection) writeRTCP(pkts []rtcp.Packet, _ interceptor.Attributes) (int, error) {
-	return pc.dtlsTransport.WriteRTCP(pkts)
+	return pc.dtlsTransport.WriteRTCP(pc.appendSourceDescription(pkts))
+}
+
+// SetCNAME overrides the RTCP CNAME used by all RTPSenders of this PeerConnection,
+// which defaults to the stream ID of their track. Once set, compound RTCP packets
+// carrying Sender Reports also carry an SDES packet with the CNAME, MID and RID of
+// every reported stream, allowing the remote to correlate SSRCs without the SDP.
+// It should be called before creating an offer or answer, as it changes the
+// cname attributes of the session description.
+func (pc *PeerConnection) SetCNAME(cname string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.cname.Store(cname)
+	for _, transceiver := range pc.rtpTransceivers {
+		if sender := transceiver.Sender(); sender != nil {
+			sender.setCNAME(cname)
+		}
+	}
+}
+
+// SetSourceDescription sets the items of the RTCP SDES packets describing the streams
+// of all RTPSenders. They are sent like the CNAME set with SetCNAME, which the CNAME
+// of description sets if it isn't empty.
+func (pc *PeerConnection) SetSourceDescription(description SourceDescription) {
+	if description.CNAME != "" {
+		pc.SetCNAME(description.CNAME)
+	}
+	pc.sourceDescriptionItems.Store(description.items())
+}
+
+func (pc *PeerConnection) getCNAME() string {
+	cname, _ := pc.cname.Load().(string)
+
+	return cname
+}
+
+// SetPacing changes the bitrate in bits per second and the burst in bytes of the pacer
+// smoothing the outgoing RTP of the PeerConnection, see SettingEngine.SetPacing.
+// It can be called at any time, e.g. to follow the estimated bandwidth.
+// A bitrate of 0 or less disables the pacing.
+func (pc *PeerConnection) SetPacing(bitrate, burst int) {
+	pc.dtlsTransport.pacer.setRate(bitrate, burst)
+}
+
+// SetICETimeouts changes the ICE timeouts of the PeerConnection once it was created,
+// see ICETransport.SetTimeouts.
+func (pc *PeerConnection) SetICETimeouts(disconnectedTimeout, failedTimeout, keepAliveInterval time.Duration) error {
+	return pc.iceTransport.SetTimeouts(disconnectedTimeout, failedTimeout, keepAliveInterval)
+}
+
+// SetAnswerDirectionPolicy overrides the AnswerDirectionPolicy set with
+// SettingEngine.SetAnswerDirectionPolicy for this PeerConnection. It applies to
+// the answers created afterwards.
+func (pc *PeerConnection) SetAnswerDirectionPolicy(policy AnswerDirectionPolicy) {
+	pc.answerDirectionPolicy.Store(policy)
+}
+
+func (pc *PeerConnection) getAnswerDirectionPolicy() AnswerDirectionPolicy {
+	if policy, ok := pc.answerDirectionPolicy.Load().(AnswerDirectionPolicy); ok {
+		return policy
+	}
+
+	return pc.api.settingEngine.answerDirectionPolicy
+}
+
+// newRTPSender creates an RTPSender inheriting the CNAME of the PeerConnection.
+func (pc *PeerConnection) newRTPSender(track TrackLocal) (*RTPSender, error) {
+	sender, err := pc.api.NewRTPSender(track, pc.dtlsTransport)
+	if err != nil {
+		return nil, err
+	}
+	sender.setCNAME(pc.getCNAME())
+	if handler, ok := pc.bandwidthEstimator.(ECNFeedbackHandler); ok {
+		sender.ecnFeedback.setHandler(handler)
+	}
+
+	return sender, nil
+}
+
+// newRTPReceiver creates an RTPReceiver reporting the statistics of the PeerConnection
+// through TrackRemote.Stats.
+func (pc *PeerConnection) newRTPReceiver(kind RTPCodecType) (*RTPReceiver, error) {
+	receiver, err := pc.api.NewRTPReceiver(kind, pc.dtlsTransport)
+	if err != nil {
+		return nil, err
+	}
+	receiver.statsGetter = pc.statsGetter
+
+	return receiver, nil
+}
+
+// appendSourceDescription adds an SDES packet describing the local streams to
+// compound packets carrying Sender Reports, if a CNAME or other items have been set.
+func (pc *PeerConnection) appendSourceDescription(pkts []rtcp.Packet) []rtcp.Packet {
+	items, _ := pc.sourceDescriptionItems.Load().([]rtcp.SourceDescriptionItem)
+	if pc.getCNAME() == "" && len(items) == 0 {
+		return pkts
+	}
+
+	ssrcs := map[uint32]struct{}{}
+	for _, pkt := range pkts {
+		switch pkt := pkt.(type) {
+		case *rtcp.SourceDescription:
+			return pkts
+		case *rtcp.SenderReport:
+			ssrcs[pkt.SSRC] = struct{}{}
+		}
+	}
+	if len(ssrcs) == 0 {
+		return pkts
+	}
+
+	sdes := &rtcp.SourceDescription{}
+	for _, transceiver := range pc.GetTransceivers() {
+		if sender := transceiver.Sender(); sender != nil {
+			sdes.Chunks = append(sdes.Chunks, sender.sourceDescriptionChunks(ssrcs, items)...)
+		}
+	}
+	if len(sdes.Chunks) == 0 {
+		return pkts
+	}
+
+	return append(pkts, sdes)
 }
 
 // Close ends the PeerConnection.
@@ -2358,6 +3201,27 @@ func (pc *PeerConnection) Close() error {
 	return pc.close(false /* shouldGracefullyClose */)
 }
 
+// CloseContext is like Close, and returns the error of ctx if it is done before the
+// PeerConnection is closed, the close then goes on in the background. Unlike the
+// other Context variants ctx is only watched during the call.
+func (pc *PeerConnection) CloseContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- pc.Close()
+	}()
+
+	select {
+	case err := <-closed:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // GracefulClose ends the PeerConnection. It also waits
 // for any goroutines it started to complete. This is only safe to call outside of
 // PeerConnection callbacks or if in a callback, in its own goroutine.
@@ -2365,6 +3229,52 @@ func (pc *PeerConnection) GracefulClose() error {
 	return pc.close(true /* shouldGracefullyClose */)
 }
 
+// GracefulCloseContext ends the PeerConnection like GracefulClose, after delivering
+// what is still queued: the messages sent on the DataChannels are acknowledged by the
+// remote, and an RTCP BYE is sent for the local streams before the DTLS close_notify.
+// If ctx is done first the remaining messages are discarded, and ctx's error is returned.
+// ctx also bounds the wait for the goroutines, the close then goes on in the background.
+func (pc *PeerConnection) GracefulCloseContext(ctx context.Context) error {
+	var closeErrs []error
+	if !pc.isClosed.Load() {
+		// The SCTP association closes the DTLS connection once shut down
+		closeErrs = append(closeErrs, pc.sendGoodbye(), pc.sctpTransport.gracefulStop(ctx))
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- pc.GracefulClose()
+	}()
+
+	select {
+	case err := <-closed:
+		return util.FlattenErrs(append(closeErrs, err))
+	case <-ctx.Done():
+		return util.FlattenErrs(append(closeErrs, ctx.Err()))
+	}
+}
+
+// sendGoodbye sends an RTCP BYE for the streams of the RTPSenders.
+func (pc *PeerConnection) sendGoodbye() error {
+	var sources []uint32
+	for _, sender := range pc.GetSenders() {
+		sources = append(sources, sender.sendingSSRCs()...)
+	}
+
+	// A BYE packet carries up to 31 sources
+	var pkts []rtcp.Packet
+	for len(sources) > 0 {
+		count := min(len(sources), 31)
+		pkts = append(pkts, &rtcp.Goodbye{Sources: sources[:count]})
+		sources = sources[count:]
+	}
+	if len(pkts) == 0 {
+		return nil
+	}
+
+	return pc.WriteRTCP(pkts)
+}
+
 func (pc *PeerConnection) close(shouldGracefullyClose bool) error { //nolint:cyclop
 	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-close (step #1)
 	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-close (step #2)
@@ -2401,6 +3311,8 @@ func (pc *PeerConnection) close(shouldGracefullyClose bool) error { //nolint:cyc
 		// graceful close.
 		<-pc.isCloseDone
 	} else {
+		pc.stopWatchingContexts(false)
+		pc.updateICERestartTimer(ICEConnectionStateClosed)
 		defer close(pc.isCloseDone)
 	}
 
@@ -2483,6 +3395,9 @@ func (pc *PeerConnection) close(shouldGracefullyClose bool) error { //nolint:cyc
 
 	pc.statsGetter = nil
 	cleanupStats(pc.id)
+	cleanupBandwidthEstimator(pc.id)
+	pc.events.close()
+	pc.debugSession.close()
 
 	// Interceptor closes at the end to prevent Bind from being called after interceptor is closed
 	closeErrs = append(closeErrs, pc.api.interceptor.Close())
@@ -2596,8 +3511,14 @@ func (pc *PeerConnection) GetStats() StatsReport {
 	statsCollector.Collecting()
 
 	pc.mu.Lock()
+	var iceConn *ice.Conn
+	if pc.iceTransport != nil {
+		pc.iceTransport.lock.RLock()
+		iceConn = pc.iceTransport.conn
+		pc.iceTransport.lock.RUnlock()
+	}
 	if pc.iceGatherer != nil {
-		pc.iceGatherer.collectStats(statsCollector)
+		pc.iceGatherer.collectStats(statsCollector, iceConn)
 	}
 	if pc.iceTransport != nil {
 		pc.iceTransport.collectStats(statsCollector)
@@ -2644,17 +3565,53 @@ func (pc *PeerConnection) GetStats() StatsReport {
 	for _, receiver := range receivers {
 		receiver.collectStats(statsCollector, pc.statsGetter)
 	}
+	for _, sender := range pc.GetSenders() {
+		sender.collectStats(statsCollector, pc.statsGetter)
+	}
 
 	pc.api.mediaEngine.collectStats(statsCollector)
+	pc.dtlsTransport.pipelineMetrics.collectStats(statsCollector)
+	pc.iceServerProbe.collectStats(statsCollector, pc.GetConfiguration().ICEServers)
 
 	return statsCollector.Ready()
 }
 
+// SelectedCandidatePairStats returns the stats of the candidate pair packets are sent on,
+// with the bitrates averaged over the last second. Unlike GetStats it only queries the
+// ICE agent, so it is cheap enough to be polled. false is returned if no pair is selected.
+func (pc *PeerConnection) SelectedCandidatePairStats() (SelectedCandidatePairStats, bool) {
+	pc.mu.RLock()
+	iceTransport := pc.iceTransport
+	pc.mu.RUnlock()
+
+	if iceTransport == nil {
+		return SelectedCandidatePairStats{}, false
+	}
+
+	stats, ok := iceTransport.GetSelectedCandidatePairStats()
+	if !ok {
+		return SelectedCandidatePairStats{}, false
+	}
+
+	// The ICE agent doesn't count the bytes per pair, all the packets of the
+	// transport are sent and received on the selected pair
+	iceTransport.lock.RLock()
+	conn := iceTransport.conn
+	iceTransport.lock.RUnlock()
+	if conn != nil {
+		stats.BytesSent = conn.BytesSent()
+		stats.BytesReceived = conn.BytesReceived()
+	}
+
+	return pc.selectedPairBitrate.sample(stats, time.Now()), true
+}
+
 // Start all transports. PeerConnection now has enough state.
 func (pc *PeerConnection) startTransports(
 	iceRole ICERole,
 	dtlsRole DTLSRole,
-	remoteUfrag, remotePwd, fingerprint, fingerprintHash string,
+	remoteUfrag, remotePwd string,
+	fingerprints []DTLSFingerprint,
 ) {
 	// Start the ice transport
 	err := pc.iceTransport.Start(
@@ -2688,7 +3645,7 @@ func (pc *PeerConnection) startTransports(
 	// Start the dtls transport
 	err = pc.dtlsTransport.Start(DTLSParameters{
 		Role:         dtlsRole,
-		Fingerprints: []DTLSFingerprint{{Algorithm: fingerprintHash, Value: fingerprint}},
+		Fingerprints: fingerprints,
 	})
 	pc.updateConnectionState(pc.ICEConnectionState(), pc.dtlsTransport.State())
 	if err != nil {
@@ -2783,7 +3740,9 @@ func (pc *PeerConnection) generateUnmatchedSDP(
 		}
 	}
 
-	dtlsFingerprints, err := pc.configuration.Certificates[0].GetFingerprints()
+	dtlsFingerprints, err := pc.configuration.Certificates[0].getFingerprints(
+		pc.api.settingEngine.getDTLSFingerprintAlgorithms(),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -2794,6 +3753,7 @@ func (pc *PeerConnection) generateUnmatchedSDP(
 		dtlsFingerprints,
 		pc.api.settingEngine.sdpMediaLevelFingerprints,
 		pc.api.settingEngine.candidates.ICELite,
+		pc.api.settingEngine.getICEOptions(),
 		true,
 		pc.api.mediaEngine,
 		connectionRoleFromDtlsRole(defaultDtlsRoleOffer),
@@ -2806,6 +3766,20 @@ func (pc *PeerConnection) generateUnmatchedSDP(
 	)
 }
 
+// answerDirection returns the direction of a transceiver being answered, narrowed to the
+// AnswerDirectionPolicy. The direction of the transceiver is left as set by the application.
+func (pc *PeerConnection) answerDirection(transceiver *RTPTransceiver) RTPTransceiverDirection {
+	direction := transceiver.Direction()
+	if constrained := pc.getAnswerDirectionPolicy().constrain(transceiver.kind, direction); constrained != direction {
+		pc.log.Debugf("Answering %s transceiver %s as %s, constrained by the AnswerDirectionPolicy",
+			transceiver.kind, transceiver.Mid(), constrained)
+
+		return constrained
+	}
+
+	return direction
+}
+
 // generateMatchedSDP generates a SDP and takes the remote state into account
 // this is used everytime we have a RemoteDescription
 //
@@ -2894,7 +3868,11 @@ func (pc *PeerConnection) generateMatchedSDP(
 				}
 				mediaTransceivers = append(mediaTransceivers, transceiver)
 			}
-			mediaSections = append(mediaSections, mediaSection{id: midValue, transceivers: mediaTransceivers})
+			section := mediaSection{id: midValue, transceivers: mediaTransceivers}
+			if !includeUnmatched {
+				section.direction = pc.answerDirection(mediaTransceivers[0])
+			}
+			mediaSections = append(mediaSections, section)
 		case sdpSemantics == SDPSemanticsUnifiedPlan || sdpSemantics == SDPSemanticsUnifiedPlanWithFallback:
 			if detectedPlanB {
 				return nil, &rtcerr.TypeError{
@@ -2914,10 +3892,16 @@ func (pc *PeerConnection) generateMatchedSDP(
 			mediaTransceivers := []*RTPTransceiver{transceiver}
 
 			extensions, _ := rtpExtensionsFromMediaDescription(media)
-			mediaSections = append(
-				mediaSections,
-				mediaSection{id: midValue, transceivers: mediaTransceivers, matchExtensions: extensions, rids: getRids(media)},
-			)
+			section := mediaSection{
+				id:              midValue,
+				transceivers:    mediaTransceivers,
+				matchExtensions: extensions,
+				rids:            getRids(media),
+			}
+			if !includeUnmatched {
+				section.direction = pc.answerDirection(transceiver)
+			}
+			mediaSections = append(mediaSections, section)
 		}
 	}
 
@@ -2953,7 +3937,9 @@ func (pc *PeerConnection) generateMatchedSDP(
 		pc.log.Info("Plan-B Offer detected; responding with Plan-B Answer")
 	}
 
-	dtlsFingerprints, err := pc.configuration.Certificates[0].GetFingerprints()
+	dtlsFingerprints, err := pc.configuration.Certificates[0].getFingerprints(
+		pc.api.settingEngine.getDTLSFingerprintAlgorithms(),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -2964,6 +3950,7 @@ func (pc *PeerConnection) generateMatchedSDP(
 		dtlsFingerprints,
 		pc.api.settingEngine.sdpMediaLevelFingerprints,
 		pc.api.settingEngine.candidates.ICELite,
+		pc.api.settingEngine.getICEOptions(),
 		isExtmapAllowMixed,
 		pc.api.mediaEngine,
 		connectionRole,
@@ -7,11 +7,14 @@
 package webrtc
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/pion/rtcp"
 	"github.com/pion/transport/v3/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -257,3 +260,109 @@ func TestPeerConnection_GracefulCloseConcurrent(t *testing.T) {
 		})
 	}
 }
+
+func TestPeerConnection_GracefulCloseContext(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	dcOffer, err := pcOffer.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+	offerDataChannelOpened := make(chan struct{})
+	dcOffer.OnOpen(func() {
+		close(offerDataChannelOpened)
+	})
+
+	var received atomic.Int32
+	answerDataChannelOpened := make(chan struct{})
+	pcAnswer.OnDataChannel(func(d *DataChannel) {
+		if d.Label() != "data" {
+			return
+		}
+		d.OnMessage(func(DataChannelMessage) {
+			received.Add(1)
+		})
+		d.OnOpen(func() {
+			close(answerDataChannelOpened)
+		})
+	})
+
+	trackFired, trackFiredFunc := context.WithCancel(context.Background())
+	goodbyeReceived := make(chan struct{})
+	pcAnswer.OnTrack(func(_ *TrackRemote, receiver *RTPReceiver) {
+		trackFiredFunc()
+		for {
+			pkts, _, readErr := receiver.ReadRTCP()
+			if readErr != nil {
+				return
+			}
+			for _, pkt := range pkts {
+				if _, ok := pkt.(*rtcp.Goodbye); ok {
+					close(goodbyeReceived)
+
+					return
+				}
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, trackFired.Done(), []*TrackLocalStaticSample{track})
+	<-offerDataChannelOpened
+	<-answerDataChannelOpened
+
+	// The messages queued when closing are still delivered
+	const messageCount = 100
+	for i := 0; i < messageCount; i++ {
+		assert.NoError(t, dcOffer.Send(make([]byte, 4096)))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NotZero(t, dcOffer.BufferedAmount())
+	assert.NoError(t, pcOffer.GracefulCloseContext(ctx))
+	assert.Eventually(t, func() bool {
+		return received.Load() == messageCount
+	}, 5*time.Second, 10*time.Millisecond)
+	<-goodbyeReceived
+
+	// Once closed there is nothing left to deliver
+	assert.NoError(t, pcOffer.GracefulCloseContext(ctx))
+	assert.NoError(t, pcAnswer.GracefulClose())
+}
+
+func TestPeerConnection_GracefulCloseContext_Timeout(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	// An operation that doesn't return keeps GracefulClose waiting
+	unblock := make(chan struct{})
+	pc.ops.Enqueue(func() {
+		<-unblock
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pc.GracefulCloseContext(ctx), context.DeadlineExceeded)
+
+	close(unblock)
+	assert.NoError(t, pc.GracefulClose())
+}
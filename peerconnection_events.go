@@ -0,0 +1,307 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PeerConnectionEvent is an event emitted to the subscriptions returned by
+// PeerConnection.Events, use a type switch to handle the different events.
+type PeerConnectionEvent interface {
+	peerConnectionEvent()
+}
+
+// SignalingStateChangeEvent is emitted when the signaling state changes.
+type SignalingStateChangeEvent struct {
+	State SignalingState
+}
+
+// ICEConnectionStateChangeEvent is emitted when the ICE connection state changes.
+type ICEConnectionStateChangeEvent struct {
+	State ICEConnectionState
+}
+
+// ConnectionStateChangeEvent is emitted when the PeerConnectionState changes.
+// Err is the cause of the failure when State is failed, see OnConnectionError.
+type ConnectionStateChangeEvent struct {
+	State PeerConnectionState
+	Err   error
+}
+
+// ICEGatheringStateChangeEvent is emitted when the ICE gathering state changes.
+type ICEGatheringStateChangeEvent struct {
+	State ICEGatheringState
+}
+
+// ICECandidateEvent is emitted when a local ICE candidate is gathered,
+// Candidate is nil once gathering is complete.
+type ICECandidateEvent struct {
+	Candidate *ICECandidate
+}
+
+// SelectedCandidatePairChangeEvent is emitted when a new ICE candidate pair is selected.
+type SelectedCandidatePairChangeEvent struct {
+	Pair *ICECandidatePair
+}
+
+// SelectedCandidatePairSwitchEvent is emitted after SelectedCandidatePairChangeEvent with
+// the previous pair and the reason of the switch, see PeerConnection.OnSelectedCandidatePairChange.
+type SelectedCandidatePairSwitchEvent struct {
+	SelectedCandidatePairChange
+}
+
+// ICERemoteCredentialsChangeEvent is emitted when a remote description changes the ICE
+// credentials of the remote peer. Restarted is true if the change restarted ICE, false
+// if only the credentials used by the connectivity checks were updated, as for answers
+// and with SettingEngine.DisableICERestartOnRemoteCredentialsChange.
+type ICERemoteCredentialsChangeEvent struct {
+	Ufrag     string
+	Restarted bool
+}
+
+// NegotiationNeededEvent is emitted when a change requiring session negotiation has occurred.
+type NegotiationNeededEvent struct{}
+
+// TrackEvent is emitted when a remote track arrives, as OnTrack is fired.
+type TrackEvent struct {
+	Track    *TrackRemote
+	Receiver *RTPReceiver
+}
+
+// TrackRemovedEvent is emitted when a remote track that has been received
+// is removed from the remote description by a renegotiation.
+type TrackRemovedEvent struct {
+	Track    *TrackRemote
+	Receiver *RTPReceiver
+}
+
+// DataChannelEvent is emitted when the remote peer opens a DataChannel.
+type DataChannelEvent struct {
+	DataChannel *DataChannel
+}
+
+// StatsEvent carries a stats snapshot, it is emitted periodically to the
+// subscriptions created with WithEventsStatsInterval.
+type StatsEvent struct {
+	Report StatsReport
+}
+
+// SRTPDecryptionFailureEvent is emitted when many incoming packets of an SSRC fail to decrypt,
+// see DTLSTransport.OnSRTPDecryptionFailure.
+type SRTPDecryptionFailureEvent struct {
+	SRTPDecryptionFailure
+}
+
+// DTLSHandshakeEvent is emitted when the DTLS handshake starts, completes or fails,
+// see DTLSTransport.OnHandshake.
+type DTLSHandshakeEvent struct {
+	DTLSHandshake
+}
+
+// NetworkChangeEvent is emitted when the addresses of the network interfaces change,
+// see SettingEngine.SetNetworkMonitor.
+type NetworkChangeEvent struct {
+	Added, Removed []net.IP
+}
+
+// BandwidthEstimateEvent is emitted when the congestion controller changes its
+// estimate, see PeerConnection.OnBandwidthEstimate.
+type BandwidthEstimateEvent struct {
+	BandwidthEstimate
+}
+
+func (SignalingStateChangeEvent) peerConnectionEvent()        {}
+func (ICEConnectionStateChangeEvent) peerConnectionEvent()    {}
+func (ConnectionStateChangeEvent) peerConnectionEvent()       {}
+func (ICEGatheringStateChangeEvent) peerConnectionEvent()     {}
+func (ICECandidateEvent) peerConnectionEvent()                {}
+func (SelectedCandidatePairChangeEvent) peerConnectionEvent() {}
+func (SelectedCandidatePairSwitchEvent) peerConnectionEvent() {}
+func (ICERemoteCredentialsChangeEvent) peerConnectionEvent()  {}
+func (NegotiationNeededEvent) peerConnectionEvent()           {}
+func (TrackEvent) peerConnectionEvent()                       {}
+func (TrackRemovedEvent) peerConnectionEvent()                {}
+func (DataChannelEvent) peerConnectionEvent()                 {}
+func (StatsEvent) peerConnectionEvent()                       {}
+func (SRTPDecryptionFailureEvent) peerConnectionEvent()       {}
+func (DTLSHandshakeEvent) peerConnectionEvent()               {}
+func (NetworkChangeEvent) peerConnectionEvent()               {}
+func (BandwidthEstimateEvent) peerConnectionEvent()           {}
+
+// EventSubscription receives the events of a PeerConnection, see PeerConnection.Events.
+type EventSubscription struct {
+	pc            *PeerConnection
+	events        chan PeerConnectionEvent
+	bufferSize    int
+	statsInterval time.Duration
+	dropped       atomic.Uint64
+	done          chan struct{}
+	closeOnce     sync.Once
+}
+
+// EventsOption configures an EventSubscription.
+type EventsOption func(*EventSubscription)
+
+// WithEventsBufferSize sets how many events the subscription buffers before dropping them.
+func WithEventsBufferSize(size int) EventsOption {
+	return func(s *EventSubscription) {
+		s.bufferSize = size
+	}
+}
+
+// WithEventsStatsInterval makes the subscription receive a StatsEvent every interval.
+func WithEventsStatsInterval(interval time.Duration) EventsOption {
+	return func(s *EventSubscription) {
+		s.statsInterval = interval
+	}
+}
+
+// Events subscribes to the events of the PeerConnection. All events are delivered on
+// a single channel in the order they are emitted, the handlers set with the On methods
+// are still fired. Events are never blocking the PeerConnection, they are dropped
+// if the buffer of the subscription is full, see EventSubscription.Dropped.
+// The channel is closed by EventSubscription.Close or once the PeerConnection is closed.
+func (pc *PeerConnection) Events(opts ...EventsOption) *EventSubscription {
+	subscription := &EventSubscription{
+		pc:         pc,
+		bufferSize: defaultEventsBufferSize,
+		done:       make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(subscription)
+	}
+	subscription.events = make(chan PeerConnectionEvent, subscription.bufferSize)
+
+	if !pc.events.subscribe(subscription) {
+		subscription.close()
+
+		return subscription
+	}
+
+	if subscription.statsInterval > 0 {
+		go subscription.emitStats()
+	}
+
+	return subscription
+}
+
+// C returns the channel the events are delivered on.
+func (s *EventSubscription) C() <-chan PeerConnectionEvent {
+	return s.events
+}
+
+// Dropped returns the number of events dropped because the buffer of the subscription was full.
+func (s *EventSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops the subscription and closes its channel.
+func (s *EventSubscription) Close() {
+	s.pc.events.unsubscribe(s)
+}
+
+// close must be called once the subscription has been removed from the bus.
+func (s *EventSubscription) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		close(s.events)
+	})
+}
+
+// emit must be called with the lock of the bus held.
+func (s *EventSubscription) emit(event PeerConnectionEvent) {
+	select {
+	case s.events <- event:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+func (s *EventSubscription) emitStats() {
+	ticker := time.NewTicker(s.statsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			report := s.pc.GetStats()
+			s.pc.events.emitTo(s, StatsEvent{Report: report})
+		}
+	}
+}
+
+// eventBus dispatches the events of a PeerConnection to its subscriptions.
+type eventBus struct {
+	mu            sync.RWMutex
+	subscriptions map[*EventSubscription]struct{}
+	closed        bool
+
+	// observer is called with every event emitted, it is set before any is.
+	observer func(PeerConnectionEvent)
+}
+
+func (b *eventBus) subscribe(s *EventSubscription) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return false
+	}
+	if b.subscriptions == nil {
+		b.subscriptions = map[*EventSubscription]struct{}{}
+	}
+	b.subscriptions[s] = struct{}{}
+
+	return true
+}
+
+func (b *eventBus) unsubscribe(s *EventSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.subscriptions, s)
+	s.close()
+}
+
+func (b *eventBus) emit(event PeerConnectionEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.observer != nil && !b.closed {
+		b.observer(event)
+	}
+	for s := range b.subscriptions {
+		s.emit(event)
+	}
+}
+
+func (b *eventBus) emitTo(s *EventSubscription, event PeerConnectionEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if _, ok := b.subscriptions[s]; ok {
+		s.emit(event)
+	}
+}
+
+// close closes all the subscriptions, no events are emitted afterwards.
+func (b *eventBus) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for s := range b.subscriptions {
+		s.close()
+	}
+	b.subscriptions = nil
+}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerConnection_Events(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	offerEvents := pcOffer.Events(WithEventsBufferSize(1024), WithEventsStatsInterval(20*time.Millisecond))
+	answerEvents := pcAnswer.Events(WithEventsBufferSize(1024))
+	droppingEvents := pcOffer.Events(WithEventsBufferSize(0))
+
+	_, err = pcOffer.CreateDataChannel("events", nil)
+	require.NoError(t, err)
+
+	// negotiationneeded is not fired once the negotiation started
+	for event := range offerEvents.C() {
+		if _, ok := event.(NegotiationNeededEvent); ok {
+			break
+		}
+	}
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	seen := map[string]bool{}
+	for !(seen["connected"] && seen["candidate"] && seen["gathered"] && seen["pair"] && seen["stats"] &&
+		seen["signaling"] && seen["switch"]) {
+		switch event := (<-offerEvents.C()).(type) {
+		case ConnectionStateChangeEvent:
+			seen["connected"] = seen["connected"] || event.State == PeerConnectionStateConnected
+		case ICECandidateEvent:
+			seen["candidate"] = seen["candidate"] || event.Candidate != nil
+		case ICEGatheringStateChangeEvent:
+			seen["gathered"] = seen["gathered"] || event.State == ICEGatheringStateComplete
+		case SelectedCandidatePairChangeEvent:
+			seen["pair"] = event.Pair != nil
+		case SelectedCandidatePairSwitchEvent:
+			seen["switch"] = seen["switch"] || event.Reason == SelectedCandidatePairChangeReasonInitial
+		case StatsEvent:
+			_, seen["stats"] = event.Report.GetConnectionStats(pcOffer)
+		case SignalingStateChangeEvent:
+			seen["signaling"] = seen["signaling"] || event.State == SignalingStateStable
+		}
+	}
+
+	for event := range answerEvents.C() {
+		if dataChannel, ok := event.(DataChannelEvent); ok {
+			assert.Equal(t, "events", dataChannel.DataChannel.Label())
+
+			break
+		}
+	}
+	// buffered events are still delivered before the channel is closed
+	answerEvents.Close()
+	for range answerEvents.C() { //nolint:revive
+	}
+
+	assert.NotZero(t, droppingEvents.Dropped())
+
+	closePairNow(t, pcOffer, pcAnswer)
+
+	// the subscriptions are closed with the PeerConnection
+	for range offerEvents.C() { //nolint:revive
+	}
+	_, ok := <-droppingEvents.C()
+	assert.False(t, ok)
+
+	closedEvents := pcOffer.Events()
+	_, ok = <-closedEvents.C()
+	assert.False(t, ok)
+}
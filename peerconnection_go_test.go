@@ -872,6 +872,76 @@ func TestICERestart(t *testing.T) {
 	closePairNow(t, offerPC, answerPC)
 }
 
+func TestICERestart_DisabledOnRemoteCredentialsChange(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settingEngine := SettingEngine{}
+	settingEngine.DisableICERestartOnRemoteCredentialsChange(true)
+
+	offerPC, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	answerPC, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+
+	firstAnswer := answerPC.LocalDescription().SDP
+	events := answerPC.Events()
+	defer events.Close()
+
+	reconnected := make(chan struct{})
+	var reconnectedOnce sync.Once
+	offerPC.OnICEConnectionStateChange(func(state ICEConnectionState) {
+		if state == ICEConnectionStateConnected {
+			reconnectedOnce.Do(func() { close(reconnected) })
+		}
+	})
+
+	// The offerer rotates its credentials, the answerer only updates the remote ones
+	offer, err := offerPC.CreateOffer(&OfferOptions{ICERestart: true})
+	assert.NoError(t, err)
+	assert.NoError(t, offerPC.SetLocalDescription(offer))
+	assert.NoError(t, answerPC.SetRemoteDescription(*offerPC.LocalDescription()))
+
+	answer, err := answerPC.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, answerPC.SetLocalDescription(answer))
+	assert.NoError(t, offerPC.SetRemoteDescription(*answerPC.LocalDescription()))
+
+	for event := range events.C() {
+		if change, ok := event.(ICERemoteCredentialsChangeEvent); ok {
+			assert.False(t, change.Restarted)
+			assert.NotEmpty(t, change.Ufrag)
+
+			break
+		}
+	}
+
+	// The answerer kept its credentials
+	ufrag := func(sdp string) string {
+		for _, line := range strings.Split(sdp, "\r\n") {
+			if strings.HasPrefix(line, "a=ice-ufrag:") {
+				return line
+			}
+		}
+
+		return ""
+	}
+	assert.NotEmpty(t, ufrag(firstAnswer))
+	assert.Equal(t, ufrag(firstAnswer), ufrag(answerPC.LocalDescription().SDP))
+
+	<-reconnected
+	assert.Equal(t, PeerConnectionStateConnected, answerPC.ConnectionState())
+
+	closePairNow(t, offerPC, answerPC)
+}
+
 // Assert error handling when an Agent is restart.
 func TestICERestart_Error_Handling(t *testing.T) {
 	iceStates := make(chan ICEConnectionState, 100)
@@ -976,6 +1046,117 @@ func TestICERestart_Error_Handling(t *testing.T) {
 	closePairNow(t, offerPeerConnection, answerPeerConnection)
 }
 
+// Assert the PeerConnection restarts ICE on its own once the connection is lost.
+func TestICERestart_Policy(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPeerConnection, answerPeerConnection, wan := createVNetPair(t, nil)
+	offerPeerConnection.api.settingEngine.SetICERestartPolicy(500 * time.Millisecond)
+
+	keepPackets := &atomic.Bool{}
+	keepPackets.Store(true)
+	wan.AddChunkFilter(func(vnet.Chunk) bool {
+		return keepPackets.Load()
+	})
+
+	connected := make(chan struct{}, 2)
+	offerPeerConnection.OnICEConnectionStateChange(func(state ICEConnectionState) {
+		if state == ICEConnectionStateConnected {
+			connected <- struct{}{}
+		}
+	})
+
+	dataChannel, err := offerPeerConnection.CreateDataChannel("foo", nil)
+	assert.NoError(t, err)
+	opened := make(chan struct{})
+	dataChannel.OnOpen(func() {
+		close(opened)
+	})
+	assert.NoError(t, signalPair(offerPeerConnection, answerPeerConnection))
+	<-connected
+	<-opened
+	before, err := offerPeerConnection.iceGatherer.GetLocalParameters()
+	assert.NoError(t, err)
+
+	// Once the connection is lost, the offerer asks for the restart it negotiates
+	negotiationNeeded := make(chan struct{}, 1)
+	offerPeerConnection.OnNegotiationNeeded(func() {
+		negotiationNeeded <- struct{}{}
+	})
+	keepPackets.Store(false)
+	<-negotiationNeeded
+
+	keepPackets.Store(true)
+	assert.NoError(t, signalPair(offerPeerConnection, answerPeerConnection))
+	<-connected
+	after, err := offerPeerConnection.iceGatherer.GetLocalParameters()
+	assert.NoError(t, err)
+	assert.NotEqual(t, before.UsernameFragment, after.UsernameFragment)
+
+	assert.NoError(t, wan.Stop())
+	closePairNow(t, offerPeerConnection, answerPeerConnection)
+}
+
+// Assert the restart of RestartICE is needed until an answer applies it.
+func TestPeerConnection_RestartICE(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	ufrag := func(desc SessionDescription) string {
+		parsed, parseErr := desc.Unmarshal()
+		assert.NoError(t, parseErr)
+		details, parseErr := extractICEDetails(parsed, pcOffer.log)
+		assert.NoError(t, parseErr)
+
+		return details.Ufrag
+	}
+	before := ufrag(*pcOffer.CurrentLocalDescription())
+
+	pcOffer.RestartICE()
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	restarted := ufrag(offer)
+	assert.NotEqual(t, before, restarted)
+
+	// The restart is still needed once its offer is rolled back, the next offer carries
+	// the same credentials
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.NoError(t, pcOffer.SetLocalDescription(SessionDescription{Type: SDPTypeRollback}))
+	assert.True(t, pcOffer.isICERestartNeeded.Load())
+	offer, err = pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, restarted, ufrag(offer))
+
+	// The answer applies it
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.True(t, pcOffer.isICERestartNeeded.Load())
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+	answer, err := pcAnswer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+	assert.NoError(t, pcOffer.SetRemoteDescription(answer))
+	assert.False(t, pcOffer.isICERestartNeeded.Load())
+
+	offer, err = pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, restarted, ufrag(offer))
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
 type trackRecords struct {
 	mu               sync.Mutex
 	trackIDs         map[string]struct{}
@@ -1496,7 +1677,7 @@ a=sendonly
 a=rtpmap:125 H264/90000
 a=fmtp:125 level-asymmetry-allowed=1;packetization-mode=0;profile-level-id=42e01f
 a=rtpmap:49 H265/90000
-a=fmtp:49 level-id=93;profile-id=1;tier-flag=0;tx-mode=SRST
+a=fmtp:49 level-id=93;profile-id=2;tier-flag=0;tx-mode=SRST
 `
 		mediaEngine := MediaEngine{}
 		assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
@@ -1696,6 +1877,11 @@ func TestPeerConnectionState(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, PeerConnectionStateNew, pc.ConnectionState())
 
+	connectionErrs := make(chan error, 1)
+	pc.OnConnectionError(func(err error) {
+		connectionErrs <- err
+	})
+
 	pc.updateConnectionState(ICEConnectionStateChecking, DTLSTransportStateNew)
 	assert.Equal(t, PeerConnectionStateConnecting, pc.ConnectionState())
 
@@ -1719,6 +1905,7 @@ func TestPeerConnectionState(t *testing.T) {
 
 	pc.updateConnectionState(ICEConnectionStateFailed, DTLSTransportStateConnected)
 	assert.Equal(t, PeerConnectionStateFailed, pc.ConnectionState())
+	assert.ErrorIs(t, <-connectionErrs, ErrICEConnectionFailed)
 
 	pc.updateConnectionState(ICEConnectionStateConnected, DTLSTransportStateFailed)
 	assert.Equal(t, PeerConnectionStateFailed, pc.ConnectionState())
@@ -1989,6 +2176,149 @@ func Test_WriteRTCP_Disconnected(t *testing.T) {
 	assert.NoError(t, peerConnection.Close())
 }
 
+func TestPeerConnection_SetCNAME(t *testing.T) {
+	peerConnection, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	audioTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeOpus}, "audio", "pion")
+	assert.NoError(t, err)
+
+	audioSender, err := peerConnection.AddTrack(audioTrack)
+	assert.NoError(t, err)
+
+	peerConnection.SetCNAME("monitoring-cname")
+
+	videoTrackHigh, err := NewTrackLocalStaticRTP(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithRTPStreamID("h"),
+	)
+	assert.NoError(t, err)
+	videoTrackLow, err := NewTrackLocalStaticRTP(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithRTPStreamID("l"),
+	)
+	assert.NoError(t, err)
+
+	videoSender, err := peerConnection.AddTrack(videoTrackHigh)
+	assert.NoError(t, err)
+	assert.NoError(t, videoSender.AddEncoding(videoTrackLow))
+
+	offer, err := peerConnection.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, offer.SDP, "cname:pion")
+	for _, sender := range []*RTPSender{audioSender, videoSender} {
+		for _, encoding := range sender.GetParameters().Encodings {
+			assert.Contains(t, offer.SDP, fmt.Sprintf("a=ssrc:%d cname:monitoring-cname", encoding.SSRC))
+		}
+	}
+	assert.NoError(t, peerConnection.SetLocalDescription(offer))
+
+	t.Run("No Sender Report", func(t *testing.T) {
+		pkts := []rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 1}}
+		assert.Equal(t, pkts, peerConnection.appendSourceDescription(pkts))
+	})
+
+	t.Run("Unknown SSRC", func(t *testing.T) {
+		pkts := []rtcp.Packet{&rtcp.SenderReport{SSRC: 1}}
+		assert.Equal(t, pkts, peerConnection.appendSourceDescription(pkts))
+	})
+
+	t.Run("Simulcast", func(t *testing.T) {
+		encodings := videoSender.GetParameters().Encodings
+		assert.Len(t, encodings, 2)
+
+		pkts := peerConnection.appendSourceDescription([]rtcp.Packet{
+			&rtcp.SenderReport{SSRC: uint32(encodings[1].SSRC)},
+			&rtcp.SenderReport{SSRC: uint32(audioSender.GetParameters().Encodings[0].SSRC)},
+		})
+		assert.Len(t, pkts, 3)
+
+		sdes, ok := pkts[2].(*rtcp.SourceDescription)
+		assert.True(t, ok)
+
+		raw, err := rtcp.Marshal(pkts)
+		assert.NoError(t, err)
+		_, err = rtcp.Unmarshal(raw)
+		assert.NoError(t, err)
+
+		audioMid := audioSender.rtpTransceiver.Mid()
+		videoMid := videoSender.rtpTransceiver.Mid()
+		assert.ElementsMatch(t, []rtcp.SourceDescriptionChunk{
+			{
+				Source: uint32(audioSender.GetParameters().Encodings[0].SSRC),
+				Items: []rtcp.SourceDescriptionItem{
+					{Type: rtcp.SDESCNAME, Text: "monitoring-cname"},
+					{Type: sdesMid, Text: audioMid},
+				},
+			},
+			{
+				Source: uint32(encodings[1].SSRC),
+				Items: []rtcp.SourceDescriptionItem{
+					{Type: rtcp.SDESCNAME, Text: "monitoring-cname"},
+					{Type: sdesMid, Text: videoMid},
+					{Type: sdesRtpStreamID, Text: "l"},
+				},
+			},
+			{
+				Source: uint32(encodings[1].RTX.SSRC),
+				Items: []rtcp.SourceDescriptionItem{
+					{Type: rtcp.SDESCNAME, Text: "monitoring-cname"},
+					{Type: sdesMid, Text: videoMid},
+					{Type: sdesRepairedRtpStreamID, Text: "l"},
+				},
+			},
+		}, sdes.Chunks)
+
+		// Existing SDES packets are left untouched
+		pkts = []rtcp.Packet{&rtcp.SenderReport{SSRC: uint32(encodings[0].SSRC)}, &rtcp.SourceDescription{}}
+		assert.Equal(t, pkts, peerConnection.appendSourceDescription(pkts))
+	})
+
+	assert.NoError(t, peerConnection.Close())
+}
+
+func TestPeerConnection_SetSourceDescription(t *testing.T) {
+	peerConnection, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeOpus}, "audio", "pion")
+	assert.NoError(t, err)
+	sender, err := peerConnection.AddTrack(track)
+	assert.NoError(t, err)
+
+	offer, err := peerConnection.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, peerConnection.SetLocalDescription(offer))
+
+	ssrc := uint32(sender.GetParameters().Encodings[0].SSRC)
+	pkts := []rtcp.Packet{&rtcp.SenderReport{SSRC: ssrc}}
+	assert.Equal(t, pkts, peerConnection.appendSourceDescription(pkts))
+
+	// The CNAME defaults to the stream ID
+	peerConnection.SetSourceDescription(SourceDescription{Name: "alice", Tool: "pion"})
+	assert.Empty(t, peerConnection.getCNAME())
+	pkts = peerConnection.appendSourceDescription(pkts)
+	assert.Len(t, pkts, 2)
+	assert.Equal(t, &rtcp.SourceDescription{Chunks: []rtcp.SourceDescriptionChunk{{
+		Source: ssrc,
+		Items: []rtcp.SourceDescriptionItem{
+			{Type: rtcp.SDESCNAME, Text: "pion"},
+			{Type: sdesMid, Text: sender.rtpTransceiver.Mid()},
+			{Type: rtcp.SDESName, Text: "alice"},
+			{Type: rtcp.SDESTool, Text: "pion"},
+		},
+	}}}, pkts[1])
+
+	peerConnection.SetSourceDescription(SourceDescription{CNAME: "monitoring-cname"})
+	assert.Equal(t, "monitoring-cname", peerConnection.getCNAME())
+	pkts = peerConnection.appendSourceDescription([]rtcp.Packet{&rtcp.SenderReport{SSRC: ssrc}})
+	assert.Len(t, pkts, 2)
+	assert.Equal(t, []rtcp.SourceDescriptionItem{
+		{Type: rtcp.SDESCNAME, Text: "monitoring-cname"},
+		{Type: sdesMid, Text: sender.rtpTransceiver.Mid()},
+	}, pkts[1].(*rtcp.SourceDescription).Chunks[0].Items) //nolint:forcetypeassert
+
+	assert.NoError(t, peerConnection.Close())
+}
+
 func Test_IPv6(t *testing.T) { //nolint: cyclop
 	interfaces, err := net.Interfaces()
 	if err != nil {
@@ -2151,3 +2481,85 @@ func TestAddICECandidate__DroppingOldGenerationCandidates(t *testing.T) {
 
 	closePairNow(t, pc, remotePC)
 }
+
+func TestPeerConnection_NegotiationContext(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	t.Run("Done", func(t *testing.T) {
+		pc, err := NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = pc.CreateOfferContext(ctx, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, pc.SetRemoteDescriptionContext(ctx, SessionDescription{}), context.Canceled)
+		assert.Equal(t, PeerConnectionStateNew, pc.ConnectionState())
+
+		assert.ErrorIs(t, pc.CloseContext(ctx), context.Canceled)
+		assert.NoError(t, pc.CloseContext(context.Background()))
+		assert.Equal(t, PeerConnectionStateClosed, pc.ConnectionState())
+	})
+
+	t.Run("CancelledBeforeConnected", func(t *testing.T) {
+		pcOffer, pcAnswer, err := newPair()
+		assert.NoError(t, err)
+
+		_, err = pcOffer.CreateDataChannel("data", nil)
+		assert.NoError(t, err)
+		offer, err := pcOffer.CreateOffer(nil)
+		assert.NoError(t, err)
+		assert.NoError(t, pcOffer.SetLocalDescription(offer))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		closed := untilConnectionState(PeerConnectionStateClosed, pcAnswer)
+		assert.NoError(t, pcAnswer.SetRemoteDescriptionContext(ctx, offer))
+		answer, err := pcAnswer.CreateAnswerContext(ctx, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, pcAnswer.SetLocalDescriptionContext(ctx, answer))
+
+		// The answer never reaches the offerer, the negotiation is given up
+		cancel()
+		closed.Wait()
+		assert.Equal(t, PeerConnectionStateClosed, pcAnswer.ConnectionState())
+
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+
+	t.Run("CancelledAfterConnected", func(t *testing.T) {
+		pcOffer, pcAnswer, err := newPair()
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		_, err = pcOffer.CreateDataChannel("data", nil)
+		assert.NoError(t, err)
+		offer, err := pcOffer.CreateOfferContext(ctx, nil)
+		assert.NoError(t, err)
+		offerGatheringComplete := GatheringCompletePromise(pcOffer)
+		assert.NoError(t, pcOffer.SetLocalDescriptionContext(ctx, offer))
+		<-offerGatheringComplete
+
+		connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+		assert.NoError(t, pcAnswer.SetRemoteDescription(*pcOffer.LocalDescription()))
+		answer, err := pcAnswer.CreateAnswer(nil)
+		assert.NoError(t, err)
+		answerGatheringComplete := GatheringCompletePromise(pcAnswer)
+		assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+		<-answerGatheringComplete
+		assert.NoError(t, pcOffer.SetRemoteDescriptionContext(ctx, *pcAnswer.LocalDescription()))
+		connected.Wait()
+
+		cancel()
+		assert.Empty(t, pcOffer.contextWatchers)
+		assert.Equal(t, PeerConnectionStateConnected, pcOffer.ConnectionState())
+
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+}
@@ -24,12 +24,14 @@ import (
 	"github.com/pion/logging"
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
 	"github.com/pion/sdp/v3"
 	"github.com/pion/transport/v3/test"
 	"github.com/pion/transport/v3/vnet"
 	"github.com/pion/webrtc/v4/internal/fmtp"
 	"github.com/pion/webrtc/v4/internal/util"
 	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/pion/webrtc/v4/pkg/media/samplebuilder"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -707,6 +709,46 @@ func TestAddTransceiverFromTrackSendRecv(t *testing.T) {
 	assert.NoError(t, pc.Close())
 }
 
+func TestPeerConnection_AddTrack_StreamIDs(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+
+	_, err = pcOffer.AddTrack(track, AddTrackOptions{}, AddTrackOptions{})
+	assert.ErrorIs(t, err, errPeerConnAddTrackOnlyAcceptsOne)
+
+	sender, err := pcOffer.AddTrack(track, AddTrackOptions{StreamIDs: []string{"room", "speaker"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"room", "speaker"}, sender.StreamIDs())
+
+	onTrackFired, onTrackFiredFunc := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(remote *TrackRemote, _ *RTPReceiver) {
+		assert.Equal(t, "room", remote.StreamID())
+		assert.Equal(t, "video", remote.ID())
+		onTrackFiredFunc()
+	})
+
+	offer, err := pcOffer.CreateOffer(nil)
+	require.NoError(t, err)
+	assert.Contains(t, offer.SDP, "a=msid:room video\r\na=msid:speaker video\r\n")
+	assert.Regexp(t, "a=ssrc:[0-9]+ msid:room video", offer.SDP)
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, onTrackFired.Done(), []*TrackLocalStaticSample{track})
+
+	assert.False(t, pcOffer.checkNegotiationNeeded())
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
 func TestAddTransceiverAddTrack_Reuse(t *testing.T) {
 	t.Run("reuse test", func(t *testing.T) {
 		pc, err := NewPeerConnection(Configuration{})
@@ -2278,8 +2320,11 @@ func Test_PeerConnection_RTX_E2E(t *testing.T) { //nolint:cyclop
 			if rtxPayloadType != nil && rtxSequenceNumber != nil && rtxSSRC != nil {
 				assert.Equal(t, rtxPayloadType, uint8(97))
 				assert.Equal(t, rtxSSRC, uint32(rtxSsrc))
+				assert.Equal(t, true, attributes.Get(AttributeRepaired))
 
 				rtxReadCancel()
+			} else {
+				assert.Nil(t, attributes.Get(AttributeRepaired))
 			}
 		}
 	})
@@ -2301,3 +2346,62 @@ func Test_PeerConnection_RTX_E2E(t *testing.T) { //nolint:cyclop
 	assert.NoError(t, wan.Stop())
 	closePairNow(t, pcOffer, pcAnswer)
 }
+
+func TestPeerConnection_Media_H265(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeH265}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	// A VPS, SPS and PPS aggregated in a packet, and an IDR slice fragmented over several
+	nalus := [][]byte{
+		{0x40, 0x01, 0x0c}, {0x42, 0x01, 0x01}, {0x44, 0x01, 0xc0},
+		append([]byte{0x26, 0x01}, bytes.Repeat([]byte{0xaf}, 3000)...),
+	}
+	var sample []byte
+	for _, nalu := range nalus {
+		sample = append(append(sample, 0x00, 0x00, 0x00, 0x01), nalu...)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		assert.Equal(t, "level-id=93;profile-id=1;tier-flag=0;tx-mode=SRST", track.Codec().SDPFmtpLine)
+
+		builder := samplebuilder.New(32, &codecs.H265Packet{}, track.Codec().ClockRate)
+		for {
+			packet, _, readErr := track.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			builder.Push(packet)
+			if received := builder.Pop(); received != nil && bytes.Equal(received.Data, sample) {
+				cancel()
+
+				return
+			}
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	for {
+		select {
+		case <-time.After(20 * time.Millisecond):
+			assert.NoError(t, track.WriteSample(media.Sample{Data: sample, Duration: 20 * time.Millisecond}))
+		case <-ctx.Done():
+			closePairNow(t, pcOffer, pcAnswer)
+
+			return
+		}
+	}
+}
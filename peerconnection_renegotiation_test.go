@@ -999,6 +999,49 @@ func TestNegotiationNeededStressOneSided(t *testing.T) {
 	closePairNow(t, pcA, pcB)
 }
 
+// Assert that the changes made while an operation is running fire OnNegotiationNeeded
+// once, after the operation.
+func TestNegotiationNeededCoalescedDuringOperation(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	var negotiationNeededCount atomic.Int32
+	negotiated := make(chan struct{}, 10)
+	pcOffer.OnNegotiationNeeded(func() {
+		negotiationNeededCount.Add(1)
+		assert.NoError(t, signalPair(pcOffer, pcAnswer))
+		negotiated <- struct{}{}
+	})
+
+	// Hold the operations chain like a pending SetRemoteDescription
+	pcOffer.ops.begin()
+	for i := 0; i < 3; i++ {
+		track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		assert.NoError(t, err)
+
+		_, err = pcOffer.AddTrack(track)
+		assert.NoError(t, err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(0), negotiationNeededCount.Load())
+	pcOffer.ops.end()
+
+	<-negotiated
+	assert.Equal(t, 3, len(pcAnswer.GetTransceivers()))
+
+	// A negotiation including every change doesn't fire again
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), negotiationNeededCount.Load())
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
 // TestPeerConnection_Renegotiation_DisableTrack asserts that if a remote track is set inactive
 // that locally it goes inactive as well.
 func TestPeerConnection_Renegotiation_DisableTrack(t *testing.T) {
@@ -1433,3 +1476,116 @@ func TestNegotiationNotNeededAfterReplaceTrackNil(t *testing.T) {
 	assert.NoError(t, pcOffer.Close())
 	assert.NoError(t, pcAnswer.Close())
 }
+
+func TestPeerConnection_Renegotiation_RemoteRollback(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	for _, init := range []struct {
+		kind      RTPCodecType
+		direction RTPTransceiverDirection
+	}{
+		{RTPCodecTypeVideo, RTPTransceiverDirectionSendrecv},
+		{RTPCodecTypeAudio, RTPTransceiverDirectionRecvonly},
+		{RTPCodecTypeVideo, RTPTransceiverDirectionSendrecv},
+	} {
+		_, err = pcOffer.AddTransceiverFromKind(init.kind, RTPTransceiverInit{Direction: init.direction})
+		require.NoError(t, err)
+	}
+
+	recvonly, err := pcAnswer.AddTransceiverFromKind(RTPCodecTypeVideo, RTPTransceiverInit{
+		Direction: RTPTransceiverDirectionRecvonly,
+	})
+	require.NoError(t, err)
+	sendrecv, err := pcAnswer.AddTransceiverFromKind(RTPCodecTypeAudio, RTPTransceiverInit{
+		Direction: RTPTransceiverDirectionSendrecv,
+	})
+	require.NoError(t, err)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pcOffer.SetLocalDescription(offer))
+
+	// The offer associates both transceivers, changes the direction of the
+	// second one and creates a third one
+	require.NoError(t, pcAnswer.SetRemoteDescription(offer))
+	require.Len(t, pcAnswer.GetTransceivers(), 3)
+	assert.Equal(t, "0", recvonly.Mid())
+	assert.Equal(t, "1", sendrecv.Mid())
+	assert.Equal(t, RTPTransceiverDirectionSendonly, sendrecv.Direction())
+
+	// The SDP of a rollback is ignored
+	require.NoError(t, pcAnswer.SetRemoteDescription(SessionDescription{Type: SDPTypeRollback}))
+	assert.Equal(t, SignalingStateStable, pcAnswer.SignalingState())
+	assert.Nil(t, pcAnswer.PendingRemoteDescription())
+	assert.Equal(t, []*RTPTransceiver{recvonly, sendrecv}, pcAnswer.GetTransceivers())
+	assert.Equal(t, "", recvonly.Mid())
+	assert.Equal(t, "", sendrecv.Mid())
+	assert.Equal(t, RTPTransceiverDirectionSendrecv, sendrecv.Direction())
+	assert.Equal(t, RTPTransceiverDirectionUnknown, sendrecv.getCurrentRemoteDirection())
+
+	assert.Error(t, pcAnswer.SetRemoteDescription(SessionDescription{Type: SDPTypeRollback}))
+
+	// The offer can be applied again
+	require.NoError(t, pcAnswer.SetRemoteDescription(offer))
+	answer, err := pcAnswer.CreateAnswer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pcAnswer.SetLocalDescription(answer))
+	require.NoError(t, pcOffer.SetRemoteDescription(answer))
+	assert.Len(t, pcAnswer.GetTransceivers(), 3)
+	assert.Equal(t, "0", recvonly.Mid())
+	assert.Nil(t, pcAnswer.remoteOfferUndo)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestPeerConnection_Renegotiation_RemoteRollbackICERestart(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	local, err := pcAnswer.iceGatherer.GetLocalParameters()
+	require.NoError(t, err)
+	remote, err := pcAnswer.iceTransport.getRemoteParameters()
+	require.NoError(t, err)
+
+	offer, err := pcOffer.CreateOffer(&OfferOptions{ICERestart: true})
+	require.NoError(t, err)
+	require.NoError(t, pcOffer.SetLocalDescription(offer))
+
+	// The restarted offer changes both the local and the remote credentials
+	require.NoError(t, pcAnswer.SetRemoteDescription(offer))
+	restarted, err := pcAnswer.iceGatherer.GetLocalParameters()
+	require.NoError(t, err)
+	assert.NotEqual(t, local.UsernameFragment, restarted.UsernameFragment)
+
+	// Rolling it back restores them
+	require.NoError(t, pcAnswer.SetRemoteDescription(SessionDescription{Type: SDPTypeRollback}))
+	rolledBack, err := pcAnswer.iceGatherer.GetLocalParameters()
+	require.NoError(t, err)
+	assert.Equal(t, local.UsernameFragment, rolledBack.UsernameFragment)
+	assert.Equal(t, local.Password, rolledBack.Password)
+	rolledBackRemote, err := pcAnswer.iceTransport.getRemoteParameters()
+	require.NoError(t, err)
+	assert.Equal(t, remote, rolledBackRemote)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
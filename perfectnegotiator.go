@@ -0,0 +1,194 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+)
+
+// SignalingMessage is a message exchanged by two PerfectNegotiators, it carries
+// either a SessionDescription or an ICE candidate.
+type SignalingMessage struct {
+	Description *SessionDescription `json:"description,omitempty"`
+	Candidate   *ICECandidateInit   `json:"candidate,omitempty"`
+}
+
+// PerfectNegotiator negotiates a PeerConnection with the perfect negotiation pattern
+// of https://www.w3.org/TR/webrtc/#perfect-negotiation-example. Each peer creates
+// offers whenever negotiation is needed. When both peers offer at the same time the
+// impolite peer ignores the offer of the other, while the polite peer rolls back
+// its own offer and answers. So the two peers must be created with a different polite.
+//
+// The messages are sent with the send callback, and the messages received from the
+// remote peer must be passed to HandleMessage, in the order they were sent. send is
+// called in order from a goroutine of the negotiator, without holding its lock, so it
+// may block or deliver the message to the remote negotiator right away. Its errors are
+// logged.
+type PerfectNegotiator struct {
+	pc     *PeerConnection
+	polite bool
+	send   func(SignalingMessage) error
+
+	// mu serializes the negotiations and the handling of the remote messages
+	mu          sync.Mutex
+	ignoreOffer bool
+
+	// sendMu guards the messages queued for send, sent by a goroutine running while
+	// sending is set. It is held while setting a local description, to queue it before
+	// its candidates.
+	sendMu  sync.Mutex
+	sends   []SignalingMessage
+	sending bool
+}
+
+// NewPerfectNegotiator starts negotiating pc, it sets the OnNegotiationNeeded and
+// OnICECandidate handlers of pc, which must not be replaced.
+func NewPerfectNegotiator(pc *PeerConnection, polite bool, send func(SignalingMessage) error) *PerfectNegotiator {
+	negotiator := &PerfectNegotiator{
+		pc:     pc,
+		polite: polite,
+		send:   send,
+	}
+
+	pc.OnNegotiationNeeded(func() {
+		go negotiator.negotiate()
+	})
+	pc.OnICECandidate(func(candidate *ICECandidate) {
+		if candidate == nil {
+			return
+		}
+
+		init := candidate.ToJSON()
+		negotiator.queueSend(SignalingMessage{Candidate: &init})
+	})
+
+	return negotiator
+}
+
+// negotiate sends an offer, unless the negotiation is already in progress. In this
+// case the PeerConnection fires negotiation needed again once it is stable.
+func (n *PerfectNegotiator) negotiate() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.pc.SignalingState() != SignalingStateStable {
+		return
+	}
+
+	offer, err := n.pc.CreateOffer(nil)
+	if err == nil {
+		err = n.setAndSendLocalDescription(offer)
+	}
+	if err != nil {
+		n.pc.log.Errorf("Failed to negotiate: %v", err)
+	}
+}
+
+// setAndSendLocalDescription sets the local description and queues it for send. The
+// candidates gathered meanwhile are queued after it, so they don't reach the remote
+// peer before their description.
+func (n *PerfectNegotiator) setAndSendLocalDescription(description SessionDescription) error {
+	n.sendMu.Lock()
+	defer n.sendMu.Unlock()
+
+	if err := n.pc.SetLocalDescription(description); err != nil {
+		return err
+	}
+	n.queueSendLocked(SignalingMessage{Description: n.pc.LocalDescription()})
+
+	return nil
+}
+
+// queueSend queues msg for send, starting the goroutine sending the queue if needed.
+func (n *PerfectNegotiator) queueSend(msg SignalingMessage) {
+	n.sendMu.Lock()
+	defer n.sendMu.Unlock()
+
+	n.queueSendLocked(msg)
+}
+
+func (n *PerfectNegotiator) queueSendLocked(msg SignalingMessage) {
+	n.sends = append(n.sends, msg)
+	if !n.sending {
+		n.sending = true
+		go n.sendQueued()
+	}
+}
+
+// sendQueued sends the queued messages in order, it returns once the queue is empty.
+func (n *PerfectNegotiator) sendQueued() {
+	for {
+		n.sendMu.Lock()
+		if len(n.sends) == 0 {
+			n.sending = false
+			n.sendMu.Unlock()
+
+			return
+		}
+		msg := n.sends[0]
+		n.sends[0] = SignalingMessage{}
+		n.sends = n.sends[1:]
+		n.sendMu.Unlock()
+
+		if err := n.send(msg); err != nil {
+			if msg.Candidate != nil {
+				n.pc.log.Errorf("Failed to send ICE candidate: %v", err)
+			} else {
+				n.pc.log.Errorf("Failed to send %s: %v", msg.Description.Type, err)
+			}
+		}
+	}
+}
+
+// HandleMessage applies a message received from the remote peer, answering its offers.
+// Offers colliding with the local offer are ignored by the impolite peer, and roll
+// back the local offer of the polite peer.
+func (n *PerfectNegotiator) HandleMessage(msg SignalingMessage) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if msg.Description != nil {
+		return n.handleDescription(*msg.Description)
+	}
+
+	if msg.Candidate != nil {
+		// The candidates of an ignored offer can't be added
+		if err := n.pc.AddICECandidate(*msg.Candidate); err != nil && !n.ignoreOffer {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (n *PerfectNegotiator) handleDescription(description SessionDescription) error {
+	offerCollision := description.Type == SDPTypeOffer && n.pc.SignalingState() != SignalingStateStable
+	n.ignoreOffer = !n.polite && offerCollision
+	if n.ignoreOffer {
+		return nil
+	}
+
+	if offerCollision {
+		if err := n.pc.SetLocalDescription(SessionDescription{Type: SDPTypeRollback}); err != nil {
+			return err
+		}
+	}
+
+	if err := n.pc.SetRemoteDescription(description); err != nil {
+		return err
+	}
+	if description.Type != SDPTypeOffer {
+		return nil
+	}
+
+	answer, err := n.pc.CreateAnswer(nil)
+	if err != nil {
+		return err
+	}
+
+	return n.setAndSendLocalDescription(answer)
+}
@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signalNegotiators delivers the messages of each negotiator to the other in order,
+// until done is closed.
+func signalNegotiators(t *testing.T, politePC, impolitePC *PeerConnection, done <-chan struct{}) *sync.WaitGroup {
+	t.Helper()
+
+	toPolite, toImpolite := make(chan SignalingMessage, 100), make(chan SignalingMessage, 100)
+	polite := NewPerfectNegotiator(politePC, true, func(msg SignalingMessage) error {
+		toImpolite <- msg
+
+		return nil
+	})
+	impolite := NewPerfectNegotiator(impolitePC, false, func(msg SignalingMessage) error {
+		toPolite <- msg
+
+		return nil
+	})
+
+	var delivering sync.WaitGroup
+	deliver := func(negotiator *PerfectNegotiator, messages <-chan SignalingMessage) {
+		defer delivering.Done()
+
+		for {
+			select {
+			case <-done:
+				return
+			case msg := <-messages:
+				assert.NoError(t, negotiator.HandleMessage(msg))
+			}
+		}
+	}
+	delivering.Add(2)
+	go deliver(polite, toPolite)
+	go deliver(impolite, toImpolite)
+
+	return &delivering
+}
+
+func TestPerfectNegotiator_Glare(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	politePC, impolitePC, err := newPair()
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	delivering := signalNegotiators(t, politePC, impolitePC, done)
+	connected := untilConnectionState(PeerConnectionStateConnected, politePC, impolitePC)
+
+	// Both peers need negotiation at the same time
+	var opened sync.WaitGroup
+	opened.Add(2)
+	for _, pc := range []*PeerConnection{politePC, impolitePC} {
+		dc, dcErr := pc.CreateDataChannel("glare", nil)
+		require.NoError(t, dcErr)
+		dc.OnOpen(opened.Done)
+	}
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "polite")
+	require.NoError(t, err)
+	_, err = politePC.AddTrack(track)
+	require.NoError(t, err)
+	track, err = NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeOpus}, "audio", "impolite")
+	require.NoError(t, err)
+	_, err = impolitePC.AddTrack(track)
+	require.NoError(t, err)
+
+	connected.Wait()
+	opened.Wait()
+
+	// The renegotiations queued behind the collision complete
+	assert.Eventually(t, func() bool {
+		return politePC.SignalingState() == SignalingStateStable &&
+			impolitePC.SignalingState() == SignalingStateStable &&
+			len(politePC.GetTransceivers()) == 2 && len(impolitePC.GetTransceivers()) == 2 &&
+			politePC.CurrentRemoteDescription() != nil && impolitePC.CurrentRemoteDescription() != nil &&
+			len(politePC.CurrentRemoteDescription().parsed.MediaDescriptions) == 3 &&
+			len(impolitePC.CurrentRemoteDescription().parsed.MediaDescriptions) == 3
+	}, 10*time.Second, 10*time.Millisecond)
+
+	close(done)
+	delivering.Wait()
+	closePairNow(t, politePC, impolitePC)
+}
+
+func TestPerfectNegotiator_DirectSend(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	politePC, impolitePC, err := newPair()
+	require.NoError(t, err)
+
+	// The messages are handled from send, which is not called with the lock held
+	var polite, impolite *PerfectNegotiator
+	var negotiators sync.WaitGroup
+	negotiators.Add(1)
+	polite = NewPerfectNegotiator(politePC, true, func(msg SignalingMessage) error {
+		negotiators.Wait()
+
+		return impolite.HandleMessage(msg)
+	})
+	impolite = NewPerfectNegotiator(impolitePC, false, func(msg SignalingMessage) error {
+		negotiators.Wait()
+
+		return polite.HandleMessage(msg)
+	})
+	negotiators.Done()
+	connected := untilConnectionState(PeerConnectionStateConnected, politePC, impolitePC)
+
+	var opened sync.WaitGroup
+	opened.Add(2)
+	for _, pc := range []*PeerConnection{politePC, impolitePC} {
+		dc, dcErr := pc.CreateDataChannel("direct", nil)
+		require.NoError(t, dcErr)
+		dc.OnOpen(opened.Done)
+	}
+
+	connected.Wait()
+	opened.Wait()
+	closePairNow(t, politePC, impolitePC)
+}
+
+func TestPeerConnection_SetLocalDescriptionRollback(t *testing.T) {
+	pc, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	assert.Error(t, pc.SetLocalDescription(SessionDescription{Type: SDPTypeRollback}))
+
+	_, err = pc.CreateDataChannel("rollback", nil)
+	require.NoError(t, err)
+	offer, err := pc.CreateOffer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pc.SetLocalDescription(offer))
+	assert.Equal(t, SignalingStateHaveLocalOffer, pc.SignalingState())
+
+	require.NoError(t, pc.SetLocalDescription(SessionDescription{Type: SDPTypeRollback}))
+	assert.Equal(t, SignalingStateStable, pc.SignalingState())
+	assert.Nil(t, pc.PendingLocalDescription())
+
+	assert.NoError(t, pc.Close())
+}
@@ -0,0 +1,219 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/transport/v3/packetio"
+)
+
+// pipelineHistogramBounds are the upper bounds of the buckets of the pipeline histograms,
+// the last bucket is unbounded.
+var pipelineHistogramBounds = []time.Duration{ //nolint:gochecknoglobals
+	time.Microsecond,
+	2 * time.Microsecond,
+	5 * time.Microsecond,
+	10 * time.Microsecond,
+	20 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	200 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+}
+
+var pipelineStages = []PipelineStage{ //nolint:gochecknoglobals
+	PipelineStageSRTP,
+	PipelineStageInterceptor,
+	PipelineStageDepacketize,
+}
+
+// pipelineMetrics times the stages of the incoming RTP packets of a DTLSTransport.
+// A nil *pipelineMetrics is valid and disabled.
+type pipelineMetrics struct {
+	histograms map[PipelineStage]*pipelineHistogram
+}
+
+type pipelineHistogram struct {
+	packets   atomic.Uint64
+	totalTime atomic.Int64
+	maxTime   atomic.Int64
+	buckets   []atomic.Uint64
+}
+
+func newPipelineMetrics() *pipelineMetrics {
+	metrics := &pipelineMetrics{histograms: map[PipelineStage]*pipelineHistogram{}}
+	for _, stage := range pipelineStages {
+		metrics.histograms[stage] = &pipelineHistogram{
+			buckets: make([]atomic.Uint64, len(pipelineHistogramBounds)+1),
+		}
+	}
+
+	return metrics
+}
+
+func (m *pipelineMetrics) observe(stage PipelineStage, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+
+	histogram := m.histograms[stage]
+	histogram.packets.Add(1)
+	histogram.totalTime.Add(int64(elapsed))
+	for {
+		maxTime := histogram.maxTime.Load()
+		if int64(elapsed) <= maxTime || histogram.maxTime.CompareAndSwap(maxTime, int64(elapsed)) {
+			break
+		}
+	}
+
+	bucket := len(pipelineHistogramBounds)
+	for i, bound := range pipelineHistogramBounds {
+		if elapsed <= bound {
+			bucket = i
+
+			break
+		}
+	}
+	histogram.buckets[bucket].Add(1)
+}
+
+// observeSince observes the time elapsed since start, if the metrics are enabled.
+func (m *pipelineMetrics) observeSince(stage PipelineStage, start time.Time) {
+	if m == nil {
+		return
+	}
+
+	m.observe(stage, time.Since(start))
+}
+
+// now returns the current time if the metrics are enabled, so disabled
+// metrics don't cost a clock read per packet.
+func (m *pipelineMetrics) now() time.Time {
+	if m == nil {
+		return time.Time{}
+	}
+
+	return time.Now()
+}
+
+func (m *pipelineMetrics) collectStats(collector *statsReportCollector) {
+	if m == nil {
+		return
+	}
+
+	for _, stage := range pipelineStages {
+		collector.Collecting()
+
+		histogram := m.histograms[stage]
+		stats := PipelineStageStats{
+			Timestamp: statsTimestampNow(),
+			Type:      StatsTypePipelineStage,
+			ID:        "pipeline-" + string(stage),
+			Stage:     stage,
+			Packets:   histogram.packets.Load(),
+			TotalTime: time.Duration(histogram.totalTime.Load()).Seconds(),
+			MaxTime:   time.Duration(histogram.maxTime.Load()).Seconds(),
+		}
+		for i := range histogram.buckets {
+			bucket := PipelineHistogramBucket{Packets: histogram.buckets[i].Load()}
+			if i < len(pipelineHistogramBounds) {
+				bucket.UpperBound = pipelineHistogramBounds[i].Seconds()
+			}
+			stats.Histogram = append(stats.Histogram, bucket)
+		}
+
+		collector.Collect(stats.ID, stats)
+	}
+}
+
+// getPipelineMetrics returns the pipelineMetrics of the DTLSTransport of the receiver, if any.
+func (r *RTPReceiver) getPipelineMetrics() *pipelineMetrics {
+	if r.transport == nil {
+		return nil
+	}
+
+	return r.transport.pipelineMetrics
+}
+
+// bindRemoteStream binds the interceptor chain to reader with bind, timing the
+// chain from the packet being read from reader to it being returned by the chain.
+func (m *pipelineMetrics) bindRemoteStream(
+	bind func(interceptor.RTPReader) interceptor.RTPReader,
+	reader interceptor.RTPReader,
+) interceptor.RTPReader {
+	if m == nil {
+		return bind(reader)
+	}
+
+	var readAt atomic.Int64
+	chain := bind(interceptor.RTPReaderFunc(
+		func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+			n, attributes, err := reader.Read(b, a)
+			readAt.Store(time.Now().UnixNano())
+
+			return n, attributes, err
+		},
+	))
+
+	return interceptor.RTPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		n, attributes, err := chain.Read(b, a)
+		if err == nil {
+			m.observe(PipelineStageInterceptor, time.Since(time.Unix(0, readAt.Load())))
+		}
+
+		return n, attributes, err
+	})
+}
+
+// pipelineTimedConn sits between the SRTP endpoint and the SRTP session to time
+// PipelineStageSRTP. The session decrypts a packet and writes it to the buffer of
+// its SSRC before reading the next one, the time between the two is the stage.
+type pipelineTimedConn struct {
+	net.Conn
+
+	metrics       *pipelineMetrics
+	bufferFactory func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser
+	readAt        atomic.Int64
+}
+
+type pipelineTimedBuffer struct {
+	io.ReadWriteCloser
+
+	conn *pipelineTimedConn
+}
+
+func (c *pipelineTimedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.readAt.Store(time.Now().UnixNano())
+
+	return n, err
+}
+
+// BufferFactory creates the buffers of the SRTP session.
+func (c *pipelineTimedConn) BufferFactory(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+	buffer := newSRTPStreamBuffer(c.bufferFactory, packetType, ssrc)
+	if packetType != packetio.RTPBufferPacket {
+		return buffer
+	}
+
+	return &pipelineTimedBuffer{ReadWriteCloser: buffer, conn: c}
+}
+
+func (b *pipelineTimedBuffer) Write(p []byte) (int, error) {
+	b.conn.metrics.observe(PipelineStageSRTP, time.Since(time.Unix(0, b.conn.readAt.Load())))
+
+	return b.ReadWriteCloser.Write(p)
+}
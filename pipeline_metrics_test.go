@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipelineMetrics_Observe(t *testing.T) {
+	metrics := newPipelineMetrics()
+	metrics.observe(PipelineStageSRTP, 3*time.Microsecond)
+	metrics.observe(PipelineStageSRTP, 5*time.Microsecond)
+	metrics.observe(PipelineStageSRTP, time.Second)
+
+	collector := newStatsReportCollector()
+	metrics.collectStats(collector)
+	report := collector.Ready()
+
+	stats, ok := report["pipeline-srtp"].(PipelineStageStats)
+	require.True(t, ok)
+	assert.Equal(t, uint64(3), stats.Packets)
+	assert.InDelta(t, 1.000008, stats.TotalTime, 1e-9)
+	assert.Equal(t, 1.0, stats.MaxTime)
+	assert.Equal(t, PipelineHistogramBucket{UpperBound: 5e-6, Packets: 2}, stats.Histogram[2])
+	assert.Equal(t, PipelineHistogramBucket{Packets: 1}, stats.Histogram[len(stats.Histogram)-1])
+
+	assert.Contains(t, report, "pipeline-interceptor")
+	assert.Contains(t, report, "pipeline-depacketize")
+
+	// Disabled metrics report nothing
+	var disabled *pipelineMetrics
+	disabled.observe(PipelineStageSRTP, time.Second)
+	collector = newStatsReportCollector()
+	disabled.collectStats(collector)
+	assert.Empty(t, collector.Ready())
+}
+
+func TestPeerConnection_PipelineMetrics(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settingEngine := SettingEngine{}
+	settingEngine.EnablePipelineMetrics(true)
+
+	pcOffer, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		for i := 0; i < 5; i++ {
+			_, _, readErr := track.ReadRTP()
+			assert.NoError(t, readErr)
+		}
+		cancel()
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, ctx.Done(), []*TrackLocalStaticSample{track})
+
+	stats := pcAnswer.GetStats()
+	for _, stage := range []PipelineStage{PipelineStageSRTP, PipelineStageInterceptor, PipelineStageDepacketize} {
+		stageStats, ok := stats["pipeline-"+string(stage)].(PipelineStageStats)
+		require.True(t, ok)
+		assert.Equal(t, stage, stageStats.Stage)
+		assert.NotZero(t, stageStats.Packets)
+
+		raw, marshalErr := json.Marshal(stageStats)
+		require.NoError(t, marshalErr)
+		unmarshaled, unmarshalErr := UnmarshalStatsJSON(raw)
+		require.NoError(t, unmarshalErr)
+		assert.Equal(t, stageStats, unmarshaled)
+	}
+
+	_, ok := pcOffer.GetStats()["pipeline-srtp"]
+	assert.False(t, ok)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -0,0 +1,332 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package rawvideo implements the RTP payload format for uncompressed video
+// https://datatracker.ietf.org/doc/html/rfc4175
+package rawvideo
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	extendedSequenceNumberSize = 2
+	segmentHeaderSize          = 6
+
+	continuationBit = 0x8000
+	fieldBit        = 0x8000
+	lineNumberMask  = 0x7FFF
+	offsetMask      = 0x7FFF
+)
+
+// Sampling values as defined in RFC 4175 section 6.1.
+const (
+	SamplingYCbCr444 = "YCbCr-4:4:4"
+	SamplingYCbCr422 = "YCbCr-4:2:2"
+	SamplingRGB      = "RGB"
+	SamplingRGBA     = "RGBA"
+)
+
+var (
+	errShortPacket          = errors.New("packet is not large enough")
+	errUnsupportedSampling  = errors.New("unsupported sampling")
+	errUnsupportedDepth     = errors.New("unsupported depth")
+	errInvalidDimensions    = errors.New("width and height must be positive")
+	errMissingParameter     = errors.New("missing fmtp parameter")
+	errSegmentOutOfBounds   = errors.New("segment is out of the frame bounds")
+	errSegmentNotAligned    = errors.New("segment is not aligned to a pixel group")
+	errInvalidParameterLine = errors.New("invalid fmtp parameter")
+)
+
+// pgroup describes the smallest unit of pixels that is sent, see RFC 4175 section 4.3.
+type pgroup struct {
+	size, pixels int
+}
+
+// nolint:gochecknoglobals
+var pgroups = map[string]map[int]pgroup{
+	SamplingYCbCr444: {8: {3, 1}, 10: {15, 4}, 12: {9, 2}, 16: {6, 1}},
+	SamplingYCbCr422: {8: {4, 2}, 10: {5, 2}, 12: {6, 2}, 16: {8, 2}},
+	SamplingRGB:      {8: {3, 1}, 10: {15, 4}, 12: {9, 2}, 16: {6, 1}},
+	SamplingRGBA:     {8: {4, 1}, 10: {5, 1}, 12: {6, 1}, 16: {8, 1}},
+}
+
+// Format describes the layout of the frames of a raw video stream.
+type Format struct {
+	Sampling      string
+	Width, Height int
+	Depth         int
+}
+
+// ParseFormat parses the sampling, width, height and depth parameters of an fmtp line.
+func ParseFormat(fmtpLine string) (Format, error) {
+	var format Format
+
+	parameters := map[string]string{}
+	for _, p := range strings.Split(fmtpLine, ";") {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) == 2 {
+			parameters[strings.ToLower(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+
+	format.Sampling = parameters["sampling"]
+	if format.Sampling == "" {
+		return format, fmt.Errorf("%w: sampling", errMissingParameter)
+	}
+
+	for key, dst := range map[string]*int{"width": &format.Width, "height": &format.Height, "depth": &format.Depth} {
+		value, ok := parameters[key]
+		if !ok {
+			return format, fmt.Errorf("%w: %s", errMissingParameter, key)
+		}
+
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return format, fmt.Errorf("%w: %s=%s", errInvalidParameterLine, key, value)
+		}
+		*dst = parsed
+	}
+
+	_, err := format.pgroup()
+
+	return format, err
+}
+
+func (f Format) pgroup() (pgroup, error) {
+	if f.Width <= 0 || f.Height <= 0 {
+		return pgroup{}, errInvalidDimensions
+	}
+
+	depths, ok := pgroups[f.Sampling]
+	if !ok {
+		return pgroup{}, fmt.Errorf("%w: %s", errUnsupportedSampling, f.Sampling)
+	}
+
+	group, ok := depths[f.Depth]
+	if !ok || f.Width%group.pixels != 0 {
+		return pgroup{}, fmt.Errorf("%w: %d", errUnsupportedDepth, f.Depth)
+	}
+
+	return group, nil
+}
+
+// LineSize returns the size of a single scan line in bytes.
+func (f Format) LineSize() int {
+	group, err := f.pgroup()
+	if err != nil {
+		return 0
+	}
+
+	return f.Width / group.pixels * group.size
+}
+
+// FrameSize returns the size of a complete frame in bytes.
+func (f Format) FrameSize() int {
+	return f.LineSize() * f.Height
+}
+
+// Segment is a part of a single scan line carried in a packet.
+type Segment struct {
+	// Field is set for the second field of interlaced video
+	Field bool
+	// Line is the scan line number
+	Line int
+	// Offset is the position of the first pixel of the segment in the line
+	Offset int
+	Data   []byte
+}
+
+// CopySegment copies the data of a Segment to its position in frame,
+// which must be of Format.FrameSize. It allows to reassemble a frame
+// even if packets are lost or reordered.
+func (f Format) CopySegment(frame []byte, segment Segment) error {
+	group, err := f.pgroup()
+	if err != nil {
+		return err
+	}
+
+	if segment.Offset%group.pixels != 0 || len(segment.Data)%group.size != 0 {
+		return errSegmentNotAligned
+	}
+
+	start := segment.Line*f.LineSize() + segment.Offset/group.pixels*group.size
+	if segment.Line >= f.Height || segment.Offset/group.pixels*group.size+len(segment.Data) > f.LineSize() ||
+		start+len(segment.Data) > len(frame) {
+		return errSegmentOutOfBounds
+	}
+
+	copy(frame[start:], segment.Data)
+
+	return nil
+}
+
+// Payloader payloads raw video frames.
+type Payloader struct {
+	Format Format
+	// SequenceNumber is the RTP sequence number of the next payload, it must be set to the
+	// first sequence number of the packetizer. It is incremented for every payload, the
+	// payloader counting its rollovers as the extended sequence number.
+	SequenceNumber uint16
+
+	rollovers uint16
+}
+
+// Payload fragments a frame across one or more byte arrays. Every payload carries as many
+// scan line segments as fit the mtu, lines are only split at pixel group boundaries.
+// No payloads are returned if the frame doesn't match the Format.
+//
+// Each payload gets the next SequenceNumber, the packetizer must send every payload.
+func (p *Payloader) Payload(mtu uint16, frame []byte) [][]byte {
+	group, err := p.Format.pgroup()
+	if err != nil || len(frame) != p.Format.FrameSize() ||
+		int(mtu) < extendedSequenceNumberSize+segmentHeaderSize+group.size {
+		return nil
+	}
+
+	lineSize := p.Format.LineSize()
+	payloads := [][]byte{}
+	line, offset := 0, 0
+
+	for line < p.Format.Height {
+		segments := []Segment{}
+		remaining := int(mtu) - extendedSequenceNumberSize
+
+		for line < p.Format.Height && remaining >= segmentHeaderSize+group.size {
+			groups := min((remaining-segmentHeaderSize)/group.size, (p.Format.Width-offset)/group.pixels)
+			start := line*lineSize + offset/group.pixels*group.size
+
+			segments = append(segments, Segment{
+				Line:   line,
+				Offset: offset,
+				Data:   frame[start : start+groups*group.size],
+			})
+			remaining -= segmentHeaderSize + groups*group.size
+
+			offset += groups * group.pixels
+			if offset >= p.Format.Width {
+				line++
+				offset = 0
+			}
+		}
+
+		payloads = append(payloads, p.marshal(segments))
+	}
+
+	return payloads
+}
+
+func (p *Payloader) marshal(segments []Segment) []byte {
+	size := extendedSequenceNumberSize
+	for _, segment := range segments {
+		size += segmentHeaderSize + len(segment.Data)
+	}
+
+	out := make([]byte, size)
+	binary.BigEndian.PutUint16(out, p.rollovers)
+	p.SequenceNumber++
+	if p.SequenceNumber == 0 {
+		p.rollovers++
+	}
+
+	pos := extendedSequenceNumberSize
+	for i, segment := range segments {
+		lineNumber := uint16(segment.Line) & lineNumberMask //nolint:gosec // G115
+		if segment.Field {
+			lineNumber |= fieldBit
+		}
+		offset := uint16(segment.Offset) & offsetMask //nolint:gosec // G115
+		if i != len(segments)-1 {
+			offset |= continuationBit
+		}
+
+		binary.BigEndian.PutUint16(out[pos:], uint16(len(segment.Data))) //nolint:gosec // G115
+		binary.BigEndian.PutUint16(out[pos+2:], lineNumber)
+		binary.BigEndian.PutUint16(out[pos+4:], offset)
+		pos += segmentHeaderSize
+	}
+
+	for _, segment := range segments {
+		pos += copy(out[pos:], segment.Data)
+	}
+
+	return out
+}
+
+// Packet represents a raw video RTP payload.
+type Packet struct {
+	ExtendedSequenceNumber uint16
+	Segments               []Segment
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the Packet.
+// The returned data is the concatenation of all segments, which is the frame
+// itself if no packets have been lost.
+func (p *Packet) Unmarshal(payload []byte) ([]byte, error) {
+	if len(payload) < extendedSequenceNumberSize+segmentHeaderSize {
+		return nil, errShortPacket
+	}
+
+	p.ExtendedSequenceNumber = binary.BigEndian.Uint16(payload)
+	p.Segments = p.Segments[:0]
+
+	pos := extendedSequenceNumberSize
+	lengths := []int{}
+	for {
+		if len(payload) < pos+segmentHeaderSize {
+			return nil, errShortPacket
+		}
+
+		length := int(binary.BigEndian.Uint16(payload[pos:]))
+		lineNumber := binary.BigEndian.Uint16(payload[pos+2:])
+		offset := binary.BigEndian.Uint16(payload[pos+4:])
+		pos += segmentHeaderSize
+
+		lengths = append(lengths, length)
+		p.Segments = append(p.Segments, Segment{
+			Field:  lineNumber&fieldBit != 0,
+			Line:   int(lineNumber & lineNumberMask),
+			Offset: int(offset & offsetMask),
+		})
+
+		if offset&continuationBit == 0 {
+			break
+		}
+	}
+
+	data := make([]byte, 0, len(payload)-pos)
+	for i, length := range lengths {
+		if len(payload) < pos+length {
+			return nil, errShortPacket
+		}
+
+		p.Segments[i].Data = payload[pos : pos+length]
+		data = append(data, p.Segments[i].Data...)
+		pos += length
+	}
+
+	return data, nil
+}
+
+// IsPartitionHead checks whether if this is a head of the frame,
+// which is the case when it starts with the first pixel of the first line.
+func (p *Packet) IsPartitionHead(payload []byte) bool {
+	if len(payload) < extendedSequenceNumberSize+segmentHeaderSize {
+		return false
+	}
+
+	lineNumber := binary.BigEndian.Uint16(payload[extendedSequenceNumberSize+2:]) & lineNumberMask
+	offset := binary.BigEndian.Uint16(payload[extendedSequenceNumberSize+4:]) & offsetMask
+
+	return lineNumber == 0 && offset == 0
+}
+
+// IsPartitionTail checks whether if this is the last packet of the frame,
+// which is signaled by the marker bit.
+func (p *Packet) IsPartitionTail(marker bool, _ []byte) bool {
+	return marker
+}
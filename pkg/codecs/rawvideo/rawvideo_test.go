@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rawvideo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("sampling=YCbCr-4:2:2; width=1280; height=720; depth=10; colorimetry=BT709-2")
+	assert.NoError(t, err)
+	assert.Equal(t, Format{Sampling: SamplingYCbCr422, Width: 1280, Height: 720, Depth: 10}, format)
+	assert.Equal(t, 3200, format.LineSize())
+	assert.Equal(t, 3200*720, format.FrameSize())
+
+	_, err = ParseFormat("width=1280; height=720; depth=8")
+	assert.ErrorIs(t, err, errMissingParameter)
+
+	_, err = ParseFormat("sampling=RGB; width=abc; height=720; depth=8")
+	assert.ErrorIs(t, err, errInvalidParameterLine)
+
+	_, err = ParseFormat("sampling=CLYCbCr-4:2:0; width=1280; height=720; depth=8")
+	assert.ErrorIs(t, err, errUnsupportedSampling)
+
+	_, err = ParseFormat("sampling=RGB; width=1280; height=720; depth=9")
+	assert.ErrorIs(t, err, errUnsupportedDepth)
+
+	_, err = ParseFormat("sampling=RGB; width=0; height=720; depth=8")
+	assert.ErrorIs(t, err, errInvalidDimensions)
+}
+
+func TestPayloaderRoundTrip(t *testing.T) {
+	format := Format{Sampling: SamplingYCbCr422, Width: 64, Height: 8, Depth: 8}
+	frame := make([]byte, format.FrameSize())
+	for i := range frame {
+		frame[i] = byte(i)
+	}
+
+	payloader := &Payloader{Format: format}
+
+	t.Run("Mismatched frame", func(t *testing.T) {
+		assert.Nil(t, payloader.Payload(1200, frame[1:]))
+	})
+
+	t.Run("MTU too small", func(t *testing.T) {
+		assert.Nil(t, payloader.Payload(8, frame))
+	})
+
+	for _, mtu := range []uint16{12, 100, 266, 1200} {
+		payloads := payloader.Payload(mtu, frame)
+		assert.NotEmpty(t, payloads)
+
+		depacketizer := &Packet{}
+		assembled := make([]byte, format.FrameSize())
+		concatenated := []byte{}
+		for i, payload := range payloads {
+			assert.LessOrEqual(t, len(payload), int(mtu))
+			assert.Equal(t, i == 0, depacketizer.IsPartitionHead(payload))
+
+			data, err := depacketizer.Unmarshal(payload)
+			assert.NoError(t, err)
+			concatenated = append(concatenated, data...)
+
+			for _, segment := range depacketizer.Segments {
+				assert.Zero(t, len(segment.Data)%4, "segments must be aligned to pixel groups")
+				assert.NoError(t, format.CopySegment(assembled, segment))
+			}
+		}
+
+		assert.True(t, bytes.Equal(frame, assembled), "mtu %d", mtu)
+		assert.True(t, bytes.Equal(frame, concatenated), "mtu %d", mtu)
+	}
+}
+
+func TestPacketUnmarshal(t *testing.T) {
+	depacketizer := &Packet{}
+
+	_, err := depacketizer.Unmarshal([]byte{0x00, 0x01})
+	assert.ErrorIs(t, err, errShortPacket)
+
+	// header announces a continuation but the packet ends
+	_, err = depacketizer.Unmarshal([]byte{0x00, 0x01, 0x00, 0x04, 0x00, 0x00, 0x80, 0x00})
+	assert.ErrorIs(t, err, errShortPacket)
+
+	// segment is longer than the packet
+	_, err = depacketizer.Unmarshal([]byte{0x00, 0x01, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00, 0xAA})
+	assert.ErrorIs(t, err, errShortPacket)
+
+	data, err := depacketizer.Unmarshal([]byte{
+		0x00, 0x01,
+		0x00, 0x04, 0x80, 0x03, 0x80, 0x02,
+		0x00, 0x04, 0x80, 0x04, 0x00, 0x00,
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, data)
+	assert.Equal(t, uint16(1), depacketizer.ExtendedSequenceNumber)
+	assert.Equal(t, []Segment{
+		{Field: true, Line: 3, Offset: 2, Data: []byte{0x01, 0x02, 0x03, 0x04}},
+		{Field: true, Line: 4, Offset: 0, Data: []byte{0x05, 0x06, 0x07, 0x08}},
+	}, depacketizer.Segments)
+	assert.True(t, depacketizer.IsPartitionTail(true, nil))
+	assert.False(t, depacketizer.IsPartitionTail(false, nil))
+}
+
+func TestCopySegment(t *testing.T) {
+	format := Format{Sampling: SamplingRGB, Width: 4, Height: 2, Depth: 8}
+	frame := make([]byte, format.FrameSize())
+
+	assert.ErrorIs(t, format.CopySegment(frame, Segment{Line: 2, Data: []byte{1, 2, 3}}), errSegmentOutOfBounds)
+	assert.ErrorIs(t, format.CopySegment(frame, Segment{Offset: 3, Data: make([]byte, 6)}), errSegmentOutOfBounds)
+	assert.ErrorIs(t, format.CopySegment(frame, Segment{Data: []byte{1, 2}}), errSegmentNotAligned)
+
+	assert.NoError(t, format.CopySegment(frame, Segment{Line: 1, Offset: 1, Data: []byte{1, 2, 3}}))
+	assert.Equal(t, []byte{1, 2, 3}, frame[15:18])
+}
+
+func TestPayloaderExtendedSequenceNumber(t *testing.T) {
+	format := Format{Sampling: SamplingRGB, Width: 4, Height: 4, Depth: 8}
+	frame := make([]byte, format.FrameSize())
+
+	payloader := &Payloader{Format: format, SequenceNumber: 65534}
+	depacketizer := &Packet{}
+	extended := []uint16{}
+	for i := 0; i < 2; i++ {
+		// A line per payload
+		for _, payload := range payloader.Payload(uint16(extendedSequenceNumberSize+segmentHeaderSize+format.LineSize()), frame) {
+			_, err := depacketizer.Unmarshal(payload)
+			assert.NoError(t, err)
+			extended = append(extended, depacketizer.ExtendedSequenceNumber)
+		}
+	}
+
+	// 65534, 65535, then 0 to 5 after the rollover
+	assert.Equal(t, []uint16{0, 0, 1, 1, 1, 1, 1, 1}, extended)
+	assert.Equal(t, uint16(6), payloader.SequenceNumber)
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package red implements the RTP payload format for redundant audio data
+// https://datatracker.ietf.org/doc/html/rfc2198
+package red
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	primaryHeaderSize   = 1
+	redundantHeaderSize = 4
+
+	followBit        = 0x80
+	payloadTypeMask  = 0x7F
+	maxTimestampDiff = 1 << 14
+	maxBlockLength   = 1 << 10
+)
+
+var errShortPacket = errors.New("packet is not large enough")
+
+// Block is a single encoding carried in a RED payload.
+type Block struct {
+	PayloadType uint8
+	// TimestampOffset is the difference between the timestamp of the
+	// packet and the one of the block, it is always 0 for the primary block
+	TimestampOffset uint16
+	Data            []byte
+}
+
+type historyEntry struct {
+	timestamp uint32
+	data      []byte
+}
+
+// Encoder adds the previous payloads of a stream as redundant blocks to each of its payloads.
+type Encoder struct {
+	// PayloadType is the payload type of the encoded blocks
+	PayloadType uint8
+	// Distance is the number of previous payloads carried with each payload
+	Distance int
+	// MaxSize is the maximum size of an encoded payload, redundant blocks are dropped
+	// starting with the oldest one to respect it. 0 means unlimited.
+	MaxSize int
+
+	history []historyEntry
+}
+
+// Encode returns payload, sent with the given timestamp, prefixed by as many of the previous payloads
+// as Distance and MaxSize allow. Previous payloads too old to be signaled are skipped.
+func (e *Encoder) Encode(timestamp uint32, payload []byte) []byte {
+	blocks := []historyEntry{}
+	size := primaryHeaderSize + len(payload)
+	for i := len(e.history) - 1; i >= 0; i-- {
+		entry := e.history[i]
+		if timestamp-entry.timestamp >= maxTimestampDiff || len(entry.data) >= maxBlockLength {
+			continue
+		}
+		if e.MaxSize > 0 && size+redundantHeaderSize+len(entry.data) > e.MaxSize {
+			break
+		}
+
+		size += redundantHeaderSize + len(entry.data)
+		blocks = append([]historyEntry{entry}, blocks...)
+	}
+
+	out := make([]byte, size)
+	pos := 0
+	for _, block := range blocks {
+		header := uint32(followBit|e.PayloadType&payloadTypeMask)<<24 |
+			(timestamp-block.timestamp)<<10 | uint32(len(block.data)) //nolint:gosec // G115
+		binary.BigEndian.PutUint32(out[pos:], header)
+		pos += redundantHeaderSize
+	}
+	out[pos] = e.PayloadType & payloadTypeMask
+	pos += primaryHeaderSize
+
+	for _, block := range blocks {
+		pos += copy(out[pos:], block.data)
+	}
+	copy(out[pos:], payload)
+
+	if e.Distance > 0 && len(payload) != 0 {
+		e.history = append(e.history, historyEntry{timestamp: timestamp, data: append([]byte{}, payload...)})
+		if len(e.history) > e.Distance {
+			e.history = e.history[len(e.history)-e.Distance:]
+		}
+	}
+
+	return out
+}
+
+// Packet represents a RED payload.
+type Packet struct {
+	// Blocks contains the redundant blocks, from the oldest to the most recent,
+	// followed by the primary block
+	Blocks []Block
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the Packet.
+// It returns the data of the primary block.
+func (p *Packet) Unmarshal(payload []byte) ([]byte, error) {
+	p.Blocks = p.Blocks[:0]
+
+	pos := 0
+	lengths := []int{}
+	for {
+		if len(payload) < pos+primaryHeaderSize {
+			return nil, errShortPacket
+		}
+
+		if payload[pos]&followBit == 0 {
+			p.Blocks = append(p.Blocks, Block{PayloadType: payload[pos] & payloadTypeMask})
+			pos += primaryHeaderSize
+
+			break
+		}
+
+		if len(payload) < pos+redundantHeaderSize {
+			return nil, errShortPacket
+		}
+
+		header := binary.BigEndian.Uint32(payload[pos:])
+		p.Blocks = append(p.Blocks, Block{
+			PayloadType:     payload[pos] & payloadTypeMask,
+			TimestampOffset: uint16(header >> 10 & (maxTimestampDiff - 1)), //nolint:gosec // G115
+		})
+		lengths = append(lengths, int(header&(maxBlockLength-1)))
+		pos += redundantHeaderSize
+	}
+
+	for i, length := range lengths {
+		if len(payload) < pos+length {
+			return nil, errShortPacket
+		}
+
+		p.Blocks[i].Data = payload[pos : pos+length]
+		pos += length
+	}
+
+	primary := &p.Blocks[len(p.Blocks)-1]
+	primary.Data = payload[pos:]
+
+	return primary.Data, nil
+}
+
+// IsPartitionHead checks whether if this is a head of the RED packet.
+func (p *Packet) IsPartitionHead(_ []byte) bool {
+	return true
+}
+
+// IsPartitionTail checks whether if this is a tail of the RED packet.
+func (p *Packet) IsPartitionTail(_ bool, _ []byte) bool {
+	return true
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package red
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoder(t *testing.T) {
+	encoder := &Encoder{PayloadType: 111, Distance: 2}
+	depacketizer := &Packet{}
+
+	// No history yet, only the primary block
+	payload := encoder.Encode(1000, []byte{0x01})
+	assert.Equal(t, []byte{111, 0x01}, payload)
+
+	data, err := depacketizer.Unmarshal(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01}, data)
+	assert.Equal(t, []Block{{PayloadType: 111, Data: []byte{0x01}}}, depacketizer.Blocks)
+
+	encoder.Encode(1960, []byte{0x02, 0x02})
+	payload = encoder.Encode(2920, []byte{0x03})
+
+	data, err = depacketizer.Unmarshal(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x03}, data)
+	assert.Equal(t, []Block{
+		{PayloadType: 111, TimestampOffset: 1920, Data: []byte{0x01}},
+		{PayloadType: 111, TimestampOffset: 960, Data: []byte{0x02, 0x02}},
+		{PayloadType: 111, Data: []byte{0x03}},
+	}, depacketizer.Blocks)
+	assert.True(t, depacketizer.IsPartitionHead(payload))
+	assert.True(t, depacketizer.IsPartitionTail(false, payload))
+
+	// Distance limits the history
+	payload = encoder.Encode(3880, []byte{0x04})
+	_, err = depacketizer.Unmarshal(payload)
+	assert.NoError(t, err)
+	assert.Len(t, depacketizer.Blocks, 3)
+	assert.Equal(t, []byte{0x02, 0x02}, depacketizer.Blocks[0].Data)
+
+	// Blocks too old for the 14 bits timestamp offset are skipped
+	payload = encoder.Encode(3880+maxTimestampDiff, []byte{0x05})
+	assert.Equal(t, []byte{111, 0x05}, payload)
+}
+
+func TestEncoderMaxSize(t *testing.T) {
+	encoder := &Encoder{PayloadType: 111, Distance: 2, MaxSize: 12}
+	depacketizer := &Packet{}
+
+	encoder.Encode(0, []byte{0x01, 0x01, 0x01})
+	encoder.Encode(960, []byte{0x02, 0x02})
+
+	// Only the most recent block fits
+	payload := encoder.Encode(1920, []byte{0x03, 0x03})
+	assert.LessOrEqual(t, len(payload), 12)
+
+	_, err := depacketizer.Unmarshal(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, []Block{
+		{PayloadType: 111, TimestampOffset: 960, Data: []byte{0x02, 0x02}},
+		{PayloadType: 111, Data: []byte{0x03, 0x03}},
+	}, depacketizer.Blocks)
+}
+
+func TestPacketUnmarshal(t *testing.T) {
+	depacketizer := &Packet{}
+
+	_, err := depacketizer.Unmarshal([]byte{})
+	assert.ErrorIs(t, err, errShortPacket)
+
+	_, err = depacketizer.Unmarshal([]byte{0x80 | 111, 0x00})
+	assert.ErrorIs(t, err, errShortPacket)
+
+	// redundant block is longer than the packet
+	_, err = depacketizer.Unmarshal([]byte{0x80 | 111, 0x00, 0x00, 0x05, 111, 0x01})
+	assert.ErrorIs(t, err, errShortPacket)
+}
@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package conformance provides fixed RTP inputs and the golden outputs the media
+// writers of this module produce for them, so forks and custom writers can
+// validate they stay compatible.
+package conformance
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+// Format is a container and codec pair with golden outputs.
+type Format string
+
+const (
+	// FormatIVFVP8 is VP8 in IVF, as written by ivfwriter.
+	FormatIVFVP8 Format = "ivf-vp8"
+	// FormatOggOpus is Opus in Ogg, as written by oggwriter with the serial OggSerial.
+	FormatOggOpus Format = "ogg-opus"
+	// FormatH264 is an H264 Annex B byte stream, as written by h264writer.
+	FormatH264 Format = "h264"
+	// FormatH265 is an H265 Annex B byte stream, as written by h265writer.
+	FormatH265 Format = "h265"
+)
+
+// OggSerial is the serial number of the logical stream of the FormatOggOpus golden outputs.
+const OggSerial = 0x50494f4e
+
+var (
+	// ErrOutputMismatch is returned by Check when the output of a writer differs from the golden output.
+	ErrOutputMismatch = errors.New("output differs from the golden output")
+
+	errUnknownFormat = errors.New("unknown format")
+	errInvalidOffset = errors.New("invalid offset")
+)
+
+//go:embed testdata
+var goldens embed.FS
+
+// Formats returns the formats with golden outputs.
+func Formats() []Format {
+	return []Format{FormatIVFVP8, FormatOggOpus, FormatH264, FormatH265}
+}
+
+// Case is a fixed RTP input of a writer.
+type Case struct {
+	// Name identifies the case: ordered, loss, reorder or timestamp-wrap.
+	Name    string
+	Packets []*rtp.Packet
+}
+
+// Cases returns the inputs of format. The packets are created on every call,
+// so they can be modified by the writers.
+func Cases(format Format) ([]Case, error) {
+	generate, ok := generators[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errUnknownFormat, format)
+	}
+
+	ordered := generate(1000, 100000)
+	duration := ordered[len(ordered)-1].Timestamp - ordered[0].Timestamp
+
+	return []Case{
+		{Name: "ordered", Packets: ordered},
+		{Name: "loss", Packets: dropPackets(generate(1000, 100000))},
+		{Name: "reorder", Packets: reorderPackets(generate(1000, 100000))},
+		// Both the sequence numbers and the timestamps wrap in the middle of the stream
+		{Name: "timestamp-wrap", Packets: generate(uint16(65536-len(ordered)/2), -(duration / 2))},
+	}, nil
+}
+
+// Golden returns the golden output of format for the case name.
+func Golden(format Format, name string) ([]byte, error) {
+	if _, ok := generators[format]; !ok {
+		return nil, fmt.Errorf("%w: %s", errUnknownFormat, format)
+	}
+
+	return goldens.ReadFile(goldenPath(format, name))
+}
+
+func goldenPath(format Format, name string) string {
+	return "testdata/" + string(format) + "/" + name + ".golden"
+}
+
+// Output writes the packets of c to a writer created by newWriter, closes it and returns
+// what was written. The writer is given an io.WriteSeeker, like a file. The packets
+// rejected by WriteRTP are skipped, as an application would, the golden outputs
+// only record what the writers make of the packets they accept.
+func Output(newWriter func(io.Writer) (media.Writer, error), c Case) ([]byte, error) {
+	out := &seekBuffer{}
+	writer, err := newWriter(out)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, packet := range c.Packets {
+		_ = writer.WriteRTP(packet)
+	}
+
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.buf, nil
+}
+
+// Check writes all the cases of format with writers created by newWriter, and returns
+// an error wrapping ErrOutputMismatch for the first case whose output differs from
+// the golden output.
+func Check(format Format, newWriter func(io.Writer) (media.Writer, error)) error {
+	cases, err := Cases(format)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range cases {
+		golden, err := Golden(format, c.Name)
+		if err != nil {
+			return err
+		}
+
+		output, err := Output(newWriter, c)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(output, golden) {
+			return fmt.Errorf("%w: %s %s: %s", ErrOutputMismatch, format, c.Name, firstDifference(output, golden))
+		}
+	}
+
+	return nil
+}
+
+func firstDifference(output, golden []byte) string {
+	for i := 0; i < len(output) && i < len(golden); i++ {
+		if output[i] != golden[i] {
+			return fmt.Sprintf("byte %d is 0x%02x, expected 0x%02x", i, output[i], golden[i])
+		}
+	}
+
+	return fmt.Sprintf("%d bytes, expected %d", len(output), len(golden))
+}
+
+// seekBuffer is an in-memory io.WriteSeeker.
+type seekBuffer struct {
+	buf    []byte
+	offset int64
+}
+
+func (b *seekBuffer) Write(p []byte) (int, error) {
+	if end := b.offset + int64(len(p)); end > int64(len(b.buf)) {
+		b.buf = append(b.buf, make([]byte, end-int64(len(b.buf)))...)
+	}
+	n := copy(b.buf[b.offset:], p)
+	b.offset += int64(n)
+
+	return n, nil
+}
+
+func (b *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += b.offset
+	case io.SeekEnd:
+		offset += int64(len(b.buf))
+	}
+	if offset < 0 {
+		return 0, errInvalidOffset
+	}
+	b.offset = offset
+
+	return offset, nil
+}
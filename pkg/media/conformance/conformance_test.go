@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package conformance
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/pion/webrtc/v4/pkg/media/h264writer"
+	"github.com/pion/webrtc/v4/pkg/media/h265writer"
+	"github.com/pion/webrtc/v4/pkg/media/ivfwriter"
+	"github.com/pion/webrtc/v4/pkg/media/oggwriter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files") //nolint:gochecknoglobals
+
+var writers = map[Format]func(io.Writer) (media.Writer, error){ //nolint:gochecknoglobals
+	FormatIVFVP8: func(w io.Writer) (media.Writer, error) {
+		return ivfwriter.NewWith(w, ivfwriter.WithCodec("video/VP8"))
+	},
+	FormatOggOpus: func(w io.Writer) (media.Writer, error) {
+		return oggwriter.NewWith(w, 48000, 2, oggwriter.WithSerial(OggSerial))
+	},
+	FormatH264: func(w io.Writer) (media.Writer, error) {
+		return h264writer.NewWith(w), nil
+	},
+	FormatH265: func(w io.Writer) (media.Writer, error) {
+		return h265writer.NewWith(w), nil
+	},
+}
+
+func TestWriters(t *testing.T) {
+	for _, format := range Formats() {
+		newWriter, ok := writers[format]
+		require.True(t, ok, format)
+
+		if *update {
+			cases, err := Cases(format)
+			require.NoError(t, err)
+			for _, c := range cases {
+				output, err := Output(newWriter, c)
+				require.NoError(t, err)
+
+				path := goldenPath(format, c.Name)
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+				require.NoError(t, os.WriteFile(path, output, 0o600))
+			}
+
+			continue
+		}
+
+		assert.NoError(t, Check(format, newWriter), format)
+	}
+}
+
+func TestCases(t *testing.T) {
+	cases, err := Cases(FormatIVFVP8)
+	require.NoError(t, err)
+	require.Len(t, cases, 4)
+
+	ordered := cases[0].Packets
+	assert.Less(t, len(cases[1].Packets), len(ordered))
+	assert.Equal(t, ordered[3].SequenceNumber, cases[2].Packets[2].SequenceNumber)
+
+	wrapped := cases[3].Packets
+	assert.Greater(t, wrapped[0].SequenceNumber, wrapped[len(wrapped)-1].SequenceNumber)
+	assert.Greater(t, wrapped[0].Timestamp, wrapped[len(wrapped)-1].Timestamp)
+
+	_, err = Cases("mp4")
+	assert.ErrorIs(t, err, errUnknownFormat)
+}
+
+func TestCheck_Mismatch(t *testing.T) {
+	err := Check(FormatIVFVP8, func(w io.Writer) (media.Writer, error) {
+		return ivfwriter.NewWith(w, ivfwriter.WithCodec("video/VP8"), ivfwriter.WithWidthAndHeight(1280, 720))
+	})
+	assert.ErrorIs(t, err, ErrOutputMismatch)
+}
@@ -0,0 +1,235 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package conformance
+
+import (
+	"encoding/binary"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	fixtureSSRC = 0x50494f4e
+
+	videoPayloadType = 96
+	opusPayloadType  = 111
+
+	videoFrames        = 8
+	videoFrameDuration = 3000 // 30 fps at 90 kHz
+	opusFrames         = 16
+	opusPacketDuration = 960 // 20 ms at 48 kHz
+)
+
+// generators create the packets of a format, starting at a sequence number and timestamp.
+var generators = map[Format]func(sequenceNumber uint16, timestamp uint32) []*rtp.Packet{ //nolint:gochecknoglobals
+	FormatIVFVP8:  vp8Packets,
+	FormatOggOpus: opusPackets,
+	FormatH264:    h264Packets,
+	FormatH265:    h265Packets,
+}
+
+// packetizer numbers the packets of a fixture.
+type packetizer struct {
+	payloadType    uint8
+	sequenceNumber uint16
+	packets        []*rtp.Packet
+}
+
+func (p *packetizer) add(timestamp uint32, marker bool, payload []byte) {
+	p.packets = append(p.packets, &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         marker,
+			PayloadType:    p.payloadType,
+			SequenceNumber: p.sequenceNumber,
+			Timestamp:      timestamp,
+			SSRC:           fixtureSSRC,
+		},
+		Payload: payload,
+	})
+	p.sequenceNumber++
+}
+
+// filler returns size deterministic bytes derived from seed.
+func filler(seed, size int) []byte {
+	b := make([]byte, size)
+	for i := range b {
+		b[i] = byte(seed*31 + i*7)
+	}
+
+	return b
+}
+
+// vp8Packets is a key frame followed by delta frames, each split into several packets.
+func vp8Packets(sequenceNumber uint16, timestamp uint32) []*rtp.Packet {
+	p := &packetizer{payloadType: videoPayloadType, sequenceNumber: sequenceNumber}
+	for frame := 0; frame < videoFrames; frame++ {
+		frameTimestamp := timestamp + uint32(frame*videoFrameDuration) //nolint:gosec // G115
+
+		fragments := 2
+		// Bit 0 of the first byte of the frame is 0 for key frames
+		frameHeader := byte(0x01)
+		if frame == 0 {
+			fragments = 3
+			frameHeader = 0x00
+		}
+
+		for fragment := 0; fragment < fragments; fragment++ {
+			// VP8 payload descriptor, S is set on the first packet of the frame
+			payload := []byte{0x00}
+			data := filler(frame*4+fragment, 40)
+			if fragment == 0 {
+				payload[0] = 0x10
+				data[0] = frameHeader
+			}
+			p.add(frameTimestamp, fragment == fragments-1, append(payload, data...))
+		}
+	}
+
+	return p.packets
+}
+
+// opusPackets is a packet per 20 ms frame.
+func opusPackets(sequenceNumber uint16, timestamp uint32) []*rtp.Packet {
+	p := &packetizer{payloadType: opusPayloadType, sequenceNumber: sequenceNumber}
+	for frame := 0; frame < opusFrames; frame++ {
+		// TOC of a 20 ms CELT fullband mono frame
+		payload := append([]byte{0xfc}, filler(frame, 30)...)
+		p.add(timestamp+uint32(frame*opusPacketDuration), false, payload) //nolint:gosec // G115
+	}
+
+	return p.packets
+}
+
+// h264Packets is an STAP-A with the SPS and PPS, an IDR fragmented with FU-A and
+// delta frames fragmented with FU-A or sent as single NAL units.
+func h264Packets(sequenceNumber uint16, timestamp uint32) []*rtp.Packet {
+	const (
+		naluTypeSlice = 1
+		naluTypeIDR   = 5
+		naluTypeSTAPA = 24
+		naluTypeFUA   = 28
+		nriBits       = 0x60
+	)
+
+	p := &packetizer{payloadType: videoPayloadType, sequenceNumber: sequenceNumber}
+
+	sps := append([]byte{0x67, 0x42, 0xc0, 0x1f}, filler(100, 8)...)
+	pps := append([]byte{0x68, 0xce}, filler(101, 2)...)
+	stapA := []byte{nriBits | naluTypeSTAPA}
+	for _, nalu := range [][]byte{sps, pps} {
+		stapA = binary.BigEndian.AppendUint16(stapA, uint16(len(nalu))) //nolint:gosec // G115
+		stapA = append(stapA, nalu...)
+	}
+	p.add(timestamp, false, stapA)
+
+	for frame := 0; frame < videoFrames; frame++ {
+		frameTimestamp := timestamp + uint32(frame*videoFrameDuration) //nolint:gosec // G115
+
+		naluType := byte(naluTypeSlice)
+		fragments := 2
+		if frame == 0 {
+			naluType = naluTypeIDR
+			fragments = 3
+		} else if frame%2 == 0 {
+			p.add(frameTimestamp, true, append([]byte{0x40 | naluType}, filler(frame*4, 40)...))
+
+			continue
+		}
+
+		for fragment := 0; fragment < fragments; fragment++ {
+			fuHeader := naluType
+			switch fragment {
+			case 0:
+				fuHeader |= 0x80
+			case fragments - 1:
+				fuHeader |= 0x40
+			}
+			payload := append([]byte{nriBits | naluTypeFUA, fuHeader}, filler(frame*4+fragment, 40)...)
+			p.add(frameTimestamp, fragment == fragments-1, payload)
+		}
+	}
+
+	return p.packets
+}
+
+// h265Packets is an aggregation packet with the VPS, SPS and PPS, an IDR fragmented
+// with FUs and delta frames fragmented with FUs or sent as single NAL units.
+func h265Packets(sequenceNumber uint16, timestamp uint32) []*rtp.Packet {
+	const (
+		naluTypeTrailR = 1
+		naluTypeIDR    = 19
+		naluTypeVPS    = 32
+		naluTypeSPS    = 33
+		naluTypePPS    = 34
+		naluTypeAP     = 48
+		naluTypeFU     = 49
+	)
+
+	// The second byte of the NAL unit header is the layer ID 0 and the temporal ID 1
+	naluHeader := func(naluType byte) []byte {
+		return []byte{naluType << 1, 0x01}
+	}
+
+	p := &packetizer{payloadType: videoPayloadType, sequenceNumber: sequenceNumber}
+
+	aggregation := naluHeader(naluTypeAP)
+	for i, naluType := range []byte{naluTypeVPS, naluTypeSPS, naluTypePPS} {
+		nalu := append(naluHeader(naluType), filler(100+i, 8)...)
+		aggregation = binary.BigEndian.AppendUint16(aggregation, uint16(len(nalu))) //nolint:gosec // G115
+		aggregation = append(aggregation, nalu...)
+	}
+	p.add(timestamp, false, aggregation)
+
+	for frame := 0; frame < videoFrames; frame++ {
+		frameTimestamp := timestamp + uint32(frame*videoFrameDuration) //nolint:gosec // G115
+
+		naluType := byte(naluTypeTrailR)
+		fragments := 2
+		if frame == 0 {
+			naluType = naluTypeIDR
+			fragments = 3
+		} else if frame%2 == 0 {
+			p.add(frameTimestamp, true, append(naluHeader(naluType), filler(frame*4, 40)...))
+
+			continue
+		}
+
+		for fragment := 0; fragment < fragments; fragment++ {
+			fuHeader := naluType
+			switch fragment {
+			case 0:
+				fuHeader |= 0x80
+			case fragments - 1:
+				fuHeader |= 0x40
+			}
+			payload := append(naluHeader(naluTypeFU), fuHeader)
+			p.add(frameTimestamp, fragment == fragments-1, append(payload, filler(frame*4+fragment, 40)...))
+		}
+	}
+
+	return p.packets
+}
+
+// dropPackets drops every fifth packet, the first packets carrying the parameter sets
+// and the start of the key frame are kept.
+func dropPackets(packets []*rtp.Packet) []*rtp.Packet {
+	kept := packets[:0]
+	for i, packet := range packets {
+		if i%5 != 4 {
+			kept = append(kept, packet)
+		}
+	}
+
+	return kept
+}
+
+// reorderPackets swaps the third and fourth packet of every group of four.
+func reorderPackets(packets []*rtp.Packet) []*rtp.Packet {
+	for i := 2; i+1 < len(packets); i += 4 {
+		packets[i], packets[i+1] = packets[i+1], packets[i]
+	}
+
+	return packets
+}
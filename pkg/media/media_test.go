@@ -2,3 +2,31 @@
 // SPDX-License-Identifier: MIT
 
 package media_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRTPTimestampFromPTS(t *testing.T) {
+	for _, test := range []struct {
+		pts                  int64
+		timescale, clockRate uint32
+		expected             uint32
+	}{
+		{pts: 90000, timescale: 90000, clockRate: 90000, expected: 90000},
+		{pts: 1001, timescale: 30000, clockRate: 90000, expected: 3003},
+		{pts: 1024, timescale: 44100, clockRate: 48000, expected: 1114},
+		{pts: -1, timescale: 90000, clockRate: 90000, expected: 1<<32 - 1},
+		{pts: 1 << 33, timescale: 90000, clockRate: 90000, expected: 0},
+		{pts: 1 << 62, timescale: 1000, clockRate: 90000, expected: 0},
+		{pts: 1, timescale: 0, clockRate: 90000, expected: 0},
+	} {
+		assert.Equal(t, test.expected, media.RTPTimestampFromPTS(test.pts, test.timescale, test.clockRate))
+	}
+
+	assert.Equal(t, uint32(4800), media.RTPTimestampFromDuration(100*time.Millisecond, 48000))
+}
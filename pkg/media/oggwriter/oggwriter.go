@@ -45,12 +45,12 @@ type OggWriter struct {
 }
 
 // New builds a new OGG Opus writer.
-func New(fileName string, sampleRate uint32, channelCount uint16) (*OggWriter, error) {
+func New(fileName string, sampleRate uint32, channelCount uint16, opts ...Option) (*OggWriter, error) {
 	file, err := os.Create(fileName) //nolint:gosec
 	if err != nil {
 		return nil, err
 	}
-	writer, err := NewWith(file, sampleRate, channelCount)
+	writer, err := NewWith(file, sampleRate, channelCount, opts...)
 	if err != nil {
 		return nil, file.Close()
 	}
@@ -60,7 +60,7 @@ func New(fileName string, sampleRate uint32, channelCount uint16) (*OggWriter, e
 }
 
 // NewWith initialize a new OGG Opus writer with an io.Writer output.
-func NewWith(out io.Writer, sampleRate uint32, channelCount uint16) (*OggWriter, error) {
+func NewWith(out io.Writer, sampleRate uint32, channelCount uint16, opts ...Option) (*OggWriter, error) {
 	if out == nil {
 		return nil, errFileNotOpened
 	}
@@ -77,6 +77,11 @@ func NewWith(out io.Writer, sampleRate uint32, channelCount uint16) (*OggWriter,
 		previousTimestamp:       1,
 		previousGranulePosition: 1,
 	}
+	for _, o := range opts {
+		if err := o(writer); err != nil {
+			return nil, err
+		}
+	}
 	if err := writer.writeHeaders(); err != nil {
 		return nil, err
 	}
@@ -284,3 +289,16 @@ func generateChecksumTable() *[256]uint32 {
 
 	return &table
 }
+
+// An Option configures an OggWriter.
+type Option func(i *OggWriter) error
+
+// WithSerial sets the serial number of the logical stream, instead of a random one.
+// A fixed serial makes the output reproducible, e.g. to compare it to a golden file.
+func WithSerial(serial uint32) Option {
+	return func(i *OggWriter) error {
+		i.serial = serial
+
+		return nil
+	}
+}
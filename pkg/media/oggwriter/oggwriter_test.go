@@ -134,6 +134,16 @@ func TestOggWriter_EmptyPayload(t *testing.T) {
 	assert.NoError(t, writer.WriteRTP(&rtp.Packet{Payload: []byte{}}))
 }
 
+func TestOggWriter_WithSerial(t *testing.T) {
+	buffer := &bytes.Buffer{}
+
+	_, err := NewWith(buffer, 48000, 2, WithSerial(0x01020304))
+	assert.NoError(t, err)
+
+	// The serial follows the signature, version, header type and granule position of the page
+	assert.Equal(t, []byte{0x04, 0x03, 0x02, 0x01}, buffer.Bytes()[14:18])
+}
+
 func TestOggWriter_LargePayload(t *testing.T) {
 	rawPkt := bytes.Repeat([]byte{0x45}, 1000)
 
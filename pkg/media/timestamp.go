@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package media
+
+import (
+	"math/big"
+	"time"
+)
+
+// RTPTimestampFromPTS converts a presentation timestamp counted in units of 1/timescale
+// seconds, like the PTS of MPEG-TS (timescale 90000) or MP4 tracks, to an RTP timestamp
+// of clockRate. The result is rounded down and wraps around like RTP timestamps do.
+func RTPTimestampFromPTS(pts int64, timescale, clockRate uint32) uint32 {
+	if timescale == 0 {
+		return 0
+	}
+
+	// pts * clockRate doesn't fit an int64 for large pts
+	ticks := new(big.Int).Mul(big.NewInt(pts), big.NewInt(int64(clockRate)))
+	ticks.Div(ticks, big.NewInt(int64(timescale)))
+
+	return uint32(new(big.Int).Mod(ticks, big.NewInt(1<<32)).Uint64()) //nolint:gosec // G115, reduced modulo 2^32
+}
+
+// RTPTimestampFromDuration converts a presentation timestamp relative to the start of
+// a stream to an RTP timestamp of clockRate.
+func RTPTimestampFromDuration(pts time.Duration, clockRate uint32) uint32 {
+	return RTPTimestampFromPTS(int64(pts), uint32(time.Second), clockRate)
+}
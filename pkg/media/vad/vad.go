@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package vad implements an energy based voice activity detector computing
+// the audio level carried in the header extension defined by RFC 6464.
+package vad
+
+import (
+	"math"
+	"sync"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+const (
+	// MinLevel is the level of digital silence, -127 dBov.
+	MinLevel = 127
+
+	defaultThreshold = 50
+	defaultHangover  = 10
+)
+
+// Level returns the audio level of 16-bit linear PCM samples in -dBov,
+// in the range 0 (loudest) to 127 (silence) as defined by RFC 6464.
+func Level(pcm []int16) uint8 {
+	if len(pcm) == 0 {
+		return MinLevel
+	}
+
+	var sum float64
+	for _, s := range pcm {
+		sum += float64(s) * float64(s)
+	}
+
+	rms := math.Sqrt(sum/float64(len(pcm))) / math.MaxInt16
+	if rms <= 0 {
+		return MinLevel
+	}
+
+	dBov := -20 * math.Log10(rms)
+	switch {
+	case dBov < 0:
+		return 0
+	case dBov > MinLevel:
+		return MinLevel
+	default:
+		return uint8(math.Round(dBov))
+	}
+}
+
+// EnergyDetector is a voice activity detector which considers any audio louder than
+// a threshold level as voice. Voice stays active for a number of frames after the
+// level dropped, so the gaps between words are not reported as silence.
+type EnergyDetector struct {
+	threshold uint8
+	hangover  int
+	decoder   func([]byte) []int16
+
+	mu        sync.Mutex
+	level     uint8
+	voice     bool
+	remaining int
+}
+
+// Option configures an EnergyDetector.
+type Option func(d *EnergyDetector)
+
+// WithThreshold sets the level in -dBov up to which a frame is considered voice, defaults to 50.
+func WithThreshold(threshold uint8) Option {
+	return func(d *EnergyDetector) {
+		d.threshold = threshold
+	}
+}
+
+// WithHangover sets how many frames voice stays active after the level dropped below the threshold,
+// defaults to 10.
+func WithHangover(frames int) Option {
+	return func(d *EnergyDetector) {
+		d.hangover = frames
+	}
+}
+
+// WithDecoder sets the function decoding the data of a media.Sample to 16-bit linear PCM,
+// which allows AudioLevel to analyze Samples directly, see DecodePCMU and DecodePCMA.
+func WithDecoder(decoder func([]byte) []int16) Option {
+	return func(d *EnergyDetector) {
+		d.decoder = decoder
+	}
+}
+
+// NewEnergyDetector creates a new EnergyDetector.
+func NewEnergyDetector(opts ...Option) *EnergyDetector {
+	d := &EnergyDetector{
+		threshold: defaultThreshold,
+		hangover:  defaultHangover,
+		level:     MinLevel,
+	}
+	for _, o := range opts {
+		o(d)
+	}
+
+	return d
+}
+
+// Process analyzes a frame of 16-bit linear PCM and returns its level and if it contains voice.
+// When the Samples are encoded with a codec that can't be decoded by the detector, every
+// frame should be processed before the corresponding Sample is written.
+func (d *EnergyDetector) Process(pcm []int16) (level uint8, voice bool) {
+	level = Level(pcm)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if level <= d.threshold {
+		d.remaining = d.hangover
+		d.voice = true
+	} else if d.remaining > 0 {
+		d.remaining--
+	} else {
+		d.voice = false
+	}
+	d.level = level
+
+	return d.level, d.voice
+}
+
+// AudioLevel returns the level of a Sample and if it contains voice. Without a decoder
+// the result of the last call to Process is returned.
+func (d *EnergyDetector) AudioLevel(sample media.Sample) (level uint8, voice bool) {
+	if d.decoder != nil {
+		return d.Process(d.decoder(sample.Data))
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.level, d.voice
+}
+
+// DecodePCMU decodes G.711 mu-law audio to 16-bit linear PCM.
+func DecodePCMU(data []byte) []int16 {
+	pcm := make([]int16, len(data))
+	for i, b := range data {
+		b = ^b
+		magnitude := ((int16(b&0x0F) << 3) + 0x84) << ((b & 0x70) >> 4)
+		if b&0x80 != 0 {
+			pcm[i] = 0x84 - magnitude
+		} else {
+			pcm[i] = magnitude - 0x84
+		}
+	}
+
+	return pcm
+}
+
+// DecodePCMA decodes G.711 A-law audio to 16-bit linear PCM.
+func DecodePCMA(data []byte) []int16 {
+	pcm := make([]int16, len(data))
+	for i, b := range data {
+		b ^= 0x55
+		magnitude := int16(b&0x0F)<<4 + 8
+		if exponent := (b & 0x70) >> 4; exponent != 0 {
+			magnitude = (magnitude + 0x100) << (exponent - 1)
+		}
+		if b&0x80 != 0 {
+			pcm[i] = magnitude
+		} else {
+			pcm[i] = -magnitude
+		}
+	}
+
+	return pcm
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package vad
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+func sine(amplitude float64, length int) []int16 {
+	pcm := make([]int16, length)
+	for i := range pcm {
+		pcm[i] = int16(amplitude * math.MaxInt16 * math.Sin(2*math.Pi*float64(i)/16))
+	}
+
+	return pcm
+}
+
+func TestLevel(t *testing.T) {
+	assert.Equal(t, uint8(MinLevel), Level(nil))
+	assert.Equal(t, uint8(MinLevel), Level(make([]int16, 160)))
+
+	// A full scale sine wave has an RMS of -3 dBov
+	assert.Equal(t, uint8(3), Level(sine(1, 160)))
+	assert.Equal(t, uint8(23), Level(sine(0.1, 160)))
+	assert.Equal(t, uint8(63), Level(sine(0.001, 160)))
+}
+
+func TestEnergyDetector(t *testing.T) {
+	detector := NewEnergyDetector(WithThreshold(40), WithHangover(2))
+
+	level, voice := detector.AudioLevel(media.Sample{})
+	assert.Equal(t, uint8(MinLevel), level)
+	assert.False(t, voice)
+
+	level, voice = detector.Process(sine(0.1, 160))
+	assert.Equal(t, uint8(23), level)
+	assert.True(t, voice)
+
+	// Voice is held during the hangover
+	for i := 0; i < 2; i++ {
+		_, voice = detector.Process(sine(0.001, 160))
+		assert.True(t, voice)
+	}
+
+	level, voice = detector.Process(sine(0.001, 160))
+	assert.Equal(t, uint8(63), level)
+	assert.False(t, voice)
+
+	level, voice = detector.AudioLevel(media.Sample{Data: []byte{0x00}})
+	assert.Equal(t, uint8(63), level)
+	assert.False(t, voice)
+}
+
+func TestEnergyDetectorWithDecoder(t *testing.T) {
+	detector := NewEnergyDetector(WithDecoder(DecodePCMU))
+
+	// 0xFF and 0x7F are the mu-law encodings of zero
+	level, voice := detector.AudioLevel(media.Sample{Data: []byte{0xFF, 0x7F, 0xFF, 0x7F}})
+	assert.Equal(t, uint8(MinLevel), level)
+	assert.False(t, voice)
+
+	// 0x00 and 0x80 are the loudest mu-law samples
+	level, voice = detector.AudioLevel(media.Sample{Data: []byte{0x00, 0x80, 0x00, 0x80}})
+	assert.Equal(t, uint8(0), level)
+	assert.True(t, voice)
+}
+
+func TestDecodeG711(t *testing.T) {
+	assert.Equal(t, []int16{-32124, 32124, 0, 0}, DecodePCMU([]byte{0x00, 0x80, 0x7F, 0xFF}))
+	assert.Equal(t, []int16{-5504, 5504, -8, 8, 32256, -32256}, DecodePCMA([]byte{0x00, 0x80, 0x55, 0xD5, 0xAA, 0x2A}))
+}
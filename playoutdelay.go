@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	// playoutDelayGranularity is the unit of the delays of the playout delay header extension.
+	playoutDelayGranularity = 10 * time.Millisecond
+	// playoutDelayMax is the largest delay the 12 bits of the extension can carry.
+	playoutDelayMax = 4095 * playoutDelayGranularity
+)
+
+// PlayoutDelay is the range of delay between the capture and the render of the frames
+// of a video track a sender asks the receiver for, with the playout delay header extension
+// http://www.webrtc.org/experiments/rtp-hdrext/playout-delay. A Min and Max of 0
+// asks the receiver to render frames as soon as they are decoded, without buffering.
+type PlayoutDelay struct {
+	Min, Max time.Duration
+}
+
+// marshal returns the payload of the header extension. The delays are rounded down
+// to the 10 ms granularity of the extension.
+func (d PlayoutDelay) marshal() ([]byte, error) {
+	if d.Min < 0 || d.Max < d.Min || d.Max > playoutDelayMax {
+		return nil, errPlayoutDelayInvalid
+	}
+
+	return rtp.PlayoutDelayExtension{
+		MinDelay: uint16(d.Min / playoutDelayGranularity), //nolint:gosec // G115, checked above
+		MaxDelay: uint16(d.Max / playoutDelayGranularity), //nolint:gosec // G115, checked above
+	}.Marshal()
+}
+
+// unmarshalPlayoutDelay parses the delays of the header extension payload.
+func unmarshalPlayoutDelay(payload []byte) (PlayoutDelay, error) {
+	extension := rtp.PlayoutDelayExtension{}
+	if err := extension.Unmarshal(payload); err != nil {
+		return PlayoutDelay{}, err
+	}
+
+	return PlayoutDelay{
+		Min: time.Duration(extension.MinDelay) * playoutDelayGranularity,
+		Max: time.Duration(extension.MaxDelay) * playoutDelayGranularity,
+	}, nil
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlayoutDelay_Marshal(t *testing.T) {
+	payload, err := PlayoutDelay{Min: 0, Max: 105 * time.Millisecond}.marshal()
+	require.NoError(t, err)
+
+	delay, err := unmarshalPlayoutDelay(payload)
+	require.NoError(t, err)
+	assert.Equal(t, PlayoutDelay{Min: 0, Max: 100 * time.Millisecond}, delay)
+
+	for _, invalid := range []PlayoutDelay{
+		{Min: -time.Millisecond},
+		{Min: time.Second, Max: 500 * time.Millisecond},
+		{Max: time.Minute},
+	} {
+		_, err = invalid.marshal()
+		assert.ErrorIs(t, err, errPlayoutDelayInvalid)
+	}
+
+	_, err = unmarshalPlayoutDelay([]byte{0x00})
+	assert.Error(t, err)
+}
+
+func TestPeerConnection_PlayoutDelay(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newPeerConnection := func() *PeerConnection {
+		mediaEngine := &MediaEngine{}
+		require.NoError(t, mediaEngine.RegisterDefaultCodecs())
+		require.NoError(t, ConfigurePlayoutDelayHeaderExtension(mediaEngine))
+
+		pc, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		return pc
+	}
+	pcOffer, pcAnswer := newPeerConnection(), newPeerConnection()
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	delay := PlayoutDelay{Min: 0, Max: 50 * time.Millisecond}
+	assert.ErrorIs(t, sender.SetPlayoutDelay(&PlayoutDelay{Min: time.Second}), errPlayoutDelayInvalid)
+	require.NoError(t, sender.SetPlayoutDelay(&delay))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		for {
+			if _, _, readErr := track.ReadRTP(); readErr != nil {
+				return
+			}
+
+			if received, ok := track.PlayoutDelay(); ok {
+				assert.Equal(t, delay, received)
+				cancel()
+
+				return
+			}
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, ctx.Done(), []*TrackLocalStaticSample{track})
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/randutil"
+	"github.com/pion/transport/v3"
+	"github.com/pion/transport/v3/stdnet"
+)
+
+// PortRange is a range of ports, from Min to Max included. The zero PortRange leaves
+// the choice of the port to the OS.
+type PortRange struct {
+	Min uint16
+	Max uint16
+}
+
+// EphemeralPortRanges are the ranges of the local ports ICE allocates from, by use,
+// see SettingEngine.SetEphemeralPortRanges.
+type EphemeralPortRanges struct {
+	// UDP is the range of the UDP host candidates and of the local address of the server
+	// reflexive candidates, as set by SetEphemeralUDPPortRange.
+	UDP PortRange
+	// TURNTCP is the range of the TCP connections dialed to the TURN servers over TCP
+	// and TLS. It doesn't apply to ICE-TCP: the passive candidates use the port of the
+	// listener of SetICETCPMux, and the active ones are dialed by ICE from an OS
+	// ephemeral port, see DisableActiveTCP.
+	TURNTCP PortRange
+	// Relay is the range of the UDP sockets allocating relays on the TURN servers over
+	// UDP and DTLS.
+	Relay PortRange
+}
+
+func (r PortRange) isZero() bool {
+	return r == PortRange{}
+}
+
+func (r PortRange) validate() error {
+	if r.Max < r.Min {
+		return ice.ErrPort
+	}
+
+	return nil
+}
+
+// portRangeNet binds the sockets ICE creates with the OS choosing their port, the
+// relay and TURN TCP ones, to a port of their range.
+type portRangeNet struct {
+	transport.Net
+
+	turnTCP PortRange
+	relay   PortRange
+}
+
+func newPortRangeNet(n transport.Net, turnTCP, relay PortRange) (transport.Net, error) {
+	if n == nil {
+		var err error
+		if n, err = stdnet.NewNet(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &portRangeNet{Net: n, turnTCP: turnTCP, relay: relay}, nil
+}
+
+func (n *portRangeNet) ListenPacket(network string, address string) (net.PacketConn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || port != "0" || !strings.HasPrefix(network, "udp") || n.relay.isZero() {
+		return n.Net.ListenPacket(network, address)
+	}
+
+	var conn net.PacketConn
+	err = n.relay.forEachPort(func(port uint16) (listenErr error) {
+		conn, listenErr = n.Net.ListenPacket(network, net.JoinHostPort(host, strconv.Itoa(int(port))))
+
+		return listenErr
+	})
+
+	return conn, err
+}
+
+func (n *portRangeNet) DialUDP(network string, laddr, raddr *net.UDPAddr) (transport.UDPConn, error) {
+	if (laddr != nil && laddr.Port != 0) || n.relay.isZero() {
+		return n.Net.DialUDP(network, laddr, raddr)
+	}
+
+	var conn transport.UDPConn
+	err := n.relay.forEachPort(func(port uint16) (dialErr error) {
+		addr := &net.UDPAddr{Port: int(port)}
+		if laddr != nil {
+			addr.IP, addr.Zone = laddr.IP, laddr.Zone
+		}
+		conn, dialErr = n.Net.DialUDP(network, addr, raddr)
+
+		return dialErr
+	})
+
+	return conn, err
+}
+
+func (n *portRangeNet) DialTCP(network string, laddr, raddr *net.TCPAddr) (transport.TCPConn, error) {
+	if (laddr != nil && laddr.Port != 0) || n.turnTCP.isZero() {
+		return n.Net.DialTCP(network, laddr, raddr)
+	}
+
+	var conn transport.TCPConn
+	err := n.turnTCP.forEachPort(func(port uint16) (dialErr error) {
+		addr := &net.TCPAddr{Port: int(port)}
+		if laddr != nil {
+			addr.IP, addr.Zone = laddr.IP, laddr.Zone
+		}
+		conn, dialErr = n.Net.DialTCP(network, addr, raddr)
+
+		return dialErr
+	})
+
+	return conn, err
+}
+
+// forEachPort calls f with the ports of the range from a random one while the port
+// is already in use, returning the last error.
+func (r PortRange) forEachPort(f func(port uint16) error) error {
+	portMin, portMax := int(r.Min), int(r.Max)
+	if portMin == 0 {
+		portMin = 1
+	}
+	if portMax == 0 {
+		portMax = 0xFFFF
+	}
+
+	size := portMax - portMin + 1
+	start := int(randutil.NewMathRandomGenerator().Intn(size))
+
+	var err error
+	for i := 0; i < size; i++ {
+		// Only the binding is retried, not the failures to reach the remote address
+		if err = f(uint16(portMin + (start+i)%size)); !errors.Is(err, syscall.EADDRINUSE) { //nolint:gosec // G115
+			return err
+		}
+	}
+
+	return err
+}
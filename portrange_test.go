@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// freePorts returns a UDP and a TCP port free on the loopback address.
+func freePorts(t *testing.T) (uint16, uint16) {
+	t.Helper()
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	udpPort := conn.LocalAddr().(*net.UDPAddr).Port //nolint:forcetypeassert
+	require.NoError(t, conn.Close())
+
+	listener, err := net.ListenTCP("tcp4", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	tcpPort := listener.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert
+	require.NoError(t, listener.Close())
+
+	return uint16(udpPort), uint16(tcpPort) //nolint:gosec // G115
+}
+
+func TestPortRangeNet(t *testing.T) {
+	relayPort, tcpPort := freePorts(t)
+	rangeNet, err := newPortRangeNet(nil, PortRange{Min: tcpPort, Max: tcpPort}, PortRange{Min: relayPort, Max: relayPort})
+	require.NoError(t, err)
+
+	// The relay sockets bind a port of the relay range
+	conn, err := rangeNet.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	assert.Equal(t, int(relayPort), conn.LocalAddr().(*net.UDPAddr).Port) //nolint:forcetypeassert
+
+	_, err = rangeNet.ListenPacket("udp4", "127.0.0.1:0")
+	assert.ErrorIs(t, err, syscall.EADDRINUSE)
+
+	udpConn, err := rangeNet.DialUDP("udp4", nil, conn.LocalAddr().(*net.UDPAddr)) //nolint:forcetypeassert
+	assert.ErrorIs(t, err, syscall.EADDRINUSE)
+	assert.Nil(t, udpConn)
+	assert.NoError(t, conn.Close())
+
+	// The TCP connections are dialed from a port of the TURN TCP range
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	tcpConn, err := rangeNet.DialTCP("tcp4", nil, listener.Addr().(*net.TCPAddr)) //nolint:forcetypeassert
+	require.NoError(t, err)
+	assert.Equal(t, int(tcpPort), tcpConn.LocalAddr().(*net.TCPAddr).Port) //nolint:forcetypeassert
+	assert.NoError(t, tcpConn.Close())
+	assert.NoError(t, listener.Close())
+}
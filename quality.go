@@ -0,0 +1,358 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor/pkg/stats"
+)
+
+// QualityLevel summarizes a quality score, see Quality.
+type QualityLevel int
+
+const (
+	// QualityLevelUnknown is the enum's zero-value, used until the PeerConnection
+	// has selected a candidate pair.
+	QualityLevelUnknown QualityLevel = iota
+
+	// QualityLevelPoor indicates the media is likely unusable.
+	QualityLevelPoor
+
+	// QualityLevelFair indicates noticeable degradations, like frozen video
+	// or choppy audio.
+	QualityLevelFair
+
+	// QualityLevelGood indicates minor degradations.
+	QualityLevelGood
+
+	// QualityLevelExcellent indicates no degradation.
+	QualityLevelExcellent
+)
+
+const (
+	qualityLevelPoorStr      = "poor"
+	qualityLevelFairStr      = "fair"
+	qualityLevelGoodStr      = "good"
+	qualityLevelExcellentStr = "excellent"
+)
+
+func (l QualityLevel) String() string {
+	switch l {
+	case QualityLevelPoor:
+		return qualityLevelPoorStr
+	case QualityLevelFair:
+		return qualityLevelFairStr
+	case QualityLevelGood:
+		return qualityLevelGoodStr
+	case QualityLevelExcellent:
+		return qualityLevelExcellentStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// Quality is a score computed from the network conditions of a PeerConnection or
+// of one of its tracks.
+type Quality struct {
+	// Score goes from 0, unusable, to 100, no degradation. Each of the metrics
+	// lowers it once it exceeds what is unnoticeable in a call.
+	Score int
+	Level QualityLevel
+
+	RoundTripTime time.Duration
+	// PacketLoss is the fraction of the packets lost, between 0 and 1.
+	PacketLoss float64
+	Jitter     time.Duration
+}
+
+// TrackQuality is the Quality of a stream sent or received by a PeerConnection.
+type TrackQuality struct {
+	Quality
+
+	TrackID string
+	RID     string
+	Kind    RTPCodecType
+	SSRC    SSRC
+	// Direction is RTPTransceiverDirectionSendonly for the local tracks and
+	// RTPTransceiverDirectionRecvonly for the remote ones.
+	Direction RTPTransceiverDirection
+}
+
+// ConnectionQuality is the Quality of a PeerConnection, see PeerConnection.Quality.
+type ConnectionQuality struct {
+	// Quality combines the worst metrics of the tracks with the bandwidth headroom.
+	Quality
+
+	// BandwidthHeadroom is the ratio of the bitrate estimated by the congestion
+	// controller to the bitrate sent, below 1 more is sent than the path is
+	// estimated to carry. It is 0 without a congestion controller, see
+	// ConfigureCongestionController.
+	BandwidthHeadroom float64
+
+	Tracks []TrackQuality
+}
+
+// qualityCounters are the counters of a received stream at the previous evaluation.
+type qualityCounters struct {
+	packetsReceived uint64
+	packetsLost     int64
+}
+
+// qualityWindow is what the periodic evaluations of monitorQuality are relative to,
+// it is owned by monitorQuality.
+type qualityWindow struct {
+	received map[uint32]qualityCounters
+}
+
+// qualityMonitor keeps the last evaluation of monitorQuality.
+type qualityMonitor struct {
+	mu   sync.Mutex
+	last *ConnectionQuality
+
+	startOnce sync.Once
+}
+
+// newQuality scores the metrics, the penalty of each one grows linearly from its
+// threshold.
+func newQuality(roundTripTime, jitter time.Duration, packetLoss float64) Quality {
+	quality := Quality{RoundTripTime: roundTripTime, PacketLoss: packetLoss, Jitter: jitter}
+
+	penalty := packetLoss * qualityPacketLossPenalty
+	if roundTripTime > qualityRoundTripTimeThreshold {
+		penalty += float64(roundTripTime-qualityRoundTripTimeThreshold) / float64(time.Second) *
+			qualityRoundTripTimePenalty
+	}
+	if jitter > qualityJitterThreshold {
+		penalty += float64(jitter-qualityJitterThreshold) / float64(time.Second) * qualityJitterPenalty
+	}
+	quality.setScore(100 - penalty)
+
+	return quality
+}
+
+func (q *Quality) setScore(score float64) {
+	q.Score = int(max(0, min(100, score)))
+	switch {
+	case q.Score >= 80:
+		q.Level = QualityLevelExcellent
+	case q.Score >= 60:
+		q.Level = QualityLevelGood
+	case q.Score >= 40:
+		q.Level = QualityLevelFair
+	default:
+		q.Level = QualityLevelPoor
+	}
+}
+
+// Quality evaluates the quality of the PeerConnection and of its tracks from the
+// round trip time, the packet loss, the jitter and the bandwidth headroom. The
+// packet loss of the received tracks is counted since they started, unlike the
+// evaluations of OnQualityChange which count it since the previous one, and calling
+// Quality doesn't affect them. The metrics of the tracks come from the RTCP reports,
+// the stats interceptor must be registered for them, see ConfigureStatsInterceptor.
+// Without reports the round trip time of the selected candidate pair is used. The
+// Level is unknown until a candidate pair is selected.
+func (pc *PeerConnection) Quality() ConnectionQuality {
+	return pc.evaluateQuality(nil)
+}
+
+// evaluateQuality evaluates the quality, with the packet loss counted since the previous
+// evaluation of window, or since the tracks started if window is nil.
+func (pc *PeerConnection) evaluateQuality(window *qualityWindow) ConnectionQuality {
+	pairStats, ok := pc.SelectedCandidatePairStats()
+	if !ok {
+		return ConnectionQuality{}
+	}
+	pairRoundTripTime := time.Duration(pairStats.CurrentRoundTripTime * float64(time.Second))
+
+	connection := ConnectionQuality{}
+	for _, transceiver := range pc.GetTransceivers() {
+		if sender := transceiver.Sender(); sender != nil {
+			connection.Tracks = append(connection.Tracks, pc.sentTracksQuality(sender, pairRoundTripTime)...)
+		}
+		if receiver := transceiver.Receiver(); receiver != nil {
+			connection.Tracks = append(connection.Tracks,
+				pc.receivedTracksQuality(receiver, pairRoundTripTime, window)...)
+		}
+	}
+
+	roundTripTime, jitter, packetLoss := pairRoundTripTime, time.Duration(0), 0.0
+	for _, track := range connection.Tracks {
+		roundTripTime = max(roundTripTime, track.RoundTripTime)
+		jitter = max(jitter, track.Jitter)
+		packetLoss = max(packetLoss, track.PacketLoss)
+	}
+	connection.Quality = newQuality(roundTripTime, jitter, packetLoss)
+
+	if pc.bandwidthEstimator != nil && pairStats.SendBitrate > 0 {
+		connection.BandwidthHeadroom = float64(pc.bandwidthEstimator.GetTargetBitrate()) / pairStats.SendBitrate
+		if connection.BandwidthHeadroom < 1 {
+			connection.setScore(float64(connection.Score) -
+				(1-connection.BandwidthHeadroom)*qualityBandwidthHeadroomPenalty)
+		}
+	}
+
+	return connection
+}
+
+// sentTracksQuality returns the quality of the encodings sent by sender, reported
+// by the Receiver Reports of the remote.
+func (pc *PeerConnection) sentTracksQuality(sender *RTPSender, pairRoundTripTime time.Duration) []TrackQuality {
+	if !sender.hasSent() || sender.hasStopped() {
+		return nil
+	}
+
+	sender.mu.RLock()
+	defer sender.mu.RUnlock()
+
+	var tracks []TrackQuality
+	for _, encoding := range sender.trackEncodings {
+		if encoding.track == nil {
+			continue
+		}
+
+		roundTripTime, jitter, packetLoss := pairRoundTripTime, time.Duration(0), 0.0
+		if interceptorStats := pc.getInterceptorStats(uint32(encoding.ssrc)); interceptorStats != nil {
+			remoteInbound := interceptorStats.RemoteInboundRTPStreamStats
+			if remoteInbound.RoundTripTimeMeasurements != 0 {
+				roundTripTime = remoteInbound.RoundTripTime
+			}
+			jitter = time.Duration(remoteInbound.Jitter * float64(time.Second))
+			packetLoss = remoteInbound.FractionLost
+		}
+
+		tracks = append(tracks, TrackQuality{
+			Quality:   newQuality(roundTripTime, jitter, packetLoss),
+			TrackID:   encoding.track.ID(),
+			RID:       encoding.track.RID(),
+			Kind:      sender.kind,
+			SSRC:      encoding.ssrc,
+			Direction: RTPTransceiverDirectionSendonly,
+		})
+	}
+
+	return tracks
+}
+
+// receivedTracksQuality returns the quality of the tracks received by receiver,
+// their packet loss is counted since the previous evaluation of window if any.
+func (pc *PeerConnection) receivedTracksQuality(
+	receiver *RTPReceiver,
+	pairRoundTripTime time.Duration,
+	window *qualityWindow,
+) []TrackQuality {
+	var tracks []TrackQuality
+	for _, track := range receiver.Tracks() {
+		ssrc := uint32(track.SSRC())
+		if ssrc == 0 {
+			continue
+		}
+
+		roundTripTime, jitter, packetLoss := pairRoundTripTime, time.Duration(0), 0.0
+		if interceptorStats := pc.getInterceptorStats(ssrc); interceptorStats != nil {
+			inbound := interceptorStats.InboundRTPStreamStats
+			jitter = time.Duration(inbound.Jitter * float64(time.Second))
+			if interceptorStats.RemoteOutboundRTPStreamStats.RoundTripTimeMeasurements != 0 {
+				roundTripTime = interceptorStats.RemoteOutboundRTPStreamStats.RoundTripTime
+			}
+
+			var previous qualityCounters
+			if window != nil {
+				previous = window.received[ssrc]
+				window.received[ssrc] = qualityCounters{
+					packetsReceived: inbound.PacketsReceived,
+					packetsLost:     inbound.PacketsLost,
+				}
+			}
+			received := int64(inbound.PacketsReceived) - int64(previous.packetsReceived) //nolint:gosec // G115
+			lost := inbound.PacketsLost - previous.packetsLost
+			if lost > 0 && received+lost > 0 {
+				packetLoss = float64(lost) / float64(received+lost)
+			}
+		}
+
+		tracks = append(tracks, TrackQuality{
+			Quality:   newQuality(roundTripTime, jitter, packetLoss),
+			TrackID:   track.ID(),
+			RID:       track.RID(),
+			Kind:      track.Kind(),
+			SSRC:      track.SSRC(),
+			Direction: RTPTransceiverDirectionRecvonly,
+		})
+	}
+
+	return tracks
+}
+
+func (pc *PeerConnection) getInterceptorStats(ssrc uint32) *stats.Stats {
+	if pc.statsGetter == nil {
+		return nil
+	}
+
+	return pc.statsGetter.Get(ssrc)
+}
+
+// OnQualityChange sets an event handler which is called when the Level of the
+// PeerConnection or of one of its tracks changes, or when a track is added or
+// removed. The quality is evaluated every interval set with
+// SettingEngine.SetQualityInterval, every 2 seconds by default.
+func (pc *PeerConnection) OnQualityChange(f func(ConnectionQuality)) {
+	pc.onQualityChangeHandler.Store(f)
+	pc.quality.startOnce.Do(func() {
+		go pc.monitorQuality()
+	})
+}
+
+func (pc *PeerConnection) monitorQuality() {
+	interval := pc.api.settingEngine.qualityInterval
+	if interval <= 0 {
+		interval = defaultQualityInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	window := &qualityWindow{received: map[uint32]qualityCounters{}}
+	for {
+		select {
+		case <-pc.isCloseDone:
+			return
+		case <-ticker.C:
+			pc.onQualityChange(pc.evaluateQuality(window))
+		}
+	}
+}
+
+func (pc *PeerConnection) onQualityChange(quality ConnectionQuality) {
+	pc.quality.mu.Lock()
+	changed := pc.quality.last == nil || !sameQualityLevels(*pc.quality.last, quality)
+	pc.quality.last = &quality
+	pc.quality.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	if handler, ok := pc.onQualityChangeHandler.Load().(func(ConnectionQuality)); ok && handler != nil {
+		handler(quality)
+	}
+}
+
+// sameQualityLevels returns true if a and b have the same tracks, and the same
+// levels for the PeerConnection and each track.
+func sameQualityLevels(a, b ConnectionQuality) bool {
+	if a.Level != b.Level || len(a.Tracks) != len(b.Tracks) {
+		return false
+	}
+	for i := range a.Tracks {
+		if a.Tracks[i].SSRC != b.Tracks[i].SSRC || a.Tracks[i].Level != b.Tracks[i].Level {
+			return false
+		}
+	}
+
+	return true
+}
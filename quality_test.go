@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewQuality(t *testing.T) {
+	for _, testCase := range []struct {
+		name          string
+		roundTripTime time.Duration
+		jitter        time.Duration
+		packetLoss    float64
+		score         int
+		level         QualityLevel
+	}{
+		{"Perfect", 20 * time.Millisecond, 5 * time.Millisecond, 0, 100, QualityLevelExcellent},
+		{"PacketLoss", 20 * time.Millisecond, 0, 0.05, 75, QualityLevelGood},
+		{"RoundTripTime", 450 * time.Millisecond, 0, 0, 50, QualityLevelFair},
+		{"Jitter", 0, 130 * time.Millisecond, 0.01, 70, QualityLevelGood},
+		{"Unusable", time.Second, 200 * time.Millisecond, 0.2, 0, QualityLevelPoor},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			quality := newQuality(testCase.roundTripTime, testCase.jitter, testCase.packetLoss)
+			assert.Equal(t, testCase.score, quality.Score)
+			assert.Equal(t, testCase.level, quality.Level)
+			assert.Equal(t, testCase.roundTripTime, quality.RoundTripTime)
+		})
+	}
+
+	assert.Equal(t, "good", QualityLevelGood.String())
+	assert.Equal(t, ErrUnknownType.Error(), QualityLevelUnknown.String())
+}
+
+func TestPeerConnection_Quality(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetQualityInterval(50 * time.Millisecond)
+	pcOffer, pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).newPair(Configuration{})
+	require.NoError(t, err)
+
+	assert.Equal(t, QualityLevelUnknown, pcOffer.Quality().Level)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	trackFired, trackFiredFunc := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(*TrackRemote, *RTPReceiver) {
+		trackFiredFunc()
+	})
+
+	qualityChanged := make(chan ConnectionQuality, 1)
+	pcAnswer.OnQualityChange(func(quality ConnectionQuality) {
+		if len(quality.Tracks) == 0 {
+			return
+		}
+		select {
+		case qualityChanged <- quality:
+		default:
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, trackFired.Done(), []*TrackLocalStaticSample{track})
+
+	offerQuality := pcOffer.Quality()
+	assert.Equal(t, QualityLevelExcellent, offerQuality.Level)
+	if assert.Len(t, offerQuality.Tracks, 1) {
+		assert.Equal(t, "video", offerQuality.Tracks[0].TrackID)
+		assert.Equal(t, RTPCodecTypeVideo, offerQuality.Tracks[0].Kind)
+		assert.Equal(t, RTPTransceiverDirectionSendonly, offerQuality.Tracks[0].Direction)
+		assert.Equal(t, QualityLevelExcellent, offerQuality.Tracks[0].Level)
+	}
+
+	answerQuality := <-qualityChanged
+	assert.Equal(t, QualityLevelExcellent, answerQuality.Level)
+	if assert.Len(t, answerQuality.Tracks, 1) {
+		assert.Equal(t, "video", answerQuality.Tracks[0].TrackID)
+		assert.Equal(t, RTPTransceiverDirectionRecvonly, answerQuality.Tracks[0].Direction)
+	}
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
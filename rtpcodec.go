@@ -59,6 +59,30 @@ type RTPCodecCapability struct {
 	RTCPFeedback []RTCPFeedback
 }
 
+// OpusChannelConfig is the channel configuration signaled in the fmtp line of an Opus codec.
+//
+// https://datatracker.ietf.org/doc/html/rfc7587#section-6.1
+type OpusChannelConfig struct {
+	// Stereo is set when the endpoint signaling the codec prefers to receive stereo
+	Stereo bool
+	// SpropStereo is set when the endpoint signaling the codec is likely to send stereo
+	SpropStereo bool
+}
+
+// OpusChannelConfig returns the channel configuration signaled by an Opus codec.
+// Once negotiated, the codecs of an RTPSender tell if the remote peer prefers to
+// receive stereo, and the codec of a TrackRemote if the remote peer sends stereo.
+func (c RTPCodecCapability) OpusChannelConfig() OpusChannelConfig {
+	parsed := fmtp.Parse(c.MimeType, c.ClockRate, c.Channels, c.SDPFmtpLine)
+	stereo, _ := parsed.Parameter(opusStereoParameter)
+	spropStereo, _ := parsed.Parameter(opusSpropStereoParameter)
+
+	return OpusChannelConfig{
+		Stereo:      stereo == "1",
+		SpropStereo: spropStereo == "1",
+	}
+}
+
 // RTPHeaderExtensionCapability is used to define a RFC5285 RTP header extension supported by the codec.
 //
 // https://w3c.github.io/webrtc-pc/#dom-rtcrtpcapabilities-headerextensions
@@ -212,6 +236,33 @@ func findFECPayloadType(haystack []RTPCodecParameters) PayloadType {
 	return PayloadType(0)
 }
 
+// withStereoParameters replaces the stereo parameters of an Opus fmtp line with the ones of local.
+func withStereoParameters(line, local string) string {
+	if line == local {
+		return line
+	}
+
+	isStereoParameter := func(parameter string) bool {
+		key := strings.ToLower(strings.TrimSpace(strings.SplitN(parameter, "=", 2)[0]))
+
+		return key == opusStereoParameter || key == opusSpropStereoParameter
+	}
+
+	parameters := []string{}
+	for _, parameter := range strings.Split(line, ";") {
+		if strings.TrimSpace(parameter) != "" && !isStereoParameter(parameter) {
+			parameters = append(parameters, parameter)
+		}
+	}
+	for _, parameter := range strings.Split(local, ";") {
+		if isStereoParameter(parameter) {
+			parameters = append(parameters, strings.TrimSpace(parameter))
+		}
+	}
+
+	return strings.Join(parameters, ";")
+}
+
 func rtcpFeedbackIntersection(a, b []RTCPFeedback) (out []RTCPFeedback) {
 	for _, aFeedback := range a {
 		for _, bFeeback := range b {
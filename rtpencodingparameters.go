@@ -8,4 +8,12 @@ package webrtc
 // http://draft.ortc.org/#dom-rtcrtpencodingparameters
 type RTPEncodingParameters struct {
 	RTPCodingParameters
+
+	// Active is false when the sending of the encoding is paused, see RTPSender.SetParameters
+	Active bool `json:"active"`
+	// MaxBitrate is the maximum bitrate in bits per second of the encoding, 0 means unlimited
+	MaxBitrate uint64 `json:"maxBitrate"`
+	// ScaleResolutionDownBy is the factor the resolution of the encoding is scaled down by.
+	// Pion WebRTC doesn't encode, applications have to scale the frames they write.
+	ScaleResolutionDownBy float64 `json:"scaleResolutionDownBy"`
 }
@@ -10,7 +10,10 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"log/slog"
 	"math"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,7 +21,9 @@ import (
 	"github.com/pion/interceptor/pkg/stats"
 	"github.com/pion/logging"
 	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
 	"github.com/pion/srtp/v3"
+	"github.com/pion/transport/v3/deadline"
 	"github.com/pion/webrtc/v4/internal/util"
 )
 
@@ -41,6 +46,53 @@ type trackStreams struct {
 
 	repairRtcpReadStream  *srtp.ReadStreamSRTCP
 	repairRtcpInterceptor interceptor.RTCPReader
+
+	// The FlexFEC stream protecting the track, its recovered packets are
+	// delivered through repairStreamChannel
+	fecStreamInfo      *interceptor.StreamInfo
+	fecReadStream      *srtp.ReadStreamSRTP
+	fecInterceptor     interceptor.RTPReader
+	fecRtcpReadStream  *srtp.ReadStreamSRTCP
+	fecRtcpInterceptor interceptor.RTCPReader
+	fecDecoder         *fecDecoder
+
+	// rtpReadDeadline is the read deadline of the track, kept to be applied to
+	// rtpReadStream once it is opened.
+	rtpReadDeadline *deadline.Deadline
+}
+
+// setRTPReadDeadline sets the read deadline of the track and of its RTP stream if it is opened.
+func (t *trackStreams) setRTPReadDeadline(readDeadline time.Time) error {
+	if t.rtpReadDeadline != nil {
+		t.rtpReadDeadline.Set(readDeadline)
+	}
+	if t.rtpReadStream != nil {
+		return t.rtpReadStream.SetReadDeadline(readDeadline)
+	}
+
+	return nil
+}
+
+// applyRTPReadDeadline applies the read deadline of the track to its newly opened RTP stream.
+func (t *trackStreams) applyRTPReadDeadline() error {
+	if t.rtpReadDeadline == nil {
+		return nil
+	}
+	if readDeadline, ok := t.rtpReadDeadline.Deadline(); ok {
+		return t.rtpReadStream.SetReadDeadline(readDeadline)
+	}
+
+	return nil
+}
+
+// rtpReadDeadlineDone returns a channel closed once the read deadline of the track
+// has expired, nil if the track has no deadline as it never fires.
+func (t *trackStreams) rtpReadDeadlineDone() <-chan struct{} {
+	if t.rtpReadDeadline == nil {
+		return nil
+	}
+
+	return t.rtpReadDeadline.Done()
 }
 
 type rtxPacketWithAttributes struct {
@@ -67,6 +119,10 @@ type RTPReceiver struct {
 	closed, received chan any
 	mu               sync.RWMutex
 
+	// rtcpReadDeadline is the deadline set with SetReadDeadline, kept to time out
+	// the reads before Receive and to be applied to the RTCP stream once opened.
+	rtcpReadDeadline *deadline.Deadline
+
 	tr *RTPTransceiver
 
 	// A reference to the associated api object
@@ -74,6 +130,12 @@ type RTPReceiver struct {
 
 	rtxPool sync.Pool
 
+	// Interceptors attached to this RTPReceiver only, see AddInterceptor
+	interceptors []interceptor.Interceptor
+
+	// statsGetter of the PeerConnection, if any, read by TrackRemote.Stats
+	statsGetter stats.Getter
+
 	log logging.LeveledLogger
 }
 
@@ -90,10 +152,12 @@ func (api *API) NewRTPReceiver(kind RTPCodecType, transport *DTLSTransport) (*RT
 		closed:    make(chan any),
 		received:  make(chan any),
 		tracks:    []trackStreams{},
+
+		rtcpReadDeadline: deadline.New(),
 		rtxPool: sync.Pool{New: func() any {
 			return make([]byte, api.settingEngine.getReceiveMTU())
 		}},
-		log: api.settingEngine.LoggerFactory.NewLogger("RTPReceiver"),
+		log: api.loggerFactory().NewLogger("RTPReceiver"),
 	}
 
 	return rtpReceiver, nil
@@ -119,6 +183,7 @@ func (r *RTPReceiver) getParameters() RTPParameters {
 		r.kind,
 		[]RTPTransceiverDirection{RTPTransceiverDirectionRecvonly},
 	)
+	parameters.HeaderExtensions = r.tr.filterHeaderExtensions(parameters.HeaderExtensions)
 	if r.tr != nil {
 		parameters.Codecs = r.tr.getCodecs()
 	}
@@ -184,6 +249,16 @@ func (r *RTPReceiver) configureReceive(parameters RTPReceiveParameters) {
 				parameters.Encodings[i].RID,
 				r,
 			),
+			rtpReadDeadline: deadline.New(),
+		}
+		if r.isFlexFECNegotiated() {
+			t.track.setFecSSRC(parameters.Encodings[i].FEC.SSRC)
+		}
+		// ULPFEC is sent on the stream it protects, use it if there is no FlexFEC stream
+		if redPayloadType, ulpFECPayloadType, ok := r.ulpFECPayloadTypes(); ok && !t.track.HasFEC() {
+			t.fecDecoder = newULPFECDecoder(redPayloadType, ulpFECPayloadType)
+			t.repairStreamChannel = make(chan rtxPacketWithAttributes, 50)
+			t.track.setULPFEC()
 		}
 
 		r.tracks = append(r.tracks, t)
@@ -237,6 +312,19 @@ func (r *RTPReceiver) startReceive(parameters RTPReceiveParameters) error { //no
 		if streams.rtpReadStream, streams.rtpInterceptor, streams.rtcpReadStream, streams.rtcpInterceptor, err = r.transport.streamsForSSRC(parameters.Encodings[i].SSRC, *streams.streamInfo); err != nil {
 			return err
 		}
+		streams.rtcpInterceptor = streams.track.observeSourceDescription(streams.rtcpInterceptor)
+		if err = streams.applyRTPReadDeadline(); err != nil {
+			return err
+		}
+		if readDeadline, ok := r.rtcpReadDeadline.Deadline(); ok && streams == &r.tracks[0] {
+			if err = streams.rtcpReadStream.SetReadDeadline(readDeadline); err != nil {
+				return err
+			}
+		}
+
+		for _, attached := range r.interceptors {
+			r.bindInterceptor(streams, attached)
+		}
 
 		if rtxSsrc := parameters.Encodings[i].RTX.SSRC; rtxSsrc != 0 {
 			streamInfo := createStreamInfo("", rtxSsrc, 0, 0, 0, 0, 0, codec, globalParams.HeaderExtensions)
@@ -260,6 +348,24 @@ func (r *RTPReceiver) startReceive(parameters RTPReceiveParameters) error { //no
 				return err
 			}
 		}
+
+		if fecSsrc := streams.track.FecSSRC(); fecSsrc != 0 {
+			streamInfo := createStreamInfo("", fecSsrc, 0, 0, 0, 0, 0, codec, globalParams.HeaderExtensions)
+			rtpReadStream, rtpInterceptor, rtcpReadStream, rtcpInterceptor, err := r.transport.streamsForSSRC(
+				fecSsrc,
+				*streamInfo,
+			)
+			if err != nil {
+				return err
+			}
+
+			streams.fecStreamInfo = streamInfo
+			streams.fecReadStream = rtpReadStream
+			streams.fecInterceptor = rtpInterceptor
+			streams.fecRtcpReadStream = rtcpReadStream
+			streams.fecRtcpInterceptor = rtcpInterceptor
+			r.receiveForFEC(streams)
+		}
 	}
 
 	close(r.received)
@@ -277,12 +383,18 @@ func (r *RTPReceiver) Receive(parameters RTPReceiveParameters) error {
 // Read reads incoming RTCP for this RTPReceiver.
 func (r *RTPReceiver) Read(b []byte) (n int, a interceptor.Attributes, err error) {
 	select {
+	case <-r.rtcpReadDeadline.Done():
+		return 0, nil, os.ErrDeadlineExceeded
 	case <-r.received:
 		if len(r.tracks) > 1 {
 			r.log.Errorf(useReadSimulcast)
 		}
 
-		return r.tracks[0].rtcpInterceptor.Read(b, a)
+		r.mu.RLock()
+		rtcpInterceptor := r.tracks[0].rtcpInterceptor
+		r.mu.RUnlock()
+
+		return rtcpInterceptor.Read(b, a)
 	case <-r.closed:
 		return 0, nil, io.ErrClosedPipe
 	}
@@ -385,6 +497,14 @@ func (r *RTPReceiver) Stop() error { //nolint:cyclop
 				errs = append(errs, r.tracks[i].repairRtcpReadStream.Close())
 			}
 
+			if r.tracks[i].fecReadStream != nil {
+				errs = append(errs, r.tracks[i].fecReadStream.Close())
+			}
+
+			if r.tracks[i].fecRtcpReadStream != nil {
+				errs = append(errs, r.tracks[i].fecRtcpReadStream.Close())
+			}
+
 			if r.tracks[i].streamInfo != nil {
 				r.api.interceptor.UnbindRemoteStream(r.tracks[i].streamInfo)
 			}
@@ -393,11 +513,27 @@ func (r *RTPReceiver) Stop() error { //nolint:cyclop
 				r.api.interceptor.UnbindRemoteStream(r.tracks[i].repairStreamInfo)
 			}
 
+			if r.tracks[i].fecStreamInfo != nil {
+				r.api.interceptor.UnbindRemoteStream(r.tracks[i].fecStreamInfo)
+			}
+
+			for _, attached := range r.interceptors {
+				if r.tracks[i].streamInfo != nil {
+					attached.UnbindRemoteStream(r.tracks[i].streamInfo)
+				}
+			}
+
 			err = util.FlattenErrs(errs)
 		}
 	default:
 	}
 
+	errs := []error{err}
+	for _, attached := range r.interceptors {
+		errs = append(errs, attached.Close())
+	}
+	err = util.FlattenErrs(errs)
+
 	close(r.closed)
 
 	return err
@@ -444,6 +580,12 @@ func (r *RTPReceiver) collectStats(collector *statsReportCollector, statsGetter
 		}
 		r.populateInboundStats(&inboundStats, statsGetter, remoteTrack)
 
+		if remoteOutboundStats, ok := remoteOutboundStatsFor(inboundStats, statsGetter); ok {
+			inboundStats.RemoteID = remoteOutboundStats.ID
+			collector.Collecting()
+			collector.Collect(remoteOutboundStats.ID, remoteOutboundStats)
+		}
+
 		collector.Collect(inboundID, inboundStats)
 
 		if remoteTrack.Kind() == RTPCodecTypeAudio {
@@ -452,6 +594,11 @@ func (r *RTPReceiver) collectStats(collector *statsReportCollector, statsGetter
 	}
 }
 
+// ssrcLog returns the logger of the lines about the RTP stream ssrc.
+func (r *RTPReceiver) ssrcLog(ssrc SSRC) logging.LeveledLogger {
+	return newLoggerWithAttrs(r.api.loggerFactory(), r.log, "RTPReceiver", slog.Uint64(logKeySSRC, uint64(ssrc)))
+}
+
 func (r *RTPReceiver) populateInboundStats(
 	inboundStats *InboundRTPStreamStats,
 	statsGetter stats.Getter,
@@ -465,13 +612,13 @@ func (r *RTPReceiver) populateInboundStats(
 	// Wrap-around casting by design, with warnings if overflow/underflow is detected.
 	pr := stats.InboundRTPStreamStats.PacketsReceived
 	if pr > math.MaxUint32 {
-		r.log.Warnf("Inbound PacketsReceived exceeds uint32 and will wrap: %d", pr)
+		r.ssrcLog(remoteTrack.SSRC()).Warnf("Inbound PacketsReceived exceeds uint32 and will wrap: %d", pr)
 	}
 	inboundStats.PacketsReceived = uint32(pr) //nolint:gosec
 
 	pl := stats.InboundRTPStreamStats.PacketsLost
 	if pl > math.MaxInt32 || pl < math.MinInt32 {
-		r.log.Warnf("Inbound PacketsLost exceeds int32 range and will wrap: %d", pl)
+		r.ssrcLog(remoteTrack.SSRC()).Warnf("Inbound PacketsLost exceeds int32 range and will wrap: %d", pl)
 	}
 	inboundStats.PacketsLost = int32(pl) //nolint:gosec
 
@@ -484,6 +631,39 @@ func (r *RTPReceiver) populateInboundStats(
 	inboundStats.FIRCount = stats.InboundRTPStreamStats.FIRCount
 	inboundStats.PLICount = stats.InboundRTPStreamStats.PLICount
 	inboundStats.NACKCount = stats.InboundRTPStreamStats.NACKCount
+	inboundStats.FramesReceived = uint32(remoteTrack.framesReceived.Load()) //nolint:gosec
+}
+
+// remoteOutboundStatsFor returns the remote-outbound-rtp stats of the stream, false
+// until the remote peer sent a sender report about it.
+func remoteOutboundStatsFor(
+	inboundStats InboundRTPStreamStats,
+	statsGetter stats.Getter,
+) (RemoteOutboundRTPStreamStats, bool) {
+	interceptorStats := statsGetter.Get(uint32(inboundStats.SSRC))
+	if interceptorStats == nil || interceptorStats.RemoteOutboundRTPStreamStats.ReportsSent == 0 {
+		return RemoteOutboundRTPStreamStats{}, false
+	}
+
+	remote := interceptorStats.RemoteOutboundRTPStreamStats
+
+	return RemoteOutboundRTPStreamStats{
+		Timestamp:                 inboundStats.Timestamp,
+		Type:                      StatsTypeRemoteOutboundRTP,
+		ID:                        fmt.Sprintf("remote-outbound-rtp-%d", uint32(inboundStats.SSRC)),
+		SSRC:                      inboundStats.SSRC,
+		Kind:                      inboundStats.Kind,
+		TransportID:               inboundStats.TransportID,
+		CodecID:                   inboundStats.CodecID,
+		PacketsSent:               uint32(remote.PacketsSent), //nolint:gosec // G115, 32 bits counter of the report
+		BytesSent:                 remote.BytesSent,
+		LocalID:                   inboundStats.ID,
+		RemoteTimestamp:           statsTimestampFrom(remote.RemoteTimeStamp),
+		ReportsSent:               remote.ReportsSent,
+		RoundTripTime:             remote.RoundTripTime.Seconds(),
+		TotalRoundTripTime:        remote.TotalRoundTripTime.Seconds(),
+		RoundTripTimeMeasurements: remote.RoundTripTimeMeasurements,
+	}, true
 }
 
 func (r *RTPReceiver) collectAudioPlayoutStats(
@@ -510,19 +690,91 @@ func (r *RTPReceiver) streamsForTrack(t *TrackRemote) *trackStreams {
 
 // readRTP should only be called by a track, this only exists so we can keep state in one place.
 func (r *RTPReceiver) readRTP(b []byte, reader *TrackRemote) (n int, a interceptor.Attributes, err error) {
+	r.mu.RLock()
+	var readDeadlineDone <-chan struct{}
+	if t := r.streamsForTrack(reader); t != nil {
+		readDeadlineDone = t.rtpReadDeadlineDone()
+	}
+	r.mu.RUnlock()
+
 	select {
 	case <-r.received:
 	case <-r.closed:
 		return 0, nil, io.EOF
+	case <-readDeadlineDone:
+		return 0, nil, os.ErrDeadlineExceeded
 	}
 
+	r.mu.RLock()
+	var rtpInterceptor interceptor.RTPReader
+	var streams *trackStreams
 	if t := r.streamsForTrack(reader); t != nil {
-		return t.rtpInterceptor.Read(b, a)
+		rtpInterceptor = t.rtpInterceptor
+		streams = t
+	}
+	r.mu.RUnlock()
+
+	if rtpInterceptor != nil {
+		for {
+			n, a, err = rtpInterceptor.Read(b, nil)
+			if err != nil || streams.fecDecoder == nil {
+				return n, a, err
+			}
+
+			// The FEC packets and the packets already recovered are dropped
+			var deliver bool
+			if n, deliver = r.decodeFEC(streams, b, n); deliver {
+				return n, a, nil
+			}
+		}
 	}
 
 	return 0, nil, fmt.Errorf("%w: %d", errRTPReceiverWithSSRCTrackStreamNotFound, reader.SSRC())
 }
 
+// AddInterceptor attaches an Interceptor to this RTPReceiver only. Unlike the Interceptors
+// configured via the API's InterceptorRegistry it only sees the streams of this receiver,
+// which allows things like dumping the packets of a single problematic track.
+//
+// The Interceptor may be added at any time, including from OnTrack, and is closed
+// when the RTPReceiver is stopped.
+func (r *RTPReceiver) AddInterceptor(i interceptor.Interceptor) error {
+	if i == nil {
+		return errRTPReceiverInterceptorNil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	select {
+	case <-r.closed:
+		return errRTPReceiverStopped
+	default:
+	}
+
+	i.BindRTCPWriter(interceptor.RTCPWriterFunc(r.writeRTCP))
+	r.interceptors = append(r.interceptors, i)
+
+	for idx := range r.tracks {
+		if r.tracks[idx].streamInfo != nil {
+			r.bindInterceptor(&r.tracks[idx], i)
+		}
+	}
+
+	return nil
+}
+
+// bindInterceptor wraps the streams of a track with a receiver scoped Interceptor.
+// It must be called with r.mu held.
+func (r *RTPReceiver) bindInterceptor(streams *trackStreams, i interceptor.Interceptor) {
+	streams.rtpInterceptor = i.BindRemoteStream(streams.streamInfo, streams.rtpInterceptor)
+	streams.rtcpInterceptor = i.BindRTCPReader(streams.rtcpInterceptor)
+}
+
+func (r *RTPReceiver) writeRTCP(pkts []rtcp.Packet, _ interceptor.Attributes) (int, error) {
+	return r.transport.WriteRTCP(pkts)
+}
+
 // receiveForRid is the sibling of Receive expect for RIDs instead of SSRCs
 // It populates all the internal state for the given RID.
 func (r *RTPReceiver) receiveForRid(
@@ -550,7 +802,15 @@ func (r *RTPReceiver) receiveForRid(
 			r.tracks[i].rtpReadStream = rtpReadStream
 			r.tracks[i].rtpInterceptor = rtpInterceptor
 			r.tracks[i].rtcpReadStream = rtcpReadStream
-			r.tracks[i].rtcpInterceptor = rtcpInterceptor
+			r.tracks[i].rtcpInterceptor = r.tracks[i].track.observeSourceDescription(rtcpInterceptor)
+
+			for _, attached := range r.interceptors {
+				r.bindInterceptor(&r.tracks[i], attached)
+			}
+
+			if err := r.tracks[i].applyRTPReadDeadline(); err != nil {
+				return nil, err
+			}
 
 			return r.tracks[i].track, nil
 		}
@@ -596,7 +856,9 @@ func (r *RTPReceiver) receiveForRtx(
 	track.repairInterceptor = rtpInterceptor
 	track.repairRtcpReadStream = rtcpReadStream
 	track.repairRtcpInterceptor = rtcpInterceptor
-	track.repairStreamChannel = make(chan rtxPacketWithAttributes, 50)
+	if track.repairStreamChannel == nil {
+		track.repairStreamChannel = make(chan rtxPacketWithAttributes, 50)
+	}
 
 	go func() {
 		for {
@@ -636,6 +898,7 @@ func (r *RTPReceiver) receiveForRtx(
 			attributes.Set(AttributeRtxPayloadType, b[1]&0x7F)
 			attributes.Set(AttributeRtxSequenceNumber, binary.BigEndian.Uint16(b[2:4]))
 			attributes.Set(AttributeRtxSsrc, binary.BigEndian.Uint32(b[8:12]))
+			attributes.Set(AttributeRepaired, true)
 
 			b[1] = (b[1] & 0x80) | uint8(track.track.PayloadType())
 			b[2] = b[headerLength]
@@ -658,14 +921,136 @@ func (r *RTPReceiver) receiveForRtx(
 	return nil
 }
 
+// isFlexFECNegotiated returns whether FlexFEC-03 is negotiated for the receiver, the
+// only FEC scheme that is decoded.
+func (r *RTPReceiver) isFlexFECNegotiated() bool {
+	for _, codec := range r.getParameters().Codecs {
+		if strings.EqualFold(codec.MimeType, MimeTypeFlexFEC03) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ulpFECPayloadTypes returns the payload types of ULPFEC and of the video RED carrying
+// it, 0 if ULPFEC is sent without RED, and whether ULPFEC is negotiated.
+func (r *RTPReceiver) ulpFECPayloadTypes() (red, ulpFEC PayloadType, ok bool) {
+	for _, codec := range r.getParameters().Codecs {
+		switch {
+		case strings.EqualFold(codec.MimeType, MimeTypeUlpFEC):
+			ulpFEC, ok = codec.PayloadType, true
+		case strings.EqualFold(codec.MimeType, MimeTypeVideoRED):
+			red = codec.PayloadType
+		}
+	}
+
+	return red, ulpFEC, ok
+}
+
+// receiveForFEC starts a routine that decodes the FlexFEC stream of a track, the
+// packets it recovers are delivered like the RTX packets.
+func (r *RTPReceiver) receiveForFEC(track *trackStreams) {
+	track.fecDecoder = newFlexFECDecoder(track.track.SSRC())
+	if track.repairStreamChannel == nil {
+		track.repairStreamChannel = make(chan rtxPacketWithAttributes, 50)
+	}
+
+	go func() {
+		b := make([]byte, r.api.settingEngine.getReceiveMTU())
+		for {
+			i, _, err := track.fecInterceptor.Read(b, nil)
+			if err != nil {
+				return
+			}
+
+			packet := &rtp.Packet{}
+			if err = packet.Unmarshal(append([]byte{}, b[:i]...)); err == nil {
+				r.queueRecovered(track, track.fecDecoder.pushRepair(packet))
+			}
+		}
+	}()
+}
+
+// decodeFEC pushes the packet of the track in b to its FEC decoder, and queues the
+// packets it recovered. It returns the size of the packet to deliver, unwrapped from
+// RED if needed, and false if the packet is a FEC packet or has already been recovered.
+func (r *RTPReceiver) decodeFEC(track *trackStreams, b []byte, n int) (int, bool) {
+	packet := &rtp.Packet{}
+	if err := packet.Unmarshal(append([]byte{}, b[:n]...)); err != nil {
+		return n, true
+	}
+
+	media, recovered := track.fecDecoder.pushPacket(packet)
+	r.queueRecovered(track, recovered)
+	switch {
+	case media == nil:
+		return 0, false
+	case media == packet:
+		return n, true
+	}
+
+	n, err := media.MarshalTo(b)
+
+	return n, err == nil
+}
+
+// queueRecovered queues the packets recovered by the FEC decoder of the track, they
+// are delivered like the RTX packets.
+func (r *RTPReceiver) queueRecovered(track *trackStreams, packets []*rtp.Packet) {
+	for _, recovered := range packets {
+		b := r.rtxPool.Get().([]byte) // nolint:forcetypeassert
+		n, err := recovered.MarshalTo(b)
+		if err != nil {
+			r.rtxPool.Put(b) // nolint:staticcheck
+
+			continue
+		}
+
+		attributes := interceptor.Attributes{}
+		attributes.Set(AttributeRepaired, true)
+		attributes.Set(AttributeFECRecovered, true)
+
+		select {
+		case track.repairStreamChannel <- rtxPacketWithAttributes{pkt: b[:n], attributes: attributes, pool: &r.rtxPool}:
+		default:
+			// skip the packet if the repair stream channel is full, could be blocked in the application's read loop
+			r.rtxPool.Put(b) // nolint:staticcheck
+		}
+	}
+}
+
 // SetReadDeadline sets the max amount of time the RTCP stream will block before returning. 0 is forever.
+// It can be set before Receive, Read then times out if the receiver isn't started by the deadline.
 func (r *RTPReceiver) SetReadDeadline(t time.Time) error {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	r.rtcpReadDeadline.Set(t)
+	if len(r.tracks) == 0 || r.tracks[0].rtcpReadStream == nil {
+		return nil
+	}
+
 	return r.tracks[0].rtcpReadStream.SetReadDeadline(t)
 }
 
+// SetRTPReadDeadline sets the max amount of time the RTP streams of all the tracks of the
+// receiver will block before returning, 0 is forever. ReadRTP of a TrackRemote then fails with
+// a net.Error whose Timeout is true, allowing to stop reading a track whose sender went silent
+// without closing the PeerConnection. It overrides the deadlines set with TrackRemote.SetReadDeadline.
+func (r *RTPReceiver) SetRTPReadDeadline(deadline time.Time) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := range r.tracks {
+		if err := r.tracks[i].setRTPReadDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // SetReadDeadlineSimulcast sets the max amount of time the RTCP stream for a given rid will block before returning.
 // 0 is forever.
 func (r *RTPReceiver) SetReadDeadlineSimulcast(deadline time.Time, rid string) error {
@@ -688,7 +1073,7 @@ func (r *RTPReceiver) setRTPReadDeadline(deadline time.Time, reader *TrackRemote
 	defer r.mu.RUnlock()
 
 	if t := r.streamsForTrack(reader); t != nil {
-		return t.rtpReadStream.SetReadDeadline(deadline)
+		return t.setRTPReadDeadline(deadline)
 	}
 
 	return fmt.Errorf("%w: %d", errRTPReceiverWithSSRCTrackStreamNotFound, reader.SSRC())
@@ -696,7 +1081,7 @@ func (r *RTPReceiver) setRTPReadDeadline(deadline time.Time, reader *TrackRemote
 
 // readRTX returns an RTX packet if one is available on the RTX track, otherwise returns nil.
 func (r *RTPReceiver) readRTX(reader *TrackRemote) *rtxPacketWithAttributes {
-	if !reader.HasRTX() {
+	if !reader.HasRTX() && !reader.HasFEC() {
 		return nil
 	}
 
@@ -8,7 +8,11 @@ package webrtc
 
 import (
 	"context"
+	"errors"
+	"io"
 	"math"
+	"net"
+	"os"
 	"testing"
 	"time"
 
@@ -69,6 +73,60 @@ func Test_RTPReceiver_SetReadDeadline(t *testing.T) {
 	closePairNow(t, sender, receiver)
 }
 
+// Assert that the deadlines also apply while waiting for the RTPReceiver to start,
+// so a reader doesn't block forever on a track that is never received.
+func Test_RTPReceiver_SetReadDeadline_BeforeReceive(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pc, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	transceiver, err := pc.AddTransceiverFromKind(RTPCodecTypeVideo)
+	require.NoError(t, err)
+	receiver := transceiver.Receiver()
+
+	assert.NoError(t, receiver.SetReadDeadline(time.Now().Add(20*time.Millisecond)))
+	_, _, err = receiver.ReadRTCP()
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	receiver.configureReceive(RTPReceiveParameters{Encodings: []RTPDecodingParameters{
+		{RTPCodingParameters: RTPCodingParameters{SSRC: 1234}},
+	}})
+	trackRemote := receiver.Track()
+	require.NotNil(t, trackRemote)
+
+	assert.NoError(t, trackRemote.SetReadDeadline(time.Now().Add(20*time.Millisecond)))
+	_, _, err = trackRemote.ReadRTP()
+	var netErr net.Error
+	assert.True(t, errors.As(err, &netErr) && netErr.Timeout())
+
+	// A deadline in the past expires right away, clearing it blocks again
+	assert.NoError(t, receiver.SetRTPReadDeadline(time.Now().Add(-time.Second)))
+	_, _, err = trackRemote.ReadRTP()
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	assert.NoError(t, receiver.SetRTPReadDeadline(time.Time{}))
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		_, _, readErr := trackRemote.ReadRTP()
+		assert.ErrorIs(t, readErr, io.EOF)
+	}()
+
+	select {
+	case <-readDone:
+		assert.Fail(t, "ReadRTP returned without deadline")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.NoError(t, pc.Close())
+	<-readDone
+}
+
 // TestRTPReceiver_CollectStats_Mapping validates that collectStats maps
 // interceptor/pkg/stats values into InboundRTPStreamStats.
 func TestRTPReceiver_CollectStats_Mapping(t *testing.T) {
@@ -102,6 +160,7 @@ func TestRTPReceiver_CollectStats_Mapping(t *testing.T) {
 	// Minimal RTPReceiver with one track
 	receiver := &RTPReceiver{
 		kind: RTPCodecTypeVideo,
+		api:  NewAPI(),
 		log:  logging.NewDefaultLoggerFactory().NewLogger("RTPReceiverTest"),
 	}
 	tr := newTrackRemote(RTPCodecTypeVideo, ssrc, 0, "", receiver)
@@ -10,13 +10,16 @@ import (
 	"fmt"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/stats"
 	"github.com/pion/randutil"
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v4/internal/util"
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
 )
 
 type trackEncoding struct {
@@ -26,12 +29,31 @@ type trackEncoding struct {
 
 	rtcpInterceptor interceptor.RTCPReader
 	streamInfo      interceptor.StreamInfo
+	writeStream     *interceptorToTrackLocalWriter
 
 	context *baseTrackLocalContext
 
 	ssrc, ssrcRTX, ssrcFEC SSRC
+
+	// playoutDelayExtensionID is the negotiated ID of the playout delay header extension
+	playoutDelayExtensionID uint8
+
+	paused                bool
+	maxBitrate            uint64
+	scaleResolutionDownBy float64
+}
+
+// sendEncodingTrack is the track of an encoding of RTPTransceiverInit.SendEncodings,
+// the track of the transceiver with the RID of the encoding, until AddEncoding replaces
+// it with the track of the layer. Only the first encoding sends it.
+type sendEncodingTrack struct {
+	TrackLocal
+
+	rid string
 }
 
+func (t *sendEncodingTrack) RID() string { return t.rid }
+
 // RTPSender allows an application to control how a given Track is encoded and transmitted to a remote peer.
 type RTPSender struct {
 	trackEncodings []*trackEncoding
@@ -53,6 +75,24 @@ type RTPSender struct {
 
 	rtpTransceiver *RTPTransceiver
 
+	// Interceptors attached to this RTPSender only, see AddInterceptor
+	interceptors []interceptor.Interceptor
+
+	// cname overrides the stream ID as CNAME in SDP and RTCP SDES
+	cname string
+
+	// streamIDs override the stream ID of the track in the msid of SDP, see AddTrackOptions
+	streamIDs []string
+
+	// ecnFeedback counts the ECN marks reported for the local streams, see SettingEngine.EnableECN
+	ecnFeedback *ecnFeedback
+
+	// playoutDelay is the marshaled playout delay header extension added to the packets, see SetPlayoutDelay
+	playoutDelay atomic.Value // []byte
+
+	targetBitrate                atomic.Int64
+	onTargetBitrateChangeHandler atomic.Value // func(int)
+
 	mu                     sync.RWMutex
 	sendCalled, stopCalled chan struct{}
 }
@@ -78,6 +118,9 @@ func (api *API) NewRTPSender(track TrackLocal, transport *DTLSTransport) (*RTPSe
 		id:         id,
 		kind:       track.Kind(),
 	}
+	if api.settingEngine.ecn {
+		r.ecnFeedback = &ecnFeedback{}
+	}
 
 	r.addEncoding(track)
 
@@ -103,6 +146,169 @@ func (r *RTPSender) setRTPTransceiver(rtpTransceiver *RTPTransceiver) {
 	r.rtpTransceiver = rtpTransceiver
 }
 
+func (r *RTPSender) setCNAME(cname string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cname = cname
+}
+
+func (r *RTPSender) setStreamIDs(streamIDs []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.streamIDs = append([]string{}, streamIDs...)
+}
+
+// StreamIDs returns the IDs of the MediaStreams the track of the RTPSender is
+// associated with, the stream ID of the track unless AddTrack set them.
+func (r *RTPSender) StreamIDs() []string {
+	return r.getStreamIDs(r.Track())
+}
+
+func (r *RTPSender) getStreamIDs(track TrackLocal) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.streamIDs) != 0 || track == nil {
+		return append([]string{}, r.streamIDs...)
+	}
+
+	return []string{track.StreamID()}
+}
+
+// ECNCounts returns the ECN marks reported by the RFC 8888 congestion control feedback
+// read from this RTPSender, for all its encodings. They are only counted when ECN is
+// enabled with SettingEngine.EnableECN.
+func (r *RTPSender) ECNCounts() ECNCounts {
+	return r.ecnFeedback.getCounts()
+}
+
+// SetPlayoutDelay sets the playout delay sent to the remote in the header extension of
+// every packet of the track, or stops sending it if delay is nil. The delays are sent
+// with a 10 ms granularity. The extension is only sent if it has been negotiated, which
+// requires ConfigurePlayoutDelayHeaderExtension.
+func (r *RTPSender) SetPlayoutDelay(delay *PlayoutDelay) error {
+	if delay == nil {
+		r.playoutDelay.Store([]byte(nil))
+
+		return nil
+	}
+
+	payload, err := delay.marshal()
+	if err != nil {
+		return err
+	}
+	r.playoutDelay.Store(payload)
+
+	return nil
+}
+
+// withPlayoutDelay returns header with the playout delay extension added if it is set
+// and has been negotiated for the encoding.
+func (r *RTPSender) withPlayoutDelay(trackEncoding *trackEncoding, header *rtp.Header) (*rtp.Header, error) {
+	payload, _ := r.playoutDelay.Load().([]byte)
+	if payload == nil || trackEncoding.playoutDelayExtensionID == 0 || header.SSRC != uint32(trackEncoding.ssrc) {
+		return header, nil
+	}
+
+	// The header may be retained by the interceptors, don't modify it
+	extended := header.Clone()
+	if err := extended.SetExtension(trackEncoding.playoutDelayExtensionID, payload); err != nil {
+		return nil, err
+	}
+
+	return &extended, nil
+}
+
+// getCNAME returns the CNAME of the RTPSender, which defaults to
+// the stream ID of the track.
+func (r *RTPSender) getCNAME(track TrackLocal) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.cname != "" || track == nil {
+		return r.cname
+	}
+
+	return track.StreamID()
+}
+
+// sendingSSRCs returns the SSRCs of the streams sent by the RTPSender, once Send
+// has been called and until it is stopped.
+func (r *RTPSender) sendingSSRCs() []uint32 {
+	if !r.hasSent() || r.hasStopped() {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ssrcs []uint32
+	for _, encoding := range r.trackEncodings {
+		for _, ssrc := range []SSRC{encoding.ssrc, encoding.ssrcRTX, encoding.ssrcFEC} {
+			if ssrc != 0 {
+				ssrcs = append(ssrcs, uint32(ssrc))
+			}
+		}
+	}
+
+	return ssrcs
+}
+
+// sourceDescriptionChunks returns the RTCP SDES chunks describing every encoding
+// whose media SSRC is contained in ssrcs. Besides the CNAME they carry the MID and
+// RID, the RTX stream of an encoding is described with its RepairedRtpStreamId.
+// The items are appended to every chunk.
+func (r *RTPSender) sourceDescriptionChunks(
+	ssrcs map[uint32]struct{},
+	items []rtcp.SourceDescriptionItem,
+) []rtcp.SourceDescriptionChunk {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var mid string
+	if r.rtpTransceiver != nil {
+		mid = r.rtpTransceiver.Mid()
+	}
+
+	chunks := []rtcp.SourceDescriptionChunk{}
+	for _, encoding := range r.trackEncodings {
+		if encoding.track == nil {
+			continue
+		}
+		if _, ok := ssrcs[uint32(encoding.ssrc)]; !ok {
+			continue
+		}
+
+		cname := r.cname
+		if cname == "" {
+			cname = encoding.track.StreamID()
+		}
+
+		newChunk := func(ssrc SSRC, ridType rtcp.SDESType) rtcp.SourceDescriptionChunk {
+			chunk := rtcp.SourceDescriptionChunk{
+				Source: uint32(ssrc),
+				Items:  []rtcp.SourceDescriptionItem{{Type: rtcp.SDESCNAME, Text: cname}},
+			}
+			if mid != "" {
+				chunk.Items = append(chunk.Items, rtcp.SourceDescriptionItem{Type: sdesMid, Text: mid})
+			}
+			if rid := encoding.track.RID(); rid != "" {
+				chunk.Items = append(chunk.Items, rtcp.SourceDescriptionItem{Type: ridType, Text: rid})
+			}
+			chunk.Items = append(chunk.Items, items...)
+
+			return chunk
+		}
+
+		chunks = append(chunks, newChunk(encoding.ssrc, sdesRtpStreamID))
+		if encoding.ssrcRTX != 0 {
+			chunks = append(chunks, newChunk(encoding.ssrcRTX, sdesRepairedRtpStreamID))
+		}
+	}
+
+	return chunks
+}
+
 // Transport returns the currently-configured *DTLSTransport or nil
 // if one has not yet been configured.
 func (r *RTPSender) Transport() *DTLSTransport {
@@ -112,6 +318,19 @@ func (r *RTPSender) Transport() *DTLSTransport {
 	return r.transport
 }
 
+// mtu returns the maximum size of the RTP packets of the tracks, the discovered path
+// MTU once SettingEngine.SetPathMTUDiscovery has found it, the one set by
+// SettingEngine.SetSendMTU otherwise. The transport is set once by NewRTPSender.
+func (r *RTPSender) mtu() int {
+	if r.transport.iceTransport != nil {
+		if mtu := r.transport.iceTransport.getDiscoveredMTU(); mtu != 0 {
+			return mtu
+		}
+	}
+
+	return int(r.api.settingEngine.getSendMTU())
+}
+
 // GetParameters describes the current configuration for the encoding and
 // transmission of media on the sender's track.
 func (r *RTPSender) GetParameters() RTPSendParameters {
@@ -132,6 +351,9 @@ func (r *RTPSender) GetParameters() RTPSendParameters {
 				FEC:         RTPFecParameters{SSRC: trackEncoding.ssrcFEC},
 				PayloadType: r.payloadType,
 			},
+			Active:                !trackEncoding.paused,
+			MaxBitrate:            trackEncoding.maxBitrate,
+			ScaleResolutionDownBy: trackEncoding.scaleResolutionDownBy,
 		})
 	}
 	sendParameters := RTPSendParameters{
@@ -141,6 +363,7 @@ func (r *RTPSender) GetParameters() RTPSendParameters {
 		),
 		Encodings: encodings,
 	}
+	sendParameters.HeaderExtensions = r.rtpTransceiver.filterHeaderExtensions(sendParameters.HeaderExtensions)
 	if r.rtpTransceiver != nil {
 		sendParameters.Codecs = r.rtpTransceiver.getCodecs()
 	} else {
@@ -150,6 +373,88 @@ func (r *RTPSender) GetParameters() RTPSendParameters {
 	return sendParameters
 }
 
+// SetParameters changes the Active, MaxBitrate and ScaleResolutionDownBy of the encodings of
+// the sender without renegotiation, parameters should be obtained with GetParameters.
+// The packets written to an inactive encoding are dropped, the bitrate allocated by the
+// congestion controller is limited by the MaxBitrate of the active encodings.
+// Encodings can't be added, removed or reordered, other parameters are ignored.
+func (r *RTPSender) SetParameters(parameters RTPSendParameters) error {
+	if err := r.setParameters(parameters); err != nil {
+		return err
+	}
+
+	// Apply the new limit right away instead of waiting for the next estimate
+	if limit, paused := r.getBitrateLimit(); paused {
+		r.setTargetBitrate(0)
+	} else if target := r.TargetBitrate(); limit != 0 && target > limit {
+		r.setTargetBitrate(limit)
+	}
+
+	return nil
+}
+
+func (r *RTPSender) setParameters(parameters RTPSendParameters) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.hasStopped() {
+		return &rtcerr.InvalidStateError{Err: errRTPSenderStopped}
+	}
+
+	if len(parameters.Encodings) != len(r.trackEncodings) {
+		return &rtcerr.InvalidModificationError{Err: errRTPSenderEncodingsMismatch}
+	}
+	for i, encoding := range parameters.Encodings {
+		if track := r.trackEncodings[i].track; track != nil && track.RID() != encoding.RID {
+			return &rtcerr.InvalidModificationError{Err: errRTPSenderEncodingsMismatch}
+		}
+		if encoding.ScaleResolutionDownBy < 1 {
+			return &rtcerr.RangeError{Err: errRTPSenderScaleResolution}
+		}
+	}
+
+	for i, encoding := range parameters.Encodings {
+		trackEncoding := r.trackEncodings[i]
+		trackEncoding.paused = !encoding.Active
+		trackEncoding.maxBitrate = encoding.MaxBitrate
+		trackEncoding.scaleResolutionDownBy = encoding.ScaleResolutionDownBy
+		if trackEncoding.writeStream != nil {
+			trackEncoding.writeStream.paused.Store(trackEncoding.paused)
+		}
+	}
+
+	return nil
+}
+
+// bitrateLimit returns the sum of the MaxBitrate of the active encodings, 0 if one of them is
+// unlimited, and if all the encodings are paused. It must be called with the lock held.
+func (r *RTPSender) bitrateLimit() (limit int, paused bool) {
+	unlimited, active := false, false
+	for _, trackEncoding := range r.trackEncodings {
+		if trackEncoding.paused {
+			continue
+		}
+
+		active = true
+		if trackEncoding.maxBitrate == 0 {
+			unlimited = true
+		}
+		limit += int(trackEncoding.maxBitrate) //nolint:gosec // G115
+	}
+	if unlimited {
+		limit = 0
+	}
+
+	return limit, !active && len(r.trackEncodings) != 0
+}
+
+func (r *RTPSender) getBitrateLimit() (limit int, paused bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.bitrateLimit()
+}
+
 // AddEncoding adds an encoding to RTPSender. Used by simulcast senders.
 func (r *RTPSender) AddEncoding(track TrackLocal) error { //nolint:cyclop
 	r.mu.Lock()
@@ -189,6 +494,12 @@ func (r *RTPSender) AddEncoding(track TrackLocal) error { //nolint:cyclop
 		}
 
 		if encoding.track.RID() == track.RID() {
+			if _, ok := encoding.track.(*sendEncodingTrack); ok {
+				encoding.track, encoding.paused = track, false
+
+				return nil
+			}
+
 			return errRTPSenderRIDCollision
 		}
 	}
@@ -198,10 +509,71 @@ func (r *RTPSender) AddEncoding(track TrackLocal) error { //nolint:cyclop
 	return nil
 }
 
+// setSendEncodings sets the encodings of RTPTransceiverInit.SendEncodings, before the
+// sender is sent. Several encodings need a RID each, the first one being the RID of the
+// track if it has one. The other encodings are inactive until AddEncoding is called with
+// the track of their RID, they would each send a copy of the track otherwise. Their SSRC
+// is generated if 0.
+func (r *RTPSender) setSendEncodings(encodings []RTPEncodingParameters) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rids := map[string]struct{}{}
+	for _, encoding := range encodings {
+		if encoding.ScaleResolutionDownBy != 0 && encoding.ScaleResolutionDownBy < 1 {
+			return &rtcerr.RangeError{Err: errRTPSenderScaleResolution}
+		}
+		if len(encodings) == 1 {
+			continue
+		}
+
+		if encoding.RID == "" {
+			return errRTPSenderRidNil
+		}
+		if _, ok := rids[encoding.RID]; ok {
+			return errRTPSenderRIDCollision
+		}
+		rids[encoding.RID] = struct{}{}
+	}
+
+	track := r.trackEncodings[0].track
+	if len(encodings) > 1 {
+		if rid := track.RID(); rid != "" && rid != encodings[0].RID {
+			return errRTPSenderBaseEncodingMismatch
+		}
+
+		r.trackEncodings = nil
+		for i, encoding := range encodings {
+			if i == 0 && track.RID() != "" {
+				r.addEncoding(track)
+
+				continue
+			}
+
+			r.addEncoding(&sendEncodingTrack{TrackLocal: track, rid: encoding.RID})
+			r.trackEncodings[i].paused = i != 0
+		}
+	}
+
+	for i, encoding := range encodings {
+		trackEncoding := r.trackEncodings[i]
+		if encoding.SSRC != 0 {
+			trackEncoding.ssrc = encoding.SSRC
+		}
+		trackEncoding.maxBitrate = encoding.MaxBitrate
+		if encoding.ScaleResolutionDownBy != 0 {
+			trackEncoding.scaleResolutionDownBy = encoding.ScaleResolutionDownBy
+		}
+	}
+
+	return nil
+}
+
 func (r *RTPSender) addEncoding(track TrackLocal) {
 	trackEncoding := &trackEncoding{
-		track: track,
-		ssrc:  SSRC(util.RandUint32()),
+		track:                 track,
+		ssrc:                  SSRC(util.RandUint32()),
+		scaleResolutionDownBy: 1,
 	}
 
 	if r.api.mediaEngine.isRTXEnabled(r.kind, []RTPTransceiverDirection{RTPTransceiverDirectionSendonly}) {
@@ -268,6 +640,7 @@ func (r *RTPSender) ReplaceTrack(track TrackLocal) error { //nolint:cyclop
 		track.Kind(),
 		[]RTPTransceiverDirection{RTPTransceiverDirectionSendonly},
 	)
+	params.HeaderExtensions = r.rtpTransceiver.filterHeaderExtensions(params.HeaderExtensions)
 
 	// If we reach this point in the routine, there is only 1 track encoding
 	codec, err := track.Bind(&baseTrackLocalContext{
@@ -278,6 +651,7 @@ func (r *RTPSender) ReplaceTrack(track TrackLocal) error { //nolint:cyclop
 		ssrcFEC:         context.SSRCForwardErrorCorrection(),
 		writeStream:     context.WriteStream(),
 		rtcpInterceptor: context.RTCPReader(),
+		mtu:             r.mtu,
 	})
 	if err != nil {
 		// Re-bind the original track
@@ -318,28 +692,45 @@ func (r *RTPSender) Send(parameters RTPSendParameters) error {
 			trackEncoding.track.Kind(),
 			[]RTPTransceiverDirection{RTPTransceiverDirectionSendonly},
 		)
+		rtpParameters.HeaderExtensions = r.rtpTransceiver.filterHeaderExtensions(rtpParameters.HeaderExtensions)
 
 		trackEncoding.srtpStream = srtpStream
+		trackEncoding.writeStream = writeStream
+		writeStream.paused.Store(trackEncoding.paused)
 		trackEncoding.ssrc = parameters.Encodings[idx].SSRC
 		trackEncoding.ssrcRTX = parameters.Encodings[idx].RTX.SSRC
 		trackEncoding.ssrcFEC = parameters.Encodings[idx].FEC.SSRC
+		trackEncoding.playoutDelayExtensionID = findHeaderExtensionID(PlayoutDelayURI, parameters.HeaderExtensions)
 		trackEncoding.rtcpInterceptor = r.api.interceptor.BindRTCPReader(
 			interceptor.RTCPReaderFunc(
 				func(in []byte, a interceptor.Attributes) (n int, attributes interceptor.Attributes, err error) {
 					n, err = trackEncoding.srtpStream.Read(in)
+					if err == nil {
+						r.ecnFeedback.observe(in[:n], trackEncoding.ssrc)
+					}
 
 					return n, a, err
 				},
 			),
 		)
 		trackEncoding.context = &baseTrackLocalContext{
-			id:              r.id,
-			params:          rtpParameters,
-			ssrc:            parameters.Encodings[idx].SSRC,
-			ssrcFEC:         parameters.Encodings[idx].FEC.SSRC,
-			ssrcRTX:         parameters.Encodings[idx].RTX.SSRC,
-			writeStream:     writeStream,
-			rtcpInterceptor: trackEncoding.rtcpInterceptor,
+			id:          r.id,
+			params:      rtpParameters,
+			ssrc:        parameters.Encodings[idx].SSRC,
+			ssrcFEC:     parameters.Encodings[idx].FEC.SSRC,
+			ssrcRTX:     parameters.Encodings[idx].RTX.SSRC,
+			writeStream: writeStream,
+			mtu:         r.mtu,
+			// Interceptors attached via AddInterceptor may replace the reader at any time
+			rtcpInterceptor: interceptor.RTCPReaderFunc(
+				func(in []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+					r.mu.RLock()
+					reader := trackEncoding.rtcpInterceptor
+					r.mu.RUnlock()
+
+					return reader.Read(in, a)
+				},
+			),
 		}
 
 		codec, err := trackEncoding.track.Bind(trackEncoding.context)
@@ -363,11 +754,20 @@ func (r *RTPSender) Send(parameters RTPSendParameters) error {
 		rtpInterceptor := r.api.interceptor.BindLocalStream(
 			&trackEncoding.streamInfo,
 			interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
-				return srtpStream.WriteRTP(header, payload)
+				header, err := r.withPlayoutDelay(trackEncoding, header)
+				if err != nil {
+					return 0, err
+				}
+
+				return r.transport.pacer.writeRTP(header, payload, srtpStream.WriteRTP)
 			}),
 		)
 
 		writeStream.interceptor.Store(rtpInterceptor)
+
+		for _, i := range r.interceptors {
+			r.bindInterceptor(trackEncoding, i)
+		}
 	}
 
 	close(r.sendCalled)
@@ -404,14 +804,72 @@ func (r *RTPSender) Stop() error {
 		}
 	}
 
+	r.mu.RLock()
+	interceptors := r.interceptors
+	r.mu.RUnlock()
+	for _, i := range interceptors {
+		for _, trackEncoding := range r.trackEncodings {
+			i.UnbindLocalStream(&trackEncoding.streamInfo)
+		}
+		errs = append(errs, i.Close())
+	}
+
 	return util.FlattenErrs(errs)
 }
 
+// AddInterceptor attaches an Interceptor to this RTPSender only. Unlike the Interceptors
+// configured via the API's InterceptorRegistry it only sees the streams of this sender,
+// which allows things like dumping the packets of a single problematic track.
+//
+// The Interceptor may be added before or after media has started flowing and is
+// closed when the RTPSender is stopped.
+func (r *RTPSender) AddInterceptor(i interceptor.Interceptor) error {
+	if i == nil {
+		return errRTPSenderInterceptorNil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.hasStopped() {
+		return errRTPSenderStopped
+	}
+
+	i.BindRTCPWriter(interceptor.RTCPWriterFunc(r.writeRTCP))
+	r.interceptors = append(r.interceptors, i)
+
+	if r.hasSent() {
+		for _, trackEncoding := range r.trackEncodings {
+			r.bindInterceptor(trackEncoding, i)
+		}
+	}
+
+	return nil
+}
+
+// bindInterceptor wraps the streams of a trackEncoding with a sender scoped Interceptor.
+// It must be called with r.mu held.
+func (r *RTPSender) bindInterceptor(trackEncoding *trackEncoding, i interceptor.Interceptor) {
+	trackEncoding.rtcpInterceptor = i.BindRTCPReader(trackEncoding.rtcpInterceptor)
+
+	if writer, ok := trackEncoding.writeStream.interceptor.Load().(interceptor.RTPWriter); ok && writer != nil {
+		trackEncoding.writeStream.interceptor.Store(i.BindLocalStream(&trackEncoding.streamInfo, writer))
+	}
+}
+
+func (r *RTPSender) writeRTCP(pkts []rtcp.Packet, _ interceptor.Attributes) (int, error) {
+	return r.transport.WriteRTCP(pkts)
+}
+
 // Read reads incoming RTCP for this RTPSender.
 func (r *RTPSender) Read(b []byte) (n int, a interceptor.Attributes, err error) {
 	select {
 	case <-r.sendCalled:
-		return r.trackEncodings[0].rtcpInterceptor.Read(b, a)
+		r.mu.RLock()
+		reader := r.trackEncodings[0].rtcpInterceptor
+		r.mu.RUnlock()
+
+		return reader.Read(b, a)
 	case <-r.stopCalled:
 		return 0, nil, io.ErrClosedPipe
 	}
@@ -492,6 +950,30 @@ func (r *RTPSender) SetReadDeadlineSimulcast(deadline time.Time, rid string) err
 	return fmt.Errorf("%w: %s", errRTPSenderNoTrackForRID, rid)
 }
 
+// OnTargetBitrateChange sets an event handler which is invoked when the bitrate in bits per second
+// allocated to this RTPSender by the congestion controller changes. Use it to drive the encoder of
+// the track. Congestion control must be enabled via ConfigureCongestionController.
+// The handler is called from the congestion controller and must not block.
+func (r *RTPSender) OnTargetBitrateChange(f func(bitrate int)) {
+	r.onTargetBitrateChangeHandler.Store(f)
+}
+
+// TargetBitrate returns the last bitrate in bits per second allocated to this RTPSender by the
+// congestion controller, or 0 if none has been allocated yet.
+func (r *RTPSender) TargetBitrate() int {
+	return int(r.targetBitrate.Load())
+}
+
+func (r *RTPSender) setTargetBitrate(bitrate int) {
+	if r.targetBitrate.Swap(int64(bitrate)) == int64(bitrate) {
+		return
+	}
+
+	if handler, ok := r.onTargetBitrateChangeHandler.Load().(func(int)); ok && handler != nil {
+		handler(bitrate)
+	}
+}
+
 // hasSent tells if data has been ever sent for this instance.
 func (r *RTPSender) hasSent() bool {
 	select {
@@ -528,3 +1010,124 @@ func (r *RTPSender) configureRTXAndFEC() {
 		}
 	}
 }
+
+// collectStats emits the outbound-rtp and media-source stats of each encoding, and
+// its remote-inbound-rtp stats once the remote peer sent a receiver report about it.
+func (r *RTPSender) collectStats(collector *statsReportCollector, statsGetter stats.Getter) {
+	if statsGetter == nil || !r.hasSent() || r.hasStopped() {
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	mid := ""
+	if r.rtpTransceiver != nil {
+		mid = r.rtpTransceiver.Mid()
+	}
+	now := statsTimestampNow()
+	for _, encoding := range r.trackEncodings {
+		if encoding.track == nil || encoding.context == nil {
+			continue
+		}
+
+		outboundID := fmt.Sprintf("outbound-rtp-%d", uint32(encoding.ssrc))
+		remoteInboundID := fmt.Sprintf("remote-inbound-rtp-%d", uint32(encoding.ssrc))
+		mediaSourceID := fmt.Sprintf("media-source-%d", uint32(encoding.ssrc))
+		codecID := ""
+		if codecs := encoding.context.params.Codecs; len(codecs) != 0 {
+			codecID = codecs[0].statsID
+		}
+
+		outboundStats := OutboundRTPStreamStats{
+			Mid:           mid,
+			Rid:           encoding.track.RID(),
+			MediaSourceID: mediaSourceID,
+			Timestamp:     now,
+			Type:          StatsTypeOutboundRTP,
+			ID:            outboundID,
+			SSRC:          encoding.ssrc,
+			Kind:          r.kind.String(),
+			TransportID:   "iceTransport",
+			CodecID:       codecID,
+			TrackID:       encoding.track.ID(),
+			Active:        !encoding.paused,
+		}
+
+		interceptorStats := statsGetter.Get(uint32(encoding.ssrc))
+		if interceptorStats != nil {
+			populateOutboundStats(&outboundStats, interceptorStats)
+		}
+
+		if interceptorStats != nil && hasRemoteInboundStats(interceptorStats) {
+			outboundStats.RemoteID = remoteInboundID
+			collector.Collecting()
+			collector.Collect(remoteInboundID, newRemoteInboundStats(
+				remoteInboundID, outboundStats, interceptorStats.RemoteInboundRTPStreamStats,
+			))
+		}
+
+		collector.Collecting()
+		collector.Collect(outboundID, outboundStats)
+
+		collector.Collecting()
+		if encoding.track.Kind() == RTPCodecTypeAudio {
+			collector.Collect(mediaSourceID, AudioSourceStats{
+				Timestamp:       now,
+				Type:            StatsTypeMediaSource,
+				ID:              mediaSourceID,
+				TrackIdentifier: encoding.track.ID(),
+				Kind:            string(MediaKindAudio),
+			})
+		} else {
+			collector.Collect(mediaSourceID, VideoSourceStats{
+				Timestamp:       now,
+				Type:            StatsTypeMediaSource,
+				ID:              mediaSourceID,
+				TrackIdentifier: encoding.track.ID(),
+				Kind:            string(MediaKindVideo),
+			})
+		}
+	}
+}
+
+func populateOutboundStats(outboundStats *OutboundRTPStreamStats, interceptorStats *stats.Stats) {
+	sent := interceptorStats.OutboundRTPStreamStats
+	outboundStats.PacketsSent = uint32(sent.PacketsSent) //nolint:gosec // G115, wraps like the RTCP counters
+	outboundStats.BytesSent = sent.BytesSent
+	outboundStats.HeaderBytesSent = sent.HeaderBytesSent
+	outboundStats.NACKCount = sent.NACKCount
+	outboundStats.FIRCount = sent.FIRCount
+	outboundStats.PLICount = sent.PLICount
+}
+
+// hasRemoteInboundStats tells if a receiver report has been received for the stream.
+func hasRemoteInboundStats(interceptorStats *stats.Stats) bool {
+	remote := interceptorStats.RemoteInboundRTPStreamStats
+
+	return remote.PacketsReceived != 0 || remote.PacketsLost != 0 || remote.RoundTripTimeMeasurements != 0
+}
+
+func newRemoteInboundStats(
+	id string,
+	outboundStats OutboundRTPStreamStats,
+	remote stats.RemoteInboundRTPStreamStats,
+) RemoteInboundRTPStreamStats {
+	return RemoteInboundRTPStreamStats{
+		Timestamp:                 outboundStats.Timestamp,
+		Type:                      StatsTypeRemoteInboundRTP,
+		ID:                        id,
+		SSRC:                      outboundStats.SSRC,
+		Kind:                      outboundStats.Kind,
+		TransportID:               outboundStats.TransportID,
+		CodecID:                   outboundStats.CodecID,
+		PacketsReceived:           uint32(remote.PacketsReceived), //nolint:gosec // G115
+		PacketsLost:               int32(remote.PacketsLost),      //nolint:gosec // G115
+		Jitter:                    remote.Jitter,
+		LocalID:                   outboundStats.ID,
+		RoundTripTime:             remote.RoundTripTime.Seconds(),
+		TotalRoundTripTime:        remote.TotalRoundTripTime.Seconds(),
+		FractionLost:              remote.FractionLost,
+		RoundTripTimeMeasurements: remote.RoundTripTimeMeasurements,
+	}
+}
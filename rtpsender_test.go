@@ -422,6 +422,79 @@ func Test_RTPSender_Add_Encoding(t *testing.T) {
 	assert.NoError(t, peerConnection.Close())
 }
 
+func Test_RTPSender_SendEncodings(t *testing.T) {
+	track, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithRTPStreamID("q"),
+	)
+	assert.NoError(t, err)
+
+	peerConnection, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	addTransceiver := func(encodings ...RTPEncodingParameters) (*RTPTransceiver, error) {
+		return peerConnection.AddTransceiverFromTrack(track, RTPTransceiverInit{
+			Direction:     RTPTransceiverDirectionSendonly,
+			SendEncodings: encodings,
+		})
+	}
+	encoding := func(rid string, maxBitrate uint64, scaleResolutionDownBy float64) RTPEncodingParameters {
+		return RTPEncodingParameters{
+			RTPCodingParameters:   RTPCodingParameters{RID: rid},
+			MaxBitrate:            maxBitrate,
+			ScaleResolutionDownBy: scaleResolutionDownBy,
+		}
+	}
+
+	_, err = addTransceiver(encoding("q", 0, 0), encoding("", 0, 0))
+	assert.Equal(t, errRTPSenderRidNil, err)
+	_, err = addTransceiver(encoding("q", 0, 0), encoding("q", 0, 0))
+	assert.Equal(t, errRTPSenderRIDCollision, err)
+	_, err = addTransceiver(encoding("h", 0, 0), encoding("q", 0, 0))
+	assert.Equal(t, errRTPSenderBaseEncodingMismatch, err)
+	_, err = addTransceiver(encoding("q", 0, 0.5), encoding("h", 0, 0))
+	assert.ErrorIs(t, err, errRTPSenderScaleResolution)
+
+	transceiver, err := addTransceiver(
+		encoding("q", 150_000, 4), encoding("h", 500_000, 2), encoding("f", 0, 0),
+	)
+	assert.NoError(t, err)
+	rtpSender := transceiver.Sender()
+
+	encodings := rtpSender.GetParameters().Encodings
+	assert.Len(t, encodings, 3)
+	for i, expected := range []RTPEncodingParameters{
+		encoding("q", 150_000, 4), encoding("h", 500_000, 2), encoding("f", 0, 1),
+	} {
+		assert.Equal(t, expected.RID, encodings[i].RID)
+		assert.Equal(t, expected.MaxBitrate, encodings[i].MaxBitrate)
+		assert.Equal(t, expected.ScaleResolutionDownBy, encodings[i].ScaleResolutionDownBy)
+		assert.NotZero(t, encodings[i].SSRC)
+	}
+
+	offer, err := peerConnection.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, offer.SDP, "a=rid:q send max-br=150000\r\n")
+	assert.Contains(t, offer.SDP, "a=rid:h send max-br=500000\r\n")
+	assert.Contains(t, offer.SDP, "a=rid:f send\r\n")
+	assert.Contains(t, offer.SDP, "a=simulcast:send q;h;f\r\n")
+
+	// The layers aren't sent until their track is added
+	assert.Equal(t, []bool{true, false, false}, []bool{encodings[0].Active, encodings[1].Active, encodings[2].Active})
+	layer, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithRTPStreamID("h"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, track, rtpSender.trackEncodings[1].track.(*sendEncodingTrack).TrackLocal) //nolint:forcetypeassert
+	assert.NoError(t, rtpSender.AddEncoding(layer))
+	assert.Equal(t, layer, rtpSender.trackEncodings[1].track)
+	assert.True(t, rtpSender.GetParameters().Encodings[1].Active)
+	assert.False(t, rtpSender.GetParameters().Encodings[2].Active)
+	assert.Equal(t, errRTPSenderRIDCollision, rtpSender.AddEncoding(layer))
+	assert.Equal(t, encodings[1].SSRC, rtpSender.GetParameters().Encodings[1].SSRC)
+
+	assert.NoError(t, peerConnection.Close())
+}
+
 // nolint: dupl
 func Test_RTPSender_FEC_Support(t *testing.T) {
 	t.Run("FEC disabled by default", func(t *testing.T) {
@@ -558,3 +631,107 @@ func Test_RTPSender_SetReadDeadline_Crash(t *testing.T) {
 	assert.NoError(t, stackA.close())
 	assert.NoError(t, stackB.close())
 }
+
+func Test_RTPSender_TargetBitrate(t *testing.T) {
+	t.Run("Allocation", func(t *testing.T) {
+		audio, video := &RTPSender{kind: RTPCodecTypeAudio}, &RTPSender{kind: RTPCodecTypeVideo}
+
+		assert.Empty(t, allocateTargetBitrate(1_000_000, nil))
+		assert.Equal(t, []int{1_000_000}, allocateTargetBitrate(1_000_000, []*RTPSender{audio}))
+		assert.Equal(t, []int{1_000_000}, allocateTargetBitrate(1_000_000, []*RTPSender{video}))
+		assert.Equal(
+			t,
+			[]int{audioTargetBitrateLimit, 468_000, 468_000},
+			allocateTargetBitrate(1_000_000, []*RTPSender{audio, video, video}),
+		)
+		assert.Equal(t, []int{50_000, 50_000}, allocateTargetBitrate(100_000, []*RTPSender{audio, video}))
+	})
+
+	t.Run("Allocation with parameters", func(t *testing.T) {
+		audio := &RTPSender{kind: RTPCodecTypeAudio}
+		video := &RTPSender{kind: RTPCodecTypeVideo}
+		limited := &RTPSender{kind: RTPCodecTypeVideo, trackEncodings: []*trackEncoding{{maxBitrate: 200_000}}}
+		paused := &RTPSender{kind: RTPCodecTypeVideo, trackEncodings: []*trackEncoding{{paused: true}}}
+
+		assert.Equal(
+			t,
+			[]int{audioTargetBitrateLimit, 200_000, 736_000, 0},
+			allocateTargetBitrate(1_000_000, []*RTPSender{audio, limited, video, paused}),
+		)
+		assert.Equal(t, []int{0}, allocateTargetBitrate(1_000_000, []*RTPSender{paused}))
+		assert.Equal(t, []int{200_000}, allocateTargetBitrate(1_000_000, []*RTPSender{limited}))
+	})
+
+	t.Run("Handler", func(t *testing.T) {
+		sender := &RTPSender{kind: RTPCodecTypeVideo}
+		assert.Equal(t, 0, sender.TargetBitrate())
+
+		var calls []int
+		sender.OnTargetBitrateChange(func(bitrate int) {
+			calls = append(calls, bitrate)
+		})
+
+		sender.setTargetBitrate(500_000)
+		sender.setTargetBitrate(500_000)
+		sender.setTargetBitrate(300_000)
+
+		assert.Equal(t, []int{500_000, 300_000}, calls)
+		assert.Equal(t, 300_000, sender.TargetBitrate())
+	})
+}
+
+func Test_RTPSender_SetParameters(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerer, answerer, err := newPair()
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+
+	sender, err := offerer.AddTrack(track)
+	assert.NoError(t, err)
+
+	assert.NoError(t, signalPair(offerer, answerer))
+
+	parameters := sender.GetParameters()
+	assert.True(t, parameters.Encodings[0].Active)
+	assert.Equal(t, uint64(0), parameters.Encodings[0].MaxBitrate)
+	assert.Equal(t, 1.0, parameters.Encodings[0].ScaleResolutionDownBy)
+
+	sender.setTargetBitrate(500_000)
+	parameters.Encodings[0].MaxBitrate = 300_000
+	parameters.Encodings[0].ScaleResolutionDownBy = 2
+	assert.NoError(t, sender.SetParameters(parameters))
+	assert.Equal(t, 300_000, sender.TargetBitrate())
+
+	parameters = sender.GetParameters()
+	assert.Equal(t, uint64(300_000), parameters.Encodings[0].MaxBitrate)
+	assert.Equal(t, 2.0, parameters.Encodings[0].ScaleResolutionDownBy)
+
+	// Packets written to an inactive encoding are dropped
+	parameters.Encodings[0].Active = false
+	assert.NoError(t, sender.SetParameters(parameters))
+	assert.False(t, sender.GetParameters().Encodings[0].Active)
+	assert.Equal(t, 0, sender.TargetBitrate())
+	assert.True(t, sender.trackEncodings[0].writeStream.paused.Load())
+
+	parameters.Encodings[0].ScaleResolutionDownBy = 0.5
+	assert.ErrorIs(t, sender.SetParameters(parameters), errRTPSenderScaleResolution)
+
+	parameters = sender.GetParameters()
+	parameters.Encodings[0].RID = "invalid"
+	assert.ErrorIs(t, sender.SetParameters(parameters), errRTPSenderEncodingsMismatch)
+
+	parameters.Encodings = append(sender.GetParameters().Encodings, RTPEncodingParameters{})
+	assert.ErrorIs(t, sender.SetParameters(parameters), errRTPSenderEncodingsMismatch)
+
+	assert.NoError(t, sender.Stop())
+	assert.ErrorIs(t, sender.SetParameters(sender.GetParameters()), errRTPSenderStopped)
+
+	closePairNow(t, offerer, answerer)
+}
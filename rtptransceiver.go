@@ -26,7 +26,8 @@ type RTPTransceiver struct {
 	currentDirection       atomic.Value // RTPTransceiverDirection
 	currentRemoteDirection atomic.Value // RTPTransceiverDirection
 
-	codecs []RTPCodecParameters // User provided codecs via SetCodecPreferences
+	codecs           []RTPCodecParameters           // User provided codecs via SetCodecPreferences
+	headerExtensions []RTPHeaderExtensionCapability // User provided header extensions via SetHeaderExtensions
 
 	kind RTPCodecType
 
@@ -69,6 +70,59 @@ func (t *RTPTransceiver) SetCodecPreferences(codecs []RTPCodecParameters) error
 	return nil
 }
 
+// SetHeaderExtensions sets the header extensions offered and accepted for this
+// transceiver, they must have been registered in the MediaEngine for its kind.
+// If extensions is nil we reset to all the extensions of the MediaEngine, an empty
+// slice disables all of them.
+func (t *RTPTransceiver) SetHeaderExtensions(extensions []RTPHeaderExtensionCapability) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, extension := range extensions {
+		if !t.api.mediaEngine.isHeaderExtensionRegistered(extension, t.kind) {
+			return fmt.Errorf("%w %s", errRTPTransceiverHeaderExtensionUnsupported, extension.URI)
+		}
+	}
+
+	if extensions == nil {
+		t.headerExtensions = nil
+	} else {
+		t.headerExtensions = append([]RTPHeaderExtensionCapability{}, extensions...)
+	}
+
+	return nil
+}
+
+// filterHeaderExtensions returns the extensions enabled with SetHeaderExtensions,
+// all of them if it hasn't been called, was reset with nil, or t is nil.
+func (t *RTPTransceiver) filterHeaderExtensions(
+	extensions []RTPHeaderExtensionParameter,
+) []RTPHeaderExtensionParameter {
+	if t == nil {
+		return extensions
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.headerExtensions == nil {
+		return extensions
+	}
+
+	filtered := make([]RTPHeaderExtensionParameter, 0, len(extensions))
+	for _, extension := range extensions {
+		for _, enabled := range t.headerExtensions {
+			if extension.URI == enabled.URI {
+				filtered = append(filtered, extension)
+
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
 // getCodecs returns list of supported codecs.
 func (t *RTPTransceiver) getCodecs() []RTPCodecParameters {
 	t.mu.RLock()
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/pion/sdp/v3"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -274,3 +275,65 @@ func Test_RTPTransceiver_UnattachedRTX(t *testing.T) {
 
 	closePairNow(t, offerPC, answerPC)
 }
+
+func Test_RTPTransceiver_SetHeaderExtensions(t *testing.T) {
+	mediaEngine := &MediaEngine{}
+	assert.NoError(t, mediaEngine.RegisterDefaultCodecs())
+	for _, typ := range []RTPCodecType{RTPCodecTypeVideo, RTPCodecTypeAudio} {
+		for _, uri := range []string{sdp.TransportCCURI, sdp.SDESMidURI} {
+			assert.NoError(t, mediaEngine.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: uri}, typ))
+		}
+	}
+	assert.NoError(t, mediaEngine.RegisterHeaderExtension(
+		RTPHeaderExtensionCapability{URI: sdp.AudioLevelURI}, RTPCodecTypeAudio,
+	))
+
+	pc, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	video, err := pc.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+	audio, err := pc.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, video.SetHeaderExtensions([]RTPHeaderExtensionCapability{
+		{URI: sdp.AudioLevelURI},
+	}), errRTPTransceiverHeaderExtensionUnsupported)
+
+	assert.NoError(t, video.SetHeaderExtensions([]RTPHeaderExtensionCapability{{URI: sdp.TransportCCURI}}))
+	assert.NoError(t, audio.SetHeaderExtensions([]RTPHeaderExtensionCapability{{URI: sdp.AudioLevelURI}}))
+
+	extensionURIs := func() map[string][]string {
+		offer, err := pc.CreateOffer(nil)
+		assert.NoError(t, err)
+		parsed, err := offer.Unmarshal()
+		assert.NoError(t, err)
+
+		uris := map[string][]string{}
+		for _, media := range parsed.MediaDescriptions {
+			for _, attr := range media.Attributes {
+				if attr.Key == "extmap" {
+					fields := strings.Fields(attr.Value)
+					uris[media.MediaName.Media] = append(uris[media.MediaName.Media], fields[1])
+				}
+			}
+		}
+
+		return uris
+	}
+
+	assert.Equal(t, map[string][]string{
+		"video": {sdp.TransportCCURI},
+		"audio": {sdp.AudioLevelURI},
+	}, extensionURIs())
+	assert.Len(t, video.Receiver().GetParameters().HeaderExtensions, 1)
+
+	assert.NoError(t, video.SetHeaderExtensions([]RTPHeaderExtensionCapability{}))
+	assert.NotContains(t, extensionURIs(), "video")
+	assert.Empty(t, video.Receiver().GetParameters().HeaderExtensions)
+
+	assert.NoError(t, video.SetHeaderExtensions(nil))
+	assert.Contains(t, extensionURIs()["video"], sdp.SDESMidURI)
+
+	assert.NoError(t, pc.Close())
+}
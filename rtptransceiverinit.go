@@ -6,7 +6,15 @@ package webrtc
 // RTPTransceiverInit dictionary is used when calling the WebRTC function addTransceiver()
 // to provide configuration options for the new transceiver.
 type RTPTransceiverInit struct {
-	Direction     RTPTransceiverDirection
+	Direction RTPTransceiverDirection
+	// SendEncodings are the encodings of the track sent, the simulcast layers offered
+	// with their RID, MaxBitrate and ScaleResolutionDownBy. The layers are written with
+	// the tracks of their RID given to RTPSender.AddEncoding, see there, the first one
+	// with the track of the transceiver until then and the others aren't sent. Active is
+	// ignored, the encodings are paused with RTPSender.SetParameters. It is only used by
+	// AddTransceiverFromTrack.
 	SendEncodings []RTPEncodingParameters
-	// Streams       []*Track
+	// StreamIDs are the IDs of the MediaStreams the track is associated with,
+	// the stream ID of the track is used if empty.
+	StreamIDs []string
 }
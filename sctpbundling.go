@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"net"
+	"sync"
+	"time"
+)
+
+// sctpChecksumTable is the CRC32c table of the SCTP checksums of RFC 9260 appendix B.
+var sctpChecksumTable = crc32.MakeTable(crc32.Castagnoli) //nolint:gochecknoglobals
+
+// sctpBundlingConn bundles the small SCTP packets of DATA chunks written by the association
+// into larger packets, see SettingEngine.SetSCTPBundlingDelay. A packet is held for delay
+// at most, the packets written meanwhile are appended to it as long as it fits the MTU.
+// The other packets are written right away, after those held.
+type sctpBundlingConn struct {
+	net.Conn
+
+	delay time.Duration
+	mtu   int
+
+	mu      sync.Mutex
+	pending []byte
+	timer   *time.Timer
+	// err is the error of the last write of the packets held, returned by the next Write.
+	err    error
+	closed bool
+}
+
+func newSCTPBundlingConn(conn net.Conn, delay time.Duration, mtu int) *sctpBundlingConn {
+	return &sctpBundlingConn{Conn: conn, delay: delay, mtu: mtu}
+}
+
+func (c *sctpBundlingConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.err; err != nil {
+		c.err = nil
+
+		return 0, err
+	}
+	if c.closed || len(p) <= sctpCommonHeaderSize {
+		return c.Conn.Write(p)
+	}
+
+	if !sctpDataOnly(p) {
+		if err := c.flush(); err != nil {
+			return 0, err
+		}
+
+		return c.Conn.Write(p)
+	}
+
+	if c.pending != nil && len(c.pending)+len(p)-sctpCommonHeaderSize > c.mtu {
+		if err := c.flush(); err != nil {
+			return 0, err
+		}
+	}
+	if c.pending == nil {
+		// The association reuses its buffer
+		c.pending = append(make([]byte, 0, c.mtu), p...)
+		c.timer = time.AfterFunc(c.delay, c.flushPending)
+	} else {
+		c.pending = append(c.pending, p[sctpCommonHeaderSize:]...)
+	}
+
+	// Written right away once no other DATA chunk fits
+	if c.mtu-len(c.pending) < sctpDataChunkSize+4 {
+		if err := c.flush(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Close writes the packets held then closes the conn.
+func (c *sctpBundlingConn) Close() error {
+	c.mu.Lock()
+	err := c.flush()
+	c.closed = true
+	c.mu.Unlock()
+
+	if closeErr := c.Conn.Close(); closeErr != nil {
+		return closeErr
+	}
+
+	return err
+}
+
+func (c *sctpBundlingConn) flushPending() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.flush(); err != nil {
+		c.err = err
+	}
+}
+
+// flush writes the packet held, it must be called with the lock held.
+func (c *sctpBundlingConn) flush() error {
+	if c.pending == nil {
+		return nil
+	}
+	c.timer.Stop()
+	packet := c.pending
+	c.pending = nil
+
+	// The checksum covers the chunks bundled, unless the remote accepts zero checksums
+	if binary.LittleEndian.Uint32(packet[sctpChecksumOffset:]) != 0 {
+		binary.LittleEndian.PutUint32(packet[sctpChecksumOffset:], 0)
+		binary.LittleEndian.PutUint32(packet[sctpChecksumOffset:], crc32.Checksum(packet, sctpChecksumTable))
+	}
+	_, err := c.Conn.Write(packet)
+
+	return err
+}
+
+// sctpDataOnly reports whether the SCTP packet carries DATA chunks only, the other
+// chunks aren't delayed.
+func sctpDataOnly(packet []byte) bool {
+	dataOnly := true
+	forEachSCTPChunk(packet, func(chunkType, _ byte, _ []byte) {
+		dataOnly = dataOnly && chunkType == sctpChunkTypeData
+	})
+
+	return dataOnly
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sctpBundlingTestConn struct {
+	net.Conn
+
+	mu     sync.Mutex
+	writes [][]byte
+}
+
+func (c *sctpBundlingTestConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writes = append(c.writes, append([]byte{}, p...))
+
+	return len(p), nil
+}
+
+func (c *sctpBundlingTestConn) Close() error {
+	return nil
+}
+
+func (c *sctpBundlingTestConn) written() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([][]byte{}, c.writes...)
+}
+
+// sctpTestChecksummed sets the checksum of packet.
+func sctpTestChecksummed(packet []byte) []byte {
+	binary.LittleEndian.PutUint32(packet[sctpChecksumOffset:], crc32.Checksum(packet, sctpChecksumTable))
+
+	return packet
+}
+
+func TestSCTPBundlingConn(t *testing.T) {
+	recorder := &sctpBundlingTestConn{}
+	conn := newSCTPBundlingConn(recorder, time.Hour, 64)
+
+	// Two DATA packets of 32 bytes are bundled, the third one doesn't fit
+	for tsn := uint32(1); tsn <= 3; tsn++ {
+		n, err := conn.Write(sctpTestChecksummed(sctpTestPacket(sctpTestData(tsn, 1, sctpDataFlagEnd))))
+		assert.NoError(t, err)
+		assert.Equal(t, 32, n)
+	}
+	writes := recorder.written()
+	require.Len(t, writes, 1)
+	assert.Equal(t, sctpTestChecksummed(sctpTestPacket(
+		sctpTestData(1, 1, sctpDataFlagEnd),
+		sctpTestData(2, 1, sctpDataFlagEnd),
+	)), writes[0])
+
+	// A SACK is written right away, after the DATA held
+	sack := sctpTestChecksummed(sctpTestPacket(sctpTestSACK(5)))
+	_, err := conn.Write(sack)
+	assert.NoError(t, err)
+	writes = recorder.written()
+	require.Len(t, writes, 3)
+	assert.Equal(t, sctpTestChecksummed(sctpTestPacket(sctpTestData(3, 1, sctpDataFlagEnd))), writes[1])
+	assert.Equal(t, sack, writes[2])
+
+	// The zero checksums are kept, the packets held are written on close
+	_, err = conn.Write(sctpTestPacket(sctpTestData(4, 1, sctpDataFlagEnd)))
+	assert.NoError(t, err)
+	assert.NoError(t, conn.Close())
+	writes = recorder.written()
+	require.Len(t, writes, 4)
+	assert.Equal(t, sctpTestPacket(sctpTestData(4, 1, sctpDataFlagEnd)), writes[3])
+
+	// The packets are written once the delay is over
+	recorder = &sctpBundlingTestConn{}
+	conn = newSCTPBundlingConn(recorder, 10*time.Millisecond, 1200)
+	_, err = conn.Write(sctpTestPacket(sctpTestData(1, 1, sctpDataFlagEnd)))
+	assert.NoError(t, err)
+	_, err = conn.Write(sctpTestPacket(sctpTestData(2, 1, sctpDataFlagEnd)))
+	assert.NoError(t, err)
+	assert.Empty(t, recorder.written())
+	assert.Eventually(t, func() bool { return len(recorder.written()) == 1 }, time.Second, time.Millisecond)
+}
+
+func TestSCTPTransport_BundlingDelay(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetSCTPBundlingDelay(5 * time.Millisecond)
+	api := NewAPI(WithSettingEngine(settingEngine))
+	pcOffer, err := api.NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := api.NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	const messageCount = 100
+	dc, err := pcOffer.CreateDataChannel("bundled", nil)
+	require.NoError(t, err)
+	dc.OnOpen(func() {
+		for i := 0; i < messageCount; i++ {
+			assert.NoError(t, dc.Send([]byte{byte(i)}))
+		}
+	})
+
+	received := make(chan []byte, messageCount)
+	pcAnswer.OnDataChannel(func(d *DataChannel) {
+		d.OnMessage(func(msg DataChannelMessage) {
+			received <- msg.Data
+		})
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	for i := 0; i < messageCount; i++ {
+		assert.Equal(t, []byte{byte(i)}, <-received)
+	}
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -7,9 +7,12 @@
 package webrtc
 
 import (
+	"context"
 	"errors"
 	"io"
+	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/datachannel"
@@ -39,8 +42,9 @@ type SCTPTransport struct {
 
 	// OnStateChange  func()
 
-	onErrorHandler func(error)
-	onCloseHandler func(error)
+	onErrorHandler         func(error)
+	onCloseHandler         func(error)
+	internalOnErrorHandler func(error)
 
 	sctpAssociation            *sctp.Association
 	onDataChannelHandler       func(*DataChannel)
@@ -52,6 +56,9 @@ type SCTPTransport struct {
 	dataChannelsOpened    uint32
 	dataChannelsRequested uint32
 	dataChannelsAccepted  uint32
+	scheduler             *dataChannelScheduler
+	reliability           *sctpReliabilityObserver
+	zeroChecksum          atomic.Bool
 
 	api *API
 	log logging.LeveledLogger
@@ -65,9 +72,13 @@ func (api *API) NewSCTPTransport(dtls *DTLSTransport) *SCTPTransport {
 		dtlsTransport:      dtls,
 		state:              SCTPTransportStateConnecting,
 		api:                api,
-		log:                api.settingEngine.LoggerFactory.NewLogger("ortc"),
+		log:                api.loggerFactory().NewLogger("ortc"),
 		dataChannelIDsUsed: make(map[uint16]struct{}),
 	}
+	res.scheduler = newDataChannelScheduler(res.BufferedAmount)
+	if api.settingEngine.sctp.enableReliabilityStats {
+		res.reliability = newSCTPReliabilityObserver(api.settingEngine.sctp.rtoMax)
+	}
 
 	res.updateMaxChannels()
 
@@ -112,15 +123,20 @@ func (r *SCTPTransport) Start(capabilities SCTPCapabilities) error {
 	if dtlsTransport == nil || dtlsTransport.conn == nil {
 		return errSCTPTransportDTLS
 	}
+	var conn net.Conn = dtlsTransport.conn
+	if delay := r.api.settingEngine.sctp.bundlingDelay; delay > 0 {
+		conn = newSCTPBundlingConn(conn, delay, int(r.api.settingEngine.getSendMTU())) //nolint:gosec // G115
+	}
+	conn = &sctpObservedConn{Conn: conn, observer: r.reliability, zeroChecksum: &r.zeroChecksum}
 	sctpAssociation, err := sctp.Client(sctp.Config{
-		NetConn:              dtlsTransport.conn,
+		NetConn:              conn,
 		MaxReceiveBufferSize: r.api.settingEngine.sctp.maxReceiveBufferSize,
 		EnableZeroChecksum:   r.api.settingEngine.sctp.enableZeroChecksum,
-		LoggerFactory:        r.api.settingEngine.LoggerFactory,
+		LoggerFactory:        r.api.loggerFactory(),
 		RTOMax:               float64(r.api.settingEngine.sctp.rtoMax) / float64(time.Millisecond),
 		BlockWrite:           r.api.settingEngine.detach.DataChannels && r.api.settingEngine.dataChannelBlockWrite,
 		MaxMessageSize:       maxMessageSize,
-		MTU:                  outboundMTU,
+		MTU:                  uint32(r.api.settingEngine.getSendMTU()), //nolint:gosec // G115
 		MinCwnd:              r.api.settingEngine.sctp.minCwnd,
 		FastRtxWnd:           r.api.settingEngine.sctp.fastRtxWnd,
 		CwndCAStep:           r.api.settingEngine.sctp.cwndCAStep,
@@ -173,6 +189,33 @@ func (r *SCTPTransport) Stop() error {
 	return nil
 }
 
+// gracefulStop shuts the association down after the data queued on it has been
+// acknowledged by the remote, or until ctx is done.
+func (r *SCTPTransport) gracefulStop(ctx context.Context) error {
+	association := r.association()
+	if association == nil {
+		return nil
+	}
+
+	// Shutdown only waits for the data in flight, not for the data still queued
+	ticker := time.NewTicker(sctpDrainInterval)
+	defer ticker.Stop()
+	for association.BufferedAmount() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	// The remote may have shut the association down already
+	if err := association.Shutdown(ctx); err != nil && !errors.Is(err, sctp.ErrShutdownNonEstablished) {
+		return err
+	}
+
+	return r.Stop()
+}
+
 //nolint:cyclop
 func (r *SCTPTransport) acceptDataChannels(
 	assoc *sctp.Association,
@@ -191,7 +234,7 @@ func (r *SCTPTransport) acceptDataChannels(
 ACCEPT:
 	for {
 		dc, err := datachannel.Accept(assoc, &datachannel.Config{
-			LoggerFactory: r.api.settingEngine.LoggerFactory,
+			LoggerFactory: r.api.loggerFactory(),
 		}, dataChannels...)
 		if err != nil {
 			if !errors.Is(err, io.EOF) {
@@ -246,7 +289,8 @@ ACCEPT:
 			Ordered:           ordered,
 			MaxPacketLifeTime: maxPacketLifeTime,
 			MaxRetransmits:    maxRetransmits,
-		}, r, r.api.settingEngine.LoggerFactory.NewLogger("ortc"))
+			Priority:          DataChannelPriority(dc.Config.Priority),
+		}, r, r.api.loggerFactory().NewLogger("ortc"))
 		if err != nil {
 			// This data channel is invalid. Close it and log an error.
 			if err1 := dc.Close(); err1 != nil {
@@ -282,10 +326,14 @@ func (r *SCTPTransport) OnError(f func(err error)) {
 func (r *SCTPTransport) onError(err error) {
 	r.lock.RLock()
 	handler := r.onErrorHandler
+	internalHandler := r.internalOnErrorHandler
 	r.lock.RUnlock()
 
+	if internalHandler != nil {
+		internalHandler(err)
+	}
 	if handler != nil {
-		go handler(err)
+		r.api.workerPool.run(func() { handler(err) })
 	}
 }
 
@@ -302,7 +350,7 @@ func (r *SCTPTransport) onClose(err error) {
 	r.lock.RUnlock()
 
 	if handler != nil {
-		go handler(err)
+		r.api.workerPool.run(func() { handler(err) })
 	}
 }
 
@@ -355,6 +403,9 @@ func (r *SCTPTransport) onDataChannel(dc *DataChannel) (done chan struct{}) {
 
 func (r *SCTPTransport) updateMaxChannels() {
 	val := sctpMaxChannels
+	if streams := r.api.settingEngine.sctp.maxOutboundStreams; streams != 0 && streams < val {
+		val = streams
+	}
 	r.maxChannels = &val
 }
 
@@ -370,6 +421,12 @@ func (r *SCTPTransport) MaxChannels() uint16 {
 	return *r.maxChannels
 }
 
+// ZeroChecksum reports whether the SCTP packets sent carry no checksum, once the remote
+// accepted the zero checksum extension, see SettingEngine.EnableSCTPZeroChecksum.
+func (r *SCTPTransport) ZeroChecksum() bool {
+	return r.zeroChecksum.Load()
+}
+
 // State returns the current state of the SCTPTransport.
 func (r *SCTPTransport) State() SCTPTransportState {
 	r.lock.RLock()
@@ -382,9 +439,10 @@ func (r *SCTPTransport) collectStats(collector *statsReportCollector) {
 	collector.Collecting()
 
 	stats := SCTPTransportStats{
-		Timestamp: statsTimestampFrom(time.Now()),
-		Type:      StatsTypeSCTPTransport,
-		ID:        "sctpTransport",
+		Timestamp:   statsTimestampFrom(time.Now()),
+		Type:        StatsTypeSCTPTransport,
+		ID:          "sctpTransport",
+		TransportID: "iceTransport",
 	}
 
 	association := r.association()
@@ -401,17 +459,19 @@ func (r *SCTPTransport) collectStats(collector *statsReportCollector) {
 }
 
 func (r *SCTPTransport) generateAndSetDataChannelID(dtlsRole DTLSRole, idOut **uint16) error {
-	var id uint16
+	var start int
 	if dtlsRole != DTLSRoleClient {
-		id++
+		start++
 	}
 
-	maxVal := r.MaxChannels()
+	maxVal := int(r.MaxChannels())
 
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	for ; id < maxVal-1; id += 2 {
+	// The identifier 65535 is reserved, RFC 8832 Section 6
+	for streamID := start; streamID < maxVal; streamID += 2 {
+		id := uint16(streamID) //nolint:gosec // G115, below maxVal
 		if _, ok := r.dataChannelIDsUsed[id]; ok {
 			continue
 		}
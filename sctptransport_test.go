@@ -14,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pion/transport/v3/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -62,6 +63,30 @@ func TestGenerateDataChannelID(t *testing.T) {
 	}
 }
 
+func TestSCTPTransport_MaxOutboundStreams(t *testing.T) {
+	s := SettingEngine{}
+	s.SetSCTPMaxOutboundStreams(5)
+
+	pc, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	assert.Equal(t, uint16(5), pc.sctpTransport.MaxChannels())
+
+	id := uint16(5)
+	_, err = pc.CreateDataChannel("negotiated", &DataChannelInit{ID: &id})
+	assert.ErrorIs(t, err, ErrMaxDataChannelID)
+
+	// Client identifiers are even
+	for _, expected := range []uint16{0, 2, 4} {
+		idPtr := new(uint16)
+		assert.NoError(t, pc.sctpTransport.generateAndSetDataChannelID(DTLSRoleClient, &idPtr))
+		assert.Equal(t, expected, *idPtr)
+	}
+	idPtr := new(uint16)
+	assert.ErrorIs(t, pc.sctpTransport.generateAndSetDataChannelID(DTLSRoleClient, &idPtr), ErrMaxDataChannelID)
+
+	assert.NoError(t, pc.Close())
+}
+
 func TestSCTPTransportOnClose(t *testing.T) {
 	offerPC, answerPC, err := newPair()
 	require.NoError(t, err)
@@ -321,3 +346,57 @@ func TestMaxMessageSizeSignaling(t *testing.T) {
 		closePairNow(t, offerPeerConnection, answerPeerConnection)
 	})
 }
+
+func TestSCTPTransport_ZeroChecksum(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	for _, tc := range []struct {
+		name                  string
+		offerZero, answerZero bool
+	}{
+		{"Neither", false, false},
+		{"Both", true, true},
+		{"Offerer Only", true, false},
+		{"Answerer Only", false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			newPeerConnection := func(zeroChecksum bool) *PeerConnection {
+				settingEngine := SettingEngine{}
+				settingEngine.EnableSCTPZeroChecksum(zeroChecksum)
+				pc, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+				require.NoError(t, err)
+
+				return pc
+			}
+			pcOffer, pcAnswer := newPeerConnection(tc.offerZero), newPeerConnection(tc.answerZero)
+
+			dc, err := pcOffer.CreateDataChannel("checksum", nil)
+			require.NoError(t, err)
+			echoed := make(chan []byte, 1)
+			dc.OnMessage(func(msg DataChannelMessage) {
+				echoed <- msg.Data
+			})
+			dc.OnOpen(func() {
+				assert.NoError(t, dc.Send([]byte("ping")))
+			})
+			pcAnswer.OnDataChannel(func(d *DataChannel) {
+				d.OnMessage(func(msg DataChannelMessage) {
+					assert.NoError(t, d.Send(msg.Data))
+				})
+			})
+
+			require.NoError(t, signalPair(pcOffer, pcAnswer))
+			assert.Equal(t, []byte("ping"), <-echoed)
+
+			// Each peer skips the checksum of the packets to a remote accepting the extension
+			assert.Equal(t, tc.answerZero, pcOffer.SCTP().ZeroChecksum())
+			assert.Equal(t, tc.offerZero, pcAnswer.SCTP().ZeroChecksum())
+
+			closePairNow(t, pcOffer, pcAnswer)
+		})
+	}
+}
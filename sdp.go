@@ -173,9 +173,10 @@ func trackDetailsFromSDP(
 			// Handle `a=msid:<stream_id> <track_label>` for Unified plan. The first value is the same as MediaStream.id
 			// in the browser and can be used to figure out which tracks belong to the same stream. The browser should
 			// figure this out automatically when an ontrack event is emitted on RTCPeerConnection.
+			// A track associated with several streams has an msid per stream, the first one is kept.
 			case sdp.AttrKeyMsid:
 				split := strings.Split(attr.Value, " ")
-				if len(split) == 2 {
+				if len(split) == 2 && (streamID == "" || trackID != split[1]) {
 					streamID = split[0]
 					trackID = split[1]
 				}
@@ -458,6 +459,8 @@ func addSenderSDP(
 			continue
 		}
 
+		cname := sender.getCNAME(track)
+		streamIDs := sender.getStreamIDs(track)
 		sendParameters := sender.GetParameters()
 		for _, encoding := range sendParameters.Encodings {
 			if encoding.RTX.SSRC != 0 {
@@ -485,8 +488,8 @@ func addSenderSDP(
 
 			media = media.WithMediaSource(
 				uint32(encoding.SSRC),
-				track.StreamID(), /* cname */
-				track.StreamID(), /* streamLabel */
+				cname,
+				streamIDs[0], /* streamLabel */
 				track.ID(),
 			)
 
@@ -494,21 +497,23 @@ func addSenderSDP(
 				if encoding.RTX.SSRC != 0 {
 					media = media.WithMediaSource(
 						uint32(encoding.RTX.SSRC),
-						track.StreamID(), /* cname */
-						track.StreamID(), /* streamLabel */
+						cname,
+						streamIDs[0], /* streamLabel */
 						track.ID(),
 					)
 				}
 				if encoding.FEC.SSRC != 0 {
 					media = media.WithMediaSource(
 						uint32(encoding.FEC.SSRC),
-						track.StreamID(), /* cname */
-						track.StreamID(), /* streamLabel */
+						cname,
+						streamIDs[0], /* streamLabel */
 						track.ID(),
 					)
 				}
 
-				media = media.WithPropertyAttribute("msid:" + track.StreamID() + " " + track.ID())
+				for _, streamID := range streamIDs {
+					media = media.WithPropertyAttribute("msid:" + streamID + " " + track.ID())
+				}
 			}
 		}
 
@@ -516,7 +521,12 @@ func addSenderSDP(
 			sendRids := make([]string, 0, len(sendParameters.Encodings))
 
 			for _, encoding := range sendParameters.Encodings {
-				media.WithValueAttribute(sdpAttributeRid, encoding.RID+" send")
+				rid := encoding.RID + " send"
+				if encoding.MaxBitrate != 0 {
+					// RFC 8851 section 5, the restrictions of the RID
+					rid += fmt.Sprintf(" max-br=%d", encoding.MaxBitrate)
+				}
+				media.WithValueAttribute(sdpAttributeRid, rid)
 				sendRids = append(sendRids, encoding.RID)
 			}
 			// Simulcast
@@ -560,7 +570,7 @@ func addTransceiverSDP(
 	for _, codec := range codecs {
 		name := strings.TrimPrefix(codec.MimeType, "audio/")
 		name = strings.TrimPrefix(name, "video/")
-		media.WithCodec(uint8(codec.PayloadType), name, codec.ClockRate, codec.Channels, codec.SDPFmtpLine)
+		media.WithCodec(uint8(codec.PayloadType), name, codec.ClockRate, codec.Channels, mediaEngine.sdpFmtpLine(codec))
 
 		for _, feedback := range codec.RTPCodecCapability.RTCPFeedback {
 			if feedback.Parameter == "" {
@@ -611,7 +621,7 @@ func addTransceiverSDP(
 	}
 
 	parameters := mediaEngine.getRTPParametersByKind(transceiver.kind, directions)
-	for _, rtpExtension := range parameters.HeaderExtensions {
+	for _, rtpExtension := range transceiver.filterHeaderExtensions(parameters.HeaderExtensions) {
 		if mediaSection.matchExtensions != nil {
 			if _, enabled := mediaSection.matchExtensions[rtpExtension.URI]; !enabled {
 				continue
@@ -641,7 +651,11 @@ func addTransceiverSDP(
 
 	addSenderSDP(mediaSection, isPlanB, media)
 
-	media = media.WithPropertyAttribute(transceiver.Direction().String())
+	direction := transceiver.Direction()
+	if mediaSection.direction != RTPTransceiverDirectionUnknown {
+		direction = mediaSection.direction
+	}
+	media = media.WithPropertyAttribute(direction.String())
 
 	for _, fingerprint := range dtlsFingerprints {
 		media = media.WithFingerprint(fingerprint.Algorithm, strings.ToUpper(fingerprint.Value))
@@ -670,6 +684,9 @@ type mediaSection struct {
 	data            bool
 	matchExtensions map[string]int
 	rids            []*simulcastRid
+	// direction is the direction of an answer narrowed by the AnswerDirectionPolicy,
+	// the one of the first transceiver is used when it is unknown.
+	direction RTPTransceiverDirection
 }
 
 func bundleMatchFromRemote(matchBundleGroup *string) func(mid string) bool {
@@ -694,6 +711,7 @@ func populateSDP(
 	dtlsFingerprints []DTLSFingerprint,
 	mediaDescriptionFingerprint bool,
 	isICELite bool,
+	iceOptions []string,
 	isExtmapAllowMixed bool,
 	mediaEngine *MediaEngine,
 	connectionRole sdp.ConnectionRole,
@@ -782,6 +800,11 @@ func populateSDP(
 		descr = descr.WithValueAttribute(sdp.AttrKeyICELite, "")
 	}
 
+	if len(iceOptions) > 0 {
+		// RFC 8839 S5.6
+		descr = descr.WithValueAttribute(sdp.AttrKeyICEOptions, strings.Join(iceOptions, " "))
+	}
+
 	if isExtmapAllowMixed {
 		descr = descr.WithPropertyAttribute(sdp.AttrKeyExtMapAllowMixed)
 	}
@@ -868,24 +891,32 @@ func extractBundleID(desc *sdp.SessionDescription) string {
 	return bundleIDs[1]
 }
 
-func extractFingerprint(desc *sdp.SessionDescription) (string, string, error) { //nolint:gocognit,cyclop
-	fingerprint := ""
+// fingerprintAttributes returns the values of the fingerprint attributes of attributes.
+func fingerprintAttributes(attributes []sdp.Attribute) []string {
+	values := []string{}
+	for _, attr := range attributes {
+		if attr.Key == "fingerprint" {
+			values = append(values, attr.Value)
+		}
+	}
+
+	return values
+}
 
+// extractFingerprints returns the fingerprints of the description, a certificate may be
+// described by several fingerprints computed with different hash algorithms.
+func extractFingerprints(desc *sdp.SessionDescription) ([]DTLSFingerprint, error) { //nolint:gocognit,cyclop
 	// Fingerprint on session level has highest priority
-	if sessionFingerprint, haveFingerprint := desc.Attribute("fingerprint"); haveFingerprint {
-		fingerprint = sessionFingerprint
-	}
+	values := fingerprintAttributes(desc.Attributes)
 
-	if fingerprint == "" { //nolint:nestif
+	if len(values) == 0 { //nolint:nestif
 		bundleID := extractBundleID(desc)
 		if bundleID != "" {
 			// Locate the fingerprint of the bundled media section
 			for _, mediaDescr := range desc.MediaDescriptions {
 				if mid, haveMid := mediaDescr.Attribute("mid"); haveMid {
-					if mid == bundleID && fingerprint == "" {
-						if mediaFingerprint, haveFingerprint := mediaDescr.Attribute("fingerprint"); haveFingerprint {
-							fingerprint = mediaFingerprint
-						}
+					if mid == bundleID && len(values) == 0 {
+						values = fingerprintAttributes(mediaDescr.Attributes)
 					}
 				}
 			}
@@ -894,24 +925,27 @@ func extractFingerprint(desc *sdp.SessionDescription) (string, string, error) {
 			// Note: According to Bundle spec each media section would have it's own transport
 			//       with it's own cert and fingerprint each, so we would need to return a list.
 			for _, mediaDescr := range desc.MediaDescriptions {
-				mediaFingerprint, haveFingerprint := mediaDescr.Attribute("fingerprint")
-				if haveFingerprint && fingerprint == "" {
-					fingerprint = mediaFingerprint
+				if len(values) == 0 {
+					values = fingerprintAttributes(mediaDescr.Attributes)
 				}
 			}
 		}
 	}
 
-	if fingerprint == "" {
-		return "", "", ErrSessionDescriptionNoFingerprint
+	if len(values) == 0 {
+		return nil, ErrSessionDescriptionNoFingerprint
 	}
 
-	parts := strings.Split(fingerprint, " ")
-	if len(parts) != 2 {
-		return "", "", ErrSessionDescriptionInvalidFingerprint
+	fingerprints := make([]DTLSFingerprint, 0, len(values))
+	for _, value := range values {
+		parts := strings.Split(value, " ")
+		if len(parts) != 2 {
+			return nil, ErrSessionDescriptionInvalidFingerprint
+		}
+		fingerprints = append(fingerprints, DTLSFingerprint{Algorithm: parts[0], Value: parts[1]})
 	}
 
-	return parts[1], parts[0], nil
+	return fingerprints, nil
 }
 
 // identifiedMediaDescription contains a MediaDescription with sdpMid and sdpMLineIndex.
@@ -1040,6 +1074,27 @@ func selectCandidateMediaSection(sessionDescription *sdp.SessionDescription) (
 	return nil, false
 }
 
+// msidsMatch returns true if the msid attributes of media associate the track with
+// exactly the streams of streamIDs, in order.
+func msidsMatch(media *sdp.MediaDescription, streamIDs []string, trackID string) bool {
+	msids := []string{}
+	for _, attr := range media.Attributes {
+		if attr.Key == sdp.AttrKeyMsid {
+			msids = append(msids, attr.Value)
+		}
+	}
+	if len(msids) != len(streamIDs) {
+		return false
+	}
+	for i, streamID := range streamIDs {
+		if msids[i] != streamID+" "+trackID {
+			return false
+		}
+	}
+
+	return true
+}
+
 func getByMid(searchMid string, desc *SessionDescription) *sdp.MediaDescription {
 	for _, m := range desc.parsed.MediaDescriptions {
 		if mid, ok := m.Attribute(sdp.AttrKeyMID); ok && mid == searchMid {
@@ -1158,6 +1213,27 @@ func isIceLiteSet(desc *sdp.SessionDescription) bool {
 	return false
 }
 
+// hasICEOption reports whether desc advertises the ICE option, at the session level or
+// at the level of a media section, RFC 8839 S5.6.
+func hasICEOption(desc *sdp.SessionDescription, option string) bool {
+	attributes := append([]sdp.Attribute{}, desc.Attributes...)
+	for _, media := range desc.MediaDescriptions {
+		attributes = append(attributes, media.Attributes...)
+	}
+	for _, a := range attributes {
+		if strings.TrimSpace(a.Key) != sdp.AttrKeyICEOptions {
+			continue
+		}
+		for _, value := range strings.Fields(a.Value) {
+			if value == option {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func isExtMapAllowMixedSet(desc *sdp.SessionDescription) bool {
 	for _, a := range desc.Attributes {
 		if strings.TrimSpace(a.Key) == sdp.AttrKeyExtMapAllowMixed {
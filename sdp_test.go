@@ -19,16 +19,15 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestExtractFingerprint(t *testing.T) {
+func TestExtractFingerprints(t *testing.T) {
 	t.Run("Good Session Fingerprint", func(t *testing.T) {
 		s := &sdp.SessionDescription{
 			Attributes: []sdp.Attribute{{Key: "fingerprint", Value: "foo bar"}},
 		}
 
-		fingerprint, hash, err := extractFingerprint(s)
+		fingerprints, err := extractFingerprints(s)
 		assert.NoError(t, err)
-		assert.Equal(t, fingerprint, "bar")
-		assert.Equal(t, hash, "foo")
+		assert.Equal(t, []DTLSFingerprint{{Algorithm: "foo", Value: "bar"}}, fingerprints)
 	})
 
 	t.Run("Good Media Fingerprint", func(t *testing.T) {
@@ -38,16 +37,15 @@ func TestExtractFingerprint(t *testing.T) {
 			},
 		}
 
-		fingerprint, hash, err := extractFingerprint(s)
+		fingerprints, err := extractFingerprints(s)
 		assert.NoError(t, err)
-		assert.Equal(t, fingerprint, "bar")
-		assert.Equal(t, hash, "foo")
+		assert.Equal(t, []DTLSFingerprint{{Algorithm: "foo", Value: "bar"}}, fingerprints)
 	})
 
 	t.Run("No Fingerprint", func(t *testing.T) {
 		s := &sdp.SessionDescription{}
 
-		_, _, err := extractFingerprint(s)
+		_, err := extractFingerprints(s)
 		assert.Equal(t, ErrSessionDescriptionNoFingerprint, err)
 	})
 
@@ -56,10 +54,29 @@ func TestExtractFingerprint(t *testing.T) {
 			Attributes: []sdp.Attribute{{Key: "fingerprint", Value: "foo"}},
 		}
 
-		_, _, err := extractFingerprint(s)
+		_, err := extractFingerprints(s)
 		assert.Equal(t, ErrSessionDescriptionInvalidFingerprint, err)
 	})
 
+	t.Run("Multiple Fingerprints", func(t *testing.T) {
+		s := &sdp.SessionDescription{
+			MediaDescriptions: []*sdp.MediaDescription{
+				{Attributes: []sdp.Attribute{
+					{Key: "fingerprint", Value: "sha-512 foo"},
+					{Key: "fingerprint", Value: "sha-256 bar"},
+				}},
+				{Attributes: []sdp.Attribute{{Key: "fingerprint", Value: "sha-1 zoo"}}},
+			},
+		}
+
+		fingerprints, err := extractFingerprints(s)
+		assert.NoError(t, err)
+		assert.Equal(t, []DTLSFingerprint{
+			{Algorithm: "sha-512", Value: "foo"},
+			{Algorithm: "sha-256", Value: "bar"},
+		}, fingerprints)
+	})
+
 	t.Run("Session fingerprint wins over media", func(t *testing.T) {
 		s := &sdp.SessionDescription{
 			Attributes: []sdp.Attribute{{Key: "fingerprint", Value: "foo bar"}},
@@ -68,10 +85,9 @@ func TestExtractFingerprint(t *testing.T) {
 			},
 		}
 
-		fingerprint, hash, err := extractFingerprint(s)
+		fingerprints, err := extractFingerprints(s)
 		assert.NoError(t, err)
-		assert.Equal(t, fingerprint, "bar")
-		assert.Equal(t, hash, "foo")
+		assert.Equal(t, []DTLSFingerprint{{Algorithm: "foo", Value: "bar"}}, fingerprints)
 	})
 
 	t.Run("Fingerprint from master bundle section", func(t *testing.T) {
@@ -91,10 +107,9 @@ func TestExtractFingerprint(t *testing.T) {
 			},
 		}
 
-		fingerprint, hash, err := extractFingerprint(descr)
+		fingerprints, err := extractFingerprints(descr)
 		assert.NoError(t, err)
-		assert.Equal(t, fingerprint, "foo")
-		assert.Equal(t, hash, "bar")
+		assert.Equal(t, []DTLSFingerprint{{Algorithm: "bar", Value: "foo"}}, fingerprints)
 	})
 
 	t.Run("Fingerprint from first media section", func(t *testing.T) {
@@ -111,10 +126,9 @@ func TestExtractFingerprint(t *testing.T) {
 			},
 		}
 
-		fingerprint, hash, err := extractFingerprint(descr)
+		fingerprints, err := extractFingerprints(descr)
 		assert.NoError(t, err)
-		assert.Equal(t, fingerprint, "boo")
-		assert.Equal(t, hash, "zoo")
+		assert.Equal(t, []DTLSFingerprint{{Algorithm: "zoo", Value: "boo"}}, fingerprints)
 	})
 }
 
@@ -588,6 +602,21 @@ func TestHaveApplicationMediaSection(t *testing.T) {
 	})
 }
 
+func TestHasICEOption(t *testing.T) {
+	assert.False(t, hasICEOption(&sdp.SessionDescription{}, iceOptionRenomination))
+	assert.False(t, hasICEOption(&sdp.SessionDescription{
+		Attributes: []sdp.Attribute{{Key: sdp.AttrKeyICEOptions, Value: "trickle"}},
+	}, iceOptionRenomination))
+	assert.True(t, hasICEOption(&sdp.SessionDescription{
+		Attributes: []sdp.Attribute{{Key: sdp.AttrKeyICEOptions, Value: "trickle renomination"}},
+	}, iceOptionRenomination))
+	assert.True(t, hasICEOption(&sdp.SessionDescription{
+		MediaDescriptions: []*sdp.MediaDescription{
+			{Attributes: []sdp.Attribute{{Key: sdp.AttrKeyICEOptions, Value: "renomination"}}},
+		},
+	}, iceOptionRenomination))
+}
+
 func TestMediaDescriptionFingerprints(t *testing.T) {
 	engine := &MediaEngine{}
 	assert.NoError(t, engine.RegisterDefaultCodecs())
@@ -642,6 +671,7 @@ func TestMediaDescriptionFingerprints(t *testing.T) {
 				dtlsFingerprints,
 				SDPMediaDescriptionFingerprints,
 				false,
+				nil,
 				true,
 				engine,
 				sdp.ConnectionRoleActive,
@@ -696,6 +726,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:cyclop,maintidx
 			[]DTLSFingerprint{},
 			se.sdpMediaLevelFingerprints,
 			se.candidates.ICELite,
+			nil,
 			true,
 			me,
 			connectionRoleFromDtlsRole(defaultDtlsRoleOffer),
@@ -755,6 +786,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:cyclop,maintidx
 			[]DTLSFingerprint{},
 			se.sdpMediaLevelFingerprints,
 			se.candidates.ICELite,
+			nil,
 			true,
 			me,
 			connectionRoleFromDtlsRole(defaultDtlsRoleOffer),
@@ -795,6 +827,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:cyclop,maintidx
 			[]DTLSFingerprint{},
 			se.sdpMediaLevelFingerprints,
 			se.candidates.ICELite,
+			nil,
 			true,
 			&MediaEngine{},
 			connectionRoleFromDtlsRole(defaultDtlsRoleOffer),
@@ -821,6 +854,34 @@ func TestPopulateSDP(t *testing.T) { //nolint:cyclop,maintidx
 		}
 		assert.Equal(t, true, found, "ICELite key should be present")
 	})
+	t.Run("ice-options", func(t *testing.T) {
+		se := SettingEngine{}
+		assert.Nil(t, se.getICEOptions())
+		se.SetICERenomination(true)
+
+		offerSdp, err := populateSDP(
+			&sdp.SessionDescription{},
+			false,
+			[]DTLSFingerprint{},
+			se.sdpMediaLevelFingerprints,
+			se.candidates.ICELite,
+			se.getICEOptions(),
+			true,
+			&MediaEngine{},
+			connectionRoleFromDtlsRole(defaultDtlsRoleOffer),
+			[]ICECandidate{},
+			ICEParameters{},
+			[]mediaSection{},
+			ICEGatheringStateComplete,
+			nil,
+			se.getSCTPMaxMessageSize(),
+		)
+		assert.Nil(t, err)
+
+		value, ok := offerSdp.Attribute(sdp.AttrKeyICEOptions)
+		assert.True(t, ok, "ice-options should be present")
+		assert.Equal(t, "renomination", value)
+	})
 	t.Run("rejected track", func(t *testing.T) {
 		se := SettingEngine{}
 
@@ -853,6 +914,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:cyclop,maintidx
 			[]DTLSFingerprint{},
 			se.sdpMediaLevelFingerprints,
 			se.candidates.ICELite,
+			nil,
 			true,
 			me,
 			connectionRoleFromDtlsRole(defaultDtlsRoleOffer),
@@ -886,6 +948,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:cyclop,maintidx
 			[]DTLSFingerprint{},
 			se.sdpMediaLevelFingerprints,
 			se.candidates.ICELite,
+			nil,
 			true,
 			&MediaEngine{},
 			connectionRoleFromDtlsRole(defaultDtlsRoleOffer),
@@ -917,6 +980,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:cyclop,maintidx
 			[]DTLSFingerprint{},
 			se.sdpMediaLevelFingerprints,
 			se.candidates.ICELite,
+			nil,
 			false, &MediaEngine{},
 			connectionRoleFromDtlsRole(defaultDtlsRoleOffer),
 			[]ICECandidate{},
@@ -960,6 +1024,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:cyclop,maintidx
 			[]DTLSFingerprint{},
 			se.sdpMediaLevelFingerprints,
 			se.candidates.ICELite,
+			nil,
 			true,
 			me,
 			connectionRoleFromDtlsRole(defaultDtlsRoleOffer),
@@ -1000,6 +1065,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:cyclop,maintidx
 			[]DTLSFingerprint{},
 			se.sdpMediaLevelFingerprints,
 			se.candidates.ICELite,
+			nil,
 			true,
 			me,
 			connectionRoleFromDtlsRole(defaultDtlsRoleOffer),
@@ -1042,6 +1108,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:cyclop,maintidx
 			[]DTLSFingerprint{},
 			se.sdpMediaLevelFingerprints,
 			se.candidates.ICELite,
+			nil,
 			true,
 			me,
 			connectionRoleFromDtlsRole(defaultDtlsRoleOffer),
@@ -1075,6 +1142,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:cyclop,maintidx
 			[]DTLSFingerprint{},
 			se.sdpMediaLevelFingerprints,
 			se.candidates.ICELite,
+			nil,
 			true,
 			me,
 			connectionRoleFromDtlsRole(defaultDtlsRoleOffer),
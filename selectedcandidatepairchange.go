@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+// SelectedCandidatePairChangeReason tells why the ICE transport switched to a new
+// candidate pair.
+type SelectedCandidatePairChangeReason int
+
+const (
+	// SelectedCandidatePairChangeReasonUnknown is the enum's zero-value.
+	SelectedCandidatePairChangeReasonUnknown SelectedCandidatePairChangeReason = iota
+
+	// SelectedCandidatePairChangeReasonInitial indicates the first candidate pair
+	// of the ICE transport has been selected.
+	SelectedCandidatePairChangeReasonInitial
+
+	// SelectedCandidatePairChangeReasonICERestart indicates the candidate pair has
+	// been selected by the connectivity checks of an ICE restart.
+	SelectedCandidatePairChangeReasonICERestart
+
+	// SelectedCandidatePairChangeReasonDisconnected indicates the previous candidate
+	// pair stopped working, the ICE transport got disconnected before switching.
+	SelectedCandidatePairChangeReasonDisconnected
+
+	// SelectedCandidatePairChangeReasonRenomination indicates the controlling agent
+	// nominated another candidate pair while the previous one was still working,
+	// typically one with a higher priority that succeeded its checks later.
+	SelectedCandidatePairChangeReasonRenomination
+
+	// SelectedCandidatePairChangeReasonDegraded indicates the previous candidate pair
+	// lost checks or had a longer round trip time than the candidate pair migrated to,
+	// see SettingEngine.SetICEPairMigration.
+	SelectedCandidatePairChangeReasonDegraded
+)
+
+const (
+	selectedCandidatePairChangeReasonInitialStr      = "initial"
+	selectedCandidatePairChangeReasonICERestartStr   = "ice-restart"
+	selectedCandidatePairChangeReasonDisconnectedStr = "disconnected"
+	selectedCandidatePairChangeReasonRenominationStr = "renomination"
+	selectedCandidatePairChangeReasonDegradedStr     = "degraded"
+)
+
+func (r SelectedCandidatePairChangeReason) String() string {
+	switch r {
+	case SelectedCandidatePairChangeReasonInitial:
+		return selectedCandidatePairChangeReasonInitialStr
+	case SelectedCandidatePairChangeReasonICERestart:
+		return selectedCandidatePairChangeReasonICERestartStr
+	case SelectedCandidatePairChangeReasonDisconnected:
+		return selectedCandidatePairChangeReasonDisconnectedStr
+	case SelectedCandidatePairChangeReasonRenomination:
+		return selectedCandidatePairChangeReasonRenominationStr
+	case SelectedCandidatePairChangeReasonDegraded:
+		return selectedCandidatePairChangeReasonDegradedStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// SelectedCandidatePairChange describes a switch of the ICE transport to a new
+// candidate pair, a migration from a host to a relay path for example.
+type SelectedCandidatePairChange struct {
+	// Pair is the newly selected candidate pair, its Local and Remote candidates
+	// tell the type, protocol and address of each end of the path.
+	Pair *ICECandidatePair
+	// Previous is the candidate pair selected before, nil for the first one.
+	Previous *ICECandidatePair
+	Reason   SelectedCandidatePairChangeReason
+}
@@ -4,6 +4,13 @@
 package webrtc
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
 	"github.com/pion/sdp/v3"
 )
 
@@ -23,3 +30,136 @@ func (sd *SessionDescription) Unmarshal() (*sdp.SessionDescription, error) {
 
 	return sd.parsed, err
 }
+
+// Scrub returns a copy of the SessionDescription safe to log or attach to bug reports.
+// The addresses of the origin, connection and candidate lines are replaced by
+// documentation addresses, the same address always by the same replacement, and the
+// ICE credentials are removed. The DTLS fingerprints are replaced by a hash of them,
+// so the descriptions of a session can still be matched.
+func (sd SessionDescription) Scrub() (SessionDescription, error) {
+	parsed, err := sd.Unmarshal()
+	if err != nil {
+		return SessionDescription{}, err
+	}
+
+	scrubber := &sdpScrubber{addresses: map[string]string{}}
+	parsed.Origin.UnicastAddress = scrubber.address(parsed.Origin.UnicastAddress)
+	scrubber.connectionInformation(parsed.ConnectionInformation)
+	scrubber.attributes(parsed.Attributes)
+	for _, media := range parsed.MediaDescriptions {
+		scrubber.connectionInformation(media.ConnectionInformation)
+		scrubber.attributes(media.Attributes)
+	}
+
+	return sd.fromParsed(parsed)
+}
+
+// Canonicalize returns a copy of the SessionDescription with its attributes sorted,
+// so descriptions with the same content but created in a different order, like the
+// candidates gathered concurrently, compare equal in tests. The order of the media
+// sections and of their formats, which are significant, is kept.
+func (sd SessionDescription) Canonicalize() (SessionDescription, error) {
+	parsed, err := sd.Unmarshal()
+	if err != nil {
+		return SessionDescription{}, err
+	}
+
+	sortAttributes(parsed.Attributes)
+	for _, media := range parsed.MediaDescriptions {
+		sortAttributes(media.Attributes)
+	}
+
+	return sd.fromParsed(parsed)
+}
+
+func (sd SessionDescription) fromParsed(parsed *sdp.SessionDescription) (SessionDescription, error) {
+	raw, err := parsed.Marshal()
+	if err != nil {
+		return SessionDescription{}, err
+	}
+
+	return SessionDescription{Type: sd.Type, SDP: string(raw), parsed: parsed}, nil
+}
+
+func sortAttributes(attributes []sdp.Attribute) {
+	sort.SliceStable(attributes, func(i, j int) bool {
+		if attributes[i].Key != attributes[j].Key {
+			return attributes[i].Key < attributes[j].Key
+		}
+
+		return attributes[i].Value < attributes[j].Value
+	})
+}
+
+// sdpScrubber replaces the sensitive values of a description.
+type sdpScrubber struct {
+	addresses                       map[string]string
+	ipv4Count, ipv6Count, hostCount int
+}
+
+// address returns the replacement of an IP address or a hostname. The unspecified
+// addresses commonly used as placeholders are kept.
+func (s *sdpScrubber) address(address string) string {
+	if address == "" || address == "0.0.0.0" || address == "::" {
+		return address
+	}
+	if replacement, ok := s.addresses[address]; ok {
+		return replacement
+	}
+
+	var replacement string
+	ip := net.ParseIP(address)
+	switch {
+	case ip != nil && ip.To4() != nil:
+		s.ipv4Count++
+		replacement = fmt.Sprintf("192.0.2.%d", (s.ipv4Count-1)%254+1)
+	case ip != nil:
+		s.ipv6Count++
+		replacement = fmt.Sprintf("2001:db8::%x", s.ipv6Count)
+	case strings.HasSuffix(address, ".local"):
+		s.hostCount++
+		replacement = fmt.Sprintf("scrubbed-%d.local", s.hostCount)
+	default:
+		s.hostCount++
+		replacement = fmt.Sprintf("scrubbed-%d.invalid", s.hostCount)
+	}
+	s.addresses[address] = replacement
+
+	return replacement
+}
+
+func (s *sdpScrubber) connectionInformation(connectionInformation *sdp.ConnectionInformation) {
+	if connectionInformation != nil && connectionInformation.Address != nil {
+		connectionInformation.Address.Address = s.address(connectionInformation.Address.Address)
+	}
+}
+
+func (s *sdpScrubber) attributes(attributes []sdp.Attribute) {
+	for i := range attributes {
+		attribute := &attributes[i]
+		switch attribute.Key {
+		case "ice-ufrag", "ice-pwd":
+			attribute.Value = "scrubbed"
+		case "fingerprint":
+			if algorithm, value, ok := strings.Cut(attribute.Value, " "); ok {
+				hash := sha256.Sum256([]byte(strings.ToUpper(value)))
+				attribute.Value = algorithm + " scrubbed-" + hex.EncodeToString(hash[:8])
+			}
+		case sdp.AttrKeyCandidate:
+			// foundation component transport priority address port typ type [raddr address rport port] ...
+			fields := strings.Fields(attribute.Value)
+			for j := range fields {
+				if j == 4 || (j > 0 && fields[j-1] == "raddr") {
+					fields[j] = s.address(fields[j])
+				}
+			}
+			attribute.Value = strings.Join(fields, " ")
+		case "rtcp":
+			// port [nettype addrtype address]
+			if fields := strings.Fields(attribute.Value); len(fields) == 4 {
+				fields[3] = s.address(fields[3])
+				attribute.Value = strings.Join(fields, " ")
+			}
+		}
+	}
+}
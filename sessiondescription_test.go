@@ -85,3 +85,93 @@ func TestSessionDescription_Unmarshal(t *testing.T) {
 	// check if the two parsed results _really_ match, could be affected by internal caching
 	assert.True(t, reflect.DeepEqual(parsed1, parsed2))
 }
+
+func TestSessionDescription_Scrub(t *testing.T) {
+	desc := SessionDescription{Type: SDPTypeOffer, SDP: `v=0
+o=- 4596489990601351948 2 IN IP4 203.0.113.7
+s=-
+t=0 0
+a=fingerprint:sha-256 0F:74:31:25:CB:A2:13:EC:28:6F:6D:2C:61:FF:5D:C2:BC:B9:DB:3D:98:14:8D:1A:BB:EA:33:0C:A4:60:A8:8E
+m=application 9 UDP/DTLS/SCTP webrtc-datachannel
+c=IN IP4 203.0.113.7
+a=rtcp:9 IN IP6 2001:db8:85a3::8a2e:370:7334
+a=ice-ufrag:fSFzpXkhwvZLemDF
+a=ice-pwd:GnYjMYfcolxKlMYkGsZRHEBvGfsZNJdT
+a=candidate:1 1 udp 2130706431 203.0.113.7 50000 typ host
+a=candidate:2 1 udp 1694498815 198.51.100.4 50001 typ srflx raddr 203.0.113.7 rport 50000
+a=candidate:3 1 udp 2130706431 6b7e1c3d-3a3b.local 50002 typ host
+`}
+
+	scrubbed, err := desc.Scrub()
+	assert.NoError(t, err)
+	assert.Equal(t, SDPTypeOffer, scrubbed.Type)
+	for _, sensitive := range []string{
+		"203.0.113.7", "198.51.100.4", "2001:db8:85a3", "6b7e1c3d", "fSFzpXkhwvZLemDF", "GnYjMYfcolxKlMYkGsZRHEBvGfsZNJdT", "0F:74",
+	} {
+		assert.NotContains(t, scrubbed.SDP, sensitive)
+	}
+
+	// The same address is always replaced by the same one
+	for _, expected := range []string{
+		"o=- 4596489990601351948 2 IN IP4 192.0.2.1\r\n",
+		"c=IN IP4 192.0.2.1\r\n",
+		"a=rtcp:9 IN IP6 2001:db8::1\r\n",
+		"a=candidate:1 1 udp 2130706431 192.0.2.1 50000 typ host\r\n",
+		"a=candidate:2 1 udp 1694498815 192.0.2.2 50001 typ srflx raddr 192.0.2.1 rport 50000\r\n",
+		"a=candidate:3 1 udp 2130706431 scrubbed-1.local 50002 typ host\r\n",
+		"a=ice-ufrag:scrubbed\r\n",
+	} {
+		assert.Contains(t, scrubbed.SDP, expected)
+	}
+
+	// Fingerprints are hashed, so descriptions can still be matched
+	again, err := desc.Scrub()
+	assert.NoError(t, err)
+	assert.Equal(t, scrubbed.SDP, again.SDP)
+	assert.Contains(t, scrubbed.SDP, "a=fingerprint:sha-256 scrubbed-")
+
+	_, err = SessionDescription{Type: SDPTypeOffer, SDP: "invalid"}.Scrub()
+	assert.Error(t, err)
+}
+
+func TestSessionDescription_Canonicalize(t *testing.T) {
+	const header = "v=0\r\no=- 1 2 IN IP4 0.0.0.0\r\ns=-\r\nt=0 0\r\n"
+	first := SessionDescription{Type: SDPTypeAnswer, SDP: header +
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111 0\r\n" +
+		"a=rtpmap:111 opus/48000/2\r\n" +
+		"a=rtpmap:0 PCMU/8000\r\n" +
+		"a=candidate:2 1 udp 1 192.0.2.2 2 typ host\r\n" +
+		"a=mid:0\r\n" +
+		"a=candidate:1 1 udp 1 192.0.2.1 1 typ host\r\n" +
+		"m=video 9 UDP/TLS/RTP/SAVPF 96\r\n" +
+		"a=mid:1\r\n",
+	}
+	second := SessionDescription{Type: SDPTypeAnswer, SDP: header +
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111 0\r\n" +
+		"a=candidate:1 1 udp 1 192.0.2.1 1 typ host\r\n" +
+		"a=mid:0\r\n" +
+		"a=candidate:2 1 udp 1 192.0.2.2 2 typ host\r\n" +
+		"a=rtpmap:0 PCMU/8000\r\n" +
+		"a=rtpmap:111 opus/48000/2\r\n" +
+		"m=video 9 UDP/TLS/RTP/SAVPF 96\r\n" +
+		"a=mid:1\r\n",
+	}
+	assert.NotEqual(t, first.SDP, second.SDP)
+
+	canonicalFirst, err := first.Canonicalize()
+	assert.NoError(t, err)
+	canonicalSecond, err := second.Canonicalize()
+	assert.NoError(t, err)
+	assert.Equal(t, canonicalFirst.SDP, canonicalSecond.SDP)
+
+	// The media sections and their formats keep their order
+	assert.Equal(t, header+
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111 0\r\n"+
+		"a=candidate:1 1 udp 1 192.0.2.1 1 typ host\r\n"+
+		"a=candidate:2 1 udp 1 192.0.2.2 2 typ host\r\n"+
+		"a=mid:0\r\n"+
+		"a=rtpmap:0 PCMU/8000\r\n"+
+		"a=rtpmap:111 opus/48000/2\r\n"+
+		"m=video 9 UDP/TLS/RTP/SAVPF 96\r\n"+
+		"a=mid:1\r\n", canonicalFirst.SDP)
+}
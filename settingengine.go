@@ -8,16 +8,22 @@ package webrtc
 
 import (
 	"context"
+	"crypto"
+	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"io"
 	"net"
+	"net/url"
 	"time"
 
 	"github.com/pion/dtls/v3"
 	dtlsElliptic "github.com/pion/dtls/v3/pkg/crypto/elliptic"
+	"github.com/pion/dtls/v3/pkg/crypto/fingerprint"
 	"github.com/pion/dtls/v3/pkg/protocol/handshake"
 	"github.com/pion/ice/v4"
 	"github.com/pion/logging"
+	"github.com/pion/sdp/v3"
 	"github.com/pion/stun/v3"
 	"github.com/pion/transport/v3"
 	"github.com/pion/transport/v3/packetio"
@@ -44,16 +50,23 @@ type SettingEngine struct {
 		ICEPrflxAcceptanceMinWait *time.Duration
 		ICERelayAcceptanceMinWait *time.Duration
 		ICESTUNGatherTimeout      *time.Duration
+		ICECheckInterval          *time.Duration
+		ICEConsentInterval        time.Duration
+		ICEConsentMaxFailures     uint16
 	}
 	candidates struct {
 		ICELite                  bool
 		ICENetworkTypes          []NetworkType
 		InterfaceFilter          func(string) (keep bool)
 		IPFilter                 func(net.IP) (keep bool)
+		CandidateFilter          func(candidate ICECandidate, remote bool) (keep bool)
 		NAT1To1IPs               []string
 		NAT1To1IPCandidateType   ICECandidateType
 		MulticastDNSMode         ice.MulticastDNSMode
 		MulticastDNSHostName     string
+		MulticastDNSTimeout      time.Duration
+		MulticastDNSInterfaces   []string
+		MulticastDNSCacheTTL     time.Duration
 		UsernameFragment         string
 		Password                 string
 		IncludeLoopbackCandidate bool
@@ -63,11 +76,22 @@ type SettingEngine struct {
 		SRTP  *uint
 		SRTCP *uint
 	}
+	srtpDecryptionFailure struct {
+		threshold int
+		window    time.Duration
+	}
+	pacing struct {
+		bitrate int
+		burst   int
+	}
 	dtls struct {
 		insecureSkipHelloVerify       bool
 		disableInsecureSkipVerify     bool
 		retransmissionInterval        time.Duration
 		ellipticCurves                []dtlsElliptic.Curve
+		cipherSuites                  []dtls.CipherSuiteID
+		signatureSchemes              []tls.SignatureScheme
+		verifyPeerCertificate         func(rawCerts [][]byte, fingerprints []DTLSFingerprint) error
 		connectContextMaker           func() (context.Context, func())
 		extendedMasterSecret          dtls.ExtendedMasterSecretType
 		clientAuth                    *dtls.ClientAuthType
@@ -78,15 +102,21 @@ type SettingEngine struct {
 		clientHelloMessageHook        func(handshake.MessageClientHello) handshake.Message
 		serverHelloMessageHook        func(handshake.MessageServerHello) handshake.Message
 		certificateRequestMessageHook func(handshake.MessageCertificateRequest) handshake.Message
+		fingerprintAlgorithms         []crypto.Hash
 	}
 	sctp struct {
 		maxReceiveBufferSize uint32
 		enableZeroChecksum   bool
 		rtoMax               time.Duration
 		maxMessageSize       uint32
+		maxSendBufferSize    uint32
+		maxOutboundStreams   uint16
 		minCwnd              uint32
 		fastRtxWnd           uint32
 		cwndCAStep           uint32
+		bundlingDelay        time.Duration
+
+		enableReliabilityStats bool
 	}
 	sdpMediaLevelFingerprints                 bool
 	answeringDTLSRole                         DTLSRole
@@ -100,16 +130,49 @@ type SettingEngine struct {
 	iceUDPMux                                 ice.UDPMux
 	iceProxyDialer                            proxy.Dialer
 	iceDisableActiveTCP                       bool
+	iceDisableRestartOnCredentialsChange      bool
+	iceRenomination                           bool
 	iceBindingRequestHandler                  func(m *stun.Message, local, remote ice.Candidate, pair *ice.CandidatePair) bool //nolint:lll
+	offerAdmissionHandler                     func(*PeerConnection, OfferSummary) error
+	localSDPTransform                         func(*PeerConnection, SDPType, *sdp.SessionDescription) error
+	remoteSDPTransform                        func(*PeerConnection, SDPType, *sdp.SessionDescription) error
 	disableMediaEngineCopy                    bool
 	disableMediaEngineMultipleCodecs          bool
 	srtpProtectionProfiles                    []dtls.SRTPProtectionProfile
+	srtpKeyExport                             bool
 	receiveMTU                                uint
+	sendMTU                                   uint
+	pathMTUMax                                uint
 	iceMaxBindingRequests                     *uint16
 	fireOnTrackBeforeFirstRTP                 bool
 	disableCloseByDTLS                        bool
 	dataChannelBlockWrite                     bool
 	handleUndeclaredSSRCWithoutAnswer         bool
+	answerDirectionPolicy                     AnswerDirectionPolicy
+	pipelineMetrics                           bool
+	iceServerProbe                            struct {
+		servers  []ICEServer
+		timeout  time.Duration
+		interval time.Duration
+	}
+	icePairMigration struct {
+		interval, rttMargin time.Duration
+	}
+	handlerWorkers   int
+	ecn              bool
+	iceReusePort     bool
+	iceDSCP          uint8
+	ephemeralTURNTCP PortRange
+	ephemeralRelay   PortRange
+	qualityInterval  time.Duration
+	iceRestartPolicy time.Duration
+	networkMonitor   time.Duration
+	iceNetworkCost   func(interfaceName string) uint16
+}
+
+// keepICECandidate reports whether the candidate passes the filter of SetICECandidateFilter.
+func (e *SettingEngine) keepICECandidate(candidate ICECandidate, remote bool) bool {
+	return e.candidates.CandidateFilter == nil || e.candidates.CandidateFilter(candidate, remote)
 }
 
 func (e *SettingEngine) getSCTPMaxMessageSize() uint32 {
@@ -120,6 +183,46 @@ func (e *SettingEngine) getSCTPMaxMessageSize() uint32 {
 	return defaultMaxSCTPMessageSize
 }
 
+func (e *SettingEngine) getSRTPDecryptionFailureThreshold() (int, time.Duration) {
+	window := e.srtpDecryptionFailure.window
+	if window <= 0 {
+		window = defaultSRTPDecryptionFailureWindow
+	}
+
+	return e.srtpDecryptionFailure.threshold, window
+}
+
+// getDTLSFingerprintAlgorithms returns the algorithms of the local fingerprints, sha-256 by default.
+func (e *SettingEngine) getDTLSFingerprintAlgorithms() []crypto.Hash {
+	if len(e.dtls.fingerprintAlgorithms) != 0 {
+		return e.dtls.fingerprintAlgorithms
+	}
+
+	return []crypto.Hash{crypto.SHA256}
+}
+
+// getICEOptions returns the ICE options advertised in the descriptions generated.
+func (e *SettingEngine) getICEOptions() []string {
+	if e.hasICERenomination() {
+		return []string{iceOptionRenomination}
+	}
+
+	return nil
+}
+
+// hasICERenomination reports whether the agent follows the renominations of the remote,
+// see SetICERenomination, the pairs migrated to by SetICEPairMigration are renominated.
+func (e *SettingEngine) hasICERenomination() bool {
+	return e.iceRenomination || e.icePairMigration.interval > 0
+}
+
+// hasMulticastDNSResolverOptions reports whether the mDNS resolution is tuned, see
+// SetMulticastDNSResolveTimeout, SetMulticastDNSInterfaces and SetMulticastDNSCacheTTL.
+func (e *SettingEngine) hasMulticastDNSResolverOptions() bool {
+	return e.candidates.MulticastDNSTimeout > 0 || len(e.candidates.MulticastDNSInterfaces) > 0 ||
+		e.candidates.MulticastDNSCacheTTL > 0
+}
+
 // getReceiveMTU returns the configured MTU. If SettingEngine's MTU is configured to 0 it returns the default.
 func (e *SettingEngine) getReceiveMTU() uint {
 	if e.receiveMTU != 0 {
@@ -129,6 +232,15 @@ func (e *SettingEngine) getReceiveMTU() uint {
 	return receiveMTU
 }
 
+// getSendMTU returns the configured send MTU, outboundMTU if it is 0.
+func (e *SettingEngine) getSendMTU() uint {
+	if e.sendMTU != 0 {
+		return e.sendMTU
+	}
+
+	return outboundMTU
+}
+
 // DetachDataChannels enables detaching data channels. When enabled
 // data channels have to be detached in the OnOpen callback using the
 // DataChannel.Detach method.
@@ -144,10 +256,22 @@ func (e *SettingEngine) EnableDataChannelBlockWrite(nonblockWrite bool) {
 
 // SetSRTPProtectionProfiles allows the user to override the default SRTP Protection Profiles
 // The default srtp protection profiles are provided by the function `defaultSrtpProtectionProfiles`.
+// The profiles are offered in the DTLS handshake in the given order, the most preferred
+// first. The AES-GCM ones, the AES-CM ones with HMAC-SHA1, of 128 or 256 bits, and the
+// NULL ones are supported. Restricting them to SRTP_AEAD_AES_256_GCM and
+// SRTP_AES256_CM_SHA1_80, for example, only allows 256-bit keys.
 func (e *SettingEngine) SetSRTPProtectionProfiles(profiles ...dtls.SRTPProtectionProfile) {
 	e.srtpProtectionProfiles = profiles
 }
 
+// EnableSRTPKeyExport allows DTLSTransport.ExportSRTPKeyingMaterial to return the SRTP
+// master keys and salts negotiated in the DTLS handshake, so that an external recorder
+// can decrypt a copy of the media. Anyone holding them can decrypt the media and forge
+// packets of the session, they must be protected like a private key.
+func (e *SettingEngine) EnableSRTPKeyExport(isEnabled bool) {
+	e.srtpKeyExport = isEnabled
+}
+
 // SetICETimeouts sets the behavior around ICE Timeouts
 //
 // disconnectedTimeout:
@@ -163,12 +287,66 @@ func (e *SettingEngine) SetSRTPProtectionProfiles(profiles ...dtls.SRTPProtectio
 //	How often the ICE Agent sends extra traffic if there is no activity, if media is flowing no traffic will be sent.
 //
 // Default is 2 seconds.
+//
+// PeerConnection.SetICETimeouts tightens them on a live PeerConnection.
 func (e *SettingEngine) SetICETimeouts(disconnectedTimeout, failedTimeout, keepAliveInterval time.Duration) {
 	e.timeout.ICEDisconnectedTimeout = &disconnectedTimeout
 	e.timeout.ICEFailedTimeout = &failedTimeout
 	e.timeout.ICEKeepaliveInterval = &keepAliveInterval
 }
 
+// SetICEConsentFreshness enables the consent freshness of RFC 7675: a binding request is
+// sent on the selected candidate pair every interval, replacing the keepAliveInterval of
+// SetICETimeouts, and the ICETransport fails once maxFailures of them in a row went
+// unanswered, even if other packets are still received. The PeerConnection then fails
+// with ErrICEConsentExpired. RFC 7675 recommends an interval of 5 seconds and 6 failures.
+// An interval of 0, the default, disables it. A lite agent, see SetLite, sends no binding
+// requests and ignores it, consent freshness only applies to full agents.
+func (e *SettingEngine) SetICEConsentFreshness(interval time.Duration, maxFailures uint16) error {
+	if interval < 0 || (interval > 0 && maxFailures == 0) {
+		return errInvalidICEConsentFreshness
+	}
+	e.timeout.ICEConsentInterval = interval
+	e.timeout.ICEConsentMaxFailures = maxFailures
+
+	return nil
+}
+
+// SetICEPairMigration keeps checking the candidate pairs once one is selected, and migrates
+// to another pair when the selected one degrades, without an ICE restart, from Wi-Fi to
+// Ethernet for instance. A binding request is sent on each UDP pair of host and server
+// reflexive local candidates every interval. Once 2 of the last 5 checks of the selected
+// pair are lost, or once its round trip time exceeds the one of another pair by more than
+// rttMargin, the controlling agent nominates the pair whose last 5 checks were answered
+// with the lowest round trip time, and sends on it once the remote checks it back. The
+// migration is a renomination, the pairs are only migrated once the remote advertised the
+// renomination ICE option, which SetICEPairMigration advertises like SetICERenomination:
+// when controlled the agent follows the renominations of the remote. Nothing is
+// checked with SetICEUDPMux. An interval of 0, the default, disables the migration, a
+// rttMargin of 0 migrates on loss only.
+func (e *SettingEngine) SetICEPairMigration(interval, rttMargin time.Duration) error {
+	if interval < 0 || rttMargin < 0 {
+		return errInvalidICEPairMigration
+	}
+	e.icePairMigration.interval = interval
+	e.icePairMigration.rttMargin = rttMargin
+
+	return nil
+}
+
+// SetICENetworkCost sets the cost of the network interfaces, to prefer Ethernet over a
+// metered cellular interface for instance. The local preference of the priority of the
+// candidates gathered on an interface, or on its address for the reflexive ones, is
+// lowered by its cost, down to 0: the candidates of the same type are preferred on the
+// cheapest interface, a host candidate is still preferred to a server reflexive one.
+// The priorities are those signaled, a remote controlling agent nominates the pairs of
+// the cheapest interfaces. The local agent doesn't see them, as the controlling agent
+// it migrates to the pairs of the cheapest interfaces once one is selected if
+// SetICEPairMigration is enabled, see there. The relay candidates cost nothing.
+func (e *SettingEngine) SetICENetworkCost(cost func(interfaceName string) uint16) {
+	e.iceNetworkCost = cost
+}
+
 // SetHostAcceptanceMinWait sets the ICEHostAcceptanceMinWait.
 func (e *SettingEngine) SetHostAcceptanceMinWait(t time.Duration) {
 	e.timeout.ICEHostAcceptanceMinWait = &t
@@ -194,6 +372,56 @@ func (e *SettingEngine) SetSTUNGatherTimeout(t time.Duration) {
 	e.timeout.ICESTUNGatherTimeout = &t
 }
 
+// SetICECheckInterval sets how often the ICE Agent sends a binding request on the
+// candidate pairs it is checking. A pair is pruned as failed once it didn't get
+// a response to SetICEMaxBindingRequests requests, so with the defaults of 200ms
+// and 7 requests a failed candidate is pruned after about 1.4 seconds. Shorter
+// intervals detect failures faster, longer ones suit high latency paths.
+func (e *SettingEngine) SetICECheckInterval(t time.Duration) {
+	e.timeout.ICECheckInterval = &t
+}
+
+// SetICEServerProbe makes the API probe the URLs of servers in the background, sending a
+// STUN binding request to the UDP servers and connecting to the TCP and TLS ones. The
+// URLs found unreachable are dropped from the ICE servers the PeerConnections created
+// with the API gather with, their GetConfiguration is unchanged. The URLs which weren't
+// probed yet are used, including by the PeerConnections created before the first probe
+// completes. The servers are probed again every interval, 0 uses 5 minutes, once a
+// PeerConnection is created or GetStats is called. timeout is how long a probe waits
+// for the servers to answer, 0 uses 2 seconds. GetStats reports the probe of each
+// URL as an ICEServerStats.
+func (e *SettingEngine) SetICEServerProbe(servers []ICEServer, timeout, interval time.Duration) {
+	e.iceServerProbe.servers = servers
+	e.iceServerProbe.timeout = timeout
+	e.iceServerProbe.interval = interval
+}
+
+// SetQualityInterval sets how often the quality of a PeerConnection is evaluated once
+// an OnQualityChange handler is set, 0 uses the default of 2 seconds.
+func (e *SettingEngine) SetQualityInterval(interval time.Duration) {
+	e.qualityInterval = interval
+}
+
+// SetICERestartPolicy makes the PeerConnections restart ICE on their own once their
+// ICEConnectionState stayed disconnected or failed for after, as RestartICE does:
+// OnNegotiationNeeded is fired and the next offer created restarts ICE. The restart is
+// requested again every after until the connection recovers. 0, the default, disables
+// the automatic restarts.
+func (e *SettingEngine) SetICERestartPolicy(after time.Duration) {
+	e.iceRestartPolicy = after
+}
+
+// SetNetworkMonitor makes the PeerConnections poll the addresses of the network
+// interfaces every interval, through the Net of SetNet, filtered like the host candidates
+// with SetInterfaceFilter, SetIPFilter, SetNetworkTypes and SetIncludeLoopbackCandidate.
+// Once an address is added or removed, a NetworkChangeEvent is emitted and, if candidates
+// were gathered, ICE is restarted as RestartICE does: OnNegotiationNeeded is fired and
+// the next offer gathers the candidates of the new addresses, dropping those of the
+// addresses removed. 0, the default, disables the monitor.
+func (e *SettingEngine) SetNetworkMonitor(interval time.Duration) {
+	e.networkMonitor = interval
+}
+
 // SetEphemeralUDPPortRange limits the pool of ephemeral ports that
 // ICE UDP connections can allocate from. This affects both host candidates,
 // and the local address of server reflexive candidates.
@@ -211,7 +439,33 @@ func (e *SettingEngine) SetEphemeralUDPPortRange(portMin, portMax uint16) error
 	return nil
 }
 
+// SetEphemeralPortRanges limits the pools of ephemeral ports that ICE allocates from,
+// with a range for each use so that the firewall rules can tell them apart. The UDP range
+// is the one of SetEphemeralUDPPortRange. A zero PortRange leaves the choice of the port
+// to the OS. The TURNTCP and Relay ranges are applied on the sockets created by the Net of
+// SetNet, stdnet when unset. The active ICE-TCP connections are dialed by ICE without the
+// Net, they are not covered by a range.
+func (e *SettingEngine) SetEphemeralPortRanges(ranges EphemeralPortRanges) error {
+	for _, portRange := range []PortRange{ranges.UDP, ranges.TURNTCP, ranges.Relay} {
+		if err := portRange.validate(); err != nil {
+			return err
+		}
+	}
+
+	e.ephemeralUDP.PortMin = ranges.UDP.Min
+	e.ephemeralUDP.PortMax = ranges.UDP.Max
+	e.ephemeralTURNTCP = ranges.TURNTCP
+	e.ephemeralRelay = ranges.Relay
+
+	return nil
+}
+
 // SetLite configures whether or not the ice agent should be a lite agent.
+// A lite agent, for a server with a public address, only gathers host candidates,
+// takes the controlled role against a full agent and never initiates connectivity
+// checks: it only answers those of the remote, which saves CPU per connection.
+// The descriptions it generates carry the ice-lite attribute, and the ICEParameters
+// of its ICEGatherer have ICELite set.
 func (e *SettingEngine) SetLite(lite bool) {
 	e.candidates.ICELite = lite
 }
@@ -238,6 +492,21 @@ func (e *SettingEngine) SetIPFilter(filter func(net.IP) (keep bool)) {
 	e.candidates.IPFilter = filter
 }
 
+// SetICECandidateFilter sets a function deciding which ICE candidates are signaled, called
+// with remote set to false for each local candidate gathered and to true for each remote
+// candidate signaled. Unlike SetInterfaceFilter and SetIPFilter it can decide on the
+// type, the address, the protocol and the port of the candidate. The local candidates
+// dropped are not signaled to the remote peer and the remote ones are not added to the
+// ICE agent. It only filters the signaling though: the dropped local candidates stay in
+// the ICE agent, which still sends checks from them, and the remote can learn them, as
+// the agent can learn the dropped remote ones, as peer-reflexive candidates that may be
+// selected. Use SetInterfaceFilter and SetIPFilter to keep link-local or VPN addresses
+// out of the ICE agent altogether. The filter must not block, it is called while
+// gathering and applying the remote descriptions.
+func (e *SettingEngine) SetICECandidateFilter(filter func(candidate ICECandidate, remote bool) (keep bool)) {
+	e.candidates.CandidateFilter = filter
+}
+
 // SetNAT1To1IPs sets a list of external IP addresses of 1:1 (D)NAT
 // and a candidate type for which the external IP address is used.
 // This is useful when you host a server using Pion on an AWS EC2 instance
@@ -316,6 +585,27 @@ func (e *SettingEngine) SetMulticastDNSHostName(hostName string) {
 	e.candidates.MulticastDNSHostName = hostName
 }
 
+// SetMulticastDNSResolveTimeout sets how long the mDNS name of a remote candidate is
+// queried before the candidate is dropped. By default it is queried until the agent closes.
+// Setting it, or SetMulticastDNSInterfaces or SetMulticastDNSCacheTTL, makes the
+// PeerConnection resolve the remote mDNS candidates itself rather than pion/ice.
+func (e *SettingEngine) SetMulticastDNSResolveTimeout(timeout time.Duration) {
+	e.candidates.MulticastDNSTimeout = timeout
+}
+
+// SetMulticastDNSInterfaces sets the names of the network interfaces the mDNS names of
+// the remote candidates are queried on, instead of all the multicast interfaces. It lets
+// multi-homed servers query on the interface of their peers only.
+func (e *SettingEngine) SetMulticastDNSInterfaces(interfaces ...string) {
+	e.candidates.MulticastDNSInterfaces = interfaces
+}
+
+// SetMulticastDNSCacheTTL keeps the addresses of the mDNS names resolved for ttl, the
+// cache is shared by the PeerConnections of the API. 0, the default, disables it.
+func (e *SettingEngine) SetMulticastDNSCacheTTL(ttl time.Duration) {
+	e.candidates.MulticastDNSCacheTTL = ttl
+}
+
 // SetICECredentials sets a staic uFrag/uPwd to be used by pion/ice
 //
 // This is useful if you want to do signalless WebRTC session,
@@ -325,6 +615,18 @@ func (e *SettingEngine) SetICECredentials(usernameFragment, password string) {
 	e.candidates.Password = password
 }
 
+// SetDTLSVerifyPeerCertificate sets a function called during the DTLS handshake with the
+// certificate chain sent by the remote peer, DER encoded with the leaf first, and the
+// fingerprints of its description. It lets applications pin the certificates of their
+// peers or verify their identity out of band. The handshake is aborted, and the
+// DTLSTransport fails, if it returns an error. The fingerprints are still verified after
+// the handshake, unless disabled with DisableCertificateFingerprintVerification.
+func (e *SettingEngine) SetDTLSVerifyPeerCertificate(
+	verify func(rawCerts [][]byte, fingerprints []DTLSFingerprint) error,
+) {
+	e.dtls.verifyPeerCertificate = verify
+}
+
 // DisableCertificateFingerprintVerification disables fingerprint verification after DTLS Handshake has finished.
 func (e *SettingEngine) DisableCertificateFingerprintVerification(isDisabled bool) {
 	e.disableCertificateFingerprintVerification = isDisabled
@@ -347,6 +649,94 @@ func (e *SettingEngine) SetSRTCPReplayProtectionWindow(n uint) {
 	e.replayProtection.SRTCP = &n
 }
 
+// SetSRTPDecryptionFailureThreshold sets how many packets of an SSRC must fail to decrypt
+// within window before DTLSTransport.OnSRTPDecryptionFailure is fired and the rollover
+// counter of the SSRC is re-estimated, 16 packets within one second is a good start. The
+// packets rejected by the replay protection aren't failures. A window of 0 defaults to one
+// second. The detection is disabled by default, and with a threshold of 0 or less, as it
+// costs a copy and a header parsing for every incoming packet.
+func (e *SettingEngine) SetSRTPDecryptionFailureThreshold(failures int, window time.Duration) {
+	e.srtpDecryptionFailure.threshold = failures
+	e.srtpDecryptionFailure.window = window
+}
+
+// SetAnswerDirectionPolicy constrains the directions of the answers created by the
+// PeerConnections of the API, see AnswerDirectionPolicy. It can be overridden for a
+// PeerConnection with PeerConnection.SetAnswerDirectionPolicy. This is useful for
+// servers only receiving or only sending a kind of media.
+func (e *SettingEngine) SetAnswerDirectionPolicy(policy AnswerDirectionPolicy) {
+	e.answerDirectionPolicy = policy
+}
+
+// SetPacing enables a leaky bucket pacer shared by all the RTPSenders of a PeerConnection.
+// The outgoing RTP, including retransmissions and FEC, is smoothed to bitrate in bits per
+// second, allowing bursts of up to burst bytes. Packets exceeding the rate are queued and
+// sent as the bucket drains, the writes don't block but fail once too many packets are
+// queued. A bitrate of 0 or less disables the pacing, the default. The rate can be
+// changed at runtime with PeerConnection.SetPacing.
+func (e *SettingEngine) SetPacing(bitrate, burst int) {
+	e.pacing.bitrate = bitrate
+	e.pacing.burst = burst
+}
+
+// EnablePipelineMetrics times the stages of the processing of the incoming RTP packets,
+// the SRTP decryption, the interceptors and the parsing by TrackRemote.ReadRTP. The
+// timings are reported by PeerConnection.GetStats as a PipelineStageStats per stage,
+// allowing to localize where packets are delayed without a profiler.
+// They are disabled by default as they read the clock several times per packet.
+func (e *SettingEngine) EnablePipelineMetrics(enabled bool) {
+	e.pipelineMetrics = enabled
+}
+
+// SetHandlerWorkers makes the PeerConnections of an API share a pool of at most workers
+// goroutines to fire the signaling state, connection state and SCTP close and error
+// handlers, instead of starting a goroutine per event. This bounds the goroutines of
+// servers hosting many PeerConnections when their states change at once, e.g. on a
+// network outage. The handlers run on the pool must not block, as they delay the
+// handlers of all the PeerConnections. 0, the default, disables the pool.
+//
+// Only these event handlers are pooled. The read loops of the transports, the DTLS
+// and SCTP associations and the RTP streams still run a goroutine each per
+// PeerConnection, as they block on the net.Conn chain of the ICE agent.
+func (e *SettingEngine) SetHandlerWorkers(workers int) {
+	e.handlerWorkers = workers
+}
+
+// EnableECN marks the RTP and RTCP packets sent on the UDP sockets gathered by ICE as
+// ECN-Capable Transport ECT(1), and counts the ECN marks reported by the RFC 8888 congestion control
+// feedback received for the local streams, see RTPSender.ECNCounts. The counts are fed
+// to the cc.BandwidthEstimator of the PeerConnection if it implements ECNFeedbackHandler.
+// This is the groundwork for L4S congestion control. The feedback is parsed from the RTCP
+// read from the RTPSender. The STUN, DTLS and SCTP packets are not marked, nor is the
+// media relayed by TURN. Sockets provided with SetICEUDPMux are not marked, and marking
+// is only supported on unix platforms.
+func (e *SettingEngine) EnableECN(enabled bool) {
+	e.ecn = enabled
+}
+
+// SetICEReusePort sets SO_REUSEPORT on the UDP sockets gathered by ICE, so that several
+// processes can bind the same port, set with SetEphemeralUDPPortRange, and the kernel
+// balances the traffic between them. Sockets provided with SetICEUDPMux or created by
+// the Net of SetNet are left as is, and SO_REUSEPORT is only supported on Linux, the
+// BSDs and macOS.
+func (e *SettingEngine) SetICEReusePort(enabled bool) {
+	e.iceReusePort = enabled
+}
+
+// SetICEDSCP sets the Differentiated Services Code Point of the packets sent on the UDP
+// sockets gathered by ICE, 46 for Expedited Forwarding for example. DSCP values are
+// defined by RFC 2474, their use for WebRTC by RFC 8837. The media keeps the ECN bits of
+// EnableECN. Sockets provided with SetICEUDPMux are not marked, and marking is only supported
+// on unix platforms. 0, the default, leaves the marking to the OS.
+func (e *SettingEngine) SetICEDSCP(dscp uint8) error {
+	if dscp > maxDSCP {
+		return errInvalidDSCP
+	}
+	e.iceDSCP = dscp
+
+	return nil
+}
+
 // DisableSRTPReplayProtection disables SRTP replay protection.
 func (e *SettingEngine) DisableSRTPReplayProtection(isDisabled bool) {
 	e.disableSRTPReplayProtection = isDisabled
@@ -379,21 +769,62 @@ func (e *SettingEngine) SetICEUDPMux(udpMux ice.UDPMux) {
 }
 
 // SetICEProxyDialer sets the proxy dialer interface based on golang.org/x/net/proxy.
+// It is used to connect to the TURN servers over TCP and TLS.
 func (e *SettingEngine) SetICEProxyDialer(d proxy.Dialer) {
 	e.iceProxyDialer = d
 }
 
+// SetICEProxy connects to the TURN servers over TCP and TLS through the proxy of
+// proxyURL, an HTTP proxy with the http and https schemes, see NewHTTPProxyDialer,
+// or a SOCKS5 one with the socks5 scheme. It replaces the dialer of SetICEProxyDialer.
+func (e *SettingEngine) SetICEProxy(proxyURL *url.URL) error {
+	var (
+		dialer proxy.Dialer
+		err    error
+	)
+	switch proxyURL.Scheme {
+	case "http", "https":
+		dialer, err = NewHTTPProxyDialer(proxyURL, nil)
+	case "socks5", "socks5h":
+		dialer, err = proxy.FromURL(proxyURL, proxy.Direct)
+	default:
+		err = fmt.Errorf("%w: %s", errICEProxyUnsupportedScheme, proxyURL.Scheme)
+	}
+	if err != nil {
+		return err
+	}
+	e.iceProxyDialer = dialer
+
+	return nil
+}
+
 // SetICEMaxBindingRequests sets the maximum amount of binding requests
 // that can be sent on a candidate before it is considered invalid.
+// The requests are retransmitted every SetICECheckInterval, default is 7.
 func (e *SettingEngine) SetICEMaxBindingRequests(d uint16) {
 	e.iceMaxBindingRequests = &d
 }
 
 // DisableActiveTCP disables using active TCP for ICE. Active TCP is enabled by default.
+// With NetworkTypeTCP4 or NetworkTypeTCP6 set with SetNetworkTypes, the agent dials the
+// remote passive TCP candidates, those of a server with SetICETCPMux for instance, and
+// the active candidates dialing them are gathered as they are added. A client behind a
+// firewall blocking UDP reaches such a server without TCPMux nor TURN. The candidates
+// are dialed with the net package, not the Net of SetNet nor the proxy of
+// SetICEProxyDialer.
 func (e *SettingEngine) DisableActiveTCP(isDisabled bool) {
 	e.iceDisableActiveTCP = isDisabled
 }
 
+// DisableICERestartOnRemoteCredentialsChange makes a remote offer changing the ICE credentials
+// only update the credentials used by the connectivity checks, instead of restarting ICE.
+// Some gateways rotate their ufrag in re-offers without intending a restart, which would
+// drop the selected candidate pair and gather new local candidates. The local credentials
+// are kept and an ICERemoteCredentialsChangeEvent is emitted either way.
+func (e *SettingEngine) DisableICERestartOnRemoteCredentialsChange(isDisabled bool) {
+	e.iceDisableRestartOnCredentialsChange = isDisabled
+}
+
 // DisableMediaEngineCopy stops the MediaEngine from being copied. This allows a user to modify
 // the MediaEngine after the PeerConnection has been constructed. This is useful if you wish to
 // modify codecs after signaling. Make sure not to share MediaEngines between PeerConnections.
@@ -417,6 +848,39 @@ func (e *SettingEngine) SetReceiveMTU(receiveMTU uint) {
 	e.receiveMTU = receiveMTU
 }
 
+// SetSendMTU sets the maximum size of the RTP packets packetized by TrackLocalStaticSample,
+// and of the SCTP packets, before their encryption. This is optional. Leave this 0 for the
+// default of 1200 bytes, which fits the links with a smaller MTU than Ethernet, like VPNs.
+// The size of the RTP packets follows the path MTU instead once it is discovered, see
+// SetPathMTUDiscovery.
+func (e *SettingEngine) SetSendMTU(sendMTU uint) {
+	e.sendMTU = sendMTU
+}
+
+// SetPathMTUDiscovery enables the discovery of the path MTU of the selected candidate pair
+// of ICE, in the style of the Datagram PLPMTUD of RFC 8899, up to maxSize bytes of UDP
+// payload. Binding requests padded to the size probed are sent to the remote like the
+// connectivity checks, a size is delivered once their response is received. The send MTU
+// is probed first, then the search continues above or below it, and it runs again when the
+// selected pair changes and every 10 minutes. The RTP packets are then sized to the path MTU,
+// leaving 64 bytes for the SRTP authentication tag and the header extensions, see
+// the MTU of the TrackLocalContext, while the SCTP packets keep the send MTU.
+//
+// maxSize must not exceed the size of the packets the remote reads: a Pion remote reads up
+// to its receive MTU, 1500 bytes by default, see SetReceiveMTU. Only the UDP pairs of host
+// and server reflexive candidates are probed, on the sockets gathered by ICE, so nothing is
+// probed with SetICEUDPMux. The probes need the Don't Fragment bit, which is only set on
+// Linux, and only for the sending of each probe: the media and data packets are fragmented
+// as the sockets are set. 0, the default, disables the discovery.
+func (e *SettingEngine) SetPathMTUDiscovery(maxSize uint) error {
+	if maxSize != 0 && maxSize < pathMTUMinProbeSize {
+		return errInvalidPathMTUDiscovery
+	}
+	e.pathMTUMax = maxSize
+
+	return nil
+}
+
 // SetDTLSRetransmissionInterval sets the retranmission interval for DTLS.
 func (e *SettingEngine) SetDTLSRetransmissionInterval(interval time.Duration) {
 	e.dtls.retransmissionInterval = interval
@@ -441,6 +905,39 @@ func (e *SettingEngine) SetDTLSEllipticCurves(ellipticCurves ...dtlsElliptic.Cur
 	e.dtls.ellipticCurves = ellipticCurves
 }
 
+// SetDTLSCipherSuites restricts the DTLS cipher suites to cipherSuites, in the order of
+// preference of the client. The suites must match the key of the certificates, the
+// ECDSA ones for the default certificates. This, SetDTLSSignatureSchemes and
+// SetDTLSEllipticCurves, with SetSRTPProtectionProfiles, keep the handshake to the
+// approved algorithms of FIPS-constrained environments. The default suites of
+// pion/dtls are used when unset.
+func (e *SettingEngine) SetDTLSCipherSuites(cipherSuites ...dtls.CipherSuiteID) {
+	e.dtls.cipherSuites = cipherSuites
+}
+
+// SetDTLSSignatureSchemes restricts the signature schemes of the DTLS handshake to
+// signatureSchemes, in the order of preference. The default schemes of pion/dtls are
+// used when unset.
+func (e *SettingEngine) SetDTLSSignatureSchemes(signatureSchemes ...tls.SignatureScheme) {
+	e.dtls.signatureSchemes = signatureSchemes
+}
+
+// SetDTLSFingerprintAlgorithms sets the hash algorithms of the fingerprints of the local
+// certificates in SDP, a fingerprint line is added per algorithm in the order of preference.
+// Some gateways refuse sha-256 only descriptions and require a sha-384 or sha-512 fingerprint.
+// The remote certificate is accepted if it matches any of the remote fingerprints, whatever
+// their algorithms. sha-256 is used by default.
+func (e *SettingEngine) SetDTLSFingerprintAlgorithms(algorithms ...crypto.Hash) error {
+	for _, algorithm := range algorithms {
+		if _, err := fingerprint.StringFromHash(algorithm); err != nil {
+			return fmt.Errorf("%w: %v", errSettingEngineFingerprintAlgorithm, algorithm)
+		}
+	}
+	e.dtls.fingerprintAlgorithms = algorithms
+
+	return nil
+}
+
 // SetDTLSConnectContextMaker sets the context used during the DTLS Handshake.
 // It can be used to extend or reduce the timeout on the DTLS Handshake.
 // If nil, the default dtls.ConnectContextMaker is used. It can be implemented as following.
@@ -478,25 +975,57 @@ func (e *SettingEngine) SetDTLSKeyLogWriter(writer io.Writer) {
 	e.dtls.keyLogWriter = writer
 }
 
-// SetSCTPMaxReceiveBufferSize sets the maximum receive buffer size.
-// Leave this 0 for the default maxReceiveBufferSize.
+// SetSCTPMaxReceiveBufferSize sets the maximum receive buffer size, the receiver window
+// advertised to the remote. The buffer of the DTLS packets feeding the association grows
+// along when it is larger than its 1MB. Leave this 0 for the default maxReceiveBufferSize.
 func (e *SettingEngine) SetSCTPMaxReceiveBufferSize(maxReceiveBufferSize uint32) {
 	e.sctp.maxReceiveBufferSize = maxReceiveBufferSize
 }
 
-// EnableSCTPZeroChecksum controls the zero checksum feature in SCTP.
+// EnableSCTPZeroChecksum controls the zero checksum feature in SCTP, RFC 9653.
 // This removes the need to checksum every incoming/outgoing packet and will reduce
-// latency and CPU usage. This feature is not backwards compatible so is disabled by default.
+// latency and CPU usage, DTLS already authenticating the packets. When enabled the
+// SCTP INIT and INIT ACK chunks tell the remote that the packets it sends need no
+// checksum, and the checksums of the packets received aren't verified. The feature is
+// negotiated in SCTP rather than in the SDP, a remote that doesn't support it keeps
+// sending checksums. Whether the remote accepts the packets sent without checksum is
+// reported by SCTPTransport.ZeroChecksum. It is disabled by default.
 func (e *SettingEngine) EnableSCTPZeroChecksum(isEnabled bool) {
 	e.sctp.enableZeroChecksum = isEnabled
 }
 
-// SetSCTPMaxMessageSize sets the largest message we are willing to accept.
+// EnableSCTPReliabilityStats counts the retransmissions and the abandoned messages of
+// each DataChannel, reported by DataChannel.ReliabilityStats. The SCTP association
+// doesn't count them: the SCTP packets sent and received are parsed for it, at some CPU
+// cost. It is disabled by default.
+func (e *SettingEngine) EnableSCTPReliabilityStats(isEnabled bool) {
+	e.sctp.enableReliabilityStats = isEnabled
+}
+
+// SetSCTPMaxMessageSize sets the largest message we are willing to accept, advertised
+// to the remote with the max-message-size attribute of the session descriptions.
 // Leave this 0 for the default max message size.
 func (e *SettingEngine) SetSCTPMaxMessageSize(maxMessageSize uint32) {
 	e.sctp.maxMessageSize = maxMessageSize
 }
 
+// SetSCTPMaxSendBufferSize limits the data buffered by each DataChannel: Send and SendText
+// fail with an OperationError wrapping ErrDataChannelSendBufferFull once its BufferedAmount
+// reached maxSendBufferSize, until the remote acknowledged enough of it. A message is
+// accepted whatever its size while the buffer is below the limit, and the buffered amount
+// counts the DCEP messages opening the channel too. Leave this 0, the default, for an
+// unbounded buffer.
+func (e *SettingEngine) SetSCTPMaxSendBufferSize(maxSendBufferSize uint32) {
+	e.sctp.maxSendBufferSize = maxSendBufferSize
+}
+
+// SetSCTPMaxOutboundStreams limits the SCTP streams, and so the DataChannels, this end
+// opens: the identifiers of its DataChannels are below maxOutboundStreams, see
+// SCTPTransport.MaxChannels. Leave this 0 for the default of 65535 streams.
+func (e *SettingEngine) SetSCTPMaxOutboundStreams(maxOutboundStreams uint16) {
+	e.sctp.maxOutboundStreams = maxOutboundStreams
+}
+
 // SetDTLSCustomerCipherSuites allows the user to specify a list of DTLS CipherSuites.
 // This allow usage of Ciphers that are reserved for private usage.
 func (e *SettingEngine) SetDTLSCustomerCipherSuites(customCipherSuites func() []dtls.CipherSuite) {
@@ -545,6 +1074,26 @@ func (e *SettingEngine) SetSCTPCwndCAStep(cwndCAStep uint32) {
 	e.sctp.cwndCAStep = cwndCAStep
 }
 
+// SetSCTPBundlingDelay sets how long the small SCTP packets of DATA chunks are held to be
+// bundled with the next ones into a single DTLS record, up to the MTU. It cuts the packet
+// count of the DataChannels sending many small messages, at the cost of this delay added
+// to their latency. Leave this 0 to send every packet right away, the default.
+func (e *SettingEngine) SetSCTPBundlingDelay(delay time.Duration) {
+	e.sctp.bundlingDelay = delay
+}
+
+// SetICERenomination enables the renomination ICE option, draft-thatcher-ice-renomination,
+// advertised in the descriptions generated. A remote controlling agent supporting it,
+// libwebrtc for example, then nominates a better candidate pair mid-call, on a network
+// change, instead of requiring an ICE restart: when controlled, the agent switches to every
+// pair the remote nominates once the remote advertised the option too, even to a pair of a
+// lower priority than the selected one. A nomination carrying a NOMINATION attribute not
+// above the one of the last followed is ignored. When controlling, the agent renominates
+// the pairs migrated to by SetICEPairMigration, it keeps the pair nominated first otherwise.
+func (e *SettingEngine) SetICERenomination(enabled bool) {
+	e.iceRenomination = enabled
+}
+
 // SetICEBindingRequestHandler sets a callback that is fired on a STUN BindingRequest
 // This allows users to do things like
 // - Log incoming Binding Requests for debugging
@@ -556,6 +1105,36 @@ func (e *SettingEngine) SetICEBindingRequestHandler(
 	e.iceBindingRequestHandler = bindingRequestHandler
 }
 
+// SetOfferAdmissionHandler sets a handler called by SetRemoteDescription for every remote
+// offer, before it is applied and before the PeerConnection gathers candidates or starts
+// its transports. Returning an error rejects the offer: SetRemoteDescription returns an
+// AdmissionError, which the handler can return itself to give the reason. This allows
+// services to enforce quotas and blocklists before allocating resources for a session.
+func (e *SettingEngine) SetOfferAdmissionHandler(handler func(pc *PeerConnection, offer OfferSummary) error) {
+	e.offerAdmissionHandler = handler
+}
+
+// SetLocalSDPTransform sets a function called by CreateOffer and CreateAnswer with the
+// description they created, it can modify it before it is returned to be signaled, to add
+// bandwidth lines or custom attributes for example. An error is returned by CreateOffer
+// or CreateAnswer. The description is being created when transform is called, it must
+// not call the methods of the PeerConnection.
+func (e *SettingEngine) SetLocalSDPTransform(
+	transform func(pc *PeerConnection, sdpType SDPType, desc *sdp.SessionDescription) error,
+) {
+	e.localSDPTransform = transform
+}
+
+// SetRemoteSDPTransform sets a function called by SetRemoteDescription with the parsed
+// remote description, it can modify it before it is applied, and before an offer is
+// passed to the handler of SetOfferAdmissionHandler. An error is returned by
+// SetRemoteDescription. RemoteDescription returns the modified description.
+func (e *SettingEngine) SetRemoteSDPTransform(
+	transform func(pc *PeerConnection, sdpType SDPType, desc *sdp.SessionDescription) error,
+) {
+	e.remoteSDPTransform = transform
+}
+
 // SetFireOnTrackBeforeFirstRTP sets if firing the OnTrack event should happen
 // before any RTP packets are received. Setting this to true will
 // have the Track's Codec and PayloadTypes be initially set to their
@@ -10,7 +10,9 @@ import (
 	"bytes"
 	"context"
 	"crypto/x509"
+	"errors"
 	"net"
+	"net/url"
 	"testing"
 	"time"
 
@@ -19,6 +21,7 @@ import (
 	"github.com/pion/dtls/v3/pkg/crypto/elliptic"
 	"github.com/pion/dtls/v3/pkg/protocol/handshake"
 	"github.com/pion/ice/v4"
+	"github.com/pion/sdp/v3"
 	"github.com/pion/stun/v3"
 	"github.com/pion/transport/v3/test"
 	"github.com/stretchr/testify/assert"
@@ -276,6 +279,14 @@ func TestSetSCTPRTOMax(t *testing.T) {
 	assert.Equal(t, expSize, s.sctp.rtoMax)
 }
 
+func TestSetSCTPBundlingDelay(t *testing.T) {
+	s := SettingEngine{}
+	assert.Equal(t, time.Duration(0), s.sctp.bundlingDelay)
+
+	s.SetSCTPBundlingDelay(5 * time.Millisecond)
+	assert.Equal(t, 5*time.Millisecond, s.sctp.bundlingDelay)
+}
+
 func TestSetICEBindingRequestHandler(t *testing.T) {
 	seenICEControlled, seenICEControlledCancel := context.WithCancel(context.Background())
 	seenICEControlling, seenICEControllingCancel := context.WithCancel(context.Background())
@@ -305,6 +316,27 @@ func TestSetICEBindingRequestHandler(t *testing.T) {
 	closePairNow(t, pcOffer, pcAnswer)
 }
 
+func TestSetICECheckInterval(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetICECheckInterval(20 * time.Millisecond)
+	settingEngine.SetICEMaxBindingRequests(3)
+
+	pcOffer, pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).newPair(Configuration{})
+	assert.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
 func TestSetHooks(t *testing.T) {
 	settingEngine := SettingEngine{}
 
@@ -485,24 +517,28 @@ func TestSettingEngine_ICEAcceptanceAndSTUNSetters(t *testing.T) {
 	prflx := 30 * time.Millisecond
 	relay := 40 * time.Millisecond
 	stun := 50 * time.Millisecond
+	check := 60 * time.Millisecond
 
 	se.SetHostAcceptanceMinWait(host)
 	se.SetSrflxAcceptanceMinWait(srflx)
 	se.SetPrflxAcceptanceMinWait(prflx)
 	se.SetRelayAcceptanceMinWait(relay)
 	se.SetSTUNGatherTimeout(stun)
+	se.SetICECheckInterval(check)
 
 	assert.NotNil(t, se.timeout.ICEHostAcceptanceMinWait)
 	assert.NotNil(t, se.timeout.ICESrflxAcceptanceMinWait)
 	assert.NotNil(t, se.timeout.ICEPrflxAcceptanceMinWait)
 	assert.NotNil(t, se.timeout.ICERelayAcceptanceMinWait)
 	assert.NotNil(t, se.timeout.ICESTUNGatherTimeout)
+	assert.NotNil(t, se.timeout.ICECheckInterval)
 
 	assert.Equal(t, host, *se.timeout.ICEHostAcceptanceMinWait)
 	assert.Equal(t, srflx, *se.timeout.ICESrflxAcceptanceMinWait)
 	assert.Equal(t, prflx, *se.timeout.ICEPrflxAcceptanceMinWait)
 	assert.Equal(t, relay, *se.timeout.ICERelayAcceptanceMinWait)
 	assert.Equal(t, stun, *se.timeout.ICESTUNGatherTimeout)
+	assert.Equal(t, check, *se.timeout.ICECheckInterval)
 }
 
 func TestSettingEngine_CandidateFiltersAndNetworkTypes(t *testing.T) {
@@ -626,3 +662,142 @@ func TestSettingEngine_HandleUndeclaredSSRCWithoutAnswer(t *testing.T) {
 	se.SetHandleUndeclaredSSRCWithoutAnswer(true)
 	assert.True(t, se.handleUndeclaredSSRCWithoutAnswer)
 }
+
+func TestSettingEngine_SDPTransforms(t *testing.T) {
+	offerSettingEngine := SettingEngine{}
+	offerSettingEngine.SetLocalSDPTransform(func(_ *PeerConnection, sdpType SDPType, desc *sdp.SessionDescription) error {
+		assert.Equal(t, SDPTypeOffer, sdpType)
+		for _, media := range desc.MediaDescriptions {
+			media.Bandwidth = append(media.Bandwidth, sdp.Bandwidth{Type: "AS", Bandwidth: 512})
+		}
+		desc.WithValueAttribute("x-custom", "offer")
+
+		return nil
+	})
+
+	var remoteAttribute string
+	answerSettingEngine := SettingEngine{}
+	answerSettingEngine.SetRemoteSDPTransform(func(_ *PeerConnection, sdpType SDPType, desc *sdp.SessionDescription) error {
+		assert.Equal(t, SDPTypeOffer, sdpType)
+		remoteAttribute, _ = desc.Attribute("x-custom")
+		for _, media := range desc.MediaDescriptions {
+			media.Bandwidth = nil
+		}
+
+		return nil
+	})
+
+	pcOffer, err := NewAPI(WithSettingEngine(offerSettingEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewAPI(WithSettingEngine(answerSettingEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, offer.SDP, "a=x-custom:offer\r\n")
+	assert.Contains(t, offer.SDP, "b=AS:512\r\n")
+
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.Contains(t, pcOffer.LocalDescription().SDP, "b=AS:512\r\n")
+
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+	assert.Equal(t, "offer", remoteAttribute)
+	assert.NotContains(t, pcAnswer.RemoteDescription().SDP, "b=AS:512")
+
+	// An error of the transform is returned
+	errTransform := errors.New("transform failed")
+	pcAnswer.api.settingEngine.SetLocalSDPTransform(func(*PeerConnection, SDPType, *sdp.SessionDescription) error {
+		return errTransform
+	})
+	_, err = pcAnswer.CreateAnswer(nil)
+	assert.ErrorIs(t, err, errTransform)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestSettingEngine_SocketOptions(t *testing.T) {
+	var se SettingEngine
+
+	se.SetICEReusePort(true)
+	assert.True(t, se.iceReusePort)
+
+	assert.NoError(t, se.SetICEDSCP(46))
+	assert.Equal(t, uint8(46), se.iceDSCP)
+	assert.ErrorIs(t, se.SetICEDSCP(64), errInvalidDSCP)
+	assert.Equal(t, uint8(46), se.iceDSCP)
+}
+
+func TestSettingEngine_SetICEProxy(t *testing.T) {
+	var se SettingEngine
+
+	assert.NoError(t, se.SetICEProxy(&url.URL{Scheme: "http", Host: "proxy.example.com:3128"}))
+	_, isHTTP := se.iceProxyDialer.(*httpProxyDialer)
+	assert.True(t, isHTTP)
+
+	assert.NoError(t, se.SetICEProxy(&url.URL{Scheme: "socks5", Host: "proxy.example.com:1080"}))
+	assert.NotNil(t, se.iceProxyDialer)
+	_, isHTTP = se.iceProxyDialer.(*httpProxyDialer)
+	assert.False(t, isHTTP)
+
+	assert.ErrorIs(t, se.SetICEProxy(&url.URL{Scheme: "ftp", Host: "proxy.example.com"}), errICEProxyUnsupportedScheme)
+}
+
+func TestSettingEngine_SetEphemeralPortRanges(t *testing.T) {
+	var se SettingEngine
+
+	assert.NoError(t, se.SetEphemeralPortRanges(EphemeralPortRanges{
+		UDP:     PortRange{Min: 10000, Max: 19999},
+		TURNTCP: PortRange{Min: 20000, Max: 29999},
+		Relay:   PortRange{Min: 30000, Max: 39999},
+	}))
+	assert.Equal(t, uint16(10000), se.ephemeralUDP.PortMin)
+	assert.Equal(t, uint16(19999), se.ephemeralUDP.PortMax)
+	assert.Equal(t, PortRange{Min: 20000, Max: 29999}, se.ephemeralTURNTCP)
+	assert.Equal(t, PortRange{Min: 30000, Max: 39999}, se.ephemeralRelay)
+
+	assert.ErrorIs(t, se.SetEphemeralPortRanges(EphemeralPortRanges{Relay: PortRange{Min: 2, Max: 1}}), ice.ErrPort)
+}
+
+func TestSettingEngine_SetPathMTUDiscovery(t *testing.T) {
+	var se SettingEngine
+
+	assert.Equal(t, uint(outboundMTU), se.getSendMTU())
+	se.SetSendMTU(1400)
+	assert.Equal(t, uint(1400), se.getSendMTU())
+
+	assert.NoError(t, se.SetPathMTUDiscovery(9000))
+	assert.Equal(t, uint(9000), se.pathMTUMax)
+	assert.ErrorIs(t, se.SetPathMTUDiscovery(500), errInvalidPathMTUDiscovery)
+	assert.Equal(t, uint(9000), se.pathMTUMax)
+	assert.NoError(t, se.SetPathMTUDiscovery(0))
+	assert.Zero(t, se.pathMTUMax)
+}
+
+func TestSettingEngine_SetICEPairMigration(t *testing.T) {
+	var se SettingEngine
+
+	assert.NoError(t, se.SetICEPairMigration(time.Second, 50*time.Millisecond))
+	assert.Equal(t, time.Second, se.icePairMigration.interval)
+	assert.Equal(t, 50*time.Millisecond, se.icePairMigration.rttMargin)
+	assert.ErrorIs(t, se.SetICEPairMigration(-time.Second, 0), errInvalidICEPairMigration)
+	assert.ErrorIs(t, se.SetICEPairMigration(time.Second, -time.Millisecond), errInvalidICEPairMigration)
+	assert.Equal(t, time.Second, se.icePairMigration.interval)
+}
+
+func TestSettingEngine_SetICENetworkCost(t *testing.T) {
+	var se SettingEngine
+	assert.Nil(t, se.iceNetworkCost)
+
+	se.SetICENetworkCost(func(interfaceName string) uint16 {
+		if interfaceName == "wwan0" {
+			return 1000
+		}
+
+		return 0
+	})
+	assert.Equal(t, uint16(1000), se.iceNetworkCost("wwan0"))
+	assert.Equal(t, uint16(0), se.iceNetworkCost("eth0"))
+}
@@ -129,6 +129,30 @@ func TestSignalingState_Transitions(t *testing.T) {
 			SDPTypeAnswer,
 			nil,
 		},
+		{
+			"have-local-offer->SetLocal(rollback)->stable",
+			SignalingStateHaveLocalOffer,
+			SignalingStateStable,
+			stateChangeOpSetLocal,
+			SDPTypeRollback,
+			nil,
+		},
+		{
+			"have-remote-offer->SetRemote(rollback)->stable",
+			SignalingStateHaveRemoteOffer,
+			SignalingStateStable,
+			stateChangeOpSetRemote,
+			SDPTypeRollback,
+			nil,
+		},
+		{
+			"(invalid) have-local-offer->SetRemote(rollback)->stable",
+			SignalingStateHaveLocalOffer,
+			SignalingStateStable,
+			stateChangeOpSetRemote,
+			SDPTypeRollback,
+			&rtcerr.InvalidModificationError{},
+		},
 		{
 			"(invalid) stable->SetRemote(pranswer)->have-remote-pranswer",
 			SignalingStateStable,
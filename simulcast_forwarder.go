@@ -0,0 +1,316 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
+)
+
+// SimulcastForwarder forwards one of the RID layers of a simulcast track received by a
+// PeerConnection to one or more TrackLocalStaticRTP, the layer selected with SetLayer.
+// Each layer, a TrackRemote of the RTPReceiver, is read by Forward. The forwarding
+// switches to the layer selected at its next keyframe, which is requested to the
+// publisher, and the sequence numbers and timestamps of the layer are rewritten to
+// follow the packets already forwarded, so the subscribers keep receiving a single
+// stream. The keyframes of VP8, VP9, H264 and H265 are detected, the layers of the other
+// codecs are switched to at their next packet.
+type SimulcastForwarder struct {
+	forwarder *TrackForwarder
+
+	mu     sync.Mutex
+	layers map[string]*TrackRemote
+	// target is the RID of the layer switched to at its next keyframe, empty if none.
+	target string
+	// requested is when a keyframe of target was last requested.
+	requested time.Time
+}
+
+// NewSimulcastForwarder creates a SimulcastForwarder for a simulcast track received by
+// publisher. The first layer read is forwarded until another one is selected.
+func NewSimulcastForwarder(publisher *PeerConnection, outputs ...*TrackLocalStaticRTP) *SimulcastForwarder {
+	return &SimulcastForwarder{
+		forwarder: NewTrackForwarder(nil, publisher, outputs...),
+		layers:    map[string]*TrackRemote{},
+	}
+}
+
+// AddOutput adds a TrackLocalStaticRTP the packets are forwarded to.
+func (f *SimulcastForwarder) AddOutput(output *TrackLocalStaticRTP) {
+	f.forwarder.AddOutput(output)
+}
+
+// RemoveOutput stops forwarding the packets to a TrackLocalStaticRTP.
+func (f *SimulcastForwarder) RemoveOutput(output *TrackLocalStaticRTP) {
+	f.forwarder.RemoveOutput(output)
+}
+
+// RelayRTCP relays the PLI, FIR and NACK packets of a subscriber to the publisher, for
+// the layer forwarded, like TrackForwarder.RelayRTCP does.
+func (f *SimulcastForwarder) RelayRTCP(sender *RTPSender) error {
+	return f.forwarder.RelayRTCP(sender)
+}
+
+// Layers returns the RIDs of the layers read by Forward, sorted.
+func (f *SimulcastForwarder) Layers() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rids := make([]string, 0, len(f.layers))
+	for rid := range f.layers {
+		rids = append(rids, rid)
+	}
+	sort.Strings(rids)
+
+	return rids
+}
+
+// Layer returns the RID of the layer forwarded, empty until a keyframe of the first
+// layer was forwarded.
+func (f *SimulcastForwarder) Layer() string {
+	if track := f.forwarder.Track(); track != nil {
+		return track.RID()
+	}
+
+	return ""
+}
+
+// SetLayer selects the layer forwarded by its RID, the layer must be read by Forward.
+// The forwarding switches to it at its next keyframe, until then the current layer
+// is forwarded.
+func (f *SimulcastForwarder) SetLayer(rid string) error {
+	f.mu.Lock()
+	track, ok := f.layers[rid]
+	if !ok {
+		f.mu.Unlock()
+
+		return fmt.Errorf("%w: %s", errSimulcastForwarderUnknownLayer, rid)
+	}
+
+	if current := f.forwarder.Track(); current == track {
+		f.target = ""
+		f.mu.Unlock()
+
+		return nil
+	}
+	f.target = rid
+	f.requested = time.Now()
+	f.mu.Unlock()
+
+	return f.requestKeyframe(track)
+}
+
+// Forward reads the packets of a layer of the track until reading fails, which happens
+// once the track is stopped, and forwards them while the layer is selected. It is
+// called for each layer, in OnTrack typically.
+func (f *SimulcastForwarder) Forward(track *TrackRemote) error {
+	rid := track.RID()
+	if rid == "" {
+		return errSimulcastForwarderNoRID
+	}
+
+	f.mu.Lock()
+	f.layers[rid] = track
+	first := f.target == "" && f.forwarder.Track() == nil
+	if first {
+		f.target = rid
+		f.requested = time.Now()
+	}
+	f.mu.Unlock()
+	defer f.removeLayer(rid, track)
+
+	if first {
+		_ = f.requestKeyframe(track)
+	}
+
+	for {
+		packet, _, err := track.ReadRTP()
+		if err != nil {
+			return err
+		}
+
+		if f.selects(track, packet) {
+			f.forwarder.write(track, packet)
+		}
+	}
+}
+
+// selects reports whether the packet read from track is forwarded, switching to the
+// track at its first keyframe once it is the target.
+func (f *SimulcastForwarder) selects(track *TrackRemote, packet *rtp.Packet) bool {
+	f.mu.Lock()
+	request := false
+	if f.target == track.RID() {
+		if rtpKeyframe(track.Codec().MimeType, packet.Payload) {
+			f.target = ""
+			f.forwarder.mu.Lock()
+			f.forwarder.track = track
+			f.forwarder.mu.Unlock()
+		} else if time.Since(f.requested) >= trackForwarderKeyframeRequestInterval {
+			// The keyframe requested may have been lost
+			f.requested = time.Now()
+			request = true
+		}
+	}
+	f.mu.Unlock()
+
+	if request {
+		_ = f.requestKeyframe(track)
+	}
+
+	return f.forwarder.Track() == track
+}
+
+func (f *SimulcastForwarder) removeLayer(rid string, track *TrackRemote) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.layers[rid] == track {
+		delete(f.layers, rid)
+		if f.target == rid {
+			f.target = ""
+		}
+	}
+}
+
+func (f *SimulcastForwarder) requestKeyframe(track *TrackRemote) error {
+	if track.Kind() != RTPCodecTypeVideo {
+		return nil
+	}
+
+	// The keyframe requests of the subscribers are not relayed meanwhile
+	f.forwarder.rtcpMu.Lock()
+	f.forwarder.lastKeyframeRequest = time.Now()
+	f.forwarder.rtcpMu.Unlock()
+
+	return f.forwarder.getPublisher().WriteRTCP([]rtcp.Packet{
+		&rtcp.PictureLossIndication{MediaSSRC: uint32(track.SSRC())},
+	})
+}
+
+// rtpKeyframe reports whether payload is the first packet of a keyframe of the codec
+// mimeType, true for the codecs whose keyframes aren't detected.
+func rtpKeyframe(mimeType string, payload []byte) bool {
+	switch {
+	case strings.EqualFold(mimeType, MimeTypeVP8):
+		vp8 := &codecs.VP8Packet{}
+		frame, err := vp8.Unmarshal(payload)
+
+		// The P bit of the VP8 payload header, RFC 7741 section 4.3
+		return err == nil && vp8.S == 1 && vp8.PID == 0 && len(frame) != 0 && frame[0]&0x01 == 0
+	case strings.EqualFold(mimeType, MimeTypeVP9):
+		vp9 := &codecs.VP9Packet{}
+		_, err := vp9.Unmarshal(payload)
+
+		return err == nil && vp9.B && !vp9.P
+	case strings.EqualFold(mimeType, MimeTypeH264):
+		return h264Keyframe(payload)
+	case strings.EqualFold(mimeType, MimeTypeH265):
+		return h265Keyframe(payload)
+	default:
+		return true
+	}
+}
+
+// The H264 NAL unit types of RFC 6184 section 5.2.
+const (
+	h264NALUTypeIDR   = 5
+	h264NALUTypeSPS   = 7
+	h264NALUTypeSTAPA = 24
+	h264NALUTypeFUA   = 28
+
+	h264NALUTypeMask  = 0x1F
+	h264FUAStartBit   = 0x80
+	h264STAPASizeSize = 2
+)
+
+// h264Keyframe reports whether the H264 payload starts an IDR picture or carries a SPS.
+func h264Keyframe(payload []byte) bool {
+	if len(payload) == 0 {
+		return false
+	}
+
+	switch naluType := payload[0] & h264NALUTypeMask; naluType {
+	case h264NALUTypeIDR, h264NALUTypeSPS:
+		return true
+	case h264NALUTypeSTAPA:
+		for nalus := payload[1:]; len(nalus) > h264STAPASizeSize; {
+			size := int(nalus[0])<<8 | int(nalus[1])
+			nalus = nalus[h264STAPASizeSize:]
+			if size == 0 || size > len(nalus) {
+				return false
+			}
+			if t := nalus[0] & h264NALUTypeMask; t == h264NALUTypeIDR || t == h264NALUTypeSPS {
+				return true
+			}
+			nalus = nalus[size:]
+		}
+
+		return false
+	case h264NALUTypeFUA:
+		return len(payload) > 1 && payload[1]&h264FUAStartBit != 0 && payload[1]&h264NALUTypeMask == h264NALUTypeIDR
+	default:
+		return false
+	}
+}
+
+// The H265 NAL unit types of RFC 7798 section 1.1.4 and 4.4.
+const (
+	h265NALUTypeIRAPFirst = 16
+	h265NALUTypeIRAPLast  = 23
+	h265NALUTypeVPS       = 32
+	h265NALUTypeSPS       = 33
+	h265NALUTypeAP        = 48
+	h265NALUTypeFU        = 49
+
+	h265NALUHeaderSize = 2
+	h265FUStartBit     = 0x80
+	h265NALUTypeMask   = 0x3F
+	h265APSizeSize     = 2
+)
+
+// h265Keyframe reports whether the H265 payload starts an IRAP picture or carries a VPS or SPS.
+func h265Keyframe(payload []byte) bool {
+	if len(payload) <= h265NALUHeaderSize {
+		return false
+	}
+
+	keyframe := func(naluType byte) bool {
+		return (naluType >= h265NALUTypeIRAPFirst && naluType <= h265NALUTypeIRAPLast) ||
+			naluType == h265NALUTypeVPS || naluType == h265NALUTypeSPS
+	}
+
+	switch naluType := payload[0] >> 1 & h265NALUTypeMask; naluType {
+	case h265NALUTypeAP:
+		for nalus := payload[h265NALUHeaderSize:]; len(nalus) > h265APSizeSize; {
+			size := int(nalus[0])<<8 | int(nalus[1])
+			nalus = nalus[h265APSizeSize:]
+			if size == 0 || size > len(nalus) {
+				return false
+			}
+			if keyframe(nalus[0] >> 1 & h265NALUTypeMask) {
+				return true
+			}
+			nalus = nalus[size:]
+		}
+
+		return false
+	case h265NALUTypeFU:
+		fuHeader := payload[h265NALUHeaderSize]
+
+		return fuHeader&h265FUStartBit != 0 && keyframe(fuHeader&h265NALUTypeMask)
+	default:
+		return keyframe(naluType)
+	}
+}
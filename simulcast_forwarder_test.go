@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulcastForwarder(t *testing.T) {
+	publisher, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	codec := RTPCodecParameters{RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeVP8, ClockRate: 90000}}
+	newLayer := func(rid string, ssrc SSRC) *TrackRemote {
+		return &TrackRemote{kind: RTPCodecTypeVideo, codec: codec, rid: rid, ssrc: ssrc}
+	}
+	low, high := newLayer("q", 1), newLayer("f", 2)
+
+	forwarder := NewSimulcastForwarder(publisher)
+	assert.ErrorIs(t, forwarder.SetLayer("q"), errSimulcastForwarderUnknownLayer)
+	assert.Equal(t, errSimulcastForwarderNoRID, forwarder.Forward(&TrackRemote{}))
+
+	// The layers read by Forward
+	forwarder.layers["q"], forwarder.layers["f"] = low, high
+	forwarder.target = "q"
+	assert.Equal(t, []string{"f", "q"}, forwarder.Layers())
+
+	keyframe, interframe := []byte{0x10, 0x00, 0x9d}, []byte{0x10, 0x01}
+	forward := func(track *TrackRemote, sequenceNumber uint16, payload []byte) bool {
+		packet := &rtp.Packet{Header: rtp.Header{SequenceNumber: sequenceNumber}, Payload: payload}
+		if !forwarder.selects(track, packet) {
+			return false
+		}
+		forwarder.forwarder.write(track, packet)
+
+		return true
+	}
+
+	// The first layer is forwarded from its first keyframe
+	assert.False(t, forward(low, 10, interframe))
+	assert.Equal(t, "", forwarder.Layer())
+	assert.True(t, forward(low, 11, keyframe))
+	assert.True(t, forward(low, 12, interframe))
+	assert.False(t, forward(high, 500, keyframe))
+	assert.Equal(t, "q", forwarder.Layer())
+
+	// The current layer is forwarded until the one selected sends a keyframe
+	assert.Error(t, forwarder.SetLayer("f"), "the keyframe request fails until connected")
+	assert.False(t, forward(high, 501, interframe))
+	assert.True(t, forward(low, 13, interframe))
+	assert.True(t, forward(high, 502, keyframe))
+	assert.False(t, forward(low, 14, interframe))
+	assert.Equal(t, "f", forwarder.Layer())
+	assert.Equal(t, uint16(14), forwarder.forwarder.rewriter.lastSequenceNumber)
+
+	assert.True(t, forward(high, 503, interframe))
+	assert.Equal(t, uint16(15), forwarder.forwarder.rewriter.lastSequenceNumber)
+
+	// Selecting the current layer cancels the switch
+	assert.Error(t, forwarder.SetLayer("q"))
+	assert.NoError(t, forwarder.SetLayer("f"))
+	assert.False(t, forward(low, 15, keyframe))
+	assert.Equal(t, "f", forwarder.Layer())
+
+	forwarder.removeLayer("f", high)
+	assert.Equal(t, []string{"q"}, forwarder.Layers())
+
+	assert.NoError(t, publisher.Close())
+}
+
+func TestRTPKeyframe(t *testing.T) {
+	for _, test := range []struct {
+		mimeType string
+		payload  []byte
+		keyframe bool
+	}{
+		{MimeTypeVP8, []byte{0x10, 0x00}, true},
+		{MimeTypeVP8, []byte{0x10, 0x01}, false},
+		// Not the start of the first partition
+		{MimeTypeVP8, []byte{0x00, 0x00}, false},
+		{MimeTypeVP8, []byte{0x11, 0x00}, false},
+		{MimeTypeVP9, []byte{0x08, 0x00}, true},
+		{MimeTypeVP9, []byte{0x48, 0x00}, false},
+		{MimeTypeVP9, []byte{0x00, 0x00}, false},
+		{MimeTypeH264, []byte{0x65, 0x00}, true},
+		{MimeTypeH264, []byte{0x67, 0x00}, true},
+		{MimeTypeH264, []byte{0x41, 0x00}, false},
+		// STAP-A of a SPS and a PPS, and of a non-IDR slice
+		{MimeTypeH264, []byte{0x78, 0x00, 0x02, 0x67, 0x00, 0x00, 0x02, 0x68, 0x00}, true},
+		{MimeTypeH264, []byte{0x78, 0x00, 0x02, 0x41, 0x00}, false},
+		// FU-A start and middle of an IDR slice
+		{MimeTypeH264, []byte{0x7c, 0x85, 0x00}, true},
+		{MimeTypeH264, []byte{0x7c, 0x05, 0x00}, false},
+		{MimeTypeH264, nil, false},
+		// IDR_W_RADL, VPS and TRAIL_R NAL units
+		{MimeTypeH265, []byte{0x26, 0x01, 0x00}, true},
+		{MimeTypeH265, []byte{0x40, 0x01, 0x00}, true},
+		{MimeTypeH265, []byte{0x02, 0x01, 0x00}, false},
+		// AP of a VPS and a SPS, and of a TRAIL_R slice
+		{MimeTypeH265, []byte{0x60, 0x01, 0x00, 0x03, 0x40, 0x01, 0x00, 0x00, 0x03, 0x42, 0x01, 0x00}, true},
+		{MimeTypeH265, []byte{0x60, 0x01, 0x00, 0x03, 0x02, 0x01, 0x00}, false},
+		// FU start and middle of a CRA slice
+		{MimeTypeH265, []byte{0x62, 0x01, 0x95, 0x00}, true},
+		{MimeTypeH265, []byte{0x62, 0x01, 0x15, 0x00}, false},
+		{MimeTypeH265, []byte{0x26}, false},
+		{MimeTypeAV1, []byte{0x00}, true},
+	} {
+		assert.Equal(t, test.keyframe, rtpKeyframe(test.mimeType, test.payload), "%s %x", test.mimeType, test.payload)
+	}
+}
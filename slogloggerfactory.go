@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"github.com/pion/logging"
+)
+
+// SlogLevelTrace is the slog level of the lines logged at the trace level, below
+// slog.LevelDebug.
+const SlogLevelTrace = slog.LevelDebug - 4
+
+// The keys of the fields added to the lines of the loggers of a PeerConnection.
+const (
+	logKeyScope        = "scope"
+	logKeyConnectionID = "connection_id"
+	logKeyUfrag        = "ufrag"
+	logKeySSRC         = "ssrc"
+)
+
+// AttrsLoggerFactory is a LoggerFactory whose loggers can carry structured fields.
+// When the LoggerFactory of the SettingEngine implements it, the loggers of each
+// PeerConnection and of the transports it creates, including the ones of the
+// ICE, DTLS and SCTP packages, carry its ID in the "connection_id" field. The
+// lines of the ICETransport carry the local username fragment in the "ufrag"
+// field, and the lines about a RTP stream its SSRC in the "ssrc" field.
+type AttrsLoggerFactory interface {
+	logging.LoggerFactory
+
+	// WithAttrs returns a LoggerFactory whose loggers add attrs to their lines.
+	WithAttrs(attrs ...slog.Attr) logging.LoggerFactory
+}
+
+// SlogLoggerFactory is an AttrsLoggerFactory logging to a slog.Handler. Each line
+// carries the scope of its logger in the "scope" field, the lines logged at the
+// trace level are handled at SlogLevelTrace.
+type SlogLoggerFactory struct {
+	handler slog.Handler
+}
+
+// NewSlogLoggerFactory returns a SlogLoggerFactory logging to handler, like the one
+// of slog.Default() if it is nil.
+func NewSlogLoggerFactory(handler slog.Handler) *SlogLoggerFactory {
+	if handler == nil {
+		handler = slog.Default().Handler()
+	}
+
+	return &SlogLoggerFactory{handler: handler}
+}
+
+// NewLogger returns a logger for scope.
+func (f *SlogLoggerFactory) NewLogger(scope string) logging.LeveledLogger {
+	return &slogLogger{handler: f.handler.WithAttrs([]slog.Attr{slog.String(logKeyScope, scope)})}
+}
+
+// WithAttrs returns a SlogLoggerFactory whose loggers add attrs to their lines.
+func (f *SlogLoggerFactory) WithAttrs(attrs ...slog.Attr) logging.LoggerFactory {
+	return &SlogLoggerFactory{handler: f.handler.WithAttrs(attrs)}
+}
+
+// slogLogger is the logging.LeveledLogger of SlogLoggerFactory.
+type slogLogger struct {
+	handler slog.Handler
+}
+
+// log handles msg, formatted with args if format is set, when the handler is enabled
+// for level. It must be called by the methods of the LeveledLogger, for the source
+// of the record to be their caller.
+func (l *slogLogger) log(level slog.Level, format bool, msg string, args ...any) {
+	ctx := context.Background()
+	if !l.handler.Enabled(ctx, level) {
+		return
+	}
+	if format {
+		msg = fmt.Sprintf(msg, args...)
+	}
+
+	// Skips runtime.Callers, log and the method of the LeveledLogger
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	_ = l.handler.Handle(ctx, slog.NewRecord(time.Now(), level, msg, pcs[0]))
+}
+
+func (l *slogLogger) Trace(msg string) {
+	l.log(SlogLevelTrace, false, msg)
+}
+
+func (l *slogLogger) Tracef(format string, args ...any) {
+	l.log(SlogLevelTrace, true, format, args...)
+}
+
+func (l *slogLogger) Debug(msg string) {
+	l.log(slog.LevelDebug, false, msg)
+}
+
+func (l *slogLogger) Debugf(format string, args ...any) {
+	l.log(slog.LevelDebug, true, format, args...)
+}
+
+func (l *slogLogger) Info(msg string) {
+	l.log(slog.LevelInfo, false, msg)
+}
+
+func (l *slogLogger) Infof(format string, args ...any) {
+	l.log(slog.LevelInfo, true, format, args...)
+}
+
+func (l *slogLogger) Warn(msg string) {
+	l.log(slog.LevelWarn, false, msg)
+}
+
+func (l *slogLogger) Warnf(format string, args ...any) {
+	l.log(slog.LevelWarn, true, format, args...)
+}
+
+func (l *slogLogger) Error(msg string) {
+	l.log(slog.LevelError, false, msg)
+}
+
+func (l *slogLogger) Errorf(format string, args ...any) {
+	l.log(slog.LevelError, true, format, args...)
+}
+
+// withLogAttrs returns a LoggerFactory whose loggers add attrs to their lines, the
+// factory itself if it isn't an AttrsLoggerFactory.
+func withLogAttrs(factory logging.LoggerFactory, attrs ...slog.Attr) logging.LoggerFactory {
+	if attrsFactory, ok := factory.(AttrsLoggerFactory); ok {
+		return attrsFactory.WithAttrs(attrs...)
+	}
+
+	return factory
+}
+
+// newLoggerWithAttrs returns a logger for scope adding attrs to its lines, or log if
+// factory isn't an AttrsLoggerFactory, sparing the creation of a logger.
+func newLoggerWithAttrs(
+	factory logging.LoggerFactory,
+	log logging.LeveledLogger,
+	scope string,
+	attrs ...slog.Attr,
+) logging.LeveledLogger {
+	if attrsFactory, ok := factory.(AttrsLoggerFactory); ok {
+		return attrsFactory.WithAttrs(attrs...).NewLogger(scope)
+	}
+
+	return log
+}
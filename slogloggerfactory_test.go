@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slogTestBuffer collects the lines of a slog.JSONHandler.
+type slogTestBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *slogTestBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *slogTestBuffer) lines(t *testing.T) []map[string]any {
+	t.Helper()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var lines []map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if raw == "" {
+			continue
+		}
+		line := map[string]any{}
+		require.NoError(t, json.Unmarshal([]byte(raw), &line))
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func TestSlogLoggerFactory(t *testing.T) {
+	buffer := &slogTestBuffer{}
+	factory := NewSlogLoggerFactory(slog.NewJSONHandler(buffer, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     slog.LevelDebug,
+	}))
+
+	log := factory.WithAttrs(slog.String(logKeyConnectionID, "pc-1")).NewLogger("test")
+	log.Trace("dropped")
+	log.Tracef("dropped %d", 1)
+	log.Debug("debug")
+	log.Infof("info %d", 1)
+	log.Warn("100%")
+	log.Errorf("error %s", "formatted")
+
+	lines := buffer.lines(t)
+	require.Len(t, lines, 4)
+	for i, expected := range []struct{ level, msg string }{
+		{"DEBUG", "debug"},
+		{"INFO", "info 1"},
+		{"WARN", "100%"},
+		{"ERROR", "error formatted"},
+	} {
+		assert.Equal(t, expected.level, lines[i][slog.LevelKey])
+		assert.Equal(t, expected.msg, lines[i][slog.MessageKey])
+		assert.Equal(t, "test", lines[i][logKeyScope])
+		assert.Equal(t, "pc-1", lines[i][logKeyConnectionID])
+
+		source, ok := lines[i][slog.SourceKey].(map[string]any)
+		require.True(t, ok)
+		assert.Equal(t, "slogloggerfactory_test.go", filepath.Base(source["file"].(string)))
+	}
+}
+
+func TestPeerConnection_SlogLoggerFactory(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	buffer := &slogTestBuffer{}
+	settingEngine := SettingEngine{}
+	settingEngine.LoggerFactory = NewSlogLoggerFactory(slog.NewJSONHandler(buffer, &slog.HandlerOptions{
+		Level: SlogLevelTrace,
+	}))
+	api := NewAPI(WithSettingEngine(settingEngine))
+
+	pcOffer, err := api.NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := api.NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	// The lines of the ICETransport carry the local username fragment
+	localParameters, err := pcOffer.iceGatherer.GetLocalParameters()
+	require.NoError(t, err)
+	pcOffer.iceTransport.logger().Warn("ice transport")
+
+	closePairNow(t, pcOffer, pcAnswer)
+
+	ids := map[string]bool{pcOffer.ID(): true, pcAnswer.ID(): true}
+	scopes := map[string]bool{}
+	var transportLine bool
+	for _, line := range buffer.lines(t) {
+		// The lines of pion/ice, pion/dtls and pion/sctp carry the ID as well
+		assert.True(t, ids[line[logKeyConnectionID].(string)], line)
+		scopes[line[logKeyScope].(string)] = true
+
+		if line[slog.MessageKey] == "ice transport" {
+			transportLine = true
+			assert.Equal(t, pcOffer.ID(), line[logKeyConnectionID])
+			assert.Equal(t, localParameters.UsernameFragment, line[logKeyUfrag])
+		}
+	}
+	assert.True(t, transportLine)
+	for _, scope := range []string{"pc", "ice", "dtls", "sctp", "ortc"} {
+		assert.True(t, scopes[scope], scope)
+	}
+}
@@ -0,0 +1,228 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"net"
+	"syscall"
+
+	"github.com/pion/logging"
+	"github.com/pion/transport/v3"
+	"github.com/pion/transport/v3/stdnet"
+)
+
+// socketOptions are the options set on the UDP sockets gathered by ICE.
+type socketOptions struct {
+	// reusePort sets SO_REUSEPORT before the socket is bound
+	reusePort bool
+	// trafficClass is the IPv4 TOS and IPv6 traffic class byte, the DSCP in the upper
+	// 6 bits and the ECN codepoint in the lower 2. It is left to the OS when 0.
+	trafficClass int
+	// mediaECN is the ECN codepoint of the RTP and RTCP packets, set on each packet
+	// with the traffic class, so the STUN, DTLS and SCTP packets are not marked.
+	mediaECN int
+	// pathMTUProbes receives the responses to the path MTU probes, and to the checks of
+	// the ICE pair migration, read on the sockets if it is set.
+	pathMTUProbes *pathMTUProbes
+	// dontFragment sends the path MTU probes with the Don't Fragment bit.
+	dontFragment bool
+}
+
+// socketOptionsNet sets socketOptions on the UDP sockets it creates.
+type socketOptionsNet struct {
+	transport.Net
+
+	options socketOptions
+	log     logging.LeveledLogger
+}
+
+func newSocketOptionsNet(n transport.Net, options socketOptions, log logging.LeveledLogger) (transport.Net, error) {
+	if n == nil {
+		var err error
+		if n, err = stdnet.NewNet(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &socketOptionsNet{Net: n, options: options, log: log}, nil
+}
+
+func (n *socketOptionsNet) ListenPacket(network string, address string) (net.PacketConn, error) {
+	conn, err := n.listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	n.mark(conn)
+	if udpConn, ok := conn.(*net.UDPConn); ok {
+		return n.markMedia(udpConn, nil), nil
+	}
+
+	return conn, nil
+}
+
+func (n *socketOptionsNet) ListenUDP(network string, locAddr *net.UDPAddr) (transport.UDPConn, error) {
+	if !n.canReusePort() {
+		conn, err := n.Net.ListenUDP(network, locAddr)
+		if err != nil {
+			return nil, err
+		}
+		n.mark(conn)
+		if udpConn, ok := conn.(*net.UDPConn); ok {
+			conn = n.markMedia(udpConn, locAddr)
+		}
+
+		return n.matchPathMTUProbes(conn, locAddr), nil
+	}
+
+	address := ""
+	if locAddr != nil {
+		address = locAddr.String()
+	}
+	conn, err := n.listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	udpConn, ok := conn.(*net.UDPConn)
+	if !ok {
+		_ = conn.Close()
+
+		return nil, errSocketOptionsUnsupportedConn
+	}
+	n.mark(udpConn)
+
+	return n.matchPathMTUProbes(n.markMedia(udpConn, locAddr), locAddr), nil
+}
+
+// canReusePort reports whether SO_REUSEPORT is set, it is only set on the sockets of
+// the OS, not on the ones of a virtual Net.
+func (n *socketOptionsNet) canReusePort() bool {
+	if !n.options.reusePort {
+		return false
+	}
+	if _, ok := n.Net.(*stdnet.Net); !ok {
+		n.log.Warnf("Failed to set SO_REUSEPORT: %v", errSocketOptionsUnsupportedConn)
+
+		return false
+	}
+
+	return true
+}
+
+func (n *socketOptionsNet) listen(network string, address string) (net.PacketConn, error) {
+	if !n.canReusePort() {
+		return n.Net.ListenPacket(network, address)
+	}
+
+	config := net.ListenConfig{Control: func(_, _ string, rawConn syscall.RawConn) error {
+		var reuseErr error
+		if err := rawConn.Control(func(fd uintptr) {
+			reuseErr = setReusePort(fd)
+		}); err != nil {
+			return err
+		}
+
+		// The port is used alone if it can't be shared
+		if reuseErr != nil {
+			n.log.Warnf("Failed to set SO_REUSEPORT: %v", reuseErr)
+		}
+
+		return nil
+	}}
+
+	return config.ListenPacket(context.Background(), network, address)
+}
+
+// mark sets the traffic class of conn, marking is best effort and the socket is
+// used unmarked if the traffic class can't be set. The ECN codepoint of the media
+// is set per packet by markMedia.
+func (n *socketOptionsNet) mark(conn any) {
+	if n.options.trafficClass == 0 {
+		return
+	}
+
+	if err := setTrafficClass(conn, n.options.trafficClass); err != nil {
+		n.log.Warnf("Failed to set the traffic class of socket: %v", err)
+	}
+}
+
+// markMedia wraps udpConn to send its RTP and RTCP packets with the ECN codepoint of
+// the media. udpConn is returned as is if the media is not marked, or if it can't be.
+// The mDNS sockets, bound to a multicast locAddr, carry no media and are never wrapped
+// as mDNS reads them in batches, which needs the *net.UDPConn.
+func (n *socketOptionsNet) markMedia(udpConn *net.UDPConn, locAddr *net.UDPAddr) transport.UDPConn {
+	if n.options.mediaECN == 0 || (locAddr != nil && locAddr.IP.IsMulticast()) {
+		return udpConn
+	}
+
+	trafficClass := n.options.trafficClass | n.options.mediaECN
+	ipv4, err := trafficClassControl(trafficClass, false)
+	if err == nil {
+		var ipv6 []byte
+		if ipv6, err = trafficClassControl(trafficClass, true); err == nil {
+			return &mediaMarkingConn{UDPConn: udpConn, ipv4: ipv4, ipv6: ipv6}
+		}
+	}
+	n.log.Warnf("Failed to mark the media with ECN: %v", err)
+
+	return udpConn
+}
+
+// matchPathMTUProbes wraps conn to pass the responses to the path MTU probes to the
+// pathMTUProbes, and to send the probes with the Don't Fragment bit if dontFragment is
+// set. The probing is disabled if the bit can't be set. The mDNS sockets carry no probes
+// and are never wrapped, like in markMedia.
+func (n *socketOptionsNet) matchPathMTUProbes(conn transport.UDPConn, locAddr *net.UDPAddr) transport.UDPConn {
+	probes := n.options.pathMTUProbes
+	if probes == nil || (locAddr != nil && locAddr.IP.IsMulticast()) {
+		return conn
+	}
+
+	if !n.options.dontFragment {
+		return &pathMTUProbeConn{UDPConn: conn, probes: probes}
+	}
+
+	// The bit is set once to check it is supported, the socket is then restored
+	setDontFragment, err := newDontFragmentSetter(conn)
+	if err == nil {
+		if err = setDontFragment(true); err == nil {
+			err = setDontFragment(false)
+		}
+	}
+	if err != nil {
+		n.log.Warnf("Failed to set the Don't Fragment bit, the path MTU is not probed: %v", err)
+		probes.unsupported.Store(true)
+
+		return &pathMTUProbeConn{UDPConn: conn, probes: probes}
+	}
+
+	return &pathMTUProbeConn{UDPConn: conn, probes: probes, setDontFragment: setDontFragment}
+}
+
+// mediaMarkingConn sends the RTP and RTCP packets with the traffic class control
+// messages ipv4 and ipv6, the other packets with the traffic class of the socket.
+type mediaMarkingConn struct {
+	*net.UDPConn
+
+	ipv4, ipv6 []byte
+}
+
+func (c *mediaMarkingConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	// RTP and RTCP packets start with 128 to 191, RFC 7983
+	if !ok || len(p) == 0 || p[0] < 128 || p[0] > 191 {
+		return c.UDPConn.WriteTo(p, addr)
+	}
+
+	oob := c.ipv6
+	if udpAddr.IP.To4() != nil {
+		oob = c.ipv4
+	}
+	n, _, err := c.UDPConn.WriteMsgUDP(p, oob, udpAddr)
+
+	return n, err
+}
@@ -0,0 +1,17 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !unix && !js
+// +build !unix,!js
+
+package webrtc
+
+// setTrafficClass is not supported on this platform.
+func setTrafficClass(any, int) error {
+	return errSocketOptionsUnsupportedPlatform
+}
+
+// trafficClassControl is not supported on this platform.
+func trafficClassControl(int, bool) ([]byte, error) {
+	return nil, errSocketOptionsUnsupportedPlatform
+}
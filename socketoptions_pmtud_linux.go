@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+// +build linux
+
+package webrtc
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// newDontFragmentSetter returns a function setting the Don't Fragment bit on the next
+// packets sent on conn, without fragmenting them to the path MTU learned by the kernel
+// either, IP_PMTUDISC_PROBE, or restoring the path MTU discovery mode the socket had.
+// Both the IPv4 and IPv6 options are set, as an IPv6 socket may send IPv4 packets.
+func newDontFragmentSetter(conn any) (func(enabled bool) error, error) {
+	syscallConn, ok := conn.(syscall.Conn)
+	if !ok {
+		return nil, errSocketOptionsUnsupportedConn
+	}
+
+	rawConn, err := syscallConn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	// The modes are -1 for the options the socket doesn't have
+	ipv4Mode, ipv6Mode := -1, -1
+	var ipv4Err error
+	if err = rawConn.Control(func(fd uintptr) {
+		if ipv4Mode, ipv4Err = unix.GetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MTU_DISCOVER); ipv4Err != nil {
+			ipv4Mode = -1
+		}
+		if mode, ipv6Err := unix.GetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_MTU_DISCOVER); ipv6Err == nil {
+			ipv6Mode = mode
+		}
+	}); err != nil {
+		return nil, err
+	}
+	if ipv4Mode < 0 && ipv6Mode < 0 {
+		return nil, ipv4Err
+	}
+
+	return func(enabled bool) error {
+		ipv4, ipv6 := ipv4Mode, ipv6Mode
+		if enabled {
+			ipv4, ipv6 = unix.IP_PMTUDISC_PROBE, unix.IPV6_PMTUDISC_PROBE
+		}
+
+		var setErr error
+		if err := rawConn.Control(func(fd uintptr) {
+			if ipv4Mode >= 0 {
+				setErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MTU_DISCOVER, ipv4)
+			}
+			if ipv6Mode >= 0 && setErr == nil {
+				setErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_MTU_DISCOVER, ipv6)
+			}
+		}); err != nil {
+			return err
+		}
+
+		return setErr
+	}, nil
+}
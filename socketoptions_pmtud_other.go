@@ -0,0 +1,12 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !linux && !js
+// +build !linux,!js
+
+package webrtc
+
+// newDontFragmentSetter is not supported on this platform.
+func newDontFragmentSetter(any) (func(enabled bool) error, error) {
+	return nil, errSocketOptionsUnsupportedPlatform
+}
@@ -0,0 +1,12 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !js
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!js
+
+package webrtc
+
+// setReusePort is not supported on this platform.
+func setReusePort(uintptr) error {
+	return errSocketOptionsUnsupportedPlatform
+}
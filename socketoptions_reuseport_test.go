@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build aix darwin dragonfly freebsd linux netbsd openbsd
+
+package webrtc
+
+import (
+	"net"
+	"testing"
+
+	"github.com/pion/logging"
+	"github.com/pion/transport/v3/vnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSocketOptionsNet_ReusePort(t *testing.T) {
+	log := logging.NewDefaultLoggerFactory().NewLogger("test")
+	optionsNet, err := newSocketOptionsNet(nil, socketOptions{reusePort: true}, log)
+	require.NoError(t, err)
+
+	first, err := optionsNet.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, first.Close())
+	}()
+
+	// A second socket binds the same port
+	second, err := optionsNet.ListenPacket("udp4", first.LocalAddr().String())
+	require.NoError(t, err)
+	assert.Equal(t, first.LocalAddr().String(), second.LocalAddr().String())
+	assert.NoError(t, second.Close())
+
+	// The sockets of a virtual Net are created as is
+	router, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	require.NoError(t, err)
+	virtualNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{"1.2.3.4"}})
+	require.NoError(t, err)
+	require.NoError(t, router.AddNet(virtualNet))
+	require.NoError(t, router.Start())
+	defer func() {
+		assert.NoError(t, router.Stop())
+	}()
+
+	optionsNet, err = newSocketOptionsNet(virtualNet, socketOptions{reusePort: true}, log)
+	require.NoError(t, err)
+	conn, err := optionsNet.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(1, 2, 3, 4)})
+	require.NoError(t, err)
+	assert.NoError(t, conn.Close())
+}
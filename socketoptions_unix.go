@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build unix
+// +build unix
+
+package webrtc
+
+import (
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// setTrafficClass sets the traffic class, DSCP and ECN bits, of the packets sent on conn.
+// Both the IPv4 and IPv6 options are set, as an IPv6 socket may send IPv4 packets.
+func setTrafficClass(conn any, trafficClass int) error {
+	syscallConn, ok := conn.(syscall.Conn)
+	if !ok {
+		return errSocketOptionsUnsupportedConn
+	}
+
+	rawConn, err := syscallConn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var ipv4Err, ipv6Err error
+	if err = rawConn.Control(func(fd uintptr) {
+		ipv4Err = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TOS, trafficClass)
+		ipv6Err = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS, trafficClass)
+	}); err != nil {
+		return err
+	}
+
+	if ipv4Err != nil && ipv6Err != nil {
+		return ipv4Err
+	}
+
+	return nil
+}
+
+// trafficClassControl returns the control message setting the traffic class of a
+// packet, IP_TOS for IPv4 and IPV6_TCLASS for IPv6, both taking an int.
+func trafficClassControl(trafficClass int, ipv6 bool) ([]byte, error) {
+	oob := make([]byte, unix.CmsgSpace(4))
+	header := (*unix.Cmsghdr)(unsafe.Pointer(&oob[0])) //nolint:gosec // G103
+	if ipv6 {
+		header.Level, header.Type = unix.IPPROTO_IPV6, unix.IPV6_TCLASS
+	} else {
+		header.Level, header.Type = unix.IPPROTO_IP, unix.IP_TOS
+	}
+	header.SetLen(unix.CmsgLen(4))
+	*(*int32)(unsafe.Pointer(&oob[unix.CmsgLen(0)])) = int32(trafficClass) //nolint:gosec // G103, G115
+
+	return oob, nil
+}
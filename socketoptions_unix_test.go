@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build unix
+// +build unix
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/pion/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSocketOptionsNet_TrafficClass(t *testing.T) {
+	// DSCP Expedited Forwarding and ECT(1)
+	trafficClass := 46<<2 | ecnECT1
+	optionsNet, err := newSocketOptionsNet(nil, socketOptions{trafficClass: trafficClass}, logging.NewDefaultLoggerFactory().NewLogger("test"))
+	require.NoError(t, err)
+
+	conn, err := optionsNet.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, conn.Close())
+	}()
+
+	udpConn, ok := conn.(*net.UDPConn)
+	require.True(t, ok)
+	rawConn, err := udpConn.SyscallConn()
+	require.NoError(t, err)
+
+	var tos int
+	var tosErr error
+	require.NoError(t, rawConn.Control(func(fd uintptr) {
+		tos, tosErr = syscall.GetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TOS)
+	}))
+	require.NoError(t, tosErr)
+	assert.Equal(t, trafficClass, tos)
+
+	assert.ErrorIs(t, setTrafficClass(struct{}{}, trafficClass), errSocketOptionsUnsupportedConn)
+}
+
+func TestSocketOptionsNet_MediaECN(t *testing.T) {
+	optionsNet, err := newSocketOptionsNet(
+		nil, socketOptions{trafficClass: 46 << 2, mediaECN: ecnECT1}, logging.NewDefaultLoggerFactory().NewLogger("test"),
+	)
+	require.NoError(t, err)
+
+	conn, err := optionsNet.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, conn.Close())
+	}()
+
+	// The socket keeps the DSCP, ECT(1) is only set on the media packets
+	mediaConn, ok := conn.(*mediaMarkingConn)
+	require.True(t, ok)
+	rawConn, err := mediaConn.SyscallConn()
+	require.NoError(t, err)
+	var tos int
+	var tosErr error
+	require.NoError(t, rawConn.Control(func(fd uintptr) {
+		tos, tosErr = syscall.GetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TOS)
+	}))
+	require.NoError(t, tosErr)
+	assert.Equal(t, 46<<2, tos)
+
+	for _, control := range []struct {
+		oob   []byte
+		level int32
+	}{
+		{mediaConn.ipv4, syscall.IPPROTO_IP},
+		{mediaConn.ipv6, syscall.IPPROTO_IPV6},
+	} {
+		msgs, parseErr := syscall.ParseSocketControlMessage(control.oob)
+		require.NoError(t, parseErr)
+		require.Len(t, msgs, 1)
+		assert.Equal(t, control.level, msgs[0].Header.Level)
+		assert.Equal(t, uint32(46<<2|ecnECT1), binary.NativeEndian.Uint32(msgs[0].Data))
+	}
+
+	receiver, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, receiver.Close())
+	}()
+
+	buf := make([]byte, 16)
+	for _, packet := range [][]byte{{0x80, 0x60}, {0x00, 0x01}} {
+		n, writeErr := conn.WriteTo(packet, receiver.LocalAddr())
+		require.NoError(t, writeErr)
+		assert.Equal(t, len(packet), n)
+
+		n, _, readErr := receiver.ReadFrom(buf)
+		require.NoError(t, readErr)
+		assert.Equal(t, packet, buf[:n])
+	}
+}
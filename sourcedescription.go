@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+)
+
+// SourceDescription holds the items of the RTCP SDES packets describing the participant
+// sending an RTP stream, see RFC 3550 section 6.5. Monitoring systems use them to
+// correlate the streams with the endpoints across servers, as the SSRCs change at
+// every hop but the CNAME doesn't. Empty items are not sent.
+type SourceDescription struct {
+	CNAME    string
+	Name     string
+	Email    string
+	Phone    string
+	Location string
+	Tool     string
+	Note     string
+}
+
+// items returns the SDES items of the description except the CNAME, which is
+// described by every chunk.
+func (d SourceDescription) items() []rtcp.SourceDescriptionItem {
+	var items []rtcp.SourceDescriptionItem
+	for _, item := range []rtcp.SourceDescriptionItem{
+		{Type: rtcp.SDESName, Text: d.Name},
+		{Type: rtcp.SDESEmail, Text: d.Email},
+		{Type: rtcp.SDESPhone, Text: d.Phone},
+		{Type: rtcp.SDESLocation, Text: d.Location},
+		{Type: rtcp.SDESTool, Text: d.Tool},
+		{Type: rtcp.SDESNote, Text: d.Note},
+	} {
+		if item.Text != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// update returns the description with the items of chunk. The items a chunk doesn't
+// carry are kept, as senders are free to send some of them less often than the CNAME.
+func (d SourceDescription) update(chunk rtcp.SourceDescriptionChunk) SourceDescription {
+	for _, item := range chunk.Items {
+		switch item.Type { //nolint:exhaustive
+		case rtcp.SDESCNAME:
+			d.CNAME = item.Text
+		case rtcp.SDESName:
+			d.Name = item.Text
+		case rtcp.SDESEmail:
+			d.Email = item.Text
+		case rtcp.SDESPhone:
+			d.Phone = item.Text
+		case rtcp.SDESLocation:
+			d.Location = item.Text
+		case rtcp.SDESTool:
+			d.Tool = item.Text
+		case rtcp.SDESNote:
+			d.Note = item.Text
+		}
+	}
+
+	return d
+}
+
+// SourceDescription returns the items of the RTCP SDES packets the remote sent about
+// the track, and false if none has been received. Like the Sender Report fields of
+// Stats, they are only updated as the RTCP is read from the RTPReceiver.
+func (t *TrackRemote) SourceDescription() (SourceDescription, bool) {
+	description := t.sourceDescription.Load()
+	if description == nil {
+		return SourceDescription{}, false
+	}
+
+	return *description, true
+}
+
+// observeSourceDescription returns an RTCPReader recording the SDES chunks about
+// the track in the RTCP read from reader.
+func (t *TrackRemote) observeSourceDescription(reader interceptor.RTCPReader) interceptor.RTCPReader {
+	return interceptor.RTCPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		n, attributes, err := reader.Read(b, a)
+		if err != nil {
+			return n, attributes, err
+		}
+
+		pkts, unmarshalErr := rtcp.Unmarshal(b[:n])
+		if unmarshalErr != nil {
+			return n, attributes, err
+		}
+
+		ssrc := uint32(t.SSRC())
+		for _, pkt := range pkts {
+			sdes, ok := pkt.(*rtcp.SourceDescription)
+			if !ok {
+				continue
+			}
+			for _, chunk := range sdes.Chunks {
+				if chunk.Source == ssrc {
+					t.updateSourceDescription(chunk)
+				}
+			}
+		}
+
+		return n, attributes, err
+	})
+}
+
+func (t *TrackRemote) updateSourceDescription(chunk rtcp.SourceDescriptionChunk) {
+	for {
+		previous := t.sourceDescription.Load()
+		description := SourceDescription{}
+		if previous != nil {
+			description = *previous
+		}
+		description = description.update(chunk)
+
+		if t.sourceDescription.CompareAndSwap(previous, &description) {
+			return
+		}
+	}
+}
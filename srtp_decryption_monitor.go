@@ -0,0 +1,332 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/srtp/v3"
+	"github.com/pion/transport/v3/packetio"
+	"github.com/pion/transport/v3/replaydetector"
+)
+
+// SRTPDecryptionFailure describes a burst of incoming SRTP packets of a single SSRC
+// that failed to decrypt, most often because the rollover counter got out of sync
+// after a long mute or a packet storm.
+type SRTPDecryptionFailure struct {
+	SSRC SSRC
+	// Failures is the number of packets that failed to decrypt within Window
+	Failures int
+	Window   time.Duration
+	// Resynced is true if a rollover counter decrypting the packets has been found,
+	// the following packets of the SSRC are decrypted with it.
+	Resynced        bool
+	RolloverCounter uint32
+}
+
+// srtpDecryptionMonitor sits between the SRTP endpoint and the SRTP session, it detects
+// packets the session failed to decrypt as they never reach the buffer of their SSRC.
+// The session decrypts every packet before reading the next one, so a packet that
+// hasn't been delivered when the next one is read has been dropped.
+type srtpDecryptionMonitor struct {
+	net.Conn
+
+	bufferFactory func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser
+	newContext    func() (*srtp.Context, error)
+	threshold     int
+	window        time.Duration
+	replayWindow  uint
+	onFailure     func(SRTPDecryptionFailure)
+
+	mu      sync.Mutex
+	streams map[uint32]*srtpMonitoredStream
+	pending *srtpMonitoredStream
+	packet  []byte
+}
+
+// maxSRTPSequenceNumber is the largest RTP sequence number, before the rollover.
+const maxSRTPSequenceNumber = 1<<16 - 1
+
+type srtpMonitoredStream struct {
+	ssrc   uint32
+	buffer io.ReadWriteCloser
+
+	delivered bool
+	failures  []time.Time
+
+	// sequence number of the pending packet, and the replay protection of the session
+	// replayed by the delivered packets
+	pendingSequenceNumber uint16
+	replay                replaydetector.ReplayDetector
+
+	// estimated rollover counter, from the sequence numbers seen in the clear
+	lastSequenceNumber uint16
+	rolloverCounter    uint32
+	started            bool
+
+	// context decrypting the packets the session fails to decrypt once resynced
+	resync *srtp.Context
+}
+
+func newSRTPDecryptionMonitor(
+	conn net.Conn,
+	bufferFactory func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser,
+	newContext func() (*srtp.Context, error),
+	threshold int,
+	window time.Duration,
+	replayWindow uint,
+	onFailure func(SRTPDecryptionFailure),
+) *srtpDecryptionMonitor {
+	return &srtpDecryptionMonitor{
+		Conn:          conn,
+		bufferFactory: bufferFactory,
+		newContext:    newContext,
+		threshold:     threshold,
+		window:        window,
+		replayWindow:  replayWindow,
+		onFailure:     onFailure,
+		streams:       map[uint32]*srtpMonitoredStream{},
+	}
+}
+
+// BufferFactory is used as srtp.Config.BufferFactory, it wraps the buffers of
+// the RTP streams to learn which packets have been decrypted.
+func (m *srtpDecryptionMonitor) BufferFactory(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+	buffer := newSRTPStreamBuffer(m.bufferFactory, packetType, ssrc)
+	if packetType != packetio.RTPBufferPacket {
+		return buffer
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stream := m.getStream(ssrc)
+	stream.buffer = buffer
+
+	return &srtpMonitoredBuffer{ReadWriteCloser: buffer, monitor: m, stream: stream}
+}
+
+// getStream must be called with the lock held.
+func (m *srtpDecryptionMonitor) getStream(ssrc uint32) *srtpMonitoredStream {
+	stream, ok := m.streams[ssrc]
+	if !ok {
+		stream = &srtpMonitoredStream{ssrc: ssrc}
+		if m.replayWindow != 0 {
+			stream.replay = replaydetector.WithWrap(m.replayWindow, maxSRTPSequenceNumber)
+		}
+		m.streams[ssrc] = stream
+	}
+
+	return stream
+}
+
+func (m *srtpDecryptionMonitor) Read(b []byte) (int, error) {
+	m.checkPending()
+
+	n, err := m.Conn.Read(b)
+	if err != nil {
+		return n, err
+	}
+
+	header := &rtp.Header{}
+	if _, headerErr := header.Unmarshal(b[:n]); headerErr != nil {
+		return n, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stream := m.getStream(header.SSRC)
+	stream.updateRolloverCounter(header.SequenceNumber)
+	stream.delivered = false
+	stream.pendingSequenceNumber = header.SequenceNumber
+	m.pending = stream
+	m.packet = append(m.packet[:0], b[:n]...)
+
+	return n, err
+}
+
+// checkPending handles the previous packet if the session didn't deliver it.
+func (m *srtpDecryptionMonitor) checkPending() {
+	m.mu.Lock()
+	stream := m.pending
+	m.pending = nil
+	if stream == nil || stream.delivered || stream.buffer == nil {
+		m.mu.Unlock()
+
+		return
+	}
+
+	if stream.resync != nil {
+		if decrypted, err := stream.resync.DecryptRTP(nil, m.packet, nil); err == nil {
+			stream.markDelivered()
+			m.mu.Unlock()
+			_, _ = stream.buffer.Write(decrypted)
+
+			return
+		}
+	}
+
+	if stream.isReplayed() {
+		m.mu.Unlock()
+
+		return
+	}
+
+	now := time.Now()
+	stream.failures = append(stream.failures, now)
+	for len(stream.failures) != 0 && now.Sub(stream.failures[0]) > m.window {
+		stream.failures = stream.failures[1:]
+	}
+	if len(stream.failures) < m.threshold {
+		m.mu.Unlock()
+
+		return
+	}
+
+	failure := SRTPDecryptionFailure{
+		SSRC:     SSRC(stream.ssrc),
+		Failures: len(stream.failures),
+		Window:   m.window,
+	}
+	stream.failures = stream.failures[:0]
+
+	var decrypted []byte
+	if resync, rolloverCounter, ok := m.estimateRolloverCounter(stream); ok {
+		stream.resync = resync
+		failure.Resynced = true
+		failure.RolloverCounter = rolloverCounter
+		if decrypted, _ = resync.DecryptRTP(nil, m.packet, nil); decrypted != nil {
+			stream.markDelivered()
+		}
+	}
+	m.mu.Unlock()
+
+	if decrypted != nil {
+		_, _ = stream.buffer.Write(decrypted)
+	}
+	if m.onFailure != nil {
+		go m.onFailure(failure)
+	}
+}
+
+// estimateRolloverCounter tries the rollover counters around the one estimated from the
+// sequence numbers until one authenticates the last packet. It must be called with the lock held.
+func (m *srtpDecryptionMonitor) estimateRolloverCounter(stream *srtpMonitoredStream) (*srtp.Context, uint32, bool) {
+	candidates := []uint32{stream.rolloverCounter}
+	for distance := uint32(1); distance <= srtpResyncMaxRolloverDistance; distance++ {
+		candidates = append(candidates, stream.rolloverCounter+distance, stream.rolloverCounter-distance)
+	}
+
+	for _, candidate := range candidates {
+		context, err := m.newContext()
+		if err != nil {
+			return nil, 0, false
+		}
+
+		context.SetROC(stream.ssrc, candidate)
+		if _, err = context.DecryptRTP(nil, m.packet, nil); err == nil {
+			stream.rolloverCounter = candidate
+
+			return context, candidate, true
+		}
+	}
+
+	return nil, 0, false
+}
+
+// markDelivered records the pending packet in the replay protection, it must be called
+// with the lock of the monitor held.
+func (s *srtpMonitoredStream) markDelivered() {
+	s.delivered = true
+	if s.replay == nil {
+		return
+	}
+	if accept, ok := s.replay.Check(uint64(s.pendingSequenceNumber)); ok {
+		accept()
+	}
+}
+
+// isReplayed returns whether the session rejected the pending packet as a replay, it must
+// be called with the lock of the monitor held.
+func (s *srtpMonitoredStream) isReplayed() bool {
+	if s.replay == nil {
+		return false
+	}
+	_, ok := s.replay.Check(uint64(s.pendingSequenceNumber))
+
+	return !ok
+}
+
+func (s *srtpMonitoredStream) updateRolloverCounter(sequenceNumber uint16) {
+	switch {
+	case !s.started:
+		s.started = true
+	case sequenceNumber < s.lastSequenceNumber && s.lastSequenceNumber-sequenceNumber > 1<<15:
+		s.rolloverCounter++
+	case sequenceNumber < s.lastSequenceNumber || sequenceNumber-s.lastSequenceNumber > 1<<15:
+		// reordered packet, keep the most recent sequence number
+		return
+	}
+	s.lastSequenceNumber = sequenceNumber
+}
+
+type srtpMonitoredBuffer struct {
+	io.ReadWriteCloser
+
+	monitor *srtpDecryptionMonitor
+	stream  *srtpMonitoredStream
+}
+
+func (b *srtpMonitoredBuffer) Write(p []byte) (int, error) {
+	b.monitor.mu.Lock()
+	b.stream.markDelivered()
+	b.monitor.mu.Unlock()
+
+	return b.ReadWriteCloser.Write(p)
+}
+
+func (b *srtpMonitoredBuffer) Close() error {
+	b.monitor.mu.Lock()
+	if b.monitor.streams[b.stream.ssrc] == b.stream {
+		delete(b.monitor.streams, b.stream.ssrc)
+	}
+	b.monitor.mu.Unlock()
+
+	return b.ReadWriteCloser.Close()
+}
+
+func (b *srtpMonitoredBuffer) SetReadDeadline(t time.Time) error {
+	if buffer, ok := b.ReadWriteCloser.(interface {
+		SetReadDeadline(time.Time) error
+	}); ok {
+		return buffer.SetReadDeadline(t)
+	}
+
+	return nil
+}
+
+// newSRTPStreamBuffer creates a buffer of the SRTP session with bufferFactory,
+// or as pion/srtp does if it is nil.
+func newSRTPStreamBuffer(
+	bufferFactory func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser,
+	packetType packetio.BufferPacketType,
+	ssrc uint32,
+) io.ReadWriteCloser {
+	if bufferFactory != nil {
+		return bufferFactory(packetType, ssrc)
+	}
+
+	buffer := packetio.NewBuffer()
+	buffer.SetLimitSize(srtpBufferSize)
+
+	return buffer
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/srtp/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSRTPDecryptionMonitor_Resync(t *testing.T) {
+	key := []byte{0x0d, 0xcd, 0x21, 0x3e, 0x4c, 0xbc, 0xf2, 0x8f, 0x01, 0x7f, 0x69, 0x94, 0x40, 0x1e, 0x28, 0x89}
+	salt := []byte{0x62, 0x77, 0x60, 0x38, 0xc0, 0x6d, 0xc9, 0x41, 0x9f, 0x6d, 0xd9, 0x43, 0x3e, 0x7c}
+	profile := srtp.ProtectionProfileAes128CmHmacSha1_80
+	ssrc := uint32(5000)
+
+	sender, err := srtp.CreateContext(key, salt, profile)
+	require.NoError(t, err)
+
+	remote, local := net.Pipe()
+	failures := make(chan SRTPDecryptionFailure, 1)
+	monitor := newSRTPDecryptionMonitor(local, nil, func() (*srtp.Context, error) {
+		return srtp.CreateContext(key, salt, profile)
+	}, 4, time.Second, defaultSRTPReplayProtectionWindow, func(failure SRTPDecryptionFailure) {
+		failures <- failure
+	})
+
+	session, err := srtp.NewSessionSRTP(monitor, &srtp.Config{
+		Keys: srtp.SessionKeys{
+			LocalMasterKey: key, LocalMasterSalt: salt,
+			RemoteMasterKey: key, RemoteMasterSalt: salt,
+		},
+		Profile:       profile,
+		BufferFactory: monitor.BufferFactory,
+	})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, session.Close())
+	}()
+
+	send := func(sequenceNumber uint16) {
+		raw, marshalErr := (&rtp.Packet{
+			Header:  rtp.Header{Version: 2, SSRC: ssrc, SequenceNumber: sequenceNumber},
+			Payload: []byte{0x01, 0x02, 0x03},
+		}).Marshal()
+		require.NoError(t, marshalErr)
+
+		encrypted, encryptErr := sender.EncryptRTP(nil, raw, nil)
+		require.NoError(t, encryptErr)
+
+		_, writeErr := remote.Write(encrypted)
+		require.NoError(t, writeErr)
+	}
+
+	readStream := make(chan *srtp.ReadStreamSRTP, 1)
+	go func() {
+		stream, _, acceptErr := session.AcceptStream()
+		assert.NoError(t, acceptErr)
+		readStream <- stream
+	}()
+
+	send(100)
+	stream := <-readStream
+
+	header := &rtp.Header{}
+	buffer := make([]byte, 1500)
+	read := func() uint16 {
+		_, readErr := stream.Read(buffer)
+		require.NoError(t, readErr)
+		_, readErr = header.Unmarshal(buffer)
+		require.NoError(t, readErr)
+
+		return header.SequenceNumber
+	}
+	assert.Equal(t, uint16(100), read())
+
+	// The session rejects the replayed packets, they aren't decryption failures
+	for i := 0; i < 6; i++ {
+		send(100)
+	}
+	send(101)
+	assert.Equal(t, uint16(101), read())
+	select {
+	case failure := <-failures:
+		assert.Fail(t, "replayed packets reported as decryption failures", failure)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The sender skips three rollovers, the session can't decrypt its packets anymore
+	sender.SetROC(ssrc, 3)
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for sequenceNumber := uint16(102); sequenceNumber <= 111; sequenceNumber++ {
+			send(sequenceNumber)
+		}
+	}()
+
+	select {
+	case failure := <-failures:
+		assert.Equal(t, SRTPDecryptionFailure{
+			SSRC:            SSRC(ssrc),
+			Failures:        4,
+			Window:          time.Second,
+			Resynced:        true,
+			RolloverCounter: 3,
+		}, failure)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "OnSRTPDecryptionFailure not fired")
+	}
+
+	// The packet completing the burst and the following ones are decrypted
+	// with the re-synced rollover counter, the last one is still pending.
+	for sequenceNumber := uint16(105); sequenceNumber < 111; sequenceNumber++ {
+		assert.Equal(t, sequenceNumber, read())
+	}
+	<-sent
+}
@@ -69,6 +69,10 @@ func UnmarshalStatsJSON(b []byte) (Stats, error) { //nolint:cyclop
 		return unmarshalCertificateStats(b)
 	case StatsTypeSCTPTransport:
 		return unmarshalSCTPTransportStats(b)
+	case StatsTypePipelineStage:
+		return unmarshalPipelineStageStats(b)
+	case StatsTypeICEServer:
+		return unmarshalICEServerStats(b)
 	default:
 		return nil, fmt.Errorf("type: %w", ErrUnknownType)
 	}
@@ -137,6 +141,12 @@ const (
 
 	// StatsTypeSCTPTransport is used by SCTPTransportStats.
 	StatsTypeSCTPTransport StatsType = "sctp-transport"
+
+	// StatsTypePipelineStage is used by PipelineStageStats.
+	StatsTypePipelineStage StatsType = "pipeline-stage"
+
+	// StatsTypeICEServer is used by ICEServerStats.
+	StatsTypeICEServer StatsType = "ice-server"
 )
 
 // MediaKind indicates the kind of media (audio or video).
@@ -172,6 +182,34 @@ func statsTimestampNow() StatsTimestamp {
 // StatsReport collects Stats objects indexed by their ID.
 type StatsReport map[string]Stats
 
+// MarshalJSON encodes the report like a browser RTCStatsReport converted to an object,
+// with Object.fromEntries for example: the stats indexed by their ID, each with the
+// members named as in webrtc-stats.
+func (r StatsReport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]Stats(r))
+}
+
+// UnmarshalJSON decodes a report encoded by MarshalJSON, the stats are decoded
+// with UnmarshalStatsJSON according to their type.
+func (r *StatsReport) UnmarshalJSON(b []byte) error {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return fmt.Errorf("unmarshal stats report: %w", err)
+	}
+
+	report := make(StatsReport, len(entries))
+	for id, entry := range entries {
+		stats, err := UnmarshalStatsJSON(entry)
+		if err != nil {
+			return fmt.Errorf("unmarshal stats %s: %w", id, err)
+		}
+		report[id] = stats
+	}
+	*r = report
+
+	return nil
+}
+
 type statsReportCollector struct {
 	collectingGroup sync.WaitGroup
 	report          StatsReport
@@ -2442,3 +2480,109 @@ func unmarshalSCTPTransportStats(b []byte) (SCTPTransportStats, error) {
 
 	return sctpTransportStats, nil
 }
+
+// PipelineStage is a stage of the processing of the incoming RTP packets.
+type PipelineStage string
+
+const (
+	// PipelineStageSRTP is the decryption of a packet and its dispatch to the stream of its SSRC.
+	PipelineStageSRTP PipelineStage = "srtp"
+
+	// PipelineStageInterceptor is the processing of a packet by the interceptors of the API.
+	PipelineStageInterceptor PipelineStage = "interceptor"
+
+	// PipelineStageDepacketize is the parsing of a packet by TrackRemote.ReadRTP.
+	PipelineStageDepacketize PipelineStage = "depacketize"
+)
+
+// PipelineStageStats contains the timings of a PipelineStage for all the incoming packets
+// of a PeerConnection. They are only reported if enabled with SettingEngine.EnablePipelineMetrics.
+type PipelineStageStats struct {
+	// Timestamp is the timestamp associated with this object.
+	Timestamp StatsTimestamp `json:"timestamp"`
+
+	// Type is the object's StatsType
+	Type StatsType `json:"type"`
+
+	// ID is a unique id that is associated with the component inspected to produce
+	// this Stats object. Two Stats objects will have the same ID if they were produced
+	// by inspecting the same underlying object.
+	ID string `json:"id"`
+
+	// Stage is the PipelineStage these timings belong to.
+	Stage PipelineStage `json:"stage"`
+
+	// Packets is the number of packets processed by the stage.
+	Packets uint64 `json:"packets"`
+
+	// TotalTime is the sum of the time spent by each packet in the stage, in seconds.
+	TotalTime float64 `json:"totalTime"`
+
+	// MaxTime is the longest time spent by a packet in the stage, in seconds.
+	MaxTime float64 `json:"maxTime"`
+
+	// Histogram counts the packets by the time they spent in the stage.
+	Histogram []PipelineHistogramBucket `json:"histogram"`
+}
+
+// PipelineHistogramBucket counts the packets which spent at most UpperBound in a stage
+// and more than the UpperBound of the previous bucket. The UpperBound of the last bucket
+// is 0, it counts the packets which spent longer than all the other buckets.
+type PipelineHistogramBucket struct {
+	// UpperBound of the bucket in seconds.
+	UpperBound float64 `json:"upperBound"`
+
+	// Packets is the number of packets in the bucket.
+	Packets uint64 `json:"packets"`
+}
+
+func (s PipelineStageStats) statsMarker() {}
+
+func unmarshalPipelineStageStats(b []byte) (PipelineStageStats, error) {
+	var pipelineStageStats PipelineStageStats
+	if err := json.Unmarshal(b, &pipelineStageStats); err != nil {
+		return PipelineStageStats{}, fmt.Errorf("unmarshal pipeline stage stats: %w", err)
+	}
+
+	return pipelineStageStats, nil
+}
+
+// ICEServerStats contains the probe of an ICE server URL, they are only reported if
+// enabled with SettingEngine.SetICEServerProbe.
+type ICEServerStats struct {
+	// Timestamp is the timestamp associated with this object.
+	Timestamp StatsTimestamp `json:"timestamp"`
+
+	// Type is the object's StatsType
+	Type StatsType `json:"type"`
+
+	// ID is a unique id that is associated with the component inspected to produce
+	// this Stats object. Two Stats objects will have the same ID if they were produced
+	// by inspecting the same underlying object.
+	ID string `json:"id"`
+
+	// URL is the URL of the ICE server that was probed.
+	URL string `json:"url"`
+
+	// Priority is the position of the ICE server in the ICEServers of the PeerConnection.
+	Priority int `json:"priority"`
+
+	// Reachable is false if the server didn't answer the probe, the URL is then not
+	// gathered with unless no URL is reachable.
+	Reachable bool `json:"reachable"`
+
+	// RoundTripTime is the round trip time measured by the probe in seconds, 0 if the
+	// server is not reachable.
+	RoundTripTime float64 `json:"roundTripTime"`
+}
+
+func (s ICEServerStats) statsMarker() {}
+
+func unmarshalICEServerStats(b []byte) (ICEServerStats, error) {
+	var iceServerStats ICEServerStats
+	if err := json.Unmarshal(b, &iceServerStats); err != nil {
+		return ICEServerStats{}, fmt.Errorf("unmarshal ice server stats: %w", err)
+	}
+
+	return iceServerStats, nil
+}
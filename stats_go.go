@@ -108,6 +108,80 @@ func (r StatsReport) GetCodecStats(c *RTPCodecParameters) (CodecStats, bool) {
 	return codecStats, true
 }
 
+// SelectedCandidatePairStats is a snapshot of the candidate pair the ICE transport
+// is sending packets on, as returned by PeerConnection.SelectedCandidatePairStats.
+// BytesSent and BytesReceived count the bytes of the ICE transport, including
+// those sent before the pair was selected.
+type SelectedCandidatePairStats struct {
+	ICECandidatePairStats
+
+	// SendBitrate is the bitrate in bits per second sent on the pair, averaged over
+	// the last second. The calls are sampled whichever goroutine makes them, if they
+	// are less frequent it is averaged since the previous one. It is 0 on the first
+	// call and when the selected pair changed.
+	SendBitrate float64
+
+	// ReceiveBitrate is the bitrate in bits per second received on the pair, averaged
+	// as SendBitrate.
+	ReceiveBitrate float64
+}
+
+// selectedCandidatePairBitrateSample is the byte counters of the selected candidate pair
+// at a call to PeerConnection.SelectedCandidatePairStats.
+type selectedCandidatePairBitrateSample struct {
+	bytesSent     uint64
+	bytesReceived uint64
+	sampledAt     time.Time
+}
+
+// selectedCandidatePairBitrate computes the bitrates of the selected candidate pair
+// over a rolling window of the samples taken by PeerConnection.SelectedCandidatePairStats,
+// so that several callers don't reset the bitrates of each other.
+type selectedCandidatePairBitrate struct {
+	mu sync.Mutex
+
+	id string
+	// samples are ordered by time, the first one is the last taken before the window.
+	samples []selectedCandidatePairBitrateSample
+}
+
+func (b *selectedCandidatePairBitrate) sample(stats ICECandidatePairStats, now time.Time) SelectedCandidatePairStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.id != stats.ID {
+		b.id = stats.ID
+		b.samples = b.samples[:0]
+	}
+
+	windowStart := now.Add(-selectedCandidatePairBitrateWindow)
+	for len(b.samples) > 1 && !b.samples[1].sampledAt.After(windowStart) {
+		b.samples = b.samples[1:]
+	}
+
+	selected := SelectedCandidatePairStats{ICECandidatePairStats: stats}
+	if len(b.samples) > 0 {
+		first := b.samples[0]
+		if elapsed := now.Sub(first.sampledAt).Seconds(); elapsed > 0 &&
+			stats.BytesSent >= first.bytesSent && stats.BytesReceived >= first.bytesReceived {
+			selected.SendBitrate = float64(stats.BytesSent-first.bytesSent) * 8 / elapsed
+			selected.ReceiveBitrate = float64(stats.BytesReceived-first.bytesReceived) * 8 / elapsed
+		}
+	}
+
+	// The samples are spaced to bound their number when callers poll in a tight loop
+	if last := len(b.samples) - 1; last < 0 ||
+		now.Sub(b.samples[last].sampledAt) >= selectedCandidatePairBitrateWindow/selectedCandidatePairBitrateSamples {
+		b.samples = append(b.samples, selectedCandidatePairBitrateSample{
+			bytesSent:     stats.BytesSent,
+			bytesReceived: stats.BytesReceived,
+			sampledAt:     now,
+		})
+	}
+
+	return selected
+}
+
 // AudioPlayoutStatsProvider is an interface for getting audio playout metrics.
 type AudioPlayoutStatsProvider interface {
 	// AddTrack registers a track to report playout stats to this provider.
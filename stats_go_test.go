@@ -1159,6 +1159,30 @@ func TestStatsUnmarshal(t *testing.T) {
 	}
 }
 
+func TestStatsReport_JSON(t *testing.T) {
+	report := StatsReport{}
+	expectedJSON := map[string]json.RawMessage{}
+	for i, sample := range getStatsSamples() {
+		// Some samples share their ID
+		id := fmt.Sprintf("%s-%d", sample.name, i)
+		report[id] = sample.stats
+		expectedJSON[id] = json.RawMessage(sample.json)
+	}
+
+	actualJSON, err := json.Marshal(report)
+	require.NoError(t, err)
+	expected, err := json.Marshal(expectedJSON)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(actualJSON))
+
+	var decoded StatsReport
+	require.NoError(t, json.Unmarshal(actualJSON, &decoded))
+	assert.Equal(t, report, decoded)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"a": {"type": "unknown"}}`), &decoded), ErrUnknownType)
+	assert.Error(t, json.Unmarshal([]byte(`[]`), &decoded))
+}
+
 func waitWithTimeout(t *testing.T, wg *sync.WaitGroup) {
 	t.Helper()
 
@@ -1470,6 +1494,7 @@ func TestPeerConnection_GetStats(t *testing.T) { //nolint:cyclop // involves mul
 	assert.Equal(t, uint32(0), connStatsOffer.DataChannelsAccepted)
 	dcStatsOffer := getDataChannelStats(t, reportPCOffer, offerDC)
 	assert.Equal(t, DataChannelStateOpen, dcStatsOffer.State)
+	assert.Equal(t, "iceTransport", dcStatsOffer.TransportID)
 	assert.Equal(t, uint32(1), dcStatsOffer.MessagesSent)
 	assert.Equal(t, uint64(len(msg)), dcStatsOffer.BytesSent)
 	assert.NotEmpty(t, findLocalCandidateStats(reportPCOffer))
@@ -1586,6 +1611,7 @@ func TestPeerConnection_GetStats(t *testing.T) { //nolint:cyclop // involves mul
 	offerSCTPTransportStats := getSctpTransportStats(t, reportPCOffer)
 	assert.GreaterOrEqual(t, offerSCTPTransportStats.BytesSent, answerSCTPTransportStats.BytesReceived)
 	assert.GreaterOrEqual(t, answerSCTPTransportStats.BytesSent, offerSCTPTransportStats.BytesReceived)
+	assert.Equal(t, "iceTransport", offerSCTPTransportStats.TransportID)
 
 	certificates := offerPC.configuration.Certificates
 
@@ -1596,6 +1622,114 @@ func TestPeerConnection_GetStats(t *testing.T) { //nolint:cyclop // involves mul
 	closePairNow(t, offerPC, answerPC)
 }
 
+func TestPeerConnection_GetStats_RTPStreams(t *testing.T) {
+	offerPC, answerPC, err := newPair()
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	sender, err := offerPC.AddTrack(track)
+	require.NoError(t, err)
+
+	// The interceptors only see the RTCP packets read by the application
+	go func() {
+		for {
+			if _, _, readErr := sender.ReadRTCP(); readErr != nil {
+				return
+			}
+		}
+	}()
+	answerPC.OnTrack(func(remote *TrackRemote, receiver *RTPReceiver) {
+		go func() {
+			for {
+				if _, _, readErr := receiver.ReadRTCP(); readErr != nil {
+					return
+				}
+			}
+		}()
+		for {
+			if _, _, readErr := remote.ReadRTP(); readErr != nil {
+				return
+			}
+		}
+	})
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	require.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+
+	done := make(chan struct{})
+	sending := make(chan struct{})
+	go func() {
+		defer close(sending)
+		sendVideoUntilDone(t, done, []*TrackLocalStaticSample{track})
+	}()
+
+	ssrc := sender.GetParameters().Encodings[0].SSRC
+	outboundID := fmt.Sprintf("outbound-rtp-%d", ssrc)
+	inboundID := fmt.Sprintf("inbound-rtp-%d", ssrc)
+
+	// The reports are sent every second, the remote stats follow them
+	var offerReport, answerReport StatsReport
+	assert.Eventually(t, func() bool {
+		offerReport, answerReport = offerPC.GetStats(), answerPC.GetStats()
+		outbound, _ := offerReport[outboundID].(OutboundRTPStreamStats)
+		inbound, _ := answerReport[inboundID].(InboundRTPStreamStats)
+
+		return outbound.RemoteID != "" && inbound.RemoteID != ""
+	}, 10*time.Second, 50*time.Millisecond)
+	close(done)
+	<-sending
+
+	outbound, ok := offerReport[outboundID].(OutboundRTPStreamStats)
+	require.True(t, ok)
+	assert.Equal(t, StatsTypeOutboundRTP, outbound.Type)
+	assert.Equal(t, ssrc, outbound.SSRC)
+	assert.Equal(t, "video", outbound.Kind)
+	assert.Equal(t, "0", outbound.Mid)
+	assert.Equal(t, "iceTransport", outbound.TransportID)
+	assert.Equal(t, "video", outbound.TrackID)
+	assert.Greater(t, outbound.PacketsSent, uint32(0))
+	assert.Greater(t, outbound.BytesSent, uint64(0))
+
+	codec, ok := offerReport[outbound.CodecID].(CodecStats)
+	require.True(t, ok)
+	assert.Equal(t, MimeTypeVP8, codec.MimeType)
+	assert.Equal(t, "iceTransport", codec.TransportID)
+
+	mediaSource, ok := offerReport[outbound.MediaSourceID].(VideoSourceStats)
+	require.True(t, ok)
+	assert.Equal(t, StatsType(StatsTypeMediaSource), mediaSource.Type)
+	assert.Equal(t, "video", mediaSource.TrackIdentifier)
+
+	remoteInbound, ok := offerReport[outbound.RemoteID].(RemoteInboundRTPStreamStats)
+	require.True(t, ok)
+	assert.Equal(t, StatsTypeRemoteInboundRTP, remoteInbound.Type)
+	assert.Equal(t, ssrc, remoteInbound.SSRC)
+	assert.Equal(t, outboundID, remoteInbound.LocalID)
+	assert.Greater(t, remoteInbound.PacketsReceived, uint32(0))
+
+	inbound, ok := answerReport[inboundID].(InboundRTPStreamStats)
+	require.True(t, ok)
+	remoteOutbound, ok := answerReport[inbound.RemoteID].(RemoteOutboundRTPStreamStats)
+	require.True(t, ok)
+	assert.Equal(t, StatsTypeRemoteOutboundRTP, remoteOutbound.Type)
+	assert.Equal(t, ssrc, remoteOutbound.SSRC)
+	assert.Equal(t, inboundID, remoteOutbound.LocalID)
+	assert.Greater(t, remoteOutbound.ReportsSent, uint64(0))
+	assert.Greater(t, remoteOutbound.PacketsSent, uint32(0))
+
+	selected, ok := offerPC.SelectedCandidatePairStats()
+	require.True(t, ok)
+	pair, ok := offerReport[selected.ID].(ICECandidatePairStats)
+	require.True(t, ok)
+	assert.Equal(t, "iceTransport", pair.TransportID)
+	assert.Greater(t, pair.BytesSent, uint64(0))
+	assert.Greater(t, pair.BytesReceived, uint64(0))
+
+	closePairNow(t, offerPC, answerPC)
+}
+
 func TestPeerConnection_GetStats_Closed(t *testing.T) {
 	pc, err := NewPeerConnection(Configuration{})
 	assert.NoError(t, err)
@@ -1605,6 +1739,76 @@ func TestPeerConnection_GetStats_Closed(t *testing.T) {
 	pc.GetStats()
 }
 
+func TestSelectedCandidatePairBitrate(t *testing.T) {
+	var bitrate selectedCandidatePairBitrate
+	now := time.Now()
+
+	selected := bitrate.sample(ICECandidatePairStats{ID: "a", BytesSent: 1000, BytesReceived: 500}, now)
+	assert.Equal(t, uint64(1000), selected.BytesSent)
+	assert.Zero(t, selected.SendBitrate)
+	assert.Zero(t, selected.ReceiveBitrate)
+
+	selected = bitrate.sample(ICECandidatePairStats{ID: "a", BytesSent: 3000, BytesReceived: 1500}, now.Add(time.Second))
+	assert.Equal(t, float64(16000), selected.SendBitrate)
+	assert.Equal(t, float64(8000), selected.ReceiveBitrate)
+
+	// The bitrates restart when the selected pair changes
+	now = now.Add(2 * time.Second)
+	selected = bitrate.sample(ICECandidatePairStats{ID: "b", BytesSent: 100}, now)
+	assert.Zero(t, selected.SendBitrate)
+	assert.Zero(t, selected.ReceiveBitrate)
+
+	// Several callers polling at 1000 bytes per second don't reset each other,
+	// the bitrate is averaged over the window
+	sent := uint64(100)
+	for i := 0; i < 20; i++ {
+		now = now.Add(100 * time.Millisecond)
+		sent += 100
+		for caller := 0; caller < 3; caller++ {
+			selected = bitrate.sample(ICECandidatePairStats{ID: "b", BytesSent: sent}, now)
+		}
+	}
+	assert.Equal(t, float64(8000), selected.SendBitrate)
+	assert.LessOrEqual(t, len(bitrate.samples), selectedCandidatePairBitrateSamples+1)
+}
+
+func TestPeerConnection_SelectedCandidatePairStats(t *testing.T) {
+	offerPC, answerPC, err := newPair()
+	require.NoError(t, err)
+
+	_, ok := offerPC.SelectedCandidatePairStats()
+	assert.False(t, ok)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	dc, err := offerPC.CreateDataChannel("selected-pair", nil)
+	require.NoError(t, err)
+	opened := make(chan struct{})
+	dc.OnOpen(func() {
+		close(opened)
+	})
+
+	require.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+	<-opened
+
+	first, ok := offerPC.SelectedCandidatePairStats()
+	require.True(t, ok)
+	assert.NotEmpty(t, first.LocalCandidateID)
+	assert.NotEmpty(t, first.RemoteCandidateID)
+
+	require.NoError(t, dc.Send(make([]byte, 1000)))
+	assert.Eventually(t, func() bool {
+		second, ok := offerPC.SelectedCandidatePairStats()
+
+		return ok && second.BytesSent > first.BytesSent && second.SendBitrate > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	closePairNow(t, offerPC, answerPC)
+
+	_, ok = offerPC.SelectedCandidatePairStats()
+	assert.False(t, ok)
+}
+
 func TestUnmarshalStatsJSON_TypeFieldUnmarshalError(t *testing.T) {
 	input := []byte(`{"type":123}`)
 
@@ -0,0 +1,260 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
+)
+
+const (
+	// svcMaxLayers is how many spatial and temporal layers the VP9 payload descriptor and
+	// the dependency descriptor can carry.
+	svcMaxLayers = 8
+	// svcFilterBitrateWindow is the window the bitrates of the layers are measured over.
+	svcFilterBitrateWindow = time.Second
+)
+
+// SVCFilter drops the spatial and temporal layers of a VP9 or AV1 SVC stream above the
+// layers selected, so a publisher sending all its layers can be forwarded to receivers
+// of lower bandwidths or resolutions. The layers of the VP9 packets are read from their
+// payload descriptor, those of the AV1 packets from the dependency descriptor header
+// extension, whose template structure is sent with the keyframes. The packets carrying
+// no layer are kept.
+//
+// The layers are switched at the start of a picture: down right away, a temporal layer
+// up at a frame of the base temporal layer, and a spatial layer up at a keyframe, see
+// NeedsKeyframe. The sequence numbers of the packets kept are rewritten to follow each
+// other, and the marker bit set on the last packet kept of each picture. The dependency
+// descriptors are forwarded as is.
+type SVCFilter struct {
+	mimeType               string
+	dependencyDescriptorID uint8
+
+	mu sync.Mutex
+	// maxSpatial and maxTemporal are the layers selected with SetLayers.
+	maxSpatial, maxTemporal uint8
+	targetBitrate           uint64
+	// current are the layers forwarded, highest the highest layers received.
+	current, highest     svcLayer
+	sequenceNumberOffset uint16
+
+	// structure is the last template dependency structure received.
+	structure *FrameDependencyStructure
+
+	windowStart time.Time
+	bytes       [svcMaxLayers][svcMaxLayers]uint64
+	bitrates    [svcMaxLayers][svcMaxLayers]uint64
+}
+
+type svcLayer struct {
+	spatial, temporal uint8
+}
+
+// svcPacket describes the layer of a packet.
+type svcPacket struct {
+	layer      svcLayer
+	start, end bool
+	keyframe   bool
+}
+
+// NewSVCFilter creates a SVCFilter for a track of the codec mimeType, VP9 or AV1, whose
+// negotiated header extensions are headerExtensions, the ones of RTPReceiver.GetParameters
+// for instance. All the layers are kept until SetLayers or SetTargetBitrate are called.
+func NewSVCFilter(mimeType string, headerExtensions []RTPHeaderExtensionParameter) *SVCFilter {
+	return &SVCFilter{
+		mimeType:               mimeType,
+		dependencyDescriptorID: findHeaderExtensionID(DependencyDescriptorURI, headerExtensions),
+		maxSpatial:             svcMaxLayers - 1,
+		maxTemporal:            svcMaxLayers - 1,
+		current:                svcLayer{svcMaxLayers - 1, svcMaxLayers - 1},
+	}
+}
+
+// SetLayers sets the highest spatial and temporal layers kept, starting from 0.
+func (f *SVCFilter) SetLayers(spatial, temporal uint8) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.maxSpatial, f.maxTemporal = min(spatial, svcMaxLayers-1), min(temporal, svcMaxLayers-1)
+}
+
+// SetTargetBitrate keeps the highest layers, within those of SetLayers, whose bitrate is
+// at most bitrate bits per second, as measured over the last second, the lowest layers
+// if none fits. 0 keeps the layers of SetLayers.
+func (f *SVCFilter) SetTargetBitrate(bitrate uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.targetBitrate = bitrate
+}
+
+// Layers returns the spatial and temporal layers forwarded, the highest ones kept.
+func (f *SVCFilter) Layers() (spatial, temporal uint8) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return min(f.current.spatial, f.highest.spatial), min(f.current.temporal, f.highest.temporal)
+}
+
+// NeedsKeyframe reports whether a higher spatial layer is selected, which is switched to at
+// the next keyframe: the application requests it to the publisher with a PLI.
+func (f *SVCFilter) NeedsKeyframe() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.target().spatial > min(f.current.spatial, f.highest.spatial)
+}
+
+// Filter reports whether packet is forwarded, its sequence number and marker bit are
+// rewritten if so. The packets must be filtered in the order they are received.
+func (f *SVCFilter) Filter(packet *rtp.Packet) bool {
+	return f.filter(packet, time.Now())
+}
+
+func (f *SVCFilter) filter(packet *rtp.Packet, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, ok := f.packetLayer(packet)
+	if !ok {
+		packet.SequenceNumber -= f.sequenceNumberOffset
+
+		return true
+	}
+
+	f.measure(info.layer, packet.MarshalSize(), now)
+	if info.start && info.layer.spatial == 0 {
+		f.switchLayers(info)
+	}
+
+	if info.layer.spatial > f.current.spatial || info.layer.temporal > f.current.temporal {
+		f.sequenceNumberOffset++
+
+		return false
+	}
+
+	if info.end && info.layer.spatial == f.current.spatial {
+		packet.Marker = true
+	}
+	packet.SequenceNumber -= f.sequenceNumberOffset
+
+	return true
+}
+
+// switchLayers switches the layers forwarded to the target at the start of a picture.
+func (f *SVCFilter) switchLayers(info svcPacket) {
+	target := f.target()
+	current := svcLayer{min(f.current.spatial, f.highest.spatial), min(f.current.temporal, f.highest.temporal)}
+
+	if target.spatial < current.spatial || (target.spatial > current.spatial && info.keyframe) {
+		f.current.spatial = target.spatial
+	}
+	if target.temporal < current.temporal || (target.temporal > current.temporal && info.layer.temporal == 0) {
+		f.current.temporal = target.temporal
+	}
+}
+
+// target returns the layers selected, those of SetLayers limited by the target bitrate.
+func (f *SVCFilter) target() svcLayer {
+	target := svcLayer{min(f.maxSpatial, f.highest.spatial), min(f.maxTemporal, f.highest.temporal)}
+	if f.targetBitrate == 0 {
+		return target
+	}
+
+	for spatial := int(target.spatial); spatial >= 0; spatial-- {
+		for temporal := int(target.temporal); temporal >= 0; temporal-- {
+			var bitrate uint64
+			for s := 0; s <= spatial; s++ {
+				for t := 0; t <= temporal; t++ {
+					bitrate += f.bitrates[s][t]
+				}
+			}
+			if bitrate <= f.targetBitrate {
+				return svcLayer{uint8(spatial), uint8(temporal)} //nolint:gosec // G115, below svcMaxLayers
+			}
+		}
+	}
+
+	return svcLayer{}
+}
+
+// measure adds a packet of layer to the bitrates of the layers.
+func (f *SVCFilter) measure(layer svcLayer, size int, now time.Time) {
+	f.highest.spatial, f.highest.temporal = max(f.highest.spatial, layer.spatial), max(f.highest.temporal, layer.temporal)
+
+	if f.windowStart.IsZero() {
+		f.windowStart = now
+	}
+	f.bytes[layer.spatial][layer.temporal] += uint64(size) //nolint:gosec // G115, size is positive
+
+	elapsed := now.Sub(f.windowStart)
+	if elapsed < svcFilterBitrateWindow {
+		return
+	}
+	for s := range f.bytes {
+		for t := range f.bytes[s] {
+			f.bitrates[s][t] = f.bytes[s][t] * 8 * uint64(time.Second) / uint64(elapsed)
+			f.bytes[s][t] = 0
+		}
+	}
+	f.windowStart = now
+}
+
+// packetLayer returns the layer of packet, false if it carries none.
+func (f *SVCFilter) packetLayer(packet *rtp.Packet) (svcPacket, bool) {
+	switch {
+	case strings.EqualFold(f.mimeType, MimeTypeVP9):
+		vp9 := &codecs.VP9Packet{}
+		if _, err := vp9.Unmarshal(packet.Payload); err != nil || !vp9.L {
+			return svcPacket{}, false
+		}
+
+		return svcPacket{
+			layer:    svcLayer{vp9.SID, vp9.TID},
+			start:    vp9.B,
+			end:      vp9.E,
+			keyframe: !vp9.P,
+		}, true
+	case strings.EqualFold(f.mimeType, MimeTypeAV1) && f.dependencyDescriptorID != 0:
+		return f.dependencyDescriptorLayer(packet.GetExtension(f.dependencyDescriptorID))
+	default:
+		return svcPacket{}, false
+	}
+}
+
+// dependencyDescriptorLayer returns the layer of the frame of a dependency descriptor,
+// the frame of a descriptor carrying a template structure is taken as a keyframe.
+func (f *SVCFilter) dependencyDescriptorLayer(payload []byte) (svcPacket, bool) {
+	if payload == nil {
+		return svcPacket{}, false
+	}
+
+	descriptor := &DependencyDescriptor{}
+	if err := descriptor.Unmarshal(payload, f.structure); err != nil {
+		return svcPacket{}, false
+	}
+	if descriptor.Structure != nil {
+		f.structure = descriptor.Structure
+	}
+
+	dependencies := descriptor.FrameDependencies
+	if dependencies.SpatialID >= svcMaxLayers || dependencies.TemporalID >= svcMaxLayers {
+		return svcPacket{}, false
+	}
+
+	return svcPacket{
+		layer:    svcLayer{uint8(dependencies.SpatialID), uint8(dependencies.TemporalID)}, //nolint:gosec // G115
+		start:    descriptor.StartOfFrame,
+		end:      descriptor.EndOfFrame,
+		keyframe: descriptor.Structure != nil,
+	}, true
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSVCFilter_VP9(t *testing.T) {
+	filter := NewSVCFilter(MimeTypeVP9, nil)
+
+	// A L2T2 stream, a packet per spatial layer of each picture, the marker on the last one
+	var sequenceNumber uint16
+	now := time.Now()
+	picture := func(temporal uint8, keyframe bool) (kept []rtp.Packet) {
+		for spatial := uint8(0); spatial < 2; spatial++ {
+			descriptor := byte(0x20 | 0x08 | 0x04)
+			if !keyframe || spatial != 0 {
+				descriptor |= 0x40
+			}
+			packet := rtp.Packet{
+				Header:  rtp.Header{SequenceNumber: sequenceNumber, Marker: spatial == 1},
+				Payload: append([]byte{descriptor, temporal<<5 | spatial<<1, 0x00}, make([]byte, 100*(spatial+1))...),
+			}
+			sequenceNumber++
+			if filter.filter(&packet, now) {
+				kept = append(kept, packet)
+			}
+		}
+		now = now.Add(time.Second / 30)
+
+		return kept
+	}
+
+	kept := picture(0, true)
+	require.Len(t, kept, 2)
+	assert.Equal(t, []uint16{0, 1}, []uint16{kept[0].SequenceNumber, kept[1].SequenceNumber})
+	assert.False(t, kept[0].Marker)
+	spatial, temporal := filter.Layers()
+	assert.Equal(t, []uint8{1, 0}, []uint8{spatial, temporal})
+	assert.Len(t, picture(1, false), 2)
+
+	// The layers are switched down right away
+	filter.SetLayers(0, 0)
+	kept = picture(0, false)
+	require.Len(t, kept, 1)
+	assert.Equal(t, uint16(4), kept[0].SequenceNumber)
+	assert.True(t, kept[0].Marker)
+	assert.Empty(t, picture(1, false))
+	kept = picture(0, false)
+	require.Len(t, kept, 1)
+	assert.Equal(t, uint16(5), kept[0].SequenceNumber)
+
+	// The temporal layer is switched up at the base layer, the spatial one at a keyframe
+	filter.SetLayers(1, 1)
+	assert.True(t, filter.NeedsKeyframe())
+	assert.Empty(t, picture(1, false))
+	assert.Len(t, picture(0, false), 1)
+	assert.Len(t, picture(1, false), 1)
+	kept = picture(0, true)
+	require.Len(t, kept, 2)
+	assert.Equal(t, []uint16{8, 9}, []uint16{kept[0].SequenceNumber, kept[1].SequenceNumber})
+	assert.False(t, filter.NeedsKeyframe())
+
+	// Over a second, the spatial layer 0 sends 27.6kbps, the layer 1 51.6kbps, half of
+	// each in the temporal layer 1
+	for i := 0; i < 30; i++ {
+		picture(uint8(i%2), false)
+	}
+	filter.SetTargetBitrate(100_000)
+	picture(0, false)
+	spatial, temporal = filter.Layers()
+	assert.Equal(t, []uint8{1, 1}, []uint8{spatial, temporal})
+
+	filter.SetTargetBitrate(30_000)
+	assert.Len(t, picture(0, false), 1)
+	spatial, temporal = filter.Layers()
+	assert.Equal(t, []uint8{0, 1}, []uint8{spatial, temporal})
+
+	filter.SetTargetBitrate(20_000)
+	assert.Len(t, picture(0, false), 1)
+	spatial, temporal = filter.Layers()
+	assert.Equal(t, []uint8{0, 0}, []uint8{spatial, temporal})
+
+	// The packets without layer indices are kept
+	packet := &rtp.Packet{Header: rtp.Header{SequenceNumber: sequenceNumber}, Payload: []byte{0x0c, 0x00}}
+	assert.True(t, filter.filter(packet, now))
+}
+
+func TestSVCFilter_AV1(t *testing.T) {
+	filter := NewSVCFilter(MimeTypeAV1, []RTPHeaderExtensionParameter{{URI: DependencyDescriptorURI, ID: 5}})
+
+	// A L2T1 stream, the templates 10 and 11 are the frames of the layers 0 and 1
+	structure := &FrameDependencyStructure{
+		TemplateIDOffset:  10,
+		DecodeTargetCount: 2,
+		Templates: []FrameDependencyTemplate{
+			{SpatialID: 0, DecodeTargetIndications: []DecodeTargetIndication{DecodeTargetSwitch, DecodeTargetSwitch}},
+			{SpatialID: 1, DecodeTargetIndications: []DecodeTargetIndication{DecodeTargetNotPresent, DecodeTargetSwitch}},
+		},
+	}
+	descriptor := func(templateID uint8, keyframe bool) []byte {
+		descriptor := &DependencyDescriptor{StartOfFrame: true, EndOfFrame: true, TemplateID: templateID}
+		if keyframe {
+			descriptor.Structure = structure
+		}
+		payload, err := descriptor.Marshal(structure)
+		require.NoError(t, err)
+
+		return payload
+	}
+	filterFrame := func(templateID uint8, structure bool) bool {
+		packet := &rtp.Packet{Payload: []byte{0x00}}
+		require.NoError(t, packet.Header.SetExtension(5, descriptor(templateID, structure)))
+
+		return filter.Filter(packet)
+	}
+
+	// The layers are unknown until a structure is received
+	assert.True(t, filterFrame(11, false))
+	spatial, _ := filter.Layers()
+	assert.Equal(t, uint8(0), spatial)
+
+	assert.True(t, filterFrame(10, true))
+	assert.True(t, filterFrame(11, false))
+	spatial, _ = filter.Layers()
+	assert.Equal(t, uint8(1), spatial)
+
+	filter.SetLayers(0, 0)
+	assert.True(t, filterFrame(10, false))
+	assert.False(t, filterFrame(11, false))
+
+	filter.SetLayers(1, 0)
+	assert.True(t, filter.NeedsKeyframe())
+	assert.True(t, filterFrame(10, false))
+	assert.False(t, filterFrame(11, false))
+	assert.True(t, filterFrame(10, true))
+	assert.True(t, filterFrame(11, false))
+}
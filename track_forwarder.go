@@ -0,0 +1,256 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+// TrackForwarder forwards the RTP packets of a TrackRemote to one or more
+// TrackLocalStaticRTP, and relays the keyframe requests (PLI and FIR) and the
+// NACKs the subscribers send back to the PeerConnection publishing the track.
+type TrackForwarder struct {
+	mu        sync.RWMutex
+	track     *TrackRemote
+	publisher *PeerConnection
+	outputs   []*TrackLocalStaticRTP
+	rewriter  trackForwarderRewriter
+
+	rtcpMu              sync.Mutex
+	lastKeyframeRequest time.Time
+	firSequenceNumber   uint8
+}
+
+// NewTrackForwarder creates a TrackForwarder for a track received by publisher.
+func NewTrackForwarder(track *TrackRemote, publisher *PeerConnection, outputs ...*TrackLocalStaticRTP) *TrackForwarder {
+	return &TrackForwarder{
+		track:     track,
+		publisher: publisher,
+		outputs:   append([]*TrackLocalStaticRTP{}, outputs...),
+	}
+}
+
+// AddOutput adds a TrackLocalStaticRTP the packets are forwarded to.
+func (f *TrackForwarder) AddOutput(output *TrackLocalStaticRTP) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.outputs = append(f.outputs, output)
+}
+
+// RemoveOutput stops forwarding the packets to a TrackLocalStaticRTP.
+func (f *TrackForwarder) RemoveOutput(output *TrackLocalStaticRTP) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.outputs {
+		if f.outputs[i] == output {
+			f.outputs = append(f.outputs[:i], f.outputs[i+1:]...)
+
+			return
+		}
+	}
+}
+
+// SetTrack makes the forwarder read the packets of track, received by publisher, in
+// place of the current TrackRemote, when the publisher reconnected on a new
+// PeerConnection for example. The sequence numbers and timestamps of track are
+// rewritten to follow the packets already forwarded, so the subscribers keep
+// receiving a single stream, and a keyframe is requested to the new publisher.
+func (f *TrackForwarder) SetTrack(track *TrackRemote, publisher *PeerConnection) error {
+	f.mu.Lock()
+	previous := f.track
+	f.track = track
+	f.publisher = publisher
+	f.mu.Unlock()
+
+	// Forward returns to read the new track once reading the previous one fails
+	if previous != nil && previous != track {
+		_ = previous.SetReadDeadline(time.Now())
+	}
+
+	if track.Kind() != RTPCodecTypeVideo {
+		return nil
+	}
+
+	f.rtcpMu.Lock()
+	f.lastKeyframeRequest = time.Now()
+	f.rtcpMu.Unlock()
+
+	return publisher.WriteRTCP([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: uint32(track.SSRC())}})
+}
+
+// Track returns the TrackRemote the packets are read from.
+func (f *TrackForwarder) Track() *TrackRemote {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.track
+}
+
+func (f *TrackForwarder) getPublisher() *PeerConnection {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.publisher
+}
+
+// Forward reads the packets of the TrackRemote and writes them to the outputs until
+// reading fails, which happens once the track is stopped, unless the track has been
+// replaced with SetTrack. Header extensions are stripped as their IDs are negotiated
+// by each PeerConnection, but the dependency descriptor, which is written with the ID
+// negotiated by each output. An output failing to write doesn't stop the forwarding.
+func (f *TrackForwarder) Forward() error {
+	for {
+		track := f.Track()
+		packet, _, err := track.ReadRTP()
+		if err != nil {
+			if f.Track() != track {
+				continue
+			}
+
+			return err
+		}
+		f.write(track, packet)
+	}
+}
+
+// write writes a packet read from track to the outputs, with its dependency descriptor.
+func (f *TrackForwarder) write(track *TrackRemote, packet *rtp.Packet) {
+	var dependencyDescriptor []byte
+	track.mu.RLock()
+	if id := track.dependencyDescriptorExtensionID; id != 0 {
+		dependencyDescriptor = packet.GetExtension(id)
+	}
+	track.mu.RUnlock()
+	packet.Header.Extension = false
+	packet.Header.Extensions = nil
+
+	f.mu.RLock()
+	f.rewriter.rewrite(track, packet, time.Now())
+	for _, output := range f.outputs {
+		_ = output.WriteRTPWithDependencyDescriptor(packet, dependencyDescriptor)
+	}
+	f.mu.RUnlock()
+}
+
+// RelayRTCP reads the RTCP packets received by the RTPSender of a subscriber and relays
+// its PLI, FIR and NACK packets to the publisher, until reading fails. Keyframe requests
+// of all subscribers are merged so the publisher gets at most one per
+// trackForwarderKeyframeRequestInterval. The RTCP of the sender must not be read elsewhere.
+func (f *TrackForwarder) RelayRTCP(sender *RTPSender) error {
+	for {
+		pkts, _, err := sender.ReadRTCP()
+		if err != nil {
+			return err
+		}
+
+		if relayed := f.relayedRTCP(pkts); len(relayed) != 0 {
+			if err = f.getPublisher().WriteRTCP(relayed); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// relayedRTCP returns the packets of pkts to relay to the publisher, addressed to the TrackRemote.
+func (f *TrackForwarder) relayedRTCP(pkts []rtcp.Packet) []rtcp.Packet {
+	f.mu.RLock()
+	track := f.track
+	sequenceNumberOffset := f.rewriter.getSequenceNumberOffset()
+	f.mu.RUnlock()
+	relayed := []rtcp.Packet{}
+	if track == nil {
+		return relayed
+	}
+	mediaSSRC := uint32(track.SSRC())
+
+	for _, pkt := range pkts {
+		switch pkt := pkt.(type) {
+		case *rtcp.PictureLossIndication:
+			if f.shouldRequestKeyframe() {
+				relayed = append(relayed, &rtcp.PictureLossIndication{MediaSSRC: mediaSSRC})
+			}
+		case *rtcp.FullIntraRequest:
+			if f.shouldRequestKeyframe() {
+				f.rtcpMu.Lock()
+				f.firSequenceNumber++
+				relayed = append(relayed, &rtcp.FullIntraRequest{
+					MediaSSRC: mediaSSRC,
+					FIR:       []rtcp.FIREntry{{SSRC: mediaSSRC, SequenceNumber: f.firSequenceNumber}},
+				})
+				f.rtcpMu.Unlock()
+			}
+		case *rtcp.TransportLayerNack:
+			nacks := make([]rtcp.NackPair, 0, len(pkt.Nacks))
+			for _, nack := range pkt.Nacks {
+				nack.PacketID -= sequenceNumberOffset
+				nacks = append(nacks, nack)
+			}
+			relayed = append(relayed, &rtcp.TransportLayerNack{MediaSSRC: mediaSSRC, Nacks: nacks})
+		}
+	}
+
+	return relayed
+}
+
+func (f *TrackForwarder) shouldRequestKeyframe() bool {
+	f.rtcpMu.Lock()
+	defer f.rtcpMu.Unlock()
+
+	now := time.Now()
+	if now.Sub(f.lastKeyframeRequest) < trackForwarderKeyframeRequestInterval {
+		return false
+	}
+	f.lastKeyframeRequest = now
+
+	return true
+}
+
+// trackForwarderRewriter rewrites the sequence numbers and the timestamps of the
+// packets of the tracks set with TrackForwarder.SetTrack to follow the packets
+// forwarded from the previous track.
+type trackForwarderRewriter struct {
+	mu sync.Mutex
+
+	track                *TrackRemote
+	sequenceNumberOffset uint16
+	timestampOffset      uint32
+	lastSequenceNumber   uint16
+	lastTimestamp        uint32
+	lastPacketForwarded  time.Time
+}
+
+func (r *trackForwarderRewriter) rewrite(track *TrackRemote, packet *rtp.Packet, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.track != nil && r.track != track {
+		elapsed := uint32(now.Sub(r.lastPacketForwarded).Seconds() * float64(track.Codec().ClockRate))
+		r.sequenceNumberOffset = r.lastSequenceNumber + 1 - packet.SequenceNumber
+		r.timestampOffset = r.lastTimestamp + max(elapsed, 1) - packet.Timestamp
+	}
+	r.track = track
+
+	packet.SequenceNumber += r.sequenceNumberOffset
+	packet.Timestamp += r.timestampOffset
+	r.lastSequenceNumber = packet.SequenceNumber
+	r.lastTimestamp = packet.Timestamp
+	r.lastPacketForwarded = now
+}
+
+// getSequenceNumberOffset returns what is added to the sequence numbers of the current track.
+func (r *trackForwarderRewriter) getSequenceNumberOffset() uint16 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sequenceNumberOffset
+}
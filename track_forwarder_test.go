@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackForwarder(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pubOffer, pubAnswer, err := newPair()
+	require.NoError(t, err)
+	subOffer, subAnswer, err := newPair()
+	require.NoError(t, err)
+
+	publishedTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	publisher, err := pubOffer.AddTrack(publishedTrack)
+	require.NoError(t, err)
+
+	output, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	subscriber, err := subOffer.AddTrack(output)
+	require.NoError(t, err)
+
+	pubAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		forwarder := NewTrackForwarder(track, pubAnswer, output)
+		go func() {
+			_ = forwarder.Forward()
+		}()
+		go func() {
+			_ = forwarder.RelayRTCP(subscriber)
+		}()
+	})
+
+	subAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		_, _, readErr := track.ReadRTP()
+		assert.NoError(t, readErr)
+		assert.NoError(t, subAnswer.WriteRTCP([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: uint32(track.SSRC())}}))
+	})
+
+	require.NoError(t, signalPair(pubOffer, pubAnswer))
+	require.NoError(t, signalPair(subOffer, subAnswer))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for {
+			pkts, _, readErr := publisher.ReadRTCP()
+			if readErr != nil {
+				return
+			}
+
+			for _, pkt := range pkts {
+				if pli, ok := pkt.(*rtcp.PictureLossIndication); ok &&
+					pli.MediaSSRC == uint32(publisher.GetParameters().Encodings[0].SSRC) {
+					cancel()
+				}
+			}
+		}
+	}()
+
+	sendVideoUntilDone(t, ctx.Done(), []*TrackLocalStaticSample{publishedTrack})
+
+	closePairNow(t, subOffer, subAnswer)
+	closePairNow(t, pubOffer, pubAnswer)
+}
+
+func TestTrackForwarder_RelayedRTCP(t *testing.T) {
+	forwarder := NewTrackForwarder(&TrackRemote{ssrc: 1234}, nil)
+
+	nacks := []rtcp.NackPair{{PacketID: 10, LostPackets: 0b101}}
+	relayed := forwarder.relayedRTCP([]rtcp.Packet{
+		&rtcp.PictureLossIndication{SenderSSRC: 1, MediaSSRC: 5678},
+		&rtcp.FullIntraRequest{SenderSSRC: 1, MediaSSRC: 5678, FIR: []rtcp.FIREntry{{SSRC: 5678, SequenceNumber: 7}}},
+		&rtcp.TransportLayerNack{SenderSSRC: 1, MediaSSRC: 5678, Nacks: nacks},
+		&rtcp.ReceiverReport{SSRC: 1},
+	})
+
+	// keyframe requests following each other closely are merged
+	assert.Equal(t, []rtcp.Packet{
+		&rtcp.PictureLossIndication{MediaSSRC: 1234},
+		&rtcp.TransportLayerNack{MediaSSRC: 1234, Nacks: nacks},
+	}, relayed)
+
+	forwarder.lastKeyframeRequest = time.Now().Add(-trackForwarderKeyframeRequestInterval)
+	relayed = forwarder.relayedRTCP([]rtcp.Packet{
+		&rtcp.FullIntraRequest{SenderSSRC: 1, MediaSSRC: 5678, FIR: []rtcp.FIREntry{{SSRC: 5678, SequenceNumber: 7}}},
+	})
+	assert.Equal(t, []rtcp.Packet{
+		&rtcp.FullIntraRequest{MediaSSRC: 1234, FIR: []rtcp.FIREntry{{SSRC: 1234, SequenceNumber: 1}}},
+	}, relayed)
+}
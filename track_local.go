@@ -19,6 +19,10 @@ type TrackLocalWriter interface {
 
 // TrackLocalContext is the Context passed when a TrackLocal has been Binded/Unbinded from a PeerConnection, and used
 // in Interceptors.
+//
+// The TrackLocalContext of a PeerConnection also implements interface{ MTU() int }, which returns the maximum
+// size of the RTP packets written to the WriteStream, before their encryption. It follows the path MTU once it is
+// discovered, see SettingEngine.SetPathMTUDiscovery.
 type TrackLocalContext interface {
 	// CodecParameters returns the negotiated RTPCodecParameters. These are the codecs supported by both
 	// PeerConnections and the PayloadTypes
@@ -48,12 +52,25 @@ type TrackLocalContext interface {
 	RTCPReader() interceptor.RTCPReader
 }
 
+// trackLocalContextMTU returns the MTU of trackContext if it implements interface{ MTU() int },
+// the default send MTU otherwise.
+func trackLocalContextMTU(trackContext TrackLocalContext) func() int {
+	if withMTU, ok := trackContext.(interface{ MTU() int }); ok {
+		return withMTU.MTU
+	}
+
+	return func() int {
+		return outboundMTU
+	}
+}
+
 type baseTrackLocalContext struct {
 	id                     string
 	params                 RTPParameters
 	ssrc, ssrcRTX, ssrcFEC SSRC
 	writeStream            TrackLocalWriter
 	rtcpInterceptor        interceptor.RTCPReader
+	mtu                    func() int
 }
 
 // CodecParameters returns the negotiated RTPCodecParameters. These are the codecs supported by both
@@ -99,6 +116,15 @@ func (t *baseTrackLocalContext) RTCPReader() interceptor.RTCPReader {
 	return t.rtcpInterceptor
 }
 
+// MTU returns the maximum size of the RTP packets written to the WriteStream.
+func (t *baseTrackLocalContext) MTU() int {
+	if t.mtu == nil {
+		return outboundMTU
+	}
+
+	return t.mtu()
+}
+
 // TrackLocal is an interface that controls how the user can send media
 // The user can provide their own TrackLocal implementations, or use
 // the implementations in pkg/media.
@@ -7,11 +7,18 @@
 package webrtc
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
+	"github.com/pion/randutil"
 	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
 	"github.com/pion/webrtc/v4/internal/util"
+	"github.com/pion/webrtc/v4/pkg/codecs/rawvideo"
+	"github.com/pion/webrtc/v4/pkg/codecs/red"
 	"github.com/pion/webrtc/v4/pkg/media"
 )
 
@@ -22,7 +29,12 @@ type trackBinding struct {
 	id                          string
 	ssrc, ssrcRTX, ssrcFEC      SSRC
 	payloadType, payloadTypeRTX PayloadType
+	audioLevelExtensionID       uint8
 	writeStream                 TrackLocalWriter
+	mtu                         func() int
+
+	// dependencyDescriptorExtensionID is the negotiated ID of the dependency descriptor
+	dependencyDescriptorExtensionID uint8
 }
 
 // TrackLocalStaticRTP  is a TrackLocal that has a pre-set codec and accepts RTP Packets.
@@ -34,6 +46,11 @@ type TrackLocalStaticRTP struct {
 	payloader         func(RTPCodecCapability) (rtp.Payloader, error)
 	id, rid, streamID string
 	rtpTimestamp      *uint32
+	sequenceNumber    *uint16
+	// sequenceNumberShift is added to the sequence numbers of the packets written with
+	// WithRTPSequenceNumber, with bit 16 set once it is known.
+	sequenceNumberShift atomic.Uint32
+	redDistance         int
 }
 
 // NewTrackLocalStaticRTP returns a TrackLocalStaticRTP.
@@ -43,10 +60,11 @@ func NewTrackLocalStaticRTP(
 	options ...func(*TrackLocalStaticRTP),
 ) (*TrackLocalStaticRTP, error) {
 	t := &TrackLocalStaticRTP{
-		codec:    c,
-		bindings: []trackBinding{},
-		id:       id,
-		streamID: streamID,
+		codec:       c,
+		bindings:    []trackBinding{},
+		id:          id,
+		streamID:    streamID,
+		redDistance: defaultREDDistance,
 	}
 
 	for _, option := range options {
@@ -77,6 +95,24 @@ func WithRTPTimestamp(timestamp uint32) func(*TrackLocalStaticRTP) {
 	}
 }
 
+// WithRTPSequenceNumber sets the initial RTP sequence number of the track, which allows to
+// continue the numbering of another source. A TrackLocalStaticSample numbers its packets
+// from it, it is random by default. A TrackLocalStaticRTP shifts the sequence numbers of
+// the packets written, so the first one sent once bound gets it and the gaps are kept.
+func WithRTPSequenceNumber(sequenceNumber uint16) func(*TrackLocalStaticRTP) {
+	return func(s *TrackLocalStaticRTP) {
+		s.sequenceNumber = &sequenceNumber
+	}
+}
+
+// WithREDDistance sets how many previous payloads a TrackLocalStaticSample using the
+// MimeTypeRED codec sends as redundancy with each payload, defaults to 1.
+func WithREDDistance(distance int) func(*TrackLocalStaticRTP) {
+	return func(s *TrackLocalStaticRTP) {
+		s.redDistance = distance
+	}
+}
+
 // Bind is called by the PeerConnection after negotiation is complete
 // This asserts that the code requested is supported by the remote peer.
 // If so it sets up all the state (SSRC and PayloadType) to have a call.
@@ -97,6 +133,12 @@ func (s *TrackLocalStaticRTP) Bind(trackContext TrackLocalContext) (RTPCodecPara
 			payloadTypeRTX: findRTXPayloadType(codec.PayloadType, trackContext.CodecParameters()),
 			writeStream:    trackContext.WriteStream(),
 			id:             trackContext.ID(),
+			mtu:            trackLocalContextMTU(trackContext),
+
+			audioLevelExtensionID: findHeaderExtensionID(sdp.AudioLevelURI, trackContext.HeaderExtensions()),
+			dependencyDescriptorExtensionID: findHeaderExtensionID(
+				DependencyDescriptorURI, trackContext.HeaderExtensions(),
+			),
 		})
 
 		return codec, nil
@@ -105,6 +147,18 @@ func (s *TrackLocalStaticRTP) Bind(trackContext TrackLocalContext) (RTPCodecPara
 	return RTPCodecParameters{}, ErrUnsupportedCodec
 }
 
+// findHeaderExtensionID returns the negotiated ID of the header extension with the given URI,
+// or 0 if it hasn't been negotiated.
+func findHeaderExtensionID(uri string, headerExtensions []RTPHeaderExtensionParameter) uint8 {
+	for _, h := range headerExtensions {
+		if h.URI == uri {
+			return uint8(h.ID) //nolint:gosec // G115, IDs are in the range 1-255
+		}
+	}
+
+	return 0
+}
+
 // Unbind implements the teardown logic when the track is no longer needed. This happens
 // because a track has been stopped.
 func (s *TrackLocalStaticRTP) Unbind(t TrackLocalContext) error {
@@ -186,9 +240,60 @@ func (s *TrackLocalStaticRTP) WriteRTP(p *rtp.Packet) error {
 
 // writeRTP is like WriteRTP, except that it may modify the packet p.
 func (s *TrackLocalStaticRTP) writeRTP(packet *rtp.Packet) error {
+	return s.writeRTPWithExtensions(packet, trackHeaderExtensions{})
+}
+
+// WriteRTPWithDependencyDescriptor writes a RTP packet like WriteRTP, with the dependency
+// descriptor header extension, the payload returned by DependencyDescriptor.Marshal, in
+// the ID negotiated by each PeerConnection the track is bound to. It is left out for the
+// PeerConnections which haven't negotiated it, see
+// ConfigureDependencyDescriptorHeaderExtension.
+func (s *TrackLocalStaticRTP) WriteRTPWithDependencyDescriptor(p *rtp.Packet, dependencyDescriptor []byte) error {
+	packet := getPacketAllocationFromPool()
+
+	defer resetPacketPoolAllocation(packet)
+
+	*packet = *p
+
+	return s.writeRTPWithExtensions(packet, trackHeaderExtensions{dependencyDescriptor: dependencyDescriptor})
+}
+
+// trackHeaderExtensions are the marshaled header extensions written with the IDs
+// negotiated by each binding, nil if not written.
+type trackHeaderExtensions struct {
+	audioLevel, dependencyDescriptor []byte
+}
+
+// set sets the extensions negotiated by b on header.
+func (e trackHeaderExtensions) set(header *rtp.Header, b trackBinding) error {
+	if e.dependencyDescriptor != nil && b.dependencyDescriptorExtensionID != 0 {
+		// The descriptors carrying a template structure are often too large for one byte headers
+		profile := uint16(rtp.ExtensionProfileOneByte)
+		if len(e.dependencyDescriptor) > 16 {
+			profile = rtp.ExtensionProfileTwoByte
+		}
+		err := header.SetExtensionWithProfile(b.dependencyDescriptorExtensionID, e.dependencyDescriptor, profile)
+		if err != nil {
+			return err
+		}
+	}
+	if e.audioLevel != nil && b.audioLevelExtensionID != 0 {
+		return header.SetExtension(b.audioLevelExtensionID, e.audioLevel)
+	}
+
+	return nil
+}
+
+// writeRTPWithExtensions is like writeRTP, it also adds the extensions to the packets of
+// every binding that negotiated them.
+func (s *TrackLocalStaticRTP) writeRTPWithExtensions(packet *rtp.Packet, extensions trackHeaderExtensions) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	if s.sequenceNumber != nil && len(s.bindings) > 0 {
+		packet.SequenceNumber += s.sequenceNumberOffset(packet.SequenceNumber)
+	}
+
 	writeErrs := []error{}
 
 	for _, b := range s.bindings {
@@ -199,7 +304,20 @@ func (s *TrackLocalStaticRTP) writeRTP(packet *rtp.Packet) error {
 		if packet.PaddingSize != 0 && packet.Header.PaddingSize == 0 {
 			packet.Header.PaddingSize = packet.PaddingSize
 		}
-		if _, err := b.writeStream.WriteRTP(&packet.Header, packet.Payload); err != nil {
+
+		header := &packet.Header
+		if (extensions.audioLevel != nil && b.audioLevelExtensionID != 0) ||
+			(extensions.dependencyDescriptor != nil && b.dependencyDescriptorExtensionID != 0) {
+			// Extension IDs are negotiated per binding, don't leak them to the others
+			extended := packet.Header.Clone()
+			if err := extensions.set(&extended, b); err != nil {
+				writeErrs = append(writeErrs, err)
+
+				continue
+			}
+			header = &extended
+		}
+		if _, err := b.writeStream.WriteRTP(header, packet.Payload); err != nil {
 			writeErrs = append(writeErrs, err)
 		}
 	}
@@ -207,6 +325,17 @@ func (s *TrackLocalStaticRTP) writeRTP(packet *rtp.Packet) error {
 	return util.FlattenErrs(writeErrs)
 }
 
+// sequenceNumberOffset returns the offset of the sequence numbers set with
+// WithRTPSequenceNumber, computed from the first packet sent.
+func (s *TrackLocalStaticRTP) sequenceNumberOffset(sequenceNumber uint16) uint16 {
+	shift := 1<<16 | uint32(*s.sequenceNumber-sequenceNumber)
+	if !s.sequenceNumberShift.CompareAndSwap(0, shift) {
+		shift = s.sequenceNumberShift.Load()
+	}
+
+	return uint16(shift) //nolint:gosec // G115
+}
+
 // Write writes a RTP Packet as a buffer to the TrackLocalStaticRTP
 // If one PeerConnection fails the packets will still be sent to
 // all PeerConnections. The error message will contain the ID of the failed
@@ -226,10 +355,21 @@ func (s *TrackLocalStaticRTP) Write(b []byte) (n int, err error) {
 // TrackLocalStaticSample is a TrackLocal that has a pre-set codec and accepts Samples.
 // If you wish to send a RTP Packet use TrackLocalStaticRTP.
 type TrackLocalStaticSample struct {
-	packetizer rtp.Packetizer
-	sequencer  rtp.Sequencer
-	rtpTrack   *TrackLocalStaticRTP
-	clockRate  float64
+	packetizer         rtp.Packetizer
+	sequencer          rtp.Sequencer
+	rtpTrack           *TrackLocalStaticRTP
+	clockRate          float64
+	audioLevelDetector AudioLevelDetector
+	redEncoder         *red.Encoder
+}
+
+// AudioLevelDetector computes the audio level of outgoing Samples, which is sent to the
+// remote in the client-to-mixer audio level header extension defined by RFC 6464.
+// pkg/media/vad provides an energy based implementation.
+type AudioLevelDetector interface {
+	// AudioLevel returns the level of the Sample in -dBov, in the range 0 (loudest)
+	// to 127 (silence), and if the Sample contains voice.
+	AudioLevel(sample media.Sample) (level uint8, voice bool)
 }
 
 // NewTrackLocalStaticSample returns a TrackLocalStaticSample.
@@ -294,7 +434,17 @@ func (s *TrackLocalStaticSample) Bind(t TrackLocalContext) (RTPCodecParameters,
 		return codec, err
 	}
 
-	s.sequencer = rtp.NewRandomSequencer()
+	sequenceNumber := uint16(randutil.NewMathRandomGenerator().Uint32()) //nolint:gosec // G115
+	if s.rtpTrack.sequenceNumber != nil {
+		sequenceNumber = *s.rtpTrack.sequenceNumber
+	}
+	s.sequencer = rtp.NewFixedSequencer(sequenceNumber)
+	if rawPayloader, ok := payloader.(*rawvideo.Payloader); ok {
+		// The extended sequence numbers follow the rollovers of the sequencer
+		rawPayloader.SequenceNumber = sequenceNumber
+	}
+	// The packets fit the MTU of every binding, checked for each Sample
+	payloader = &mtuPayloader{Payloader: payloader, mtu: s.mtu}
 
 	options := []rtp.PacketizerOption{}
 
@@ -303,7 +453,7 @@ func (s *TrackLocalStaticSample) Bind(t TrackLocalContext) (RTPCodecParameters,
 	}
 
 	s.packetizer = rtp.NewPacketizerWithOptions(
-		outboundMTU,
+		packetizerMaxMTU,
 		payloader,
 		s.sequencer,
 		codec.ClockRate,
@@ -312,9 +462,69 @@ func (s *TrackLocalStaticSample) Bind(t TrackLocalContext) (RTPCodecParameters,
 
 	s.clockRate = float64(codec.RTPCodecCapability.ClockRate)
 
+	if strings.EqualFold(codec.MimeType, MimeTypeRED) {
+		// The fmtp line lists the payload type of every block, they are all the same for Opus
+		blockPayloadType, err := strconv.ParseUint(strings.Split(codec.SDPFmtpLine, "/")[0], 10, 7)
+		if err != nil {
+			return codec, fmt.Errorf("%w: %s", errInvalidREDFmtp, codec.SDPFmtpLine)
+		}
+
+		s.redEncoder = &red.Encoder{
+			PayloadType: uint8(blockPayloadType),
+			Distance:    s.rtpTrack.redDistance,
+			MaxSize:     outboundMTU - rtpHeaderSize,
+		}
+	}
+
 	return codec, nil
 }
 
+// mtu returns the smallest MTU of the bindings.
+func (s *TrackLocalStaticSample) mtu() int {
+	s.rtpTrack.mu.RLock()
+	defer s.rtpTrack.mu.RUnlock()
+
+	mtu := 0
+	for _, b := range s.rtpTrack.bindings {
+		if bindingMTU := b.mtu(); mtu == 0 || bindingMTU < mtu {
+			mtu = bindingMTU
+		}
+	}
+	if mtu == 0 {
+		return outboundMTU
+	}
+
+	return mtu
+}
+
+// mtuPayloader limits the size of the payloads of a Payloader to the MTU of the
+// bindings of a TrackLocalStaticSample, which changes with the discovered path MTU.
+type mtuPayloader struct {
+	rtp.Payloader
+
+	mtu func() int
+}
+
+func (p *mtuPayloader) Payload(mtu uint16, payload []byte) [][]byte {
+	maxSize := p.mtu() - rtpHeaderSize
+	if maxSize > 0 && maxSize < int(mtu) {
+		mtu = uint16(maxSize) //nolint:gosec // G115
+	}
+
+	return p.Payloader.Payload(mtu, payload)
+}
+
+// SetAudioLevelDetector sets the AudioLevelDetector used to add the audio level header extension
+// to the packets of every written Sample. The extension is only added for PeerConnections that
+// negotiated it, which requires registering sdp.AudioLevelURI with the MediaEngine.
+// Pass nil to stop sending the extension.
+func (s *TrackLocalStaticSample) SetAudioLevelDetector(detector AudioLevelDetector) {
+	s.rtpTrack.mu.Lock()
+	defer s.rtpTrack.mu.Unlock()
+
+	s.audioLevelDetector = detector
+}
+
 // Unbind implements the teardown logic when the track is no longer needed. This happens
 // because a track has been stopped.
 func (s *TrackLocalStaticSample) Unbind(t TrackLocalContext) error {
@@ -329,6 +539,7 @@ func (s *TrackLocalStaticSample) WriteSample(sample media.Sample) error {
 	s.rtpTrack.mu.RLock()
 	packetizer := s.packetizer
 	clockRate := s.clockRate
+	detector := s.audioLevelDetector
 	s.rtpTrack.mu.RUnlock()
 
 	if packetizer == nil {
@@ -345,10 +556,87 @@ func (s *TrackLocalStaticSample) WriteSample(sample media.Sample) error {
 		packetizer.SkipSamples(samples * uint32(sample.PrevDroppedPackets))
 	}
 	packets := packetizer.Packetize(sample.Data, samples)
+	s.encodeRED(packets)
+
+	return s.writePackets(packets, audioLevelPayload(detector, sample))
+}
+
+// WriteSampleWithTimestamp writes a Sample to the TrackLocalStaticSample using rtpTimestamp
+// as the RTP timestamp of its packets instead of deriving it from the Duration of the previous Samples.
+// Samples must be written in decode order, so the timestamps of a stream with B-frames are not monotonic.
+// A following WriteSample continues from rtpTimestamp + Duration, which allows to splice or seek within a source.
+// media.RTPTimestampFromPTS converts the presentation timestamps of containers to rtpTimestamp.
+// If one PeerConnection fails the packets will still be sent to
+// all PeerConnections. The error message will contain the ID of the failed
+// PeerConnections so you can remove them.
+func (s *TrackLocalStaticSample) WriteSampleWithTimestamp(sample media.Sample, rtpTimestamp uint32) error {
+	s.rtpTrack.mu.RLock()
+	packetizer := s.packetizer
+	clockRate := s.clockRate
+	detector := s.audioLevelDetector
+	s.rtpTrack.mu.RUnlock()
+
+	if packetizer == nil {
+		return nil
+	}
+
+	// skip packets by the number of previously dropped packets
+	for i := uint16(0); i < sample.PrevDroppedPackets; i++ {
+		s.sequencer.NextSequenceNumber()
+	}
+
+	packets := packetizer.Packetize(sample.Data, 0)
+	if len(packets) == 0 {
+		return nil
+	}
+
+	// Move the packetizer to the end of this Sample, so WriteSample and GeneratePadding continue from there
+	samples := uint32(sample.Duration.Seconds() * clockRate)
+	packetizer.SkipSamples(rtpTimestamp + samples - packets[0].Timestamp)
 
+	for _, p := range packets {
+		p.Timestamp = rtpTimestamp
+	}
+	s.encodeRED(packets)
+
+	return s.writePackets(packets, audioLevelPayload(detector, sample))
+}
+
+// encodeRED adds the redundant blocks to packets if the RED codec has been negotiated.
+func (s *TrackLocalStaticSample) encodeRED(packets []*rtp.Packet) {
+	s.rtpTrack.mu.RLock()
+	encoder := s.redEncoder
+	s.rtpTrack.mu.RUnlock()
+
+	if encoder == nil {
+		return
+	}
+
+	for _, p := range packets {
+		p.Payload = encoder.Encode(p.Timestamp, p.Payload)
+	}
+}
+
+// audioLevelPayload returns the marshaled audio level extension of a Sample,
+// or nil if no AudioLevelDetector is set.
+func audioLevelPayload(detector AudioLevelDetector, sample media.Sample) []byte {
+	if detector == nil {
+		return nil
+	}
+
+	level, voice := detector.AudioLevel(sample)
+	payload, err := (&rtp.AudioLevelExtension{Level: min(level, 127), Voice: voice}).Marshal()
+	if err != nil {
+		return nil
+	}
+
+	return payload
+}
+
+func (s *TrackLocalStaticSample) writePackets(packets []*rtp.Packet, audioLevel []byte) error {
 	writeErrs := []error{}
 	for _, p := range packets {
-		if err := s.rtpTrack.WriteRTP(p); err != nil {
+		if err := s.rtpTrack.writeRTPWithExtensions(p, trackHeaderExtensions{audioLevel: audioLevel}); err != nil {
 			writeErrs = append(writeErrs, err)
 		}
 	}
@@ -369,14 +657,5 @@ func (s *TrackLocalStaticSample) GeneratePadding(samples uint32) error {
 		return nil
 	}
 
-	packets := p.GeneratePadding(samples)
-
-	writeErrs := []error{}
-	for _, p := range packets {
-		if err := s.rtpTrack.WriteRTP(p); err != nil {
-			writeErrs = append(writeErrs, err)
-		}
-	}
-
-	return util.FlattenErrs(writeErrs)
+	return s.writePackets(p.GeneratePadding(samples), nil)
 }
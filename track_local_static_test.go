@@ -16,6 +16,7 @@ import (
 	"github.com/pion/interceptor"
 	"github.com/pion/rtp"
 	"github.com/pion/transport/v3/test"
+	"github.com/pion/webrtc/v4/pkg/codecs/red"
 	"github.com/pion/webrtc/v4/pkg/media"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -507,6 +508,61 @@ func Test_TrackLocalStatic_Timestamp(t *testing.T) {
 	closePairNow(t, pcOffer, pcAnswer)
 }
 
+type recordingWriter struct {
+	headers      []rtp.Header
+	payloadSizes []int
+}
+
+func (r *recordingWriter) WriteRTP(header *rtp.Header, payload []byte) (int, error) {
+	r.headers = append(r.headers, *header)
+	r.payloadSizes = append(r.payloadSizes, len(payload))
+
+	return 0, nil
+}
+func (r *recordingWriter) Write(_ []byte) (int, error) { return 0, nil }
+
+func Test_TrackLocalStaticSample_WriteSampleWithTimestamp(t *testing.T) {
+	track, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithRTPSequenceNumber(65535),
+	)
+	require.NoError(t, err)
+
+	// Not bound yet, nothing to do
+	require.NoError(t, track.WriteSampleWithTimestamp(media.Sample{Data: []byte{0x00}}, 1000))
+
+	_, err = track.Bind(dummyTrackLocalContext{id: "b1"})
+	require.NoError(t, err)
+
+	writer := &recordingWriter{}
+	track.rtpTrack.mu.Lock()
+	track.rtpTrack.bindings[0].writeStream = writer
+	track.rtpTrack.mu.Unlock()
+
+	// Decode order of I P B, presentation timestamps are not monotonic
+	require.NoError(t, track.WriteSampleWithTimestamp(media.Sample{Data: []byte{0x00}}, 3000))
+	require.NoError(t, track.WriteSampleWithTimestamp(
+		media.Sample{Data: []byte{0x01}}, media.RTPTimestampFromPTS(300, 3000, 90000),
+	))
+	require.NoError(t, track.WriteSampleWithTimestamp(
+		media.Sample{Data: []byte{0x02}, Duration: 100 * time.Millisecond}, 6000,
+	))
+
+	// WriteSample continues after the last explicit timestamp
+	require.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x03}, Duration: 100 * time.Millisecond}))
+
+	// Empty samples are not sent
+	require.NoError(t, track.WriteSampleWithTimestamp(media.Sample{}, 1))
+
+	require.Len(t, writer.headers, 4)
+	require.Equal(t, uint16(65535), writer.headers[0].SequenceNumber)
+	for i, expected := range []uint32{3000, 9000, 6000, 15000} {
+		require.Equal(t, expected, writer.headers[i].Timestamp)
+		if i > 0 {
+			require.Equal(t, writer.headers[i-1].SequenceNumber+1, writer.headers[i].SequenceNumber)
+		}
+	}
+}
+
 type dummyWriter struct{}
 
 func (dummyWriter) WriteRTP(_ *rtp.Header, _ []byte) (int, error) { return 0, nil }
@@ -860,3 +916,217 @@ func TestBaseTrackLocalContext_HeaderExtensions_NilWhenUnset(t *testing.T) {
 	var ctx baseTrackLocalContext
 	assert.Nil(t, ctx.HeaderExtensions())
 }
+
+func TestTrackLocalContextMTU(t *testing.T) {
+	// The contexts not implementing MTU get the default send MTU
+	assert.Equal(t, outboundMTU, trackLocalContextMTU(dummyTrackLocalContext{})())
+
+	ctx := &baseTrackLocalContext{mtu: func() int { return 1400 }}
+	assert.Equal(t, 1400, trackLocalContextMTU(ctx)())
+}
+
+type staticAudioLevelDetector struct {
+	level uint8
+	voice bool
+}
+
+func (d staticAudioLevelDetector) AudioLevel(media.Sample) (uint8, bool) { return d.level, d.voice }
+
+func Test_TrackLocalStaticSample_AudioLevelDetector(t *testing.T) {
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "audio", "pion")
+	require.NoError(t, err)
+
+	_, err = track.Bind(dummyTrackLocalContext{id: "b1"})
+	require.NoError(t, err)
+	_, err = track.Bind(dummyTrackLocalContext{id: "b2"})
+	require.NoError(t, err)
+
+	withExtension, withoutExtension := &recordingWriter{}, &recordingWriter{}
+	track.rtpTrack.mu.Lock()
+	track.rtpTrack.bindings[0].writeStream = withExtension
+	track.rtpTrack.bindings[0].audioLevelExtensionID = 3
+	track.rtpTrack.bindings[1].writeStream = withoutExtension
+	track.rtpTrack.mu.Unlock()
+
+	// No detector, no extension
+	require.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Millisecond}))
+	require.Len(t, withExtension.headers, 1)
+	require.Nil(t, withExtension.headers[0].GetExtension(3))
+
+	track.SetAudioLevelDetector(staticAudioLevelDetector{level: 30, voice: true})
+	require.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Millisecond}))
+	require.NoError(t, track.WriteSampleWithTimestamp(media.Sample{Data: []byte{0x00}}, 1000))
+	require.Len(t, withExtension.headers, 3)
+
+	for _, header := range withExtension.headers[1:] {
+		audioLevel := rtp.AudioLevelExtension{}
+		require.NoError(t, audioLevel.Unmarshal(header.GetExtension(3)))
+		require.Equal(t, rtp.AudioLevelExtension{Level: 30, Voice: true}, audioLevel)
+	}
+	for _, header := range withoutExtension.headers {
+		require.False(t, header.Extension)
+	}
+
+	track.SetAudioLevelDetector(nil)
+	require.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Millisecond}))
+	require.Nil(t, withExtension.headers[3].GetExtension(3))
+}
+
+func Test_TrackLocalStaticSample_MTU(t *testing.T) {
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+
+	_, err = track.Bind(dummyTrackLocalContext{id: "b1"})
+	require.NoError(t, err)
+	_, err = track.Bind(dummyTrackLocalContext{id: "b2"})
+	require.NoError(t, err)
+
+	var pathMTU atomic.Int32
+	pathMTU.Store(outboundMTU)
+	writer := &recordingWriter{}
+	track.rtpTrack.mu.Lock()
+	track.rtpTrack.bindings[0].writeStream = writer
+	track.rtpTrack.bindings[1].mtu = func() int { return int(pathMTU.Load()) }
+	track.rtpTrack.mu.Unlock()
+
+	// The packets fit the smallest MTU of the bindings, which follows the path MTU
+	for _, mtu := range []int32{outboundMTU, 600, 8000} {
+		pathMTU.Store(mtu)
+		writer.payloadSizes = nil
+		require.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 4000), Duration: time.Millisecond}))
+
+		expected := int(min(mtu, outboundMTU)) - rtpHeaderSize
+		assert.Equal(t, expected, writer.payloadSizes[0], mtu)
+		for _, size := range writer.payloadSizes {
+			assert.LessOrEqual(t, size, expected, mtu)
+		}
+	}
+
+	track.rtpTrack.mu.Lock()
+	track.rtpTrack.bindings[0].mtu = func() int { return 9000 }
+	track.rtpTrack.mu.Unlock()
+	writer.payloadSizes = nil
+	require.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 20000), Duration: time.Millisecond}))
+	assert.Equal(t, 8000-rtpHeaderSize, writer.payloadSizes[0])
+}
+
+func Test_TrackLocalStatic_RED(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newAPI := func() *API {
+		mediaEngine := &MediaEngine{}
+		assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeRED, ClockRate: 48000, Channels: 2, SDPFmtpLine: "111/111"},
+			PayloadType:        63,
+		}, RTPCodecTypeAudio))
+		assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeOpus, ClockRate: 48000, Channels: 2},
+			PayloadType:        111,
+		}, RTPCodecTypeAudio))
+
+		return NewAPI(WithMediaEngine(mediaEngine))
+	}
+
+	offerer, err := newAPI().NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	answerer, err := newAPI().NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeRED, ClockRate: 48000, Channels: 2, SDPFmtpLine: "111/111"},
+		"audio", "pion", WithREDDistance(2),
+	)
+	assert.NoError(t, err)
+
+	_, err = offerer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+
+	_, err = answerer.AddTrack(track)
+	assert.NoError(t, err)
+
+	onRedundancy, onRedundancyFunc := context.WithCancel(context.Background())
+	offerer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		assert.Equal(t, PayloadType(63), track.PayloadType())
+
+		depacketizer := &red.Packet{}
+		for {
+			pkt, _, readErr := track.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			primary, unmarshalErr := depacketizer.Unmarshal(pkt.Payload)
+			assert.NoError(t, unmarshalErr)
+			assert.Equal(t, []byte{0x00}, primary)
+
+			if len(depacketizer.Blocks) == 3 {
+				for i, block := range depacketizer.Blocks {
+					assert.Equal(t, uint8(111), block.PayloadType)
+					assert.Equal(t, uint16(960*(2-i)), block.TimestampOffset)
+				}
+				onRedundancyFunc()
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(offerer, answerer))
+
+	sendVideoUntilDone(t, onRedundancy.Done(), []*TrackLocalStaticSample{track})
+
+	closePairNow(t, offerer, answerer)
+}
+
+func Test_TrackLocalStaticSample_Bind_InvalidREDFmtp(t *testing.T) {
+	track, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeRED, ClockRate: 48000, Channels: 2}, "audio", "pion",
+	)
+	require.NoError(t, err)
+
+	_, err = track.Bind(redTrackLocalContext{dummyTrackLocalContext{id: "b1"}})
+	require.ErrorIs(t, err, errInvalidREDFmtp)
+}
+
+type redTrackLocalContext struct {
+	dummyTrackLocalContext
+}
+
+func (redTrackLocalContext) CodecParameters() []RTPCodecParameters {
+	return []RTPCodecParameters{{
+		RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeRED, ClockRate: 48000, Channels: 2},
+		PayloadType:        63,
+	}}
+}
+
+func Test_TrackLocalStaticRTP_SequenceNumber(t *testing.T) {
+	track, err := NewTrackLocalStaticRTP(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithRTPSequenceNumber(65535),
+	)
+	require.NoError(t, err)
+
+	// Not bound yet, the packet is not sent and doesn't set the offset
+	require.NoError(t, track.WriteRTP(&rtp.Packet{Header: rtp.Header{SequenceNumber: 10}}))
+
+	_, err = track.Bind(dummyTrackLocalContext{id: "b1"})
+	require.NoError(t, err)
+
+	writer := &recordingWriter{}
+	track.mu.Lock()
+	track.bindings[0].writeStream = writer
+	track.mu.Unlock()
+
+	for _, sequenceNumber := range []uint16{100, 101, 103} {
+		packet := &rtp.Packet{Header: rtp.Header{SequenceNumber: sequenceNumber}}
+		require.NoError(t, track.WriteRTP(packet))
+		require.Equal(t, sequenceNumber, packet.SequenceNumber)
+	}
+
+	require.Len(t, writer.headers, 3)
+	for i, expected := range []uint16{65535, 0, 2} {
+		require.Equal(t, expected, writer.headers[i].SequenceNumber)
+	}
+}
@@ -9,6 +9,7 @@ package webrtc
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/interceptor"
@@ -26,6 +27,8 @@ type TrackRemote struct {
 	kind        RTPCodecType
 	ssrc        SSRC
 	rtxSsrc     SSRC
+	fecSsrc     SSRC
+	ulpFEC      bool
 	codec       RTPCodecParameters
 	params      RTPParameters
 	rid         string
@@ -35,6 +38,53 @@ type TrackRemote struct {
 	peekedAttributes interceptor.Attributes
 
 	audioPlayoutStatsProviders []AudioPlayoutStatsProvider
+
+	// framesReceived counts the video packets with the marker bit returned by Read
+	framesReceived atomic.Uint64
+
+	// playoutDelayExtensionID is the negotiated ID of the playout delay header extension
+	playoutDelayExtensionID uint8
+	playoutDelay            atomic.Value // PlayoutDelay
+
+	// dependencyDescriptorExtensionID is the negotiated ID of the dependency descriptor
+	// header extension, dependencyStructure the last template structure it carried
+	dependencyDescriptorExtensionID uint8
+	dependencyStructure             atomic.Pointer[FrameDependencyStructure]
+
+	// fanout copies the packets to the clones of the track once it has been cloned,
+	// the track then reads its own copies from fanoutReader, see Clone
+	fanout       *trackFanout
+	fanoutReader *trackFanoutReader
+
+	// sourceDescription is shared with the clones of the track
+	sourceDescription *atomic.Pointer[SourceDescription]
+}
+
+// TrackRemoteStats is a snapshot of the statistics of a TrackRemote, see TrackRemote.Stats.
+type TrackRemoteStats struct {
+	SSRC SSRC
+
+	PacketsReceived             uint64
+	PacketsLost                 int64
+	BytesReceived               uint64
+	HeaderBytesReceived         uint64
+	LastPacketReceivedTimestamp time.Time
+	// Jitter is the interarrival jitter of the packets in seconds.
+	Jitter float64
+
+	FIRCount  uint32
+	PLICount  uint32
+	NACKCount uint32
+
+	// FramesReceived counts the video frames read from the track, a frame being
+	// completed by a packet with the marker bit.
+	FramesReceived uint64
+
+	// The last Sender Report received for the stream, RemoteTimestamp is its NTP time
+	SenderReportsReceived uint64
+	RemoteTimestamp       time.Time
+	RemotePacketsSent     uint64
+	RemoteBytesSent       uint64
 }
 
 func newTrackRemote(kind RTPCodecType, ssrc, rtxSsrc SSRC, rid string, receiver *RTPReceiver) *TrackRemote {
@@ -44,6 +94,8 @@ func newTrackRemote(kind RTPCodecType, ssrc, rtxSsrc SSRC, rid string, receiver
 		rtxSsrc:  rtxSsrc,
 		rid:      rid,
 		receiver: receiver,
+
+		sourceDescription: &atomic.Pointer[SourceDescription]{},
 	}
 }
 
@@ -113,7 +165,40 @@ func (t *TrackRemote) Codec() RTPCodecParameters {
 }
 
 // Read reads data from the track.
+// If the track has a separate RTX stream, the retransmitted packets are unwrapped and
+// returned in the same format as the packets of the track, interleaved with them in
+// the order they are received. Their attributes have AttributeRepaired set to true.
+// The same goes for the packets recovered from a FlexFEC-03 stream or from ULPFEC,
+// which also have AttributeFECRecovered set to true. The ULPFEC packets are dropped,
+// and the packets of the track are unwrapped from the RED carrying them, as are the
+// original packets arriving after they have been recovered.
 func (t *TrackRemote) Read(b []byte) (n int, attributes interceptor.Attributes, err error) {
+	t.mu.RLock()
+	fanoutReader := t.fanoutReader
+	t.mu.RUnlock()
+
+	if fanoutReader != nil {
+		n, attributes, err = fanoutReader.read(b)
+	} else {
+		n, attributes, err = t.readReceiver(b)
+	}
+	if err != nil {
+		return n, attributes, err
+	}
+
+	err = t.checkAndUpdateTrack(b)
+	if descriptor := t.observePacket(b[:n]); descriptor != nil {
+		if attributes == nil {
+			attributes = make(interceptor.Attributes)
+		}
+		attributes.Set(AttributeDependencyDescriptor, descriptor)
+	}
+
+	return n, attributes, err
+}
+
+// readReceiver reads the next packet of the track from the RTPReceiver.
+func (t *TrackRemote) readReceiver(b []byte) (n int, attributes interceptor.Attributes, err error) {
 	t.mu.RLock()
 	receiver := t.receiver
 	peeked := t.peeked != nil
@@ -131,9 +216,8 @@ func (t *TrackRemote) Read(b []byte) (n int, attributes interceptor.Attributes,
 		// released the lock.  Deal with it.
 		if data != nil {
 			n = copy(b, data)
-			err = t.checkAndUpdateTrack(b)
 
-			return n, attributes, err
+			return n, attributes, nil
 		}
 	}
 
@@ -142,19 +226,105 @@ func (t *TrackRemote) Read(b []byte) (n int, attributes interceptor.Attributes,
 		n = copy(b, rtxPacketReceived.pkt)
 		attributes = rtxPacketReceived.attributes
 		rtxPacketReceived.release()
-		err = nil
-	} else {
-		// If there's no separate RTX track (or there's a separate RTX track but no RTX packet waiting), wait for and return
-		// a packet from the main track
-		n, attributes, err = receiver.readRTP(b, t)
-		if err != nil {
-			return n, attributes, err
+
+		return n, attributes, nil
+	}
+
+	// If there's no separate RTX track (or there's a separate RTX track but no RTX packet waiting), wait for and return
+	// a packet from the main track
+	return receiver.readRTP(b, t)
+}
+
+// observePacket counts the video frames completed by the packet in b, records the
+// playout delay it carries, and returns its dependency descriptor, nil if none.
+func (t *TrackRemote) observePacket(b []byte) *DependencyDescriptor {
+	if t.receiver.kind == RTPCodecTypeVideo && len(b) > 1 && b[1]&0x80 != 0 {
+		t.framesReceived.Add(1)
+	}
+
+	t.mu.RLock()
+	extensionID, dependencyDescriptorID := t.playoutDelayExtensionID, t.dependencyDescriptorExtensionID
+	t.mu.RUnlock()
+	if (extensionID == 0 && dependencyDescriptorID == 0) || len(b) < rtpHeaderSize || b[0]&0x10 == 0 {
+		return nil
+	}
+
+	header := rtp.Header{}
+	if _, err := header.Unmarshal(b); err != nil {
+		return nil
+	}
+	if payload := header.GetExtension(extensionID); extensionID != 0 && payload != nil {
+		if delay, err := unmarshalPlayoutDelay(payload); err == nil {
+			t.playoutDelay.Store(delay)
 		}
+	}
+
+	payload := header.GetExtension(dependencyDescriptorID)
+	if dependencyDescriptorID == 0 || payload == nil {
+		return nil
+	}
+	descriptor := &DependencyDescriptor{}
+	if err := descriptor.Unmarshal(payload, t.dependencyStructure.Load()); err != nil {
+		return nil
+	}
+	if descriptor.Structure != nil {
+		t.dependencyStructure.Store(descriptor.Structure)
+	}
+
+	return descriptor
+}
+
+// DependencyStructure returns the last template dependency structure received in the
+// dependency descriptor header extension, nil if none has been received or the
+// extension hasn't been negotiated, see ConfigureDependencyDescriptorHeaderExtension.
+// The descriptor of each packet is set in the attributes returned by Read, see
+// AttributeDependencyDescriptor.
+func (t *TrackRemote) DependencyStructure() *FrameDependencyStructure {
+	return t.dependencyStructure.Load()
+}
+
+// PlayoutDelay returns the playout delay last received in the playout delay header
+// extension, and false if none has been received. The extension is only read if it
+// has been negotiated, see ConfigurePlayoutDelayHeaderExtension.
+func (t *TrackRemote) PlayoutDelay() (PlayoutDelay, bool) {
+	delay, ok := t.playoutDelay.Load().(PlayoutDelay)
+
+	return delay, ok
+}
 
-		err = t.checkAndUpdateTrack(b)
+// Stats returns a snapshot of the statistics of the track, without building the
+// report of PeerConnection.GetStats. The RTP and RTCP counters are only known when
+// the stats interceptor is registered, see ConfigureStatsInterceptor, and the Sender
+// Report fields are only updated as the RTCP is read from the RTPReceiver.
+func (t *TrackRemote) Stats() TrackRemoteStats {
+	trackStats := TrackRemoteStats{
+		SSRC:           t.SSRC(),
+		FramesReceived: t.framesReceived.Load(),
+	}
+	if t.receiver.statsGetter == nil {
+		return trackStats
 	}
 
-	return n, attributes, err
+	stats := t.receiver.statsGetter.Get(uint32(trackStats.SSRC))
+	if stats == nil {
+		return trackStats
+	}
+
+	trackStats.PacketsReceived = stats.InboundRTPStreamStats.PacketsReceived
+	trackStats.PacketsLost = stats.InboundRTPStreamStats.PacketsLost
+	trackStats.BytesReceived = stats.InboundRTPStreamStats.BytesReceived
+	trackStats.HeaderBytesReceived = stats.InboundRTPStreamStats.HeaderBytesReceived
+	trackStats.LastPacketReceivedTimestamp = stats.InboundRTPStreamStats.LastPacketReceivedTimestamp
+	trackStats.Jitter = stats.InboundRTPStreamStats.Jitter
+	trackStats.FIRCount = stats.InboundRTPStreamStats.FIRCount
+	trackStats.PLICount = stats.InboundRTPStreamStats.PLICount
+	trackStats.NACKCount = stats.InboundRTPStreamStats.NACKCount
+	trackStats.SenderReportsReceived = stats.RemoteOutboundRTPStreamStats.ReportsSent
+	trackStats.RemoteTimestamp = stats.RemoteOutboundRTPStreamStats.RemoteTimeStamp
+	trackStats.RemotePacketsSent = stats.RemoteOutboundRTPStreamStats.PacketsSent
+	trackStats.RemoteBytesSent = stats.RemoteOutboundRTPStreamStats.BytesSent
+
+	return trackStats
 }
 
 // checkAndUpdateTrack checks payloadType for every incoming packet
@@ -178,6 +348,8 @@ func (t *TrackRemote) checkAndUpdateTrack(b []byte) error {
 		t.payloadType = payloadType
 		t.codec = params.Codecs[0]
 		t.params = params
+		t.playoutDelayExtensionID = findHeaderExtensionID(PlayoutDelayURI, params.HeaderExtensions)
+		t.dependencyDescriptorExtensionID = findHeaderExtensionID(DependencyDescriptorURI, params.HeaderExtensions)
 	}
 
 	return nil
@@ -191,10 +363,14 @@ func (t *TrackRemote) ReadRTP() (*rtp.Packet, interceptor.Attributes, error) {
 		return nil, nil, err
 	}
 
+	metrics := t.receiver.getPipelineMetrics()
+	start := metrics.now()
+
 	r := &rtp.Packet{}
 	if err := r.Unmarshal(b[:i]); err != nil {
 		return nil, nil, err
 	}
+	metrics.observeSince(PipelineStageDepacketize, start)
 
 	return r, attributes, nil
 }
@@ -220,7 +396,19 @@ func (t *TrackRemote) peek(b []byte) (n int, a interceptor.Attributes, err error
 }
 
 // SetReadDeadline sets the max amount of time the RTP stream will block before returning. 0 is forever.
+// Once expired Read and ReadRTP fail with a net.Error whose Timeout is true, including
+// while waiting for the RTPReceiver to start, until a new deadline is set.
 func (t *TrackRemote) SetReadDeadline(deadline time.Time) error {
+	t.mu.RLock()
+	fanoutReader := t.fanoutReader
+	t.mu.RUnlock()
+
+	if fanoutReader != nil {
+		fanoutReader.readDeadline.Set(deadline)
+
+		return nil
+	}
+
 	return t.receiver.setRTPReadDeadline(deadline, t)
 }
 
@@ -240,6 +428,25 @@ func (t *TrackRemote) HasRTX() bool {
 	return t.rtxSsrc != 0
 }
 
+// FecSSRC returns the FlexFEC SSRC for a track, or 0 if the track does not have a
+// FlexFEC stream whose packets are decoded. The ULPFEC packets are sent with the SSRC
+// of the track.
+func (t *TrackRemote) FecSSRC() SSRC {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.fecSsrc
+}
+
+// HasFEC returns true if the track has a FlexFEC stream or ULPFEC packets that are
+// decoded.
+func (t *TrackRemote) HasFEC() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.fecSsrc != 0 || t.ulpFEC
+}
+
 func (t *TrackRemote) addProvider(provider AudioPlayoutStatsProvider) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -309,3 +516,15 @@ func (t *TrackRemote) setRtxSSRC(ssrc SSRC) {
 	defer t.mu.Unlock()
 	t.rtxSsrc = ssrc
 }
+
+func (t *TrackRemote) setFecSSRC(ssrc SSRC) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fecSsrc = ssrc
+}
+
+func (t *TrackRemote) setULPFEC() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ulpFEC = true
+}
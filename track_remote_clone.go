@@ -0,0 +1,200 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/transport/v3/deadline"
+)
+
+// Clone returns a TrackRemote reading the same packets as t, so that several consumers,
+// a recorder and a forwarder for example, can read the track independently.
+//
+// Once t has been cloned, its packets are read from the RTPReceiver in the background
+// and copied to a buffer per track: t itself and each of its clones. A track buffers up
+// to 128 packets, the packets arriving while its buffer is full are dropped for it only,
+// so a slow reader doesn't hold back the others. The track should be cloned before it
+// is read, typically in OnTrack, as a Read of t in progress while it is cloned still
+// reads from the RTPReceiver directly.
+//
+// Once the RTPReceiver is stopped, or its stream has ended, t and its clones return the
+// error reading it failed with once they have read the packets they buffered.
+func (t *TrackRemote) Clone() *TrackRemote {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fanout := t.fanout
+	if fanout == nil {
+		fanout = newTrackFanout(t)
+		t.fanout = fanout
+		t.fanoutReader = fanout.addReader()
+		go fanout.run()
+	}
+
+	return &TrackRemote{
+		id:                      t.id,
+		streamID:                t.streamID,
+		payloadType:             t.payloadType,
+		kind:                    t.kind,
+		ssrc:                    t.ssrc,
+		rtxSsrc:                 t.rtxSsrc,
+		fecSsrc:                 t.fecSsrc,
+		codec:                   t.codec,
+		params:                  t.params,
+		rid:                     t.rid,
+		receiver:                t.receiver,
+		playoutDelayExtensionID: t.playoutDelayExtensionID,
+		fanout:                  fanout,
+		fanoutReader:            fanout.addReader(),
+		sourceDescription:       t.sourceDescription,
+
+		dependencyDescriptorExtensionID: t.dependencyDescriptorExtensionID,
+	}
+}
+
+type trackFanoutPacket struct {
+	data       []byte
+	attributes interceptor.Attributes
+}
+
+// trackFanout reads the packets of a TrackRemote from its RTPReceiver, and copies
+// them to the readers of the track and of its clones.
+type trackFanout struct {
+	source *TrackRemote
+
+	mu      sync.Mutex
+	readers []*trackFanoutReader
+
+	// err is the error reading the RTPReceiver failed with, set before done is closed
+	err  error
+	done chan struct{}
+}
+
+type trackFanoutReader struct {
+	fanout       *trackFanout
+	packets      chan trackFanoutPacket
+	readDeadline *deadline.Deadline
+}
+
+func newTrackFanout(source *TrackRemote) *trackFanout {
+	return &trackFanout{
+		source: source,
+		done:   make(chan struct{}),
+	}
+}
+
+func (f *trackFanout) addReader() *trackFanoutReader {
+	reader := &trackFanoutReader{
+		fanout:       f,
+		packets:      make(chan trackFanoutPacket, trackRemoteCloneBufferPackets),
+		readDeadline: deadline.New(),
+	}
+
+	f.mu.Lock()
+	f.readers = append(f.readers, reader)
+	f.mu.Unlock()
+
+	return reader
+}
+
+func (f *trackFanout) run() {
+	b := make([]byte, f.source.receiver.api.settingEngine.getReceiveMTU())
+	for {
+		n, attributes, err := f.source.readReceiver(b)
+		if err != nil {
+			if f.retry(err) {
+				continue
+			}
+			f.err = err
+			close(f.done)
+
+			return
+		}
+
+		f.mu.Lock()
+		for _, reader := range f.readers {
+			packet := trackFanoutPacket{data: append([]byte{}, b[:n]...)}
+			if attributes != nil {
+				// Each reader gets its own attributes, which the interceptors may modify
+				packet.attributes = make(interceptor.Attributes, len(attributes))
+				for key, value := range attributes {
+					packet.attributes[key] = value
+				}
+			}
+
+			select {
+			case reader.packets <- packet:
+			default:
+			}
+		}
+		f.mu.Unlock()
+	}
+}
+
+// retry reports whether reading the RTPReceiver should go on after err. Only the end of
+// the stream and the RTPReceiver being closed are terminal, a packet too large is
+// dropped, and a deadline set with RTPReceiver.SetRTPReadDeadline is waited out
+// as the readers of the fanout have their own deadlines.
+func (f *trackFanout) retry(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrClosedPipe) || errors.Is(err, net.ErrClosed) {
+		return false
+	}
+	select {
+	case <-f.source.receiver.closed:
+		return false
+	default:
+	}
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		return true
+	}
+
+	timer := time.NewTimer(trackFanoutRetryInterval)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-f.source.receiver.closed:
+		return false
+	}
+}
+
+// read returns the next packet buffered for the reader, and the error the fanout
+// stopped with once they have all been read.
+func (r *trackFanoutReader) read(b []byte) (int, interceptor.Attributes, error) {
+	select {
+	case packet := <-r.packets:
+		return r.copyPacket(b, packet)
+	case <-r.readDeadline.Done():
+		return 0, nil, os.ErrDeadlineExceeded
+	case <-r.fanout.done:
+	}
+
+	select {
+	case packet := <-r.packets:
+		return r.copyPacket(b, packet)
+	default:
+		return 0, nil, r.fanout.err
+	}
+}
+
+func (r *trackFanoutReader) copyPacket(b []byte, packet trackFanoutPacket) (int, interceptor.Attributes, error) {
+	if len(b) < len(packet.data) {
+		return 0, nil, io.ErrShortBuffer
+	}
+
+	return copy(b, packet.data), packet.attributes, nil
+}
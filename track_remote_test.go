@@ -7,9 +7,12 @@
 package webrtc
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/pion/rtcp"
+	"github.com/pion/transport/v3/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -114,3 +117,217 @@ func TestTrackRemotePullAudioPlayoutStatsNormalizesDefaults(t *testing.T) {
 	assert.Equal(t, string(MediaKindAudio), stats.Kind)
 	assert.NotZero(t, stats.Timestamp)
 }
+
+func TestTrackRemote_Stats(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(track *TrackRemote, receiver *RTPReceiver) {
+		// The Sender Reports are counted as they are read
+		go func() {
+			for {
+				if _, _, readErr := receiver.ReadRTCP(); readErr != nil {
+					return
+				}
+			}
+		}()
+
+		for track.Stats().SenderReportsReceived == 0 {
+			if _, _, readErr := track.ReadRTP(); readErr != nil {
+				return
+			}
+		}
+
+		stats := track.Stats()
+		assert.Equal(t, track.SSRC(), stats.SSRC)
+		assert.NotZero(t, stats.PacketsReceived)
+		assert.NotZero(t, stats.BytesReceived)
+		assert.NotZero(t, stats.HeaderBytesReceived)
+		assert.False(t, stats.LastPacketReceivedTimestamp.IsZero())
+		assert.NotZero(t, stats.FramesReceived)
+		assert.NotZero(t, stats.RemotePacketsSent)
+		assert.False(t, stats.RemoteTimestamp.IsZero())
+		cancel()
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, ctx.Done(), []*TrackLocalStaticSample{track})
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestTrackRemote_Clone(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	const packetCount = 10
+	readSequenceNumbers := func(track *TrackRemote, sequenceNumbers chan<- []uint16) {
+		var read []uint16
+		for len(read) < packetCount {
+			packet, _, readErr := track.ReadRTP()
+			if readErr != nil {
+				break
+			}
+			read = append(read, packet.SequenceNumber)
+		}
+		sequenceNumbers <- read
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	originalRead, cloneRead := make(chan []uint16, 1), make(chan []uint16, 1)
+	closed := make(chan error, 1)
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		clone := track.Clone()
+		assert.Equal(t, track.SSRC(), clone.SSRC())
+		assert.Equal(t, track.ID(), clone.ID())
+
+		go readSequenceNumbers(clone, cloneRead)
+		readSequenceNumbers(track, originalRead)
+		cancel()
+
+		// The clone is closed with the receiver, once it read its buffered packets
+		for {
+			if _, _, readErr := clone.ReadRTP(); readErr != nil {
+				closed <- readErr
+
+				return
+			}
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, ctx.Done(), []*TrackLocalStaticSample{track})
+
+	original := <-originalRead
+	assert.Len(t, original, packetCount)
+	assert.Equal(t, original, <-cloneRead)
+
+	closePairNow(t, pcOffer, pcAnswer)
+	assert.Error(t, <-closed)
+}
+
+func TestTrackRemote_CloneReadDeadline(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	readErr := make(chan error, 1)
+	pcAnswer.OnTrack(func(track *TrackRemote, receiver *RTPReceiver) {
+		clone := track.Clone()
+
+		// An expired deadline of the receiver doesn't stop the fanout
+		assert.NoError(t, receiver.SetRTPReadDeadline(time.Now()))
+		time.Sleep(50 * time.Millisecond)
+		assert.NoError(t, receiver.SetRTPReadDeadline(time.Time{}))
+
+		for i := 0; i < 10; i++ {
+			if _, _, err := clone.ReadRTP(); err != nil {
+				readErr <- err
+
+				return
+			}
+		}
+		readErr <- nil
+		cancel()
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, ctx.Done(), []*TrackLocalStaticSample{track})
+	assert.NoError(t, <-readErr)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestTrackRemote_SourceDescription(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+	pcOffer.SetSourceDescription(SourceDescription{CNAME: "endpoint-1", Name: "alice", Tool: "pion"})
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(track *TrackRemote, receiver *RTPReceiver) {
+		_, ok := track.SourceDescription()
+		assert.False(t, ok)
+
+		// The SDES packets are sent with the Sender Reports, and parsed as they are read
+		go func() {
+			for {
+				if _, _, readErr := receiver.ReadRTCP(); readErr != nil {
+					return
+				}
+			}
+		}()
+
+		for {
+			if description, ok := track.SourceDescription(); ok {
+				assert.Equal(t, SourceDescription{CNAME: "endpoint-1", Name: "alice", Tool: "pion"}, description)
+				cancel()
+
+				return
+			}
+			if _, _, readErr := track.ReadRTP(); readErr != nil {
+				return
+			}
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, ctx.Done(), []*TrackLocalStaticSample{track})
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestSourceDescription_Update(t *testing.T) {
+	description := SourceDescription{CNAME: "cname", Name: "alice"}.update(rtcp.SourceDescriptionChunk{
+		Items: []rtcp.SourceDescriptionItem{
+			{Type: rtcp.SDESCNAME, Text: "cname"},
+			{Type: rtcp.SDESEmail, Text: "alice@example.com"},
+			{Type: sdesMid, Text: "0"},
+		},
+	})
+	assert.Equal(t, SourceDescription{CNAME: "cname", Name: "alice", Email: "alice@example.com"}, description)
+}
@@ -0,0 +1,284 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pion/randutil"
+)
+
+// TrackResumer keeps the identity of the tracks published by clients across their
+// PeerConnections. A client gets a resume token for its session when it first
+// connects, and sends it back in the signaling when it reconnects on a new
+// PeerConnection. The tracks it publishes again are then rebound to the
+// ResumableTracks of its session: their TrackForwarder switches to the new
+// TrackRemote and the OnRebind handlers are called. The TrackResumer doesn't record
+// anything itself, a recording is rebound by the OnRebind handler its application
+// sets, which moves the recording to the new TrackRemote.
+//
+// Within a session a track is identified by its ID and RID, its durable identity,
+// so the client must publish it again with the same track ID. A session ends once
+// its client hasn't published for the session TTL, see SetSessionTTL.
+type TrackResumer struct {
+	mu         sync.Mutex
+	sessions   map[string]*trackResumerSession
+	sessionTTL time.Duration
+}
+
+// trackResumerSession is a session of a TrackResumer, expire ends it once waiting,
+// the number of its tracks whose publisher left, covers all of them.
+type trackResumerSession struct {
+	tracks  map[string]*ResumableTrack
+	waiting int
+	expire  *time.Timer
+}
+
+// NewTrackResumer creates a TrackResumer without sessions.
+func NewTrackResumer() *TrackResumer {
+	return &TrackResumer{
+		sessions:   map[string]*trackResumerSession{},
+		sessionTTL: defaultTrackResumerSessionTTL,
+	}
+}
+
+// SetSessionTTL sets how long a session is kept while its client doesn't publish,
+// before it first publishes or after its publisher left, waiting for the client to
+// resume it. The sessions then end like with EndSession. It applies to the sessions
+// waiting from then on, and defaults to one minute.
+func (r *TrackResumer) SetSessionTTL(ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessionTTL = ttl
+}
+
+// NewSession starts a session and returns its resume token, which the client must
+// keep to resume the session.
+func (r *TrackResumer) NewSession() (string, error) {
+	token, err := randutil.GenerateCryptoRandomString(
+		trackResumeTokenLength, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session := &trackResumerSession{tracks: map[string]*ResumableTrack{}}
+	session.expire = time.AfterFunc(r.sessionTTL, func() {
+		r.expireSession(token, session)
+	})
+	r.sessions[token] = session
+
+	return token, nil
+}
+
+// Bind adds track, received by publisher, to the session of token. It is typically
+// called from the OnTrack handler of publisher. If the session already has a track
+// with the same identity, the track is rebound to its ResumableTrack and true is
+// returned. Otherwise a ResumableTrack is created, forwarding the packets of track
+// until EndSession.
+func (r *TrackResumer) Bind(token string, track *TrackRemote, publisher *PeerConnection) (*ResumableTrack, bool, error) {
+	identity := track.ID() + "/" + track.RID()
+
+	r.mu.Lock()
+	session, ok := r.sessions[token]
+	if !ok {
+		r.mu.Unlock()
+
+		return nil, false, errTrackResumerUnknownSession
+	}
+
+	resumable, resumed := session.tracks[identity]
+	if !resumed {
+		resumable = &ResumableTrack{
+			identity:  identity,
+			resumer:   r,
+			session:   session,
+			forwarder: NewTrackForwarder(track, publisher),
+			rebound:   make(chan struct{}, 1),
+			ended:     make(chan struct{}),
+			done:      make(chan struct{}),
+		}
+		session.tracks[identity] = resumable
+		session.expire.Stop()
+	}
+	r.mu.Unlock()
+
+	if !resumed {
+		go resumable.forward()
+
+		return resumable, false, nil
+	}
+
+	if err := resumable.rebind(track, publisher); err != nil {
+		return nil, false, err
+	}
+
+	return resumable, true, nil
+}
+
+// EndSession forgets the session of token once its client left for good, the
+// forwarding of its ResumableTracks stops.
+func (r *TrackResumer) EndSession(token string) {
+	r.mu.Lock()
+	session := r.sessions[token]
+	delete(r.sessions, token)
+	r.mu.Unlock()
+
+	if session != nil {
+		session.end()
+	}
+}
+
+// expireSession ends session once its TTL elapsed, unless it has been resumed or ended.
+func (r *TrackResumer) expireSession(token string, session *trackResumerSession) {
+	r.mu.Lock()
+	if r.sessions[token] != session || session.waiting != len(session.tracks) {
+		r.mu.Unlock()
+
+		return
+	}
+	delete(r.sessions, token)
+	r.mu.Unlock()
+
+	session.end()
+}
+
+func (s *trackResumerSession) end() {
+	s.expire.Stop()
+	for _, resumable := range s.tracks {
+		resumable.end()
+	}
+}
+
+// setWaiting records whether the publisher of track left, the session expires once
+// the publishers of all its tracks left for the session TTL.
+func (r *TrackResumer) setWaiting(track *ResumableTrack, waiting bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	select {
+	case <-track.ended:
+		return
+	default:
+	}
+
+	session := track.session
+	if track.waiting == waiting {
+		return
+	}
+	track.waiting = waiting
+
+	if !waiting {
+		session.waiting--
+		session.expire.Stop()
+
+		return
+	}
+	if session.waiting++; session.waiting == len(session.tracks) {
+		session.expire.Reset(r.sessionTTL)
+	}
+}
+
+// ResumableTrack is a track of a TrackResumer session, it outlives the
+// PeerConnections of the session.
+type ResumableTrack struct {
+	identity  string
+	forwarder *TrackForwarder
+
+	resumer *TrackResumer
+	session *trackResumerSession
+	// waiting is set while the publisher of the track left, guarded by resumer.mu
+	waiting bool
+
+	mu       sync.Mutex
+	onRebind func(*TrackRemote)
+
+	rebound chan struct{}
+	ended   chan struct{}
+	done    chan struct{}
+}
+
+// Identity returns the durable identity of the track, its ID and RID.
+func (t *ResumableTrack) Identity() string {
+	return t.identity
+}
+
+// Track returns the TrackRemote currently bound.
+func (t *ResumableTrack) Track() *TrackRemote {
+	return t.forwarder.Track()
+}
+
+// Forwarder returns the TrackForwarder of the track, its outputs are the
+// subscriptions which are kept across the reconnections of the publisher.
+// Its forwarding is run by the TrackResumer, Forward must not be called.
+func (t *ResumableTrack) Forwarder() *TrackForwarder {
+	return t.forwarder
+}
+
+// OnRebind sets an event handler which is called with the new TrackRemote when
+// the track is rebound after the client reconnected. The handler must not read
+// the TrackRemote, its packets are read by the TrackForwarder. This is where the
+// application rebinds what follows the track besides the TrackForwarder outputs,
+// its recording for example.
+func (t *ResumableTrack) OnRebind(f func(*TrackRemote)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.onRebind = f
+}
+
+func (t *ResumableTrack) rebind(track *TrackRemote, publisher *PeerConnection) error {
+	if current := t.forwarder.Track(); current.Kind() != track.Kind() {
+		return fmt.Errorf("%w: %s is a %s track", errTrackResumerKindMismatch, t.identity, current.Kind())
+	}
+
+	err := t.forwarder.SetTrack(track, publisher)
+	select {
+	case t.rebound <- struct{}{}:
+	default:
+	}
+
+	t.mu.Lock()
+	handler := t.onRebind
+	t.mu.Unlock()
+	if handler != nil {
+		handler(track)
+	}
+
+	return err
+}
+
+// forward runs the forwarder until the session ends, waiting for a rebind when
+// the track bound stops.
+func (t *ResumableTrack) forward() {
+	defer close(t.done)
+
+	for {
+		_ = t.forwarder.Forward()
+
+		t.resumer.setWaiting(t, true)
+		select {
+		case <-t.rebound:
+			t.resumer.setWaiting(t, false)
+		case <-t.ended:
+			return
+		}
+	}
+}
+
+func (t *ResumableTrack) end() {
+	close(t.ended)
+	// Forward returns as reading the current track fails
+	_ = t.forwarder.Track().SetReadDeadline(time.Now())
+	<-t.done
+}
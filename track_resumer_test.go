@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v3/test"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackForwarderRewriter(t *testing.T) {
+	first := &TrackRemote{kind: RTPCodecTypeVideo, codec: RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeVP8, ClockRate: 90000},
+	}}
+	second := &TrackRemote{kind: RTPCodecTypeVideo, codec: first.codec}
+
+	rewriter := trackForwarderRewriter{}
+	now := time.Now()
+	for i, packet := range []*rtp.Packet{
+		{Header: rtp.Header{SequenceNumber: 100, Timestamp: 3000}},
+		{Header: rtp.Header{SequenceNumber: 101, Timestamp: 6000}},
+	} {
+		rewriter.rewrite(first, packet, now.Add(time.Duration(i)*time.Second/30))
+		assert.Equal(t, uint16(100+i), packet.SequenceNumber)
+		assert.Equal(t, uint32(3000*(i+1)), packet.Timestamp)
+	}
+
+	// The packets of the second track follow the ones of the first
+	packet := &rtp.Packet{Header: rtp.Header{SequenceNumber: 65535, Timestamp: 90000}}
+	rewriter.rewrite(second, packet, now.Add(time.Second/30+time.Second))
+	assert.Equal(t, uint16(102), packet.SequenceNumber)
+	assert.Equal(t, uint32(6000+90000), packet.Timestamp)
+
+	packet = &rtp.Packet{Header: rtp.Header{SequenceNumber: 0, Timestamp: 93000}}
+	rewriter.rewrite(second, packet, now.Add(time.Second/15+time.Second))
+	assert.Equal(t, uint16(103), packet.SequenceNumber)
+	assert.Equal(t, uint32(6000+93000), packet.Timestamp)
+
+	// The NACKs of the subscribers are addressed to the sequence numbers of the second track
+	forwarder := NewTrackForwarder(&TrackRemote{ssrc: 1234}, nil)
+	forwarder.rewriter = trackForwarderRewriter{sequenceNumberOffset: rewriter.getSequenceNumberOffset()}
+	assert.Equal(t, []rtcp.Packet{
+		&rtcp.TransportLayerNack{MediaSSRC: 1234, Nacks: []rtcp.NackPair{{PacketID: 65535, LostPackets: 0b1}}},
+	}, forwarder.relayedRTCP([]rtcp.Packet{
+		&rtcp.TransportLayerNack{MediaSSRC: 5678, Nacks: []rtcp.NackPair{{PacketID: 102, LostPackets: 0b1}}},
+	}))
+}
+
+func TestTrackResumer(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	resumer := NewTrackResumer()
+	token, err := resumer.NewSession()
+	require.NoError(t, err)
+	assert.Len(t, token, trackResumeTokenLength)
+
+	_, _, err = resumer.Bind("unknown", &TrackRemote{}, nil)
+	assert.ErrorIs(t, err, errTrackResumerUnknownSession)
+
+	subOffer, subAnswer, err := newPair()
+	require.NoError(t, err)
+	output, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = subOffer.AddTrack(output)
+	require.NoError(t, err)
+
+	// The subscriber reports the last byte of the payloads, the sample data, it receives
+	received := make(chan byte, 1)
+	subAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		for {
+			packet, _, readErr := track.ReadRTP()
+			if readErr != nil {
+				return
+			}
+			select {
+			case received <- packet.Payload[len(packet.Payload)-1]:
+			default:
+			}
+		}
+	})
+	require.NoError(t, signalPair(subOffer, subAnswer))
+
+	bound := make(chan *ResumableTrack, 1)
+	rebound := make(chan *TrackRemote, 1)
+	publish := func(marker byte) (*PeerConnection, *PeerConnection, chan struct{}) {
+		pubOffer, pubAnswer, pairErr := newPair()
+		require.NoError(t, pairErr)
+
+		published, trackErr := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "camera", "pion")
+		require.NoError(t, trackErr)
+		_, pairErr = pubOffer.AddTrack(published)
+		require.NoError(t, pairErr)
+
+		pubAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+			resumable, resumed, bindErr := resumer.Bind(token, track, pubAnswer)
+			assert.NoError(t, bindErr)
+			assert.Equal(t, "camera/", resumable.Identity())
+			if !resumed {
+				bound <- resumable
+				resumable.Forwarder().AddOutput(output)
+				resumable.OnRebind(func(track *TrackRemote) {
+					rebound <- track
+				})
+			}
+		})
+		require.NoError(t, signalPair(pubOffer, pubAnswer))
+
+		stop := make(chan struct{})
+		go func() {
+			for {
+				select {
+				case <-stop:
+					return
+				case <-time.After(20 * time.Millisecond):
+					_ = published.WriteSample(media.Sample{Data: []byte{marker}, Duration: 20 * time.Millisecond})
+				}
+			}
+		}()
+
+		return pubOffer, pubAnswer, stop
+	}
+
+	untilReceived := func(marker byte) {
+		for b := range received {
+			if b == marker {
+				return
+			}
+		}
+	}
+
+	firstOffer, firstAnswer, firstStop := publish(1)
+	resumable := <-bound
+	untilReceived(1)
+
+	// The client reconnects on a new PeerConnection, the subscriber receives its new track
+	secondOffer, secondAnswer, secondStop := publish(2)
+	assert.Equal(t, <-rebound, resumable.Track())
+	close(firstStop)
+	closePairNow(t, firstOffer, firstAnswer)
+	untilReceived(2)
+
+	resumer.EndSession(token)
+	close(secondStop)
+	closePairNow(t, secondOffer, secondAnswer)
+	closePairNow(t, subOffer, subAnswer)
+}
+
+func TestTrackResumer_SessionTTL(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	resumer := NewTrackResumer()
+	resumer.SetSessionTTL(100 * time.Millisecond)
+	hasSession := func(token string) bool {
+		resumer.mu.Lock()
+		defer resumer.mu.Unlock()
+
+		return resumer.sessions[token] != nil
+	}
+
+	// A session whose client never publishes expires
+	unused, err := resumer.NewSession()
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return !hasSession(unused)
+	}, 5*time.Second, 10*time.Millisecond)
+	_, _, err = resumer.Bind(unused, &TrackRemote{}, nil)
+	assert.ErrorIs(t, err, errTrackResumerUnknownSession)
+
+	token, err := resumer.NewSession()
+	require.NoError(t, err)
+
+	pubOffer, pubAnswer, err := newPair()
+	require.NoError(t, err)
+	published, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "camera", "pion")
+	require.NoError(t, err)
+	_, err = pubOffer.AddTrack(published)
+	require.NoError(t, err)
+
+	bound := make(chan struct{})
+	pubAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		_, _, bindErr := resumer.Bind(token, track, pubAnswer)
+		assert.NoError(t, bindErr)
+		close(bound)
+	})
+	require.NoError(t, signalPair(pubOffer, pubAnswer))
+	sendVideoUntilDone(t, bound, []*TrackLocalStaticSample{published})
+
+	// The session is kept while its client publishes, and expires once it left
+	time.Sleep(300 * time.Millisecond)
+	assert.True(t, hasSession(token))
+	closePairNow(t, pubOffer, pubAnswer)
+	assert.Eventually(t, func() bool {
+		return !hasSession(token)
+	}, 5*time.Second, 10*time.Millisecond)
+}
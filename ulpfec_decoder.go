@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	// ulpFECHeaderSize is the size of the FEC header of an ULPFEC packet, the level 0
+	// header follows it.
+	ulpFECHeaderSize = 10
+	// ulpFECLevelHeaderSize is the size of the level 0 header with the short mask.
+	ulpFECLevelHeaderSize = 4
+	// redBlockHeaderSize is the size of the header of a redundant RED block, the
+	// header of the primary block is a single byte.
+	redBlockHeaderSize = 4
+)
+
+// newULPFECDecoder returns a decoder recovering the lost packets of a stream from
+// the ULPFEC packets sent on it, see RFC 5109, with the payload type
+// ulpFECPayloadType. The packets of the stream are carried in RED, see RFC 2198, if
+// redPayloadType isn't 0, as sent by libwebrtc. Only the level 0 of the packets is
+// used.
+func newULPFECDecoder(redPayloadType, ulpFECPayloadType PayloadType) *fecDecoder {
+	decoder := newFlexFECDecoder(0)
+	decoder.ulpFEC = true
+	decoder.redPayloadType = redPayloadType
+	decoder.ulpFECPayloadType = ulpFECPayloadType
+
+	return decoder
+}
+
+// pushPacket pushes a packet of the stream to the decoder, unwrapping it from RED. It
+// returns the media packet to deliver, nil for the FEC packets and the packets already
+// recovered, and the packets recovered.
+func (d *fecDecoder) pushPacket(packet *rtp.Packet) (*rtp.Packet, []*rtp.Packet) {
+	if d.ulpFEC && d.redPayloadType != 0 && PayloadType(packet.PayloadType) == d.redPayloadType {
+		payloadType, payload, ok := parseREDPrimaryBlock(packet.Payload)
+		if !ok {
+			return nil, nil
+		}
+
+		// The padding belongs to the RED packet, not to the media packet in it
+		unwrapped := &rtp.Packet{Header: packet.Header, Payload: payload}
+		unwrapped.PayloadType = payloadType
+		unwrapped.Padding = false
+		unwrapped.PaddingSize = 0
+		packet = unwrapped
+	}
+
+	if d.ulpFEC && PayloadType(packet.PayloadType) == d.ulpFECPayloadType {
+		return nil, d.pushRepair(packet)
+	}
+
+	recovered, duplicate := d.pushMedia(packet)
+	if duplicate {
+		return nil, recovered
+	}
+
+	return packet, recovered
+}
+
+// parseREDPrimaryBlock returns the payload type and the data of the primary block of
+// a RED payload, the redundant blocks are skipped.
+func parseREDPrimaryBlock(payload []byte) (uint8, []byte, bool) {
+	offset := 0
+	redundantLength := 0
+	for {
+		if len(payload) <= offset {
+			return 0, nil, false
+		}
+
+		// The F bit is set on the headers of the redundant blocks
+		if payload[offset]&0x80 == 0 {
+			payloadType := payload[offset] & 0x7F
+			offset += 1 + redundantLength
+			if len(payload) < offset {
+				return 0, nil, false
+			}
+
+			return payloadType, payload[offset:], true
+		}
+
+		if len(payload) < offset+redBlockHeaderSize {
+			return 0, nil, false
+		}
+		redundantLength += int(binary.BigEndian.Uint16(payload[offset+2:offset+4]) & 0x03FF)
+		offset += redBlockHeaderSize
+	}
+}
+
+// parseULPFECRepair parses the FEC header and the level 0 header of an ULPFEC packet,
+// see section 7 of RFC 5109. The header bits are laid out like the FlexFEC-03 ones,
+// so both are recovered the same way. Packets with the E bit set are ignored.
+func parseULPFECRepair(payload []byte) (*fecRepair, bool) {
+	if len(payload) < ulpFECHeaderSize+ulpFECLevelHeaderSize || payload[0]&0x80 != 0 {
+		return nil, false
+	}
+
+	// The L bit selects the 48 bits mask
+	maskSize := 2
+	if payload[0]&0x40 != 0 {
+		maskSize = 6
+	}
+	offset := ulpFECHeaderSize + 2 + maskSize
+	if len(payload) < offset {
+		return nil, false
+	}
+
+	repair := &fecRepair{}
+	copy(repair.headerBits[0:2], payload[0:2])
+	// The length recovery takes the place of the sequence number
+	copy(repair.headerBits[2:4], payload[8:10])
+	copy(repair.headerBits[4:8], payload[4:8])
+
+	sequenceNumberBase := binary.BigEndian.Uint16(payload[2:4])
+	mask := payload[ulpFECHeaderSize+2 : offset]
+	for i := 0; i < maskSize*8; i++ {
+		if mask[i/8]&(0x80>>(i%8)) != 0 {
+			repair.protected = append(repair.protected, sequenceNumberBase+uint16(i)) //nolint:gosec // G115
+		}
+	}
+
+	protectionLength := int(binary.BigEndian.Uint16(payload[ulpFECHeaderSize : ulpFECHeaderSize+2]))
+	if len(payload) < offset+protectionLength {
+		return nil, false
+	}
+	repair.payload = payload[offset : offset+protectionLength]
+
+	return repair, len(repair.protected) != 0
+}
@@ -0,0 +1,255 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	mock_interceptor "github.com/pion/interceptor/pkg/mock"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ulpFECTestPacket returns the ULPFEC packet protecting media with the level 0 and
+// the short mask, see section 7 of RFC 5109.
+func ulpFECTestPacket(t *testing.T, media []rtp.Packet, sequenceNumber uint16) *rtp.Packet {
+	t.Helper()
+
+	var bits [ulpFECHeaderSize]byte
+	protectionLength := 0
+	raws := make([][]byte, len(media))
+	for i := range media {
+		raw, err := media[i].Marshal()
+		require.NoError(t, err)
+		raws[i] = raw
+
+		bits[0] ^= raw[0]
+		bits[1] ^= raw[1]
+		for j := 4; j < 8; j++ {
+			bits[j] ^= raw[j]
+		}
+		length := uint16(len(raw) - 12) //nolint:gosec // G115
+		bits[8] ^= byte(length >> 8)
+		bits[9] ^= byte(length)
+		if len(raw)-12 > protectionLength {
+			protectionLength = len(raw) - 12
+		}
+	}
+	bits[0] &= 0x3F
+	binary.BigEndian.PutUint16(bits[2:4], media[0].SequenceNumber)
+
+	payload := make([]byte, ulpFECHeaderSize+ulpFECLevelHeaderSize+protectionLength)
+	copy(payload, bits[:])
+	binary.BigEndian.PutUint16(payload[ulpFECHeaderSize:], uint16(protectionLength)) //nolint:gosec // G115
+	var mask uint16
+	for i := range media {
+		mask |= 0x8000 >> (media[i].SequenceNumber - media[0].SequenceNumber)
+	}
+	binary.BigEndian.PutUint16(payload[ulpFECHeaderSize+2:], mask)
+	for _, raw := range raws {
+		for j, b := range raw[12:] {
+			payload[ulpFECHeaderSize+ulpFECLevelHeaderSize+j] ^= b
+		}
+	}
+
+	return &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    117,
+			SequenceNumber: sequenceNumber,
+			Timestamp:      media[len(media)-1].Timestamp,
+			SSRC:           media[0].SSRC,
+		},
+		Payload: payload,
+	}
+}
+
+// redTestPacket wraps packet in a RED packet with a single primary block.
+func redTestPacket(packet *rtp.Packet) *rtp.Packet {
+	red := &rtp.Packet{Header: packet.Header, Payload: append([]byte{packet.PayloadType}, packet.Payload...)}
+	red.PayloadType = 116
+
+	return red
+}
+
+func TestULPFECDecoder(t *testing.T) {
+	for _, sequenceNumber := range []uint16{1000, 65533} {
+		media := flexFECTestPackets(5, sequenceNumber)
+		// The ULPFEC packet follows the media packets it protects
+		fec := ulpFECTestPacket(t, media, sequenceNumber+5)
+
+		decoder := newULPFECDecoder(116, 117)
+		for i := range media {
+			if i == 2 {
+				continue
+			}
+
+			delivered, recovered := decoder.pushPacket(redTestPacket(&media[i]))
+			assert.Empty(t, recovered)
+			require.NotNil(t, delivered)
+			assert.Equal(t, media[i].Header, delivered.Header)
+			assert.Equal(t, media[i].Payload, delivered.Payload)
+		}
+
+		delivered, recovered := decoder.pushPacket(redTestPacket(fec))
+		assert.Nil(t, delivered)
+		require.Len(t, recovered, 1)
+		assert.Equal(t, media[2].Header, recovered[0].Header)
+		assert.Equal(t, media[2].Payload, recovered[0].Payload)
+
+		// The original arriving late is dropped
+		delivered, recovered = decoder.pushPacket(redTestPacket(&media[2]))
+		assert.Nil(t, delivered)
+		assert.Empty(t, recovered)
+	}
+}
+
+func TestULPFECDecoder_WithoutRED(t *testing.T) {
+	media := flexFECTestPackets(3, 1000)
+	fec := ulpFECTestPacket(t, media, 1003)
+
+	decoder := newULPFECDecoder(0, 117)
+	for i := range media[1:] {
+		delivered, recovered := decoder.pushPacket(&media[1+i])
+		assert.Equal(t, &media[1+i], delivered)
+		assert.Empty(t, recovered)
+	}
+
+	delivered, recovered := decoder.pushPacket(fec)
+	assert.Nil(t, delivered)
+	require.Len(t, recovered, 1)
+	assert.Equal(t, media[0].Header, recovered[0].Header)
+	assert.Equal(t, media[0].Payload, recovered[0].Payload)
+
+	// Packets with the E bit set are ignored
+	fec.Payload[0] |= 0x80
+	_, ok := parseULPFECRepair(fec.Payload)
+	assert.False(t, ok)
+}
+
+func TestParseREDPrimaryBlock(t *testing.T) {
+	// A redundant block of 2 bytes with the payload type 96, then the primary block
+	// with the payload type 117
+	payload := []byte{0x80 | 96, 0x00, 0x00, 0x02, 117, 0xAA, 0xBB, 0x01, 0x02, 0x03}
+	payloadType, data, ok := parseREDPrimaryBlock(payload)
+	require.True(t, ok)
+	assert.Equal(t, uint8(117), payloadType)
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, data)
+
+	for _, invalid := range [][]byte{{}, {0x80 | 96, 0x00}, {0x80 | 96, 0x00, 0x00, 0x08, 117}} {
+		_, _, ok = parseREDPrimaryBlock(invalid)
+		assert.False(t, ok)
+	}
+}
+
+func TestPeerConnection_ULPFECRecovery(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newAPI := func(interceptorRegistry *interceptor.Registry) *API {
+		mediaEngine := &MediaEngine{}
+		for _, codec := range []RTPCodecParameters{
+			{RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeVP8, ClockRate: 90000}, PayloadType: 96},
+			{RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeVideoRED, ClockRate: 90000}, PayloadType: 116},
+			{RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeUlpFEC, ClockRate: 90000}, PayloadType: 117},
+		} {
+			require.NoError(t, mediaEngine.RegisterCodec(codec, RTPCodecTypeVideo))
+		}
+
+		return NewAPI(WithMediaEngine(mediaEngine), WithInterceptorRegistry(interceptorRegistry))
+	}
+
+	// Sends the media in RED like libwebrtc, with an ULPFEC packet protecting each
+	// group of 3 packets whose second one is dropped
+	senderRegistry := &interceptor.Registry{}
+	senderRegistry.Add(&mock_interceptor.Factory{
+		NewInterceptorFn: func(string) (interceptor.Interceptor, error) {
+			return &mock_interceptor.Interceptor{
+				BindLocalStreamFn: func(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+					var sequenceNumber uint16
+					var group []rtp.Packet
+					write := func(packet *rtp.Packet, attributes interceptor.Attributes) (int, error) {
+						red := redTestPacket(packet)
+
+						return writer.Write(&red.Header, red.Payload, attributes)
+					}
+
+					return interceptor.RTPWriterFunc(
+						func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+							if header.SSRC != info.SSRC {
+								return writer.Write(header, payload, attributes)
+							}
+
+							packet := rtp.Packet{Header: header.Clone(), Payload: append([]byte{}, payload...)}
+							packet.SequenceNumber = sequenceNumber
+							sequenceNumber++
+							group = append(group, packet)
+							if len(group) == 2 {
+								return len(payload), nil
+							}
+							if _, err := write(&packet, attributes); err != nil || len(group) < 3 {
+								return len(payload), err
+							}
+
+							fec := ulpFECTestPacket(t, group, sequenceNumber)
+							sequenceNumber++
+							group = nil
+							_, err := write(fec, attributes)
+
+							return len(payload), err
+						},
+					)
+				},
+			}, nil
+		},
+	})
+
+	pcOffer, err := newAPI(senderRegistry).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := newAPI(&interceptor.Registry{}).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		assert.True(t, track.HasFEC())
+		assert.Equal(t, MimeTypeVP8, track.Codec().MimeType)
+		for {
+			pkt, attributes, readErr := track.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			assert.Equal(t, uint8(96), pkt.PayloadType)
+			if attributes.Get(AttributeFECRecovered) == true {
+				assert.Equal(t, uint16(1), pkt.SequenceNumber%4)
+				assert.Equal(t, uint32(track.SSRC()), pkt.SSRC)
+				cancel()
+			} else {
+				assert.NotEqual(t, uint16(1), pkt.SequenceNumber%4)
+			}
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, ctx.Done(), []*TrackLocalStaticSample{track})
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -16,7 +16,7 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func createVNetPair(t *testing.T, interceptorRegistry *interceptor.Registry) (
+func createVNetPair(t *testing.T, interceptorRegistry *interceptor.Registry, settings ...func(*SettingEngine)) (
 	*PeerConnection,
 	*PeerConnection,
 	*vnet.Router,
@@ -41,6 +41,9 @@ func createVNetPair(t *testing.T, interceptorRegistry *interceptor.Registry) (
 	offerSettingEngine := SettingEngine{}
 	offerSettingEngine.SetNet(offerVNet)
 	offerSettingEngine.SetICETimeouts(time.Second, time.Second, time.Millisecond*200)
+	for _, setting := range settings {
+		setting(&offerSettingEngine)
+	}
 
 	// Create a network interface for answerer
 	answerVNet, err := vnet.NewNet(&vnet.NetConfig{
@@ -54,6 +57,9 @@ func createVNetPair(t *testing.T, interceptorRegistry *interceptor.Registry) (
 	answerSettingEngine := SettingEngine{}
 	answerSettingEngine.SetNet(answerVNet)
 	answerSettingEngine.SetICETimeouts(time.Second, time.Second, time.Millisecond*200)
+	for _, setting := range settings {
+		setting(&answerSettingEngine)
+	}
 
 	// Start the virtual network by calling Start() on the root router
 	assert.NoError(t, wan.Start())
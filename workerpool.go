@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import "sync"
+
+// workerPool runs the event handlers the PeerConnections of an API fire asynchronously
+// on at most size goroutines, instead of a goroutine per event. Workers are started on
+// demand and exit once the queue is empty, so an idle pool holds no goroutine.
+// A nil *workerPool runs every task on its own goroutine.
+type workerPool struct {
+	mu      sync.Mutex
+	size    int
+	running int
+	queue   []func()
+}
+
+func newWorkerPool(size int) *workerPool {
+	return &workerPool{size: size}
+}
+
+// run queues task, tasks are started in the order they are queued.
+func (p *workerPool) run(task func()) {
+	if p == nil {
+		go task()
+
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.queue = append(p.queue, task)
+	if p.running < p.size {
+		p.running++
+		go p.work()
+	}
+}
+
+func (p *workerPool) work() {
+	for {
+		p.mu.Lock()
+		if len(p.queue) == 0 {
+			p.running--
+			p.mu.Unlock()
+
+			return
+		}
+
+		task := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		p.mu.Unlock()
+
+		task()
+	}
+}
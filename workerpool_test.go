@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPool(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pool := newWorkerPool(2)
+
+	var running, maxRunning atomic.Int32
+	var done sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		done.Add(1)
+		pool.run(func() {
+			defer done.Done()
+
+			current := running.Add(1)
+			for {
+				previous := maxRunning.Load()
+				if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+		})
+	}
+	done.Wait()
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+
+	// A single worker runs the tasks in order
+	pool = newWorkerPool(1)
+	order := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		i := i
+		pool.run(func() {
+			order <- i
+		})
+	}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, i, <-order)
+	}
+
+	var disabled *workerPool
+	ran := make(chan struct{})
+	disabled.run(func() {
+		close(ran)
+	})
+	<-ran
+}
+
+func TestPeerConnection_HandlerWorkers(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetHandlerWorkers(1)
+	api := NewAPI(WithSettingEngine(settingEngine))
+
+	pcOffer, err := api.NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := api.NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	assert.Same(t, api.workerPool, pcOffer.api.workerPool)
+	assert.Same(t, api.workerPool, pcAnswer.api.workerPool)
+
+	// The handlers of both PeerConnections run one at a time on the single worker
+	var running, maxRunning, handled atomic.Int32
+	handler := func() {
+		handled.Add(1)
+		current := running.Add(1)
+		for {
+			previous := maxRunning.Load()
+			if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+	}
+
+	var connected sync.WaitGroup
+	connected.Add(2)
+	for _, pc := range []*PeerConnection{pcOffer, pcAnswer} {
+		var once sync.Once
+		pc.OnSignalingStateChange(func(SignalingState) { handler() })
+		pc.OnConnectionStateChange(func(state PeerConnectionState) {
+			handler()
+			if state == PeerConnectionStateConnected {
+				once.Do(connected.Done)
+			}
+		})
+	}
+
+	_, err = pcOffer.CreateDataChannel("workers", nil)
+	require.NoError(t, err)
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	connected.Wait()
+	assert.Greater(t, handled.Load(), int32(4))
+	assert.Equal(t, int32(1), maxRunning.Load())
+	closePairNow(t, pcOffer, pcAnswer)
+}